@@ -1,8 +1,28 @@
 package main
 
 import (
+	"bytes"
 	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/alert"
+	"github.com/pbv7/pingheat/internal/config"
+	"github.com/pbv7/pingheat/internal/exporter"
+	"github.com/pbv7/pingheat/internal/fleet"
+	"github.com/pbv7/pingheat/internal/locale"
+	"github.com/pbv7/pingheat/internal/log"
+	"github.com/pbv7/pingheat/internal/maintenance"
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/netinfo"
+	"github.com/pbv7/pingheat/internal/ping"
+	"github.com/pbv7/pingheat/internal/prefs"
+	"github.com/pbv7/pingheat/internal/sysmem"
+	"github.com/pbv7/pingheat/internal/ui/colors"
 )
 
 func TestParseArgsMissingTarget(t *testing.T) {
@@ -69,6 +89,30 @@ func TestParseArgsShowVersion(t *testing.T) {
 	}
 }
 
+func TestParseArgsVersionFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantJSON bool
+		wantErr  error
+	}{
+		{name: "text", args: []string{"-version=text"}},
+		{name: "json", args: []string{"-version=json"}, wantJSON: true},
+		{name: "unknown", args: []string{"-version=yaml"}, wantErr: errInvalidVersion},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := parseArgs(tc.args, "pingheat")
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr == nil && (!res.showVersion || res.versionJSON != tc.wantJSON) {
+				t.Fatalf("expected showVersion with JSON %v, got %+v", tc.wantJSON, res)
+			}
+		})
+	}
+}
+
 func TestParseArgsShowHelp(t *testing.T) {
 	res, err := parseArgs([]string{"-help", "example.com"}, "pingheat")
 	if err != nil {
@@ -79,6 +123,763 @@ func TestParseArgsShowHelp(t *testing.T) {
 	}
 }
 
+func TestParseArgsCountAndDuration(t *testing.T) {
+	res, err := parseArgs([]string{"-c", "20", "-duration", "10m", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.Count != 20 {
+		t.Fatalf("expected Count 20, got %d", res.cfg.Count)
+	}
+	if res.cfg.Duration != 10*time.Minute {
+		t.Fatalf("expected Duration 10m, got %v", res.cfg.Duration)
+	}
+
+	if _, err := parseArgs([]string{"-c", "-1", "example.com"}, "pingheat"); !errors.Is(err, errNegativeCount) {
+		t.Fatalf("expected errNegativeCount, got %v", err)
+	}
+	if _, err := parseArgs([]string{"-duration", "-1s", "example.com"}, "pingheat"); !errors.Is(err, errNegativeDuration) {
+		t.Fatalf("expected errNegativeDuration, got %v", err)
+	}
+}
+
+func TestParseArgsTimeout(t *testing.T) {
+	res, err := parseArgs([]string{"-timeout", "500ms", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.Timeout != 500*time.Millisecond {
+		t.Fatalf("expected Timeout 500ms, got %v", res.cfg.Timeout)
+	}
+
+	for _, bad := range []string{"-1s", "10ms", "2m"} {
+		if _, err := parseArgs([]string{"-timeout", bad, "example.com"}, "pingheat"); !errors.Is(err, errInvalidTimeout) {
+			t.Fatalf("-timeout %s: expected errInvalidTimeout, got %v", bad, err)
+		}
+	}
+}
+
+func TestParseArgsBurst(t *testing.T) {
+	res, err := parseArgs([]string{"-burst", "5", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.Burst != 5 {
+		t.Fatalf("expected Burst 5, got %d", res.cfg.Burst)
+	}
+
+	for _, bad := range []string{"0", "-2", "17"} {
+		if _, err := parseArgs([]string{"-burst", bad, "example.com"}, "pingheat"); !errors.Is(err, errInvalidBurst) {
+			t.Fatalf("-burst %s: expected errInvalidBurst, got %v", bad, err)
+		}
+	}
+}
+
+func TestParseArgsFPS(t *testing.T) {
+	res, err := parseArgs([]string{"-fps", "30", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.FPS != 30 {
+		t.Fatalf("expected FPS 30, got %d", res.cfg.FPS)
+	}
+
+	for _, bad := range []string{"0", "-1", "61"} {
+		if _, err := parseArgs([]string{"-fps", bad, "example.com"}, "pingheat"); !errors.Is(err, errInvalidFPS) {
+			t.Fatalf("-fps %s: expected errInvalidFPS, got %v", bad, err)
+		}
+	}
+}
+
+func TestParseArgsDownAfter(t *testing.T) {
+	res, err := parseArgs([]string{"-down-after", "0", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.DownAfter != 0 {
+		t.Fatalf("expected the banner disabled, got DownAfter %d", res.cfg.DownAfter)
+	}
+
+	if _, err := parseArgs([]string{"-down-after", "-1", "example.com"}, "pingheat"); !errors.Is(err, errNegativeDown) {
+		t.Fatalf("-down-after -1: expected errNegativeDown, got %v", err)
+	}
+}
+
+func TestParseArgsBell(t *testing.T) {
+	tests := []struct {
+		args    []string
+		bell    bool
+		command string
+	}{
+		{nil, false, ""},
+		{[]string{"-bell"}, true, ""},
+		{[]string{"-bell=paplay /usr/share/sounds/alert.oga"}, true, "paplay /usr/share/sounds/alert.oga"},
+	}
+	for _, tt := range tests {
+		res, err := parseArgs(append(tt.args, "example.com"), "pingheat")
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", tt.args, err)
+		}
+		if res.cfg.Bell != tt.bell || res.cfg.BellCommand != tt.command {
+			t.Fatalf("%v: got bell %v command %q, want %v %q", tt.args, res.cfg.Bell, res.cfg.BellCommand, tt.bell, tt.command)
+		}
+	}
+
+	if _, err := parseArgs([]string{"-bell-cooldown", "-1s", "example.com"}, "pingheat"); !errors.Is(err, errNegativeCooldown) {
+		t.Fatalf("-bell-cooldown -1s: expected errNegativeCooldown, got %v", err)
+	}
+}
+
+func TestParseArgsScreenshot(t *testing.T) {
+	res, err := parseArgs([]string{"-screenshot-format", "svg", "-screenshot-dir", "/tmp/shots", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.ScreenshotFormat != "svg" || res.cfg.ScreenshotDir != "/tmp/shots" {
+		t.Fatalf("expected svg screenshots in /tmp/shots, got %q in %q", res.cfg.ScreenshotFormat, res.cfg.ScreenshotDir)
+	}
+
+	if _, err := parseArgs([]string{"-screenshot-format", "png", "example.com"}, "pingheat"); !errors.Is(err, errInvalidShotFmt) {
+		t.Fatalf("-screenshot-format png: expected errInvalidShotFmt, got %v", err)
+	}
+}
+
+func TestParseArgsTOS(t *testing.T) {
+	res, err := parseArgs([]string{"-mode", "udp", "-dscp", "EF", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.TOS != 184 || res.cfg.Labels["dscp"] != "ef" {
+		t.Fatalf("expected TOS 184 labeled dscp=ef, got %d with %v", res.cfg.TOS, res.cfg.Labels)
+	}
+
+	res, err = parseArgs([]string{"-tos", "0", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.TOS != 0 || res.cfg.Labels["dscp"] != "be" {
+		t.Fatalf("expected an explicit -tos 0 labeled dscp=be, got %d with %v", res.cfg.TOS, res.cfg.Labels)
+	}
+
+	res, err = parseArgs([]string{"example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := res.cfg.Labels["dscp"]; ok {
+		t.Fatalf("expected no dscp label without -tos or -dscp, got %v", res.cfg.Labels)
+	}
+
+	errTests := []struct {
+		args []string
+		want error
+	}{
+		{[]string{"-tos", "256", "example.com"}, errInvalidTOS},
+		{[]string{"-tos", "184", "-dscp", "EF", "example.com"}, errTOSAndDSCP},
+		{[]string{"-mode", "exec:probe", "-dscp", "EF", "example.com"}, errTOSNeedsProbe},
+	}
+	for _, tt := range errTests {
+		if _, err := parseArgs(tt.args, "pingheat"); !errors.Is(err, tt.want) {
+			t.Fatalf("%v: expected %v, got %v", tt.args, tt.want, err)
+		}
+	}
+	if _, err := parseArgs([]string{"-dscp", "gold", "example.com"}, "pingheat"); err == nil {
+		t.Fatalf("-dscp gold: expected an error")
+	}
+}
+
+func TestParseArgsMode(t *testing.T) {
+	res, err := parseArgs([]string{"-mode", "udp", "-udp-port", "33434", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.Mode != ping.ModeUDP || res.cfg.UDPPort != 33434 {
+		t.Fatalf("expected UDP mode on port 33434, got %q on %d", res.cfg.Mode, res.cfg.UDPPort)
+	}
+
+	if _, err := parseArgs([]string{"-mode", "tcp", "example.com"}, "pingheat"); !errors.Is(err, errInvalidMode) {
+		t.Fatalf("-mode tcp: expected errInvalidMode, got %v", err)
+	}
+	if _, err := parseArgs([]string{"-mode", "exec: ", "example.com"}, "pingheat"); !errors.Is(err, errInvalidMode) {
+		t.Fatalf("-mode exec: without a command: expected errInvalidMode, got %v", err)
+	}
+	if res, err := parseArgs([]string{"-mode", "exec:sdwan-probe --site hq", "example.com"}, "pingheat"); err != nil || res.cfg.Mode != "exec:sdwan-probe --site hq" {
+		t.Fatalf("-mode exec: got %q, %v", res.cfg.Mode, err)
+	}
+	if _, err := parseArgs([]string{"-mode", "udp", "-udp-port", "0", "example.com"}, "pingheat"); !errors.Is(err, errInvalidPort) {
+		t.Fatalf("-udp-port 0: expected errInvalidPort, got %v", err)
+	}
+}
+
+func TestParseArgsDiagnose(t *testing.T) {
+	res, err := parseArgs([]string{"-diagnose"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.Target != diagnoseAnchor || res.cfg.DiagnoseGateway != netinfo.KeywordGateway {
+		t.Fatalf("expected %s against the gateway, got %q against %q", diagnoseAnchor, res.cfg.Target, res.cfg.DiagnoseGateway)
+	}
+
+	res, err = parseArgs([]string{"-diagnose", "9.9.9.9"}, "pingheat")
+	if err != nil || res.cfg.Target != "9.9.9.9" {
+		t.Fatalf("expected the given anchor, got %q (%v)", res.cfg.Target, err)
+	}
+}
+
+func TestParseArgsAdaptiveInterval(t *testing.T) {
+	res, err := parseArgs([]string{"-i", "2s", "-adaptive-interval", "200ms", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.AdaptiveInterval != 200*time.Millisecond {
+		t.Fatalf("expected AdaptiveInterval 200ms, got %v", res.cfg.AdaptiveInterval)
+	}
+
+	for _, bad := range []string{"50ms", "1s", "5s"} {
+		if _, err := parseArgs([]string{"-adaptive-interval", bad, "example.com"}, "pingheat"); !errors.Is(err, errInvalidAdaptive) {
+			t.Fatalf("-adaptive-interval %s: expected errInvalidAdaptive, got %v", bad, err)
+		}
+	}
+}
+
+func TestParseArgsSLO(t *testing.T) {
+	res, err := parseArgs([]string{"-slo", "99.5%<100ms/24h", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := alert.SLO{Objective: 99.5, Threshold: 100 * time.Millisecond, Window: 24 * time.Hour}
+	if res.cfg.SLO == nil || *res.cfg.SLO != want {
+		t.Fatalf("expected SLO %+v, got %+v", want, res.cfg.SLO)
+	}
+
+	if _, err := parseArgs([]string{"-slo", "99.5%", "example.com"}, "pingheat"); !errors.Is(err, alert.ErrInvalidSLO) {
+		t.Fatalf("expected ErrInvalidSLO, got %v", err)
+	}
+}
+
+func TestParseArgsNotify(t *testing.T) {
+	res, err := parseArgs([]string{"-slo", "99%<100ms", "-notify", "slack:https://hooks.slack.com/x", "-notify", "telegram:1:A/2", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.cfg.Notify) != 2 {
+		t.Fatalf("expected 2 notifiers, got %v", res.cfg.Notify)
+	}
+
+	if _, err := parseArgs([]string{"-notify", "slack:https://hooks.slack.com/x", "example.com"}, "pingheat"); !errors.Is(err, errNotifyNeedsSLO) {
+		t.Fatalf("expected errNotifyNeedsSLO, got %v", err)
+	}
+	if _, err := parseArgs([]string{"-slo", "99%<100ms", "-notify", "pager:x", "example.com"}, "pingheat"); !errors.Is(err, alert.ErrInvalidNotifier) {
+		t.Fatalf("expected ErrInvalidNotifier, got %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "alert.tmpl")
+	if err := os.WriteFile(path, []byte("{{.Target}} {{.Alert.Name}}"), 0o600); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+	res, err = parseArgs([]string{"-slo", "99%<100ms", "-notify-template", path, "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.NotifyTemplate != "{{.Target}} {{.Alert.Name}}" {
+		t.Fatalf("unexpected template %q", res.cfg.NotifyTemplate)
+	}
+}
+
+func TestParseArgsProfileEmail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+profiles:
+  wan:
+    target: 1.1.1.1
+    slo: 99.5%<100ms
+    email: {host: smtp.example.com, from: alerts@example.com, to: [oncall@example.com]}
+  noslo:
+    target: 1.1.1.1
+    email: {host: smtp.example.com, from: alerts@example.com, to: [oncall@example.com]}
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := parseArgs([]string{"-config", path, "-profile", "wan"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.SLO == nil || res.cfg.Email == nil || res.cfg.Email.To[0] != "oncall@example.com" {
+		t.Fatalf("expected profile SLO and email, got %+v / %+v", res.cfg.SLO, res.cfg.Email)
+	}
+
+	if _, err := parseArgs([]string{"-config", path, "-profile", "noslo"}, "pingheat"); !errors.Is(err, errNotifyNeedsSLO) {
+		t.Fatalf("expected errNotifyNeedsSLO, got %v", err)
+	}
+}
+
+func TestParseArgsRemoteWrite(t *testing.T) {
+	t.Setenv("PINGHEAT_REMOTE_WRITE_TOKEN", "s3cret")
+	res, err := parseArgs([]string{"-remote-write", "https://prom.example.com/api/v1/write", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.RemoteWriteURL != "https://prom.example.com/api/v1/write" || res.cfg.RemoteWriteToken != "s3cret" {
+		t.Fatalf("unexpected remote write config: %q %q", res.cfg.RemoteWriteURL, res.cfg.RemoteWriteToken)
+	}
+	if res.cfg.ExporterEnabled {
+		t.Fatalf("-remote-write must not enable the scrape endpoint")
+	}
+
+	if _, err := parseArgs([]string{"-remote-write", "prom.example.com", "example.com"}, "pingheat"); !errors.Is(err, exporter.ErrInvalidRemoteWrite) {
+		t.Fatalf("expected ErrInvalidRemoteWrite, got %v", err)
+	}
+}
+
+func TestParseArgsExport(t *testing.T) {
+	args := []string{"-export", "influx:http://localhost:8086/api/v2/write?org=home&bucket=net", "-export", "file:stats.jsonl", "example.com"}
+	res, err := parseArgs(args, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.cfg.Exports) != 2 || res.cfg.Exports[1] != "file:stats.jsonl" {
+		t.Fatalf("Exports = %q", res.cfg.Exports)
+	}
+
+	if _, err := parseArgs([]string{"-export", "graphite:localhost:2003", "example.com"}, "pingheat"); !errors.Is(err, exporter.ErrUnknownBackend) {
+		t.Fatalf("expected ErrUnknownBackend, got %v", err)
+	}
+	if _, err := parseArgs([]string{"-export", "influx:localhost:8086", "example.com"}, "pingheat"); err == nil {
+		t.Fatalf("expected an error for an influx URL without scheme")
+	}
+}
+
+func TestParseArgsFailThresholds(t *testing.T) {
+	res, err := parseArgs([]string{"-c", "10", "-fail-on-loss", "5%", "-fail-on-p95", "100ms", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.FailOnLoss != 5 {
+		t.Fatalf("expected FailOnLoss 5, got %v", res.cfg.FailOnLoss)
+	}
+	if res.cfg.FailOnP95 != 100*time.Millisecond {
+		t.Fatalf("expected FailOnP95 100ms, got %v", res.cfg.FailOnP95)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want error
+	}{
+		{name: "no limit", args: []string{"-fail-on-loss", "1", "example.com"}, want: errFailNeedsLimit},
+		{name: "loss over 100", args: []string{"-c", "5", "-fail-on-loss", "120%", "example.com"}, want: errInvalidFailLoss},
+		{name: "loss not a number", args: []string{"-c", "5", "-fail-on-loss", "lots", "example.com"}, want: errInvalidFailLoss},
+		{name: "zero p95", args: []string{"-c", "5", "-fail-on-p95", "0s", "example.com"}, want: errInvalidFailP95},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := parseArgs(tc.args, "pingheat"); !errors.Is(err, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestParseArgsExporterQuantiles(t *testing.T) {
+	res, err := parseArgs([]string{"-exporter-quantiles", "0.5, 0.999", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.cfg.ExporterQuantiles) != 2 || res.cfg.ExporterQuantiles[1] != 0.999 {
+		t.Fatalf("expected quantiles [0.5 0.999], got %v", res.cfg.ExporterQuantiles)
+	}
+
+	for _, value := range []string{"", "1", "0", "0.5,high", "-0.1"} {
+		if _, err := parseArgs([]string{"-exporter-quantiles", value, "example.com"}, "pingheat"); !errors.Is(err, errInvalidQuantiles) {
+			t.Fatalf("quantiles %q: expected %v, got %v", value, errInvalidQuantiles, err)
+		}
+	}
+}
+
+func TestParseArgsMetricsUnits(t *testing.T) {
+	res, err := parseArgs([]string{"example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.MetricsUnits != exporter.UnitsMilliseconds {
+		t.Fatalf("expected default units %q, got %q", exporter.UnitsMilliseconds, res.cfg.MetricsUnits)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("profiles:\n  wan:\n    metrics-units: seconds\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res, err = parseArgs([]string{"-config", path, "-profile", "wan", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.MetricsUnits != exporter.UnitsSeconds {
+		t.Fatalf("expected profile units %q, got %q", exporter.UnitsSeconds, res.cfg.MetricsUnits)
+	}
+
+	// The flag overrides the profile
+	res, err = parseArgs([]string{"-config", path, "-profile", "wan", "-metrics-units", "ms", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.MetricsUnits != exporter.UnitsMilliseconds {
+		t.Fatalf("expected flag units %q, got %q", exporter.UnitsMilliseconds, res.cfg.MetricsUnits)
+	}
+
+	if _, err := parseArgs([]string{"-metrics-units", "s", "example.com"}, "pingheat"); !errors.Is(err, errInvalidUnits) {
+		t.Fatalf("expected %v, got %v", errInvalidUnits, err)
+	}
+}
+
+func TestParseArgsOutputModes(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "tui", args: []string{"example.com"}, want: ""},
+		{name: "quiet", args: []string{"-quiet", "example.com"}, want: "json"},
+		{name: "csv", args: []string{"-o", "csv", "example.com"}, want: "csv"},
+		{name: "quiet csv", args: []string{"-quiet", "-o", "csv", "example.com"}, want: "csv"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := parseArgs(tc.args, "pingheat")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.cfg.Output != tc.want {
+				t.Fatalf("expected Output %q, got %q", tc.want, res.cfg.Output)
+			}
+		})
+	}
+
+	if _, err := parseArgs([]string{"-o", "xml", "example.com"}, "pingheat"); !errors.Is(err, errInvalidOutput) {
+		t.Fatalf("expected errInvalidOutput, got %v", err)
+	}
+}
+
+func TestApplyTerminalFallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		forceTUI bool
+		terminal bool
+		want     string
+	}{
+		{name: "terminal", terminal: true, want: ""},
+		{name: "no terminal", want: "json"},
+		{name: "forced", forceTUI: true, want: ""},
+		{name: "explicit csv", output: "csv", want: "csv"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Output = tc.output
+			switched := applyTerminalFallback(&cfg, tc.forceTUI, tc.terminal)
+			if cfg.Output != tc.want || switched != (tc.output == "" && tc.want != "") {
+				t.Fatalf("Output=%q switched=%v, want %q", cfg.Output, switched, tc.want)
+			}
+		})
+	}
+
+	res, err := parseArgs([]string{"-force-tui", "example.com"}, "pingheat")
+	if err != nil || !res.forceTUI {
+		t.Fatalf("parseArgs(-force-tui) = %+v, %v; want forceTUI", res.forceTUI, err)
+	}
+	if _, err := parseArgs([]string{"-force-tui", "-quiet", "example.com"}, "pingheat"); !errors.Is(err, errForceTUIOutput) {
+		t.Fatalf("expected errForceTUIOutput, got %v", err)
+	}
+}
+
+func TestParseArgsLogLevel(t *testing.T) {
+	res, err := parseArgs([]string{"-log-level", "debug", "-log-file", "/tmp/pingheat.log", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.LogLevel != slog.LevelDebug || res.cfg.LogFile != "/tmp/pingheat.log" {
+		t.Fatalf("LogLevel=%v LogFile=%q", res.cfg.LogLevel, res.cfg.LogFile)
+	}
+	if _, err := parseArgs([]string{"-log-level", "loud", "example.com"}, "pingheat"); !errors.Is(err, log.ErrInvalidLevel) {
+		t.Fatalf("expected log.ErrInvalidLevel, got %v", err)
+	}
+}
+
+func TestParseArgsDemo(t *testing.T) {
+	res, err := parseArgs([]string{"-demo"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.Demo == nil || *res.cfg.Demo != ping.DefaultDemoSpec() || res.cfg.Target != demoTarget {
+		t.Fatalf("Demo=%+v Target=%q, want defaults for %q", res.cfg.Demo, res.cfg.Target, demoTarget)
+	}
+
+	res, err = parseArgs([]string{"-demo=loss=5%,seed=3", "lab"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.Demo.Loss != 5 || res.cfg.Demo.Seed != 3 || res.cfg.Target != "lab" {
+		t.Fatalf("Demo=%+v Target=%q", res.cfg.Demo, res.cfg.Target)
+	}
+
+	if _, err := parseArgs([]string{"-demo=loss=lots"}, "pingheat"); !errors.Is(err, ping.ErrInvalidDemo) {
+		t.Fatalf("expected ping.ErrInvalidDemo, got %v", err)
+	}
+	if res, _ := parseArgs([]string{"example.com"}, "pingheat"); res.cfg.Demo != nil {
+		t.Fatalf("expected no demo by default")
+	}
+}
+
+func TestParseArgsDemoScenario(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outage.yaml")
+	if err := os.WriteFile(path, []byte("phases:\n  - {name: outage, duration: 1m, loss: 100%}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := parseArgs([]string{"-demo-scenario", path}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.Demo == nil || res.cfg.DemoScenario == nil || len(res.cfg.DemoScenario.Phases) != 1 {
+		t.Fatalf("Demo=%+v DemoScenario=%+v, want the scenario in demo mode", res.cfg.Demo, res.cfg.DemoScenario)
+	}
+
+	if err := os.WriteFile(path, []byte("phases: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseArgs([]string{"-demo-scenario", path}, "pingheat"); !errors.Is(err, ping.ErrInvalidScenario) {
+		t.Fatalf("expected ping.ErrInvalidScenario, got %v", err)
+	}
+}
+
+func TestParseArgsUTC(t *testing.T) {
+	res, err := parseArgs([]string{"-utc", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.cfg.UTC {
+		t.Fatalf("expected UTC true")
+	}
+}
+
+func TestParseArgsFormat(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_NUMERIC", "")
+	t.Setenv("LC_TIME", "")
+	t.Setenv("LANG", "en_US.UTF-8")
+
+	res, err := parseArgs([]string{"example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (locale.Format{Hour12: true, Group: ","}); res.cfg.Format != want {
+		t.Fatalf("expected the en_US format %+v, got %+v", want, res.cfg.Format)
+	}
+
+	res, err = parseArgs([]string{"-clock", "24h", "-decimal", "comma", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (locale.Format{Comma: true, Group: "."}); res.cfg.Format != want {
+		t.Fatalf("expected %+v, got %+v", want, res.cfg.Format)
+	}
+
+	if _, err := parseArgs([]string{"-clock", "ampm", "example.com"}, "pingheat"); !errors.Is(err, errInvalidClock) {
+		t.Fatalf("-clock ampm: expected errInvalidClock, got %v", err)
+	}
+	if _, err := parseArgs([]string{"-decimal", "dot", "example.com"}, "pingheat"); !errors.Is(err, errInvalidDecimal) {
+		t.Fatalf("-decimal dot: expected errInvalidDecimal, got %v", err)
+	}
+}
+
+func TestParseArgsBaseline(t *testing.T) {
+	res, err := parseArgs([]string{"-delta", "-calibrate", "30", "-baseline", "20ms/35ms", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.cfg.Delta || res.cfg.Calibrate != 30 {
+		t.Fatalf("expected delta view calibrating from 30 replies, got %v and %d", res.cfg.Delta, res.cfg.Calibrate)
+	}
+	if want := (metrics.Baseline{P50Ms: 20, P95Ms: 35}); res.cfg.Baseline != want {
+		t.Fatalf("expected baseline %+v, got %+v", want, res.cfg.Baseline)
+	}
+
+	res, err = parseArgs([]string{"-baseline", "1.5ms", "example.com"}, "pingheat")
+	if err != nil || res.cfg.Baseline != (metrics.Baseline{P50Ms: 1.5}) {
+		t.Fatalf("-baseline 1.5ms: expected a p50 of 1.5ms, got %+v, %v", res.cfg.Baseline, err)
+	}
+
+	for _, b := range []string{"fast", "0ms", "20ms/10ms", "20ms/"} {
+		if _, err := parseArgs([]string{"-baseline", b, "example.com"}, "pingheat"); !errors.Is(err, errInvalidBaseline) {
+			t.Fatalf("-baseline %s: expected errInvalidBaseline, got %v", b, err)
+		}
+	}
+	if _, err := parseArgs([]string{"-calibrate", "0", "example.com"}, "pingheat"); !errors.Is(err, errInvalidCalibrate) {
+		t.Fatalf("-calibrate 0: expected errInvalidCalibrate, got %v", err)
+	}
+}
+
+func TestParseArgsASCII(t *testing.T) {
+	res, err := parseArgs([]string{"-ascii", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.cfg.ASCII {
+		t.Fatalf("expected ASCII true")
+	}
+}
+
+func TestParseArgsOrientation(t *testing.T) {
+	res, err := parseArgs([]string{"-orientation", "columns", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.Orientation != "columns" {
+		t.Fatalf("expected Orientation columns, got %q", res.cfg.Orientation)
+	}
+
+	if _, err := parseArgs([]string{"-orientation", "diagonal", "example.com"}, "pingheat"); !errors.Is(err, errInvalidOrient) {
+		t.Fatalf("expected errInvalidOrient, got %v", err)
+	}
+}
+
+func TestParseArgsNoColor(t *testing.T) {
+	res, err := parseArgs([]string{"-no-color", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.cfg.NoColor {
+		t.Fatalf("expected NoColor true")
+	}
+}
+
+func TestParseArgsViewMode(t *testing.T) {
+	res, err := parseArgs([]string{"-view", "braille", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.ViewMode != "braille" {
+		t.Fatalf("expected ViewMode braille, got %q", res.cfg.ViewMode)
+	}
+
+	_, err = parseArgs([]string{"-view", "dots", "example.com"}, "pingheat")
+	if !errors.Is(err, errInvalidViewMode) {
+		t.Fatalf("expected errInvalidViewMode, got %v", err)
+	}
+}
+
+func TestApplyPreferences(t *testing.T) {
+	th := colors.Thresholds{Excellent: 10, Good: 20, Fair: 40, Poor: 80}
+	legend := true
+	p := prefs.Preferences{ViewMode: "braille", Thresholds: &th, Legend: &legend}
+
+	res, err := parseArgs([]string{"example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg := applyPreferences(res.cfg, p, res.flagsSet)
+	if cfg.ViewMode != "braille" || cfg.Thresholds != th || !cfg.ShowLegend {
+		t.Fatalf("expected preferences applied, got view=%q thresholds=%v legend=%v", cfg.ViewMode, cfg.Thresholds, cfg.ShowLegend)
+	}
+
+	res, err = parseArgs([]string{"-view", "blocks", "-legend=false", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg = applyPreferences(res.cfg, p, res.flagsSet)
+	if cfg.ViewMode != "blocks" {
+		t.Fatalf("expected explicit -view to win, got %q", cfg.ViewMode)
+	}
+	if cfg.ShowLegend {
+		t.Fatalf("expected explicit -legend=false to win")
+	}
+}
+
+func TestParseArgsProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+profiles:
+  wan:
+    target: 1.1.1.1
+    interval: 500ms
+    thresholds: {excellent: 20, good: 50, fair: 100, poor: 200}
+    brownout: 300ms
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := parseArgs([]string{"-config", path, "-profile", "wan"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.Target != "1.1.1.1" || res.cfg.Interval != 500*time.Millisecond {
+		t.Fatalf("expected profile target/interval, got %q/%v", res.cfg.Target, res.cfg.Interval)
+	}
+	if res.cfg.Thresholds.Poor != 200 || res.cfg.Brownout != 300*time.Millisecond {
+		t.Fatalf("expected profile thresholds and brownout, got %+v / %v", res.cfg.Thresholds, res.cfg.Brownout)
+	}
+	if res.cfg.ConfigPath != path || res.cfg.Profile != "wan" {
+		t.Fatalf("expected the profile source, got %q / %q", res.cfg.ConfigPath, res.cfg.Profile)
+	}
+
+	// Explicit flags and target override the profile
+	res, err = parseArgs([]string{"-config", path, "-profile", "wan", "-i", "2s", "8.8.8.8"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.Target != "8.8.8.8" || res.cfg.Interval != 2*time.Second {
+		t.Fatalf("expected flag overrides, got %q/%v", res.cfg.Target, res.cfg.Interval)
+	}
+
+	// Saved UI thresholds must not replace profile thresholds
+	th := colors.Thresholds{Excellent: 1, Good: 2, Fair: 3, Poor: 4}
+	cfg := applyPreferences(res.cfg, prefs.Preferences{Thresholds: &th}, res.flagsSet)
+	if cfg.Thresholds.Poor != 200 {
+		t.Fatalf("expected profile thresholds to win over preferences, got %+v", cfg.Thresholds)
+	}
+
+	if _, err := parseArgs([]string{"-config", path, "-profile", "vpn"}, "pingheat"); !errors.Is(err, config.ErrUnknownProfile) {
+		t.Fatalf("expected ErrUnknownProfile, got %v", err)
+	}
+}
+
+func TestParseArgsMaintenance(t *testing.T) {
+	res, err := parseArgs([]string{"-maintenance", "Sun 02:00-04:00", "-maintenance", "0 3 * * * 15m", "1.1.1.1"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.cfg.Maintenance) != 2 || res.cfg.Maintenance[1] != "0 3 * * * 15m" {
+		t.Fatalf("Maintenance = %q", res.cfg.Maintenance)
+	}
+
+	if _, err := parseArgs([]string{"-maintenance", "Sun 02:00", "1.1.1.1"}, "pingheat"); !errors.Is(err, maintenance.ErrInvalidWindow) {
+		t.Fatalf("expected ErrInvalidWindow, got %v", err)
+	}
+
+	// Windows from a profile apply unless given on the command line
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "profiles:\n  isp:\n    target: 1.1.1.1\n    maintenance: [\"Mon 01:00-02:00\"]\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res, err = parseArgs([]string{"-config", path, "-profile", "isp"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.cfg.Maintenance) != 1 || res.cfg.Maintenance[0] != "Mon 01:00-02:00" {
+		t.Fatalf("profile Maintenance = %q", res.cfg.Maintenance)
+	}
+	res, err = parseArgs([]string{"-config", path, "-profile", "isp", "-maintenance", "Tue 01:00-02:00"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.cfg.Maintenance) != 1 || res.cfg.Maintenance[0] != "Tue 01:00-02:00" {
+		t.Fatalf("flag Maintenance = %q, want it to replace the profile", res.cfg.Maintenance)
+	}
+}
+
 func TestParseArgsExporter(t *testing.T) {
 	res, err := parseArgs([]string{"-exporter", ":9090", "example.com"}, "pingheat")
 	if err != nil {
@@ -92,6 +893,80 @@ func TestParseArgsExporter(t *testing.T) {
 	}
 }
 
+func TestParseArgsWeb(t *testing.T) {
+	res, err := parseArgs([]string{"-web", ":8080", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.cfg.WebEnabled || res.cfg.WebAddr != ":8080" {
+		t.Fatalf("expected web UI on :8080, got enabled=%v addr=%q", res.cfg.WebEnabled, res.cfg.WebAddr)
+	}
+
+	if _, err := parseArgs([]string{"-web", ":0", "example.com"}, "pingheat"); !errors.Is(err, errInvalidPort) {
+		t.Fatalf("expected errInvalidPort, got %v", err)
+	}
+}
+
+func TestParseArgsFleet(t *testing.T) {
+	for _, addr := range []string{":9100", "0.0.0.0:9100", "192.0.2.1:9100"} {
+		if _, err := parseArgs([]string{"-aggregate", addr}, "pingheat"); !errors.Is(err, errFleetToken) {
+			t.Fatalf("aggregate on %s without a token: expected errFleetToken, got %v", addr, err)
+		}
+	}
+	for _, addr := range []string{"localhost:9100", "127.0.0.1:9100", "[::1]:9100"} {
+		if _, err := parseArgs([]string{"-aggregate", addr}, "pingheat"); err != nil {
+			t.Fatalf("aggregate on %s without a token: unexpected error: %v", addr, err)
+		}
+	}
+
+	t.Setenv("PINGHEAT_FLEET_TOKEN", "s3cret")
+	res, err := parseArgs([]string{"-aggregate", ":9100"}, "pingheat")
+	if err != nil {
+		t.Fatalf("aggregator without target: unexpected error: %v", err)
+	}
+	if !res.cfg.AggregatorEnabled || res.cfg.AggregatorAddr != ":9100" {
+		t.Fatalf("expected aggregator on :9100, got enabled=%v addr=%q", res.cfg.AggregatorEnabled, res.cfg.AggregatorAddr)
+	}
+	if res.cfg.AggregatorMaxSeries != fleet.DefaultMaxSeries {
+		t.Fatalf("max series = %d, want the default %d", res.cfg.AggregatorMaxSeries, fleet.DefaultMaxSeries)
+	}
+	if _, err := parseArgs([]string{"-aggregate", ":9100", "-aggregate-max-series", "-1"}, "pingheat"); !errors.Is(err, errNegativeSeries) {
+		t.Fatalf("negative max series: expected errNegativeSeries, got %v", err)
+	}
+
+	res, err = parseArgs([]string{"-push", "http://central:9100", "-agent", "probe-1", "1.1.1.1"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.PushURL != "http://central:9100" || res.cfg.AgentName != "probe-1" {
+		t.Fatalf("push config = %q/%q", res.cfg.PushURL, res.cfg.AgentName)
+	}
+
+	for _, bad := range []string{"central:9100", "ftp://central", "http://"} {
+		if _, err := parseArgs([]string{"-push", bad, "1.1.1.1"}, "pingheat"); !errors.Is(err, errInvalidPushURL) {
+			t.Fatalf("-push %q: expected errInvalidPushURL, got %v", bad, err)
+		}
+	}
+}
+
+func TestParseArgsGRPC(t *testing.T) {
+	res, err := parseArgs([]string{"-grpc", ":50051", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.cfg.GRPCEnabled || res.cfg.GRPCAddr != "127.0.0.1:50051" {
+		t.Fatalf("expected gRPC on 127.0.0.1:50051, got enabled=%v addr=%q", res.cfg.GRPCEnabled, res.cfg.GRPCAddr)
+	}
+
+	res, err = parseArgs([]string{"-grpc", "0.0.0.0:50051", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.GRPCAddr != "0.0.0.0:50051" {
+		t.Fatalf("expected explicit host kept, got %q", res.cfg.GRPCAddr)
+	}
+}
+
 func TestParseArgsPprofNormalization(t *testing.T) {
 	res, err := parseArgs([]string{"-pprof", ":6060", "example.com"}, "pingheat")
 	if err != nil {
@@ -325,3 +1200,64 @@ func TestParseArgsPprofPortValidationBeforeNormalization(t *testing.T) {
 		t.Fatalf("expected error for pprof port 0, got nil")
 	}
 }
+
+func TestParseArgsHistory(t *testing.T) {
+	res, err := parseArgs([]string{"-history", "5000", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.cfg.HistorySize != 5000 || res.cfg.HistoryAuto {
+		t.Fatalf("expected 5000 samples, got %d (auto %v)", res.cfg.HistorySize, res.cfg.HistoryAuto)
+	}
+
+	res, err = parseArgs([]string{"-history", "auto", "-history-budget", "8MB", "example.com"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.cfg.HistoryAuto || res.cfg.HistoryBudget != 8<<20 || res.cfg.HistorySize != config.AutoHistory(8<<20, 1) {
+		t.Fatalf("expected auto history within 8MB, got %d samples, budget %d", res.cfg.HistorySize, res.cfg.HistoryBudget)
+	}
+
+	// The aggregator sizes its series from the same flags
+	res, err = parseArgs([]string{"-aggregate", "localhost:9100", "-history", "auto"}, "pingheat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.cfg.HistoryAuto {
+		t.Fatal("expected auto history for the aggregator")
+	}
+
+	if _, err := parseArgs([]string{"-history", "0", "example.com"}, "pingheat"); !errors.Is(err, config.ErrInvalidHistory) {
+		t.Fatalf("expected ErrInvalidHistory, got %v", err)
+	}
+	if _, err := parseArgs([]string{"-history-budget", "lots", "example.com"}, "pingheat"); !errors.Is(err, config.ErrInvalidSize) {
+		t.Fatalf("expected ErrInvalidSize, got %v", err)
+	}
+}
+
+func TestCheckHistoryMemory(t *testing.T) {
+	var logged bytes.Buffer
+	logger := log.New(&logged, slog.LevelInfo, nil)
+	cfg := config.DefaultConfig()
+	available := func() (uint64, error) { return 1 << 30, nil }
+
+	if err := checkHistoryMemory(cfg, available, logger); err != nil || logged.Len() != 0 {
+		t.Fatalf("default history: error %v, log %q", err, logged.String())
+	}
+
+	cfg.HistoryBudget = config.HistoryBytes(cfg.HistorySize, 1) / 2
+	if err := checkHistoryMemory(cfg, available, logger); err != nil || !strings.Contains(logged.String(), "memory budget") {
+		t.Fatalf("over budget: error %v, log %q", err, logged.String())
+	}
+
+	cfg.HistorySize = 100_000_000
+	if err := checkHistoryMemory(cfg, available, logger); !errors.Is(err, config.ErrHistoryTooLarge) {
+		t.Fatalf("expected ErrHistoryTooLarge, got %v", err)
+	}
+
+	// Without a memory reading only the budget applies
+	unknown := func() (uint64, error) { return 0, sysmem.ErrUnsupported }
+	if err := checkHistoryMemory(cfg, unknown, logger); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
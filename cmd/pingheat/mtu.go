@@ -0,0 +1,111 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/config"
+	"github.com/pbv7/pingheat/internal/mtu"
+	"github.com/pbv7/pingheat/internal/ping"
+	"github.com/pbv7/pingheat/internal/ui/colors"
+)
+
+var (
+	errMissingMTUTarget = errors.New("target host required")
+	errInvalidMTURange  = errors.New("mtu sizes must satisfy 68 <= min <= max <= 65535")
+	errInvalidMTUStep   = errors.New("mtu step and tries must be at least 1")
+)
+
+// mtuArgs holds the parsed arguments of the mtu subcommand.
+type mtuArgs struct {
+	target  string
+	opts    mtu.Options
+	minSet  bool // -min given; otherwise it depends on the address family
+	timeout time.Duration
+}
+
+// parseMTUArgs parses the arguments following "pingheat mtu".
+func parseMTUArgs(args []string, program string) (mtuArgs, error) {
+	fs := flag.NewFlagSet(program, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	minSize := fs.Int("min", mtu.DefaultMinIPv4, "Smallest packet size probed, headers included (1280 for IPv6)")
+	maxSize := fs.Int("max", mtu.DefaultMax, "Largest packet size probed, usually the local link MTU")
+	step := fs.Int("step", mtu.DefaultStep, "Size increment of the sweep before the exact MTU is bisected")
+	tries := fs.Int("tries", mtu.DefaultTries, "Probes per size; a size passes with any reply")
+	timeout := fs.Duration("timeout", time.Second, "How long to wait for each reply (0 = 2s)")
+
+	if err := fs.Parse(args); err != nil {
+		return mtuArgs{}, err
+	}
+	if fs.NArg() != 1 {
+		return mtuArgs{}, errMissingMTUTarget
+	}
+	if *minSize < 68 || *minSize > *maxSize || *maxSize > 65535 {
+		return mtuArgs{}, fmt.Errorf("%w: %d-%d", errInvalidMTURange, *minSize, *maxSize)
+	}
+	if *step < 1 || *tries < 1 {
+		return mtuArgs{}, errInvalidMTUStep
+	}
+	if *timeout != 0 && (*timeout < config.MinTimeout || *timeout > config.MaxTimeout) {
+		return mtuArgs{}, errInvalidTimeout
+	}
+
+	minSet := false
+	fs.Visit(func(f *flag.Flag) { minSet = minSet || f.Name == "min" })
+	return mtuArgs{
+		target:  strings.Trim(fs.Arg(0), "[]"),
+		opts:    mtu.Options{Min: *minSize, Max: *maxSize, Step: *step, Tries: *tries},
+		minSet:  minSet,
+		timeout: cmp.Or(*timeout, ping.DefaultTimeout),
+	}, nil
+}
+
+// runMTU finds the path MTU to a target with don't-fragment probes and
+// prints it with a heatmap of the sizes probed.
+func runMTU(args []string, program string, stdout io.Writer) error {
+	ma, err := parseMTUArgs(args, program)
+	if err != nil {
+		if errors.Is(err, errMissingMTUTarget) || errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "Usage: %s [-min bytes] [-max bytes] [-step bytes] [-tries n] [-timeout d] <target>\n", program)
+		}
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Probe one address, so the header size is known
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, ma.target)
+	if err != nil {
+		return err
+	}
+	addr, header := ips[0].String(), mtu.HeaderIPv4
+	if ips[0].IP.To4() == nil {
+		header = mtu.HeaderIPv6
+		if !ma.minSet {
+			ma.opts.Min = max(mtu.DefaultMinIPv6, min(ma.opts.Min, ma.opts.Max))
+		}
+	}
+
+	r, err := mtu.Discover(ctx, mtu.Pinger(addr, header, ma.timeout), ma.opts)
+	if len(r.Sizes) > 0 {
+		target := ma.target
+		if target != addr {
+			target += " (" + addr + ")"
+		}
+		if werr := mtu.Write(stdout, target, header, r, colors.DefaultThresholds()); werr != nil {
+			return werr
+		}
+	}
+	return err
+}
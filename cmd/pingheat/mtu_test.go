@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/mtu"
+)
+
+func TestParseMTUArgs(t *testing.T) {
+	ma, err := parseMTUArgs([]string{"-max", "1492", "-tries", "5", "[::1]"}, "pingheat mtu")
+	if err != nil {
+		t.Fatalf("parseMTUArgs() error = %v", err)
+	}
+	want := mtu.Options{Min: mtu.DefaultMinIPv4, Max: 1492, Step: mtu.DefaultStep, Tries: 5}
+	if ma.target != "::1" || ma.opts != want || ma.minSet || ma.timeout != time.Second {
+		t.Fatalf("parseMTUArgs() = %+v", ma)
+	}
+
+	tests := []struct {
+		args []string
+		want error
+	}{
+		{nil, errMissingMTUTarget},
+		{[]string{"-min", "1500", "-max", "1400", "example.com"}, errInvalidMTURange},
+		{[]string{"-min", "20", "example.com"}, errInvalidMTURange},
+		{[]string{"-step", "0", "example.com"}, errInvalidMTUStep},
+		{[]string{"-timeout", "10ms", "example.com"}, errInvalidTimeout},
+	}
+	for _, tt := range tests {
+		if _, err := parseMTUArgs(tt.args, "pingheat mtu"); !errors.Is(err, tt.want) {
+			t.Fatalf("parseMTUArgs(%v) error = %v, want %v", tt.args, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pbv7/pingheat/internal/locale"
+	"github.com/pbv7/pingheat/internal/report"
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+var (
+	errMissingRecording  = errors.New("recording file required")
+	errInvalidReportType = errors.New("report format must be text, markdown, html or csv")
+)
+
+// reportArgs holds the parsed arguments of the report subcommand.
+type reportArgs struct {
+	format       string
+	output       string
+	inputs       []string
+	numberFormat locale.Format
+}
+
+// parseReportArgs parses the arguments following "pingheat report".
+func parseReportArgs(args []string, program string) (reportArgs, error) {
+	fs := flag.NewFlagSet(program, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	format := fs.String("format", report.FormatText, "Report format: text, markdown, html, or csv for the hour-of-day breakdown")
+	outputPath := fs.String("out", "", "Write the report to this file instead of stdout")
+	clock := fs.String("clock", "", "Clock format of report times: 12h or 24h (default: from the locale)")
+	decimal := fs.String("decimal", "", "Decimal separator of report numbers: point or comma (default: from the locale)")
+
+	if err := fs.Parse(args); err != nil {
+		return reportArgs{}, err
+	}
+	if !report.IsValidFormat(*format) {
+		return reportArgs{}, fmt.Errorf("%w: %q", errInvalidReportType, *format)
+	}
+	numberFormat, err := parseFormat(*clock, *decimal)
+	if err != nil {
+		return reportArgs{}, err
+	}
+	if fs.NArg() == 0 {
+		return reportArgs{}, errMissingRecording
+	}
+
+	return reportArgs{format: *format, output: *outputPath, inputs: fs.Args(), numberFormat: numberFormat}, nil
+}
+
+// runReport loads one or more recordings made with -o json and writes a report.
+func runReport(args []string, program string, stdout io.Writer) error {
+	ra, err := parseReportArgs(args, program)
+	if err != nil {
+		if errors.Is(err, errMissingRecording) || errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "Usage: %s [-format text|markdown|html|csv] [-out file] [-clock 12h|24h] [-decimal point|comma] <session.jsonl>...\n", program)
+		}
+		return err
+	}
+
+	r, err := loadReport(ra.inputs)
+	if err != nil {
+		return err
+	}
+	r.Format = ra.numberFormat
+
+	if ra.output == "" {
+		return report.Write(stdout, r, ra.format)
+	}
+
+	f, err := os.Create(ra.output)
+	if err != nil {
+		return fmt.Errorf("create report: %w", err)
+	}
+	if err := report.Write(f, r, ra.format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadReport reads and analyzes the given recordings as one session.
+func loadReport(paths []string) (report.Report, error) {
+	var all []types.Sample
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return report.Report{}, err
+		}
+		samples, err := report.Load(f)
+		f.Close()
+		if err != nil {
+			return report.Report{}, fmt.Errorf("%s: %w", path, err)
+		}
+		all = append(all, samples...)
+	}
+
+	title := filepath.Base(paths[0])
+	if len(paths) > 1 {
+		title = fmt.Sprintf("%s (+%d more)", title, len(paths)-1)
+	}
+	return report.Build(title, all), nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseReportArgs(t *testing.T) {
+	ra, err := parseReportArgs([]string{"-format", "markdown", "a.jsonl", "b.jsonl"}, "pingheat report")
+	if err != nil {
+		t.Fatalf("parseReportArgs() error = %v", err)
+	}
+	if ra.format != "markdown" || len(ra.inputs) != 2 {
+		t.Fatalf("parseReportArgs() = %+v", ra)
+	}
+
+	if _, err := parseReportArgs(nil, "pingheat report"); !errors.Is(err, errMissingRecording) {
+		t.Fatalf("parseReportArgs(nil) error = %v, want %v", err, errMissingRecording)
+	}
+	if _, err := parseReportArgs([]string{"-format", "pdf", "a.jsonl"}, "pingheat report"); !errors.Is(err, errInvalidReportType) {
+		t.Fatalf("parseReportArgs(pdf) error = %v, want %v", err, errInvalidReportType)
+	}
+}
+
+func TestRunReport(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session.jsonl")
+	data := `{"timestamp":"2024-01-02T10:00:00Z","seq":1,"rtt_ms":12.5,"timeout":false}
+{"timestamp":"2024-01-02T10:00:01Z","seq":2,"rtt_ms":null,"timeout":true}
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("write recording: %v", err)
+	}
+
+	var stdout bytes.Buffer
+	if err := runReport([]string{path}, "pingheat report", &stdout); err != nil {
+		t.Fatalf("runReport() error = %v", err)
+	}
+	if !strings.Contains(stdout.String(), "pingheat report: session.jsonl") {
+		t.Fatalf("unexpected report:\n%s", stdout.String())
+	}
+
+	out := filepath.Join(dir, "report.html")
+	if err := runReport([]string{"-format", "html", "-out", out, path}, "pingheat report", &stdout); err != nil {
+		t.Fatalf("runReport(-out) error = %v", err)
+	}
+	html, err := os.ReadFile(out)
+	if err != nil || !strings.Contains(string(html), "<!DOCTYPE html>") {
+		t.Fatalf("report file = %q, err = %v", html, err)
+	}
+}
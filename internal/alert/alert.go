@@ -0,0 +1,61 @@
+// Package alert evaluates service level objectives and the alerts they
+// raise.
+package alert
+
+import (
+	"fmt"
+	"time"
+)
+
+// Severity tells how urgently an alert needs attention.
+type Severity string
+
+// Alert severities, following the SRE convention of paging for fast burns
+// and filing a ticket for slow ones.
+const (
+	SeverityPage   Severity = "page"
+	SeverityTicket Severity = "ticket"
+)
+
+// Alert is the state of one alerting rule.
+type Alert struct {
+	Name        string
+	Severity    Severity
+	Firing      bool
+	Summary     string        // Human-readable state, set whether firing or not
+	Window      time.Duration // Long window of the rule
+	LossPercent float64       // Loss over Window
+}
+
+// BurnRule fires when the error budget burns faster than Rate over both the
+// Long window and the Short one. The long window avoids paging for blips,
+// the short one lets the alert resolve soon after recovery.
+type BurnRule struct {
+	Name     string
+	Severity Severity
+	Long     time.Duration
+	Short    time.Duration
+	Rate     float64
+}
+
+// DefaultBurnRules are the multi-window burn-rate rules of the Google SRE
+// workbook: 2% of a 30-day budget spent in an hour pages, 5% in six hours
+// files a ticket.
+var DefaultBurnRules = []BurnRule{
+	{Name: "SLOFastBurn", Severity: SeverityPage, Long: time.Hour, Short: 5 * time.Minute, Rate: 14.4},
+	{Name: "SLOSlowBurn", Severity: SeverityTicket, Long: 6 * time.Hour, Short: 30 * time.Minute, Rate: 6},
+}
+
+// evaluate returns the rule's alert for the burn rates of its windows.
+func (r BurnRule) evaluate(long, short BurnRate) Alert {
+	return Alert{
+		Name:     r.Name,
+		Severity: r.Severity,
+		Firing: long.Rate > r.Rate && short.Rate > r.Rate &&
+			short.Samples >= minBurnSamples,
+		Summary: fmt.Sprintf("error budget burn %.1f× over %v, %.1f× over %v (threshold %g×)",
+			long.Rate, long.Window, short.Rate, short.Window, r.Rate),
+		Window:      long.Window,
+		LossPercent: long.LossPercent,
+	}
+}
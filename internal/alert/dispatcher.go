@@ -0,0 +1,181 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/log"
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+const (
+	// heatmapMinutes is the span of the heatmap snippet in messages.
+	heatmapMinutes = 60
+
+	// maxQueuedEvents bounds the events waiting for delivery; further
+	// events are dropped and counted as failures.
+	maxQueuedEvents = 32
+)
+
+// Dispatcher evaluates the SLO after every sample and sends an event to all
+// notifiers whenever an alert starts or stops firing.
+type Dispatcher struct {
+	tracker   *Tracker
+	notifiers []Notifier
+	tmpl      *template.Template
+	events    chan Event
+	failures  atomic.Uint64
+	logger    *slog.Logger
+
+	mu     sync.Mutex
+	target string
+	firing map[string]time.Time // Alert name to when it started firing
+}
+
+// NewDispatcher creates a dispatcher for the alerts of tracker, which the
+// caller keeps feeding with samples of target.
+func NewDispatcher(tracker *Tracker, target string, notifiers []Notifier, tmpl *template.Template) *Dispatcher {
+	return &Dispatcher{
+		tracker:   tracker,
+		notifiers: notifiers,
+		tmpl:      tmpl,
+		events:    make(chan Event, maxQueuedEvents),
+		target:    target,
+		firing:    make(map[string]time.Time),
+		logger:    log.Discard(),
+	}
+}
+
+// SetLogger makes the dispatcher log alert transitions and failed
+// deliveries to l.
+func (d *Dispatcher) SetLogger(l *slog.Logger) {
+	d.logger = l.With("component", "alerts")
+}
+
+// Update evaluates the alerts as of the sample and queues an event for each
+// transition. It never blocks on delivery.
+func (d *Dispatcher) Update(sample types.Sample, _ metrics.Stats) {
+	now := sample.Timestamp
+	status := d.tracker.Status(now)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, a := range status.Alerts {
+		since, wasFiring := d.firing[a.Name]
+		if a.Firing == wasFiring {
+			continue
+		}
+		event := Event{
+			Target:            d.target,
+			SLO:               status.SLO,
+			Alert:             a,
+			Resolved:          !a.Firing,
+			Time:              now,
+			CompliancePercent: status.CompliancePercent,
+			BudgetPercent:     status.BudgetRemaining * 100,
+			Heatmap:           d.tracker.Heatmap(now, heatmapMinutes),
+		}
+		if a.Firing {
+			d.firing[a.Name] = now
+		} else {
+			delete(d.firing, a.Name)
+			event.Duration = now.Sub(since)
+		}
+		d.queue(event)
+	}
+}
+
+// queue renders and queues an event. Callers must hold mu.
+func (d *Dispatcher) queue(event Event) {
+	state := "firing"
+	if event.Resolved {
+		state = "resolved"
+	}
+	d.logger.Info("alert "+state, "alert", event.Alert.Name, "severity", event.Alert.Severity, "target", event.Target)
+
+	text, err := event.render(d.tmpl)
+	if err != nil {
+		d.failures.Add(1)
+		d.logger.Warn("rendering alert message failed", "alert", event.Alert.Name, "error", err)
+		return
+	}
+	event.Text = text
+	d.send(event)
+}
+
+// send queues a rendered event without blocking.
+func (d *Dispatcher) send(event Event) {
+	select {
+	case d.events <- event:
+	default:
+		d.failures.Add(1)
+		d.logger.Warn("alert notification dropped, delivery queue full", "alert", event.Alert.Name)
+	}
+}
+
+// Message sends text to every notifier as is, bypassing the message
+// template. Script hooks use it for their own notifications.
+func (d *Dispatcher) Message(text string) {
+	d.mu.Lock()
+	target := d.target
+	d.mu.Unlock()
+	d.send(Event{
+		Target: target,
+		Alert:  Alert{Name: "script", Firing: true, Summary: text},
+		Time:   time.Now(),
+		Text:   text,
+	})
+}
+
+// SetTarget follows a target switch. Alerts of the old target are dropped
+// without a resolve notification, since its samples stop.
+func (d *Dispatcher) SetTarget(target string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.target = target
+	clear(d.firing)
+}
+
+// Start delivers queued events to every notifier until ctx is cancelled,
+// then delivers the events still queued before returning. Failed deliveries
+// are counted, not retried.
+func (d *Dispatcher) Start(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case event := <-d.events:
+					d.deliver(context.Background(), event)
+				default:
+					return nil
+				}
+			}
+		case event := <-d.events:
+			d.deliver(ctx, event)
+		}
+	}
+}
+
+// deliver sends event to every notifier, each within notifyTimeout.
+func (d *Dispatcher) deliver(ctx context.Context, event Event) {
+	for _, n := range d.notifiers {
+		sendCtx, cancel := context.WithTimeout(ctx, notifyTimeout)
+		if err := n.Notify(sendCtx, event); err != nil {
+			d.failures.Add(1)
+			d.logger.Warn("alert notification failed", "notifier", fmt.Sprintf("%T", n), "alert", event.Alert.Name, "error", err)
+		}
+		cancel()
+	}
+}
+
+// Failures returns how many deliveries failed or were dropped.
+func (d *Dispatcher) Failures() uint64 {
+	return d.failures.Load()
+}
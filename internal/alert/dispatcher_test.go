@@ -0,0 +1,184 @@
+package alert
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+// recordingNotifier collects delivered events.
+type recordingNotifier struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, event Event) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.events = append(n.events, event)
+	return nil
+}
+
+func TestDispatcherTransitions(t *testing.T) {
+	tracker := NewTracker(SLO{Objective: 99.5, Threshold: 100 * time.Millisecond, Window: 24 * time.Hour})
+	tmpl := template.Must(template.New("t").Parse("{{.Alert.Name}} {{.Resolved}} {{.Target}}"))
+	d := NewDispatcher(tracker, "1.1.1.1", nil, tmpl)
+
+	add := func(ts time.Time, timeout bool) {
+		s := types.Sample{Timestamp: ts, RTT: 10 * time.Millisecond, Timeout: timeout}
+		tracker.Add(s)
+		d.Update(s, metrics.Stats{})
+	}
+	ts := base
+	for range 3600 {
+		add(ts, false)
+		ts = ts.Add(time.Second)
+	}
+	for range 600 {
+		add(ts, true)
+		ts = ts.Add(time.Second)
+	}
+	firedAt := ts
+	for range 3600 {
+		add(ts, false)
+		ts = ts.Add(time.Second)
+	}
+
+	var events []Event
+	for len(d.events) > 0 {
+		events = append(events, <-d.events)
+	}
+	var got []string
+	for _, e := range events {
+		got = append(got, e.Text)
+	}
+	// The slow burn's lower threshold is crossed first
+	want := []string{
+		"SLOSlowBurn false 1.1.1.1",
+		"SLOFastBurn false 1.1.1.1",
+		"SLOFastBurn true 1.1.1.1",
+		"SLOSlowBurn true 1.1.1.1",
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("events = %q, want %q", got, want)
+	}
+
+	fired, resolved := events[1], events[2]
+	if fired.Time.After(firedAt) || fired.Alert.LossPercent == 0 || !strings.Contains(fired.Heatmap, "X") {
+		t.Fatalf("unexpected firing event: %+v", fired)
+	}
+	if resolved.Duration <= 0 || resolved.Duration != resolved.Time.Sub(fired.Time) {
+		t.Fatalf("resolved duration = %v, want time since firing", resolved.Duration)
+	}
+}
+
+func TestDispatcherDelivers(t *testing.T) {
+	tracker := NewTracker(SLO{Objective: 99, Threshold: 100 * time.Millisecond, Window: time.Hour})
+	tmpl, err := ParseTemplate("")
+	if err != nil {
+		t.Fatalf("ParseTemplate error: %v", err)
+	}
+	notifier := &recordingNotifier{}
+	d := NewDispatcher(tracker, "1.1.1.1", []Notifier{notifier}, tmpl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = d.Start(ctx)
+		close(done)
+	}()
+
+	for i := range 60 {
+		s := types.Sample{Timestamp: base.Add(time.Duration(i) * time.Second), Timeout: true}
+		tracker.Add(s)
+		d.Update(s, metrics.Stats{})
+	}
+
+	deadline := time.After(2 * time.Second)
+	for {
+		notifier.mu.Lock()
+		n := len(notifier.events)
+		notifier.mu.Unlock()
+		if n == 2 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("expected 2 delivered events, got %d", n)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	cancel()
+	<-done
+
+	if text := notifier.events[0].Text; !strings.HasPrefix(text, "🔥 FIRING SLOFastBurn (page) for 1.1.1.1") {
+		t.Fatalf("unexpected message:\n%s", text)
+	}
+	if d.Failures() != 0 {
+		t.Fatalf("Failures() = %d, want 0", d.Failures())
+	}
+}
+
+func TestDispatcherDeliversQueuedOnStop(t *testing.T) {
+	tracker := NewTracker(SLO{Objective: 99, Threshold: 100 * time.Millisecond, Window: time.Hour})
+	notifier := &recordingNotifier{}
+	d := NewDispatcher(tracker, "1.1.1.1", []Notifier{notifier}, template.Must(template.New("t").Parse("{{.Alert.Name}}")))
+
+	// Queue alerts before the dispatcher runs, then stop it right away
+	for i := range 60 {
+		s := types.Sample{Timestamp: base.Add(time.Duration(i) * time.Second), Timeout: true}
+		tracker.Add(s)
+		d.Update(s, metrics.Stats{})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := d.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	if len(notifier.events) != 2 {
+		t.Fatalf("delivered %d events on stop, want the 2 queued", len(notifier.events))
+	}
+}
+
+func TestDispatcherSetTargetForgetsAlerts(t *testing.T) {
+	tracker := NewTracker(SLO{Objective: 99, Threshold: 100 * time.Millisecond, Window: time.Hour})
+	d := NewDispatcher(tracker, "a", nil, template.Must(template.New("t").Parse("{{.Target}}")))
+	for i := range 60 {
+		s := types.Sample{Timestamp: base.Add(time.Duration(i) * time.Second), Timeout: true}
+		tracker.Add(s)
+		d.Update(s, metrics.Stats{})
+	}
+	for len(d.events) > 0 {
+		<-d.events
+	}
+
+	tracker.Reset()
+	d.SetTarget("b")
+	s := types.Sample{Timestamp: base.Add(time.Minute), RTT: time.Millisecond}
+	tracker.Add(s)
+	d.Update(s, metrics.Stats{})
+	if len(d.events) != 0 {
+		t.Fatalf("expected no resolve events after a target switch, got %d", len(d.events))
+	}
+}
+
+func TestDispatcherMessageBypassesTemplate(t *testing.T) {
+	tracker := NewTracker(SLO{Objective: 99, Threshold: 100 * time.Millisecond, Window: time.Hour})
+	d := NewDispatcher(tracker, "a", nil, template.Must(template.New("t").Parse("{{.Alert.Name}}")))
+	d.Message("latency score 42")
+
+	if len(d.events) != 1 {
+		t.Fatalf("queued %d events, want 1", len(d.events))
+	}
+	event := <-d.events
+	if event.Text != "latency score 42" || event.Target != "a" {
+		t.Fatalf("event = %q for %q, want the raw message for a", event.Text, event.Target)
+	}
+}
@@ -0,0 +1,132 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Incident service endpoints.
+const (
+	pagerDutyEventsAPI = "https://events.pagerduty.com/v2/enqueue"
+	opsgenieAPI        = "https://api.opsgenie.com"
+	opsgenieEUAPI      = "https://api.eu.opsgenie.com"
+)
+
+// incidentDetails returns the structured context attached to incidents.
+func incidentDetails(event Event) map[string]any {
+	return map[string]any{
+		"slo":                event.SLO.String(),
+		"compliance_percent": event.CompliancePercent,
+		"budget_percent":     event.BudgetPercent,
+		"loss_percent":       event.Alert.LossPercent,
+		"window":             event.Alert.Window.String(),
+		"burn":               event.Alert.Summary,
+		"heatmap":            event.Heatmap,
+	}
+}
+
+// PagerDuty triggers and resolves incidents through the PagerDuty Events
+// API v2. Each alert on each target is one incident.
+type PagerDuty struct {
+	url        string
+	routingKey string
+	client     *http.Client
+}
+
+// NewPagerDuty creates a notifier for the service integration with
+// routingKey.
+func NewPagerDuty(routingKey string) *PagerDuty {
+	return &PagerDuty{url: pagerDutyEventsAPI, routingKey: routingKey, client: &http.Client{Timeout: notifyTimeout}}
+}
+
+// pagerDutyEvent is the body of an Events API v2 request.
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"` // trigger or resolve
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string         `json:"summary"`
+	Source        string         `json:"source"`
+	Severity      string         `json:"severity"`
+	Timestamp     string         `json:"timestamp,omitempty"`
+	Component     string         `json:"component"`
+	CustomDetails map[string]any `json:"custom_details"`
+}
+
+// Notify triggers the alert's incident, or resolves it.
+func (p *PagerDuty) Notify(ctx context.Context, event Event) error {
+	body := pagerDutyEvent{RoutingKey: p.routingKey, EventAction: "resolve", DedupKey: event.DedupKey()}
+	if !event.Resolved {
+		severity := "warning"
+		if event.Alert.Severity == SeverityPage {
+			severity = "critical"
+		}
+		body.EventAction = "trigger"
+		body.Payload = &pagerDutyPayload{
+			Summary:       fmt.Sprintf("%s on %s: %s", event.Alert.Name, event.Target, event.Alert.Summary),
+			Source:        event.Target,
+			Severity:      severity,
+			Component:     "pingheat",
+			CustomDetails: incidentDetails(event),
+		}
+		if !event.Time.IsZero() {
+			body.Payload.Timestamp = event.Time.Format(time.RFC3339)
+		}
+	}
+	return postJSON(ctx, p.client, p.url, nil, body)
+}
+
+// Opsgenie creates and closes alerts through the Opsgenie Alerts API. Each
+// alert on each target is one Opsgenie alert, identified by its alias.
+type Opsgenie struct {
+	api    string
+	key    string
+	client *http.Client
+}
+
+// NewOpsgenie creates a notifier for the API integration with key at api,
+// the US or EU instance.
+func NewOpsgenie(api, key string) *Opsgenie {
+	return &Opsgenie{api: api, key: key, client: &http.Client{Timeout: notifyTimeout}}
+}
+
+// Notify creates the alert, or closes it.
+func (o *Opsgenie) Notify(ctx context.Context, event Event) error {
+	header := http.Header{"Authorization": {"GenieKey " + o.key}}
+	if event.Resolved {
+		u := o.api + "/v2/alerts/" + url.PathEscape(event.DedupKey()) + "/close?identifierType=alias"
+		return postJSON(ctx, o.client, u, header, map[string]string{
+			"source": "pingheat",
+			"note":   fmt.Sprintf("Resolved after %v", event.Duration),
+		})
+	}
+
+	priority := "P3"
+	if event.Alert.Severity == SeverityPage {
+		priority = "P1"
+	}
+	details := make(map[string]string)
+	for k, v := range incidentDetails(event) {
+		details[k] = fmt.Sprint(v)
+	}
+	message := []rune(fmt.Sprintf("%s on %s", event.Alert.Name, event.Target))
+	if len(message) > 130 { // Opsgenie's limit
+		message = message[:130]
+	}
+	return postJSON(ctx, o.client, o.api+"/v2/alerts", header, map[string]any{
+		"message":     string(message),
+		"alias":       event.DedupKey(),
+		"description": event.Text,
+		"priority":    priority,
+		"source":      "pingheat",
+		"entity":      event.Target,
+		"tags":        []string{"pingheat", string(event.Alert.Severity)},
+		"details":     details,
+	})
+}
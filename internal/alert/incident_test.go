@@ -0,0 +1,107 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// request is a recorded HTTP request with a decoded JSON body.
+type request struct {
+	path   string
+	header http.Header
+	body   map[string]any
+}
+
+func newRecordingServer(t *testing.T) (*httptest.Server, *[]request) {
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := request{path: r.URL.RequestURI(), header: r.Header}
+		if err := json.NewDecoder(r.Body).Decode(&req.body); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		requests = append(requests, req)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func incidentEvents() (fired, resolved Event) {
+	fired = Event{
+		Target: "1.1.1.1",
+		SLO:    SLO{Objective: 99.5, Threshold: 100 * time.Millisecond, Window: 24 * time.Hour},
+		Alert:  Alert{Name: "SLOFastBurn", Severity: SeverityPage, Firing: true, Summary: "burning", Window: time.Hour, LossPercent: 20},
+		Time:   base,
+		Text:   "FIRING",
+	}
+	resolved = fired
+	resolved.Alert.Firing = false
+	resolved.Resolved = true
+	resolved.Duration = 5 * time.Minute
+	return fired, resolved
+}
+
+func TestPagerDuty(t *testing.T) {
+	server, requests := newRecordingServer(t)
+	pd := NewPagerDuty("R0UT1NG")
+	pd.url = server.URL + "/v2/enqueue"
+
+	fired, resolved := incidentEvents()
+	for _, e := range []Event{fired, resolved} {
+		if err := pd.Notify(context.Background(), e); err != nil {
+			t.Fatalf("Notify error: %v", err)
+		}
+	}
+	if len(*requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(*requests))
+	}
+
+	trigger, resolve := (*requests)[0].body, (*requests)[1].body
+	if trigger["event_action"] != "trigger" || trigger["routing_key"] != "R0UT1NG" || trigger["dedup_key"] != "pingheat/1.1.1.1/SLOFastBurn" {
+		t.Fatalf("unexpected trigger: %v", trigger)
+	}
+	payload := trigger["payload"].(map[string]any)
+	if payload["severity"] != "critical" || payload["source"] != "1.1.1.1" || payload["timestamp"] != "2026-03-01T12:00:00Z" {
+		t.Fatalf("unexpected payload: %v", payload)
+	}
+	if details := payload["custom_details"].(map[string]any); details["loss_percent"] != 20.0 || details["slo"] != "99.5%<100ms/1d" {
+		t.Fatalf("unexpected details: %v", details)
+	}
+	if resolve["event_action"] != "resolve" || resolve["dedup_key"] != trigger["dedup_key"] || resolve["payload"] != nil {
+		t.Fatalf("unexpected resolve: %v", resolve)
+	}
+}
+
+func TestOpsgenie(t *testing.T) {
+	server, requests := newRecordingServer(t)
+	og := NewOpsgenie(server.URL, "key")
+
+	fired, resolved := incidentEvents()
+	fired.Alert.Severity = SeverityTicket
+	for _, e := range []Event{fired, resolved} {
+		if err := og.Notify(context.Background(), e); err != nil {
+			t.Fatalf("Notify error: %v", err)
+		}
+	}
+	if len(*requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(*requests))
+	}
+
+	create, closeReq := (*requests)[0], (*requests)[1]
+	if create.path != "/v2/alerts" || create.header.Get("Authorization") != "GenieKey key" {
+		t.Fatalf("unexpected create request: %s %v", create.path, create.header)
+	}
+	if create.body["alias"] != "pingheat/1.1.1.1/SLOFastBurn" || create.body["priority"] != "P3" || create.body["description"] != "FIRING" {
+		t.Fatalf("unexpected create body: %v", create.body)
+	}
+	if closeReq.path != "/v2/alerts/pingheat%2F1.1.1.1%2FSLOFastBurn/close?identifierType=alias" {
+		t.Fatalf("unexpected close path %q", closeReq.path)
+	}
+	if closeReq.body["note"] != "Resolved after 5m0s" {
+		t.Fatalf("unexpected close body: %v", closeReq.body)
+	}
+}
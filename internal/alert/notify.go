@@ -0,0 +1,125 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// ErrInvalidNotifier is returned for notifier specifications that cannot be
+// used.
+var ErrInvalidNotifier = errors.New("invalid notifier")
+
+// Event is an alert starting or stopping to fire. It is the data of the
+// message template.
+type Event struct {
+	Target            string
+	SLO               SLO
+	Alert             Alert
+	Resolved          bool          // The alert stopped firing
+	Time              time.Time     // When the transition was observed
+	Duration          time.Duration // How long the alert fired; zero until resolved
+	CompliancePercent float64       // Good samples in the SLO window
+	BudgetPercent     float64       // Error budget left, in percent
+	Heatmap           string        // Last hour, one character per minute (see Tracker.Heatmap)
+	Text              string        // Rendered message
+}
+
+// Notifier delivers alert events to an external service.
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// DefaultTemplate is the message sent for alert events unless a custom
+// template is given.
+const DefaultTemplate = `{{if .Resolved}}✅ RESOLVED{{else}}🔥 FIRING{{end}} {{.Alert.Name}} ({{.Alert.Severity}}) for {{.Target}}
+SLO {{.SLO}}: {{printf "%.2f" .CompliancePercent}}% compliant, {{printf "%.0f" .BudgetPercent}}% of the error budget left
+Loss {{printf "%.1f" .Alert.LossPercent}}% over {{.Alert.Window}}{{if .Resolved}}, fired for {{.Duration}}{{end}}
+{{.Alert.Summary}}
+` + "```" + `
+{{.Heatmap}}
+` + "```"
+
+// ParseTemplate parses a message template using the fields of Event. An
+// empty text selects DefaultTemplate.
+func ParseTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultTemplate
+	}
+	tmpl, err := template.New("alert").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("alert template: %w", err)
+	}
+	// Field errors only show up when executing
+	if err := tmpl.Execute(io.Discard, Event{}); err != nil {
+		return nil, fmt.Errorf("alert template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// DedupKey identifies the incident of the event's alert on its target, so
+// incident services can match a resolution to its trigger.
+func (e Event) DedupKey() string {
+	return "pingheat/" + e.Target + "/" + e.Alert.Name
+}
+
+// render executes tmpl for the event.
+func (e Event) render(tmpl *template.Template) (string, error) {
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, e); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// ParseNotifier builds a notifier from a "kind:destination" specification:
+//
+//	slack:https://hooks.slack.com/services/T000/B000/XXXX
+//	discord:https://discord.com/api/webhooks/123/abc
+//	telegram:BOT_TOKEN/CHAT_ID
+//	pagerduty:ROUTING_KEY
+//	opsgenie:API_KEY (opsgenie-eu:API_KEY for the EU instance)
+func ParseNotifier(spec string) (Notifier, error) {
+	kind, dest, _ := strings.Cut(spec, ":")
+	if dest == "" {
+		return nil, fmt.Errorf("%w %q: want KIND:DESTINATION", ErrInvalidNotifier, spec)
+	}
+	switch kind {
+	case "slack":
+		return NewSlack(dest)
+	case "discord":
+		return NewDiscord(dest)
+	case "telegram":
+		token, chat, ok := strings.Cut(dest, "/")
+		if !ok || token == "" || chat == "" {
+			return nil, fmt.Errorf("%w %q: want telegram:BOT_TOKEN/CHAT_ID", ErrInvalidNotifier, spec)
+		}
+		return NewTelegram(token, chat), nil
+	case "pagerduty":
+		return NewPagerDuty(dest), nil
+	case "opsgenie":
+		return NewOpsgenie(opsgenieAPI, dest), nil
+	case "opsgenie-eu":
+		return NewOpsgenie(opsgenieEUAPI, dest), nil
+	default:
+		return nil, fmt.Errorf("%w %q: unknown kind %q (want slack, discord, telegram, pagerduty or opsgenie)", ErrInvalidNotifier, spec, kind)
+	}
+}
+
+// ParseNotifiers builds a notifier for each specification.
+func ParseNotifiers(specs []string) ([]Notifier, error) {
+	notifiers := make([]Notifier, 0, len(specs))
+	for _, spec := range specs {
+		n, err := ParseNotifier(spec)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, n)
+	}
+	return notifiers, nil
+}
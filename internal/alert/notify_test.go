@@ -0,0 +1,176 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseNotifier(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{spec: "slack:https://hooks.slack.com/services/T/B/X", want: "*alert.Slack"},
+		{spec: "discord:https://discord.com/api/webhooks/1/abc", want: "*alert.Discord"},
+		{spec: "telegram:123:ABC/@alerts", want: "*alert.Telegram"},
+		{spec: "pagerduty:R0UT1NG", want: "*alert.PagerDuty"},
+		{spec: "opsgenie:key", want: "*alert.Opsgenie"},
+		{spec: "opsgenie-eu:key", want: "*alert.Opsgenie"},
+	}
+	for _, tt := range tests {
+		n, err := ParseNotifier(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseNotifier(%q) error: %v", tt.spec, err)
+		}
+		if got := typeName(n); got != tt.want {
+			t.Fatalf("ParseNotifier(%q) = %s, want %s", tt.spec, got, tt.want)
+		}
+	}
+
+	for _, spec := range []string{"", "slack", "slack:", "slack:hooks.slack.com/x", "telegram:123:ABC", "email:a@b.c"} {
+		if _, err := ParseNotifier(spec); !errors.Is(err, ErrInvalidNotifier) {
+			t.Fatalf("ParseNotifier(%q): expected ErrInvalidNotifier, got %v", spec, err)
+		}
+	}
+}
+
+func typeName(n Notifier) string {
+	switch n.(type) {
+	case *Slack:
+		return "*alert.Slack"
+	case *Discord:
+		return "*alert.Discord"
+	case *Telegram:
+		return "*alert.Telegram"
+	case *PagerDuty:
+		return "*alert.PagerDuty"
+	case *Opsgenie:
+		return "*alert.Opsgenie"
+	}
+	return "unknown"
+}
+
+func TestParseTemplate(t *testing.T) {
+	if _, err := ParseTemplate(""); err != nil {
+		t.Fatalf("default template error: %v", err)
+	}
+	if _, err := ParseTemplate("{{.Target}} {{.Alert.Name}}"); err != nil {
+		t.Fatalf("custom template error: %v", err)
+	}
+	for _, text := range []string{"{{.Target", "{{.NoSuchField}}"} {
+		if _, err := ParseTemplate(text); err == nil {
+			t.Fatalf("ParseTemplate(%q): expected error", text)
+		}
+	}
+}
+
+func TestDefaultTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate("")
+	if err != nil {
+		t.Fatalf("ParseTemplate error: %v", err)
+	}
+	event := Event{
+		Target:            "1.1.1.1",
+		SLO:               SLO{Objective: 99.5, Threshold: 100 * time.Millisecond, Window: 24 * time.Hour},
+		Alert:             Alert{Name: "SLOFastBurn", Severity: SeverityPage, Window: time.Hour, LossPercent: 12.5},
+		Resolved:          true,
+		Duration:          7 * time.Minute,
+		CompliancePercent: 98.25,
+		BudgetPercent:     -250,
+		Heatmap:           "....xxXX..",
+	}
+	text, err := event.render(tmpl)
+	if err != nil {
+		t.Fatalf("render error: %v", err)
+	}
+	for _, want := range []string{
+		"RESOLVED SLOFastBurn (page) for 1.1.1.1",
+		"SLO 99.5%<100ms/1d: 98.25% compliant, -250% of the error budget left",
+		"Loss 12.5% over 1h0m0s, fired for 7m0s",
+		"```\n....xxXX..\n```",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("message missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestWebhookNotifiers(t *testing.T) {
+	var got map[string]string
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		got = nil
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	slack, _ := NewSlack(server.URL + "/slack")
+	discord, _ := NewDiscord(server.URL + "/discord")
+	telegram := NewTelegram("123:ABC", "@alerts")
+	telegram.api = server.URL
+
+	tests := []struct {
+		notifier Notifier
+		path     string
+		want     map[string]string
+	}{
+		{notifier: slack, path: "/slack", want: map[string]string{"text": "hello"}},
+		{notifier: discord, path: "/discord", want: map[string]string{"content": "hello"}},
+		{notifier: telegram, path: "/bot123:ABC/sendMessage", want: map[string]string{"chat_id": "@alerts", "text": "hello"}},
+	}
+	for _, tt := range tests {
+		if err := tt.notifier.Notify(context.Background(), Event{Text: "hello"}); err != nil {
+			t.Fatalf("Notify to %s error: %v", tt.path, err)
+		}
+		if path != tt.path || len(got) != len(tt.want) {
+			t.Fatalf("posted %v to %s, want %v to %s", got, path, tt.want, tt.path)
+		}
+		for k, v := range tt.want {
+			if got[k] != v {
+				t.Fatalf("posted %v to %s, want %v", got, path, tt.want)
+			}
+		}
+	}
+
+	failing, _ := NewSlack(server.URL + "/fail")
+	if err := failing.Notify(context.Background(), Event{Text: "hello"}); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Fatalf("expected HTTP status error, got %v", err)
+	}
+}
+
+func TestDiscordTruncates(t *testing.T) {
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer server.Close()
+
+	discord, _ := NewDiscord(server.URL)
+	if err := discord.Notify(context.Background(), Event{Text: strings.Repeat("é", 2500)}); err != nil {
+		t.Fatalf("Notify error: %v", err)
+	}
+	if n := len([]rune(got["content"])); n != 2000 {
+		t.Fatalf("content has %d characters, want 2000", n)
+	}
+}
+
+func TestTelegramErrorHidesToken(t *testing.T) {
+	telegram := NewTelegram("123:SECRET", "1")
+	telegram.api = "http://127.0.0.1:1"
+	err := telegram.Notify(context.Background(), Event{Text: "hello"})
+	if err == nil || strings.Contains(err.Error(), "SECRET") {
+		t.Fatalf("expected an error without the token, got %v", err)
+	}
+}
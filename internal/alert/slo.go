@@ -0,0 +1,99 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bounds of the SLO window.
+const (
+	DefaultSLOWindow = 24 * time.Hour
+	MaxSLOWindow     = 30 * 24 * time.Hour
+)
+
+// ErrInvalidSLO is returned for SLOs that cannot be evaluated.
+var ErrInvalidSLO = errors.New("invalid SLO")
+
+// SLO is a latency objective: Objective percent of samples answered within
+// Threshold over a rolling Window. Timeouts never meet it.
+type SLO struct {
+	Objective float64       // Percent of good samples, e.g. 99.5
+	Threshold time.Duration // Slowest RTT that still counts as good
+	Window    time.Duration // Rolling compliance window
+}
+
+// ParseSLO parses an objective such as "99.5%<100ms" or "99.9%<50ms/7d".
+// The window defaults to 24 hours; "d" is accepted as a day unit.
+func ParseSLO(s string) (SLO, error) {
+	spec := strings.ReplaceAll(s, " ", "")
+	slo := SLO{Window: DefaultSLOWindow}
+
+	if objective, window, ok := strings.Cut(spec, "/"); ok {
+		d, err := parseWindow(window)
+		if err != nil {
+			return SLO{}, fmt.Errorf("%w %q: window: %v", ErrInvalidSLO, s, err)
+		}
+		slo.Window = d
+		spec = objective
+	}
+
+	percent, threshold, ok := strings.Cut(spec, "<")
+	if !ok {
+		return SLO{}, fmt.Errorf("%w %q: want PERCENT%%<LATENCY[/WINDOW], e.g. 99.5%%<100ms/24h", ErrInvalidSLO, s)
+	}
+	objective, err := strconv.ParseFloat(strings.TrimSuffix(percent, "%"), 64)
+	if err != nil {
+		return SLO{}, fmt.Errorf("%w %q: objective: %v", ErrInvalidSLO, s, err)
+	}
+	slo.Objective = objective
+	if slo.Threshold, err = time.ParseDuration(threshold); err != nil {
+		return SLO{}, fmt.Errorf("%w %q: threshold: %v", ErrInvalidSLO, s, err)
+	}
+
+	if err := slo.Validate(); err != nil {
+		return SLO{}, err
+	}
+	return slo, nil
+}
+
+// parseWindow parses a duration, also accepting whole days such as "7d".
+func parseWindow(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// Validate checks that the SLO leaves an error budget and fits the tracker.
+func (s SLO) Validate() error {
+	switch {
+	case s.Objective <= 0 || s.Objective >= 100:
+		return fmt.Errorf("%w: objective must be between 0 and 100%%, got %v", ErrInvalidSLO, s.Objective)
+	case s.Threshold <= 0:
+		return fmt.Errorf("%w: latency threshold must be positive", ErrInvalidSLO)
+	case s.Window < time.Hour || s.Window > MaxSLOWindow:
+		return fmt.Errorf("%w: window must be between 1h and 30d, got %v", ErrInvalidSLO, s.Window)
+	}
+	return nil
+}
+
+// ErrorBudget returns the fraction of samples allowed to miss the objective.
+func (s SLO) ErrorBudget() float64 {
+	return 1 - s.Objective/100
+}
+
+// String formats the SLO in the syntax accepted by ParseSLO.
+func (s SLO) String() string {
+	window := s.Window.String()
+	if s.Window%(24*time.Hour) == 0 {
+		window = strconv.Itoa(int(s.Window/(24*time.Hour))) + "d"
+	}
+	return fmt.Sprintf("%s%%<%v/%s", strconv.FormatFloat(s.Objective, 'f', -1, 64), s.Threshold, window)
+}
@@ -0,0 +1,51 @@
+package alert
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseSLO(t *testing.T) {
+	tests := []struct {
+		spec string
+		want SLO
+	}{
+		{spec: "99.5%<100ms", want: SLO{Objective: 99.5, Threshold: 100 * time.Millisecond, Window: 24 * time.Hour}},
+		{spec: "99.9%<50ms/7d", want: SLO{Objective: 99.9, Threshold: 50 * time.Millisecond, Window: 7 * 24 * time.Hour}},
+		{spec: "99% < 1s / 6h", want: SLO{Objective: 99, Threshold: time.Second, Window: 6 * time.Hour}},
+		{spec: "95<200ms/1h", want: SLO{Objective: 95, Threshold: 200 * time.Millisecond, Window: time.Hour}},
+	}
+	for _, tt := range tests {
+		got, err := ParseSLO(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseSLO(%q) error: %v", tt.spec, err)
+		}
+		if got != tt.want {
+			t.Fatalf("ParseSLO(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestParseSLOInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"", "99.5%", "abc%<100ms", "99.5%<fast", "100%<100ms", "0%<100ms",
+		"99%<0ms", "99%<100ms/30m", "99%<100ms/31d", "99%<100ms/xd",
+	} {
+		if _, err := ParseSLO(spec); !errors.Is(err, ErrInvalidSLO) {
+			t.Fatalf("ParseSLO(%q): expected ErrInvalidSLO, got %v", spec, err)
+		}
+	}
+}
+
+func TestSLOStringRoundTrip(t *testing.T) {
+	for _, spec := range []string{"99.5%<100ms/1d", "99.9%<50ms/6h0m0s", "90%<1.5s/7d"} {
+		slo, err := ParseSLO(spec)
+		if err != nil {
+			t.Fatalf("ParseSLO(%q) error: %v", spec, err)
+		}
+		if got := slo.String(); got != spec {
+			t.Fatalf("String() = %q, want %q", got, spec)
+		}
+	}
+}
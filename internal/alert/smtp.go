@@ -0,0 +1,205 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTP security modes.
+const (
+	SMTPStartTLS = "starttls" // Upgrade a plain connection, usually on port 587
+	SMTPTLS      = "tls"      // TLS from the start, usually on port 465
+	SMTPNone     = "none"     // Unencrypted, for local relays only
+)
+
+// SMTPConfig describes a mail server and the recipients of alert emails.
+//
+// Example:
+//
+//	email:
+//	  host: smtp.example.com
+//	  username: alerts@example.com
+//	  password_env: PINGHEAT_SMTP_PASSWORD
+//	  from: pingheat <alerts@example.com>
+//	  to: [oncall@example.com]
+type SMTPConfig struct {
+	Host        string   `yaml:"host"`
+	Port        int      `yaml:"port"`         // Default: 587, 465 with tls: tls, 25 with tls: none
+	TLS         string   `yaml:"tls"`          // starttls (default), tls or none
+	Username    string   `yaml:"username"`     // Empty disables authentication
+	Password    string   `yaml:"password"`     // Prefer password_env to keep secrets out of the file
+	PasswordEnv string   `yaml:"password_env"` // Environment variable holding the password
+	From        string   `yaml:"from"`
+	To          []string `yaml:"to"`
+}
+
+// Validate checks that the configuration can be used to send mail.
+func (c SMTPConfig) Validate() error {
+	switch {
+	case c.Host == "":
+		return fmt.Errorf("%w: email: host is required", ErrInvalidNotifier)
+	case c.Port < 0 || c.Port > 65535:
+		return fmt.Errorf("%w: email: port must be between 1 and 65535", ErrInvalidNotifier)
+	case c.TLS != "" && c.TLS != SMTPStartTLS && c.TLS != SMTPTLS && c.TLS != SMTPNone:
+		return fmt.Errorf("%w: email: tls must be starttls, tls or none, got %q", ErrInvalidNotifier, c.TLS)
+	case len(c.To) == 0:
+		return fmt.Errorf("%w: email: at least one recipient is required", ErrInvalidNotifier)
+	}
+	if _, err := mail.ParseAddress(c.From); err != nil {
+		return fmt.Errorf("%w: email: from: %v", ErrInvalidNotifier, err)
+	}
+	for _, to := range c.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return fmt.Errorf("%w: email: to %q: %v", ErrInvalidNotifier, to, err)
+		}
+	}
+	return nil
+}
+
+// SMTP sends alert events by email. The first line of the message becomes
+// the subject.
+type SMTP struct {
+	config   SMTPConfig
+	addr     string
+	password string
+	from     *mail.Address
+	to       []*mail.Address
+}
+
+// NewSMTP creates an email notifier. The password is read from the
+// environment now, so a missing variable is reported at startup.
+func NewSMTP(c SMTPConfig) (*SMTP, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	if c.TLS == "" {
+		c.TLS = SMTPStartTLS
+	}
+	if c.Port == 0 {
+		c.Port = map[string]int{SMTPStartTLS: 587, SMTPTLS: 465, SMTPNone: 25}[c.TLS]
+	}
+
+	password := c.Password
+	if c.PasswordEnv != "" {
+		var ok bool
+		if password, ok = os.LookupEnv(c.PasswordEnv); !ok {
+			return nil, fmt.Errorf("%w: email: $%s is not set", ErrInvalidNotifier, c.PasswordEnv)
+		}
+	}
+
+	s := &SMTP{config: c, addr: net.JoinHostPort(c.Host, strconv.Itoa(c.Port)), password: password}
+	s.from, _ = mail.ParseAddress(c.From)
+	for _, to := range c.To {
+		addr, _ := mail.ParseAddress(to)
+		s.to = append(s.to, addr)
+	}
+	return s, nil
+}
+
+// Notify sends the event's message to every recipient.
+func (s *SMTP) Notify(ctx context.Context, event Event) error {
+	client, err := s.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("smtp %s: %w", s.addr, err)
+	}
+	defer func() { _ = client.Close() }()
+
+	if err := s.send(client, s.message(event)); err != nil {
+		return fmt.Errorf("smtp %s: %w", s.addr, err)
+	}
+	return client.Quit()
+}
+
+// dial connects to the server and secures the connection as configured.
+func (s *SMTP) dial(ctx context.Context) (*smtp.Client, error) {
+	tlsConfig := &tls.Config{ServerName: s.config.Host, MinVersion: tls.VersionTLS12}
+
+	var conn net.Conn
+	var err error
+	if s.config.TLS == SMTPTLS {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", s.addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", s.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if s.config.TLS == SMTPStartTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			_ = client.Close()
+			return nil, fmt.Errorf("starttls: %w", err)
+		}
+	}
+	return client, nil
+}
+
+// send authenticates if configured and transfers one message.
+func (s *SMTP) send(client *smtp.Client, msg []byte) error {
+	if s.config.Username != "" {
+		// PlainAuth refuses to send the password over unencrypted
+		// connections to anything but localhost
+		if err := client.Auth(smtp.PlainAuth("", s.config.Username, s.password, s.config.Host)); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+	if err := client.Mail(s.from.Address); err != nil {
+		return err
+	}
+	for _, to := range s.to {
+		if err := client.Rcpt(to.Address); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// message formats the event as a plain-text email.
+func (s *SMTP) message(event Event) []byte {
+	subject, _, _ := strings.Cut(event.Text, "\n")
+	to := make([]string, len(s.to))
+	for i, addr := range s.to {
+		to[i] = addr.String()
+	}
+	date := event.Time
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", s.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "[pingheat] "+subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(event.Text, "\r\n", "\n"), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
@@ -0,0 +1,183 @@
+package alert
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTP accepts one session and records the commands and message.
+type fakeSMTP struct {
+	ln       net.Listener
+	commands []string
+	data     string
+	done     chan struct{}
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	f := &fakeSMTP{ln: ln, done: make(chan struct{})}
+	t.Cleanup(func() { _ = ln.Close() })
+	go f.serve()
+	return f
+}
+
+func (f *fakeSMTP) port() int {
+	return f.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (f *fakeSMTP) serve() {
+	defer close(f.done)
+	conn, err := f.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer func() { _ = conn.Close() }()
+	r := bufio.NewReader(conn)
+	reply := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		f.commands = append(f.commands, cmd)
+		switch verb, _, _ := strings.Cut(cmd, " "); strings.ToUpper(verb) {
+		case "EHLO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			reply("235 ok")
+		case "MAIL", "RCPT":
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var b strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil || l == ".\r\n" {
+					break
+				}
+				b.WriteString(l)
+			}
+			f.data = b.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 unknown")
+		}
+	}
+}
+
+func TestSMTPNotify(t *testing.T) {
+	server := newFakeSMTP(t)
+	t.Setenv("TEST_SMTP_PASSWORD", "secret")
+	s, err := NewSMTP(SMTPConfig{
+		Host:        "127.0.0.1",
+		Port:        server.port(),
+		TLS:         SMTPNone,
+		Username:    "alerts",
+		PasswordEnv: "TEST_SMTP_PASSWORD",
+		From:        "pingheat <alerts@example.com>",
+		To:          []string{"oncall@example.com", "Net Team <net@example.com>"},
+	})
+	if err != nil {
+		t.Fatalf("NewSMTP error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	event := Event{Text: "🔥 FIRING SLOFastBurn (page) for 1.1.1.1\nLoss 12.5%", Time: base}
+	if err := s.Notify(ctx, event); err != nil {
+		t.Fatalf("Notify error: %v", err)
+	}
+	<-server.done
+
+	auth := base64.StdEncoding.EncodeToString([]byte("\x00alerts\x00secret"))
+	for _, want := range []string{
+		"AUTH PLAIN " + auth,
+		"MAIL FROM:<alerts@example.com>",
+		"RCPT TO:<oncall@example.com>",
+		"RCPT TO:<net@example.com>",
+	} {
+		if !containsLine(server.commands, want) {
+			t.Fatalf("commands %q missing %q", server.commands, want)
+		}
+	}
+	for _, want := range []string{
+		"From: \"pingheat\" <alerts@example.com>\r\n",
+		"To: <oncall@example.com>, \"Net Team\" <net@example.com>\r\n",
+		"Subject: =?utf-8?q?[pingheat]_=F0=9F=94=A5_FIRING_SLOFastBurn_(page)_for_1.1.1.1?=\r\n",
+		"Date: Sun, 01 Mar 2026 12:00:00 +0000\r\n",
+		"\r\n\r\n🔥 FIRING SLOFastBurn (page) for 1.1.1.1\r\nLoss 12.5%\r\n",
+	} {
+		if !strings.Contains(server.data, want) {
+			t.Fatalf("message missing %q:\n%s", want, server.data)
+		}
+	}
+}
+
+func containsLine(lines []string, want string) bool {
+	for _, l := range lines {
+		if l == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSMTPDefaults(t *testing.T) {
+	tests := []struct {
+		tls  string
+		port int
+	}{
+		{tls: "", port: 587},
+		{tls: SMTPTLS, port: 465},
+		{tls: SMTPNone, port: 25},
+	}
+	for _, tt := range tests {
+		s, err := NewSMTP(SMTPConfig{Host: "mail.example.com", TLS: tt.tls, From: "a@example.com", To: []string{"b@example.com"}})
+		if err != nil {
+			t.Fatalf("NewSMTP(tls=%q) error: %v", tt.tls, err)
+		}
+		if want := "mail.example.com:" + strconv.Itoa(tt.port); s.addr != want {
+			t.Fatalf("tls=%q: addr = %s, want %s", tt.tls, s.addr, want)
+		}
+	}
+}
+
+func TestSMTPConfigInvalid(t *testing.T) {
+	valid := SMTPConfig{Host: "mail.example.com", From: "a@example.com", To: []string{"b@example.com"}}
+	tests := []struct {
+		name   string
+		change func(c *SMTPConfig)
+	}{
+		{name: "no host", change: func(c *SMTPConfig) { c.Host = "" }},
+		{name: "bad port", change: func(c *SMTPConfig) { c.Port = 70000 }},
+		{name: "bad tls", change: func(c *SMTPConfig) { c.TLS = "ssl" }},
+		{name: "no recipients", change: func(c *SMTPConfig) { c.To = nil }},
+		{name: "bad from", change: func(c *SMTPConfig) { c.From = "not an address" }},
+		{name: "bad to", change: func(c *SMTPConfig) { c.To = []string{"nobody"} }},
+		{name: "unset password variable", change: func(c *SMTPConfig) { c.PasswordEnv = "PINGHEAT_TEST_UNSET_VARIABLE" }},
+	}
+	for _, tt := range tests {
+		c := valid
+		tt.change(&c)
+		if _, err := NewSMTP(c); !errors.Is(err, ErrInvalidNotifier) {
+			t.Fatalf("%s: expected ErrInvalidNotifier, got %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package alert
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+// minBurnSamples is the number of samples a burn-rate window needs before
+// its rule may fire, so a lost first sample does not page.
+const minBurnSamples = 10
+
+// minuteSlot counts the samples of one minute.
+type minuteSlot struct {
+	minute   int64 // Unix minute; slots from an earlier lap of the ring are stale
+	total    int
+	bad      int
+	timeouts int
+}
+
+// BurnRate is how fast the error budget is being spent over a window:
+// 1 spends exactly the budget over the SLO window, 10 ten times faster.
+type BurnRate struct {
+	Window      time.Duration
+	Rate        float64
+	Samples     int
+	LossPercent float64
+}
+
+// Status is the state of an SLO at a point in time.
+type Status struct {
+	SLO               SLO
+	Samples           int     // Samples in the SLO window
+	Bad               int     // Samples that missed the objective
+	CompliancePercent float64 // Good samples in the window (100 without samples)
+	BudgetRemaining   float64 // Fraction of the error budget left; negative once exhausted
+	BurnRates         []BurnRate
+	Alerts            []Alert // One per rule, firing or not
+}
+
+// Firing returns the alerts that are firing.
+func (s Status) Firing() []Alert {
+	var firing []Alert
+	for _, a := range s.Alerts {
+		if a.Firing {
+			firing = append(firing, a)
+		}
+	}
+	return firing
+}
+
+// Tracker counts good and bad samples per minute over the SLO window and
+// evaluates burn-rate rules against them.
+type Tracker struct {
+	slo   SLO
+	rules []BurnRule
+
+	mu    sync.RWMutex
+	slots []minuteSlot // Ring indexed by Unix minute
+}
+
+// NewTracker creates a tracker for slo evaluating DefaultBurnRules.
+func NewTracker(slo SLO) *Tracker {
+	return &Tracker{
+		slo:   slo,
+		rules: DefaultBurnRules,
+		slots: make([]minuteSlot, int(slo.Window/time.Minute)),
+	}
+}
+
+// SLO returns the tracked objective.
+func (t *Tracker) SLO() SLO {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.slo
+}
+
+// SetSLO switches to tracking slo. A new objective keeps the samples, while
+// a new latency threshold or window clears them, as they were counted
+// against the old one.
+func (t *Tracker) SetSLO(slo SLO) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if slo.Threshold != t.slo.Threshold || slo.Window != t.slo.Window {
+		t.slots = make([]minuteSlot, int(slo.Window/time.Minute))
+	}
+	t.slo = slo
+}
+
+// Add records a sample. Samples taken during maintenance do not count.
+func (t *Tracker) Add(sample types.Sample) {
+	if sample.Maintenance {
+		return
+	}
+	minute := sample.Timestamp.Unix() / 60
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	slot := &t.slots[minute%int64(len(t.slots))]
+	if slot.minute != minute {
+		if slot.minute > minute {
+			return // Older than the window
+		}
+		*slot = minuteSlot{minute: minute}
+	}
+	slot.total++
+	if sample.Timeout {
+		slot.timeouts++
+	}
+	if sample.Timeout || sample.RTT > t.slo.Threshold {
+		slot.bad++
+	}
+}
+
+// Reset clears all samples.
+func (t *Tracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	clear(t.slots)
+}
+
+// Status evaluates the SLO and its alerting rules at now.
+func (t *Tracker) Status(now time.Time) Status {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	s := Status{SLO: t.slo, CompliancePercent: 100, BudgetRemaining: 1}
+	s.Samples, s.Bad, _ = t.count(now, t.slo.Window)
+	if s.Samples > 0 {
+		s.CompliancePercent = float64(s.Samples-s.Bad) / float64(s.Samples) * 100
+		s.BudgetRemaining = 1 - float64(s.Bad)/(float64(s.Samples)*t.slo.ErrorBudget())
+	}
+
+	rates := make(map[time.Duration]BurnRate)
+	burn := func(window time.Duration) BurnRate {
+		if r, ok := rates[window]; ok {
+			return r
+		}
+		r := BurnRate{Window: window}
+		total, bad, timeouts := t.count(now, min(window, t.slo.Window))
+		if total > 0 {
+			r.Rate = float64(bad) / float64(total) / t.slo.ErrorBudget()
+			r.Samples = total
+			r.LossPercent = float64(timeouts) / float64(total) * 100
+		}
+		rates[window] = r
+		s.BurnRates = append(s.BurnRates, r)
+		return r
+	}
+	for _, rule := range t.rules {
+		s.Alerts = append(s.Alerts, rule.evaluate(burn(rule.Long), burn(rule.Short)))
+	}
+	return s
+}
+
+// count returns the samples, bad samples and timeouts of the window ending
+// at now. Callers must hold mu.
+func (t *Tracker) count(now time.Time, window time.Duration) (total, bad, timeouts int) {
+	last := now.Unix() / 60
+	first := last - int64(window/time.Minute) + 1
+	for _, slot := range t.slots {
+		if slot.minute >= first && slot.minute <= last {
+			total += slot.total
+			bad += slot.bad
+			timeouts += slot.timeouts
+		}
+	}
+	return total, bad, timeouts
+}
+
+// Heatmap renders the minutes up to now as one ASCII character each, oldest
+// first: ' ' without samples, '.' all good, 'o' slow samples, 'x' some loss
+// and 'X' mostly loss. It fits messages that cannot carry colors.
+func (t *Tracker) Heatmap(now time.Time, minutes int) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	minutes = min(minutes, len(t.slots))
+	last := now.Unix() / 60
+	b := make([]byte, 0, minutes)
+	for minute := last - int64(minutes) + 1; minute <= last; minute++ {
+		slot := t.slots[minute%int64(len(t.slots))]
+		switch {
+		case slot.minute != minute || slot.total == 0:
+			b = append(b, ' ')
+		case slot.timeouts*2 >= slot.total:
+			b = append(b, 'X')
+		case slot.timeouts > 0:
+			b = append(b, 'x')
+		case slot.bad > 0:
+			b = append(b, 'o')
+		default:
+			b = append(b, '.')
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,197 @@
+package alert
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+var base = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// feed adds one sample per second for the given duration, starting at start.
+// Every sample is slow when bad is true.
+func feed(tr *Tracker, start time.Time, d time.Duration, bad bool) {
+	rtt := 10 * time.Millisecond
+	if bad {
+		rtt = time.Second
+	}
+	for ts := start; ts.Before(start.Add(d)); ts = ts.Add(time.Second) {
+		tr.Add(types.Sample{Timestamp: ts, RTT: rtt})
+	}
+}
+
+func TestTrackerCompliance(t *testing.T) {
+	tr := NewTracker(SLO{Objective: 99, Threshold: 100 * time.Millisecond, Window: time.Hour})
+	for i := range 200 {
+		s := types.Sample{Timestamp: base.Add(time.Duration(i) * time.Second), RTT: 20 * time.Millisecond}
+		switch i {
+		case 0:
+			s.Timeout = true
+		case 1:
+			s.RTT = 150 * time.Millisecond
+		case 2:
+			s.Timeout = true
+			s.Maintenance = true // Not counted
+		}
+		tr.Add(s)
+	}
+
+	status := tr.Status(base.Add(200 * time.Second))
+	if status.Samples != 199 || status.Bad != 2 {
+		t.Fatalf("samples/bad = %d/%d, want 199/2", status.Samples, status.Bad)
+	}
+	if want := 197.0 / 199 * 100; math.Abs(status.CompliancePercent-want) > 1e-9 {
+		t.Fatalf("compliance = %v, want %v", status.CompliancePercent, want)
+	}
+	// 2 bad of an allowance of 1.99 samples
+	if want := 1 - 2/1.99; math.Abs(status.BudgetRemaining-want) > 1e-9 {
+		t.Fatalf("budget remaining = %v, want %v", status.BudgetRemaining, want)
+	}
+}
+
+func TestTrackerWindowExpires(t *testing.T) {
+	tr := NewTracker(SLO{Objective: 99, Threshold: 100 * time.Millisecond, Window: time.Hour})
+	feed(tr, base, time.Minute, true)
+
+	if s := tr.Status(base.Add(30 * time.Minute)); s.Bad != 60 {
+		t.Fatalf("bad within the window = %d, want 60", s.Bad)
+	}
+	if s := tr.Status(base.Add(2 * time.Hour)); s.Samples != 0 || s.BudgetRemaining != 1 || s.CompliancePercent != 100 {
+		t.Fatalf("expected an empty window after it passed, got %+v", s)
+	}
+
+	// A new lap of the ring replaces the old minute
+	feed(tr, base.Add(time.Hour), time.Minute, false)
+	if s := tr.Status(base.Add(time.Hour + time.Minute)); s.Samples != 60 || s.Bad != 0 {
+		t.Fatalf("samples/bad after a lap = %d/%d, want 60/0", s.Samples, s.Bad)
+	}
+}
+
+func TestTrackerBurnAlerts(t *testing.T) {
+	slo := SLO{Objective: 99.5, Threshold: 100 * time.Millisecond, Window: 24 * time.Hour}
+
+	tests := []struct {
+		name   string
+		build  func(tr *Tracker) time.Time
+		firing []string
+	}{
+		{
+			name: "healthy",
+			build: func(tr *Tracker) time.Time {
+				feed(tr, base, 2*time.Hour, false)
+				return base.Add(2 * time.Hour)
+			},
+		},
+		{
+			name: "hard outage pages and files a ticket",
+			build: func(tr *Tracker) time.Time {
+				feed(tr, base, 2*time.Hour, false)
+				feed(tr, base.Add(2*time.Hour), 10*time.Minute, true)
+				return base.Add(2*time.Hour + 10*time.Minute)
+			},
+			firing: []string{"SLOFastBurn", "SLOSlowBurn"},
+		},
+		{
+			name: "recovered outage resolves through the short window",
+			build: func(tr *Tracker) time.Time {
+				feed(tr, base, 2*time.Hour, false)
+				feed(tr, base.Add(2*time.Hour), 10*time.Minute, true)
+				feed(tr, base.Add(2*time.Hour+10*time.Minute), 40*time.Minute, false)
+				return base.Add(2*time.Hour + 50*time.Minute)
+			},
+		},
+		{
+			name: "slow steady burn files a ticket",
+			build: func(tr *Tracker) time.Time {
+				// 5% bad is a burn rate of 10: under the page threshold
+				for i := range 6 * 3600 {
+					ts := base.Add(time.Duration(i) * time.Second)
+					tr.Add(types.Sample{Timestamp: ts, RTT: 10 * time.Millisecond, Timeout: i%20 == 0})
+				}
+				return base.Add(6 * time.Hour)
+			},
+			firing: []string{"SLOSlowBurn"},
+		},
+		{
+			name: "too few samples never fire",
+			build: func(tr *Tracker) time.Time {
+				tr.Add(types.Sample{Timestamp: base, Timeout: true})
+				return base
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := NewTracker(slo)
+			status := tr.Status(tt.build(tr))
+			var firing []string
+			for _, a := range status.Firing() {
+				firing = append(firing, a.Name)
+			}
+			if len(firing) != len(tt.firing) {
+				t.Fatalf("firing = %v, want %v", firing, tt.firing)
+			}
+			for i := range firing {
+				if firing[i] != tt.firing[i] {
+					t.Fatalf("firing = %v, want %v", firing, tt.firing)
+				}
+			}
+			if len(status.BurnRates) != 4 {
+				t.Fatalf("expected 4 burn-rate windows, got %+v", status.BurnRates)
+			}
+		})
+	}
+}
+
+func TestTrackerReset(t *testing.T) {
+	tr := NewTracker(SLO{Objective: 99, Threshold: 100 * time.Millisecond, Window: time.Hour})
+	feed(tr, base, time.Minute, true)
+	tr.Reset()
+	if s := tr.Status(base.Add(time.Minute)); s.Samples != 0 {
+		t.Fatalf("expected no samples after Reset, got %d", s.Samples)
+	}
+}
+
+func TestTrackerHeatmap(t *testing.T) {
+	tr := NewTracker(SLO{Objective: 99, Threshold: 100 * time.Millisecond, Window: time.Hour})
+	minute := func(m int, samples ...types.Sample) {
+		for i, s := range samples {
+			s.Timestamp = base.Add(time.Duration(m)*time.Minute + time.Duration(i)*time.Second)
+			tr.Add(s)
+		}
+	}
+	ok := types.Sample{RTT: 10 * time.Millisecond}
+	slow := types.Sample{RTT: time.Second}
+	lost := types.Sample{Timeout: true}
+
+	minute(0, ok, ok)
+	minute(1, ok, slow)
+	minute(2, ok, ok, lost)
+	minute(3, ok, lost)
+	// Minute 4 has no samples
+
+	if got := tr.Heatmap(base.Add(4*time.Minute), 6); got != " .oxX " {
+		t.Fatalf("Heatmap = %q, want %q", got, " .oxX ")
+	}
+}
+
+func TestTrackerSetSLO(t *testing.T) {
+	slo := SLO{Objective: 99, Threshold: 100 * time.Millisecond, Window: time.Hour}
+	tr := NewTracker(slo)
+	feed(tr, base, time.Minute, true)
+
+	slo.Objective = 95
+	tr.SetSLO(slo)
+	if s := tr.Status(base.Add(time.Minute)); s.Bad != 60 || tr.SLO().Objective != 95 {
+		t.Fatalf("after a new objective: bad = %d, objective = %v, want 60, 95", s.Bad, tr.SLO().Objective)
+	}
+
+	slo.Threshold = 2 * time.Second
+	tr.SetSLO(slo)
+	if s := tr.Status(base.Add(time.Minute)); s.Samples != 0 {
+		t.Fatalf("expected no samples after a new threshold, got %d", s.Samples)
+	}
+}
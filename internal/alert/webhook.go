@@ -0,0 +1,126 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// notifyTimeout bounds a single delivery.
+const notifyTimeout = 10 * time.Second
+
+// telegramAPI is the Telegram Bot API endpoint.
+const telegramAPI = "https://api.telegram.org"
+
+// Slack posts messages to a Slack incoming webhook.
+type Slack struct {
+	url    string
+	client *http.Client
+}
+
+// NewSlack creates a notifier for the incoming webhook at webhookURL.
+func NewSlack(webhookURL string) (*Slack, error) {
+	if err := validateWebhook(webhookURL); err != nil {
+		return nil, err
+	}
+	return &Slack{url: webhookURL, client: &http.Client{Timeout: notifyTimeout}}, nil
+}
+
+// Notify posts the event's message.
+func (s *Slack) Notify(ctx context.Context, event Event) error {
+	return postJSON(ctx, s.client, s.url, nil, map[string]string{"text": event.Text})
+}
+
+// Discord posts messages to a Discord channel webhook.
+type Discord struct {
+	url    string
+	client *http.Client
+}
+
+// NewDiscord creates a notifier for the channel webhook at webhookURL.
+func NewDiscord(webhookURL string) (*Discord, error) {
+	if err := validateWebhook(webhookURL); err != nil {
+		return nil, err
+	}
+	return &Discord{url: webhookURL, client: &http.Client{Timeout: notifyTimeout}}, nil
+}
+
+// Notify posts the event's message. Discord rejects messages longer than
+// 2000 characters, so longer ones are cut.
+func (d *Discord) Notify(ctx context.Context, event Event) error {
+	text := []rune(event.Text)
+	if len(text) > 2000 {
+		text = append(text[:1999], '…')
+	}
+	return postJSON(ctx, d.client, d.url, nil, map[string]string{"content": string(text)})
+}
+
+// Telegram sends messages to a chat through a Telegram bot.
+type Telegram struct {
+	api    string
+	token  string
+	chat   string
+	client *http.Client
+}
+
+// NewTelegram creates a notifier sending as the bot with token to chat, a
+// numeric chat ID or "@channel".
+func NewTelegram(token, chat string) *Telegram {
+	return &Telegram{api: telegramAPI, token: token, chat: chat, client: &http.Client{Timeout: notifyTimeout}}
+}
+
+// Notify sends the event's message as plain text.
+func (t *Telegram) Notify(ctx context.Context, event Event) error {
+	return postJSON(ctx, t.client, t.api+"/bot"+t.token+"/sendMessage", nil, map[string]string{
+		"chat_id": t.chat,
+		"text":    event.Text,
+	})
+}
+
+// validateWebhook checks that u is an absolute HTTP(S) URL.
+func validateWebhook(u string) error {
+	parsed, err := url.Parse(u)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return fmt.Errorf("%w: webhook must be an http(s) URL, got %q", ErrInvalidNotifier, u)
+	}
+	return nil
+}
+
+// postJSON posts body as JSON with extra headers and fails on non-2xx
+// responses.
+func postJSON(ctx context.Context, client *http.Client, u string, header http.Header, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// Drop the URL, which carries the webhook secret or bot token
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("%s: %w", req.URL.Host, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s: %s", req.URL.Host, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/pbv7/pingheat/internal/fleet"
+	"github.com/pbv7/pingheat/internal/ui"
+)
+
+// runAggregator collects samples pushed by remote agents and shows them in a
+// fleet overview until the UI exits or the server fails. In headless mode
+// it runs until interrupted instead.
+func (a *App) runAggregator() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sd := newShutdown(a.logger, flushTimeout, cancel, cancel)
+	defer sd.run()
+
+	if a.program == nil {
+		a.program = newProgram
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		signal.Stop(sigCh)
+		cancel()
+	}()
+
+	aggregator := fleet.NewAggregator(a.config.AggregatorAddr, a.config.HistorySize)
+	aggregator.SetHistoryBudget(a.config.HistoryBudget, a.config.HistoryAuto)
+	aggregator.SetMaxSeries(a.config.AggregatorMaxSeries)
+	aggregator.SetToken(a.config.FleetToken)
+	aggregator.SetLogger(a.logger)
+	if a.config.FleetToken == "" {
+		a.logger.Warn("aggregator accepts pushes from anyone: $PINGHEAT_FLEET_TOKEN is not set", "addr", a.config.AggregatorAddr)
+	}
+	sd.components.Go("aggregator", func() {
+		defer a.recoverPanic("aggregator")
+		if err := aggregator.Start(ctx); err != nil {
+			a.fail("aggregator", err)
+		}
+	})
+
+	// Without a terminal the aggregator only serves its endpoint
+	if a.config.Output != "" {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-a.errors:
+			return err
+		}
+	}
+
+	ui.SetupTerminal(a.config.ASCII, a.config.NoColor)
+	model := ui.NewOverviewModel(a.config.AggregatorAddr, a.config.Thresholds, aggregator.Snapshot)
+	program := a.program(model)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := program.Run()
+		done <- err
+		cancel()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		program.Quit()
+		return waitForUIShutdown(done, nil)
+	case err := <-a.errors:
+		program.Quit()
+		return waitForUIShutdown(done, err)
+	}
+}
@@ -2,24 +2,57 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"os/signal"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pbv7/pingheat/internal/alert"
+	"github.com/pbv7/pingheat/internal/baselines"
+	"github.com/pbv7/pingheat/internal/buffer"
+	"github.com/pbv7/pingheat/internal/clock"
 	"github.com/pbv7/pingheat/internal/config"
+	"github.com/pbv7/pingheat/internal/control"
+	"github.com/pbv7/pingheat/internal/enrich"
 	"github.com/pbv7/pingheat/internal/exporter"
+	"github.com/pbv7/pingheat/internal/fleet"
+	"github.com/pbv7/pingheat/internal/log"
+	"github.com/pbv7/pingheat/internal/maintenance"
 	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/output"
+	"github.com/pbv7/pingheat/internal/parser"
 	"github.com/pbv7/pingheat/internal/ping"
 	"github.com/pbv7/pingheat/internal/pprof"
+	"github.com/pbv7/pingheat/internal/prefs"
+	"github.com/pbv7/pingheat/internal/script"
 	"github.com/pbv7/pingheat/internal/ui"
+	"github.com/pbv7/pingheat/internal/web"
+	"github.com/pbv7/pingheat/internal/wifi"
 )
 
 const (
 	// shutdownTimeout is the maximum time to wait for UI graceful shutdown.
 	shutdownTimeout = 5 * time.Second
+
+	// flushTimeout bounds the shutdown after the UI exited. It covers the
+	// slowest final push, a remote write with its 10s timeout.
+	flushTimeout = 12 * time.Second
+
+	// uiBufferSize is the number of samples queued for the UI between its
+	// 100ms drains; it absorbs several seconds of a stalled render.
+	uiBufferSize = 1024
+
+	// statsRecompute is how often percentiles are recomputed at most while
+	// samples arrive faster; summaries always get exact ones.
+	statsRecompute = time.Second
 )
 
 // runner emits ping samples until the context is cancelled.
@@ -27,12 +60,153 @@ type runner interface {
 	Run(ctx context.Context, samples chan<- ping.Sample) error
 }
 
-// metricsExporter publishes metrics updates and serves them over HTTP.
+// missCounter is implemented by runners that count unparsed output lines.
+type missCounter interface {
+	SetMissCounter(c *atomic.Uint64)
+}
+
+// debugLogger is implemented by runners that can record their raw output.
+type debugLogger interface {
+	SetDebugLog(l *ping.DebugLog)
+}
+
+// timeoutSetter is implemented by runners with a configurable reply timeout.
+type timeoutSetter interface {
+	SetTimeout(d time.Duration)
+}
+
+// tosSetter is implemented by runners that can mark their probes with a
+// TOS or traffic class byte.
+type tosSetter interface {
+	SetTOS(tos int)
+}
+
+// burstSetter is implemented by runners that can send several probes per
+// interval.
+type burstSetter interface {
+	SetBurst(n int)
+}
+
+// parserSetter is implemented by runners that accept a custom output parser.
+type parserSetter interface {
+	SetParser(p parser.Parser)
+}
+
+// logSetter is implemented by components that log through the runtime logger.
+type logSetter interface {
+	SetLogger(l *slog.Logger)
+}
+
+// healthSetter is implemented by exporters of pingheat's own health.
+type healthSetter interface {
+	SetHealth(fn func() metrics.Health)
+}
+
+// historySetter is implemented by servers that answer queries from the
+// persistent history.
+type historySetter interface {
+	SetHistory(h buffer.Buffer[ping.Sample])
+}
+
+// labelSetter is implemented by exporters that attach static labels.
+type labelSetter interface {
+	SetLabels(labels map[string]string)
+}
+
+// wifiSetter is implemented by exporters of the Wi-Fi link.
+type wifiSetter interface {
+	SetWiFi(fn func() wifi.Link)
+}
+
+// targetInfoSetter is implemented by exporters of the target lookup.
+type targetInfoSetter interface {
+	SetTargetInfo(fn func() enrich.Info)
+}
+
+// runnerFactorySetter is implemented by servers that start probes of their
+// own.
+type runnerFactorySetter interface {
+	SetRunnerFactory(fn func(target string, interval time.Duration) control.Runner)
+}
+
+// scriptSetter is implemented by exporters of script gauges.
+type scriptSetter interface {
+	SetScriptGauges(fn func() []script.Gauge)
+}
+
+// varPublisher is implemented by servers that expose expvar-style variables.
+type varPublisher interface {
+	Publish(name string, f func() any)
+}
+
+// metricsExporter publishes metrics updates, serving or pushing them (see
+// exporter.Backend).
 type metricsExporter interface {
 	Start(ctx context.Context) error
 	Update(stats metrics.Stats)
 }
 
+// liveView receives every sample and serves it to remote viewers.
+type liveView interface {
+	Start(ctx context.Context) error
+	Update(sample ping.Sample, stats metrics.Stats)
+}
+
+// targetSetter is implemented by components that label data with the target
+// and follow target switches.
+type targetSetter interface {
+	SetTarget(target string)
+}
+
+// intervalSetter is implemented by components that report the probe interval
+// and follow :interval changes.
+type intervalSetter interface {
+	SetInterval(interval time.Duration)
+}
+
+// aggregateSetter is implemented by components that serve per-minute buckets.
+type aggregateSetter interface {
+	SetAggregator(a *metrics.Aggregator)
+}
+
+// slaSetter is implemented by components that report hourly/daily SLA.
+type slaSetter interface {
+	SetSLA(t *metrics.SLATracker)
+}
+
+// sloSetter is implemented by components that report SLO compliance.
+type sloSetter interface {
+	SetSLO(t *alert.Tracker)
+}
+
+// remoteWriteSetter is implemented by exporters that can push their metrics.
+type remoteWriteSetter interface {
+	SetRemoteWrite(w *exporter.RemoteWriter)
+}
+
+// sampleObserver is implemented by components that record individual samples,
+// such as the exporter's RTT summary and exemplars.
+type sampleObserver interface {
+	Observe(sample ping.Sample)
+}
+
+// targetSwitch asks the probe loop to move to a new target. ack is closed
+// once the distributor has finished with the old target's samples. A
+// nonzero interval instead keeps the target and changes the interval.
+type targetSwitch struct {
+	target   string
+	interval time.Duration
+	ack      chan struct{}
+}
+
+// errSwitchPending is returned when a target switch is requested while
+// another one is still in progress.
+var errSwitchPending = errors.New("a target switch is already in progress")
+
+// errUIRunning is reported when a store the UI writes to cannot be closed
+// because the UI did not exit in time.
+var errUIRunning = errors.New("UI still running")
+
 // profiler exposes runtime profiling endpoints.
 type profiler interface {
 	Start(ctx context.Context) error
@@ -52,43 +226,268 @@ type App struct {
 	config config.Config
 
 	// Components
-	runner   runner
-	engine   *metrics.Engine
-	exporter metricsExporter
-	pprof    profiler
-	program  programFactory
+	runner    runner
+	newRunner func(target string, interval time.Duration) runner
+	engine    *metrics.Engine
+	minutes   *metrics.Aggregator   // Per-minute buckets of the last 24 hours
+	sla       *metrics.SLATracker   // Availability per hour and calendar day
+	slo       *alert.Tracker        // Latency SLO error budget (nil = no SLO)
+	diagnoser *metrics.Diagnoser    // Gateway vs target verdict (nil = no -diagnose)
+	baseline  *metrics.Calibrator   // Typical latency the delta view compares to
+	radio     *wifi.Monitor         // Wi-Fi link recorded with samples (nil = no -wifi)
+	enricher  *enrich.Enricher      // Target lookup (nil = no -enrich)
+	alerts    *alert.Dispatcher     // SLO alert notifications (nil = none)
+	hooks     *script.Hooks         // User script run per sample (nil = none)
+	schedule  *maintenance.Schedule // Maintenance windows excluded from SLA
+	clock     *clock.Detector       // Wall clock jumps between samples (nil = off)
+	parser    parser.Parser         // Custom output parser for runners (nil = detect)
+	debugLog  *ping.DebugLog        // Raw runner output log (nil = off)
+	exporters []metricsExporter     // -exporter first, then -export backends
+	web       liveView
+	pusher    liveView
+	control   liveView
+	pprof     profiler
+	program   programFactory
+	stdout    io.Writer // Destination for headless output and the final summary
+	stderr    io.Writer // Destination for the summary when stdout carries data
+	logger    *slog.Logger
+	logs      *log.Buffer // Recent log records for the TUI log pane
+	stop      context.CancelFunc
+
+	// Crash dumps: recent samples to include, and where to write them
+	// ("" = the system temporary directory)
+	recent   *buffer.RingBuffer[ping.Sample]
+	crashDir string
+
+	// Current target; changes when the user switches targets. Baselines of
+	// earlier runs by target, and those learned in this one, are guarded too
+	mu      sync.Mutex
+	target  string
+	stored  baselines.Store
+	learned baselines.Store
 
 	// Channels
+	switches   chan targetSwitch // Requests from the UI to the probe loop
+	resets     chan targetSwitch // Probe loop to distributor, between runners
 	samples    chan ping.Sample
 	uiSamples  chan ping.Sample
+	output     chan ping.Sample // Headless output, never dropped (nil = TUI)
+	outputDone chan struct{}    // Closed when headless output stops reading
 	metricsOut chan metrics.Stats
 	errors     chan error
+
+	// Self-monitoring counters, published on the pprof server's /debug/vars
+	processedTotal atomic.Uint64
+	uiDropped      atomic.Uint64
+	statsDropped   atomic.Uint64
+	parserMisses   atomic.Uint64
+
+	// Time the distributor spent on samples, in nanoseconds: the total over
+	// processedTotal and the slowest one
+	processingNanos atomic.Int64
+	processingMax   atomic.Int64
 }
 
 // New creates a new App instance.
 func New(cfg config.Config) *App {
+	newRunner := pingRunners(cfg)
 	app := &App{
 		config:     cfg,
-		runner:     ping.NewRunner(cfg.Target, cfg.Interval),
+		runner:     newRunner(cfg.Target, cfg.Interval),
+		newRunner:  newRunner,
+		switches:   make(chan targetSwitch, 1),
+		resets:     make(chan targetSwitch),
 		engine:     metrics.NewEngine(),
+		minutes:    metrics.NewAggregator(),
+		sla:        metrics.NewSLATracker(cfg.Location()),
 		program:    newProgram,
+		stdout:     os.Stdout,
+		stderr:     os.Stderr,
 		samples:    make(chan ping.Sample, 100),
-		uiSamples:  make(chan ping.Sample, 100),
+		uiSamples:  make(chan ping.Sample, uiBufferSize),
 		metricsOut: make(chan metrics.Stats, 10),
 		errors:     make(chan error, 10),
+		logger:     log.Discard(),
+		logs:       log.NewBuffer(log.DefaultBufferSize),
+		recent:     buffer.NewRingBuffer[ping.Sample](crashSamples),
+		clock:      clock.NewDetector(),
+	}
+
+	app.engine.SetMinRecompute(statsRecompute)
+	if cfg.Brownout > 0 {
+		app.engine.SetBrownoutThreshold(float64(cfg.Brownout) / float64(time.Millisecond))
+	}
+
+	if cfg.DiagnoseGateway != "" {
+		// Beyond the gateway, latency counts as bad where the heatmap turns red
+		app.diagnoser = metrics.NewDiagnoser(cfg.Thresholds.Poor)
+	}
+
+	app.baseline = metrics.NewCalibrator(cfg.Calibrate)
+	if !cfg.Baseline.IsZero() {
+		app.baseline.Set(cfg.Baseline)
+	}
+
+	if cfg.WiFi {
+		app.radio = wifi.NewMonitor(wifi.PollInterval)
+	}
+
+	if cfg.Enrich && cfg.Demo == nil {
+		app.enricher = enrich.NewEnricher(cfg.Target)
 	}
 
 	if cfg.ExporterEnabled {
-		app.exporter = exporter.NewExporter(cfg.ExporterAddr, cfg.Target)
+		e := exporter.NewExporter(cfg.ExporterAddr, cfg.Target, cfg.Interval, cfg.ExporterQuantiles)
+		e.SetOpenMetrics(cfg.ExporterOpenMetrics)
+		e.SetUnits(cfg.MetricsUnits)
+		app.exporters = append(app.exporters, e)
+	} else if cfg.RemoteWriteURL != "" {
+		// Push-only: no HTTP endpoint
+		e := exporter.NewExporter("", cfg.Target, cfg.Interval, cfg.ExporterQuantiles)
+		e.SetUnits(cfg.MetricsUnits)
+		app.exporters = append(app.exporters, e)
+	}
+
+	if cfg.WebEnabled {
+		app.web = web.NewServer(cfg.WebAddr, cfg.Target, cfg.Interval, cfg.Thresholds)
+	}
+
+	if cfg.PushURL != "" {
+		p := fleet.NewPusher(cfg.PushURL, cfg.AgentName, cfg.Target)
+		p.SetToken(cfg.FleetToken)
+		app.pusher = p
+	}
+
+	if cfg.GRPCEnabled {
+		app.control = control.NewServer(cfg.GRPCAddr, cfg.Target, cfg.Interval)
 	}
 
 	if cfg.PprofEnabled {
 		app.pprof = pprof.NewServer(cfg.PprofAddr)
 	}
 
+	if as, ok := app.web.(aggregateSetter); ok {
+		as.SetAggregator(app.minutes)
+	}
+	if rs, ok := app.control.(runnerFactorySetter); ok {
+		rs.SetRunnerFactory(app.apiRunner)
+	}
+	if cfg.SLO != nil {
+		app.slo = alert.NewTracker(*cfg.SLO)
+	}
+	for _, e := range app.exporters {
+		app.wireExporter(e)
+	}
+	if vp, ok := app.pprof.(varPublisher); ok {
+		vp.Publish("pingheat_samples_processed", func() any { return app.processedTotal.Load() })
+		vp.Publish("pingheat_ui_samples_dropped", func() any { return app.uiDropped.Load() })
+		vp.Publish("pingheat_ui_stats_dropped", func() any { return app.statsDropped.Load() })
+		vp.Publish("pingheat_parser_misses", func() any { return app.parserMisses.Load() })
+	}
+
 	return app
 }
 
+// applySettings puts settings changed in the UI into effect in brownout
+// detection and SLO alerting.
+func (a *App) applySettings(s ui.Settings) {
+	a.engine.SetBrownoutThreshold(float64(s.Brownout) / float64(time.Millisecond))
+	if a.slo != nil && s.SLO != nil {
+		a.slo.SetSLO(*s.SLO)
+	}
+}
+
+// wireExporter hands e the trackers and sources it exports, as far as it
+// implements their setters.
+func (a *App) wireExporter(e metricsExporter) {
+	if as, ok := e.(aggregateSetter); ok {
+		as.SetAggregator(a.minutes)
+	}
+	if ss, ok := e.(slaSetter); ok {
+		ss.SetSLA(a.sla)
+	}
+	if ss, ok := e.(sloSetter); ok && a.slo != nil {
+		ss.SetSLO(a.slo)
+	}
+	if hs, ok := e.(healthSetter); ok {
+		hs.SetHealth(a.health)
+	}
+	if ls, ok := e.(labelSetter); ok && len(a.config.Labels) > 0 {
+		ls.SetLabels(a.config.Labels)
+	}
+	if ws, ok := e.(wifiSetter); ok && a.radio != nil {
+		ws.SetWiFi(a.radio.Link)
+	}
+	if ts, ok := e.(targetInfoSetter); ok && a.enricher != nil {
+		ts.SetTargetInfo(a.enricher.Info)
+	}
+}
+
+// pingRunners returns the factory of default runners for cfg: the plugin of
+// a registered or exec: mode, a UDP scheduler in UDP mode, a strict-timing
+// scheduler if requested or bursting, the platform's prober otherwise,
+// wrapped to probe faster during outages with an adaptive interval. In demo
+// mode every target gets synthetic samples instead, following the scenario
+// if one is set.
+func pingRunners(cfg config.Config) func(target string, interval time.Duration) runner {
+	if cfg.Demo != nil {
+		return func(_ string, interval time.Duration) runner {
+			d := ping.NewDemo(interval, *cfg.Demo)
+			if cfg.DemoScenario != nil {
+				d = ping.NewScenarioDemo(interval, *cfg.Demo, *cfg.DemoScenario)
+			}
+			d.SetBurst(cfg.Burst)
+			return d
+		}
+	}
+	probe := func(target string, interval time.Duration) runner {
+		var r runner = ping.NewProber(target, interval)
+		plugin, isPlugin := ping.NewPlugin(cfg.Mode, target, interval)
+		// A continuous ping sends one probe per interval; bursts need
+		// single-shot probes, as UDP probes always are. Plugins pace
+		// their probes themselves.
+		switch {
+		case isPlugin:
+			r = plugin
+		case cfg.Mode == ping.ModeUDP:
+			r = ping.NewUDPScheduler(target, interval, cfg.UDPPort)
+		case cfg.StrictTiming || cfg.Burst > 1:
+			r = ping.NewScheduler(target, interval)
+		}
+		if ts, ok := r.(timeoutSetter); ok && cfg.Timeout > 0 {
+			ts.SetTimeout(cfg.Timeout)
+		}
+		if ts, ok := r.(tosSetter); ok && cfg.TOS != 0 {
+			ts.SetTOS(cfg.TOS)
+		}
+		if bs, ok := r.(burstSetter); ok && cfg.Burst > 1 {
+			bs.SetBurst(cfg.Burst)
+		}
+		return r
+	}
+	if cfg.AdaptiveInterval <= 0 {
+		return probe
+	}
+	return func(target string, interval time.Duration) runner {
+		return ping.NewAdaptive(interval, cfg.AdaptiveInterval, func(interval time.Duration) ping.Prober {
+			return probe(target, interval)
+		})
+	}
+}
+
+// apiRunner creates the runner of a probe started over the control API: a
+// default runner, as the primary probe's, parsing output the same way.
+func (a *App) apiRunner(target string, interval time.Duration) control.Runner {
+	r := a.newRunner(target, interval)
+	if ps, ok := r.(parserSetter); ok && a.parser != nil {
+		ps.SetParser(a.parser)
+	}
+	if ls, ok := r.(logSetter); ok {
+		ls.SetLogger(a.logger)
+	}
+	return r
+}
+
 // newProgram creates the default Bubble Tea program.
 func newProgram(model tea.Model) program {
 	return tea.NewProgram(model, tea.WithAltScreen())
@@ -117,10 +516,236 @@ func waitForUIShutdown(done <-chan error, originalErr error) error {
 	return originalErr
 }
 
+// hasLimit reports whether the session stops on its own (-c, -duration or
+// a demo scenario without loop).
+func (a *App) hasLimit() bool {
+	finite := a.config.DemoScenario != nil && !a.config.DemoScenario.Loop
+	return a.config.Count > 0 || a.config.Duration > 0 || finite
+}
+
 // Run starts the application.
-func (a *App) Run() error {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+// When a count or duration limit is configured, the session ends once the
+// limit is reached and a final summary is written after the UI exits.
+// A limited session that completes cleanly returns ErrThresholdExceeded if
+// any -fail-on-* threshold was exceeded.
+// A panic in any of the app's goroutines ends the session with a CrashError.
+func (a *App) Run() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = a.crashed("main", r, debug.Stack())
+		}
+	}()
+
+	closeLog, err := a.openLog()
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+
+	if a.config.AggregatorEnabled {
+		return a.runAggregator()
+	}
+
+	a.loadBaselines()
+	err = a.run()
+	a.saveBaselines()
+	if !a.hasLimit() {
+		return err
+	}
+
+	stats := a.engine.ExactStats()
+	summaryOut := a.stdout
+	if a.config.Output != "" {
+		// Keep the data stream on stdout machine-readable
+		summaryOut = a.stderr
+	}
+	if summaryOut != nil {
+		writeSummary(summaryOut, a.currentTarget(), stats)
+		if a.diagnoser != nil {
+			writeDiagnosis(summaryOut, a.config.DiagnoseGateway, a.currentTarget(), a.diagnoser.Diagnosis())
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return checkThresholds(a.config, stats)
+}
+
+// openLog sets up the runtime logger and hands it to the components. Records
+// always reach the TUI log pane; they are also written to -log-file, or to
+// stderr when no TUI owns the terminal. It returns a function closing the file.
+func (a *App) openLog() (func(), error) {
+	var w io.Writer
+	closeFile := func() {}
+	switch {
+	case a.config.LogFile != "":
+		f, err := os.OpenFile(a.config.LogFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("log file: %w", err)
+		}
+		w = f
+		closeFile = func() { _ = f.Close() }
+	case a.config.Output != "":
+		w = a.stderr
+	}
+	a.logger = log.New(w, a.config.LogLevel, a.logs)
+
+	for _, c := range a.withExporters(a.web, a.pusher, a.control, a.pprof) {
+		if ls, ok := c.(logSetter); ok {
+			ls.SetLogger(a.logger)
+		}
+	}
+	return closeFile, nil
+}
+
+// withExporters returns the exporters followed by components, for handing
+// them optional settings.
+func (a *App) withExporters(components ...any) []any {
+	all := make([]any, 0, len(a.exporters)+len(components))
+	for _, e := range a.exporters {
+		all = append(all, e)
+	}
+	return append(all, components...)
+}
+
+// fail logs a component failure and reports it to end the session.
+func (a *App) fail(component string, err error) {
+	a.logger.Error("component failed", "component", component, "error", err)
+	a.errors <- fmt.Errorf("%s: %w", component, err)
+}
+
+// run drives all components until the UI exits, a limit is reached or a
+// component fails, then shuts them down in order (see shutdown).
+func (a *App) run() error {
+	ctx, stopComponents := context.WithCancel(context.Background())
+	defer stopComponents()
+	probeCtx, stopProbes := context.WithCancel(ctx)
+	defer stopProbes()
+	if a.config.Duration > 0 {
+		var cancelTimeout context.CancelFunc
+		probeCtx, cancelTimeout = context.WithTimeout(probeCtx, a.config.Duration)
+		defer cancelTimeout()
+	}
+	a.stop = stopProbes
+	if a.config.Output != "" {
+		a.output = make(chan ping.Sample, uiBufferSize)
+		a.outputDone = make(chan struct{})
+	}
+
+	sd := newShutdown(a.logger, flushTimeout, stopProbes, stopComponents)
+	defer sd.run()
+
+	a.mu.Lock()
+	a.target = a.config.Target
+	a.mu.Unlock()
+
+	schedule, err := maintenance.Parse(a.config.Maintenance, a.config.Location())
+	if err != nil {
+		return err
+	}
+	a.schedule = schedule
+
+	if a.config.Parser != nil {
+		if a.parser, err = parser.NewRegex(*a.config.Parser); err != nil {
+			return err
+		}
+	}
+
+	if a.config.DebugLog != "" {
+		if a.debugLog, err = ping.OpenDebugLog(a.config.DebugLog); err != nil {
+			return fmt.Errorf("debug log: %w", err)
+		}
+		sd.onClose("debug log", a.debugLog.Close)
+	}
+
+	for _, spec := range a.config.Exports {
+		b, err := exporter.NewBackend(spec, exporter.Options{Target: a.config.Target, Interval: a.config.Interval})
+		if err != nil {
+			return err
+		}
+		if ls, ok := b.(logSetter); ok {
+			ls.SetLogger(a.logger)
+		}
+		a.wireExporter(b)
+		a.exporters = append(a.exporters, b)
+	}
+
+	if a.config.RemoteWriteURL != "" {
+		w, err := exporter.NewRemoteWriter(a.config.RemoteWriteURL, a.config.RemoteWriteToken, a.config.RemoteWriteInterval)
+		if err != nil {
+			return err
+		}
+		w.SetLogger(a.logger)
+		// Only the first exporter pushes, so series are not sent twice
+		for _, e := range a.exporters {
+			if rs, ok := e.(remoteWriteSetter); ok {
+				rs.SetRemoteWrite(w)
+				break
+			}
+		}
+	}
+
+	if a.slo != nil && (len(a.config.Notify) > 0 || a.config.Email != nil) {
+		notifiers, err := alert.ParseNotifiers(a.config.Notify)
+		if err != nil {
+			return err
+		}
+		if a.config.Email != nil {
+			email, err := alert.NewSMTP(*a.config.Email)
+			if err != nil {
+				return err
+			}
+			notifiers = append(notifiers, email)
+		}
+		tmpl, err := alert.ParseTemplate(a.config.NotifyTemplate)
+		if err != nil {
+			return err
+		}
+		a.alerts = alert.NewDispatcher(a.slo, a.config.Target, notifiers, tmpl)
+		a.alerts.SetLogger(a.logger)
+		if vp, ok := a.pprof.(varPublisher); ok {
+			vp.Publish("pingheat_alert_notify_failures", func() any { return a.alerts.Failures() })
+		}
+	}
+
+	if a.config.Script != "" {
+		if a.hooks, err = script.Load(a.config.Script); err != nil {
+			return err
+		}
+		a.hooks.SetLogger(a.logger)
+		if a.alerts != nil {
+			a.hooks.SetNotify(a.alerts.Message)
+		}
+		for _, e := range a.exporters {
+			if ss, ok := e.(scriptSetter); ok {
+				ss.SetScriptGauges(a.hooks.Gauges)
+			}
+		}
+	}
+
+	// Only the TUI keeps a history; open it before anything starts. It is
+	// closed once the UI stopped writing to it, with the samples the UI did
+	// not get to.
+	var history *buffer.MmapRing[ping.Sample]
+	uiStopped := make(chan struct{})
+	if a.config.HistoryFile != "" && a.config.Output == "" {
+		history, err = buffer.OpenMmap(a.config.HistoryFile, a.config.HistorySize, ping.SampleCodec{})
+		if err != nil {
+			return fmt.Errorf("history file: %w", err)
+		}
+		sd.onClose("history", func() error {
+			select {
+			case <-uiStopped:
+			default:
+				return errUIRunning
+			}
+			a.flushHistory(history)
+			return history.Close()
+		})
+		if hs, ok := a.web.(historySetter); ok {
+			hs.SetHistory(history)
+		}
+	}
 
 	if a.program == nil {
 		a.program = newProgram
@@ -131,55 +756,154 @@ func (a *App) Run() error {
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-sigCh
-		cancel()
+		// A second signal kills the process if shutting down hangs
+		signal.Stop(sigCh)
+		stopProbes()
 	}()
 
 	// Start pprof server if enabled
 	if a.pprof != nil {
-		go func() {
+		sd.components.Go("pprof server", func() {
+			defer a.recoverPanic("pprof server")
 			if err := a.pprof.Start(ctx); err != nil {
-				a.errors <- fmt.Errorf("pprof server: %w", err)
+				a.fail("pprof server", err)
 			}
-		}()
+		})
 	}
 
-	// Start exporter if enabled
-	if a.exporter != nil {
-		go func() {
-			if err := a.exporter.Start(ctx); err != nil {
-				a.errors <- fmt.Errorf("exporter: %w", err)
+	// Start exporters if enabled
+	for _, e := range a.exporters {
+		sd.components.Go("exporter", func() {
+			defer a.recoverPanic("exporter")
+			if err := e.Start(ctx); err != nil {
+				a.fail("exporter", err)
 			}
-		}()
+		})
+	}
+
+	// Start web UI if enabled
+	if a.web != nil {
+		sd.components.Go("web UI", func() {
+			defer a.recoverPanic("web UI")
+			if err := a.web.Start(ctx); err != nil {
+				a.fail("web UI", err)
+			}
+		})
+	}
+
+	// Start gRPC control API if enabled
+	if a.control != nil {
+		sd.components.Go("grpc", func() {
+			defer a.recoverPanic("grpc")
+			if err := a.control.Start(ctx); err != nil {
+				a.fail("grpc", err)
+			}
+		})
+	}
+
+	// Start delivering alert notifications if configured
+	if a.alerts != nil {
+		sd.components.Go("alerts", func() {
+			defer a.recoverPanic("alerts")
+			_ = a.alerts.Start(ctx)
+		})
+	}
+
+	// Start pushing to the aggregator if enabled
+	if a.pusher != nil {
+		sd.components.Go("pusher", func() {
+			defer a.recoverPanic("pusher")
+			_ = a.pusher.Start(ctx)
+		})
+	}
+
+	// Start reading the Wi-Fi link if enabled
+	if a.radio != nil {
+		a.radio.SetLogger(a.logger)
+		sd.components.Go("wifi", func() {
+			defer a.recoverPanic("wifi")
+			a.radio.Run(ctx)
+		})
+	}
+
+	// Start looking up the target if enabled
+	if a.enricher != nil {
+		a.enricher.SetLogger(a.logger)
+		sd.components.Go("enrich", func() {
+			defer a.recoverPanic("enrich")
+			a.enricher.Run(ctx)
+		})
 	}
 
 	// Start ping runner
-	go func() {
-		if err := a.runner.Run(ctx, a.samples); err != nil {
-			a.errors <- fmt.Errorf("ping runner: %w", err)
-		}
-		close(a.samples)
-	}()
+	a.logger.Info("probing", "target", a.config.Target, "interval", a.config.Interval)
+	sd.pipeline.Go("ping runner", func() { a.runProbes(probeCtx) })
+
+	if a.diagnoser != nil {
+		a.logger.Info("probing gateway for diagnosis", "gateway", a.config.DiagnoseGateway)
+		sd.pipeline.Go("gateway runner", func() { a.runGateway(probeCtx) })
+	}
 
 	// Start distributor
-	go a.distribute(ctx)
+	sd.pipeline.Go("distributor", a.distribute)
+
+	if a.config.Output != "" {
+		return a.runHeadless()
+	}
 
 	// Create and run UI
+	ui.SetupTerminal(a.config.ASCII, a.config.NoColor)
 	model := ui.NewModel(a.config, a.uiSamples, a.metricsOut)
-	program := a.program(model)
+	if history != nil {
+		model.SetHistory(history)
+	}
+	model.SetTargetSwitcher(a.SwitchTarget)
+	model.SetIntervalSetter(a.SetInterval)
+	model.SetMarker(func(note string) { a.logger.Info("marker", "note", note) })
+	model.SetParserMisses(func() int { return int(a.parserMisses.Load()) })
+	model.SetLogs(a.logs)
+	model.SetHealth(a.health)
+	if a.diagnoser != nil {
+		model.SetDiagnosis(a.diagnoser.Diagnosis)
+	}
+	if a.radio != nil {
+		model.SetWiFi(a.radio.Link)
+	}
+	if a.enricher != nil {
+		model.SetTargetInfo(a.enricher.Info)
+	}
+	model.SetCalibrator(a.baseline)
+	model.SetSettingsApplier(a.applySettings)
+	var panicked atomic.Pointer[uiPanic]
+	program := a.program(guardedModel{Model: model, panicked: &panicked})
 
 	// Run UI in a goroutine so we can cancel it
 	done := make(chan error, 1)
 	go func() {
-		_, err := program.Run()
+		defer close(uiStopped)
+		final, err := program.Run()
+		if errors.Is(err, tea.ErrProgramPanic) {
+			// Bubble Tea restored the terminal; keep the crashed state unsaved
+			p := panicked.Load()
+			if p == nil {
+				p = &uiPanic{value: "panic in a UI command"}
+			}
+			err = a.crashed("ui", p.value, p.stack)
+		} else {
+			if g, ok := final.(guardedModel); ok {
+				final = g.Model
+			}
+			a.savePreferences(final)
+		}
 		done <- err
-		cancel()
+		stopProbes()
 	}()
 
 	// Wait for completion
 	select {
 	case err := <-done:
 		return err
-	case <-ctx.Done():
+	case <-probeCtx.Done():
 		program.Quit()
 		// Check for a pending component error to avoid race where it could be lost
 		select {
@@ -195,43 +919,397 @@ func (a *App) Run() error {
 	}
 }
 
-// distribute fans out samples to consumers.
-func (a *App) distribute(ctx context.Context) {
+// runHeadless streams samples to stdout in the configured format instead of
+// running the TUI. It returns when the sample stream closes or a component
+// fails.
+func (a *App) runHeadless() error {
+	w, err := output.NewWriter(a.stdout, a.config.Output)
+	if err != nil {
+		return err
+	}
+	w.SetLabels(a.config.Labels)
+	defer close(a.outputDone)
+
+	for {
+		select {
+		case sample, ok := <-a.output:
+			if !ok {
+				// Distributor stopped; surface a pending component error if any
+				select {
+				case err := <-a.errors:
+					return err
+				default:
+					return nil
+				}
+			}
+			if err := w.Write(sample); err != nil {
+				return fmt.Errorf("write output: %w", err)
+			}
+		case err := <-a.errors:
+			return err
+		}
+	}
+}
+
+// savePreferences merges UI state from the final model into the saved
+// preferences, best effort: the terminal may already be torn down, so
+// failures are only logged.
+func (a *App) savePreferences(final tea.Model) {
+	if a.config.PrefsPath == "" {
+		return
+	}
+	m, ok := final.(ui.Model)
+	if !ok {
+		return
+	}
+	saved, err := prefs.Load(a.config.PrefsPath)
+	if err != nil {
+		saved = prefs.Preferences{}
+	}
+	if err := prefs.Save(a.config.PrefsPath, saved.Merge(m.Preferences())); err != nil {
+		a.logger.Warn("saving UI preferences failed", "path", a.config.PrefsPath, "error", err)
+	}
+}
+
+// currentTarget returns the target being probed.
+func (a *App) currentTarget() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.target
+}
+
+// SwitchTarget stops probing the current target and starts probing target.
+// Statistics restart for the new target; keeping or clearing the heatmap
+// history is up to the UI.
+func (a *App) SwitchTarget(target string) error {
+	if err := config.ValidateTarget(target); err != nil {
+		return err
+	}
+
+	select {
+	case a.switches <- targetSwitch{target: target, ack: make(chan struct{})}:
+		return nil
+	default:
+		return errSwitchPending
+	}
+}
+
+// SetInterval restarts probing the current target every d. Unlike a
+// target switch, statistics carry on.
+func (a *App) SetInterval(d time.Duration) error {
+	if d < config.MinInterval || d > config.MaxInterval {
+		return fmt.Errorf("interval must be between %v and %v", config.MinInterval, config.MaxInterval)
+	}
+	if a.config.AdaptiveInterval >= d {
+		return fmt.Errorf("interval must be longer than the adaptive interval %v", a.config.AdaptiveInterval)
+	}
+
+	select {
+	case a.switches <- targetSwitch{interval: d}:
+		return nil
+	default:
+		return errSwitchPending
+	}
+}
+
+// runProbes runs the ping runner until ctx is cancelled or it fails,
+// replacing it whenever a target switch is requested.
+func (a *App) runProbes(ctx context.Context) {
+	defer a.recoverPanic("ping runner")
+	defer close(a.samples)
+
+	r := a.runner
+	target, interval := a.currentTarget(), a.config.Interval
 	for {
+		if mc, ok := r.(missCounter); ok {
+			mc.SetMissCounter(&a.parserMisses)
+		}
+		if ps, ok := r.(parserSetter); ok && a.parser != nil {
+			ps.SetParser(a.parser)
+		}
+		if dl, ok := r.(debugLogger); ok && a.debugLog != nil {
+			dl.SetDebugLog(a.debugLog)
+		}
+		if ls, ok := r.(logSetter); ok {
+			ls.SetLogger(a.logger)
+		}
+		runCtx, stop := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func() {
+			defer close(done) // Ends the loop quietly if the runner panicked
+			defer a.recoverPanic("ping runner")
+			done <- r.Run(runCtx, a.samples)
+		}()
+
 		select {
-		case <-ctx.Done():
-			close(a.uiSamples)
-			close(a.metricsOut)
+		case err := <-done:
+			stop()
+			if err != nil {
+				a.fail("ping runner", err)
+			} else if a.stop != nil && ctx.Err() == nil {
+				a.stop() // The runner finished, e.g. a demo scenario
+			}
 			return
+
+		case sw := <-a.switches:
+			stop()
+			<-done // The old runner was cancelled; its error is expected
+
+			if sw.interval > 0 {
+				interval = sw.interval
+				a.logger.Info("changed interval", "interval", interval)
+				for _, c := range a.withExporters(a.web, a.control) {
+					if is, ok := c.(intervalSetter); ok {
+						is.SetInterval(interval)
+					}
+				}
+				r = a.newRunner(target, interval)
+				continue
+			}
+
+			// Let the distributor finish the old target before the new one starts
+			select {
+			case a.resets <- sw:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case <-sw.ack:
+			case <-ctx.Done():
+				return
+			}
+			target = sw.target
+			r = a.newRunner(target, interval)
+		}
+	}
+}
+
+// runGateway probes the gateway of -diagnose until ctx is cancelled. Its
+// samples only feed the diagnoser; statistics and exports cover the target.
+func (a *App) runGateway(ctx context.Context) {
+	defer a.recoverPanic("gateway runner")
+
+	r := a.newRunner(a.config.DiagnoseGateway, a.config.Interval)
+	if ls, ok := r.(logSetter); ok {
+		ls.SetLogger(a.logger)
+	}
+	samples := make(chan ping.Sample, 100)
+	done := make(chan error, 1)
+	go func() {
+		defer close(samples)
+		defer a.recoverPanic("gateway runner")
+		done <- r.Run(ctx, samples)
+	}()
+	for sample := range samples {
+		a.diagnoser.AddGateway(sample)
+	}
+	select {
+	case err := <-done:
+		if err != nil && ctx.Err() == nil {
+			a.fail("gateway runner", err)
+		}
+	default: // The runner panicked
+	}
+}
+
+// distribute fans out samples to consumers until the probe loop closes the
+// sample stream, so samples still buffered at shutdown are not lost.
+func (a *App) distribute() {
+	defer a.recoverPanic("distributor")
+	processed := 0
+	for {
+		select {
 		case sample, ok := <-a.samples:
 			if !ok {
 				close(a.uiSamples)
+				if a.output != nil {
+					close(a.output)
+				}
 				close(a.metricsOut)
 				return
 			}
+			a.process(sample, &processed)
+		case sw := <-a.resets:
+			// The old runner has stopped: whatever is buffered belongs to it
+			a.drain(&processed)
+			a.retarget(sw.target)
+			close(sw.ack)
+		}
+	}
+}
 
-			// Send to UI (non-blocking)
-			select {
-			case a.uiSamples <- sample:
-			default:
-				// UI buffer full, skip
+// drain processes samples already buffered, without waiting for more.
+func (a *App) drain(processed *int) {
+	for {
+		select {
+		case sample, ok := <-a.samples:
+			if !ok {
+				return
 			}
+			a.process(sample, processed)
+		default:
+			return
+		}
+	}
+}
 
-			// Update metrics
-			a.engine.Add(sample)
-			stats := a.engine.Stats()
-
-			// Send to metrics channel (non-blocking)
-			select {
-			case a.metricsOut <- stats:
-			default:
-				// Metrics buffer full, skip
+// flushHistory stores the samples the UI had not received when it exited.
+func (a *App) flushHistory(history buffer.Buffer[ping.Sample]) {
+	for {
+		select {
+		case sample, ok := <-a.uiSamples:
+			if !ok {
+				return
 			}
+			history.Push(sample)
+		default:
+			return
+		}
+	}
+}
 
-			// Update exporter if enabled
-			if a.exporter != nil {
-				a.exporter.Update(stats)
-			}
+// retarget restarts statistics for a new target and relabels components.
+func (a *App) retarget(target string) {
+	a.mu.Lock()
+	previous := a.target
+	a.target = target
+	a.mu.Unlock()
+	a.logger.Info("switched target", "target", target)
+
+	a.engine.Reset()
+	a.minutes.Reset()
+	a.sla.Reset()
+	if a.slo != nil {
+		a.slo.Reset()
+	}
+	if a.diagnoser != nil {
+		a.diagnoser.ResetAnchor()
+	}
+	a.rememberBaseline(previous)
+	a.baseline.Reset()
+	a.seedBaseline(target)
+	for _, c := range a.withExporters(a.web, a.pusher, a.control) {
+		if ts, ok := c.(targetSetter); ok {
+			ts.SetTarget(target)
+		}
+	}
+	if a.alerts != nil {
+		a.alerts.SetTarget(target)
+	}
+	if a.enricher != nil {
+		a.enricher.SetTarget(target)
+	}
+
+	select {
+	case a.metricsOut <- a.stats():
+	default:
+		a.statsDropped.Add(1)
+	}
+}
+
+// stats returns the engine statistics with the app's drop counters.
+func (a *App) stats() metrics.Stats {
+	stats := a.engine.Stats()
+	stats.DroppedUISamples = int(a.uiDropped.Load())
+	stats.DroppedStats = int(a.statsDropped.Load())
+	stats.ParserMisses = int(a.parserMisses.Load())
+	return stats
+}
+
+// process fans out one sample to consumers.
+func (a *App) process(sample ping.Sample, processed *int) {
+	// Ignore samples that arrive after the count limit while shutting down
+	if a.config.Count > 0 && *processed >= a.config.Count {
+		return
+	}
+	*processed++
+	a.processedTotal.Add(1)
+	defer a.observeProcessing(time.Now())
+	sample.Maintenance = a.schedule.Contains(sample.Timestamp)
+	if jump, ok := a.clock.Check(); ok {
+		sample.ClockJump = true
+		a.logger.Warn("system clock jumped, not counting the gap as an outage", "by", jump.Round(time.Second))
+	}
+	if a.radio != nil {
+		sample.Signal = a.radio.Link().Signal
+	}
+	if a.recent != nil {
+		a.recent.Push(sample)
+	}
+
+	if a.output != nil {
+		// Headless output promises a line per sample: wait for a slow
+		// reader rather than drop, unless the output has stopped
+		select {
+		case a.output <- sample:
+		case <-a.outputDone:
+		}
+	} else {
+		// Send to UI (non-blocking)
+		select {
+		case a.uiSamples <- sample:
+		default:
+			// UI buffer full, skip
+			a.uiDropped.Add(1)
+		}
+	}
+
+	// Update metrics
+	a.engine.Add(sample)
+	a.minutes.Add(sample)
+	a.sla.Add(sample)
+	if a.slo != nil {
+		a.slo.Add(sample)
+	}
+	if a.diagnoser != nil {
+		a.diagnoser.AddAnchor(sample)
+	}
+	a.baseline.Add(sample)
+	stats := a.stats()
+
+	// Stop the session once the sample count limit is reached
+	if a.config.Count > 0 && *processed >= a.config.Count && a.stop != nil {
+		a.stop()
+	}
+
+	// Send to metrics channel (non-blocking)
+	select {
+	case a.metricsOut <- stats:
+	default:
+		// Metrics buffer full, skip
+		a.statsDropped.Add(1)
+	}
+
+	// Update exporters if enabled
+	for _, e := range a.exporters {
+		if obs, ok := e.(sampleObserver); ok {
+			obs.Observe(sample)
 		}
+		e.Update(stats)
+	}
+
+	// Update web UI if enabled
+	if a.web != nil {
+		a.web.Update(sample, stats)
+	}
+
+	// Update gRPC control API if enabled
+	if a.control != nil {
+		a.control.Update(sample, stats)
+	}
+
+	// Queue for the aggregator if enabled
+	if a.pusher != nil {
+		a.pusher.Update(sample, stats)
+	}
+
+	// Notify on SLO alert transitions if configured
+	if a.alerts != nil {
+		a.alerts.Update(sample, stats)
+	}
+
+	// Run the user's script hook if configured
+	if a.hooks != nil {
+		a.hooks.Update(sample, stats)
 	}
 }
@@ -1,15 +1,29 @@
 package app
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pbv7/pingheat/internal/alert"
+	"github.com/pbv7/pingheat/internal/baselines"
 	"github.com/pbv7/pingheat/internal/config"
+	"github.com/pbv7/pingheat/internal/log"
 	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/parser"
 	"github.com/pbv7/pingheat/internal/ping"
+	"github.com/pbv7/pingheat/internal/prefs"
+	"github.com/pbv7/pingheat/internal/ui"
 )
 
 type stubRunner struct {
@@ -24,6 +38,23 @@ func (r *stubRunner) Run(ctx context.Context, samples chan<- ping.Sample) error
 	return nil
 }
 
+// sampleRunner emits a fixed set of samples, then idles until cancelled.
+type sampleRunner struct {
+	samples []ping.Sample
+}
+
+func (r *sampleRunner) Run(ctx context.Context, samples chan<- ping.Sample) error {
+	for _, s := range r.samples {
+		select {
+		case samples <- s:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	<-ctx.Done()
+	return nil
+}
+
 type stubExporter struct {
 	startErr error
 	updates  int
@@ -41,6 +72,26 @@ func (e *stubExporter) Update(stats metrics.Stats) {
 	e.updates++
 }
 
+// targetExporter records target switches and interval changes.
+type targetExporter struct {
+	stubExporter
+	mu        sync.Mutex
+	targets   []string
+	intervals []time.Duration
+}
+
+func (e *targetExporter) SetTarget(target string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.targets = append(e.targets, target)
+}
+
+func (e *targetExporter) SetInterval(interval time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.intervals = append(e.intervals, interval)
+}
+
 type stubProfiler struct {
 	startErr error
 }
@@ -73,18 +124,25 @@ func (p *stubProgram) Quit() {
 }
 
 func newTestApp(r runner, e metricsExporter, p profiler, prog *stubProgram) *App {
-	return &App{
+	app := &App{
 		config:     config.DefaultConfig(),
 		runner:     r,
 		engine:     metrics.NewEngine(),
-		exporter:   e,
+		minutes:    metrics.NewAggregator(),
+		sla:        metrics.NewSLATracker(time.UTC),
+		baseline:   metrics.NewCalibrator(metrics.DefaultCalibrationSamples),
 		pprof:      p,
 		program:    func(tea.Model) program { return prog },
 		samples:    make(chan ping.Sample, 1),
 		uiSamples:  make(chan ping.Sample, 1),
 		metricsOut: make(chan metrics.Stats, 1),
 		errors:     make(chan error, 1),
+		logger:     log.Discard(),
+	}
+	if e != nil {
+		app.exporters = []metricsExporter{e}
 	}
+	return app
 }
 
 func TestRunReturnsRunnerError(t *testing.T) {
@@ -143,3 +201,576 @@ func TestRunReturnsProgramError(t *testing.T) {
 		t.Fatalf("expected program error, got %v", err)
 	}
 }
+
+func TestSavePreferences(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ui.json")
+	app := newTestApp(&stubRunner{}, nil, nil, &stubProgram{block: make(chan struct{})})
+	app.config.PrefsPath = path
+
+	cfg := config.DefaultConfig()
+	cfg.ViewMode = "braille"
+	app.savePreferences(ui.NewModel(cfg, nil, nil))
+
+	got, err := prefs.Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if got.ViewMode != "braille" {
+		t.Fatalf("saved ViewMode=%q, want braille", got.ViewMode)
+	}
+
+	// Non-UI models (test stubs) are ignored
+	app.savePreferences(nil)
+}
+
+func TestRunStopsAtCountAndWritesSummary(t *testing.T) {
+	runner := &sampleRunner{samples: []ping.Sample{
+		{Sequence: 1, RTT: 10 * time.Millisecond},
+		{Sequence: 2, Timeout: true},
+		{Sequence: 3, RTT: 20 * time.Millisecond},
+		{Sequence: 4, RTT: 30 * time.Millisecond},
+	}}
+	prog := &stubProgram{block: make(chan struct{})}
+	app := newTestApp(runner, nil, nil, prog)
+	app.config.Target = "example.com"
+	app.config.Count = 3
+	var out bytes.Buffer
+	app.stdout = &out
+
+	if err := app.Run(); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if !prog.quitCalled {
+		t.Fatalf("expected program Quit to be called")
+	}
+
+	summary := out.String()
+	for _, want := range []string{"--- example.com pingheat statistics ---", "3 samples, 2 received", "33.3% loss"} {
+		if !strings.Contains(summary, want) {
+			t.Fatalf("summary missing %q:\n%s", want, summary)
+		}
+	}
+}
+
+func TestWriteSummaryMeanTimes(t *testing.T) {
+	var out bytes.Buffer
+	writeSummary(&out, "example.com", metrics.Stats{TotalSamples: 100, LossBursts: 2,
+		MTBF: 45*time.Minute + 300*time.Millisecond, MTTR: 2500 * time.Millisecond, Downtime: 5 * time.Second})
+	if want := "mtbf 45m0s, mttr 2.5s, downtime 5s\n"; !strings.Contains(out.String(), want) {
+		t.Fatalf("summary missing %q:\n%s", want, out.String())
+	}
+
+	out.Reset()
+	writeSummary(&out, "example.com", metrics.Stats{TotalSamples: 100})
+	if strings.Contains(out.String(), "mtbf") {
+		t.Fatalf("summary without outages mentions mtbf:\n%s", out.String())
+	}
+}
+
+func TestRunStopsAfterDuration(t *testing.T) {
+	prog := &stubProgram{block: make(chan struct{})}
+	app := newTestApp(&stubRunner{}, nil, nil, prog)
+	app.config.Duration = 50 * time.Millisecond
+	var out bytes.Buffer
+	app.stdout = &out
+
+	if err := app.Run(); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if !strings.Contains(out.String(), "0 samples") {
+		t.Fatalf("expected empty summary, got %q", out.String())
+	}
+}
+
+func TestRunGatewayFeedsDiagnoser(t *testing.T) {
+	app := newTestApp(&stubRunner{}, nil, nil, nil)
+	app.config.DiagnoseGateway = "192.0.2.1"
+	app.diagnoser = metrics.NewDiagnoser(150)
+	var probed string
+	app.newRunner = func(target string, _ time.Duration) runner {
+		probed = target
+		samples := make([]ping.Sample, 12)
+		for i := range samples {
+			samples[i] = ping.Sample{Sequence: i + 1, Timeout: true}
+		}
+		return &sampleRunner{samples: samples}
+	}
+	for i := range 12 {
+		app.diagnoser.AddAnchor(ping.Sample{Sequence: i + 1, RTT: 20 * time.Millisecond})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	app.runGateway(ctx)
+
+	if probed != "192.0.2.1" {
+		t.Fatalf("gateway runner probed %q, want the gateway", probed)
+	}
+	d := app.diagnoser.Diagnosis()
+	if d.Gateway.Samples != 12 || d.Verdict != metrics.VerdictLocal {
+		t.Fatalf("Diagnosis() = %+v, want 12 lost gateway samples blamed on the LAN", d)
+	}
+
+	var out bytes.Buffer
+	writeDiagnosis(&out, "192.0.2.1", "1.1.1.1", d)
+	want := "diagnosis: Wi-Fi/LAN problem (gateway 192.0.2.1: 100% loss; 1.1.1.1: 0.0% loss, p95 20.0ms)\n"
+	if out.String() != want {
+		t.Fatalf("writeDiagnosis() = %q, want %q", out.String(), want)
+	}
+}
+
+func TestCheckThresholds(t *testing.T) {
+	cfg := config.DefaultConfig()
+	stats := metrics.Stats{
+		TotalSuccess: 10,
+		LossPercent:  4,
+		Percentiles:  metrics.Percentiles{P95: 80},
+	}
+
+	if err := checkThresholds(cfg, stats); err != nil {
+		t.Fatalf("expected no error with thresholds disabled, got %v", err)
+	}
+
+	cfg.FailOnLoss = 5
+	cfg.FailOnP95 = 100 * time.Millisecond
+	if err := checkThresholds(cfg, stats); err != nil {
+		t.Fatalf("expected pass within thresholds, got %v", err)
+	}
+
+	cfg.FailOnLoss = 0
+	cfg.FailOnP95 = 50 * time.Millisecond
+	err := checkThresholds(cfg, stats)
+	if !errors.Is(err, ErrThresholdExceeded) {
+		t.Fatalf("expected ErrThresholdExceeded, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "loss 4.0%") || !strings.Contains(err.Error(), "p95 80.0ms") {
+		t.Fatalf("expected both violations in error, got %v", err)
+	}
+}
+
+func TestRunLogsComponentFailure(t *testing.T) {
+	errRunner := errors.New("runner failed")
+	app := newTestApp(&stubRunner{err: errRunner}, nil, nil, &stubProgram{block: make(chan struct{})})
+	app.config.Output = "json"
+	app.config.LogFile = filepath.Join(t.TempDir(), "pingheat.log")
+	app.stdout = io.Discard
+	var errOut bytes.Buffer
+	app.stderr = &errOut
+
+	if err := app.Run(); !errors.Is(err, errRunner) {
+		t.Fatalf("expected runner error, got %v", err)
+	}
+	data, err := os.ReadFile(app.config.LogFile)
+	if err != nil {
+		t.Fatalf("ReadFile() error: %v", err)
+	}
+	if !strings.Contains(string(data), `level=ERROR msg="component failed" component="ping runner" error="runner failed"`) {
+		t.Fatalf("log file = %q", data)
+	}
+	// The file replaces stderr as the log destination
+	if errOut.Len() != 0 {
+		t.Fatalf("unexpected stderr output: %q", errOut.String())
+	}
+}
+
+func TestRunHeadlessStreamsSamples(t *testing.T) {
+	runner := &sampleRunner{samples: []ping.Sample{
+		{Sequence: 1, RTT: 10 * time.Millisecond},
+		{Sequence: 2, Timeout: true},
+	}}
+	prog := &stubProgram{block: make(chan struct{})}
+	app := newTestApp(runner, nil, nil, prog)
+	app.uiSamples = make(chan ping.Sample, 10)
+	app.config.Output = "csv"
+	app.config.Count = 2
+	var out, errOut bytes.Buffer
+	app.stdout = &out
+	app.stderr = &errOut
+
+	if err := app.Run(); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if prog.quitCalled {
+		t.Fatalf("expected TUI not to run in headless mode")
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || lines[0] != "timestamp,seq,rtt_ms,timeout" {
+		t.Fatalf("unexpected CSV output: %q", out.String())
+	}
+	if !strings.Contains(errOut.String(), "2 samples, 1 received") {
+		t.Fatalf("expected summary on stderr, got %q", errOut.String())
+	}
+}
+
+// stalledWriter blocks writes until release is closed, like a pipe whose
+// reader is busy.
+type stalledWriter struct {
+	release chan struct{}
+	bytes.Buffer
+}
+
+func (w *stalledWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return w.Buffer.Write(p)
+}
+
+func TestRunHeadlessSlowReaderGetsEverySample(t *testing.T) {
+	const n = 3 * uiBufferSize
+	runner := &sampleRunner{}
+	for seq := 1; seq <= n; seq++ {
+		runner.samples = append(runner.samples, ping.Sample{Sequence: seq, RTT: time.Millisecond})
+	}
+	app := newTestApp(runner, nil, nil, &stubProgram{})
+	app.config.Output = "json"
+	app.config.Count = n
+	out := &stalledWriter{release: make(chan struct{})}
+	app.stdout = out
+	app.stderr = io.Discard
+	time.AfterFunc(100*time.Millisecond, func() { close(out.release) })
+
+	if err := app.Run(); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if lines := strings.Count(out.String(), "\n"); lines != n {
+		t.Fatalf("wrote %d lines, want one per sample (%d)", lines, n)
+	}
+}
+
+func TestMaintenanceExcludedFromSLA(t *testing.T) {
+	runner := &sampleRunner{samples: []ping.Sample{
+		{Sequence: 1, Timestamp: time.Date(2024, 1, 2, 2, 30, 0, 0, time.UTC), Timeout: true},
+		{Sequence: 2, Timestamp: time.Date(2024, 1, 2, 5, 0, 0, 0, time.UTC), RTT: 10 * time.Millisecond},
+	}}
+	app := newTestApp(runner, nil, nil, &stubProgram{})
+	app.uiSamples = make(chan ping.Sample, 10)
+	app.config.Output = "json"
+	app.config.Count = 2
+	app.config.UTC = true
+	app.config.Maintenance = []string{"02:00-04:00"}
+	var out bytes.Buffer
+	app.stdout = &out
+	app.stderr = io.Discard
+
+	if err := app.Run(); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"maintenance":true`) || strings.Contains(lines[1], "maintenance") {
+		t.Fatalf("expected only the first sample marked as maintenance, got %q", out.String())
+	}
+	hours := app.sla.Hours()
+	if len(hours) != 1 || hours[0].AvailPercent != 100 {
+		t.Fatalf("SLA hours = %+v, want only the sample outside maintenance", hours)
+	}
+	if stats := app.engine.Stats(); stats.TotalTimeouts != 1 {
+		t.Fatalf("TotalTimeouts=%d, want the maintenance loss still recorded", stats.TotalTimeouts)
+	}
+}
+
+func TestProcessFeedsSLO(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Target = "example.com"
+	cfg.SLO = &alert.SLO{Objective: 99, Threshold: 100 * time.Millisecond, Window: time.Hour}
+	app := New(cfg)
+	processed := 0
+	now := time.Now()
+	app.process(ping.Sample{Sequence: 1, Timestamp: now, RTT: time.Millisecond}, &processed)
+	app.process(ping.Sample{Sequence: 2, Timestamp: now, RTT: time.Second}, &processed)
+
+	if s := app.slo.Status(now); s.Samples != 2 || s.Bad != 1 {
+		t.Fatalf("SLO samples/bad = %d/%d, want 2/1", s.Samples, s.Bad)
+	}
+	app.retarget("example.org")
+	if s := app.slo.Status(now); s.Samples != 0 {
+		t.Fatalf("expected the SLO to restart for the new target, got %d samples", s.Samples)
+	}
+}
+
+func TestBaselinesPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baselines.json")
+	var stored baselines.Store
+	stored.Put("example.com", metrics.Baseline{P50Ms: 80, P95Ms: 120, Samples: 60}, time.Now())
+	stored.Put("example.org", metrics.Baseline{P50Ms: 30, P95Ms: 45, Samples: 60}, time.Now())
+	if err := baselines.Save(path, stored); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Target = "example.com"
+	cfg.Calibrate = 3
+	cfg.BaselinesPath = path
+	app := New(cfg)
+	app.target = cfg.Target
+	app.loadBaselines()
+	if b := app.baseline.Baseline(); b.P50Ms != 80 {
+		t.Fatalf("baseline on start = %+v, want the stored p50 of 80ms", b)
+	}
+
+	processed := 0
+	for i := range 3 {
+		app.process(ping.Sample{Sequence: i, Timestamp: time.Now(), RTT: 20 * time.Millisecond}, &processed)
+	}
+	app.retarget("example.org")
+	if b := app.baseline.Baseline(); b.P50Ms != 30 {
+		t.Fatalf("baseline after switching targets = %+v, want the stored p50 of 30ms", b)
+	}
+	app.saveBaselines()
+
+	saved, err := baselines.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := saved.Get("example.com"); b.P50Ms != 20 || b.Samples != 3 {
+		t.Fatalf("saved example.com baseline = %+v, want the p50 of 20ms learned from 3 replies", b)
+	}
+	if b := saved.Get("example.org"); b.P50Ms != 30 {
+		t.Fatalf("saved example.org baseline = %+v, want the stored one kept while still calibrating", b)
+	}
+}
+
+func TestSaveBaselinesKeepsUnreadableFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baselines.json")
+	const corrupt = "{\"targets\": {\"example.org\""
+	if err := os.WriteFile(path, []byte(corrupt), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Target = "example.com"
+	cfg.Calibrate = 3
+	cfg.BaselinesPath = path
+	app := New(cfg)
+	app.target = cfg.Target
+	processed := 0
+	for i := range 3 {
+		app.process(ping.Sample{Sequence: i, Timestamp: time.Now(), RTT: 20 * time.Millisecond}, &processed)
+	}
+	app.saveBaselines()
+
+	if data, err := os.ReadFile(path); err != nil || string(data) != corrupt {
+		t.Fatalf("baselines file = %q, %v, want it left as it was", data, err)
+	}
+}
+
+func TestProcessCountsDroppedUISamples(t *testing.T) {
+	app := newTestApp(&stubRunner{}, nil, nil, &stubProgram{})
+	processed := 0
+	for i := range 3 {
+		app.process(ping.Sample{Sequence: i, Timestamp: time.Now(), RTT: time.Millisecond}, &processed)
+	}
+
+	if n := app.processedTotal.Load(); n != 3 {
+		t.Fatalf("processedTotal=%d, want 3", n)
+	}
+	// The UI channel holds one sample; nobody reads it
+	if n := app.uiDropped.Load(); n != 2 {
+		t.Fatalf("uiDropped=%d, want 2", n)
+	}
+	if stats := app.stats(); stats.DroppedUISamples != 2 || stats.DroppedStats != 2 {
+		t.Fatalf("stats drops = %d ui, %d stats; want 2 and 2", stats.DroppedUISamples, stats.DroppedStats)
+	}
+}
+
+func TestRunReturnsHistoryFileError(t *testing.T) {
+	app := newTestApp(&stubRunner{}, nil, nil, &stubProgram{})
+	app.config.HistoryFile = filepath.Join(t.TempDir(), "missing", "history")
+
+	if err := app.Run(); err == nil || !strings.Contains(err.Error(), "history file") {
+		t.Fatalf("expected history file error, got %v", err)
+	}
+}
+
+func TestRunReturnsNotifierError(t *testing.T) {
+	app := newTestApp(&stubRunner{}, nil, nil, &stubProgram{})
+	app.slo = alert.NewTracker(alert.SLO{Objective: 99, Threshold: 100 * time.Millisecond, Window: time.Hour})
+	app.config.Notify = []string{"slack:not-a-url"}
+
+	if err := app.Run(); !errors.Is(err, alert.ErrInvalidNotifier) {
+		t.Fatalf("expected ErrInvalidNotifier, got %v", err)
+	}
+}
+
+// parserRunner records the custom parser it is given.
+type parserRunner struct {
+	stubRunner
+	parser parser.Parser
+}
+
+func (r *parserRunner) SetParser(p parser.Parser) { r.parser = p }
+
+func TestRunAppliesCustomParser(t *testing.T) {
+	r := &parserRunner{}
+	app := newTestApp(r, nil, nil, &stubProgram{block: make(chan struct{})})
+	app.config.Duration = 20 * time.Millisecond
+	app.config.Parser = &parser.Spec{Reply: `rtt (?P<time>[0-9.]+)`}
+	app.stdout = io.Discard
+
+	if err := app.Run(); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if _, ok := r.parser.(*parser.Regex); !ok {
+		t.Fatalf("runner parser = %T, want *parser.Regex", r.parser)
+	}
+
+	app = newTestApp(&stubRunner{}, nil, nil, &stubProgram{})
+	app.config.Parser = &parser.Spec{Reply: `rtt ([0-9.]+)`}
+	if err := app.Run(); !errors.Is(err, parser.ErrInvalidSpec) {
+		t.Fatalf("expected ErrInvalidSpec, got %v", err)
+	}
+}
+
+func TestSwitchTarget(t *testing.T) {
+	exp := &targetExporter{}
+	app := newTestApp(&sampleRunner{samples: []ping.Sample{
+		{Sequence: 1, RTT: 10 * time.Millisecond},
+		{Sequence: 2, RTT: 10 * time.Millisecond},
+	}}, exp, nil, nil)
+	app.target = "1.1.1.1"
+	app.switches = make(chan targetSwitch, 1)
+	app.resets = make(chan targetSwitch)
+	app.samples = make(chan ping.Sample, 10)
+	app.uiSamples = make(chan ping.Sample, 10)
+	app.metricsOut = make(chan metrics.Stats, 10)
+
+	var mu sync.Mutex
+	var started []string
+	app.newRunner = func(target string, _ time.Duration) runner {
+		mu.Lock()
+		defer mu.Unlock()
+		started = append(started, target)
+		return &sampleRunner{samples: []ping.Sample{{Sequence: 1, Timeout: true}}}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go app.runProbes(ctx)
+	go app.distribute()
+
+	waitFor := func(cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for condition")
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	waitFor(func() bool { return app.engine.Stats().TotalSamples == 2 })
+
+	if err := app.SwitchTarget("not a host!"); !errors.Is(err, config.ErrInvalidTarget) {
+		t.Fatalf("SwitchTarget(invalid) error = %v, want %v", err, config.ErrInvalidTarget)
+	}
+	if err := app.SwitchTarget("8.8.8.8"); err != nil {
+		t.Fatalf("SwitchTarget() error = %v", err)
+	}
+
+	// Statistics restart with the new target's samples only
+	waitFor(func() bool {
+		st := app.engine.Stats()
+		return app.currentTarget() == "8.8.8.8" && st.TotalSamples == 1 && st.TotalTimeouts == 1
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(started) != 1 || started[0] != "8.8.8.8" {
+		t.Fatalf("started runners = %v, want [8.8.8.8]", started)
+	}
+	exp.mu.Lock()
+	defer exp.mu.Unlock()
+	if len(exp.targets) != 1 || exp.targets[0] != "8.8.8.8" {
+		t.Fatalf("exporter targets = %v, want [8.8.8.8]", exp.targets)
+	}
+}
+
+func TestSetInterval(t *testing.T) {
+	exp := &targetExporter{}
+	app := newTestApp(&sampleRunner{samples: []ping.Sample{
+		{Sequence: 1, RTT: 10 * time.Millisecond},
+		{Sequence: 2, RTT: 10 * time.Millisecond},
+	}}, exp, nil, nil)
+	app.target = "1.1.1.1"
+	app.switches = make(chan targetSwitch, 1)
+	app.resets = make(chan targetSwitch)
+	app.samples = make(chan ping.Sample, 10)
+	app.uiSamples = make(chan ping.Sample, 10)
+	app.metricsOut = make(chan metrics.Stats, 10)
+
+	started := make(chan string, 1)
+	app.newRunner = func(target string, interval time.Duration) runner {
+		started <- fmt.Sprintf("%s every %v", target, interval)
+		return &sampleRunner{samples: []ping.Sample{{Sequence: 1, Timeout: true}}}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go app.runProbes(ctx)
+	go app.distribute()
+
+	waitFor := func(n int) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for app.engine.Stats().TotalSamples != n {
+			if time.Now().After(deadline) {
+				t.Fatalf("samples = %d, want %d", app.engine.Stats().TotalSamples, n)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	waitFor(2)
+
+	if err := app.SetInterval(time.Millisecond); err == nil {
+		t.Fatalf("expected an interval below %v to be rejected", config.MinInterval)
+	}
+	if err := app.SetInterval(500 * time.Millisecond); err != nil {
+		t.Fatalf("SetInterval() error = %v", err)
+	}
+	select {
+	case got := <-started:
+		if got != "1.1.1.1 every 500ms" {
+			t.Fatalf("started %q, want 1.1.1.1 every 500ms", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for the new runner")
+	}
+	exp.mu.Lock()
+	intervals := exp.intervals
+	exp.mu.Unlock()
+	if !slices.Equal(intervals, []time.Duration{500 * time.Millisecond}) {
+		t.Fatalf("exporter intervals = %v, want the new 500ms", intervals)
+	}
+
+	// Statistics carry on across the change
+	waitFor(3)
+}
+
+func TestPingRunnersStrictTiming(t *testing.T) {
+	cfg := config.DefaultConfig()
+	if _, ok := pingRunners(cfg)("example.com", time.Second).(*ping.Scheduler); ok {
+		t.Fatalf("expected the platform prober by default")
+	}
+	cfg.StrictTiming = true
+	if _, ok := pingRunners(cfg)("example.com", time.Second).(*ping.Scheduler); !ok {
+		t.Fatalf("expected a scheduler with StrictTiming")
+	}
+	cfg.StrictTiming = false
+	cfg.Burst = 3
+	if _, ok := pingRunners(cfg)("example.com", time.Second).(*ping.Scheduler); !ok {
+		t.Fatalf("expected a scheduler with Burst")
+	}
+	cfg.Burst = 0
+	cfg.Mode = ping.ModeUDP
+	if _, ok := pingRunners(cfg)("example.com", time.Second).(*ping.Scheduler); !ok {
+		t.Fatalf("expected a scheduler in UDP mode")
+	}
+	cfg.Mode = "exec:probe --site hq"
+	if _, ok := pingRunners(cfg)("example.com", time.Second).(*ping.Exec); !ok {
+		t.Fatalf("expected a plugin in exec mode")
+	}
+	cfg.AdaptiveInterval = 200 * time.Millisecond
+	if _, ok := pingRunners(cfg)("example.com", time.Second).(*ping.Adaptive); !ok {
+		t.Fatalf("expected an adaptive runner with AdaptiveInterval")
+	}
+}
@@ -0,0 +1,81 @@
+package app
+
+import (
+	"time"
+
+	"github.com/pbv7/pingheat/internal/baselines"
+)
+
+// loadBaselines reads the baselines of earlier runs and seeds the delta
+// view with the target's, unless -baseline gives one. A file that cannot be
+// read is logged and ignored.
+func (a *App) loadBaselines() {
+	if a.config.BaselinesPath == "" {
+		return
+	}
+	stored, err := baselines.Load(a.config.BaselinesPath)
+	if err != nil {
+		a.logger.Warn("ignoring stored baselines", "path", a.config.BaselinesPath, "error", err)
+	}
+	a.mu.Lock()
+	a.stored = stored
+	a.mu.Unlock()
+	if a.config.Baseline.IsZero() {
+		a.seedBaseline(a.config.Target)
+	}
+}
+
+// seedBaseline starts the delta view of target from its stored baseline,
+// if there is one, while this run calibrates.
+func (a *App) seedBaseline(target string) {
+	a.mu.Lock()
+	b := a.stored.Get(target)
+	a.mu.Unlock()
+	if !b.IsZero() {
+		a.baseline.Seed(b)
+		a.logger.Info("using stored baseline", "target", target, "p50_ms", b.P50Ms, "p95_ms", b.P95Ms)
+	}
+}
+
+// rememberBaseline keeps the baseline learned for target this run, to be
+// saved on exit.
+func (a *App) rememberBaseline(target string) {
+	b, ok := a.baseline.Learned()
+	if !ok || a.config.BaselinesPath == "" {
+		return
+	}
+	now := time.Now()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.stored.Put(target, b, now)
+	a.learned.Put(target, b, now)
+}
+
+// saveBaselines adds the baselines learned this run to the file, best
+// effort: failures are only logged. The file is read again first so runs
+// probing other targets meanwhile keep theirs; if it cannot be read, it is
+// left alone rather than replaced with this run's baselines only.
+func (a *App) saveBaselines() {
+	if a.config.BaselinesPath == "" {
+		return
+	}
+	a.rememberBaseline(a.currentTarget())
+	a.mu.Lock()
+	learned := a.learned
+	a.mu.Unlock()
+	if len(learned.Targets) == 0 {
+		return
+	}
+
+	saved, err := baselines.Load(a.config.BaselinesPath)
+	if err != nil {
+		a.logger.Warn("not saving baselines", "path", a.config.BaselinesPath, "error", err)
+		return
+	}
+	for target, e := range learned.Targets {
+		saved.Put(target, e.Baseline(), e.Updated)
+	}
+	if err := baselines.Save(a.config.BaselinesPath, saved); err != nil {
+		a.logger.Warn("saving baselines failed", "path", a.config.BaselinesPath, "error", err)
+	}
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/ping"
+)
+
+// sessionSamples is the length of the synthetic session BenchmarkSession
+// processes per iteration.
+const sessionSamples = 1_000_000
+
+// syntheticSession returns n samples taken 100ms apart with about 1% loss
+// and a long-tailed RTT, the same on every call.
+func syntheticSession(n int) []ping.Sample {
+	r := rand.New(rand.NewPCG(1, 2))
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	samples := make([]ping.Sample, n)
+	for i := range samples {
+		samples[i] = ping.Sample{
+			Sequence:  i + 1,
+			Timestamp: start.Add(time.Duration(i) * 100 * time.Millisecond),
+			Interval:  100 * time.Millisecond,
+		}
+		if r.IntN(100) == 0 {
+			samples[i].Timeout = true
+		} else {
+			samples[i].RTT = time.Duration((15 + r.ExpFloat64()*5) * float64(time.Millisecond))
+		}
+	}
+	return samples
+}
+
+// BenchmarkSession runs a 1M-sample session through the processing path
+// with the default statistics settings; make profile records its CPU and
+// allocation profiles.
+func BenchmarkSession(b *testing.B) {
+	samples := syntheticSession(sessionSamples)
+	for b.Loop() {
+		app := newTestApp(nil, nil, nil, nil)
+		app.engine.SetMinRecompute(statsRecompute)
+		processed := 0
+		for _, sample := range samples {
+			app.process(sample, &processed)
+		}
+	}
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*sessionSamples), "ns/sample")
+}
@@ -0,0 +1,199 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pbv7/pingheat/internal/config"
+	"github.com/pbv7/pingheat/internal/output"
+	"github.com/pbv7/pingheat/pkg/version"
+)
+
+// crashSamples is how many recent samples a crash dump includes.
+const crashSamples = 100
+
+// redacted replaces secrets in the configuration of a crash dump.
+const redacted = "[redacted]"
+
+// CrashError reports a panic recovered by the app. The session ended as if
+// a component had failed, so the TUI restored the terminal on its way out.
+type CrashError struct {
+	Component string // Goroutine that panicked, "ui" for the TUI
+	Value     any    // Value passed to panic
+	Report    string // Path of the crash dump ("" if it could not be written)
+}
+
+func (e *CrashError) Error() string {
+	return fmt.Sprintf("panic in %s: %v", e.Component, e.Value)
+}
+
+// recoverPanic is deferred at the top of the app's goroutines. It turns a
+// panic into a CrashError that ends the session through the error channel,
+// instead of killing the process with the terminal still in alt-screen mode.
+func (a *App) recoverPanic(component string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	err := a.crashed(component, r, debug.Stack())
+	select {
+	case a.errors <- err:
+	default:
+	}
+	if a.stop != nil {
+		a.stop()
+	}
+}
+
+// crashed logs a recovered panic, writes a crash dump and returns the error
+// reporting both.
+func (a *App) crashed(component string, value any, stack []byte) *CrashError {
+	a.logger.Error("panic", "component", component, "panic", fmt.Sprint(value))
+	crash := &CrashError{Component: component, Value: value}
+	dir := a.crashDir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	f, err := os.CreateTemp(dir, "pingheat-crash-*.txt")
+	if err != nil {
+		return crash
+	}
+	defer func() { _ = f.Close() }()
+	if a.writeCrashDump(f, crash, stack) == nil {
+		crash.Report = f.Name()
+	}
+	return crash
+}
+
+// writeCrashDump writes what is needed to diagnose a panic: the stack, the
+// configuration without secrets, and the most recent log records and samples.
+func (a *App) writeCrashDump(w io.Writer, crash *CrashError, stack []byte) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "pingheat crash report\n\n")
+	fmt.Fprintf(&b, "Time:      %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&b, "Version:   %s\n", version.Info())
+	fmt.Fprintf(&b, "Target:    %s\n", a.currentTarget())
+	fmt.Fprintf(&b, "Component: %s\n", crash.Component)
+	fmt.Fprintf(&b, "Panic:     %v\n\n", crash.Value)
+
+	b.WriteString("Stack:\n")
+	if len(stack) == 0 {
+		b.WriteString("(not captured; see the trace printed when the UI exited)\n")
+	}
+	b.Write(stack)
+
+	fmt.Fprintf(&b, "\nConfig:\n%+v\n", redactConfig(a.config))
+
+	if a.logs != nil {
+		b.WriteString("\nRecent log:\n")
+		for _, e := range a.logs.Entries() {
+			b.WriteString(e.String() + "\n")
+		}
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return err
+	}
+
+	if a.recent == nil {
+		return nil
+	}
+	if _, err := io.WriteString(w, "\nRecent samples (JSON Lines):\n"); err != nil {
+		return err
+	}
+	out, err := output.NewWriter(w, output.FormatJSON)
+	if err != nil {
+		return err
+	}
+	for _, s := range a.recent.All() {
+		if err := out.Write(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// redactConfig returns cfg with credentials removed: passwords in URLs,
+// tokens, notifier destinations and the SMTP password.
+func redactConfig(cfg config.Config) config.Config {
+	cfg.RemoteWriteURL = redactURL(cfg.RemoteWriteURL)
+	cfg.PushURL = redactURL(cfg.PushURL)
+	if cfg.RemoteWriteToken != "" {
+		cfg.RemoteWriteToken = redacted
+	}
+	if cfg.FleetToken != "" {
+		cfg.FleetToken = redacted
+	}
+	if len(cfg.Notify) > 0 {
+		notify := make([]string, len(cfg.Notify))
+		for i, spec := range cfg.Notify {
+			kind, _, _ := strings.Cut(spec, ":")
+			notify[i] = kind + ":" + redacted
+		}
+		cfg.Notify = notify
+	}
+	if cfg.Email != nil {
+		email := *cfg.Email
+		if email.Password != "" {
+			email.Password = redacted
+		}
+		cfg.Email = &email
+	}
+	return cfg
+}
+
+// redactURL hides the password of a URL; unparsable values are hidden whole.
+func redactURL(s string) string {
+	if s == "" {
+		return ""
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return redacted
+	}
+	return u.Redacted()
+}
+
+// uiPanic is a panic recorded by guardedModel.
+type uiPanic struct {
+	value any
+	stack []byte
+}
+
+// guardedModel records the stack of a panic in the TUI model before Bubble
+// Tea recovers it, restores the terminal and returns tea.ErrProgramPanic,
+// which does not carry the panic itself.
+type guardedModel struct {
+	tea.Model
+	panicked *atomic.Pointer[uiPanic]
+}
+
+func (m guardedModel) Init() tea.Cmd {
+	defer m.record()
+	return m.Model.Init()
+}
+
+func (m guardedModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	defer m.record()
+	next, cmd := m.Model.Update(msg)
+	return guardedModel{Model: next, panicked: m.panicked}, cmd
+}
+
+func (m guardedModel) View() string {
+	defer m.record()
+	return m.Model.View()
+}
+
+// record keeps the first panic and lets it continue to Bubble Tea.
+func (m guardedModel) record() {
+	if r := recover(); r != nil {
+		m.panicked.CompareAndSwap(nil, &uiPanic{value: r, stack: debug.Stack()})
+		panic(r)
+	}
+}
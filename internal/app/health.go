@@ -0,0 +1,57 @@
+package app
+
+import (
+	"runtime"
+	rtmetrics "runtime/metrics"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/metrics"
+)
+
+// heapObjectsMetric is the runtime metric of heap memory occupied by objects.
+// Unlike runtime.ReadMemStats, reading it does not stop the world.
+const heapObjectsMetric = "/memory/classes/heap/objects:bytes"
+
+// health reports the app's own state for the exporter and the TUI debug
+// panel.
+func (a *App) health() metrics.Health {
+	heap := []rtmetrics.Sample{{Name: heapObjectsMetric}}
+	rtmetrics.Read(heap)
+	var heapBytes uint64
+	if heap[0].Value.Kind() == rtmetrics.KindUint64 {
+		heapBytes = heap[0].Value.Uint64()
+	}
+
+	channels := []metrics.ChannelDepth{
+		{Name: "samples", Len: len(a.samples), Cap: cap(a.samples)},
+		{Name: "ui", Len: len(a.uiSamples), Cap: cap(a.uiSamples)},
+		{Name: "stats", Len: len(a.metricsOut), Cap: cap(a.metricsOut)},
+	}
+	if a.output != nil {
+		channels = append(channels, metrics.ChannelDepth{Name: "output", Len: len(a.output), Cap: cap(a.output)})
+	}
+
+	return metrics.Health{
+		Goroutines:       runtime.NumGoroutine(),
+		HeapBytes:        heapBytes,
+		ProcessedSamples: a.processedTotal.Load(),
+		ProcessingTime:   time.Duration(a.processingNanos.Load()),
+		ProcessingMax:    time.Duration(a.processingMax.Load()),
+		Channels:         channels,
+		DroppedUISamples: int(a.uiDropped.Load()),
+		DroppedStats:     int(a.statsDropped.Load()),
+	}
+}
+
+// observeProcessing records the time spent distributing one sample since
+// start.
+func (a *App) observeProcessing(start time.Time) {
+	d := int64(time.Since(start))
+	a.processingNanos.Add(d)
+	for {
+		prev := a.processingMax.Load()
+		if d <= prev || a.processingMax.CompareAndSwap(prev, d) {
+			return
+		}
+	}
+}
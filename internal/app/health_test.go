@@ -0,0 +1,32 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/ping"
+)
+
+func TestHealth(t *testing.T) {
+	app := newTestApp(&stubRunner{}, nil, nil, &stubProgram{})
+	app.uiSamples = make(chan ping.Sample, 4)
+	app.uiSamples <- ping.Sample{}
+	app.uiDropped.Add(2)
+
+	processed := 0
+	app.process(ping.Sample{Timestamp: time.Now()}, &processed)
+	h := app.health()
+
+	if h.Goroutines < 1 || h.HeapBytes == 0 {
+		t.Fatalf("runtime health = %d goroutines, %d heap bytes", h.Goroutines, h.HeapBytes)
+	}
+	if h.ProcessedSamples != 1 || h.ProcessingTime <= 0 || h.ProcessingMax != h.ProcessingTime {
+		t.Fatalf("processing = %d samples in %v (max %v)", h.ProcessedSamples, h.ProcessingTime, h.ProcessingMax)
+	}
+	if ui := h.Channels[1]; ui.Name != "ui" || ui.Len != 2 || ui.Cap != 4 {
+		t.Fatalf("ui channel = %+v, want 2 of 4 queued", ui)
+	}
+	if h.DroppedUISamples != 2 {
+		t.Fatalf("DroppedUISamples = %d, want 2", h.DroppedUISamples)
+	}
+}
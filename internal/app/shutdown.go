@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// shutdown coordinates the end of a session. Instead of cancelling every
+// goroutine at once, it tears the pipeline down in stages so nothing that
+// was already measured is lost:
+//
+//  1. the runners stop probing;
+//  2. the distributor processes the samples still buffered, feeding the
+//     stores, exporters and headless output, then closes the UI channels;
+//  3. the components are cancelled: pushers and remote write make a final
+//     push, alerts already queued are delivered, servers close;
+//  4. stores such as the history file and debug log are closed.
+//
+// All stages share one deadline so a hung component cannot keep the process
+// alive.
+type shutdown struct {
+	logger  *slog.Logger
+	timeout time.Duration
+
+	stopProbes     context.CancelFunc
+	stopComponents context.CancelFunc
+
+	pipeline   group // Probe loop and distributor
+	components group // Servers, pushers and notifiers
+	closers    []closer
+
+	once sync.Once
+}
+
+// closer releases a store once nothing writes to it anymore.
+type closer struct {
+	name  string
+	close func() error
+}
+
+func newShutdown(logger *slog.Logger, timeout time.Duration, stopProbes, stopComponents context.CancelFunc) *shutdown {
+	return &shutdown{
+		logger:         logger,
+		timeout:        timeout,
+		stopProbes:     stopProbes,
+		stopComponents: stopComponents,
+	}
+}
+
+// onClose registers a store to close in the last stage, in reverse order of
+// registration.
+func (s *shutdown) onClose(name string, close func() error) {
+	s.closers = append(s.closers, closer{name: name, close: close})
+}
+
+// run tears the session down. Only the first call has any effect.
+func (s *shutdown) run() {
+	s.once.Do(func() {
+		deadline := time.Now().Add(s.timeout)
+
+		s.stopProbes()
+		s.wait(&s.pipeline, deadline)
+
+		s.stopComponents()
+		s.wait(&s.components, deadline)
+
+		for _, c := range slices.Backward(s.closers) {
+			if err := c.close(); err != nil {
+				s.logger.Warn("closing failed", "store", c.name, "error", err)
+			}
+		}
+	})
+}
+
+// wait blocks until every goroutine of g returned or the deadline passed.
+func (s *shutdown) wait(g *group, deadline time.Time) {
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		s.logger.Warn("shutdown deadline passed, abandoning components", "components", g.pending(), "timeout", s.timeout)
+	}
+}
+
+// group tracks named goroutines.
+type group struct {
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	running map[string]int
+}
+
+// Go runs fn in a goroutine tracked under name.
+func (g *group) Go(name string, fn func()) {
+	g.mu.Lock()
+	if g.running == nil {
+		g.running = make(map[string]int)
+	}
+	g.running[name]++
+	g.mu.Unlock()
+
+	g.wg.Go(func() {
+		defer func() {
+			g.mu.Lock()
+			g.running[name]--
+			g.mu.Unlock()
+		}()
+		fn()
+	})
+}
+
+// pending returns the names of goroutines still running, sorted.
+func (g *group) pending() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var names []string
+	for name, n := range g.running {
+		if n > 0 {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
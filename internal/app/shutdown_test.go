@@ -0,0 +1,108 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/log"
+	"github.com/pbv7/pingheat/internal/ping"
+)
+
+// burstRunner queues samples at once, reports it, then idles until cancelled.
+type burstRunner struct {
+	n    int
+	sent chan struct{}
+}
+
+func (r *burstRunner) Run(ctx context.Context, samples chan<- ping.Sample) error {
+	for i := range r.n {
+		samples <- ping.Sample{Sequence: i, RTT: time.Millisecond}
+	}
+	close(r.sent)
+	<-ctx.Done()
+	return nil
+}
+
+func TestShutdownOrder(t *testing.T) {
+	components, stopComponents := context.WithCancel(context.Background())
+	probes, stopProbes := context.WithCancel(components)
+	sd := newShutdown(log.Discard(), time.Second, stopProbes, stopComponents)
+
+	var mu sync.Mutex
+	var steps []string
+	step := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		steps = append(steps, name)
+	}
+	sd.pipeline.Go("distributor", func() {
+		<-probes.Done()
+		time.Sleep(10 * time.Millisecond) // Draining buffered samples
+		step("pipeline drained")
+	})
+	sd.components.Go("exporter", func() {
+		<-components.Done()
+		step("final push")
+	})
+	sd.onClose("history", func() error { step("history closed"); return nil })
+	sd.onClose("debug log", func() error { step("debug log closed"); return nil })
+
+	sd.run()
+	sd.run() // Only the first call tears down
+
+	want := []string{"pipeline drained", "final push", "debug log closed", "history closed"}
+	if !slices.Equal(steps, want) {
+		t.Fatalf("shutdown steps = %v, want %v", steps, want)
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	sd := newShutdown(log.Discard(), 20*time.Millisecond, func() {}, func() {})
+	hang := make(chan struct{})
+	defer close(hang)
+	sd.components.Go("web UI", func() { <-hang })
+	closed := false
+	sd.onClose("history", func() error { closed = true; return errors.New("ignored") })
+
+	start := time.Now()
+	sd.run()
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("shutdown took %v despite the deadline", elapsed)
+	}
+	if got := sd.components.pending(); !slices.Equal(got, []string{"web UI"}) {
+		t.Fatalf("pending = %v, want [web UI]", got)
+	}
+	if !closed {
+		t.Fatalf("stores must be closed even when a component hangs")
+	}
+}
+
+func TestRunFlushesBufferedSamplesOnStop(t *testing.T) {
+	runner := &burstRunner{n: 50, sent: make(chan struct{})}
+	app := newTestApp(runner, nil, nil, &stubProgram{})
+	app.samples = make(chan ping.Sample, 50)
+	app.uiSamples = make(chan ping.Sample, 50)
+	app.config.Output = "json"
+	var out bytes.Buffer
+	app.stdout = &out
+
+	// Stop as soon as the samples are queued, as SIGTERM would
+	go func() {
+		<-runner.sent
+		app.stop()
+	}()
+	if err := app.Run(); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	if n := strings.Count(out.String(), "\n"); n != 50 {
+		t.Fatalf("wrote %d samples, want all 50 queued before the stop", n)
+	}
+}
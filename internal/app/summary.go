@@ -0,0 +1,81 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/config"
+	"github.com/pbv7/pingheat/internal/metrics"
+)
+
+// ErrThresholdExceeded is returned by Run when a -fail-on-* threshold was
+// exceeded at the end of a limited session.
+var ErrThresholdExceeded = errors.New("threshold exceeded")
+
+// writeSummary prints a ping-style final report for a finished session.
+func writeSummary(w io.Writer, target string, stats metrics.Stats) {
+	elapsed := time.Duration(stats.UptimeSeconds * float64(time.Second)).Round(time.Millisecond)
+
+	fmt.Fprintf(w, "--- %s pingheat statistics ---\n", target)
+	fmt.Fprintf(w, "%d samples, %d received, %.1f%% loss, time %v\n",
+		stats.TotalSamples, stats.TotalSuccess, stats.LossPercent, elapsed)
+
+	if stats.TotalSuccess > 0 {
+		fmt.Fprintf(w, "rtt min/avg/max/stddev = %.3f/%.3f/%.3f/%.3f ms, jitter %.3f ms\n",
+			stats.MinRTTMs, stats.AvgRTTMs, stats.MaxRTTMs, stats.StdDevMs, stats.JitterMs)
+		fmt.Fprintf(w, "rtt p50/p90/p95/p99 = %.3f/%.3f/%.3f/%.3f ms\n",
+			stats.Percentiles.P50, stats.Percentiles.P90, stats.Percentiles.P95, stats.Percentiles.P99)
+	}
+
+	fmt.Fprintf(w, "outages %d, longest drop %d, brownouts %d\n",
+		stats.LossBursts, stats.LongestTimeout, stats.BrownoutBursts)
+	if stats.MTBF > 0 || stats.MTTR > 0 {
+		fmt.Fprintf(w, "mtbf %v, mttr %v, downtime %v\n",
+			stats.MTBF.Round(time.Second), stats.MTTR.Round(time.Millisecond), stats.Downtime.Round(time.Millisecond))
+	}
+}
+
+// writeDiagnosis prints the -diagnose verdict over the last samples of the
+// gateway and the target.
+func writeDiagnosis(w io.Writer, gateway, target string, d metrics.Diagnosis) {
+	fmt.Fprintf(w, "diagnosis: %s (gateway %s: %s; %s: %s)\n",
+		d.Verdict, gateway, formatPath(d.Gateway), target, formatPath(d.Anchor))
+}
+
+// formatPath describes the recent loss and p95 of a diagnosed path.
+func formatPath(p metrics.PathStats) string {
+	if p.Samples == 0 {
+		return "no samples"
+	}
+	if p.LossPercent == 100 {
+		return "100% loss"
+	}
+	return fmt.Sprintf("%.1f%% loss, p95 %.1fms", p.LossPercent, p.P95Ms)
+}
+
+// checkThresholds compares final stats against the configured fail-on
+// thresholds and returns ErrThresholdExceeded describing every violation.
+func checkThresholds(cfg config.Config, stats metrics.Stats) error {
+	var violations []error
+
+	if cfg.FailOnLoss >= 0 && stats.LossPercent > cfg.FailOnLoss {
+		violations = append(violations, fmt.Errorf("loss %.1f%% > %.1f%%", stats.LossPercent, cfg.FailOnLoss))
+	}
+
+	if cfg.FailOnP95 > 0 {
+		limitMs := float64(cfg.FailOnP95.Microseconds()) / 1000.0
+		switch {
+		case stats.TotalSuccess == 0:
+			violations = append(violations, fmt.Errorf("p95 unavailable (no replies) with limit %v", cfg.FailOnP95))
+		case stats.Percentiles.P95 > limitMs:
+			violations = append(violations, fmt.Errorf("p95 %.1fms > %v", stats.Percentiles.P95, cfg.FailOnP95))
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrThresholdExceeded, errors.Join(violations...))
+}
@@ -0,0 +1,85 @@
+// Package baselines keeps the latency baselines learned for each target
+// across runs, so the delta view compares against a target's usual latency
+// from the first sample instead of calibrating again.
+package baselines
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/userfile"
+)
+
+// fileName is the baselines file name inside the pingheat data directory.
+const fileName = "baselines.json"
+
+// Entry is the baseline learned for a target and when it was learned.
+type Entry struct {
+	P50Ms   float64   `json:"p50_ms"`
+	P95Ms   float64   `json:"p95_ms"`
+	Samples int       `json:"samples"`
+	Updated time.Time `json:"updated"`
+}
+
+// Baseline returns e as a metrics.Baseline.
+func (e Entry) Baseline() metrics.Baseline {
+	return metrics.Baseline{P50Ms: e.P50Ms, P95Ms: e.P95Ms, Samples: e.Samples}
+}
+
+// Store holds the baselines by target.
+type Store struct {
+	Targets map[string]Entry `json:"targets"`
+}
+
+// Get returns the baseline stored for target, zero if there is none.
+func (s Store) Get(target string) metrics.Baseline {
+	return s.Targets[target].Baseline()
+}
+
+// Put stores b, learned at t, as the baseline of target.
+func (s *Store) Put(target string, b metrics.Baseline, t time.Time) {
+	if s.Targets == nil {
+		s.Targets = make(map[string]Entry)
+	}
+	s.Targets[target] = Entry{P50Ms: b.P50Ms, P95Ms: b.P95Ms, Samples: b.Samples, Updated: t.UTC()}
+}
+
+// DefaultPath returns the per-user baselines path
+// ($XDG_DATA_HOME/pingheat/baselines.json on Linux, the platform
+// equivalent elsewhere).
+func DefaultPath() (string, error) {
+	return userfile.DataPath(fileName)
+}
+
+// Load reads the baselines from path. A missing file yields an empty Store.
+func Load(path string) (Store, error) {
+	var s Store
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+
+	if err := json.Unmarshal(data, &s); err != nil {
+		return Store{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Save writes the baselines to path, creating the parent directory if
+// needed, without ever leaving a truncated file behind.
+func Save(path string, s Store) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return userfile.WriteAtomic(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,43 @@
+package baselines
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/metrics"
+)
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "baselines.json")
+	learned := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	var s Store
+	s.Put("8.8.8.8", metrics.Baseline{P50Ms: 12.5, P95Ms: 18, Samples: 60}, learned)
+
+	if err := Save(path, s); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if b := got.Get("8.8.8.8"); b != (metrics.Baseline{P50Ms: 12.5, P95Ms: 18, Samples: 60}) {
+		t.Fatalf("Get(8.8.8.8)=%+v, want the saved baseline", b)
+	}
+	if e := got.Targets["8.8.8.8"]; !e.Updated.Equal(learned) {
+		t.Fatalf("Updated=%v, want %v", e.Updated, learned)
+	}
+	if b := got.Get("1.1.1.1"); !b.IsZero() {
+		t.Fatalf("Get(1.1.1.1)=%+v, want zero for an unknown target", b)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	got, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(got.Targets) != 0 {
+		t.Fatalf("expected an empty store, got %+v", got)
+	}
+}
@@ -0,0 +1,22 @@
+package buffer
+
+import "testing"
+
+func BenchmarkRingBufferPush(b *testing.B) {
+	rb := NewRingBuffer[int](100000)
+	i := 0
+	for b.Loop() {
+		rb.Push(i)
+		i++
+	}
+}
+
+func BenchmarkRingBufferGetLastN(b *testing.B) {
+	rb := NewRingBuffer[int](100000)
+	for i := range 150000 {
+		rb.Push(i)
+	}
+	for b.Loop() {
+		_ = rb.GetLastN(3600)
+	}
+}
@@ -0,0 +1,44 @@
+package buffer
+
+import "time"
+
+// Buffer is a fixed-capacity history that overwrites its oldest items once
+// full. Indexes run from 0 (oldest) to Len()-1 (newest).
+type Buffer[T any] interface {
+	Push(item T)
+	PushBatch(items []T)
+	Len() int
+	Capacity() int
+	Get(index int) (T, bool)
+	GetLast() (T, bool)
+	GetRange(start, end int) []T
+	GetLastN(n int) []T
+	All() []T
+	Range(fn func(i int, v T) bool)
+	RangeFrom(start int, fn func(i int, v T) bool)
+	Search(pred func(v T) bool) int
+	Clear()
+}
+
+// FindByTime returns the index of the first item at or after t, or Len() when
+// every item is older. Items must be in time order; timeOf extracts the
+// timestamp. It binary-searches, so lookups stay fast on large histories.
+func FindByTime[T any](b Buffer[T], t time.Time, timeOf func(T) time.Time) int {
+	return b.Search(func(v T) bool { return !timeOf(v).Before(t) })
+}
+
+// RangeByTime calls fn for each item with a timestamp in [from, to), oldest
+// first, until fn returns false.
+func RangeByTime[T any](b Buffer[T], from, to time.Time, timeOf func(T) time.Time, fn func(i int, v T) bool) {
+	b.RangeFrom(FindByTime(b, from, timeOf), func(i int, v T) bool {
+		if !timeOf(v).Before(to) {
+			return false
+		}
+		return fn(i, v)
+	})
+}
+
+var (
+	_ Buffer[int] = (*RingBuffer[int])(nil)
+	_ Buffer[int] = (*MmapRing[int])(nil)
+)
@@ -0,0 +1,306 @@
+package buffer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+// ErrIncompatibleFile is returned when an existing history file was written
+// with a different capacity or record layout.
+var ErrIncompatibleFile = errors.New("incompatible history file")
+
+// Codec encodes items as fixed-size records for MmapRing.
+type Codec[T any] interface {
+	// Size returns the encoded size of every item in bytes.
+	Size() int
+	Encode(dst []byte, item T)
+	Decode(src []byte) T
+}
+
+// File layout: a 64-byte header followed by capacity records. All integers
+// are little-endian.
+//
+//	0  magic        8 bytes
+//	8  record size  uint32
+//	16 capacity     uint64
+//	24 head         uint64 (next write position)
+//	32 count        uint64
+const mmapHeaderSize = 64
+
+var mmapMagic = []byte("PHRING\x00\x01")
+
+// MmapRing is a ring buffer stored in a memory-mapped file. Items live in the
+// page cache rather than on the Go heap, and the history survives restarts
+// and crashes of the process. It is safe for concurrent use.
+type MmapRing[T any] struct {
+	mu       sync.RWMutex
+	file     *os.File
+	mem      []byte
+	codec    Codec[T]
+	size     int // Record size
+	head     int // next write position
+	count    int // number of elements
+	capacity int
+}
+
+// OpenMmap opens the history file at path, creating it for capacity items if
+// it does not exist. An existing file must have been created with the same
+// capacity and codec; its items are kept.
+func OpenMmap[T any](path string, capacity int, codec Codec[T]) (*MmapRing[T], error) {
+	if capacity <= 0 {
+		return nil, fmt.Errorf("history file capacity must be positive, got %d", capacity)
+	}
+	size := codec.Size()
+	total := mmapHeaderSize + capacity*size
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	fresh := info.Size() == 0
+	if fresh {
+		if err := f.Truncate(int64(total)); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+	} else if info.Size() != int64(total) {
+		_ = f.Close()
+		return nil, fmt.Errorf("%w %s: size %d bytes, want %d for %d items", ErrIncompatibleFile, path, info.Size(), total, capacity)
+	}
+
+	mem, err := mapFile(f, total)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("map %s: %w", path, err)
+	}
+
+	r := &MmapRing[T]{file: f, mem: mem, codec: codec, size: size, capacity: capacity}
+	if fresh {
+		copy(mem, mmapMagic)
+		binary.LittleEndian.PutUint32(mem[8:], uint32(size))
+		binary.LittleEndian.PutUint64(mem[16:], uint64(capacity))
+		r.writeHeader()
+		return r, nil
+	}
+
+	if err := r.readHeader(); err != nil {
+		_ = r.Close()
+		return nil, fmt.Errorf("%w %s: %v", ErrIncompatibleFile, path, err)
+	}
+	return r, nil
+}
+
+// readHeader validates the header of an existing file and loads the ring
+// position.
+func (r *MmapRing[T]) readHeader() error {
+	if !bytes.Equal(r.mem[:8], mmapMagic) {
+		return errors.New("not a pingheat history file")
+	}
+	if got := binary.LittleEndian.Uint32(r.mem[8:]); got != uint32(r.size) {
+		return fmt.Errorf("record size %d, want %d", got, r.size)
+	}
+	if got := binary.LittleEndian.Uint64(r.mem[16:]); got != uint64(r.capacity) {
+		return fmt.Errorf("capacity %d, want %d", got, r.capacity)
+	}
+	head := binary.LittleEndian.Uint64(r.mem[24:])
+	count := binary.LittleEndian.Uint64(r.mem[32:])
+	if head >= uint64(r.capacity) || count > uint64(r.capacity) {
+		return fmt.Errorf("corrupt header (head %d, count %d)", head, count)
+	}
+	r.head, r.count = int(head), int(count)
+	return nil
+}
+
+// writeHeader stores the ring position. Records are written before the
+// header, so a crash loses at most the item being pushed.
+func (r *MmapRing[T]) writeHeader() {
+	binary.LittleEndian.PutUint64(r.mem[24:], uint64(r.head))
+	binary.LittleEndian.PutUint64(r.mem[32:], uint64(r.count))
+}
+
+// record returns the bytes of the record at ring position pos.
+func (r *MmapRing[T]) record(pos int) []byte {
+	off := mmapHeaderSize + pos*r.size
+	return r.mem[off : off+r.size]
+}
+
+// at decodes the item at index (0 is oldest).
+func (r *MmapRing[T]) at(index int) T {
+	start := (r.head - r.count + r.capacity) % r.capacity
+	return r.codec.Decode(r.record((start + index) % r.capacity))
+}
+
+// push writes one item; callers hold the write lock.
+func (r *MmapRing[T]) push(item T) {
+	r.codec.Encode(r.record(r.head), item)
+	r.head = (r.head + 1) % r.capacity
+	if r.count < r.capacity {
+		r.count++
+	}
+}
+
+// Push adds an item to the buffer. If the buffer is full, the oldest item is overwritten.
+func (r *MmapRing[T]) Push(item T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.push(item)
+	r.writeHeader()
+}
+
+// PushBatch adds items in order, updating the header once. If more items
+// than the capacity are given, only the most recent ones are kept.
+func (r *MmapRing[T]) PushBatch(items []T) {
+	if len(items) > r.capacity {
+		items = items[len(items)-r.capacity:]
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, item := range items {
+		r.push(item)
+	}
+	r.writeHeader()
+}
+
+// Len returns the number of elements in the buffer.
+func (r *MmapRing[T]) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.count
+}
+
+// Capacity returns the maximum capacity of the buffer.
+func (r *MmapRing[T]) Capacity() int {
+	return r.capacity
+}
+
+// Get returns the item at the given index (0 is oldest).
+func (r *MmapRing[T]) Get(index int) (T, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var zero T
+	if index < 0 || index >= r.count {
+		return zero, false
+	}
+	return r.at(index), true
+}
+
+// GetLast returns the most recent item.
+func (r *MmapRing[T]) GetLast() (T, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var zero T
+	if r.count == 0 {
+		return zero, false
+	}
+	return r.at(r.count - 1), true
+}
+
+// GetRange returns items from start to end index (inclusive, 0 is oldest).
+func (r *MmapRing[T]) GetRange(start, end int) []T {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.getRange(start, end)
+}
+
+// GetLastN returns the last n items (most recent last).
+func (r *MmapRing[T]) GetLastN(n int) []T {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if n <= 0 {
+		return nil
+	}
+	return r.getRange(r.count-n, r.count-1)
+}
+
+// All returns all items in the buffer (oldest first).
+func (r *MmapRing[T]) All() []T {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.getRange(0, r.count-1)
+}
+
+// getRange decodes items start..end, clamped to the buffer; callers hold
+// the lock.
+func (r *MmapRing[T]) getRange(start, end int) []T {
+	start = max(start, 0)
+	end = min(end, r.count-1)
+	if start > end {
+		return nil
+	}
+
+	result := make([]T, end-start+1)
+	for i := range result {
+		result[i] = r.at(start + i)
+	}
+	return result
+}
+
+// Range calls fn for each item, oldest first, until fn returns false. Items
+// are decoded one at a time; fn must not modify the buffer.
+func (r *MmapRing[T]) Range(fn func(i int, v T) bool) {
+	r.RangeFrom(0, fn)
+}
+
+// RangeFrom is like Range but starts at index start.
+func (r *MmapRing[T]) RangeFrom(start int, fn func(i int, v T) bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for i := max(start, 0); i < r.count; i++ {
+		if !fn(i, r.at(i)) {
+			return
+		}
+	}
+}
+
+// Search returns the smallest index for which pred is true, or Len() if there
+// is none, like sort.Search.
+func (r *MmapRing[T]) Search(pred func(v T) bool) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return sort.Search(r.count, func(i int) bool { return pred(r.at(i)) })
+}
+
+// Clear removes all items from the buffer.
+func (r *MmapRing[T]) Clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.head = 0
+	r.count = 0
+	r.writeHeader()
+}
+
+// Sync flushes the mapped pages to disk so the history also survives a
+// system crash, not just a crash of the process.
+func (r *MmapRing[T]) Sync() error {
+	return r.file.Sync()
+}
+
+// Close flushes and unmaps the file. The buffer must not be used afterwards.
+func (r *MmapRing[T]) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := r.file.Sync()
+	if uerr := unmapFile(r.mem); err == nil {
+		err = uerr
+	}
+	r.mem = nil
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,19 @@
+//go:build !unix
+
+package buffer
+
+import (
+	"errors"
+	"os"
+)
+
+// errMmapUnsupported is returned where file-backed history is not implemented.
+var errMmapUnsupported = errors.New("file-backed history is not supported on this platform")
+
+func mapFile(*os.File, int) ([]byte, error) {
+	return nil, errMmapUnsupported
+}
+
+func unmapFile([]byte) error {
+	return nil
+}
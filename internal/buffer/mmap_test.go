@@ -0,0 +1,84 @@
+//go:build unix
+
+package buffer
+
+import (
+	"encoding/binary"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// intCodec stores ints as 8-byte records.
+type intCodec struct{}
+
+func (intCodec) Size() int                { return 8 }
+func (intCodec) Encode(dst []byte, v int) { binary.LittleEndian.PutUint64(dst, uint64(v)) }
+func (intCodec) Decode(src []byte) int    { return int(binary.LittleEndian.Uint64(src)) }
+
+func TestMmapRing_MatchesRingBuffer(t *testing.T) {
+	mm, err := OpenMmap[int](filepath.Join(t.TempDir(), "history"), 4, intCodec{})
+	if err != nil {
+		t.Fatalf("OpenMmap() error: %v", err)
+	}
+	defer mm.Close()
+	rb := NewRingBuffer[int](4)
+
+	for _, b := range []Buffer[int]{rb, mm} {
+		b.Push(1)
+		b.PushBatch([]int{2, 3, 4, 5, 6})
+		b.Push(7)
+	}
+
+	if got, want := mm.All(), rb.All(); !slices.Equal(got, want) {
+		t.Fatalf("All() = %v, want %v", got, want)
+	}
+	if got, want := mm.GetRange(1, 2), rb.GetRange(1, 2); !slices.Equal(got, want) {
+		t.Fatalf("GetRange(1, 2) = %v, want %v", got, want)
+	}
+	if got, want := mm.GetLastN(2), rb.GetLastN(2); !slices.Equal(got, want) {
+		t.Fatalf("GetLastN(2) = %v, want %v", got, want)
+	}
+	if v, ok := mm.GetLast(); !ok || v != 7 {
+		t.Fatalf("GetLast() = %d, %v; want 7, true", v, ok)
+	}
+	var ranged []int
+	mm.RangeFrom(2, func(i, v int) bool {
+		ranged = append(ranged, v)
+		return true
+	})
+	if !slices.Equal(ranged, []int{6, 7}) {
+		t.Fatalf("RangeFrom(2) visited %v, want [6 7]", ranged)
+	}
+
+	mm.Clear()
+	if mm.Len() != 0 {
+		t.Fatalf("Len() after Clear = %d, want 0", mm.Len())
+	}
+}
+
+func TestMmapRing_Persists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	mm, err := OpenMmap[int](path, 3, intCodec{})
+	if err != nil {
+		t.Fatalf("OpenMmap() error: %v", err)
+	}
+	mm.PushBatch([]int{1, 2, 3, 4})
+	if err := mm.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	mm, err = OpenMmap[int](path, 3, intCodec{})
+	if err != nil {
+		t.Fatalf("reopen error: %v", err)
+	}
+	defer mm.Close()
+	if got := mm.All(); !slices.Equal(got, []int{2, 3, 4}) {
+		t.Fatalf("All() after reopen = %v, want [2 3 4]", got)
+	}
+
+	if _, err := OpenMmap[int](path, 5, intCodec{}); !errors.Is(err, ErrIncompatibleFile) {
+		t.Fatalf("open with other capacity: expected ErrIncompatibleFile, got %v", err)
+	}
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package buffer
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile maps the first size bytes of f for reading and writing; changes
+// are written back to the file.
+func mapFile(f *os.File, size int) ([]byte, error) {
+	return syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+}
+
+// unmapFile releases a mapping created by mapFile.
+func unmapFile(mem []byte) error {
+	return syscall.Munmap(mem)
+}
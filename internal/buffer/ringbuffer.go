@@ -1,6 +1,9 @@
 package buffer
 
-import "sync"
+import (
+	"sort"
+	"sync"
+)
 
 // RingBuffer is a thread-safe generic circular buffer.
 type RingBuffer[T any] struct {
@@ -32,6 +35,27 @@ func (rb *RingBuffer[T]) Push(item T) {
 	}
 }
 
+// PushBatch adds items in order under a single lock. If more items than the
+// capacity are given, only the most recent ones are kept.
+func (rb *RingBuffer[T]) PushBatch(items []T) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	if len(items) > rb.capacity {
+		items = items[len(items)-rb.capacity:]
+	}
+
+	// Copy in at most two chunks: up to the end of data, then from the start
+	n := copy(rb.data[rb.head:], items)
+	copy(rb.data, items[n:])
+	rb.head = (rb.head + len(items)) % rb.capacity
+
+	rb.count += len(items)
+	if rb.count > rb.capacity {
+		rb.count = rb.capacity
+	}
+}
+
 // Len returns the number of elements in the buffer.
 func (rb *RingBuffer[T]) Len() int {
 	rb.mu.RLock()
@@ -41,6 +65,8 @@ func (rb *RingBuffer[T]) Len() int {
 
 // Capacity returns the maximum capacity of the buffer.
 func (rb *RingBuffer[T]) Capacity() int {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
 	return rb.capacity
 }
 
@@ -122,6 +148,43 @@ func (rb *RingBuffer[T]) GetLastN(n int) []T {
 	return result
 }
 
+// Range calls fn for each item, oldest first, until fn returns false. i is
+// the item's index as used by Get. It does not allocate; fn must not modify
+// the buffer.
+func (rb *RingBuffer[T]) Range(fn func(i int, v T) bool) {
+	rb.RangeFrom(0, fn)
+}
+
+// RangeFrom is like Range but starts at index start, so a window of items
+// can be walked by returning false past its end.
+func (rb *RingBuffer[T]) RangeFrom(start int, fn func(i int, v T) bool) {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+
+	if start < 0 {
+		start = 0
+	}
+	bufStart := (rb.head - rb.count + rb.capacity) % rb.capacity
+	for i := start; i < rb.count; i++ {
+		if !fn(i, rb.data[(bufStart+i)%rb.capacity]) {
+			return
+		}
+	}
+}
+
+// Search returns the smallest index for which pred is true, or Len() if there
+// is none, like sort.Search. pred must be false for a prefix of the items
+// and true for the rest.
+func (rb *RingBuffer[T]) Search(pred func(v T) bool) int {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+
+	start := (rb.head - rb.count + rb.capacity) % rb.capacity
+	return sort.Search(rb.count, func(i int) bool {
+		return pred(rb.data[(start+i)%rb.capacity])
+	})
+}
+
 // All returns all items in the buffer (oldest first).
 func (rb *RingBuffer[T]) All() []T {
 	rb.mu.RLock()
@@ -150,3 +213,23 @@ func (rb *RingBuffer[T]) Clear() {
 	rb.head = 0
 	rb.count = 0
 }
+
+// Resize changes the capacity, keeping the most recent items that fit.
+func (rb *RingBuffer[T]) Resize(capacity int) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	if capacity == rb.capacity {
+		return
+	}
+	n := min(rb.count, capacity)
+	data := make([]T, capacity)
+	start := (rb.head - n + rb.capacity) % rb.capacity
+	for i := range n {
+		data[i] = rb.data[(start+i)%rb.capacity]
+	}
+	rb.data = data
+	rb.capacity = capacity
+	rb.count = n
+	rb.head = n % capacity
+}
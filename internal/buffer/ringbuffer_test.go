@@ -1,8 +1,10 @@
 package buffer
 
 import (
+	"slices"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestRingBuffer_Basic(t *testing.T) {
@@ -142,6 +144,28 @@ func TestRingBuffer_Clear(t *testing.T) {
 	}
 }
 
+func TestRingBuffer_Resize(t *testing.T) {
+	rb := NewRingBuffer[int](5)
+	for i := 1; i <= 7; i++ {
+		rb.Push(i)
+	}
+
+	rb.Resize(3)
+	if got := rb.All(); !slices.Equal(got, []int{5, 6, 7}) || rb.Capacity() != 3 {
+		t.Fatalf("after shrinking: %v (capacity %d), want [5 6 7] (3)", got, rb.Capacity())
+	}
+	rb.Push(8)
+	if got := rb.All(); !slices.Equal(got, []int{6, 7, 8}) {
+		t.Fatalf("after push: %v, want [6 7 8]", got)
+	}
+
+	rb.Resize(5)
+	rb.Push(9)
+	if got := rb.All(); !slices.Equal(got, []int{6, 7, 8, 9}) {
+		t.Fatalf("after growing: %v, want [6 7 8 9]", got)
+	}
+}
+
 func TestRingBuffer_Concurrent(t *testing.T) {
 	rb := NewRingBuffer[int](100)
 	var wg sync.WaitGroup
@@ -177,3 +201,110 @@ func TestRingBuffer_Concurrent(t *testing.T) {
 		t.Errorf("len %d exceeds capacity %d", rb.Len(), rb.Capacity())
 	}
 }
+
+func TestRingBuffer_PushBatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial []int
+		batch   []int
+		want    []int
+	}{
+		{"empty batch", []int{1, 2}, nil, []int{1, 2}},
+		{"fits", []int{1}, []int{2, 3}, []int{1, 2, 3}},
+		{"wraps", []int{1, 2, 3}, []int{4, 5, 6}, []int{3, 4, 5, 6}},
+		{"larger than capacity", []int{1}, []int{2, 3, 4, 5, 6, 7}, []int{4, 5, 6, 7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rb := NewRingBuffer[int](4)
+			for _, v := range tt.initial {
+				rb.Push(v)
+			}
+			rb.PushBatch(tt.batch)
+
+			got := rb.All()
+			if len(got) != len(tt.want) {
+				t.Fatalf("All() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("All() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRingBuffer_Range(t *testing.T) {
+	rb := NewRingBuffer[int](3)
+	for i := 1; i <= 5; i++ {
+		rb.Push(i) // Keeps 3, 4, 5
+	}
+
+	var got []int
+	rb.Range(func(i int, v int) bool {
+		if v != i+3 {
+			t.Errorf("index %d holds %d, want %d", i, v, i+3)
+		}
+		got = append(got, v)
+		return true
+	})
+	if len(got) != 3 {
+		t.Fatalf("Range visited %v, want [3 4 5]", got)
+	}
+
+	got = nil
+	rb.RangeFrom(1, func(i int, v int) bool {
+		got = append(got, v)
+		return false
+	})
+	if len(got) != 1 || got[0] != 4 {
+		t.Fatalf("RangeFrom(1) visited %v, want [4]", got)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		rb.Range(func(int, int) bool { return true })
+	})
+	if allocs != 0 {
+		t.Fatalf("Range allocated %v times, want 0", allocs)
+	}
+}
+
+func TestFindAndRangeByTime(t *testing.T) {
+	type item struct {
+		at time.Time
+		v  int
+	}
+	timeOf := func(it item) time.Time { return it.at }
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	rb := NewRingBuffer[item](5)
+	for i := range 8 {
+		rb.Push(item{at: base.Add(time.Duration(i) * time.Second), v: i}) // Keeps 3..7
+	}
+
+	tests := []struct {
+		at   time.Time
+		want int
+	}{
+		{base, 0},
+		{base.Add(4 * time.Second), 1},
+		{base.Add(4500 * time.Millisecond), 2},
+		{base.Add(7 * time.Second), 4},
+		{base.Add(time.Minute), 5},
+	}
+	for _, tt := range tests {
+		if got := FindByTime[item](rb, tt.at, timeOf); got != tt.want {
+			t.Fatalf("FindByTime(%v) = %d, want %d", tt.at.Sub(base), got, tt.want)
+		}
+	}
+
+	var got []int
+	RangeByTime[item](rb, base.Add(4*time.Second), base.Add(6*time.Second), timeOf, func(i int, it item) bool {
+		got = append(got, it.v)
+		return true
+	})
+	if len(got) != 2 || got[0] != 4 || got[1] != 5 {
+		t.Fatalf("RangeByTime(4s, 6s) visited %v, want [4 5]", got)
+	}
+}
@@ -0,0 +1,50 @@
+// Package clock detects jumps of the system's wall clock, such as a
+// suspend and resume or an NTP step, by comparing how far the wall clock
+// and the monotonic clock advanced between two checks. The monotonic clock
+// stops while the system is suspended and is not stepped, so the wall
+// clock running ahead of or behind it means the timeline has a gap.
+package clock
+
+import "time"
+
+// JumpThreshold is how far the wall clock must drift from the monotonic
+// clock between two checks to count as a jump. NTP slews smaller offsets
+// gradually, which does not register.
+const JumpThreshold = time.Second
+
+// Detector reports clock jumps between successive checks. It is not safe
+// for concurrent use.
+type Detector struct {
+	start    time.Time // Carries the monotonic reading checks measure from
+	lastWall time.Time
+	lastMono time.Duration
+	started  bool
+}
+
+// NewDetector creates a detector.
+func NewDetector() *Detector {
+	return &Detector{start: time.Now()}
+}
+
+// Check returns how far the wall clock jumped since the last check:
+// positive for a suspend or a step forward, negative for a step back. The
+// first check only records the clocks; a nil detector never reports.
+func (d *Detector) Check() (time.Duration, bool) {
+	if d == nil {
+		return 0, false
+	}
+	now := time.Now()
+	return d.observe(now.Round(0), now.Sub(d.start))
+}
+
+// observe compares the wall time and monotonic offset of a check with the
+// last ones.
+func (d *Detector) observe(wall time.Time, mono time.Duration) (time.Duration, bool) {
+	jump := wall.Sub(d.lastWall) - (mono - d.lastMono)
+	started := d.started
+	d.lastWall, d.lastMono, d.started = wall, mono, true
+	if !started || (jump < JumpThreshold && jump > -JumpThreshold) {
+		return 0, false
+	}
+	return jump, true
+}
@@ -0,0 +1,42 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDetectorObserve(t *testing.T) {
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		wall time.Duration // Since start
+		mono time.Duration
+		want time.Duration // 0 for no jump
+	}{
+		{name: "steady", wall: time.Second, mono: time.Second},
+		{name: "slow processing", wall: 30 * time.Second, mono: 30 * time.Second},
+		{name: "slew", wall: 31*time.Second + 200*time.Millisecond, mono: 31 * time.Second},
+		{name: "suspend", wall: 2 * time.Hour, mono: 32 * time.Second, want: 2*time.Hour - 32*time.Second - 200*time.Millisecond},
+		{name: "step back", wall: 2*time.Hour - 10*time.Second, mono: 33 * time.Second, want: -11 * time.Second},
+	}
+
+	d := &Detector{}
+	if _, ok := d.observe(start, 0); ok {
+		t.Fatal("first check reported a jump")
+	}
+	for _, tt := range tests {
+		jump, ok := d.observe(start.Add(tt.wall), tt.mono)
+		if ok != (tt.want != 0) || jump != tt.want {
+			t.Fatalf("%s: observe() = %v, %v, want %v", tt.name, jump, ok, tt.want)
+		}
+	}
+}
+
+func TestDetectorCheck(t *testing.T) {
+	d := NewDetector()
+	for range 3 {
+		if jump, ok := d.Check(); ok {
+			t.Fatalf("Check() reported a %v jump on a steady clock", jump)
+		}
+	}
+}
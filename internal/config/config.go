@@ -1,6 +1,16 @@
 package config
 
-import "time"
+import (
+	"log/slog"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/alert"
+	"github.com/pbv7/pingheat/internal/locale"
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/parser"
+	"github.com/pbv7/pingheat/internal/ping"
+	"github.com/pbv7/pingheat/internal/ui/colors"
+)
 
 // Config holds all configuration options for pingheat.
 type Config struct {
@@ -10,8 +20,59 @@ type Config struct {
 	// Ping interval
 	Interval time.Duration
 
-	// Display history length in samples
-	HistorySize int
+	// Stop after this many samples (0 = unlimited)
+	Count int
+
+	// Run one single-shot ping per interval tick instead of a continuous
+	// ping, so samples are evenly spaced and loss is decided by a timeout
+	StrictTiming bool
+
+	// Probes sent per interval, sharing its sequence number (0 or 1 = one)
+	Burst int
+
+	// Gateway probed alongside Target by -diagnose, to tell local network
+	// problems from ISP problems ("" = off)
+	DiagnoseGateway string
+
+	// Record the Wi-Fi signal alongside samples, to tell radio problems
+	// from network ones
+	WiFi bool
+
+	// Look up the target's reverse DNS name, origin AS and country, shown
+	// in the header and exported as pingheat_target_info
+	Enrich bool
+
+	// Probe protocol: ping.ModeICMP, ping.ModeUDP or a plugin (see
+	// ping.IsValidMode; "" = ICMP), and the port UDP probes are sent to
+	Mode    string
+	UDPPort int
+
+	// IPv4 TOS or IPv6 traffic class byte marking the probes, DSCP << 2,
+	// to compare latency under QoS markings (0 = unmarked)
+	TOS int
+
+	// Shorter probe interval used while an outage lasts (0 = fixed interval)
+	AdaptiveInterval time.Duration
+
+	// How long to wait for each reply (0 = the ping command's default,
+	// ping.DefaultTimeout where pingheat sends probes itself)
+	Timeout time.Duration
+
+	// Stop after this much time (0 = unlimited)
+	Duration time.Duration
+
+	// Exit status thresholds, checked when a count/duration limit ends the session
+	FailOnLoss float64       // Fail if loss percent exceeds this (negative = disabled)
+	FailOnP95  time.Duration // Fail if p95 RTT exceeds this (0 = disabled)
+
+	// Display history length in samples; with HistoryAuto it is derived
+	// from HistoryBudget, the memory history may take (see AutoHistory)
+	HistorySize   int
+	HistoryAuto   bool
+	HistoryBudget int64
+
+	// Memory-mapped file holding the display history across restarts ("" keeps it in memory)
+	HistoryFile string
 
 	// Metrics buffer size
 	MetricsBufferSize int
@@ -20,12 +81,146 @@ type Config struct {
 	ExporterEnabled bool
 	ExporterAddr    string
 
+	// Quantiles of the exported RTT summary (nil = exporter defaults)
+	ExporterQuantiles []float64
+
+	// Serve strict OpenMetrics: base units, counters with _created samples
+	ExporterOpenMetrics bool
+
+	// Units of exported metrics: exporter.UnitsMilliseconds ("" = default)
+	// or exporter.UnitsSeconds
+	MetricsUnits string
+
+	// Prometheus remote_write endpoint receiving the exporter's metrics
+	// ("" disables pushing), with basic auth in the URL or a bearer token
+	RemoteWriteURL      string
+	RemoteWriteToken    string
+	RemoteWriteInterval time.Duration // 0 = exporter.DefaultRemoteWriteInterval
+
+	// Further export backends as NAME:SPEC (see exporter.NewBackend), each
+	// fed every sample alongside the exporter
+	Exports []string
+
 	// pprof server settings
 	PprofEnabled bool
 	PprofAddr    string
 
+	// Web UI settings
+	WebEnabled bool
+	WebAddr    string
+
+	// gRPC control API settings
+	GRPCEnabled bool
+	GRPCAddr    string
+
+	// Fleet settings: agents push samples to PushURL under AgentName;
+	// an aggregator collects them on AggregatorAddr instead of pinging, up
+	// to AggregatorMaxSeries agent/target pairs (0 = unlimited). Both sides
+	// use FleetToken as a bearer token if set
+	PushURL             string
+	AgentName           string
+	AggregatorEnabled   bool
+	AggregatorAddr      string
+	AggregatorMaxSeries int
+	FleetToken          string
+
+	// Headless output format ("json" or "csv"); empty runs the TUI
+	Output string
+
 	// UI settings
-	ShowHelp bool
+	ShowHelp    bool
+	ShowLegend  bool   // Color legend with the thresholds beneath the heatmap
+	ViewMode    string // "blocks" (one sample per cell), "braille" (2×4 samples per cell), "minutes" (one minute per cell) or "aligned" (one minute per row)
+	Orientation string // "rows" (time fills rows) or "columns" (time fills columns, with a time axis)
+	UTC         bool   // Display timestamps in UTC instead of local time
+	FPS         int    // UI refreshes per second while samples arrive
+	ASCII       bool   // Draw the UI in plain ASCII even if the terminal seems to support Unicode
+	NoColor     bool   // Draw the UI without colors, as with $NO_COLOR
+	DownAfter   int    // Lost samples in a row before the outage banner shows; 0 disables it
+
+	// Latency baseline of the delta view, learned from the first Calibrate
+	// replies unless Baseline gives it; Delta starts in the delta view
+	Calibrate int
+	Baseline  metrics.Baseline
+	Delta     bool
+
+	// How numbers and clock times are written for people: 12- or 24-hour
+	// clock, decimal separator and digit grouping (zero = 24h, plain digits)
+	Format locale.Format
+
+	// Announce the outage banner appearing and clearing with the terminal
+	// bell, or by running BellCommand, for at most one outage per
+	// BellCooldown
+	Bell         bool
+	BellCommand  string
+	BellCooldown time.Duration
+
+	// Screenshots saved with "S": always ANSI text, plus an "html" or "svg"
+	// copy unless ScreenshotFormat is "ansi"; ScreenshotDir empty is the
+	// working directory
+	ScreenshotFormat string
+	ScreenshotDir    string
+
+	// Maintenance window specifications; loss inside them does not count
+	// toward SLA (see package maintenance for the syntax)
+	Maintenance []string
+
+	// Custom ping output format for exotic ping implementations
+	// (nil detects the platform's format)
+	Parser *parser.Spec
+
+	// Static labels, such as site or isp, attached to every exported series
+	// and JSON/CSV sample for downstream grouping (nil = none)
+	Labels map[string]string
+
+	// Synthetic samples shown instead of probing the target (nil pings)
+	Demo *ping.DemoSpec
+
+	// Timeline of conditions the demo plays instead of steady Demo samples
+	// (nil keeps Demo steady; requires Demo)
+	DemoScenario *ping.Scenario
+
+	// File receiving the raw ping output, tagged with whether the parser
+	// matched each line ("" disables the debug log)
+	DebugLog string
+
+	// Runtime log: minimum level, and a file receiving it ("" writes to
+	// stderr when headless and only to the TUI log pane otherwise)
+	LogLevel slog.Level
+	LogFile  string
+
+	// Latency objective whose error budget and burn rates are tracked
+	// (nil disables SLO alerting)
+	SLO *alert.SLO
+
+	// Notifier specifications (see alert.ParseNotifier) receiving SLO
+	// alerts, and the message template ("" = alert.DefaultTemplate)
+	Notify         []string
+	NotifyTemplate string
+
+	// Mail server receiving SLO alerts by email (nil = no email)
+	Email *alert.SMTPConfig
+
+	// Starlark file whose on_sample hook runs for every sample ("" = none)
+	Script string
+
+	// RTT color band thresholds
+	Thresholds colors.Thresholds
+
+	// RTT above which a sample counts as brownout (0 = metrics.BrownoutThresholdMs)
+	Brownout time.Duration
+
+	// Configuration file and the profile loaded from it, where the settings
+	// overlay writes changes back ("" = no profile)
+	ConfigPath string
+	Profile    string
+
+	// Per-user UI preferences file, saved on exit ("" disables persistence)
+	PrefsPath string
+
+	// Per-user file of the baselines learned per target, seeding the delta
+	// view on the next run ("" disables persistence)
+	BaselinesPath string
 }
 
 // DefaultConfig returns a Config with sensible defaults.
@@ -33,12 +228,45 @@ func DefaultConfig() Config {
 	return Config{
 		Target:            "",
 		Interval:          time.Second,
+		Count:             0,
+		Duration:          0,
+		FailOnLoss:        -1,
+		FailOnP95:         0,
+		Mode:              ping.ModeICMP,
+		UDPPort:           ping.DefaultUDPPort,
 		HistorySize:       30000,
+		HistoryBudget:     DefaultHistoryBudget,
 		MetricsBufferSize: 120000,
 		ExporterEnabled:   false,
 		ExporterAddr:      ":9090",
 		PprofEnabled:      false,
 		PprofAddr:         "127.0.0.1:6060",
+		WebEnabled:        false,
+		WebAddr:           ":8080",
+		GRPCEnabled:       false,
+		GRPCAddr:          "127.0.0.1:50051",
+		AggregatorEnabled: false,
+		AggregatorAddr:    ":9100",
+		Output:            "",
 		ShowHelp:          false,
+		ViewMode:          "blocks",
+		Orientation:       "rows",
+		FPS:               10,
+		DownAfter:         5,
+		Calibrate:         metrics.DefaultCalibrationSamples,
+		BellCooldown:      time.Minute,
+		ScreenshotFormat:  "ansi",
+		UTC:               false,
+		Thresholds:        colors.DefaultThresholds(),
+		PrefsPath:         "",
+	}
+}
+
+// Location returns the time zone used for the clock and calendar periods:
+// UTC with -utc, otherwise local time.
+func (c Config) Location() *time.Location {
+	if c.UTC {
+		return time.UTC
 	}
+	return time.Local
 }
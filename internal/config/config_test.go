@@ -11,6 +11,12 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.Interval <= 0 {
 		t.Fatalf("Interval=%v, want > 0", cfg.Interval)
 	}
+	if cfg.Count != 0 || cfg.Duration != 0 {
+		t.Fatalf("Count=%d Duration=%v, want unlimited", cfg.Count, cfg.Duration)
+	}
+	if cfg.FailOnLoss >= 0 || cfg.FailOnP95 != 0 {
+		t.Fatalf("FailOnLoss=%v FailOnP95=%v, want disabled", cfg.FailOnLoss, cfg.FailOnP95)
+	}
 	if cfg.HistorySize <= 0 {
 		t.Fatalf("HistorySize=%d, want > 0", cfg.HistorySize)
 	}
@@ -29,7 +35,37 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.PprofAddr == "" {
 		t.Fatalf("PprofAddr empty, want default")
 	}
+	if cfg.WebEnabled {
+		t.Fatalf("WebEnabled=true, want false")
+	}
+	if cfg.WebAddr == "" {
+		t.Fatalf("WebAddr empty, want default")
+	}
+	if cfg.GRPCEnabled {
+		t.Fatalf("GRPCEnabled=true, want false")
+	}
+	if cfg.GRPCAddr == "" {
+		t.Fatalf("GRPCAddr empty, want default")
+	}
+	if cfg.AggregatorEnabled || cfg.PushURL != "" {
+		t.Fatalf("fleet mode enabled by default, want disabled")
+	}
+	if cfg.AggregatorAddr == "" {
+		t.Fatalf("AggregatorAddr empty, want default")
+	}
+	if cfg.Output != "" {
+		t.Fatalf("Output=%q, want empty (TUI)", cfg.Output)
+	}
 	if cfg.ShowHelp {
 		t.Fatalf("ShowHelp=true, want false")
 	}
+	if err := cfg.Thresholds.Validate(); err != nil {
+		t.Fatalf("default Thresholds invalid: %v", err)
+	}
+	if cfg.PrefsPath != "" {
+		t.Fatalf("PrefsPath=%q, want empty", cfg.PrefsPath)
+	}
+	if cfg.ViewMode != "blocks" {
+		t.Fatalf("ViewMode=%q, want blocks", cfg.ViewMode)
+	}
 }
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/alert"
+	"github.com/pbv7/pingheat/internal/exporter"
+	"github.com/pbv7/pingheat/internal/maintenance"
+	"github.com/pbv7/pingheat/internal/parser"
+	"github.com/pbv7/pingheat/internal/ui/colors"
+	"github.com/pbv7/pingheat/internal/userfile"
+	"go.yaml.in/yaml/v2"
+)
+
+// ErrUnknownProfile is returned when a requested profile is not defined.
+var ErrUnknownProfile = errors.New("unknown profile")
+
+// File is the user-authored YAML configuration file.
+//
+// Example:
+//
+//	profiles:
+//	  wan:
+//	    target: 1.1.1.1
+//	    interval: 500ms
+//	    thresholds: {excellent: 20, good: 50, fair: 100, poor: 200}
+//	    brownout: 250ms
+//	    maintenance: ["Sun 02:00-04:00"]
+//	    slo: 99.5%<100ms/24h
+//	    notify: ["slack:https://hooks.slack.com/services/T000/B000/XXXX"]
+//	    email: {host: smtp.example.com, from: alerts@example.com, to: [oncall@example.com]}
+//	    labels: {site: home, link: fiber, isp: acme}
+//	  router:
+//	    target: 10.0.0.1
+//	    parser:
+//	      reply: 'seq (?P<seq>\d+) rtt (?P<time>[0-9.]+)'
+//	      timeout: '^\.'
+type File struct {
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// Profile is a named monitoring setup selectable with -profile.
+// Zero values leave the corresponding default or flag value untouched.
+type Profile struct {
+	Target      string             `yaml:"target"`
+	Interval    time.Duration      `yaml:"interval"`
+	Thresholds  *colors.Thresholds `yaml:"thresholds"`
+	Brownout    time.Duration      `yaml:"brownout"` // RTT counted as brownout, as Config.Brownout
+	Maintenance []string           `yaml:"maintenance"`
+	Parser      *parser.Spec       `yaml:"parser"`
+	SLO         string             `yaml:"slo"`    // Latency objective, see alert.ParseSLO
+	Notify      []string           `yaml:"notify"` // Alert notifiers, see alert.ParseNotifier
+	Email       *alert.SMTPConfig  `yaml:"email"`  // Alert emails, see alert.SMTPConfig
+	Labels      map[string]string  `yaml:"labels"` // Static labels, see ValidateLabels
+
+	// Units of exported metrics: ms or seconds, as -metrics-units
+	MetricsUnits string `yaml:"metrics-units"`
+}
+
+// DefaultFilePath returns the per-user configuration file path
+// ($XDG_CONFIG_HOME/pingheat/config.yaml on Linux, the platform equivalent elsewhere).
+func DefaultFilePath() (string, error) {
+	return userfile.ConfigPath("config.yaml")
+}
+
+// LoadFile reads and validates a configuration file.
+// A missing file yields an empty File so callers can treat it as optional.
+func LoadFile(path string) (File, error) {
+	var f File
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return f, err
+	}
+	return parseFile(path, data)
+}
+
+// parseFile decodes and validates the contents of the configuration file at
+// path.
+func parseFile(path string, data []byte) (File, error) {
+	var f File
+	if err := yaml.UnmarshalStrict(data, &f); err != nil {
+		return File{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := f.validate(); err != nil {
+		return File{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// validate checks every profile for values the CLI would also reject.
+func (f File) validate() error {
+	for name, p := range f.Profiles {
+		if p.Interval < 0 {
+			return fmt.Errorf("profile %q: interval must not be negative", name)
+		}
+		if p.Thresholds != nil {
+			if err := p.Thresholds.Validate(); err != nil {
+				return fmt.Errorf("profile %q: %w", name, err)
+			}
+		}
+		if p.Brownout < 0 {
+			return fmt.Errorf("profile %q: brownout must not be negative", name)
+		}
+		for _, spec := range p.Maintenance {
+			if _, err := maintenance.ParseWindow(spec); err != nil {
+				return fmt.Errorf("profile %q: %w", name, err)
+			}
+		}
+		if p.Parser != nil {
+			if _, err := parser.NewRegex(*p.Parser); err != nil {
+				return fmt.Errorf("profile %q: %w", name, err)
+			}
+		}
+		if p.SLO != "" {
+			if _, err := alert.ParseSLO(p.SLO); err != nil {
+				return fmt.Errorf("profile %q: %w", name, err)
+			}
+		}
+		if _, err := alert.ParseNotifiers(p.Notify); err != nil {
+			return fmt.Errorf("profile %q: %w", name, err)
+		}
+		if p.Email != nil {
+			if err := p.Email.Validate(); err != nil {
+				return fmt.Errorf("profile %q: %w", name, err)
+			}
+		}
+		if err := ValidateLabels(p.Labels); err != nil {
+			return fmt.Errorf("profile %q: %w", name, err)
+		}
+		if p.MetricsUnits != "" && !exporter.IsValidUnits(p.MetricsUnits) {
+			return fmt.Errorf("profile %q: metrics-units %q: want %s or %s",
+				name, p.MetricsUnits, exporter.UnitsMilliseconds, exporter.UnitsSeconds)
+		}
+	}
+	return nil
+}
+
+// ErrInvalidLabel is returned for a static label Prometheus would reject or
+// that clashes with a label of pingheat's own series.
+var ErrInvalidLabel = errors.New("invalid label")
+
+// labelName matches Prometheus label names; names starting with __ are
+// reserved for Prometheus itself.
+var labelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// reservedLabels are the labels of pingheat's exported series and the
+// columns of its CSV output, which static labels must not replace.
+var reservedLabels = []string{
+	"target", "kind", "channel", "stat", "quantile", "le", "result", "bucket", "period", "window",
+	"alertname", "severity", "version", "commit", "go_version", "interval", "interface", "ssid",
+	"timestamp", "seq", "rtt_ms", "timeout",
+}
+
+// ValidateLabels checks static labels for names Prometheus accepts and
+// pingheat does not use itself.
+func ValidateLabels(labels map[string]string) error {
+	for name := range labels {
+		switch {
+		case !labelName.MatchString(name) || strings.HasPrefix(name, "__"):
+			return fmt.Errorf("%w %q: want letters, digits and underscores", ErrInvalidLabel, name)
+		case slices.Contains(reservedLabels, name):
+			return fmt.Errorf("%w %q: used by pingheat", ErrInvalidLabel, name)
+		}
+	}
+	return nil
+}
+
+// Profile returns the named profile.
+func (f File) Profile(name string) (Profile, error) {
+	p, ok := f.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("%w %q (available: %s)", ErrUnknownProfile, name, f.profileNames())
+	}
+	return p, nil
+}
+
+// profileNames lists defined profiles for error messages.
+func (f File) profileNames() string {
+	if len(f.Profiles) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFileProfiles(t *testing.T) {
+	path := writeConfigFile(t, `
+profiles:
+  wan:
+    target: 1.1.1.1
+    interval: 500ms
+    thresholds: {excellent: 20, good: 50, fair: 100, poor: 200}
+    labels: {site: ams, isp: acme}
+  gaming:
+    target: game.example.com
+  router:
+    parser:
+      reply: 'seq (?P<seq>\d+) rtt (?P<time>[0-9.]+)'
+      timeout: '^\.'
+`)
+
+	f, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error: %v", err)
+	}
+
+	wan, err := f.Profile("wan")
+	if err != nil {
+		t.Fatalf("Profile(wan) error: %v", err)
+	}
+	if wan.Target != "1.1.1.1" || wan.Interval != 500*time.Millisecond {
+		t.Fatalf("unexpected wan profile: %+v", wan)
+	}
+	if wan.Labels["site"] != "ams" || wan.Labels["isp"] != "acme" {
+		t.Fatalf("unexpected wan labels: %v", wan.Labels)
+	}
+	if wan.Thresholds == nil || wan.Thresholds.Poor != 200 {
+		t.Fatalf("unexpected wan thresholds: %+v", wan.Thresholds)
+	}
+
+	router, err := f.Profile("router")
+	if err != nil {
+		t.Fatalf("Profile(router) error: %v", err)
+	}
+	if router.Parser == nil || router.Parser.Timeout != `^\.` {
+		t.Fatalf("unexpected router parser: %+v", router.Parser)
+	}
+
+	_, err = f.Profile("vpn")
+	if !errors.Is(err, ErrUnknownProfile) {
+		t.Fatalf("expected ErrUnknownProfile, got %v", err)
+	}
+}
+
+func TestLoadFileMissing(t *testing.T) {
+	f, err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatalf("LoadFile() error: %v", err)
+	}
+	if len(f.Profiles) != 0 {
+		t.Fatalf("expected no profiles, got %d", len(f.Profiles))
+	}
+}
+
+func TestLoadFileRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "unknown key", content: "profiles:\n  wan:\n    tagret: 1.1.1.1\n"},
+		{name: "bad thresholds", content: "profiles:\n  wan:\n    thresholds: {excellent: 50, good: 10, fair: 100, poor: 200}\n"},
+		{name: "negative interval", content: "profiles:\n  wan:\n    interval: -1s\n"},
+		{name: "negative brownout", content: "profiles:\n  wan:\n    brownout: -1s\n"},
+		{name: "parser without time group", content: "profiles:\n  wan:\n    parser: {reply: 'rtt ([0-9.]+)'}\n"},
+		{name: "bad maintenance", content: "profiles:\n  wan:\n    maintenance: [\"Funday 01:00-02:00\"]\n"},
+		{name: "bad slo", content: "profiles:\n  wan:\n    slo: 100%<100ms\n"},
+		{name: "bad notifier", content: "profiles:\n  wan:\n    notify: [\"slack:hooks.slack.com\"]\n"},
+		{name: "bad label name", content: "profiles:\n  wan:\n    labels: {site-name: ams}\n"},
+		{name: "reserved label", content: "profiles:\n  wan:\n    labels: {target: ams}\n"},
+		{name: "bad metrics units", content: "profiles:\n  wan:\n    metrics-units: s\n"},
+		{name: "email without recipients", content: "profiles:\n  wan:\n    email: {host: smtp.example.com, from: a@example.com}\n"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := LoadFile(writeConfigFile(t, tc.content)); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+// DefaultHistoryBudget is the memory history may take before pingheat warns,
+// and what -history auto shares among targets.
+const DefaultHistoryBudget = 64 << 20
+
+// MinAutoHistory is the fewest samples -history auto keeps per target, ten
+// minutes at the default interval, however many targets share the budget.
+const MinAutoHistory = 600
+
+var (
+	// ErrInvalidHistory is returned for a -history that is neither a
+	// positive number of samples nor auto.
+	ErrInvalidHistory = errors.New("history must be a positive number of samples or auto")
+
+	// ErrInvalidSize is returned for a malformed memory size.
+	ErrInvalidSize = errors.New("size must be a positive number of bytes with an optional KB, MB or GB suffix")
+
+	// ErrHistoryTooLarge is returned when the history would not fit in the
+	// available memory.
+	ErrHistoryTooLarge = errors.New("history does not fit in available memory")
+)
+
+// sizeUnits are the suffixes ParseSize accepts, largest first.
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// ParseSize parses a memory size such as 512KB, 64MB or 1GB, in powers of
+// 1024. A number without a suffix is in bytes.
+func ParseSize(s string) (int64, error) {
+	num, unit := strings.ToUpper(strings.TrimSpace(s)), int64(1)
+	for _, u := range sizeUnits {
+		if n, ok := strings.CutSuffix(num, u.suffix); ok {
+			num, unit = strings.TrimSpace(n), u.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n <= 0 || n > (1<<62)/unit {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidSize, s)
+	}
+	return n * unit, nil
+}
+
+// ParseHistory parses a -history value: a number of samples, or auto for
+// the history per target that fits budget.
+func ParseHistory(s string, budget int64) (size int, auto bool, err error) {
+	if s == "auto" {
+		return AutoHistory(budget, 1), true, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, false, fmt.Errorf("%w: %q", ErrInvalidHistory, s)
+	}
+	return n, false, nil
+}
+
+// HistoryBytes estimates the memory taken by size samples of history for
+// each of targets.
+func HistoryBytes(size, targets int) int64 {
+	return int64(size) * int64(targets) * int64(types.SampleBytes)
+}
+
+// AutoHistory returns the history per target that keeps targets within
+// budget bytes, but at least MinAutoHistory samples.
+func AutoHistory(budget int64, targets int) int {
+	per := budget / HistoryBytes(1, max(1, targets))
+	return int(max(MinAutoHistory, min(per, 1<<31-1)))
+}
+
+// CheckHistory returns ErrHistoryTooLarge if size samples of history for
+// each of targets would take more than available bytes.
+func CheckHistory(size, targets int, available uint64) error {
+	if need := HistoryBytes(size, targets); uint64(need) > available {
+		return fmt.Errorf("%w: %d samples take %dMB, %dMB available",
+			ErrHistoryTooLarge, size*targets, need>>20, available>>20)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"4096":  4096,
+		"512KB": 512 << 10,
+		"64MB":  64 << 20,
+		"2 gb":  2 << 30,
+		"100B":  100,
+	}
+	for in, want := range tests {
+		if got, err := ParseSize(in); err != nil || got != want {
+			t.Fatalf("ParseSize(%q) = %d, %v, want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "MB", "-1MB", "0", "1TB", "1.5GB"} {
+		if _, err := ParseSize(in); !errors.Is(err, ErrInvalidSize) {
+			t.Fatalf("ParseSize(%q) error = %v, want ErrInvalidSize", in, err)
+		}
+	}
+}
+
+func TestParseHistory(t *testing.T) {
+	if size, auto, err := ParseHistory("5000", DefaultHistoryBudget); err != nil || size != 5000 || auto {
+		t.Fatalf("ParseHistory(5000) = %d, %v, %v", size, auto, err)
+	}
+	budget := int64(1000 * types.SampleBytes)
+	if size, auto, err := ParseHistory("auto", budget); err != nil || size != 1000 || !auto {
+		t.Fatalf("ParseHistory(auto) = %d, %v, %v, want 1000 samples", size, auto, err)
+	}
+	for _, in := range []string{"0", "-5", "lots"} {
+		if _, _, err := ParseHistory(in, budget); !errors.Is(err, ErrInvalidHistory) {
+			t.Fatalf("ParseHistory(%q) error = %v, want ErrInvalidHistory", in, err)
+		}
+	}
+}
+
+func TestAutoHistory(t *testing.T) {
+	budget := int64(10000 * types.SampleBytes)
+	if got := AutoHistory(budget, 4); got != 2500 {
+		t.Fatalf("AutoHistory(4 targets) = %d, want 2500", got)
+	}
+	// Many targets still keep a few minutes each
+	if got := AutoHistory(budget, 1000); got != MinAutoHistory {
+		t.Fatalf("AutoHistory(1000 targets) = %d, want %d", got, MinAutoHistory)
+	}
+}
+
+func TestCheckHistory(t *testing.T) {
+	if err := CheckHistory(1000, 2, uint64(HistoryBytes(2000, 1))); err != nil {
+		t.Fatalf("CheckHistory() within available memory error = %v", err)
+	}
+	if err := CheckHistory(1000, 3, uint64(HistoryBytes(2000, 1))); !errors.Is(err, ErrHistoryTooLarge) {
+		t.Fatalf("CheckHistory() error = %v, want ErrHistoryTooLarge", err)
+	}
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/ui/colors"
+	"github.com/pbv7/pingheat/internal/userfile"
+	"gopkg.in/yaml.v3"
+)
+
+// SaveProfileSettings writes latency settings to the named profile of the
+// configuration file at path, creating the file and profile if needed. A
+// zero brownout or empty slo removes the key. Only those keys change: other
+// keys, profiles and comments are kept, though the file is reindented. A
+// symlinked file is updated where it points and keeps its permissions; a
+// new file is readable by its owner only, as it may hold credentials.
+func SaveProfileSettings(path, name string, th colors.Thresholds, brownout time.Duration, slo string) error {
+	path, perm, err := resolveFile(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		// Empty or comments only
+		doc.Kind = yaml.DocumentNode
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode}}
+	}
+	profiles, err := childMapping(doc.Content[0], "profiles")
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	profile, err := childMapping(profiles, name)
+	if err != nil {
+		return fmt.Errorf("%s: profile %q: %w", path, name, err)
+	}
+
+	var value yaml.Node
+	if err := value.Encode(th); err != nil {
+		return err
+	}
+	setMapValue(profile, "thresholds", &value)
+	setMapValue(profile, "brownout", nil)
+	if brownout > 0 {
+		setMapValue(profile, "brownout", scalar(brownout.String()))
+	}
+	setMapValue(profile, "slo", nil)
+	if slo != "" {
+		setMapValue(profile, "slo", scalar(slo))
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	// Refuse to write a file LoadFile would reject
+	if _, err := parseFile(path, out.Bytes()); err != nil {
+		return err
+	}
+
+	return userfile.WriteAtomic(path, out.Bytes(), perm)
+}
+
+// resolveFile returns the file path refers to, following symlinks, and its
+// permissions, or path itself and 0600 if there is no such file yet.
+func resolveFile(path string) (string, fs.FileMode, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		if _, lerr := os.Lstat(path); errors.Is(lerr, fs.ErrNotExist) {
+			return path, 0o600, nil
+		}
+	}
+	if err != nil {
+		return "", 0, err
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", 0, err
+	}
+	return resolved, info.Mode().Perm(), nil
+}
+
+// childMapping returns the mapping under key in m, adding it if the key is
+// missing or empty.
+func childMapping(m *yaml.Node, key string) (*yaml.Node, error) {
+	if m.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("want a mapping holding %s", key)
+	}
+	child := mapValue(m, key)
+	switch {
+	case child == nil:
+		child = &yaml.Node{Kind: yaml.MappingNode}
+		setMapValue(m, key, child)
+	case child.Kind == yaml.ScalarNode && child.Tag == "!!null":
+		child.Kind, child.Tag, child.Value = yaml.MappingNode, "", ""
+	case child.Kind != yaml.MappingNode:
+		return nil, fmt.Errorf("%s: want a mapping", key)
+	}
+	return child, nil
+}
+
+// mapValue returns the value of key in the mapping m, or nil.
+func mapValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMapValue sets key in the mapping m to value, in place if present and
+// appended otherwise. A value replacing another keeps its comments and, for
+// collections, its flow or block style. A nil value removes the key.
+func setMapValue(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value != key {
+			continue
+		}
+		if value == nil {
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return
+		}
+		old := m.Content[i+1]
+		value.HeadComment, value.LineComment, value.FootComment = old.HeadComment, old.LineComment, old.FootComment
+		if old.Kind == value.Kind && value.Kind != yaml.ScalarNode {
+			value.Style = old.Style
+		}
+		m.Content[i+1] = value
+		return
+	}
+	if value != nil {
+		m.Content = append(m.Content, scalar(key), value)
+	}
+}
+
+// scalar returns a string scalar node holding s.
+func scalar(s string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}
+}
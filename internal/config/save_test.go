@@ -0,0 +1,134 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/ui/colors"
+)
+
+func TestSaveProfileSettings(t *testing.T) {
+	path := writeConfigFile(t, `
+profiles:
+  wan:
+    target: 1.1.1.1
+    slo: 99%<100ms/1h
+  router:
+    target: 10.0.0.1
+`)
+	th := colors.Thresholds{Excellent: 10, Good: 20, Fair: 40, Poor: 80}
+	if err := SaveProfileSettings(path, "wan", th, 150*time.Millisecond, ""); err != nil {
+		t.Fatalf("SaveProfileSettings() error: %v", err)
+	}
+	if err := SaveProfileSettings(path, "lab", th, 0, "99.9%<50ms/24h"); err != nil {
+		t.Fatalf("SaveProfileSettings() error: %v", err)
+	}
+
+	f, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error: %v", err)
+	}
+	wan := f.Profiles["wan"]
+	if wan.Target != "1.1.1.1" || wan.Thresholds == nil || *wan.Thresholds != th ||
+		wan.Brownout != 150*time.Millisecond || wan.SLO != "" {
+		t.Fatalf("wan = %+v, want target kept, new thresholds and brownout, no slo", wan)
+	}
+	if f.Profiles["router"].Target != "10.0.0.1" {
+		t.Fatalf("router profile not kept: %+v", f.Profiles["router"])
+	}
+	if lab := f.Profiles["lab"]; lab.SLO != "99.9%<50ms/24h" || lab.Brownout != 0 {
+		t.Fatalf("lab = %+v, want a new profile with the slo", lab)
+	}
+}
+
+func TestSaveProfileSettingsCreatesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pingheat", "config.yaml")
+	if err := SaveProfileSettings(path, "wan", colors.DefaultThresholds(), 0, ""); err != nil {
+		t.Fatalf("SaveProfileSettings() error: %v", err)
+	}
+	f, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error: %v", err)
+	}
+	if p, ok := f.Profiles["wan"]; !ok || p.Thresholds == nil || *p.Thresholds != colors.DefaultThresholds() {
+		t.Fatalf("profiles = %+v, want wan with the default thresholds", f.Profiles)
+	}
+}
+
+func TestSaveProfileSettingsKeepsComments(t *testing.T) {
+	path := writeConfigFile(t, `# Home links
+profiles:
+  # Uplink to the ISP
+  wan:
+    target: 1.1.1.1 # Cloudflare
+    thresholds: {excellent: 20, good: 50, fair: 100, poor: 200} # Fiber
+    brownout: 250ms
+`)
+	th := colors.Thresholds{Excellent: 10, Good: 20, Fair: 40, Poor: 80}
+	if err := SaveProfileSettings(path, "wan", th, 0, ""); err != nil {
+		t.Fatalf("SaveProfileSettings() error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# Home links
+profiles:
+  # Uplink to the ISP
+  wan:
+    target: 1.1.1.1 # Cloudflare
+    thresholds: {excellent: 10, good: 20, fair: 40, poor: 80} # Fiber
+`
+	if string(data) != want {
+		t.Fatalf("saved file:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestSaveProfileSettingsFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no Unix permissions or unprivileged symlinks")
+	}
+	dir := t.TempDir()
+	th := colors.DefaultThresholds()
+
+	created := filepath.Join(dir, "new.yaml")
+	if err := SaveProfileSettings(created, "wan", th, 0, ""); err != nil {
+		t.Fatalf("SaveProfileSettings() error: %v", err)
+	}
+	if info, err := os.Stat(created); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("new file mode = %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+
+	// A symlinked file is updated in place and keeps its mode
+	target := filepath.Join(dir, "dotfiles", "pingheat.yaml")
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("profiles: {}\n"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(target, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "config.yaml")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveProfileSettings(link, "wan", th, 0, ""); err != nil {
+		t.Fatalf("SaveProfileSettings() error: %v", err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("Lstat(link) = %v, %v, want the symlink kept", info, err)
+	}
+	info, err := os.Stat(target)
+	if err != nil || info.Mode().Perm() != 0o640 {
+		t.Fatalf("target mode = %v, %v, want 0640 kept", info.Mode().Perm(), err)
+	}
+	if data, err := os.ReadFile(target); err != nil || !strings.Contains(string(data), "wan:") {
+		t.Fatalf("target = %q, %v, want the wan profile written through the link", data, err)
+	}
+}
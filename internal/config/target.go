@@ -0,0 +1,89 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Bounds for the ping interval.
+const (
+	MinInterval = 100 * time.Millisecond
+	MaxInterval = time.Hour
+)
+
+// Bounds for the per-probe reply timeout (0 keeps the default).
+const (
+	MinTimeout = 100 * time.Millisecond
+	MaxTimeout = time.Minute
+)
+
+// ErrInvalidTarget is returned for targets that are neither an IP address
+// nor a valid hostname.
+var ErrInvalidTarget = errors.New("invalid target format")
+
+// hostnameRe validates RFC 1123 compliant hostnames.
+// Allows: letters, digits, hyphens, dots
+// Each label: starts/ends with alphanumeric, max 63 chars
+var hostnameRe = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9\-]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9\-]{0,61}[a-zA-Z0-9])?)*$`)
+
+// ValidateTarget checks that target is a valid IP address or hostname.
+// Does NOT perform DNS lookups - only format validation.
+// Supports IPv6 zone IDs (e.g., fe80::1%en0 or [fe80::1%en0]).
+func ValidateTarget(target string) error {
+	if target == "" {
+		return ErrInvalidTarget
+	}
+
+	// Check if it's a valid IP (IPv4 or IPv6 without zone)
+	if net.ParseIP(target) != nil {
+		return nil
+	}
+
+	// Handle IPv6 literals with brackets.
+	// If it has brackets, it MUST be an IP (with optional zone) and not a hostname.
+	if strings.HasPrefix(target, "[") && strings.HasSuffix(target, "]") {
+		host := target[1 : len(target)-1]
+		// Strip zone ID if present (e.g., fe80::1%en0 -> fe80::1)
+		if zoneIndex := strings.Index(host, "%"); zoneIndex != -1 {
+			// Reject empty zone IDs (e.g., [fe80::1%])
+			if zoneIndex == len(host)-1 {
+				return fmt.Errorf("%w: %q has empty zone identifier", ErrInvalidTarget, target)
+			}
+			host = host[:zoneIndex]
+		}
+		if net.ParseIP(host) != nil {
+			return nil // Valid bracketed IPv6 (with optional zone)
+		}
+		// Invalid bracketed value (not an IP)
+		return fmt.Errorf("%w: %q must be a valid IP address or hostname", ErrInvalidTarget, target)
+	}
+
+	// Check for IPv6 with zone ID (e.g., fe80::1%en0)
+	if zoneIndex := strings.Index(target, "%"); zoneIndex != -1 {
+		// Reject empty zone IDs (e.g., fe80::1%)
+		if zoneIndex == len(target)-1 {
+			return fmt.Errorf("%w: %q has empty zone identifier", ErrInvalidTarget, target)
+		}
+		host := target[:zoneIndex]
+		if net.ParseIP(host) != nil {
+			return nil // Valid IPv6 with zone ID
+		}
+		// If a '%' is present, it must be a valid zoned IPv6 address. Hostnames cannot contain '%'.
+		return fmt.Errorf("%w: %q must be a valid zoned IPv6 address (hostnames cannot contain '%%')", ErrInvalidTarget, target)
+	}
+
+	// Allow absolute FQDNs with trailing dot (e.g., example.com. or localhost.)
+	// Strip trailing dot before hostname validation
+	hostname := strings.TrimSuffix(target, ".")
+
+	// Validate hostname format (RFC 1123 compliant)
+	if !hostnameRe.MatchString(hostname) {
+		return fmt.Errorf("%w: %q must be a valid IP address or hostname", ErrInvalidTarget, target)
+	}
+
+	return nil
+}
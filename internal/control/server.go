@@ -0,0 +1,395 @@
+// Package control implements the gRPC control API (pkg/api/controlv1) for
+// managing probes of a running pingheat instance.
+package control
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/config"
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/ping"
+	"github.com/pbv7/pingheat/internal/types"
+	"github.com/pbv7/pingheat/pkg/api/controlv1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// PrimaryID identifies the probe started from the command line.
+const PrimaryID = "main"
+
+// MaxProbes is the number of probes StartProbe runs at once, besides the
+// primary one.
+const MaxProbes = 16
+
+// streamBuffer is the number of samples queued per StreamSamples client.
+const streamBuffer = 64
+
+// Runner emits ping samples until the context is cancelled.
+type Runner interface {
+	Run(ctx context.Context, samples chan<- ping.Sample) error
+}
+
+// probe is one running target, either the primary or one started over the API.
+type probe struct {
+	id        string
+	target    string        // Guarded by mu; the primary probe's target can change
+	interval  time.Duration // Guarded by mu; the primary probe's interval can change
+	startedAt time.Time
+	cancel    context.CancelFunc // nil for the primary probe
+	done      chan struct{}      // Closed when an API probe has stopped
+
+	mu    sync.Mutex
+	stats metrics.Stats
+	subs  map[chan types.Sample]struct{}
+}
+
+// info returns the API representation of the probe.
+func (p *probe) info() *controlv1.Probe {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return &controlv1.Probe{
+		Id:        p.id,
+		Target:    p.target,
+		Interval:  durationpb.New(p.interval),
+		Primary:   p.cancel == nil,
+		StartedAt: timestamppb.New(p.startedAt),
+	}
+}
+
+// update records new stats and fans the sample out to stream clients,
+// dropping it for clients that are not keeping up.
+func (p *probe) update(sample types.Sample, stats metrics.Stats) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stats = stats
+	for ch := range p.subs {
+		select {
+		case ch <- sample:
+		default:
+		}
+	}
+}
+
+// subscribe registers a stream client.
+func (p *probe) subscribe() chan types.Sample {
+	ch := make(chan types.Sample, streamBuffer)
+	p.mu.Lock()
+	p.subs[ch] = struct{}{}
+	p.mu.Unlock()
+	return ch
+}
+
+// unsubscribe removes a stream client.
+func (p *probe) unsubscribe(ch chan types.Sample) {
+	p.mu.Lock()
+	delete(p.subs, ch)
+	p.mu.Unlock()
+}
+
+// Server implements controlv1.ControlServiceServer.
+type Server struct {
+	controlv1.UnimplementedControlServiceServer
+
+	addr      string
+	newRunner func(target string, interval time.Duration) Runner
+
+	mu     sync.Mutex
+	ctx    context.Context // Parent of probes started over the API
+	probes map[string]*probe
+	nextID int
+}
+
+// NewServer creates a control server on addr whose primary probe pings
+// target at the given interval.
+func NewServer(addr, target string, interval time.Duration) *Server {
+	s := &Server{
+		addr: addr,
+		newRunner: func(target string, interval time.Duration) Runner {
+			return ping.NewRunner(target, interval)
+		},
+		ctx:    context.Background(),
+		probes: make(map[string]*probe),
+	}
+	s.probes[PrimaryID] = newProbe(PrimaryID, target, interval, nil)
+	return s
+}
+
+// SetRunnerFactory sets how probes started over the API ping their target,
+// so they probe like the primary one. Call it before Start.
+func (s *Server) SetRunnerFactory(fn func(target string, interval time.Duration) Runner) {
+	s.newRunner = fn
+}
+
+// newProbe creates probe state.
+func newProbe(id, target string, interval time.Duration, cancel context.CancelFunc) *probe {
+	return &probe{
+		id:        id,
+		target:    target,
+		interval:  interval,
+		startedAt: time.Now(),
+		cancel:    cancel,
+		done:      make(chan struct{}),
+		subs:      make(map[chan types.Sample]struct{}),
+	}
+}
+
+// Start serves the gRPC API until ctx is cancelled. Probes started over the
+// API stop with it.
+func (s *Server) Start(ctx context.Context) error {
+	lis, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	return s.serve(ctx, lis)
+}
+
+// serve serves the gRPC API on lis until ctx is cancelled.
+func (s *Server) serve(ctx context.Context, lis net.Listener) error {
+	s.mu.Lock()
+	s.ctx = ctx
+	s.mu.Unlock()
+
+	srv := grpc.NewServer()
+	controlv1.RegisterControlServiceServer(srv, s)
+
+	go func() {
+		<-ctx.Done()
+		// Stop rather than GracefulStop: sample streams never end on their own
+		srv.Stop()
+	}()
+
+	if err := srv.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+	return nil
+}
+
+// Update records a sample of the primary probe.
+func (s *Server) Update(sample types.Sample, stats metrics.Stats) {
+	s.mu.Lock()
+	p := s.probes[PrimaryID]
+	s.mu.Unlock()
+	p.update(sample, stats)
+}
+
+// SetTarget records that the primary probe now pings target.
+func (s *Server) SetTarget(target string) {
+	s.mu.Lock()
+	p := s.probes[PrimaryID]
+	s.mu.Unlock()
+
+	p.mu.Lock()
+	p.target = target
+	p.mu.Unlock()
+}
+
+// SetInterval records that the primary probe now pings every interval.
+func (s *Server) SetInterval(interval time.Duration) {
+	s.mu.Lock()
+	p := s.probes[PrimaryID]
+	s.mu.Unlock()
+
+	p.mu.Lock()
+	p.interval = interval
+	p.mu.Unlock()
+}
+
+// lookup returns the probe with the given ID; empty selects the primary.
+func (s *Server) lookup(id string) (*probe, error) {
+	if id == "" {
+		id = PrimaryID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.probes[id]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "probe %q not found", id)
+	}
+	return p, nil
+}
+
+// ListProbes returns every running probe, primary first.
+func (s *Server) ListProbes(context.Context, *controlv1.ListProbesRequest) (*controlv1.ListProbesResponse, error) {
+	s.mu.Lock()
+	probes := make([]*probe, 0, len(s.probes))
+	for _, p := range s.probes {
+		probes = append(probes, p)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(probes, func(i, j int) bool {
+		if probes[i].startedAt.Equal(probes[j].startedAt) {
+			return probes[i].id < probes[j].id
+		}
+		return probes[i].startedAt.Before(probes[j].startedAt)
+	})
+
+	resp := &controlv1.ListProbesResponse{}
+	for _, p := range probes {
+		resp.Probes = append(resp.Probes, p.info())
+	}
+	return resp, nil
+}
+
+// StartProbe starts pinging an additional target.
+func (s *Server) StartProbe(_ context.Context, req *controlv1.StartProbeRequest) (*controlv1.StartProbeResponse, error) {
+	if err := config.ValidateTarget(req.GetTarget()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	interval := time.Second
+	if req.GetInterval() != nil {
+		interval = req.GetInterval().AsDuration()
+	}
+	if interval < config.MinInterval || interval > config.MaxInterval {
+		return nil, status.Errorf(codes.InvalidArgument, "interval must be between %v and %v", config.MinInterval, config.MaxInterval)
+	}
+
+	s.mu.Lock()
+	if len(s.probes)-1 >= MaxProbes {
+		s.mu.Unlock()
+		return nil, status.Errorf(codes.ResourceExhausted, "at most %d probes can run besides the primary one", MaxProbes)
+	}
+	s.nextID++
+	id := "p" + strconv.Itoa(s.nextID)
+	ctx, cancel := context.WithCancel(s.ctx)
+	p := newProbe(id, req.GetTarget(), interval, cancel)
+	s.probes[id] = p
+	s.mu.Unlock()
+
+	go s.run(ctx, p)
+
+	return &controlv1.StartProbeResponse{Probe: p.info()}, nil
+}
+
+// run drives a probe started over the API until it is stopped.
+func (s *Server) run(ctx context.Context, p *probe) {
+	defer close(p.done)
+	defer s.remove(p.id)
+
+	samples := make(chan ping.Sample, 100)
+	go func() {
+		_ = s.newRunner(p.target, p.interval).Run(ctx, samples)
+		close(samples)
+	}()
+
+	engine := metrics.NewEngine()
+	for sample := range samples {
+		engine.Add(sample)
+		p.update(sample, engine.Stats())
+	}
+}
+
+// remove forgets a probe.
+func (s *Server) remove(id string) {
+	s.mu.Lock()
+	delete(s.probes, id)
+	s.mu.Unlock()
+}
+
+// StopProbe stops a probe started with StartProbe.
+func (s *Server) StopProbe(_ context.Context, req *controlv1.StopProbeRequest) (*controlv1.StopProbeResponse, error) {
+	p, err := s.lookup(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if p.cancel == nil {
+		return nil, status.Error(codes.FailedPrecondition, "the primary probe cannot be stopped")
+	}
+
+	p.cancel()
+	s.remove(p.id)
+	return &controlv1.StopProbeResponse{}, nil
+}
+
+// GetStats returns the current statistics of a probe.
+func (s *Server) GetStats(_ context.Context, req *controlv1.GetStatsRequest) (*controlv1.GetStatsResponse, error) {
+	p, err := s.lookup(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	st := p.stats
+	p.mu.Unlock()
+
+	return &controlv1.GetStatsResponse{Stats: toProtoStats(st)}, nil
+}
+
+// StreamSamples streams every new sample of a probe until the client cancels
+// or the probe stops.
+func (s *Server) StreamSamples(req *controlv1.StreamSamplesRequest, stream grpc.ServerStreamingServer[controlv1.Sample]) error {
+	p, err := s.lookup(req.GetId())
+	if err != nil {
+		return err
+	}
+
+	ch := p.subscribe()
+	defer p.unsubscribe(ch)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-p.done:
+			return nil
+		case sample := <-ch:
+			if err := stream.Send(toProtoSample(p.id, sample)); err != nil {
+				return fmt.Errorf("send sample: %w", err)
+			}
+		}
+	}
+}
+
+// toProtoStats converts engine statistics to the API message.
+func toProtoStats(st metrics.Stats) *controlv1.Stats {
+	return &controlv1.Stats{
+		TotalSamples:   int64(st.TotalSamples),
+		TotalSuccess:   int64(st.TotalSuccess),
+		TotalTimeouts:  int64(st.TotalTimeouts),
+		LossPercent:    st.LossPercent,
+		MinRttMs:       st.MinRTTMs,
+		AvgRttMs:       st.AvgRTTMs,
+		MaxRttMs:       st.MaxRTTMs,
+		StddevMs:       st.StdDevMs,
+		JitterMs:       st.JitterMs,
+		LastRttMs:      st.LastRTTMs,
+		P50Ms:          st.Percentiles.P50,
+		P90Ms:          st.Percentiles.P90,
+		P95Ms:          st.Percentiles.P95,
+		P99Ms:          st.Percentiles.P99,
+		CurrentStreak:  int64(st.CurrentStreak),
+		LossBursts:     int64(st.LossBursts),
+		BrownoutBursts: int64(st.BrownoutBursts),
+		InBrownout:     st.InBrownout,
+		UptimeSeconds:  st.UptimeSeconds,
+	}
+}
+
+// toProtoSample converts a sample to the API message.
+func toProtoSample(probeID string, sample types.Sample) *controlv1.Sample {
+	msg := &controlv1.Sample{
+		ProbeId:   probeID,
+		Timestamp: timestamppb.New(sample.Timestamp),
+		Seq:       int64(sample.Sequence),
+		Timeout:   sample.Timeout,
+	}
+	if !sample.Timeout {
+		msg.Rtt = durationpb.New(sample.RTT)
+	}
+	return msg
+}
@@ -0,0 +1,255 @@
+package control
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/ping"
+	"github.com/pbv7/pingheat/internal/types"
+	"github.com/pbv7/pingheat/pkg/api/controlv1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// tickRunner emits a 10ms sample every millisecond until cancelled.
+type tickRunner struct{}
+
+func (tickRunner) Run(ctx context.Context, samples chan<- ping.Sample) error {
+	for seq := 1; ; seq++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Millisecond):
+			samples <- ping.Sample{Timestamp: time.Now(), Sequence: seq, RTT: 10 * time.Millisecond}
+		}
+	}
+}
+
+// newTestClient serves s over an in-memory listener.
+func newTestClient(t *testing.T, s *Server) controlv1.ControlServiceClient {
+	t.Helper()
+	s.SetRunnerFactory(func(string, time.Duration) Runner { return tickRunner{} })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lis := bufconn.Listen(1 << 20)
+	done := make(chan error, 1)
+	go func() { done <- s.serve(ctx, lis) }()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("serve() error = %v", err)
+		}
+	})
+	return controlv1.NewControlServiceClient(conn)
+}
+
+func wantCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	if status.Code(err) != code {
+		t.Fatalf("error = %v, want code %v", err, code)
+	}
+}
+
+func TestPrimaryProbe(t *testing.T) {
+	s := NewServer("", "1.1.1.1", time.Second)
+	client := newTestClient(t, s)
+	ctx := context.Background()
+
+	list, err := client.ListProbes(ctx, &controlv1.ListProbesRequest{})
+	if err != nil {
+		t.Fatalf("ListProbes() error = %v", err)
+	}
+	if len(list.Probes) != 1 || list.Probes[0].Id != PrimaryID || !list.Probes[0].Primary || list.Probes[0].Target != "1.1.1.1" {
+		t.Fatalf("ListProbes() = %v, want only the primary probe", list.Probes)
+	}
+
+	s.SetInterval(500 * time.Millisecond)
+	list, err = client.ListProbes(ctx, &controlv1.ListProbesRequest{})
+	if err != nil {
+		t.Fatalf("ListProbes() error = %v", err)
+	}
+	if got := list.Probes[0].Interval.AsDuration(); got != 500*time.Millisecond {
+		t.Fatalf("primary interval after SetInterval = %v, want 500ms", got)
+	}
+
+	s.Update(types.Sample{RTT: 12 * time.Millisecond}, metrics.Stats{TotalSamples: 7, Percentiles: metrics.Percentiles{P95: 12}})
+	resp, err := client.GetStats(ctx, &controlv1.GetStatsRequest{})
+	if err != nil {
+		t.Fatalf("GetStats() error = %v", err)
+	}
+	if resp.Stats.TotalSamples != 7 || resp.Stats.P95Ms != 12 {
+		t.Fatalf("GetStats() = %v", resp.Stats)
+	}
+
+	_, err = client.StopProbe(ctx, &controlv1.StopProbeRequest{Id: PrimaryID})
+	wantCode(t, err, codes.FailedPrecondition)
+}
+
+func TestStreamPrimarySamples(t *testing.T) {
+	s := NewServer("", "1.1.1.1", time.Second)
+	client := newTestClient(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.StreamSamples(ctx, &controlv1.StreamSamplesRequest{})
+	if err != nil {
+		t.Fatalf("StreamSamples() error = %v", err)
+	}
+
+	// The subscription is registered asynchronously; keep publishing until
+	// the first sample arrives
+	go func() {
+		for seq := 1; ctx.Err() == nil; seq++ {
+			s.Update(types.Sample{Sequence: seq, Timeout: true}, metrics.Stats{})
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+
+	msg, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() error = %v", err)
+	}
+	if msg.ProbeId != PrimaryID || !msg.Timeout || msg.Rtt != nil {
+		t.Fatalf("Recv() = %v, want primary timeout without rtt", msg)
+	}
+}
+
+func TestStartAndStopProbe(t *testing.T) {
+	s := NewServer("", "1.1.1.1", time.Second)
+	client := newTestClient(t, s)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	started, err := client.StartProbe(ctx, &controlv1.StartProbeRequest{Target: "8.8.8.8", Interval: durationpb.New(time.Second)})
+	if err != nil {
+		t.Fatalf("StartProbe() error = %v", err)
+	}
+	id := started.Probe.Id
+	if started.Probe.Primary || started.Probe.Target != "8.8.8.8" {
+		t.Fatalf("StartProbe() = %v", started.Probe)
+	}
+
+	stream, err := client.StreamSamples(ctx, &controlv1.StreamSamplesRequest{Id: id})
+	if err != nil {
+		t.Fatalf("StreamSamples() error = %v", err)
+	}
+	msg, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() error = %v", err)
+	}
+	if msg.ProbeId != id || msg.Rtt.AsDuration() != 10*time.Millisecond {
+		t.Fatalf("Recv() = %v", msg)
+	}
+
+	resp, err := client.GetStats(ctx, &controlv1.GetStatsRequest{Id: id})
+	if err != nil {
+		t.Fatalf("GetStats() error = %v", err)
+	}
+	if resp.Stats.TotalSamples == 0 {
+		t.Fatalf("GetStats() = %v, want samples", resp.Stats)
+	}
+
+	if _, err := client.StopProbe(ctx, &controlv1.StopProbeRequest{Id: id}); err != nil {
+		t.Fatalf("StopProbe() error = %v", err)
+	}
+	_, err = client.GetStats(ctx, &controlv1.GetStatsRequest{Id: id})
+	wantCode(t, err, codes.NotFound)
+
+	// The open stream ends once the probe has stopped
+	for {
+		if _, err := stream.Recv(); err != nil {
+			break
+		}
+	}
+}
+
+func TestStartProbeRunnerFactory(t *testing.T) {
+	s := NewServer("", "1.1.1.1", time.Second)
+	client := newTestClient(t, s)
+	type call struct {
+		target   string
+		interval time.Duration
+	}
+	calls := make(chan call, 1)
+	s.SetRunnerFactory(func(target string, interval time.Duration) Runner {
+		calls <- call{target, interval}
+		return tickRunner{}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := client.StartProbe(ctx, &controlv1.StartProbeRequest{Target: "8.8.8.8", Interval: durationpb.New(2 * time.Second)}); err != nil {
+		t.Fatalf("StartProbe() error = %v", err)
+	}
+	select {
+	case c := <-calls:
+		if c != (call{"8.8.8.8", 2 * time.Second}) {
+			t.Fatalf("factory called with %+v, want the started probe's target and interval", c)
+		}
+	case <-ctx.Done():
+		t.Fatalf("StartProbe() did not use the runner factory")
+	}
+}
+
+func TestStartProbeLimit(t *testing.T) {
+	s := NewServer("", "1.1.1.1", time.Second)
+	client := newTestClient(t, s)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req := &controlv1.StartProbeRequest{Target: "8.8.8.8"}
+	var last string
+	for range MaxProbes {
+		started, err := client.StartProbe(ctx, req)
+		if err != nil {
+			t.Fatalf("StartProbe() error = %v", err)
+		}
+		last = started.Probe.Id
+	}
+	_, err := client.StartProbe(ctx, req)
+	wantCode(t, err, codes.ResourceExhausted)
+
+	// Stopping one makes room again
+	if _, err := client.StopProbe(ctx, &controlv1.StopProbeRequest{Id: last}); err != nil {
+		t.Fatalf("StopProbe() error = %v", err)
+	}
+	if _, err := client.StartProbe(ctx, req); err != nil {
+		t.Fatalf("StartProbe() after StopProbe error = %v", err)
+	}
+}
+
+func TestStartProbeValidation(t *testing.T) {
+	client := newTestClient(t, NewServer("", "1.1.1.1", time.Second))
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		req  *controlv1.StartProbeRequest
+	}{
+		{"empty target", &controlv1.StartProbeRequest{}},
+		{"flag-like target", &controlv1.StartProbeRequest{Target: "-f"}},
+		{"interval too short", &controlv1.StartProbeRequest{Target: "8.8.8.8", Interval: durationpb.New(time.Millisecond)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.StartProbe(ctx, tt.req)
+			wantCode(t, err, codes.InvalidArgument)
+		})
+	}
+}
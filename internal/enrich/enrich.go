@@ -0,0 +1,294 @@
+// Package enrich tells who a target belongs to: its reverse DNS name, the
+// autonomous system announcing its address and the country the address is
+// registered in. ASN and country come from the Team Cymru IP-to-ASN
+// service, asked over DNS through the system resolver, so no database has
+// to be installed.
+package enrich
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/log"
+)
+
+// RetryInterval is how long an Enricher waits before retrying a failed
+// lookup.
+const RetryInterval = time.Minute
+
+// Zones of the Team Cymru IP-to-ASN service.
+const (
+	originZone  = "origin.asn.cymru.com"
+	origin6Zone = "origin6.asn.cymru.com"
+	asnZone     = "asn.cymru.com"
+)
+
+// errNoAddress is returned for a target that resolves to no address.
+var errNoAddress = errors.New("no address")
+
+// Info describes a target's address. Fields that could not be looked up
+// are zero.
+type Info struct {
+	Target   string // The target as given
+	Addr     string // Its address
+	Hostname string // Reverse DNS name, without the trailing dot
+	ASN      int    // Origin autonomous system
+	ASName   string // Name of the autonomous system, as in "GOOGLE - Google LLC"
+	Country  string // ISO 3166 country code the address is registered in
+}
+
+// IsZero reports whether nothing is known about the target.
+func (i Info) IsZero() bool {
+	return i.Addr == ""
+}
+
+// Summary describes i in a few words, as in "dns.google, AS15169 GOOGLE,
+// US": the hostname unless it is the target, the ASN with the short name
+// of its holder, and the country. Unknown parts are left out.
+func (i Info) Summary() string {
+	var parts []string
+	if i.Hostname != "" && !strings.EqualFold(i.Hostname, i.Target) {
+		parts = append(parts, i.Hostname)
+	}
+	if i.ASN != 0 {
+		as := "AS" + strconv.Itoa(i.ASN)
+		if handle, _, _ := strings.Cut(i.ASName, " - "); handle != "" {
+			as += " " + handle
+		}
+		parts = append(parts, as)
+	}
+	if i.Country != "" {
+		parts = append(parts, i.Country)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Resolver is the part of net.Resolver lookups use.
+type Resolver interface {
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// Lookup looks up target, a hostname or an IP address. Reverse DNS and ASN
+// lookups that fail leave their fields zero; the error reports a target
+// with no address, or an address neither lookup knows anything about.
+// Private and other non-public addresses get only reverse DNS.
+func Lookup(ctx context.Context, r Resolver, target string) (Info, error) {
+	info := Info{Target: target}
+	addr, err := netip.ParseAddr(target)
+	if err != nil {
+		addrs, err := r.LookupNetIP(ctx, "ip", target)
+		if err != nil {
+			return info, err
+		}
+		if len(addrs) == 0 {
+			return info, fmt.Errorf("%s: %w", target, errNoAddress)
+		}
+		addr = addrs[0]
+	}
+	addr = addr.Unmap()
+	info.Addr = addr.String()
+
+	names, errRDNS := r.LookupAddr(ctx, info.Addr)
+	if errRDNS == nil && len(names) > 0 {
+		info.Hostname = strings.TrimSuffix(names[0], ".")
+	}
+	if !isPublic(addr) {
+		return info, nil
+	}
+
+	errASN := lookupOrigin(ctx, r, addr, &info)
+	if errASN == nil {
+		// The AS name is a nicety; the origin alone is worth showing
+		_ = lookupASName(ctx, r, &info)
+	}
+	if info.Hostname == "" && info.ASN == 0 {
+		return info, fmt.Errorf("%s: %w", info.Addr, errors.Join(errRDNS, errASN))
+	}
+	return info, nil
+}
+
+// isPublic reports whether addr is routed on the internet, so that an
+// origin AS can exist for it.
+func isPublic(addr netip.Addr) bool {
+	return addr.IsGlobalUnicast() && !addr.IsPrivate()
+}
+
+// lookupOrigin fills in the origin ASN and country of addr.
+func lookupOrigin(ctx context.Context, r Resolver, addr netip.Addr, info *Info) error {
+	txts, err := r.LookupTXT(ctx, originName(addr))
+	if err != nil {
+		return err
+	}
+	// "15169 | 8.8.8.0/24 | US | arin | 2023-12-28"; an address announced
+	// by several ASes lists them all in the first field
+	for _, txt := range txts {
+		f := cymruFields(txt)
+		if len(f) < 3 {
+			continue
+		}
+		asns := strings.Fields(f[0])
+		if len(asns) == 0 {
+			continue
+		}
+		asn, err := strconv.Atoi(asns[0])
+		if err != nil {
+			continue
+		}
+		info.ASN, info.Country = asn, f[2]
+		return nil
+	}
+	return fmt.Errorf("unexpected origin record %q", txts)
+}
+
+// lookupASName fills in the name of info.ASN.
+func lookupASName(ctx context.Context, r Resolver, info *Info) error {
+	txts, err := r.LookupTXT(ctx, "AS"+strconv.Itoa(info.ASN)+"."+asnZone)
+	if err != nil {
+		return err
+	}
+	// "15169 | US | arin | 2000-03-30 | GOOGLE - Google LLC, US"
+	for _, txt := range txts {
+		f := cymruFields(txt)
+		if len(f) < 5 {
+			continue
+		}
+		name := f[4]
+		if cc := f[1]; cc != "" {
+			name = strings.TrimSuffix(name, ", "+cc)
+		}
+		info.ASName = name
+		return nil
+	}
+	return fmt.Errorf("unexpected AS record %q", txts)
+}
+
+// cymruFields splits a Team Cymru TXT record into its trimmed fields.
+func cymruFields(txt string) []string {
+	f := strings.Split(txt, "|")
+	for i := range f {
+		f[i] = strings.TrimSpace(f[i])
+	}
+	return f
+}
+
+// originName returns the name whose TXT record holds the origin of addr:
+// its octets, or the nibbles of an IPv6 address, reversed under the origin
+// zone.
+func originName(addr netip.Addr) string {
+	var labels []string
+	if addr.Is4() {
+		for _, b := range addr.As4() {
+			labels = append(labels, strconv.Itoa(int(b)))
+		}
+	} else {
+		for _, b := range addr.As16() {
+			labels = append(labels, strconv.FormatUint(uint64(b>>4), 16), strconv.FormatUint(uint64(b&0xf), 16))
+		}
+	}
+	var b strings.Builder
+	for i := len(labels) - 1; i >= 0; i-- {
+		b.WriteString(labels[i])
+		b.WriteByte('.')
+	}
+	if addr.Is4() {
+		b.WriteString(originZone)
+	} else {
+		b.WriteString(origin6Zone)
+	}
+	return b.String()
+}
+
+// Enricher looks up the target in the background and keeps the result,
+// following target switches.
+type Enricher struct {
+	resolver Resolver
+	retry    time.Duration
+	logger   *slog.Logger
+	switched chan struct{}
+
+	mu     sync.RWMutex
+	target string
+	info   Info
+}
+
+// NewEnricher creates an Enricher for target using the system resolver.
+func NewEnricher(target string) *Enricher {
+	return &Enricher{
+		resolver: net.DefaultResolver,
+		retry:    RetryInterval,
+		logger:   log.Discard(),
+		switched: make(chan struct{}, 1),
+		target:   target,
+	}
+}
+
+// SetLogger sets the logger for lookup results and failures. Call it
+// before Run.
+func (e *Enricher) SetLogger(logger *slog.Logger) {
+	e.logger = logger
+}
+
+// Info returns what is known about the current target, a zero Info until
+// its first lookup finishes.
+func (e *Enricher) Info() Info {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.info
+}
+
+// SetTarget forgets the previous target and looks up target instead.
+func (e *Enricher) SetTarget(target string) {
+	e.mu.Lock()
+	e.target, e.info = target, Info{}
+	e.mu.Unlock()
+	select {
+	case e.switched <- struct{}{}:
+	default:
+	}
+}
+
+// Run looks up the target, and each target switched to, until ctx is
+// cancelled. A failed lookup is retried after the retry interval.
+func (e *Enricher) Run(ctx context.Context) {
+	for {
+		e.mu.RLock()
+		target := e.target
+		e.mu.RUnlock()
+
+		var retry <-chan time.Time
+		info, err := Lookup(ctx, e.resolver, target)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			e.logger.Warn("cannot look up target", "target", target, "err", err)
+			retry = time.After(e.retry)
+		} else {
+			e.logger.Info("looked up target", "target", target, "addr", info.Addr,
+				"hostname", info.Hostname, "asn", info.ASN, "country", info.Country)
+		}
+
+		e.mu.Lock()
+		if e.target == target {
+			e.info = info
+		}
+		e.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.switched:
+		case <-retry:
+		}
+	}
+}
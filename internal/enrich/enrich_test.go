@@ -0,0 +1,133 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+// fakeResolver answers from fixed records; missing names fail.
+type fakeResolver struct {
+	hosts map[string][]netip.Addr
+	ptr   map[string][]string
+	txt   map[string][]string
+}
+
+var errNoSuchHost = errors.New("no such host")
+
+func (r fakeResolver) LookupNetIP(_ context.Context, _, host string) ([]netip.Addr, error) {
+	if addrs, ok := r.hosts[host]; ok {
+		return addrs, nil
+	}
+	return nil, errNoSuchHost
+}
+
+func (r fakeResolver) LookupAddr(_ context.Context, addr string) ([]string, error) {
+	if names, ok := r.ptr[addr]; ok {
+		return names, nil
+	}
+	return nil, errNoSuchHost
+}
+
+func (r fakeResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	if txts, ok := r.txt[name]; ok {
+		return txts, nil
+	}
+	return nil, errNoSuchHost
+}
+
+var google = fakeResolver{
+	hosts: map[string][]netip.Addr{
+		"dns.google":   {netip.MustParseAddr("8.8.8.8")},
+		"router.local": {netip.MustParseAddr("192.168.1.1")},
+	},
+	ptr: map[string][]string{
+		"8.8.8.8":              {"dns.google."},
+		"2001:4860:4860::8888": {"dns.google."},
+		"192.168.1.1":          {"router.lan."},
+	},
+	txt: map[string][]string{
+		"8.8.8.8.origin.asn.cymru.com": {"15169 | 8.8.8.0/24 | US | arin | 2023-12-28"},
+		"8.8.8.8.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.6.8.4.0.6.8.4.1.0.0.2.origin6.asn.cymru.com": {
+			"15169 36040 | 2001:4860::/32 | US | arin | 2005-03-14",
+		},
+		"AS15169.asn.cymru.com": {"15169 | US | arin | 2000-03-30 | GOOGLE - Google LLC, US"},
+	},
+}
+
+func TestLookup(t *testing.T) {
+	want := Info{Addr: "8.8.8.8", Hostname: "dns.google", ASN: 15169, ASName: "GOOGLE - Google LLC", Country: "US"}
+	tests := []struct {
+		target string
+		want   Info
+	}{
+		{"8.8.8.8", want},
+		{"dns.google", want},
+		{"2001:4860:4860::8888", Info{Addr: "2001:4860:4860::8888", Hostname: "dns.google", ASN: 15169,
+			ASName: "GOOGLE - Google LLC", Country: "US"}},
+		{"::ffff:8.8.8.8", want},
+		// Private addresses have no origin AS
+		{"router.local", Info{Addr: "192.168.1.1", Hostname: "router.lan"}},
+	}
+	for _, tt := range tests {
+		tt.want.Target = tt.target
+		got, err := Lookup(context.Background(), google, tt.target)
+		if err != nil || got != tt.want {
+			t.Fatalf("Lookup(%q) = %+v, %v, want %+v", tt.target, got, err, tt.want)
+		}
+	}
+
+	if _, err := Lookup(context.Background(), google, "missing.example"); !errors.Is(err, errNoSuchHost) {
+		t.Fatalf("Lookup() of an unknown host error = %v, want errNoSuchHost", err)
+	}
+	got, err := Lookup(context.Background(), google, "203.0.113.9")
+	if err == nil || got.Addr != "203.0.113.9" {
+		t.Fatalf("Lookup() of an unknown address = %+v, %v, want its address and an error", got, err)
+	}
+}
+
+func TestInfoSummary(t *testing.T) {
+	tests := []struct {
+		info Info
+		want string
+	}{
+		{Info{Target: "8.8.8.8", Hostname: "dns.google", ASN: 15169, ASName: "GOOGLE - Google LLC", Country: "US"},
+			"dns.google, AS15169 GOOGLE, US"},
+		{Info{Target: "dns.google", Hostname: "dns.google", ASN: 15169, Country: "US"}, "AS15169, US"},
+		{Info{Target: "192.168.1.1", Hostname: "router.lan"}, "router.lan"},
+		{Info{Target: "203.0.113.9", Addr: "203.0.113.9"}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.info.Summary(); got != tt.want {
+			t.Fatalf("%+v.Summary() = %q, want %q", tt.info, got, tt.want)
+		}
+	}
+}
+
+func TestEnricherSetTarget(t *testing.T) {
+	e := NewEnricher("8.8.8.8")
+	e.resolver = google
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go e.Run(ctx)
+
+	waitFor := func(hostname string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for e.Info().Hostname != hostname {
+			if time.Now().After(deadline) {
+				t.Fatalf("Info() = %+v, want hostname %q", e.Info(), hostname)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	waitFor("dns.google")
+
+	e.SetTarget("router.local")
+	if info := e.Info(); info.Target == "8.8.8.8" {
+		t.Fatalf("Info() after SetTarget = %+v, want the old target forgotten", info)
+	}
+	waitFor("router.lan")
+}
@@ -0,0 +1,372 @@
+package exporter
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/alert"
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/types"
+	"github.com/pbv7/pingheat/pkg/version"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Descriptions of the metrics derived from the statistics, built from the
+// latest snapshot at scrape time.
+var (
+	targetLabels = []string{"target"}
+	bucketLabels = []string{"target", "bucket"}
+	periodLabels = []string{"target", "period"}
+
+	// Counters
+	sentDesc = prometheus.NewDesc("pingheat_ping_sent_total",
+		"Total number of ping packets sent", targetLabels, nil)
+	successDesc = prometheus.NewDesc("pingheat_ping_success_total",
+		"Total number of successful ping responses", targetLabels, nil)
+	timeoutDesc = prometheus.NewDesc("pingheat_ping_timeout_total",
+		"Total number of ping timeouts", targetLabels, nil)
+	errorsDesc = prometheus.NewDesc("pingheat_ping_errors_total",
+		"Total number of failed pings by kind (timeout, host_unreachable, net_unreachable, ttl_exceeded, admin_prohibited)",
+		[]string{"target", "kind"}, nil)
+	droppedDesc = prometheus.NewDesc("pingheat_dropped_messages_total",
+		"Messages discarded because the TUI fell behind (channel: ui=samples, stats=stats updates)",
+		[]string{"channel"}, nil)
+	parserMissesDesc = prometheus.NewDesc("pingheat_parser_misses_total",
+		"Ping output lines that were neither a sample nor a known banner or statistics line", nil, nil)
+
+	// Latency
+	latencyDesc = prometheus.NewDesc("pingheat_ping_latency_ms",
+		"Ping latency in milliseconds (min, avg, max)", []string{"target", "stat"}, nil)
+	stdDevDesc = prometheus.NewDesc("pingheat_ping_stddev_ms",
+		"Standard deviation of ping latency in milliseconds", targetLabels, nil)
+	varianceDesc = prometheus.NewDesc("pingheat_ping_variance_ms2",
+		"Variance of ping latency in milliseconds squared", targetLabels, nil)
+	jitterDesc = prometheus.NewDesc("pingheat_ping_jitter_ms",
+		"Ping jitter (mean absolute deviation) in milliseconds", targetLabels, nil)
+	lastRTTDesc = prometheus.NewDesc("pingheat_ping_last_rtt_ms",
+		"Most recent ping RTT in milliseconds (-1 if last was timeout)", targetLabels, nil)
+
+	// Percentiles
+	p50Desc = prometheus.NewDesc("pingheat_ping_latency_p50_ms",
+		"50th percentile (median) latency in milliseconds", targetLabels, nil)
+	p90Desc = prometheus.NewDesc("pingheat_ping_latency_p90_ms",
+		"90th percentile latency in milliseconds", targetLabels, nil)
+	p95Desc = prometheus.NewDesc("pingheat_ping_latency_p95_ms",
+		"95th percentile latency in milliseconds", targetLabels, nil)
+	p99Desc = prometheus.NewDesc("pingheat_ping_latency_p99_ms",
+		"99th percentile latency in milliseconds", targetLabels, nil)
+
+	// Availability
+	lossDesc = prometheus.NewDesc("pingheat_ping_loss_percent",
+		"Packet loss percentage (0-100)", targetLabels, nil)
+	availDesc = prometheus.NewDesc("pingheat_ping_availability_percent",
+		"Availability percentage (0-100)", targetLabels, nil)
+
+	// Streaks
+	currentStreakDesc = prometheus.NewDesc("pingheat_ping_current_streak",
+		"Current streak (positive=success, negative=timeout)", targetLabels, nil)
+	longestSuccessDesc = prometheus.NewDesc("pingheat_ping_longest_success_streak",
+		"Longest consecutive successful pings", targetLabels, nil)
+	longestTimeoutDesc = prometheus.NewDesc("pingheat_ping_longest_timeout_streak",
+		"Longest consecutive timeout streak", targetLabels, nil)
+
+	// Instability patterns; totals are gauges since they restart with the
+	// statistics
+	lossBurstsDesc = prometheus.NewDesc("pingheat_ping_loss_bursts_total",
+		"Number of separate packet loss burst events (outages)", targetLabels, nil)
+	brownoutSamplesDesc = prometheus.NewDesc("pingheat_ping_brownout_samples_total",
+		"Total number of high-latency samples (>200ms)", targetLabels, nil)
+	brownoutBurstsDesc = prometheus.NewDesc("pingheat_ping_brownout_bursts_total",
+		"Number of brownout events (transitions to high latency)", targetLabels, nil)
+	outageDurationDesc = prometheus.NewDesc("pingheat_ping_outage_duration_seconds",
+		"Duration of finished packet loss bursts, from the first missed to the first recovered sample",
+		targetLabels, nil)
+	longestOutageDesc = prometheus.NewDesc("pingheat_ping_longest_outage_seconds",
+		"Longest finished packet loss burst", targetLabels, nil)
+	downtimeDesc = prometheus.NewDesc("pingheat_ping_downtime_seconds_total",
+		"Time spent in packet loss bursts, including the current one up to its latest missed sample",
+		targetLabels, nil)
+	inBrownoutDesc = prometheus.NewDesc("pingheat_ping_in_brownout",
+		"Currently in brownout state (1=yes, 0=no)", targetLabels, nil)
+	burstIntervalsDesc = prometheus.NewDesc("pingheat_ping_burst_intervals_total",
+		"Intervals probed with -burst, by whether they lost none, some or all of their probes",
+		[]string{"target", "result"}, nil)
+	weakSignalDesc = prometheus.NewDesc("pingheat_ping_weak_signal_samples_total",
+		"Samples taken with -wifi while the signal was at or below -70dBm, by whether they were lost",
+		[]string{"target", "result"}, nil)
+
+	// Timing and reachability
+	uptimeDesc = prometheus.NewDesc("pingheat_uptime_seconds",
+		"Seconds since monitoring started", targetLabels, nil)
+	upDesc = prometheus.NewDesc("pingheat_ping_up",
+		"Target is reachable (1=up, 0=down based on last ping)", targetLabels, nil)
+
+	// Trailing windows from per-minute buckets
+	bucketSamplesDesc = prometheus.NewDesc("pingheat_bucket_samples",
+		"Samples in the trailing window (1m, 5m, 15m, 1h, 24h)", bucketLabels, nil)
+	bucketLossDesc = prometheus.NewDesc("pingheat_bucket_loss_percent",
+		"Packet loss percentage in the trailing window (0-100)", bucketLabels, nil)
+	bucketLatencyDesc = prometheus.NewDesc("pingheat_bucket_latency_ms",
+		"Latency in the trailing window in milliseconds (min, avg, max, p95)",
+		[]string{"target", "bucket", "stat"}, nil)
+
+	// SLA per hour and calendar day
+	slaAvailDesc = prometheus.NewDesc("pingheat_sla_availability_percent",
+		"Availability per period (hour, previous_hour, day, previous_day) (0-100)", periodLabels, nil)
+	slaP95Desc = prometheus.NewDesc("pingheat_sla_p95_ms",
+		"95th percentile latency per period in milliseconds", periodLabels, nil)
+
+	// Latency SLO error budget and burn-rate alerts
+	sloBudgetDesc = prometheus.NewDesc("pingheat_slo_error_budget_remaining",
+		"Fraction of the SLO error budget left in the SLO window (1 = untouched, negative = exhausted)",
+		targetLabels, nil)
+	sloComplianceDesc = prometheus.NewDesc("pingheat_slo_compliance_percent",
+		"Samples meeting the SLO latency threshold in the SLO window (0-100)", targetLabels, nil)
+	sloBurnRateDesc = prometheus.NewDesc("pingheat_slo_burn_rate",
+		"Error budget burn rate per alerting window (1 = spending exactly the budget)",
+		[]string{"target", "window"}, nil)
+	sloAlertFiringDesc = prometheus.NewDesc("pingheat_slo_alert_firing",
+		"Whether an SLO burn-rate alert is firing (1) or not (0)",
+		[]string{"target", "alertname", "severity"}, nil)
+
+	// Info gauges, always 1, carrying build and configuration as labels
+	buildInfoDesc = prometheus.NewDesc("pingheat_build_info",
+		"Build information of the running pingheat (always 1)",
+		[]string{"version", "commit", "go_version"}, nil)
+	configInfoDesc = prometheus.NewDesc("pingheat_config_info",
+		"Probe configuration (always 1)", []string{"target", "interval"}, nil)
+)
+
+// counts are the running totals of a target exported as counters.
+type counts struct {
+	sent, success, timeouts int
+	errors                  [types.ErrorKindCount]int
+	created                 time.Time // When the totals started from zero
+}
+
+// add returns c plus the totals of stats.
+func (c counts) add(stats metrics.Stats) counts {
+	c.sent += stats.TotalSamples
+	c.success += stats.TotalSuccess
+	c.timeouts += stats.TotalTimeouts
+	for k, n := range stats.ErrorCounts {
+		c.errors[k] += n
+	}
+	return c
+}
+
+// snapshot is what a scrape exports, copied under the exporter's lock so
+// the series of one scrape agree with each other.
+type snapshot struct {
+	target     string
+	interval   time.Duration
+	stats      metrics.Stats
+	sampled    bool
+	totals     counts
+	retired    map[string]counts
+	aggregator *metrics.Aggregator
+	sla        *metrics.SLATracker
+	slo        *alert.Tracker
+}
+
+// statsCollector exports the exporter's latest statistics. Update only
+// stores a snapshot; the series are built when scraped.
+type statsCollector struct {
+	e *Exporter
+}
+
+func (c statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		sentDesc, successDesc, timeoutDesc, errorsDesc, droppedDesc, parserMissesDesc,
+		latencyDesc, stdDevDesc, varianceDesc, jitterDesc, lastRTTDesc,
+		p50Desc, p90Desc, p95Desc, p99Desc,
+		lossDesc, availDesc,
+		currentStreakDesc, longestSuccessDesc, longestTimeoutDesc,
+		lossBurstsDesc, outageDurationDesc, longestOutageDesc, downtimeDesc,
+		brownoutSamplesDesc, brownoutBurstsDesc, inBrownoutDesc,
+		burstIntervalsDesc, weakSignalDesc,
+		uptimeDesc, upDesc,
+		bucketSamplesDesc, bucketLossDesc, bucketLatencyDesc,
+		slaAvailDesc, slaP95Desc,
+		sloBudgetDesc, sloComplianceDesc, sloBurnRateDesc, sloAlertFiringDesc,
+		buildInfoDesc, configInfoDesc,
+	} {
+		ch <- d
+	}
+}
+
+func (c statsCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.e.snapshot()
+	gauge := func(d *prometheus.Desc, v float64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v, labels...)
+	}
+
+	gauge(buildInfoDesc, 1, version.Version, version.Commit, runtime.Version())
+	gauge(configInfoDesc, 1, s.target, s.interval.String())
+
+	// Drop and miss counts span targets
+	ch <- prometheus.MustNewConstMetric(droppedDesc, prometheus.CounterValue, float64(s.stats.DroppedUISamples), "ui")
+	ch <- prometheus.MustNewConstMetric(droppedDesc, prometheus.CounterValue, float64(s.stats.DroppedStats), "stats")
+	ch <- prometheus.MustNewConstMetric(parserMissesDesc, prometheus.CounterValue, float64(s.stats.ParserMisses))
+
+	// Counters of previous targets keep their final values
+	for target, t := range s.retired {
+		collectCounts(ch, target, t)
+	}
+	if !s.sampled {
+		// Nothing is known about a new target before its first statistics
+		return
+	}
+	collectCounts(ch, s.target, s.totals)
+
+	stats, target := s.stats, s.target
+	gauge(lossDesc, stats.LossPercent, target)
+	gauge(availDesc, stats.AvailPercent, target)
+
+	gauge(currentStreakDesc, float64(stats.CurrentStreak), target)
+	gauge(longestSuccessDesc, float64(stats.LongestSuccess), target)
+	gauge(longestTimeoutDesc, float64(stats.LongestTimeout), target)
+
+	gauge(lossBurstsDesc, float64(stats.LossBursts), target)
+	ch <- outageHistogram(target, stats)
+	gauge(longestOutageDesc, stats.LongestOutage.Seconds(), target)
+	gauge(downtimeDesc, stats.Downtime.Seconds(), target)
+	gauge(brownoutSamplesDesc, float64(stats.BrownoutSamples), target)
+	gauge(brownoutBurstsDesc, float64(stats.BrownoutBursts), target)
+	gauge(inBrownoutDesc, boolValue(stats.InBrownout), target)
+	if stats.BurstIntervals > 0 {
+		clean := stats.BurstIntervals - stats.PartialLossIntervals - stats.FullLossIntervals
+		gauge(burstIntervalsDesc, float64(clean), target, "clean")
+		gauge(burstIntervalsDesc, float64(stats.PartialLossIntervals), target, "partial")
+		gauge(burstIntervalsDesc, float64(stats.FullLossIntervals), target, "lost")
+	}
+	if stats.SignalDbm != 0 || stats.WeakSignalSamples > 0 {
+		gauge(weakSignalDesc, float64(stats.WeakSignalSamples-stats.WeakSignalTimeouts), target, "success")
+		gauge(weakSignalDesc, float64(stats.WeakSignalTimeouts), target, "timeout")
+	}
+
+	gauge(uptimeDesc, stats.UptimeSeconds, target)
+	// Up if the last ping was successful (positive streak)
+	gauge(upDesc, boolValue(stats.CurrentStreak > 0), target)
+
+	// Latency only once there are replies
+	if stats.TotalSuccess > 0 {
+		gauge(latencyDesc, stats.MinRTTMs, target, "min")
+		gauge(latencyDesc, stats.AvgRTTMs, target, "avg")
+		gauge(latencyDesc, stats.MaxRTTMs, target, "max")
+
+		gauge(stdDevDesc, stats.StdDevMs, target)
+		gauge(varianceDesc, stats.VarianceMs, target)
+		gauge(jitterDesc, stats.JitterMs, target)
+
+		// -1 while the target is in a timeout streak
+		last := stats.LastRTTMs
+		if stats.CurrentStreak <= 0 {
+			last = -1
+		}
+		gauge(lastRTTDesc, last, target)
+
+		gauge(p50Desc, stats.Percentiles.P50, target)
+		gauge(p90Desc, stats.Percentiles.P90, target)
+		gauge(p95Desc, stats.Percentiles.P95, target)
+		gauge(p99Desc, stats.Percentiles.P99, target)
+	}
+
+	if s.aggregator != nil {
+		collectBuckets(ch, target, s.aggregator)
+	}
+	if s.sla != nil {
+		collectSLA(ch, target, "hour", "previous_hour", s.sla.Hours(),
+			func(t time.Time) time.Time { return t.Add(-time.Hour) })
+		collectSLA(ch, target, "day", "previous_day", s.sla.Days(),
+			func(t time.Time) time.Time { return t.AddDate(0, 0, -1) })
+	}
+	if s.slo != nil {
+		collectSLO(ch, target, s.slo.Status(time.Now()))
+	}
+}
+
+// collectCounts exports the counters of a target.
+func collectCounts(ch chan<- prometheus.Metric, target string, c counts) {
+	counter := func(d *prometheus.Desc, v int, labels ...string) {
+		ch <- prometheus.MustNewConstMetricWithCreatedTimestamp(d, prometheus.CounterValue, float64(v), c.created, labels...)
+	}
+	counter(sentDesc, c.sent, target)
+	counter(successDesc, c.success, target)
+	counter(timeoutDesc, c.timeouts, target)
+	for _, kind := range types.ErrorKinds {
+		counter(errorsDesc, c.errors[kind], target, kind.String())
+	}
+}
+
+// outageHistogram exports the durations of finished loss bursts.
+func outageHistogram(target string, stats metrics.Stats) prometheus.Metric {
+	buckets := make(map[float64]uint64, len(metrics.OutageBuckets))
+	var n uint64
+	for i, bound := range metrics.OutageBuckets {
+		n += uint64(stats.OutageCounts[i])
+		buckets[bound.Seconds()] = n
+	}
+	n += uint64(stats.OutageCounts[len(metrics.OutageBuckets)])
+	return prometheus.MustNewConstHistogram(outageDurationDesc, n, stats.OutageTotal.Seconds(), buckets, target)
+}
+
+// collectBuckets exports trailing windows of the per-minute buckets.
+func collectBuckets(ch chan<- prometheus.Metric, target string, a *metrics.Aggregator) {
+	gauge := func(d *prometheus.Desc, v float64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v, labels...)
+	}
+	for _, d := range metrics.Windows {
+		w := a.Window(d)
+		name := metrics.WindowName(d)
+		gauge(bucketSamplesDesc, float64(w.Samples), target, name)
+		gauge(bucketLossDesc, w.LossPercent, target, name)
+		if w.Samples == w.Timeouts {
+			continue
+		}
+		gauge(bucketLatencyDesc, w.MinMs, target, name, "min")
+		gauge(bucketLatencyDesc, w.AvgMs, target, name, "avg")
+		gauge(bucketLatencyDesc, w.MaxMs, target, name, "max")
+		gauge(bucketLatencyDesc, w.P95Ms, target, name, "p95")
+	}
+}
+
+// collectSLA exports the newest period as current and the one before it as
+// previous, when the two are adjacent.
+func collectSLA(ch chan<- prometheus.Metric, target, current, previous string, periods []metrics.SLAPeriod,
+	prev func(time.Time) time.Time) {
+	if len(periods) == 0 {
+		return
+	}
+	export := func(p metrics.SLAPeriod, period string) {
+		ch <- prometheus.MustNewConstMetric(slaAvailDesc, prometheus.GaugeValue, p.AvailPercent, target, period)
+		ch <- prometheus.MustNewConstMetric(slaP95Desc, prometheus.GaugeValue, p.P95Ms, target, period)
+	}
+	last := periods[len(periods)-1]
+	export(last, current)
+	if len(periods) >= 2 && periods[len(periods)-2].Start.Equal(prev(last.Start)) {
+		export(periods[len(periods)-2], previous)
+	}
+}
+
+// collectSLO exports the error budget, burn rates and alert states.
+func collectSLO(ch chan<- prometheus.Metric, target string, status alert.Status) {
+	ch <- prometheus.MustNewConstMetric(sloBudgetDesc, prometheus.GaugeValue, status.BudgetRemaining, target)
+	ch <- prometheus.MustNewConstMetric(sloComplianceDesc, prometheus.GaugeValue, status.CompliancePercent, target)
+	for _, r := range status.BurnRates {
+		ch <- prometheus.MustNewConstMetric(sloBurnRateDesc, prometheus.GaugeValue, r.Rate,
+			target, metrics.WindowName(r.Window))
+	}
+	for _, a := range status.Alerts {
+		ch <- prometheus.MustNewConstMetric(sloAlertFiringDesc, prometheus.GaugeValue, boolValue(a.Firing),
+			target, a.Name, string(a.Severity))
+	}
+}
+
+// boolValue exports a condition as 1 or 0.
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,37 @@
+package exporter
+
+import (
+	"strconv"
+
+	"github.com/pbv7/pingheat/internal/enrich"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// targetInfoDesc describes the target looked up with -enrich, read at
+// scrape time.
+var targetInfoDesc = prometheus.NewDesc("pingheat_target_info",
+	"Address, reverse DNS name, origin AS and country of the target (always 1)",
+	[]string{"target", "addr", "hostname", "asn", "as_name", "country"}, nil)
+
+// targetInfoCollector exports the target info reported by fn, nothing
+// until its lookup finishes.
+type targetInfoCollector struct {
+	fn func() enrich.Info
+}
+
+func (c targetInfoCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- targetInfoDesc
+}
+
+func (c targetInfoCollector) Collect(ch chan<- prometheus.Metric) {
+	i := c.fn()
+	if i.IsZero() {
+		return
+	}
+	asn := ""
+	if i.ASN != 0 {
+		asn = strconv.Itoa(i.ASN)
+	}
+	ch <- prometheus.MustNewConstMetric(targetInfoDesc, prometheus.GaugeValue, 1,
+		i.Target, i.Addr, i.Hostname, asn, i.ASName, i.Country)
+}
@@ -0,0 +1,53 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/pbv7/pingheat/internal/log"
+)
+
+// File appends the statistics to a file as JSON Lines, one record every
+// DefaultFlushInterval, for hosts without a metrics system.
+type File struct {
+	periodic
+	path string
+}
+
+// NewFile creates a backend appending to the file at path.
+func NewFile(path, target string) *File {
+	return &File{
+		periodic: periodic{interval: DefaultFlushInterval, target: target, logger: log.Discard()},
+		path:     path,
+	}
+}
+
+// newFileBackend creates a File backend for the path in spec.
+func newFileBackend(spec string, opts Options) (Backend, error) {
+	if spec == "" {
+		return nil, errors.New("missing file path")
+	}
+	return NewFile(spec, opts.Target), nil
+}
+
+// SetLogger makes the backend log failed writes to l.
+func (f *File) SetLogger(l *slog.Logger) {
+	f.logger = l.With("component", "file_export")
+}
+
+// Start opens the file and writes the statistics until ctx is cancelled.
+func (f *File) Start(ctx context.Context) error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("stats file: %w", err)
+	}
+	enc := json.NewEncoder(file)
+	f.run(ctx, func(_ context.Context, rec statsRecord) error {
+		return enc.Encode(rec)
+	})
+	return file.Close()
+}
@@ -0,0 +1,48 @@
+package exporter
+
+import (
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Descriptions of the self-monitoring metrics, read at scrape time.
+var (
+	goroutinesDesc = prometheus.NewDesc("pingheat_goroutines",
+		"Number of goroutines in the pingheat process", nil, nil)
+	heapBytesDesc = prometheus.NewDesc("pingheat_heap_bytes",
+		"Heap memory occupied by objects, in bytes", nil, nil)
+	processingDesc = prometheus.NewDesc("pingheat_sample_processing_seconds",
+		"Time spent distributing each sample to the stats, exporters and UI", nil, nil)
+	processingMaxDesc = prometheus.NewDesc("pingheat_sample_processing_max_seconds",
+		"Slowest single sample distribution since start", nil, nil)
+	channelDepthDesc = prometheus.NewDesc("pingheat_channel_depth",
+		"Messages waiting in a pipeline channel (samples, ui, stats)", []string{"channel"}, nil)
+	channelCapacityDesc = prometheus.NewDesc("pingheat_channel_capacity",
+		"Buffer size of a pipeline channel (samples, ui, stats)", []string{"channel"}, nil)
+)
+
+// healthCollector exports the app's own health as reported by fn.
+type healthCollector struct {
+	fn func() metrics.Health
+}
+
+func (c healthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- goroutinesDesc
+	ch <- heapBytesDesc
+	ch <- processingDesc
+	ch <- processingMaxDesc
+	ch <- channelDepthDesc
+	ch <- channelCapacityDesc
+}
+
+func (c healthCollector) Collect(ch chan<- prometheus.Metric) {
+	h := c.fn()
+	ch <- prometheus.MustNewConstMetric(goroutinesDesc, prometheus.GaugeValue, float64(h.Goroutines))
+	ch <- prometheus.MustNewConstMetric(heapBytesDesc, prometheus.GaugeValue, float64(h.HeapBytes))
+	ch <- prometheus.MustNewConstSummary(processingDesc, h.ProcessedSamples, h.ProcessingTime.Seconds(), nil)
+	ch <- prometheus.MustNewConstMetric(processingMaxDesc, prometheus.GaugeValue, h.ProcessingMax.Seconds())
+	for _, c := range h.Channels {
+		ch <- prometheus.MustNewConstMetric(channelDepthDesc, prometheus.GaugeValue, float64(c.Len), c.Name)
+		ch <- prometheus.MustNewConstMetric(channelCapacityDesc, prometheus.GaugeValue, float64(c.Cap), c.Name)
+	}
+}
@@ -0,0 +1,133 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pbv7/pingheat/internal/log"
+)
+
+// influxMeasurement names the points written to InfluxDB.
+const influxMeasurement = "pingheat"
+
+// tagEscaper escapes tag values in the line protocol.
+var tagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// Influx writes the statistics to an InfluxDB write endpoint in the line
+// protocol, one point every DefaultFlushInterval. The URL is the full write
+// endpoint: /api/v2/write?org=ORG&bucket=BUCKET for InfluxDB 2 and 3, or
+// /write?db=DB for 1.x. A token is sent as "Authorization: Token ...".
+type Influx struct {
+	periodic
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewInflux creates a backend writing to rawURL, authenticating with token
+// unless it is empty.
+func NewInflux(rawURL, token, target string) (*Influx, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("want an http(s) write URL, got %q", redactURL(rawURL))
+	}
+	return &Influx{
+		periodic: periodic{interval: DefaultFlushInterval, target: target, logger: log.Discard()},
+		url:      rawURL,
+		token:    token,
+		client:   &http.Client{Timeout: remoteWriteTimeout},
+	}, nil
+}
+
+// newInfluxBackend creates an Influx backend for the URL in spec, with the
+// token from $PINGHEAT_INFLUX_TOKEN.
+func newInfluxBackend(spec string, opts Options) (Backend, error) {
+	return NewInflux(spec, os.Getenv("PINGHEAT_INFLUX_TOKEN"), opts.Target)
+}
+
+// SetLogger makes the backend log failed writes to l.
+func (i *Influx) SetLogger(l *slog.Logger) {
+	i.logger = l.With("component", "influx")
+}
+
+// Start writes the statistics until ctx is cancelled.
+func (i *Influx) Start(ctx context.Context) error {
+	i.run(ctx, i.write)
+	return nil
+}
+
+// write posts one point.
+func (i *Influx) write(ctx context.Context, rec statsRecord) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.url, strings.NewReader(influxLine(rec)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if i.token != "" {
+		req.Header.Set("Authorization", "Token "+i.token)
+	}
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return fmt.Errorf("influx write: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// influxLine formats rec as a line protocol point with nanosecond precision.
+func influxLine(rec statsRecord) string {
+	var b strings.Builder
+	b.WriteString(influxMeasurement)
+	b.WriteString(",target=")
+	b.WriteString(tagEscaper.Replace(rec.Target))
+	fields := []struct {
+		name  string
+		value string
+	}{
+		{"samples", strconv.Itoa(rec.Samples) + "i"},
+		{"timeouts", strconv.Itoa(rec.Timeouts) + "i"},
+		{"loss_percent", lineFloat(rec.LossPercent)},
+		{"min_rtt_ms", lineFloat(rec.MinRTTMs)},
+		{"avg_rtt_ms", lineFloat(rec.AvgRTTMs)},
+		{"max_rtt_ms", lineFloat(rec.MaxRTTMs)},
+		{"stddev_ms", lineFloat(rec.StdDevMs)},
+		{"jitter_ms", lineFloat(rec.JitterMs)},
+		{"p50_ms", lineFloat(rec.P50Ms)},
+		{"p90_ms", lineFloat(rec.P90Ms)},
+		{"p95_ms", lineFloat(rec.P95Ms)},
+		{"p99_ms", lineFloat(rec.P99Ms)},
+		{"outages", strconv.Itoa(rec.Outages) + "i"},
+		{"downtime_s", lineFloat(rec.DowntimeS)},
+	}
+	for n, f := range fields {
+		if n == 0 {
+			b.WriteByte(' ')
+		} else {
+			b.WriteByte(',')
+		}
+		b.WriteString(f.name)
+		b.WriteByte('=')
+		b.WriteString(f.value)
+	}
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatInt(rec.Timestamp.UnixNano(), 10))
+	b.WriteByte('\n')
+	return b.String()
+}
+
+// lineFloat formats a line protocol float field.
+func lineFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,68 @@
+package exporter
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// openMetricsFormat is the exposition format of strict OpenMetrics mode.
+var openMetricsFormat = expfmt.NewFormat(expfmt.TypeOpenMetrics)
+
+// openMetricsGatherer adapts gathered metrics to strict OpenMetrics: base
+// units, and counters with a created timestamp for the gauges that hold
+// running totals. created returns when those totals last started from zero.
+type openMetricsGatherer struct {
+	g       prometheus.Gatherer
+	created func() time.Time
+}
+
+// Gather implements prometheus.Gatherer.
+func (o openMetricsGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := baseUnitsGatherer{o.g}.Gather()
+	created := timestamppb.New(o.created())
+	for _, mf := range families {
+		totalsToCounters(mf, created)
+	}
+	return families, err
+}
+
+// totalsToCounters turns a gauge family holding running totals, set from
+// the statistics, into a counter family created at created.
+func totalsToCounters(mf *dto.MetricFamily, created *timestamppb.Timestamp) {
+	if mf.GetType() != dto.MetricType_GAUGE || !strings.HasSuffix(mf.GetName(), "_total") {
+		return
+	}
+	mf.Type = dto.MetricType_COUNTER.Enum()
+	for _, m := range mf.Metric {
+		m.Counter = &dto.Counter{Value: m.Gauge.Value, CreatedTimestamp: created}
+		m.Gauge = nil
+	}
+}
+
+// openMetricsHandler serves g in strict OpenMetrics, with units and the
+// _created samples of counters.
+func openMetricsHandler(g prometheus.Gatherer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		families, err := g.Gather()
+		if err != nil && len(families) == 0 {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", string(openMetricsFormat))
+		enc := expfmt.NewEncoder(w, openMetricsFormat, expfmt.WithCreatedLines(), expfmt.WithUnit())
+		for _, mf := range families {
+			if err := enc.Encode(mf); err != nil {
+				return
+			}
+		}
+		if c, ok := enc.(expfmt.Closer); ok {
+			_ = c.Close() // The # EOF line
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package exporter
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestOpenMetricsHandler(t *testing.T) {
+	e := NewExporter(":0", "target", time.Second, nil)
+	e.SetOpenMetrics(true)
+	e.created = time.Unix(1700000000, 0)
+	reg := prometheus.NewRegistry()
+	e.register(reg)
+	e.Observe(types.Sample{Sequence: 1, RTT: 20 * time.Millisecond})
+	e.Update(metrics.Stats{TotalSamples: 4, TotalTimeouts: 1, TotalSuccess: 3, LossPercent: 25, LossBursts: 1,
+		AvgRTTMs: 20})
+
+	srv := httptest.NewServer(e.newServer(reg).Handler)
+	defer srv.Close()
+	resp, err := srv.Client().Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	out := string(body)
+
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/openmetrics-text") {
+		t.Fatalf("Content-Type = %q, want OpenMetrics", ct)
+	}
+	for _, want := range []string{
+		"# UNIT pingheat_ping_loss_ratio ratio\n",
+		`pingheat_ping_loss_ratio{target="target"} 0.25` + "\n",
+		`pingheat_ping_latency_seconds{stat="avg",target="target"} 0.02` + "\n",
+		"# TYPE pingheat_ping_loss_bursts counter\n",
+		`pingheat_ping_loss_bursts_total{target="target"} 1.0` + "\n",
+		`pingheat_ping_loss_bursts_created{target="target"} 1.7e+09` + "\n",
+		`pingheat_ping_sent_created{target="target"}`,
+		`pingheat_ping_rtt_histogram_seconds_bucket{target="target",le="0.02"} 1`,
+		"# EOF\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("exposition lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "_ms") || strings.Contains(out, "_percent") {
+		t.Fatalf("exposition keeps milliseconds or percentages:\n%s", out)
+	}
+}
+
+func TestExporterUnitsSeconds(t *testing.T) {
+	e := NewExporter(":0", "target", time.Second, nil)
+	e.SetUnits(UnitsSeconds)
+	reg := prometheus.NewRegistry()
+	e.register(reg)
+	e.Observe(types.Sample{Sequence: 1, RTT: 20 * time.Millisecond})
+	e.Update(metrics.Stats{TotalSamples: 4, TotalTimeouts: 1, TotalSuccess: 3, LossPercent: 25, AvgRTTMs: 20})
+
+	srv := httptest.NewServer(e.newServer(reg).Handler)
+	defer srv.Close()
+	resp, err := srv.Client().Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	out := string(body)
+
+	for _, want := range []string{
+		`pingheat_ping_loss_ratio{target="target"} 0.25` + "\n",
+		`pingheat_ping_latency_seconds{stat="avg",target="target"} 0.02` + "\n",
+		`pingheat_ping_rtt_seconds_count{target="target"} 1` + "\n",
+		`pingheat_ping_sent_total{target="target"} 4` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("exposition lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "_ms") || strings.Contains(out, "_percent") {
+		t.Fatalf("exposition keeps milliseconds or percentages:\n%s", out)
+	}
+}
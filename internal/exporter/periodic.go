@@ -0,0 +1,123 @@
+package exporter
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/metrics"
+)
+
+// DefaultFlushInterval is how often the influx and file backends write the
+// latest statistics.
+const DefaultFlushInterval = 15 * time.Second
+
+// statsRecord is the statistics written by the influx and file backends.
+type statsRecord struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Target      string    `json:"target"`
+	Samples     int       `json:"samples"`
+	Timeouts    int       `json:"timeouts"`
+	LossPercent float64   `json:"loss_percent"`
+	MinRTTMs    float64   `json:"min_rtt_ms"`
+	AvgRTTMs    float64   `json:"avg_rtt_ms"`
+	MaxRTTMs    float64   `json:"max_rtt_ms"`
+	StdDevMs    float64   `json:"stddev_ms"`
+	JitterMs    float64   `json:"jitter_ms"`
+	P50Ms       float64   `json:"p50_ms"`
+	P90Ms       float64   `json:"p90_ms"`
+	P95Ms       float64   `json:"p95_ms"`
+	P99Ms       float64   `json:"p99_ms"`
+	Outages     int       `json:"outages"`
+	DowntimeS   float64   `json:"downtime_s"`
+}
+
+// newStatsRecord converts the statistics of target as of now.
+func newStatsRecord(target string, s metrics.Stats, now time.Time) statsRecord {
+	return statsRecord{
+		Timestamp:   now,
+		Target:      target,
+		Samples:     s.TotalSamples,
+		Timeouts:    s.TotalTimeouts,
+		LossPercent: s.LossPercent,
+		MinRTTMs:    s.MinRTTMs,
+		AvgRTTMs:    s.AvgRTTMs,
+		MaxRTTMs:    s.MaxRTTMs,
+		StdDevMs:    s.StdDevMs,
+		JitterMs:    s.JitterMs,
+		P50Ms:       s.Percentiles.P50,
+		P90Ms:       s.Percentiles.P90,
+		P95Ms:       s.Percentiles.P95,
+		P99Ms:       s.Percentiles.P99,
+		Outages:     s.LossBursts,
+		DowntimeS:   s.Downtime.Seconds(),
+	}
+}
+
+// periodic keeps the latest statistics for backends that write them every
+// interval rather than after every sample.
+type periodic struct {
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu      sync.Mutex
+	target  string
+	stats   metrics.Stats
+	updated bool // Update ran since the last take
+}
+
+// Update keeps stats for the next write.
+func (p *periodic) Update(stats metrics.Stats) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stats = stats
+	p.updated = true
+}
+
+// SetTarget follows a target switch.
+func (p *periodic) SetTarget(target string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.target = target
+}
+
+// take returns the statistics as of now, or false if there was no update
+// since the last call.
+func (p *periodic) take(now time.Time) (statsRecord, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.updated {
+		return statsRecord{}, false
+	}
+	p.updated = false
+	return newStatsRecord(p.target, p.stats, now), true
+}
+
+// run calls write with new statistics every interval until ctx is
+// cancelled, then once more so the last values arrive. Failed writes are
+// logged and not retried; the next write carries current values anyway.
+func (p *periodic) run(ctx context.Context, write func(ctx context.Context, rec statsRecord) error) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if rec, ok := p.take(time.Now()); ok {
+				writeCtx, cancel := context.WithTimeout(context.Background(), remoteWriteTimeout)
+				if err := write(writeCtx, rec); err != nil {
+					p.logger.Warn("final export failed", "error", err)
+				}
+				cancel()
+			}
+			return
+		case now := <-ticker.C:
+			if rec, ok := p.take(now); ok {
+				if err := write(ctx, rec); err != nil && ctx.Err() == nil {
+					p.logger.Warn("export failed", "error", err)
+				}
+			}
+		}
+	}
+}
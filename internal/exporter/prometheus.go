@@ -2,251 +2,176 @@ package exporter
 
 import (
 	"context"
+	"maps"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/pbv7/pingheat/internal/alert"
+	"github.com/pbv7/pingheat/internal/enrich"
 	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/script"
+	"github.com/pbv7/pingheat/internal/types"
+	"github.com/pbv7/pingheat/internal/wifi"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// DefaultQuantiles are the RTT summary objectives used when none are configured.
+var DefaultQuantiles = []float64{0.5, 0.9, 0.99}
+
+// shutdownTimeout is how long a scrape in flight may take to finish when
+// the exporter stops.
+const shutdownTimeout = 3 * time.Second
+
+// rttBucketsMs are the upper bounds of the RTT histogram in milliseconds.
+var rttBucketsMs = []float64{1, 2, 5, 10, 20, 30, 50, 80, 100, 150, 200, 300, 500, 1000, 2000}
+
 // Exporter exports ping metrics to Prometheus.
 type Exporter struct {
-	addr   string
-	target string
-	server *http.Server
-
-	mu    sync.RWMutex
-	stats metrics.Stats
-
-	// Prometheus metrics - Counters
-	pingSentTotal    *prometheus.CounterVec
-	pingSuccessTotal *prometheus.CounterVec
-	pingTimeoutTotal *prometheus.CounterVec
-
-	// Gauges - Latency
-	pingLatencyMs  *prometheus.GaugeVec
-	pingStdDevMs   *prometheus.GaugeVec
-	pingVarianceMs *prometheus.GaugeVec
-	pingJitterMs   *prometheus.GaugeVec
-	pingLastRTTMs  *prometheus.GaugeVec
-
-	// Gauges - Percentiles
-	pingLatencyP50Ms *prometheus.GaugeVec
-	pingLatencyP90Ms *prometheus.GaugeVec
-	pingLatencyP95Ms *prometheus.GaugeVec
-	pingLatencyP99Ms *prometheus.GaugeVec
-
-	// Gauges - Availability
-	pingLossPercent  *prometheus.GaugeVec
-	pingAvailPercent *prometheus.GaugeVec
-
-	// Gauges - Streaks
-	pingCurrentStreak  *prometheus.GaugeVec
-	pingLongestSuccess *prometheus.GaugeVec
-	pingLongestTimeout *prometheus.GaugeVec
-
-	// Gauges - Instability patterns
-	pingLossBursts      *prometheus.GaugeVec
-	pingBrownoutSamples *prometheus.GaugeVec
-	pingBrownoutBursts  *prometheus.GaugeVec
-	pingInBrownout      *prometheus.GaugeVec
-
-	// Gauges - Timing
-	pingUptimeSeconds *prometheus.GaugeVec
-
-	// Info - for "up" logic
-	pingUp *prometheus.GaugeVec
+	addr     string
+	target   string
+	interval time.Duration
+	server   *http.Server
+
+	mu         sync.RWMutex
+	stats      metrics.Stats
+	aggregator *metrics.Aggregator   // Per-minute buckets; nil disables bucket gauges
+	sla        *metrics.SLATracker   // Hourly/daily SLA; nil disables SLA gauges
+	slo        *alert.Tracker        // Latency SLO; nil disables SLO gauges
+	remote     *RemoteWriter         // Pushes metrics via remote_write; nil disables pushing
+	health     func() metrics.Health // Self-monitoring, read at scrape time; nil disables it
+	wifi       func() wifi.Link      // Wi-Fi link, read at scrape time; nil disables it
+	targetInfo func() enrich.Info    // Target lookup, read at scrape time; nil disables it
+	script     func() []script.Gauge // Script hook gauges, read at scrape time; nil disables them
+	labels     prometheus.Labels     // Static labels attached to every series
+
+	// Strict OpenMetrics exposition (see SetOpenMetrics), and when the
+	// totals kept as gauges last started from zero
+	openMetrics bool
+	created     time.Time
+
+	// Metric units: UnitsMilliseconds (default) or UnitsSeconds
+	units string
+
+	// stats are exported by statsCollector at scrape time. sampled tells
+	// whether Update ran since the last target switch; base holds the
+	// counters of the target from before its statistics last restarted,
+	// retired the final ones of previous targets.
+	sampled bool
+	base    counts
+	retired map[string]counts
+
+	// Per-sample RTT distribution; histogram observations carry exemplars
+	pingRTTSummary   *prometheus.SummaryVec
+	pingRTTHistogram *prometheus.HistogramVec
 }
 
-// NewExporter creates a new Prometheus exporter.
-func NewExporter(addr, target string) *Exporter {
+// NewExporter creates a new Prometheus exporter. interval is reported in
+// pingheat_config_info; quantiles are the RTT summary objectives, nil
+// selects DefaultQuantiles. An empty addr serves no HTTP endpoint, for
+// exporters that only push through remote_write.
+func NewExporter(addr, target string, interval time.Duration, quantiles []float64) *Exporter {
 	e := &Exporter{
-		addr:   addr,
-		target: target,
+		addr:     addr,
+		target:   target,
+		interval: interval,
+		created:  time.Now(),
+		retired:  make(map[string]counts),
 	}
+	e.base.created = e.created
 
-	labels := []string{"target"}
-
-	// Counters
-	e.pingSentTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Name: "pingheat_ping_sent_total",
-		Help: "Total number of ping packets sent",
-	}, labels)
-
-	e.pingSuccessTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Name: "pingheat_ping_success_total",
-		Help: "Total number of successful ping responses",
-	}, labels)
-
-	e.pingTimeoutTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Name: "pingheat_ping_timeout_total",
-		Help: "Total number of ping timeouts",
-	}, labels)
-
-	// Latency gauges
-	e.pingLatencyMs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "pingheat_ping_latency_ms",
-		Help: "Ping latency in milliseconds (min, avg, max)",
-	}, append(labels, "stat"))
-
-	e.pingStdDevMs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "pingheat_ping_stddev_ms",
-		Help: "Standard deviation of ping latency in milliseconds",
-	}, labels)
-
-	e.pingVarianceMs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "pingheat_ping_variance_ms2",
-		Help: "Variance of ping latency in milliseconds squared",
-	}, labels)
-
-	e.pingJitterMs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "pingheat_ping_jitter_ms",
-		Help: "Ping jitter (mean absolute deviation) in milliseconds",
-	}, labels)
-
-	e.pingLastRTTMs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "pingheat_ping_last_rtt_ms",
-		Help: "Most recent ping RTT in milliseconds (-1 if last was timeout)",
-	}, labels)
-
-	// Percentile gauges
-	e.pingLatencyP50Ms = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "pingheat_ping_latency_p50_ms",
-		Help: "50th percentile (median) latency in milliseconds",
-	}, labels)
-
-	e.pingLatencyP90Ms = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "pingheat_ping_latency_p90_ms",
-		Help: "90th percentile latency in milliseconds",
-	}, labels)
-
-	e.pingLatencyP95Ms = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "pingheat_ping_latency_p95_ms",
-		Help: "95th percentile latency in milliseconds",
-	}, labels)
-
-	e.pingLatencyP99Ms = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "pingheat_ping_latency_p99_ms",
-		Help: "99th percentile latency in milliseconds",
-	}, labels)
-
-	// Availability gauges
-	e.pingLossPercent = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "pingheat_ping_loss_percent",
-		Help: "Packet loss percentage (0-100)",
-	}, labels)
-
-	e.pingAvailPercent = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "pingheat_ping_availability_percent",
-		Help: "Availability percentage (0-100)",
-	}, labels)
-
-	// Streak gauges
-	e.pingCurrentStreak = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "pingheat_ping_current_streak",
-		Help: "Current streak (positive=success, negative=timeout)",
-	}, labels)
-
-	e.pingLongestSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "pingheat_ping_longest_success_streak",
-		Help: "Longest consecutive successful pings",
-	}, labels)
-
-	e.pingLongestTimeout = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "pingheat_ping_longest_timeout_streak",
-		Help: "Longest consecutive timeout streak",
-	}, labels)
-
-	// Instability pattern gauges
-	e.pingLossBursts = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "pingheat_ping_loss_bursts_total",
-		Help: "Number of separate packet loss burst events (outages)",
-	}, labels)
-
-	e.pingBrownoutSamples = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "pingheat_ping_brownout_samples_total",
-		Help: "Total number of high-latency samples (>200ms)",
-	}, labels)
-
-	e.pingBrownoutBursts = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "pingheat_ping_brownout_bursts_total",
-		Help: "Number of brownout events (transitions to high latency)",
-	}, labels)
-
-	e.pingInBrownout = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "pingheat_ping_in_brownout",
-		Help: "Currently in brownout state (1=yes, 0=no)",
-	}, labels)
-
-	// Timing gauges
-	e.pingUptimeSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "pingheat_uptime_seconds",
-		Help: "Seconds since monitoring started",
-	}, labels)
-
-	// Up gauge for alerting
-	e.pingUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "pingheat_ping_up",
-		Help: "Target is reachable (1=up, 0=down based on last ping)",
-	}, labels)
+	// Per-sample RTT distribution
+	if quantiles == nil {
+		quantiles = DefaultQuantiles
+	}
+	e.pingRTTSummary = prometheus.NewSummaryVec(prometheus.SummaryOpts{
+		Name:       "pingheat_ping_rtt_ms",
+		Help:       "Ping RTT in milliseconds over the last 10 minutes",
+		Objectives: objectives(quantiles),
+	}, targetLabels)
+
+	e.pingRTTHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "pingheat_ping_rtt_histogram_ms",
+		Help:    "Ping RTT in milliseconds; exemplars identify individual samples",
+		Buckets: rttBucketsMs,
+	}, targetLabels)
 
 	return e
 }
 
-// Start starts the Prometheus HTTP server.
+// Start serves the metrics over HTTP and pushes them through remote_write
+// if configured, until ctx is cancelled.
 func (e *Exporter) Start(ctx context.Context) error {
 	// Register metrics
 	reg := prometheus.NewRegistry()
 	e.register(reg)
+
+	if e.remote != nil {
+		e.remote.register(prometheus.WrapRegistererWith(e.labels, reg))
+		done := make(chan struct{})
+		go func() {
+			e.remote.run(ctx, e.gatherer(reg))
+			close(done)
+		}()
+		// Let the final push finish before the app exits
+		defer func() { <-done }()
+	}
+	// Without an address the metrics are only pushed
+	if e.addr == "" {
+		<-ctx.Done()
+		return nil
+	}
 	e.server = e.newServer(reg)
 
+	stopped := make(chan struct{})
 	go func() {
+		defer close(stopped)
 		<-ctx.Done()
-		_ = e.server.Shutdown(context.Background())
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := e.server.Shutdown(shutdownCtx); err != nil {
+			_ = e.server.Close()
+		}
 	}()
 
 	err := e.server.ListenAndServe()
 	if err == http.ErrServerClosed {
+		<-stopped
 		return nil
 	}
 	return err
 }
 
-// register adds exporter metrics to the provided registry.
-func (e *Exporter) register(reg *prometheus.Registry) {
-	reg.MustRegister(
-		e.pingSentTotal,
-		e.pingSuccessTotal,
-		e.pingTimeoutTotal,
-		e.pingLatencyMs,
-		e.pingStdDevMs,
-		e.pingVarianceMs,
-		e.pingJitterMs,
-		e.pingLastRTTMs,
-		e.pingLatencyP50Ms,
-		e.pingLatencyP90Ms,
-		e.pingLatencyP95Ms,
-		e.pingLatencyP99Ms,
-		e.pingLossPercent,
-		e.pingAvailPercent,
-		e.pingCurrentStreak,
-		e.pingLongestSuccess,
-		e.pingLongestTimeout,
-		e.pingLossBursts,
-		e.pingBrownoutSamples,
-		e.pingBrownoutBursts,
-		e.pingInBrownout,
-		e.pingUptimeSeconds,
-		e.pingUp,
-	)
+// register adds exporter metrics to the provided registry, with the static
+// labels.
+func (e *Exporter) register(r *prometheus.Registry) {
+	reg := prometheus.WrapRegistererWith(e.labels, r)
+	reg.MustRegister(statsCollector{e}, e.pingRTTSummary, e.pingRTTHistogram)
+	if e.health != nil {
+		reg.MustRegister(healthCollector{fn: e.health})
+	}
+	if e.wifi != nil {
+		reg.MustRegister(wifiCollector{fn: e.wifi})
+	}
+	if e.targetInfo != nil {
+		reg.MustRegister(targetInfoCollector{fn: e.targetInfo})
+	}
+	if e.script != nil {
+		reg.MustRegister(scriptCollector{e: e})
+	}
 }
 
 // newServer constructs an HTTP server with metrics and health handlers.
 func (e *Exporter) newServer(reg *prometheus.Registry) *http.Server {
 	mux := http.NewServeMux()
-	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	if e.openMetrics {
+		mux.Handle("/metrics", openMetricsHandler(openMetricsGatherer{g: reg, created: e.createdAt}))
+	} else {
+		// Exemplars are only exposed in the OpenMetrics format
+		mux.Handle("/metrics", promhttp.HandlerFor(e.gatherer(reg), promhttp.HandlerOpts{EnableOpenMetrics: true}))
+	}
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("OK"))
@@ -262,75 +187,172 @@ func (e *Exporter) newServer(reg *prometheus.Registry) *http.Server {
 	}
 }
 
-// Update updates the exported metrics.
-func (e *Exporter) Update(stats metrics.Stats) {
+// SetTarget relabels metrics for a new target. Statistics restart from zero.
+func (e *Exporter) SetTarget(target string) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	prevStats := e.stats
-	e.stats = stats
-
-	// Update counters (incremental)
-	if stats.TotalSamples > prevStats.TotalSamples {
-		e.pingSentTotal.WithLabelValues(e.target).Add(float64(stats.TotalSamples - prevStats.TotalSamples))
+	// Counters of the old target keep their final values, and continue
+	// from them if it becomes the target again
+	e.retired[e.target] = e.base.add(e.stats)
+	e.target = target
+	e.created = time.Now()
+	e.base = e.retired[target]
+	if e.base.created.IsZero() {
+		e.base.created = e.created
 	}
-	if stats.TotalSuccess > prevStats.TotalSuccess {
-		e.pingSuccessTotal.WithLabelValues(e.target).Add(float64(stats.TotalSuccess - prevStats.TotalSuccess))
+	delete(e.retired, target)
+	// Drop and miss counts span targets and keep accumulating
+	e.stats = metrics.Stats{
+		DroppedUISamples: e.stats.DroppedUISamples,
+		DroppedStats:     e.stats.DroppedStats,
+		ParserMisses:     e.stats.ParserMisses,
 	}
-	if stats.TotalTimeouts > prevStats.TotalTimeouts {
-		e.pingTimeoutTotal.WithLabelValues(e.target).Add(float64(stats.TotalTimeouts - prevStats.TotalTimeouts))
+	e.sampled = false
+}
+
+// SetInterval reports a new probe interval in pingheat_config_info.
+func (e *Exporter) SetInterval(interval time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.interval = interval
+}
+
+// snapshot copies what a scrape exports.
+func (e *Exporter) snapshot() snapshot {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return snapshot{
+		target:     e.target,
+		interval:   e.interval,
+		stats:      e.stats,
+		sampled:    e.sampled,
+		totals:     e.base.add(e.stats),
+		retired:    maps.Clone(e.retired),
+		aggregator: e.aggregator,
+		sla:        e.sla,
+		slo:        e.slo,
 	}
+}
 
-	// Update availability gauges
-	e.pingLossPercent.WithLabelValues(e.target).Set(stats.LossPercent)
-	e.pingAvailPercent.WithLabelValues(e.target).Set(stats.AvailPercent)
-
-	// Update streak gauges
-	e.pingCurrentStreak.WithLabelValues(e.target).Set(float64(stats.CurrentStreak))
-	e.pingLongestSuccess.WithLabelValues(e.target).Set(float64(stats.LongestSuccess))
-	e.pingLongestTimeout.WithLabelValues(e.target).Set(float64(stats.LongestTimeout))
-
-	// Update instability pattern gauges
-	e.pingLossBursts.WithLabelValues(e.target).Set(float64(stats.LossBursts))
-	e.pingBrownoutSamples.WithLabelValues(e.target).Set(float64(stats.BrownoutSamples))
-	e.pingBrownoutBursts.WithLabelValues(e.target).Set(float64(stats.BrownoutBursts))
-	if stats.InBrownout {
-		e.pingInBrownout.WithLabelValues(e.target).Set(1)
-	} else {
-		e.pingInBrownout.WithLabelValues(e.target).Set(0)
+// SetAggregator exports trailing windows of the per-minute buckets with a
+// "bucket" label.
+func (e *Exporter) SetAggregator(a *metrics.Aggregator) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.aggregator = a
+}
+
+// SetSLA exports hourly and daily availability and p95 with a "period" label.
+func (e *Exporter) SetSLA(t *metrics.SLATracker) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sla = t
+}
+
+// SetRemoteWrite also pushes all metrics through w. Call it before Start.
+func (e *Exporter) SetRemoteWrite(w *RemoteWriter) {
+	e.remote = w
+}
+
+// SetHealth exports pingheat's own health as reported by fn at each scrape.
+// Call it before Start.
+func (e *Exporter) SetHealth(fn func() metrics.Health) {
+	e.health = fn
+}
+
+// SetWiFi exports the Wi-Fi link reported by fn at each scrape. Call it
+// before Start.
+func (e *Exporter) SetWiFi(fn func() wifi.Link) {
+	e.wifi = fn
+}
+
+// SetTargetInfo exports the target lookup reported by fn as
+// pingheat_target_info. Call it before Start.
+func (e *Exporter) SetTargetInfo(fn func() enrich.Info) {
+	e.targetInfo = fn
+}
+
+// SetOpenMetrics serves strict OpenMetrics instead of the default
+// exposition, for consumers that validate it: base units as with
+// UnitsSeconds whatever SetUnits selected, units declared, and every total a
+// counter with a _created sample. Call it before Start.
+func (e *Exporter) SetOpenMetrics(strict bool) {
+	e.openMetrics = strict
+}
+
+// SetUnits selects the metric units, UnitsMilliseconds or UnitsSeconds, of
+// the exposition and remote write. Call it before Start.
+func (e *Exporter) SetUnits(units string) {
+	e.units = units
+}
+
+// gatherer returns reg converted to the configured units.
+func (e *Exporter) gatherer(reg *prometheus.Registry) prometheus.Gatherer {
+	if e.units == UnitsSeconds {
+		return baseUnitsGatherer{reg}
 	}
+	return reg
+}
 
-	// Update uptime
-	e.pingUptimeSeconds.WithLabelValues(e.target).Set(stats.UptimeSeconds)
+// createdAt returns when the totals kept as gauges last started from zero.
+func (e *Exporter) createdAt() time.Time {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.created
+}
 
-	// Update "up" status based on current streak
-	// Up = 1 if last ping was successful (positive streak), 0 if in timeout streak
-	if stats.CurrentStreak > 0 {
-		e.pingUp.WithLabelValues(e.target).Set(1)
-	} else {
-		e.pingUp.WithLabelValues(e.target).Set(0)
+// SetLabels attaches static labels, such as site or isp, to every series.
+// Call it before Start.
+func (e *Exporter) SetLabels(labels map[string]string) {
+	e.labels = labels
+}
+
+// SetSLO exports the error budget, burn rates and alert states of a
+// latency SLO.
+func (e *Exporter) SetSLO(t *alert.Tracker) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.slo = t
+}
+
+// Observe records the RTT of a single sample. Histogram observations carry
+// the sample sequence as a "sample_id" exemplar so slow samples can be
+// looked up from a dashboard.
+func (e *Exporter) Observe(sample types.Sample) {
+	if sample.Timeout {
+		return
 	}
 
-	// Update latency gauges (only if we have successful pings)
-	if stats.TotalSuccess > 0 {
-		e.pingLatencyMs.WithLabelValues(e.target, "min").Set(stats.MinRTTMs)
-		e.pingLatencyMs.WithLabelValues(e.target, "avg").Set(stats.AvgRTTMs)
-		e.pingLatencyMs.WithLabelValues(e.target, "max").Set(stats.MaxRTTMs)
-
-		e.pingStdDevMs.WithLabelValues(e.target).Set(stats.StdDevMs)
-		e.pingVarianceMs.WithLabelValues(e.target).Set(stats.VarianceMs)
-		e.pingJitterMs.WithLabelValues(e.target).Set(stats.JitterMs)
-
-		// LastRTT: set to actual value if up, -1 if currently in timeout
-		if stats.CurrentStreak > 0 {
-			e.pingLastRTTMs.WithLabelValues(e.target).Set(stats.LastRTTMs)
-		} else {
-			e.pingLastRTTMs.WithLabelValues(e.target).Set(-1)
-		}
+	e.mu.RLock()
+	target := e.target
+	e.mu.RUnlock()
+
+	ms := sample.RTTMs()
+	e.pingRTTSummary.WithLabelValues(target).Observe(ms)
+	e.pingRTTHistogram.WithLabelValues(target).(prometheus.ExemplarObserver).ObserveWithExemplar(
+		ms, prometheus.Labels{"sample_id": strconv.Itoa(sample.Sequence)})
+}
 
-		e.pingLatencyP50Ms.WithLabelValues(e.target).Set(stats.Percentiles.P50)
-		e.pingLatencyP90Ms.WithLabelValues(e.target).Set(stats.Percentiles.P90)
-		e.pingLatencyP95Ms.WithLabelValues(e.target).Set(stats.Percentiles.P95)
-		e.pingLatencyP99Ms.WithLabelValues(e.target).Set(stats.Percentiles.P99)
+// objectives maps quantiles to summary objectives, allowing an absolute
+// error of a tenth of the distance to 1 (0.9 ± 0.01, 0.99 ± 0.001).
+func objectives(quantiles []float64) map[float64]float64 {
+	obj := make(map[float64]float64, len(quantiles))
+	for _, q := range quantiles {
+		obj[q] = (1 - q) / 10
 	}
+	return obj
+}
+
+// Update stores the statistics exported at the next scrape.
+func (e *Exporter) Update(stats metrics.Stats) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// Counters continue across a restart of the statistics
+	if stats.TotalSamples < e.stats.TotalSamples {
+		e.base = e.base.add(e.stats)
+	}
+	e.stats = stats
+	e.sampled = true
 }
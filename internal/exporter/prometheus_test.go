@@ -1,19 +1,82 @@
 package exporter
 
 import (
+	"fmt"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/pbv7/pingheat/internal/alert"
+	"github.com/pbv7/pingheat/internal/enrich"
 	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/script"
+	"github.com/pbv7/pingheat/internal/types"
+	"github.com/pbv7/pingheat/internal/wifi"
+	"github.com/pbv7/pingheat/pkg/version"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
+// gather returns the gauge and counter values e exports, keyed by series
+// as in the text exposition with labels sorted by name.
+func gather(t *testing.T, e *Exporter) map[string]float64 {
+	t.Helper()
+	reg := prometheus.NewRegistry()
+	e.register(reg)
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error: %v", err)
+	}
+	values := make(map[string]float64)
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			var labels []string
+			for _, l := range m.GetLabel() {
+				labels = append(labels, fmt.Sprintf("%s=%q", l.GetName(), l.GetValue()))
+			}
+			series := mf.GetName()
+			if len(labels) > 0 {
+				series += "{" + strings.Join(labels, ",") + "}"
+			}
+			switch {
+			case m.Gauge != nil:
+				values[series] = m.GetGauge().GetValue()
+			case m.Counter != nil:
+				values[series] = m.GetCounter().GetValue()
+			}
+		}
+	}
+	return values
+}
+
+// value returns the value of an exported series, failing if it is missing.
+func value(t *testing.T, e *Exporter, series string) float64 {
+	t.Helper()
+	v, ok := gather(t, e)[series]
+	if !ok {
+		t.Fatalf("%s not exported", series)
+	}
+	return v
+}
+
+// count returns how many series of a metric e exports.
+func count(t *testing.T, e *Exporter, name string) int {
+	t.Helper()
+	n := 0
+	for series := range gather(t, e) {
+		if series == name || strings.HasPrefix(series, name+"{") {
+			n++
+		}
+	}
+	return n
+}
+
 func TestExporterUpdateMetrics(t *testing.T) {
-	e := NewExporter(":0", "target")
+	e := NewExporter(":0", "target", time.Second, nil)
 	stats := metrics.Stats{
 		TotalSamples:    2,
 		TotalSuccess:    2,
@@ -45,47 +108,51 @@ func TestExporterUpdateMetrics(t *testing.T) {
 
 	e.Update(stats)
 
-	if v := testutil.ToFloat64(e.pingSentTotal.WithLabelValues("target")); v != 2 {
+	if v := value(t, e, `pingheat_ping_sent_total{target="target"}`); v != 2 {
 		t.Fatalf("pingSentTotal=%v, want 2", v)
 	}
-	if v := testutil.ToFloat64(e.pingSuccessTotal.WithLabelValues("target")); v != 2 {
+	if v := value(t, e, `pingheat_ping_success_total{target="target"}`); v != 2 {
 		t.Fatalf("pingSuccessTotal=%v, want 2", v)
 	}
-	if v := testutil.ToFloat64(e.pingTimeoutTotal.WithLabelValues("target")); v != 0 {
+	if v := value(t, e, `pingheat_ping_timeout_total{target="target"}`); v != 0 {
 		t.Fatalf("pingTimeoutTotal=%v, want 0", v)
 	}
-	if v := testutil.ToFloat64(e.pingInBrownout.WithLabelValues("target")); v != 1 {
+	if v := value(t, e, `pingheat_ping_in_brownout{target="target"}`); v != 1 {
 		t.Fatalf("pingInBrownout=%v, want 1", v)
 	}
-	if v := testutil.ToFloat64(e.pingLastRTTMs.WithLabelValues("target")); v != 3.3 {
+	if v := value(t, e, `pingheat_ping_last_rtt_ms{target="target"}`); v != 3.3 {
 		t.Fatalf("pingLastRTTMs=%v, want 3.3", v)
 	}
-	if v := testutil.ToFloat64(e.pingUp.WithLabelValues("target")); v != 1 {
+	if v := value(t, e, `pingheat_ping_up{target="target"}`); v != 1 {
 		t.Fatalf("pingUp=%v, want 1", v)
 	}
 
 	stats.TotalSamples = 3
 	stats.TotalTimeouts = 1
+	stats.ErrorCounts[types.ErrorHostUnreachable] = 1
 	stats.CurrentStreak = -1
 	stats.InBrownout = false
 	e.Update(stats)
 
-	if v := testutil.ToFloat64(e.pingSentTotal.WithLabelValues("target")); v != 3 {
+	if v := value(t, e, `pingheat_ping_sent_total{target="target"}`); v != 3 {
 		t.Fatalf("pingSentTotal=%v, want 3", v)
 	}
-	if v := testutil.ToFloat64(e.pingTimeoutTotal.WithLabelValues("target")); v != 1 {
+	if v := value(t, e, `pingheat_ping_timeout_total{target="target"}`); v != 1 {
 		t.Fatalf("pingTimeoutTotal=%v, want 1", v)
 	}
-	if v := testutil.ToFloat64(e.pingLastRTTMs.WithLabelValues("target")); v != -1 {
+	if v := value(t, e, `pingheat_ping_errors_total{kind="host_unreachable",target="target"}`); v != 1 {
+		t.Fatalf("pingErrorsTotal{host_unreachable}=%v, want 1", v)
+	}
+	if v := value(t, e, `pingheat_ping_last_rtt_ms{target="target"}`); v != -1 {
 		t.Fatalf("pingLastRTTMs=%v, want -1", v)
 	}
-	if v := testutil.ToFloat64(e.pingUp.WithLabelValues("target")); v != 0 {
+	if v := value(t, e, `pingheat_ping_up{target="target"}`); v != 0 {
 		t.Fatalf("pingUp=%v, want 0", v)
 	}
 }
 
 func TestExporterServerHandlersAndTimeouts(t *testing.T) {
-	e := NewExporter("127.0.0.1:9090", "target")
+	e := NewExporter("127.0.0.1:9090", "target", time.Second, nil)
 	reg := prometheus.NewRegistry()
 	e.register(reg)
 	server := e.newServer(reg)
@@ -124,3 +191,363 @@ func TestExporterServerHandlersAndTimeouts(t *testing.T) {
 		t.Fatalf("metrics output missing pingheat_ping_sent_total")
 	}
 }
+
+func TestExporterBucketGauges(t *testing.T) {
+	e := NewExporter(":0", "target", time.Second, nil)
+	agg := metrics.NewAggregator()
+	e.SetAggregator(agg)
+
+	now := time.Now()
+	agg.Add(types.Sample{Timestamp: now.Add(-30 * time.Minute), Timeout: true})
+	agg.Add(types.Sample{Timestamp: now, RTT: 10 * time.Millisecond})
+	e.Update(metrics.Stats{TotalSamples: 2, TotalSuccess: 1, TotalTimeouts: 1})
+
+	if v := value(t, e, `pingheat_bucket_samples{bucket="1m",target="target"}`); v != 1 {
+		t.Fatalf("1m samples=%v, want 1", v)
+	}
+	if v := value(t, e, `pingheat_bucket_loss_percent{bucket="1h",target="target"}`); v != 50 {
+		t.Fatalf("1h loss=%v, want 50", v)
+	}
+	if v := value(t, e, `pingheat_bucket_latency_ms{bucket="24h",stat="max",target="target"}`); v != 10 {
+		t.Fatalf("24h max=%v, want 10", v)
+	}
+}
+
+func TestExporterSLAGauges(t *testing.T) {
+	e := NewExporter(":0", "target", time.Second, nil)
+	sla := metrics.NewSLATracker(time.UTC)
+	e.SetSLA(sla)
+
+	base := time.Date(2024, 1, 2, 23, 0, 0, 0, time.UTC)
+	for i := range 4 {
+		sla.Add(types.Sample{Timestamp: base.Add(time.Duration(i) * 30 * time.Minute), RTT: 20 * time.Millisecond, Timeout: i == 0})
+	}
+	e.Update(metrics.Stats{TotalSamples: 4})
+
+	tests := []struct {
+		period string
+		want   float64
+	}{
+		{"previous_hour", 50},
+		{"hour", 100},
+		{"previous_day", 50},
+		{"day", 100},
+	}
+	for _, tt := range tests {
+		if v := value(t, e, fmt.Sprintf(`pingheat_sla_availability_percent{period=%q,target="target"}`, tt.period)); v != tt.want {
+			t.Fatalf("availability{period=%q}=%v, want %v", tt.period, v, tt.want)
+		}
+	}
+	if v := value(t, e, `pingheat_sla_p95_ms{period="hour",target="target"}`); v != 20 {
+		t.Fatalf("p95{period=hour}=%v, want 20", v)
+	}
+}
+
+func TestExporterSLOGauges(t *testing.T) {
+	e := NewExporter(":0", "target", time.Second, nil)
+	slo := alert.NewTracker(alert.SLO{Objective: 99, Threshold: 100 * time.Millisecond, Window: time.Hour})
+	e.SetSLO(slo)
+
+	// Every sample of the last minutes is slow: half the budget is spent
+	// over the hour and both burn-rate alerts fire
+	now := time.Now()
+	for i := range 200 {
+		slo.Add(types.Sample{Timestamp: now.Add(-time.Duration(i) * time.Second), RTT: 20 * time.Millisecond, Timeout: i%2 == 0})
+	}
+	e.Update(metrics.Stats{TotalSamples: 200})
+
+	if v := value(t, e, `pingheat_slo_compliance_percent{target="target"}`); v != 50 {
+		t.Fatalf("compliance=%v, want 50", v)
+	}
+	if v := value(t, e, `pingheat_slo_error_budget_remaining{target="target"}`); math.Abs(v+49) > 1e-9 {
+		t.Fatalf("budget remaining=%v, want -49", v)
+	}
+	if v := value(t, e, `pingheat_slo_burn_rate{target="target",window="5m"}`); math.Abs(v-50) > 1e-9 {
+		t.Fatalf("burn rate{window=5m}=%v, want 50", v)
+	}
+	if v := value(t, e, `pingheat_slo_alert_firing{alertname="SLOFastBurn",severity="page",target="target"}`); v != 1 {
+		t.Fatalf("SLOFastBurn firing=%v, want 1", v)
+	}
+
+	e.SetTarget("other")
+	if n := count(t, e, "pingheat_slo_error_budget_remaining"); n != 0 {
+		t.Fatalf("expected SLO gauges reset on target switch, got %d series", n)
+	}
+}
+
+func TestExporterObserveSummaryAndExemplars(t *testing.T) {
+	e := NewExporter(":0", "target", time.Second, []float64{0.5, 0.99})
+	reg := prometheus.NewRegistry()
+	e.register(reg)
+	server := e.newServer(reg)
+
+	e.Observe(types.Sample{Sequence: 1, RTT: 10 * time.Millisecond})
+	e.Observe(types.Sample{Sequence: 2, RTT: 250 * time.Millisecond})
+	e.Observe(types.Sample{Sequence: 3, Timeout: true})
+
+	if n := testutil.CollectAndCount(e.pingRTTSummary); n != 1 {
+		t.Fatalf("summary series=%d, want 1", n)
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text")
+	server.Handler.ServeHTTP(rec, req)
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		`pingheat_ping_rtt_ms{target="target",quantile="0.5"}`,
+		`pingheat_ping_rtt_ms{target="target",quantile="0.99"}`,
+		`pingheat_ping_rtt_ms_count{target="target"} 2`,
+		`pingheat_ping_rtt_histogram_ms_bucket{target="target",le="300.0"} 2 # {sample_id="2"} 250.0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("metrics output missing %q", want)
+		}
+	}
+}
+
+func TestExporterInfoGauges(t *testing.T) {
+	oldVersion, oldCommit := version.Version, version.Commit
+	defer func() { version.Version, version.Commit = oldVersion, oldCommit }()
+	version.Version, version.Commit = "v1.2.3", "abc123"
+	e := NewExporter(":0", "old.example", 500*time.Millisecond, nil)
+
+	if v := value(t, e, fmt.Sprintf(`pingheat_build_info{commit="abc123",go_version=%q,version="v1.2.3"}`, runtime.Version())); v != 1 {
+		t.Fatalf("build_info=%v, want 1", v)
+	}
+	if v := value(t, e, `pingheat_config_info{interval="500ms",target="old.example"}`); v != 1 {
+		t.Fatalf("config_info=%v, want 1", v)
+	}
+
+	e.SetTarget("new.example")
+	if n := count(t, e, "pingheat_config_info"); n != 1 {
+		t.Fatalf("config_info series=%d after target switch, want 1", n)
+	}
+	if v := value(t, e, `pingheat_config_info{interval="500ms",target="new.example"}`); v != 1 {
+		t.Fatalf("config_info{target=new.example}=%v, want 1", v)
+	}
+
+	e.SetInterval(2 * time.Second)
+	if n := count(t, e, "pingheat_config_info"); n != 1 {
+		t.Fatalf("config_info series=%d after interval change, want 1", n)
+	}
+	if v := value(t, e, `pingheat_config_info{interval="2s",target="new.example"}`); v != 1 {
+		t.Fatalf("config_info{interval=2s}=%v, want 1", v)
+	}
+}
+
+func TestExporterDroppedCounters(t *testing.T) {
+	e := NewExporter(":0", "target", time.Second, nil)
+	e.Update(metrics.Stats{DroppedUISamples: 3, DroppedStats: 1})
+	e.SetTarget("other")
+	e.Update(metrics.Stats{DroppedUISamples: 5, DroppedStats: 1})
+
+	if v := value(t, e, `pingheat_dropped_messages_total{channel="ui"}`); v != 5 {
+		t.Fatalf("dropped{channel=ui}=%v, want 5", v)
+	}
+	if v := value(t, e, `pingheat_dropped_messages_total{channel="stats"}`); v != 1 {
+		t.Fatalf("dropped{channel=stats}=%v, want 1", v)
+	}
+}
+
+func TestExporterCountersAcrossTargets(t *testing.T) {
+	e := NewExporter(":0", "a", time.Second, nil)
+	e.Update(metrics.Stats{TotalSamples: 3, TotalSuccess: 3})
+	e.SetTarget("b")
+	e.Update(metrics.Stats{TotalSamples: 2, TotalSuccess: 2})
+	e.SetTarget("a")
+	e.Update(metrics.Stats{TotalSamples: 1, TotalSuccess: 1})
+
+	// Switching back continues the target's counters from their final values
+	if v := value(t, e, `pingheat_ping_sent_total{target="a"}`); v != 4 {
+		t.Fatalf("sent{target=a}=%v, want 4", v)
+	}
+	if v := value(t, e, `pingheat_ping_sent_total{target="b"}`); v != 2 {
+		t.Fatalf("sent{target=b}=%v, want 2", v)
+	}
+	// Gauges only describe the current target
+	if n := count(t, e, "pingheat_ping_up"); n != 1 {
+		t.Fatalf("ping_up series=%d, want 1", n)
+	}
+}
+
+func TestExporterBurstIntervals(t *testing.T) {
+	e := NewExporter(":0", "target", time.Second, nil)
+	e.Update(metrics.Stats{BurstIntervals: 10, PartialLossIntervals: 3, FullLossIntervals: 1})
+
+	for result, want := range map[string]float64{"clean": 6, "partial": 3, "lost": 1} {
+		if v := value(t, e, fmt.Sprintf(`pingheat_ping_burst_intervals_total{result=%q,target="target"}`, result)); v != want {
+			t.Fatalf("burst_intervals{result=%s}=%v, want %v", result, v, want)
+		}
+	}
+}
+
+func TestExporterWiFi(t *testing.T) {
+	e := NewExporter(":0", "target", time.Second, nil)
+	link := wifi.Link{Interface: "wlan0", SSID: "home", Signal: -64, Channel: 36}
+	e.SetWiFi(func() wifi.Link { return link })
+	reg := prometheus.NewRegistry()
+	e.register(reg)
+	e.Update(metrics.Stats{SignalDbm: -64, WeakSignalSamples: 5, WeakSignalTimeouts: 2})
+
+	want := `
+# HELP pingheat_wifi_channel Channel of the Wi-Fi link
+# TYPE pingheat_wifi_channel gauge
+pingheat_wifi_channel{interface="wlan0",ssid="home"} 36
+# HELP pingheat_wifi_signal_dbm Signal strength of the Wi-Fi link (RSSI)
+# TYPE pingheat_wifi_signal_dbm gauge
+pingheat_wifi_signal_dbm{interface="wlan0",ssid="home"} -64
+`
+	// Without a noise floor, no noise metric
+	err := testutil.GatherAndCompare(reg, strings.NewReader(want),
+		"pingheat_wifi_channel", "pingheat_wifi_signal_dbm", "pingheat_wifi_noise_dbm")
+	if err != nil {
+		t.Fatalf("wifi metrics: %v", err)
+	}
+	if v := value(t, e, `pingheat_ping_weak_signal_samples_total{result="timeout",target="target"}`); v != 2 {
+		t.Fatalf("weak_signal_samples_total{result=timeout}=%v, want 2", v)
+	}
+
+	link = wifi.Link{}
+	if n, err := testutil.GatherAndCount(reg, "pingheat_wifi_signal_dbm"); err != nil || n != 0 {
+		t.Fatalf("wifi_signal_dbm series while disconnected = %d, %v, want none", n, err)
+	}
+}
+
+func TestExporterTargetInfo(t *testing.T) {
+	e := NewExporter(":0", "8.8.8.8", time.Second, nil)
+	var info enrich.Info
+	e.SetTargetInfo(func() enrich.Info { return info })
+	reg := prometheus.NewRegistry()
+	e.register(reg)
+
+	// Nothing until the lookup finishes
+	if n, err := testutil.GatherAndCount(reg, "pingheat_target_info"); err != nil || n != 0 {
+		t.Fatalf("target_info series before the lookup = %d, %v, want none", n, err)
+	}
+
+	info = enrich.Info{Target: "8.8.8.8", Addr: "8.8.8.8", Hostname: "dns.google", ASN: 15169,
+		ASName: "GOOGLE - Google LLC", Country: "US"}
+	want := `
+# HELP pingheat_target_info Address, reverse DNS name, origin AS and country of the target (always 1)
+# TYPE pingheat_target_info gauge
+pingheat_target_info{addr="8.8.8.8",as_name="GOOGLE - Google LLC",asn="15169",country="US",hostname="dns.google",target="8.8.8.8"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "pingheat_target_info"); err != nil {
+		t.Fatalf("target_info: %v", err)
+	}
+}
+
+func TestExporterLabels(t *testing.T) {
+	e := NewExporter(":0", "target", time.Second, nil)
+	e.SetLabels(map[string]string{"site": "ams", "isp": "acme"})
+	reg := prometheus.NewRegistry()
+	e.register(reg)
+	e.Update(metrics.Stats{TotalSamples: 4, TotalTimeouts: 1, LossPercent: 25})
+
+	want := `
+# HELP pingheat_ping_loss_percent Packet loss percentage (0-100)
+# TYPE pingheat_ping_loss_percent gauge
+pingheat_ping_loss_percent{isp="acme",site="ams",target="target"} 25
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "pingheat_ping_loss_percent"); err != nil {
+		t.Fatalf("labeled metrics: %v", err)
+	}
+}
+
+func TestExporterParserMisses(t *testing.T) {
+	e := NewExporter(":0", "target", time.Second, nil)
+	e.Update(metrics.Stats{ParserMisses: 2})
+	e.SetTarget("other")
+	e.Update(metrics.Stats{ParserMisses: 2})
+	e.Update(metrics.Stats{ParserMisses: 7})
+
+	if v := value(t, e, "pingheat_parser_misses_total"); v != 7 {
+		t.Fatalf("parser_misses_total=%v, want 7", v)
+	}
+}
+
+func TestExporterHealth(t *testing.T) {
+	e := NewExporter(":0", "target", time.Second, nil)
+	e.SetHealth(func() metrics.Health {
+		return metrics.Health{
+			Goroutines:       12,
+			HeapBytes:        4096,
+			ProcessedSamples: 4,
+			ProcessingTime:   2 * time.Millisecond,
+			ProcessingMax:    time.Millisecond,
+			Channels:         []metrics.ChannelDepth{{Name: "ui", Len: 3, Cap: 1024}},
+		}
+	})
+	reg := prometheus.NewRegistry()
+	e.register(reg)
+
+	want := `
+# HELP pingheat_channel_depth Messages waiting in a pipeline channel (samples, ui, stats)
+# TYPE pingheat_channel_depth gauge
+pingheat_channel_depth{channel="ui"} 3
+# HELP pingheat_goroutines Number of goroutines in the pingheat process
+# TYPE pingheat_goroutines gauge
+pingheat_goroutines 12
+# HELP pingheat_sample_processing_seconds Time spent distributing each sample to the stats, exporters and UI
+# TYPE pingheat_sample_processing_seconds summary
+pingheat_sample_processing_seconds_sum 0.002
+pingheat_sample_processing_seconds_count 4
+`
+	err := testutil.GatherAndCompare(reg, strings.NewReader(want),
+		"pingheat_channel_depth", "pingheat_goroutines", "pingheat_sample_processing_seconds")
+	if err != nil {
+		t.Fatalf("health metrics: %v", err)
+	}
+}
+
+func TestExporterOutages(t *testing.T) {
+	e := NewExporter(":0", "target", time.Second, nil)
+	reg := prometheus.NewRegistry()
+	e.register(reg)
+	var counts [len(metrics.OutageBuckets) + 1]int
+	counts[1], counts[5] = 1, 1 // 2s and 41s
+	e.Update(metrics.Stats{TotalSamples: 10, OutageCounts: counts, OutageTotal: 43 * time.Second,
+		LongestOutage: 41 * time.Second, Downtime: 45 * time.Second})
+
+	want := `
+# HELP pingheat_ping_downtime_seconds_total Time spent in packet loss bursts, including the current one up to its latest missed sample
+# TYPE pingheat_ping_downtime_seconds_total gauge
+pingheat_ping_downtime_seconds_total{target="target"} 45
+# HELP pingheat_ping_outage_duration_seconds Duration of finished packet loss bursts, from the first missed to the first recovered sample
+# TYPE pingheat_ping_outage_duration_seconds histogram
+pingheat_ping_outage_duration_seconds_bucket{target="target",le="1"} 0
+pingheat_ping_outage_duration_seconds_bucket{target="target",le="2"} 1
+pingheat_ping_outage_duration_seconds_bucket{target="target",le="5"} 1
+pingheat_ping_outage_duration_seconds_bucket{target="target",le="10"} 1
+pingheat_ping_outage_duration_seconds_bucket{target="target",le="30"} 1
+pingheat_ping_outage_duration_seconds_bucket{target="target",le="60"} 2
+pingheat_ping_outage_duration_seconds_bucket{target="target",le="120"} 2
+pingheat_ping_outage_duration_seconds_bucket{target="target",le="300"} 2
+pingheat_ping_outage_duration_seconds_bucket{target="target",le="900"} 2
+pingheat_ping_outage_duration_seconds_bucket{target="target",le="3600"} 2
+pingheat_ping_outage_duration_seconds_bucket{target="target",le="+Inf"} 2
+pingheat_ping_outage_duration_seconds_sum{target="target"} 43
+pingheat_ping_outage_duration_seconds_count{target="target"} 2
+`
+	err := testutil.GatherAndCompare(reg, strings.NewReader(want),
+		"pingheat_ping_downtime_seconds_total", "pingheat_ping_outage_duration_seconds")
+	if err != nil {
+		t.Fatalf("outage metrics: %v", err)
+	}
+}
+
+func TestExporterScriptGauges(t *testing.T) {
+	e := NewExporter(":0", "x", time.Second, nil)
+	gauges := []script.Gauge{{Name: "score", Help: "Link score", Value: 42}}
+	e.SetScriptGauges(func() []script.Gauge { return gauges })
+
+	if v := value(t, e, `pingheat_script_score{target="x"}`); v != 42 {
+		t.Fatalf("script_score = %v, want 42", v)
+	}
+	gauges = nil
+	if n := count(t, e, "pingheat_script_score"); n != 0 {
+		t.Fatalf("script_score series after the script dropped it = %d, want none", n)
+	}
+}
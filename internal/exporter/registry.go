@@ -0,0 +1,96 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/metrics"
+)
+
+// ErrUnknownBackend is returned for export specs naming no registered
+// backend.
+var ErrUnknownBackend = errors.New("unknown export backend")
+
+// Backend exports the statistics it is updated with after every sample.
+// Backends may implement the optional interfaces the app wires up, such as
+// SetTarget, SetLogger or Observe for the individual samples.
+type Backend interface {
+	Start(ctx context.Context) error
+	Update(stats metrics.Stats)
+}
+
+// Options are the settings every backend gets besides its spec.
+type Options struct {
+	Target   string
+	Interval time.Duration // Probe interval
+}
+
+// BackendFactory creates a backend from the spec after its name, such as
+// the address in prometheus::9091. It must have no side effects, so specs
+// can be validated by creating a backend and dropping it; files and
+// listeners are opened by Start.
+type BackendFactory func(spec string, opts Options) (Backend, error)
+
+var (
+	backendsMu sync.RWMutex
+	backends   = map[string]BackendFactory{
+		"prometheus": newPrometheusBackend,
+		"influx":     newInfluxBackend,
+		"file":       newFileBackend,
+	}
+)
+
+// RegisterBackend makes a backend available to -export as name:SPEC.
+// Custom builds call it from the init function of a package linked into
+// the binary. It panics if name is empty, contains a colon or is taken.
+func RegisterBackend(name string, factory BackendFactory) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	if name == "" || strings.Contains(name, ":") || factory == nil {
+		panic(fmt.Sprintf("exporter: invalid backend %q", name))
+	}
+	if _, dup := backends[name]; dup {
+		panic(fmt.Sprintf("exporter: backend %q registered twice", name))
+	}
+	backends[name] = factory
+}
+
+// Backends returns the names of the registered backends, sorted.
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	return slices.Sorted(maps.Keys(backends))
+}
+
+// NewBackend creates the backend of an export spec NAME:SPEC, e.g.
+// influx:http://localhost:8086/api/v2/write?org=home&bucket=net or
+// file:/var/log/pingheat-stats.jsonl.
+func NewBackend(spec string, opts Options) (Backend, error) {
+	name, rest, _ := strings.Cut(spec, ":")
+	backendsMu.RLock()
+	factory, ok := backends[name]
+	backendsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q (want %s)", ErrUnknownBackend, name, strings.Join(Backends(), ", "))
+	}
+	b, err := factory(rest, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s export: %w", name, err)
+	}
+	return b, nil
+}
+
+// newPrometheusBackend serves a further Prometheus endpoint on the address
+// in spec.
+func newPrometheusBackend(spec string, opts Options) (Backend, error) {
+	if spec == "" {
+		return nil, errors.New("missing listen address")
+	}
+	return NewExporter(spec, opts.Target, opts.Interval, nil), nil
+}
@@ -0,0 +1,131 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/metrics"
+)
+
+func TestNewBackend(t *testing.T) {
+	opts := Options{Target: "1.1.1.1", Interval: time.Second}
+	tests := []struct {
+		spec    string
+		want    string // Backend type; "" for an error
+		wantErr error
+	}{
+		{spec: "prometheus::9091", want: "*exporter.Exporter"},
+		{spec: "influx:https://influx.example.com/api/v2/write?org=o&bucket=b", want: "*exporter.Influx"},
+		{spec: "file:/tmp/stats.jsonl", want: "*exporter.File"},
+		{spec: "graphite:localhost:2003", wantErr: ErrUnknownBackend},
+		{spec: "prometheus:"},
+		{spec: "influx:influx.example.com"},
+		{spec: "file:"},
+	}
+	for _, tt := range tests {
+		b, err := NewBackend(tt.spec, opts)
+		if tt.want == "" {
+			if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Fatalf("NewBackend(%q) error = %v, want %v", tt.spec, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("NewBackend(%q) error = %v", tt.spec, err)
+		}
+		if got := fmt.Sprintf("%T", b); got != tt.want {
+			t.Fatalf("NewBackend(%q) = %s, want %s", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterBackend(t *testing.T) {
+	RegisterBackend("test-null", func(string, Options) (Backend, error) { return NewFile("x", ""), nil })
+	t.Cleanup(func() {
+		backendsMu.Lock()
+		delete(backends, "test-null")
+		backendsMu.Unlock()
+	})
+	if !slices.Contains(Backends(), "test-null") {
+		t.Fatalf("Backends() = %v, want test-null among them", Backends())
+	}
+	if _, err := NewBackend("test-null:", Options{}); err != nil {
+		t.Fatalf("NewBackend(test-null) error = %v", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("registering influx twice did not panic")
+		}
+	}()
+	RegisterBackend("influx", newInfluxBackend)
+}
+
+func TestInfluxLine(t *testing.T) {
+	stats := metrics.Stats{TotalSamples: 10, TotalTimeouts: 1, LossPercent: 10, AvgRTTMs: 12.5, LossBursts: 1, Downtime: 2 * time.Second}
+	line := influxLine(newStatsRecord("my host,1", stats, time.Unix(1700000000, 5)))
+	want := `pingheat,target=my\ host\,1 samples=10i,timeouts=1i,loss_percent=10,min_rtt_ms=0,avg_rtt_ms=12.5,max_rtt_ms=0,` +
+		`stddev_ms=0,jitter_ms=0,p50_ms=0,p90_ms=0,p95_ms=0,p99_ms=0,outages=1i,downtime_s=2 1700000000000000005` + "\n"
+	if line != want {
+		t.Fatalf("influxLine =\n%s\nwant\n%s", line, want)
+	}
+}
+
+func TestInfluxWrite(t *testing.T) {
+	var auth, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	i, err := NewInflux(srv.URL+"/api/v2/write?org=o&bucket=b", "tok", "1.1.1.1")
+	if err != nil {
+		t.Fatalf("NewInflux() error = %v", err)
+	}
+	i.Update(metrics.Stats{TotalSamples: 3})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := i.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if auth != "Token tok" || !strings.HasPrefix(body, "pingheat,target=1.1.1.1 samples=3i,") {
+		t.Fatalf("final write: auth %q, body %q", auth, body)
+	}
+}
+
+func TestFileBackend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.jsonl")
+	f := NewFile(path, "a")
+	f.Update(metrics.Stats{TotalSamples: 5})
+	f.SetTarget("b")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := f.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	// Without new statistics, nothing more is written
+	if err := f.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], `"target":"b","samples":5,`) {
+		t.Fatalf("stats file:\n%s", data)
+	}
+}
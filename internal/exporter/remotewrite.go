@@ -0,0 +1,264 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/pbv7/pingheat/internal/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	// DefaultRemoteWriteInterval is how often metrics are pushed unless
+	// configured otherwise.
+	DefaultRemoteWriteInterval = 15 * time.Second
+
+	// remoteWriteTimeout bounds a single push.
+	remoteWriteTimeout = 10 * time.Second
+)
+
+// ErrInvalidRemoteWrite is returned for unusable remote_write endpoints.
+var ErrInvalidRemoteWrite = errors.New("invalid remote_write URL")
+
+// RemoteWriter pushes the exporter's metrics to a Prometheus remote_write
+// endpoint (Prometheus, Grafana Cloud, Mimir, VictoriaMetrics, ...), for
+// probe hosts without a scraper nearby.
+type RemoteWriter struct {
+	url      string
+	username string // Basic auth from the URL's user info
+	password string
+	token    string // Bearer token; takes precedence over basic auth
+	interval time.Duration
+	client   *http.Client
+
+	pushedTotal *prometheus.CounterVec
+	failedTotal prometheus.Counter
+	logger      *slog.Logger
+}
+
+// NewRemoteWriter creates a writer pushing to rawURL every interval. Basic
+// auth credentials may be given as the URL's user info; a non-empty token
+// is sent as a bearer token instead.
+func NewRemoteWriter(rawURL, token string, interval time.Duration) (*RemoteWriter, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: want an http(s) URL, got %q", ErrInvalidRemoteWrite, redactURL(rawURL))
+	}
+	w := &RemoteWriter{
+		token:    token,
+		interval: interval,
+		client:   &http.Client{Timeout: remoteWriteTimeout},
+		pushedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pingheat_remote_write_samples_total",
+			Help: "Samples sent to the remote_write endpoint",
+		}, []string{"result"}),
+		failedTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "pingheat_remote_write_failures_total",
+			Help: "Pushes to the remote_write endpoint that failed",
+		}),
+		logger: log.Discard(),
+	}
+	if u.User != nil {
+		w.username = u.User.Username()
+		w.password, _ = u.User.Password()
+		u.User = nil
+	}
+	w.url = u.String()
+	if w.interval <= 0 {
+		w.interval = DefaultRemoteWriteInterval
+	}
+	return w, nil
+}
+
+// SetLogger makes the writer log failed pushes to l.
+func (w *RemoteWriter) SetLogger(l *slog.Logger) {
+	w.logger = l.With("component", "remote_write")
+}
+
+// redactURL hides the password of a URL for error messages.
+func redactURL(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		return u.Redacted()
+	}
+	return "<unparseable>"
+}
+
+// register adds the writer's own metrics to reg.
+func (w *RemoteWriter) register(reg prometheus.Registerer) {
+	reg.MustRegister(w.pushedTotal, w.failedTotal)
+}
+
+// run pushes g's metrics every interval until ctx is cancelled, then makes
+// a final push so the last values arrive. Failed pushes are counted and not
+// retried; the next push carries current values anyway.
+func (w *RemoteWriter) run(ctx context.Context, g prometheus.Gatherer) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			pushCtx, cancel := context.WithTimeout(context.Background(), remoteWriteTimeout)
+			if err := w.push(pushCtx, g, time.Now()); err != nil {
+				w.logger.Warn("final remote write failed", "error", err)
+			}
+			cancel()
+			return
+		case now := <-ticker.C:
+			if err := w.push(ctx, g, now); err != nil && ctx.Err() == nil {
+				w.logger.Warn("remote write failed", "error", err)
+			}
+		}
+	}
+}
+
+// push sends one snapshot of g's metrics, timestamped now.
+func (w *RemoteWriter) push(ctx context.Context, g prometheus.Gatherer, now time.Time) error {
+	families, err := g.Gather()
+	if err != nil {
+		w.failedTotal.Inc()
+		return err
+	}
+	body, n := encodeWriteRequest(families, now.UnixMilli())
+
+	if err := w.send(ctx, snappy.Encode(nil, body)); err != nil {
+		w.failedTotal.Inc()
+		w.pushedTotal.WithLabelValues("failure").Add(float64(n))
+		return err
+	}
+	w.pushedTotal.WithLabelValues("success").Add(float64(n))
+	w.logger.Debug("remote write", "samples", n)
+	return nil
+}
+
+// send posts a compressed WriteRequest.
+func (w *RemoteWriter) send(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	switch {
+	case w.token != "":
+		req.Header.Set("Authorization", "Bearer "+w.token)
+	case w.username != "":
+		req.SetBasicAuth(w.username, w.password)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("remote_write: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// series is one remote_write time series with a single sample.
+type series struct {
+	labels [][2]string // Name and value, sorted by name
+	value  float64
+}
+
+// encodeWriteRequest converts metric families to a protobuf WriteRequest
+// with one sample per series at timestamp ms. Summaries and histograms are
+// split into the series of the text exposition format. It returns the
+// encoded request and its number of series.
+func encodeWriteRequest(families []*dto.MetricFamily, ms int64) ([]byte, int) {
+	var all []series
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			all = appendSeries(all, mf.GetName(), mf.GetType(), m)
+		}
+	}
+
+	var req []byte
+	for _, s := range all {
+		var ts []byte
+		for _, l := range s.labels {
+			var label []byte
+			label = protowire.AppendTag(label, 1, protowire.BytesType)
+			label = protowire.AppendString(label, l[0])
+			label = protowire.AppendTag(label, 2, protowire.BytesType)
+			label = protowire.AppendString(label, l[1])
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, label)
+		}
+		var sample []byte
+		sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(s.value))
+		sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(ms))
+		ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, sample)
+
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, ts)
+	}
+	return req, len(all)
+}
+
+// appendSeries appends the series of one metric.
+func appendSeries(all []series, name string, typ dto.MetricType, m *dto.Metric) []series {
+	add := func(name string, value float64, extra ...[2]string) {
+		labels := [][2]string{{"__name__", name}}
+		for _, lp := range m.GetLabel() {
+			labels = append(labels, [2]string{lp.GetName(), lp.GetValue()})
+		}
+		labels = append(labels, extra...)
+		slices.SortFunc(labels, func(a, b [2]string) int { return strings.Compare(a[0], b[0]) })
+		all = append(all, series{labels: labels, value: value})
+	}
+
+	switch typ {
+	case dto.MetricType_COUNTER:
+		add(name, m.GetCounter().GetValue())
+	case dto.MetricType_GAUGE:
+		add(name, m.GetGauge().GetValue())
+	case dto.MetricType_SUMMARY:
+		s := m.GetSummary()
+		for _, q := range s.GetQuantile() {
+			add(name, q.GetValue(), [2]string{"quantile", formatFloat(q.GetQuantile())})
+		}
+		add(name+"_sum", s.GetSampleSum())
+		add(name+"_count", float64(s.GetSampleCount()))
+	case dto.MetricType_HISTOGRAM:
+		h := m.GetHistogram()
+		for _, b := range h.GetBucket() {
+			add(name+"_bucket", float64(b.GetCumulativeCount()), [2]string{"le", formatFloat(b.GetUpperBound())})
+		}
+		add(name+"_bucket", float64(h.GetSampleCount()), [2]string{"le", "+Inf"})
+		add(name+"_sum", h.GetSampleSum())
+		add(name+"_count", float64(h.GetSampleCount()))
+	default:
+		add(name, m.GetUntyped().GetValue())
+	}
+	return all
+}
+
+// formatFloat formats a label value like the text exposition format.
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,246 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// decodeWriteRequest parses a WriteRequest into "name{k=v,...}" keys with
+// their value and the timestamps seen.
+func decodeWriteRequest(t *testing.T, b []byte) (map[string]float64, map[int64]bool) {
+	t.Helper()
+	values := make(map[string]float64)
+	stamps := make(map[int64]bool)
+
+	fields := func(b []byte, fn func(num protowire.Number, typ protowire.Type, b []byte) int) {
+		for len(b) > 0 {
+			num, typ, n := protowire.ConsumeTag(b)
+			if n < 0 {
+				t.Fatalf("bad tag")
+			}
+			b = b[n:]
+			m := fn(num, typ, b)
+			if m < 0 {
+				t.Fatalf("bad field %d", num)
+			}
+			b = b[m:]
+		}
+	}
+
+	fields(b, func(_ protowire.Number, _ protowire.Type, b []byte) int {
+		ts, n := protowire.ConsumeBytes(b)
+		var name string
+		var labels []string
+		var value float64
+		fields(ts, func(num protowire.Number, _ protowire.Type, b []byte) int {
+			msg, n := protowire.ConsumeBytes(b)
+			switch num {
+			case 1:
+				var k, v string
+				fields(msg, func(num protowire.Number, _ protowire.Type, b []byte) int {
+					s, n := protowire.ConsumeString(b)
+					if num == 1 {
+						k = s
+					} else {
+						v = s
+					}
+					return n
+				})
+				if k == "__name__" {
+					name = v
+				} else {
+					labels = append(labels, k+"="+v)
+				}
+			case 2:
+				fields(msg, func(num protowire.Number, typ protowire.Type, b []byte) int {
+					if num == 1 {
+						bits, n := protowire.ConsumeFixed64(b)
+						value = math.Float64frombits(bits)
+						return n
+					}
+					ms, n := protowire.ConsumeVarint(b)
+					stamps[int64(ms)] = true
+					return n
+				})
+			}
+			return n
+		})
+		if !sort.StringsAreSorted(labels) {
+			t.Fatalf("labels of %s not sorted: %v", name, labels)
+		}
+		values[name+"{"+strings.Join(labels, ",")+"}"] = value
+		return n
+	})
+	return values, stamps
+}
+
+func TestEncodeWriteRequest(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "g"}, []string{"target"})
+	gauge.WithLabelValues("1.1.1.1").Set(2.5)
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "c_total"})
+	counter.Add(3)
+	hist := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "h", Buckets: []float64{1, 10}})
+	hist.Observe(5)
+	summary := prometheus.NewSummary(prometheus.SummaryOpts{Name: "s", Objectives: map[float64]float64{0.5: 0.05}})
+	summary.Observe(7)
+	reg.MustRegister(gauge, counter, hist, summary)
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, n := encodeWriteRequest(families, 1700000000000)
+	values, stamps := decodeWriteRequest(t, body)
+
+	want := map[string]float64{
+		"g{target=1.1.1.1}": 2.5,
+		"c_total{}":         3,
+		"h_bucket{le=1}":    0,
+		"h_bucket{le=10}":   1,
+		"h_bucket{le=+Inf}": 1,
+		"h_sum{}":           5,
+		"h_count{}":         1,
+		"s{quantile=0.5}":   7,
+		"s_sum{}":           7,
+		"s_count{}":         1,
+	}
+	if n != len(want) || len(values) != len(want) {
+		t.Fatalf("got %d series %v, want %v", n, values, want)
+	}
+	for k, v := range want {
+		if got, ok := values[k]; !ok || got != v {
+			t.Fatalf("series %s = %v (present %v), want %v", k, got, ok, v)
+		}
+	}
+	if len(stamps) != 1 || !stamps[1700000000000] {
+		t.Fatalf("unexpected timestamps %v", stamps)
+	}
+}
+
+func TestRemoteWriterPush(t *testing.T) {
+	var mu sync.Mutex
+	var got []*http.Request
+	var bodies [][]byte
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		got = append(got, r)
+		bodies = append(bodies, body)
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	rw, err := NewRemoteWriter(strings.Replace(server.URL, "://", "://user:secret@", 1)+"/push", "", time.Minute)
+	if err != nil {
+		t.Fatalf("NewRemoteWriter error: %v", err)
+	}
+	e := NewExporter("", "target", time.Second, nil)
+	e.Update(metrics.Stats{TotalSamples: 2, TotalSuccess: 2})
+	reg := prometheus.NewRegistry()
+	e.register(reg)
+	rw.register(reg)
+
+	if err := rw.push(context.Background(), reg, time.Now()); err != nil {
+		t.Fatalf("push error: %v", err)
+	}
+	req := got[0]
+	if req.URL.Path != "/push" || req.Header.Get("Content-Encoding") != "snappy" ||
+		req.Header.Get("Content-Type") != "application/x-protobuf" || req.Header.Get("X-Prometheus-Remote-Write-Version") != "0.1.0" {
+		t.Fatalf("unexpected request: %s %v", req.URL, req.Header)
+	}
+	if user, pass, ok := req.BasicAuth(); !ok || user != "user" || pass != "secret" {
+		t.Fatalf("expected basic auth from the URL, got %q/%q", user, pass)
+	}
+	body, err := snappy.Decode(nil, bodies[0])
+	if err != nil {
+		t.Fatalf("snappy decode: %v", err)
+	}
+	values, _ := decodeWriteRequest(t, body)
+	if values["pingheat_ping_sent_total{target=target}"] != 2 {
+		t.Fatalf("pushed series missing sent counter: %v", values)
+	}
+
+	status = http.StatusBadRequest
+	if err := rw.push(context.Background(), reg, time.Now()); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Fatalf("expected status error, got %v", err)
+	}
+	if v := testutil.ToFloat64(rw.failedTotal); v != 1 {
+		t.Fatalf("failures = %v, want 1", v)
+	}
+}
+
+func TestRemoteWriterBearerToken(t *testing.T) {
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	rw, err := NewRemoteWriter(server.URL, "tok", time.Minute)
+	if err != nil {
+		t.Fatalf("NewRemoteWriter error: %v", err)
+	}
+	if err := rw.push(context.Background(), prometheus.NewRegistry(), time.Now()); err != nil {
+		t.Fatalf("push error: %v", err)
+	}
+	if auth != "Bearer tok" {
+		t.Fatalf("Authorization = %q, want bearer token", auth)
+	}
+}
+
+func TestNewRemoteWriterInvalid(t *testing.T) {
+	for _, raw := range []string{"", "ftp://host/push", "https://", "https://user:secret@ host"} {
+		_, err := NewRemoteWriter(raw, "", 0)
+		if !errors.Is(err, ErrInvalidRemoteWrite) {
+			t.Fatalf("NewRemoteWriter(%q): expected ErrInvalidRemoteWrite, got %v", raw, err)
+		}
+		if strings.Contains(err.Error(), "secret") {
+			t.Fatalf("error leaks the password: %v", err)
+		}
+	}
+}
+
+func TestStartPushOnly(t *testing.T) {
+	pushed := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pushed <- struct{}{}
+	}))
+	defer server.Close()
+
+	rw, err := NewRemoteWriter(server.URL, "", time.Hour)
+	if err != nil {
+		t.Fatalf("NewRemoteWriter error: %v", err)
+	}
+	e := NewExporter("", "target", time.Second, nil)
+	e.SetRemoteWrite(rw)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := e.Start(ctx); err != nil {
+		t.Fatalf("Start error: %v", err)
+	}
+	// The final push happens before Start returns
+	select {
+	case <-pushed:
+	default:
+		t.Fatalf("expected a final push on shutdown")
+	}
+}
@@ -0,0 +1,33 @@
+package exporter
+
+import (
+	"github.com/pbv7/pingheat/internal/script"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// scriptPrefix names the gauges a script hook sets.
+const scriptPrefix = "pingheat_script_"
+
+// scriptCollector exports the gauges of a script hook, which come and go as
+// the script sets them, so it describes no metrics up front.
+type scriptCollector struct {
+	e *Exporter
+}
+
+func (c scriptCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (c scriptCollector) Collect(ch chan<- prometheus.Metric) {
+	c.e.mu.RLock()
+	target := c.e.target
+	c.e.mu.RUnlock()
+	for _, g := range c.e.script() {
+		desc := prometheus.NewDesc(scriptPrefix+g.Name, g.Help, targetLabels, nil)
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, g.Value, target)
+	}
+}
+
+// SetScriptGauges exports the gauges of a script hook as reported by fn at
+// each scrape, as pingheat_script_<name>. Call it before Start.
+func (e *Exporter) SetScriptGauges(fn func() []script.Gauge) {
+	e.script = fn
+}
@@ -0,0 +1,106 @@
+package exporter
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Metric units: UnitsMilliseconds keeps pingheat's historical names such as
+// pingheat_ping_rtt_ms, UnitsSeconds follows Prometheus naming practice with
+// base units, such as pingheat_ping_rtt_seconds.
+const (
+	UnitsMilliseconds = "ms"
+	UnitsSeconds      = "seconds"
+)
+
+// IsValidUnits reports whether units is a supported metric unit mode.
+func IsValidUnits(units string) bool {
+	return units == UnitsMilliseconds || units == UnitsSeconds
+}
+
+// unitSuffixes rename the units pingheat's metrics use by default to base
+// units, with the factor converting the values.
+var unitSuffixes = []struct {
+	from, to string
+	scale    float64
+}{
+	{"_ms2", "_seconds_squared", 1e-6},
+	{"_ms", "_seconds", 1e-3},
+	{"_percent", "_ratio", 1e-2},
+}
+
+// declaredUnits are the units named in OpenMetrics UNIT metadata.
+var declaredUnits = []string{"seconds", "bytes", "ratio"}
+
+// baseUnitsGatherer renames metrics in milliseconds and percent to seconds
+// and ratios, converting their values, and declares their units.
+type baseUnitsGatherer struct {
+	g prometheus.Gatherer
+}
+
+// Gather implements prometheus.Gatherer.
+func (b baseUnitsGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := b.g.Gather()
+	for _, mf := range families {
+		toBaseUnits(mf)
+	}
+	return families, err
+}
+
+// toBaseUnits rewrites one metric family in place.
+func toBaseUnits(mf *dto.MetricFamily) {
+	name := mf.GetName()
+	scale := 1.0
+	for _, u := range unitSuffixes {
+		if base, ok := strings.CutSuffix(name, u.from); ok {
+			name, scale = base+u.to, u.scale
+			break
+		}
+	}
+	mf.Name = &name
+
+	base := strings.TrimSuffix(name, "_total")
+	for _, unit := range declaredUnits {
+		if strings.HasSuffix(base, "_"+unit) {
+			mf.Unit = &unit
+			break
+		}
+	}
+
+	if scale != 1 {
+		for _, m := range mf.Metric {
+			scaleMetric(m, scale)
+		}
+	}
+}
+
+// scaleMetric multiplies the values of a gauge, summary or histogram.
+func scaleMetric(m *dto.Metric, scale float64) {
+	mul := func(v *float64) *float64 {
+		if v == nil {
+			return nil
+		}
+		x := *v * scale
+		return &x
+	}
+	if g := m.Gauge; g != nil {
+		g.Value = mul(g.Value)
+	}
+	if s := m.Summary; s != nil {
+		s.SampleSum = mul(s.SampleSum)
+		for _, q := range s.Quantile {
+			q.Value = mul(q.Value)
+		}
+	}
+	if h := m.Histogram; h != nil {
+		h.SampleSum = mul(h.SampleSum)
+		for _, b := range h.Bucket {
+			b.UpperBound = mul(b.UpperBound)
+			if b.Exemplar != nil {
+				b.Exemplar.Value = mul(b.Exemplar.Value)
+			}
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package exporter
+
+import (
+	"github.com/pbv7/pingheat/internal/wifi"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Descriptions of the Wi-Fi link metrics, read at scrape time.
+var (
+	wifiLabels     = []string{"interface", "ssid"}
+	wifiSignalDesc = prometheus.NewDesc("pingheat_wifi_signal_dbm",
+		"Signal strength of the Wi-Fi link (RSSI)", wifiLabels, nil)
+	wifiNoiseDesc = prometheus.NewDesc("pingheat_wifi_noise_dbm",
+		"Noise floor of the Wi-Fi link, where the platform reports it", wifiLabels, nil)
+	wifiChannelDesc = prometheus.NewDesc("pingheat_wifi_channel",
+		"Channel of the Wi-Fi link", wifiLabels, nil)
+)
+
+// wifiCollector exports the Wi-Fi link reported by fn, nothing while
+// disconnected.
+type wifiCollector struct {
+	fn func() wifi.Link
+}
+
+func (c wifiCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- wifiSignalDesc
+	ch <- wifiNoiseDesc
+	ch <- wifiChannelDesc
+}
+
+func (c wifiCollector) Collect(ch chan<- prometheus.Metric) {
+	l := c.fn()
+	if l.Signal == 0 {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(wifiSignalDesc, prometheus.GaugeValue, float64(l.Signal), l.Interface, l.SSID)
+	if l.Noise != 0 {
+		ch <- prometheus.MustNewConstMetric(wifiNoiseDesc, prometheus.GaugeValue, float64(l.Noise), l.Interface, l.SSID)
+	}
+	if l.Channel != 0 {
+		ch <- prometheus.MustNewConstMetric(wifiChannelDesc, prometheus.GaugeValue, float64(l.Channel), l.Interface, l.SSID)
+	}
+}
@@ -0,0 +1,305 @@
+package fleet
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/buffer"
+	"github.com/pbv7/pingheat/internal/config"
+	"github.com/pbv7/pingheat/internal/log"
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+// maxBatchBytes bounds the size of a push request body.
+const maxBatchBytes = 4 << 20
+
+// shutdownTimeout lets agents' pushes in flight land before the aggregator
+// stops.
+const shutdownTimeout = 3 * time.Second
+
+// DefaultMaxSeries is the number of agent/target series an aggregator
+// accepts unless told otherwise.
+const DefaultMaxSeries = 1000
+
+// ErrTooManySeries is returned for a batch that would start a series beyond
+// the aggregator's limit.
+var ErrTooManySeries = errors.New("too many series")
+
+// Series is a snapshot of one agent/target pair.
+type Series struct {
+	Agent    string
+	Target   string
+	Stats    metrics.Stats
+	Recent   []types.Sample // Oldest first
+	LastSeen time.Time      // When the last batch arrived
+}
+
+// series is the aggregator's mutable state for one agent/target pair. Each
+// series has its own locks, so agents pushing different series and
+// snapshots do not wait for each other.
+type series struct {
+	agent  string
+	target string
+
+	mu       sync.Mutex
+	engine   *metrics.Engine
+	history  *buffer.RingBuffer[types.Sample]
+	lastSeen time.Time
+}
+
+// Aggregator receives sample batches from agents.
+type Aggregator struct {
+	addr        string
+	historySize int
+	budget      int64  // Bytes of history across all series; 0 is unlimited
+	auto        bool   // Shrink each series' history to fit budget
+	maxSeries   int    // Series accepted before new ones are refused; 0 is unlimited
+	token       string // Bearer token pushes must carry ("" = none)
+	logger      *slog.Logger
+	server      *http.Server
+	now         func() time.Time
+
+	// mu guards the set of series, not their contents; it is taken before
+	// a series' own lock, never while holding one
+	mu         sync.RWMutex
+	series     map[string]*series
+	overBudget bool // Warned that the history outgrew budget
+	full       bool // Warned that maxSeries was reached
+}
+
+// NewAggregator creates an aggregator listening on addr that keeps the last
+// historySize samples of each series.
+func NewAggregator(addr string, historySize int) *Aggregator {
+	return &Aggregator{
+		addr:        addr,
+		historySize: historySize,
+		logger:      log.Discard(),
+		now:         time.Now,
+		series:      make(map[string]*series),
+	}
+}
+
+// SetHistoryBudget bounds the memory history takes across all series. With
+// auto, each series keeps the history that fits its share of budget, at most
+// historySize, and existing series shrink as agents join; otherwise the
+// aggregator warns once when the series outgrow budget. Call before Start.
+func (a *Aggregator) SetHistoryBudget(budget int64, auto bool) {
+	a.budget = budget
+	a.auto = auto
+}
+
+// SetMaxSeries bounds the number of agent/target series. Batches that would
+// start another one are refused, so agents cannot grow the aggregator's
+// memory without limit. Call before Start.
+func (a *Aggregator) SetMaxSeries(n int) {
+	a.maxSeries = n
+}
+
+// SetToken makes the aggregator refuse pushes that do not carry token as a
+// bearer token. Call before Start.
+func (a *Aggregator) SetToken(token string) {
+	a.token = token
+}
+
+// SetLogger sets the logger for budget warnings. Call before Start.
+func (a *Aggregator) SetLogger(logger *slog.Logger) {
+	a.logger = logger
+}
+
+// Start starts the HTTP server and blocks until ctx is cancelled.
+func (a *Aggregator) Start(ctx context.Context) error {
+	a.server = a.newServer()
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := a.server.Shutdown(shutdownCtx); err != nil {
+			_ = a.server.Close()
+		}
+	}()
+
+	err := a.server.ListenAndServe()
+	if err == http.ErrServerClosed {
+		<-stopped
+		return nil
+	}
+	return err
+}
+
+// newServer builds the HTTP server and handlers.
+func (a *Aggregator) newServer() *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+PushPath, a.handlePush)
+
+	return &http.Server{
+		Addr:              a.addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		IdleTimeout:       60 * time.Second,
+	}
+}
+
+// handlePush ingests one batch.
+func (a *Aggregator) handlePush(w http.ResponseWriter, r *http.Request) {
+	if !a.authorized(r) {
+		http.Error(w, "invalid or missing token", http.StatusForbidden)
+		return
+	}
+
+	var batch Batch
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBytes)).Decode(&batch); err != nil {
+		http.Error(w, "invalid batch: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if batch.Agent == "" || batch.Target == "" {
+		http.Error(w, "agent and target are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := a.Add(batch); err != nil {
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorized reports whether r carries the token, if one is required.
+func (a *Aggregator) authorized(r *http.Request) bool {
+	if a.token == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) == 1
+}
+
+// Add ingests a batch of samples. It returns ErrTooManySeries, keeping
+// nothing, if the batch would start a series beyond the limit.
+func (a *Aggregator) Add(batch Batch) error {
+	s, err := a.lookup(batch.Agent, batch.Target)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, rec := range batch.Samples {
+		sample := rec.Sample()
+		s.engine.Add(sample)
+		s.history.Push(sample)
+	}
+	s.lastSeen = a.now()
+	return nil
+}
+
+// lookup returns the series of agent and target, creating it on first use
+// unless the limit of series is reached.
+func (a *Aggregator) lookup(agent, target string) (*series, error) {
+	key := agent + "\x00" + target
+	a.mu.RLock()
+	s, ok := a.series[key]
+	a.mu.RUnlock()
+	if ok {
+		return s, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if s, ok := a.series[key]; ok {
+		return s, nil // Created while waiting for the lock
+	}
+	if a.maxSeries > 0 && len(a.series) >= a.maxSeries {
+		if !a.full {
+			a.full = true
+			a.logger.Warn("series limit reached, refusing pushes for new ones, raise -aggregate-max-series",
+				"series", len(a.series), "agent", agent, "target", target)
+		}
+		return nil, ErrTooManySeries
+	}
+	size := a.seriesHistory(len(a.series) + 1)
+	s = &series{
+		agent:   agent,
+		target:  target,
+		engine:  metrics.NewEngine(),
+		history: buffer.NewRingBuffer[types.Sample](size),
+	}
+	a.series[key] = s
+	a.fitBudget(size)
+	return s, nil
+}
+
+// seriesHistory returns the history each of n series keeps. Callers hold mu.
+func (a *Aggregator) seriesHistory(n int) int {
+	if !a.auto || a.budget <= 0 {
+		return a.historySize
+	}
+	return min(a.historySize, config.AutoHistory(a.budget, n))
+}
+
+// fitBudget shrinks existing series to size in auto mode, or warns when the
+// history outgrows the budget. Callers hold mu.
+func (a *Aggregator) fitBudget(size int) {
+	if a.auto {
+		for _, s := range a.series {
+			s.mu.Lock()
+			if s.history.Capacity() > size {
+				s.history.Resize(size)
+			}
+			s.mu.Unlock()
+		}
+		return
+	}
+	bytes := config.HistoryBytes(a.historySize, len(a.series))
+	if a.budget > 0 && bytes > a.budget && !a.overBudget {
+		a.overBudget = true
+		a.logger.Warn("history exceeds the memory budget, use -history auto or raise -history-budget",
+			"series", len(a.series), "history", a.historySize, "mb", bytes>>20, "budget_mb", a.budget>>20)
+	}
+}
+
+// Snapshot returns every series sorted by agent, then target, with up to
+// recent samples of history each.
+func (a *Aggregator) Snapshot(recent int) []Series {
+	// Computing statistics is the costly part; it happens outside the
+	// series locks, and engines with no new samples skip it
+	a.mu.RLock()
+	all := make([]*series, 0, len(a.series))
+	for _, s := range a.series {
+		all = append(all, s)
+	}
+	a.mu.RUnlock()
+
+	out := make([]Series, 0, len(all))
+	for _, s := range all {
+		stats := s.engine.Stats()
+		s.mu.Lock()
+		out = append(out, Series{
+			Agent:    s.agent,
+			Target:   s.target,
+			Stats:    stats,
+			Recent:   s.history.GetLastN(recent),
+			LastSeen: s.lastSeen,
+		})
+		s.mu.Unlock()
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Agent != out[j].Agent {
+			return out[i].Agent < out[j].Agent
+		}
+		return out[i].Target < out[j].Target
+	})
+	return out
+}
@@ -0,0 +1,16 @@
+// Package fleet connects remote pingheat agents to a central aggregator.
+// Agents push batches of samples over HTTP; the aggregator keeps per-series
+// statistics and recent history for an overview of every agent and target.
+package fleet
+
+import "github.com/pbv7/pingheat/internal/output"
+
+// PushPath is the aggregator endpoint that accepts sample batches.
+const PushPath = "/api/v1/push"
+
+// Batch is the body of a push request: samples from one agent for one target.
+type Batch struct {
+	Agent   string          `json:"agent"`
+	Target  string          `json:"target"`
+	Samples []output.Record `json:"samples"`
+}
@@ -0,0 +1,326 @@
+package fleet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/config"
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/output"
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+func testSample(seq int, rtt time.Duration, timeout bool) types.Sample {
+	return types.Sample{
+		Timestamp: time.Date(2024, 1, 2, 10, 0, seq, 0, time.UTC),
+		Sequence:  seq,
+		RTT:       rtt,
+		Timeout:   timeout,
+	}
+}
+
+func TestPushToAggregator(t *testing.T) {
+	agg := NewAggregator(":0", 100)
+	srv := httptest.NewServer(agg.newServer().Handler)
+	defer srv.Close()
+
+	p := NewPusher(srv.URL+"/", "probe-1", "1.1.1.1")
+	p.Update(testSample(1, 10*time.Millisecond, false), metrics.Stats{})
+	p.Update(testSample(2, 0, true), metrics.Stats{})
+
+	if err := p.flush(context.Background()); err != nil {
+		t.Fatalf("flush() error = %v", err)
+	}
+	if len(p.pending) != 0 {
+		t.Fatalf("pending = %d after successful flush, want 0", len(p.pending))
+	}
+
+	series := agg.Snapshot(10)
+	if len(series) != 1 {
+		t.Fatalf("len(series) = %d, want 1", len(series))
+	}
+	s := series[0]
+	if s.Agent != "probe-1" || s.Target != "1.1.1.1" {
+		t.Fatalf("series = %s/%s, want probe-1/1.1.1.1", s.Agent, s.Target)
+	}
+	if s.Stats.TotalSamples != 2 || s.Stats.TotalTimeouts != 1 || len(s.Recent) != 2 {
+		t.Fatalf("series stats = %d samples, %d timeouts, %d recent", s.Stats.TotalSamples, s.Stats.TotalTimeouts, len(s.Recent))
+	}
+	if s.Recent[0].RTT != 10*time.Millisecond || !s.Recent[1].Timeout {
+		t.Fatalf("recent = %+v", s.Recent)
+	}
+}
+
+func TestPusherRequeuesOnFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	p := NewPusher(srv.URL, "probe-1", "1.1.1.1")
+	p.Update(testSample(1, time.Millisecond, false), metrics.Stats{})
+
+	err := p.flush(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("flush() error = %v, want 503", err)
+	}
+	if len(p.pending) != 1 {
+		t.Fatalf("pending = %d after failed flush, want 1", len(p.pending))
+	}
+}
+
+func TestPusherBoundsPending(t *testing.T) {
+	p := NewPusher("http://127.0.0.1:1", "probe-1", "1.1.1.1")
+	for i := range maxPending + 5 {
+		p.Update(testSample(i, time.Millisecond, false), metrics.Stats{})
+	}
+
+	if len(p.pending) != maxPending || p.dropped != 5 {
+		t.Fatalf("pending = %d, dropped = %d, want %d and 5", len(p.pending), p.dropped, maxPending)
+	}
+	if p.pending[0].Seq != 5 {
+		t.Fatalf("oldest pending seq = %d, want 5", p.pending[0].Seq)
+	}
+}
+
+func TestPusherSetTargetDoesNotBlock(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	down := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-down:
+		case <-r.Context().Done():
+			return
+		}
+		var b Batch
+		if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+			t.Errorf("decode batch: %v", err)
+		}
+		mu.Lock()
+		got = append(got, fmt.Sprintf("%s:%d", b.Target, len(b.Samples)))
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	p := NewPusher(srv.URL, "probe-1", "1.1.1.1")
+	p.Update(testSample(1, time.Millisecond, false), metrics.Stats{})
+	p.Update(testSample(2, time.Millisecond, false), metrics.Stats{})
+
+	// The aggregator hangs, yet the switch returns at once
+	start := time.Now()
+	p.SetTarget("8.8.8.8")
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("SetTarget() took %v while the aggregator hangs", d)
+	}
+	p.Update(testSample(3, time.Millisecond, false), metrics.Stats{})
+
+	// Both targets' samples go out with the next push, each under its own
+	close(down)
+	if err := p.flush(context.Background()); err != nil {
+		t.Fatalf("flush() error = %v", err)
+	}
+	if want := "1.1.1.1:2 8.8.8.8:1"; strings.Join(got, " ") != want {
+		t.Fatalf("batches = %v, want %s", got, want)
+	}
+}
+
+func TestPusherRequeuesEarlierTargets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	p := NewPusher(srv.URL, "probe-1", "1.1.1.1")
+	p.Update(testSample(1, time.Millisecond, false), metrics.Stats{})
+	p.SetTarget("8.8.8.8")
+	p.Update(testSample(2, time.Millisecond, false), metrics.Stats{})
+
+	if err := p.flush(context.Background()); err == nil {
+		t.Fatalf("flush() error = nil, want the 503")
+	}
+	if len(p.retired) != 1 || p.retired[0].Target != "1.1.1.1" || len(p.retired[0].Samples) != 1 || len(p.pending) != 1 {
+		t.Fatalf("retired = %+v, pending = %d, want both targets' samples kept", p.retired, len(p.pending))
+	}
+
+	// The queue bound covers earlier targets' samples, oldest first
+	for i := range maxPending {
+		p.Update(testSample(i, time.Millisecond, false), metrics.Stats{})
+	}
+	if len(p.retired) != 0 || len(p.pending) != maxPending || p.dropped != 2 {
+		t.Fatalf("retired = %d, pending = %d, dropped = %d, want 0, %d and 2", len(p.retired), len(p.pending), p.dropped, maxPending)
+	}
+	if p.pending[0].Seq != 0 {
+		t.Fatalf("oldest pending seq = %d, want 0", p.pending[0].Seq)
+	}
+}
+
+func TestAggregatorRejectsInvalidBatches(t *testing.T) {
+	h := NewAggregator(":0", 10).newServer().Handler
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"malformed", `{"agent":`},
+		{"missing agent", `{"target":"1.1.1.1","samples":[]}`},
+		{"missing target", `{"agent":"a","samples":[]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, PushPath, strings.NewReader(tt.body)))
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400", rec.Code)
+			}
+		})
+	}
+}
+
+func TestAggregatorToken(t *testing.T) {
+	agg := NewAggregator(":0", 10)
+	agg.SetToken("s3cret")
+	srv := httptest.NewServer(agg.newServer().Handler)
+	defer srv.Close()
+
+	for _, token := range []string{"", "wrong"} {
+		p := NewPusher(srv.URL, "probe-1", "1.1.1.1")
+		p.SetToken(token)
+		p.Update(testSample(1, time.Millisecond, false), metrics.Stats{})
+		if err := p.flush(context.Background()); err == nil || !strings.Contains(err.Error(), "403") {
+			t.Fatalf("flush() with token %q error = %v, want 403", token, err)
+		}
+	}
+	if got := agg.Snapshot(0); len(got) != 0 {
+		t.Fatalf("series = %d after refused pushes, want 0", len(got))
+	}
+
+	p := NewPusher(srv.URL, "probe-1", "1.1.1.1")
+	p.SetToken("s3cret")
+	p.Update(testSample(1, time.Millisecond, false), metrics.Stats{})
+	if err := p.flush(context.Background()); err != nil {
+		t.Fatalf("flush() with the token error = %v", err)
+	}
+	if got := agg.Snapshot(0); len(got) != 1 {
+		t.Fatalf("series = %d, want 1", len(got))
+	}
+}
+
+func TestAggregatorMaxSeries(t *testing.T) {
+	agg := NewAggregator(":0", 10)
+	agg.SetMaxSeries(2)
+	h := agg.newServer().Handler
+
+	push := func(agent string) int {
+		rec := httptest.NewRecorder()
+		body := `{"agent":"` + agent + `","target":"1.1.1.1","samples":[]}`
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, PushPath, strings.NewReader(body)))
+		return rec.Code
+	}
+	for _, agent := range []string{"a", "b", "a"} {
+		if code := push(agent); code != http.StatusNoContent {
+			t.Fatalf("push from %s: status = %d, want 204", agent, code)
+		}
+	}
+	if code := push("c"); code != http.StatusTooManyRequests {
+		t.Fatalf("push beyond the limit: status = %d, want 429", code)
+	}
+	if len(agg.series) != 2 {
+		t.Fatalf("series = %d, want the refused one not allocated", len(agg.series))
+	}
+}
+
+func TestAggregatorSnapshotOrder(t *testing.T) {
+	agg := NewAggregator(":0", 10)
+	for _, b := range []Batch{
+		{Agent: "b", Target: "1.1.1.1"},
+		{Agent: "a", Target: "9.9.9.9"},
+		{Agent: "a", Target: "8.8.8.8"},
+	} {
+		agg.Add(b)
+	}
+
+	var got []string
+	for _, s := range agg.Snapshot(0) {
+		got = append(got, s.Agent+"/"+s.Target)
+	}
+	want := "a/8.8.8.8 a/9.9.9.9 b/1.1.1.1"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("order = %v, want %s", got, want)
+	}
+}
+
+func TestAggregatorHistoryBudget(t *testing.T) {
+	agg := NewAggregator(":0", 10000)
+	agg.SetHistoryBudget(config.HistoryBytes(2000, 1), true)
+
+	var records []output.Record
+	for i := range 1500 {
+		records = append(records, output.NewRecord(testSample(i, time.Millisecond, false)))
+	}
+	agg.Add(Batch{Agent: "a", Target: "1.1.1.1", Samples: records})
+	first := agg.series["a\x001.1.1.1"].history
+	if first.Capacity() != 2000 || first.Len() != 1500 {
+		t.Fatalf("one series: capacity %d, len %d, want 2000 and 1500", first.Capacity(), first.Len())
+	}
+
+	// A second series halves everyone's share, keeping the newest samples
+	agg.Add(Batch{Agent: "b", Target: "1.1.1.1"})
+	if first.Capacity() != 1000 || first.Len() != 1000 {
+		t.Fatalf("two series: capacity %d, len %d, want 1000", first.Capacity(), first.Len())
+	}
+	if newest, _ := first.GetLast(); newest.Sequence != 1499 {
+		t.Fatalf("newest sample = %d, want 1499", newest.Sequence)
+	}
+	if got := agg.series["b\x001.1.1.1"].history.Capacity(); got != 1000 {
+		t.Fatalf("second series capacity = %d, want 1000", got)
+	}
+
+	// Shares never drop below the auto minimum
+	for _, agent := range []string{"c", "d", "e", "f"} {
+		agg.Add(Batch{Agent: agent, Target: "1.1.1.1"})
+	}
+	if first.Capacity() != config.MinAutoHistory {
+		t.Fatalf("six series: capacity %d, want %d", first.Capacity(), config.MinAutoHistory)
+	}
+}
+
+func TestAggregatorConcurrentSeries(t *testing.T) {
+	agg := NewAggregator(":0", 100)
+	agg.SetHistoryBudget(config.HistoryBytes(1000, 1), true)
+
+	var wg sync.WaitGroup
+	for agent := range 8 {
+		wg.Go(func() {
+			for seq := range 50 {
+				rec := output.NewRecord(testSample(seq, time.Millisecond, false))
+				agg.Add(Batch{Agent: fmt.Sprintf("agent-%d", agent), Target: "1.1.1.1", Samples: []output.Record{rec}})
+			}
+		})
+	}
+	wg.Go(func() {
+		for range 20 {
+			agg.Snapshot(10)
+		}
+	})
+	wg.Wait()
+
+	snap := agg.Snapshot(0)
+	if len(snap) != 8 {
+		t.Fatalf("series = %d, want 8", len(snap))
+	}
+	for _, s := range snap {
+		if s.Stats.TotalSamples != 50 {
+			t.Fatalf("%s: %d samples, want 50", s.Agent, s.Stats.TotalSamples)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package fleet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/log"
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/output"
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+const (
+	// pushInterval is how often pending samples are sent to the aggregator.
+	pushInterval = time.Second
+
+	// maxPending bounds the samples kept while the aggregator is unreachable;
+	// the oldest are dropped first.
+	maxPending = 3600
+
+	// pushTimeout bounds a single push request.
+	pushTimeout = 5 * time.Second
+)
+
+// Pusher batches samples and sends them to an aggregator.
+type Pusher struct {
+	url    string
+	agent  string
+	token  string // Bearer token the aggregator requires ("" = none)
+	client *http.Client
+
+	mu      sync.Mutex
+	target  string
+	pending []output.Record
+	retired []Batch // Samples of earlier targets not sent yet, oldest first
+	dropped int
+
+	logger  *slog.Logger
+	failing bool // Whether the last push failed; only changes are logged
+}
+
+// NewPusher creates a pusher that sends samples for target to the aggregator
+// at baseURL (e.g. http://central:9100) under the given agent name.
+func NewPusher(baseURL, agent, target string) *Pusher {
+	return &Pusher{
+		url:    strings.TrimSuffix(baseURL, "/") + PushPath,
+		agent:  agent,
+		target: target,
+		client: &http.Client{Timeout: pushTimeout},
+		logger: log.Discard(),
+	}
+}
+
+// SetToken makes the pusher authenticate with token as a bearer token. Call
+// it before Start.
+func (p *Pusher) SetToken(token string) {
+	p.token = token
+}
+
+// SetLogger makes the pusher log when pushes start failing and recover.
+func (p *Pusher) SetLogger(l *slog.Logger) {
+	p.logger = l.With("component", "pusher")
+}
+
+// Update queues a sample for the next push.
+func (p *Pusher) Update(sample types.Sample, _ metrics.Stats) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.pending = append(p.pending, output.NewRecord(sample))
+	p.trim()
+}
+
+// SetTarget labels subsequent samples with a new target. Samples still queued
+// keep the old one and go out with the next push, so a switch never waits
+// for the aggregator.
+func (p *Pusher) SetTarget(target string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.pending) > 0 {
+		p.retired = append(p.retired, Batch{Agent: p.agent, Target: p.target, Samples: p.pending})
+		p.pending = nil
+	}
+	p.target = target
+}
+
+// trim drops the oldest queued samples beyond maxPending, those of earlier
+// targets first. Callers hold mu.
+func (p *Pusher) trim() {
+	over := len(p.pending) - maxPending
+	for _, b := range p.retired {
+		over += len(b.Samples)
+	}
+	for over > 0 && len(p.retired) > 0 {
+		n := min(over, len(p.retired[0].Samples))
+		p.retired[0].Samples = p.retired[0].Samples[n:]
+		if len(p.retired[0].Samples) == 0 {
+			p.retired = p.retired[1:]
+		}
+		p.dropped += n
+		over -= n
+	}
+	if over > 0 {
+		p.pending = p.pending[over:]
+		p.dropped += over
+	}
+}
+
+// Start pushes pending samples every second until ctx is cancelled, then
+// makes a final attempt to flush what is left. Failed pushes are retried on
+// the next tick, so a flapping link to the aggregator loses no samples as long
+// as it recovers before the queue fills.
+func (p *Pusher) Start(ctx context.Context) error {
+	ticker := time.NewTicker(pushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), pushTimeout)
+			if err := p.flush(flushCtx); err != nil {
+				p.logger.Warn("final push to aggregator failed, samples lost", "error", err)
+			}
+			cancel()
+			return nil
+		case <-ticker.C:
+			err := p.flush(ctx)
+			switch {
+			case err != nil && !p.failing && ctx.Err() == nil:
+				p.logger.Warn("push to aggregator failed, retrying", "error", err)
+				p.failing = true
+			case err == nil && p.failing:
+				p.logger.Info("push to aggregator recovered")
+				p.failing = false
+			}
+		}
+	}
+}
+
+// flush sends the samples of earlier targets, then all pending samples, one
+// batch per target. On failure the unsent samples stay queued.
+func (p *Pusher) flush(ctx context.Context) error {
+	p.mu.Lock()
+	retired, records, target := p.retired, p.pending, p.target
+	p.retired, p.pending = nil, nil
+	p.mu.Unlock()
+
+	for i, b := range retired {
+		if err := p.send(ctx, b.Target, b.Samples); err != nil {
+			p.requeue(retired[i:], target, records)
+			return err
+		}
+		p.logger.Debug("pushed samples to aggregator", "target", b.Target, "samples", len(b.Samples))
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	if err := p.send(ctx, target, records); err != nil {
+		p.requeue(nil, target, records)
+		return err
+	}
+	p.logger.Debug("pushed samples to aggregator", "target", target, "samples", len(records))
+	return nil
+}
+
+// requeue puts unsent batches of earlier targets and records of target back
+// in front of samples queued meanwhile. Records of a target switched away
+// from since join the earlier targets' batches.
+func (p *Pusher) requeue(retired []Batch, target string, records []output.Record) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(records) > 0 {
+		if target == p.target && len(p.retired) == 0 {
+			p.pending = append(records, p.pending...)
+		} else {
+			retired = append(retired, Batch{Agent: p.agent, Target: target, Samples: records})
+		}
+	}
+	p.retired = append(retired, p.retired...)
+	p.trim()
+}
+
+// send posts one batch to the aggregator.
+func (p *Pusher) send(ctx context.Context, target string, records []output.Record) error {
+	body, err := json.Marshal(Batch{Agent: p.agent, Target: target, Samples: records})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("aggregator returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+// Package locale formats numbers and clock times for people: digit grouping,
+// the decimal separator and a 12- or 24-hour clock, following the user's
+// locale unless flags say otherwise. Machine-readable output (CSV, JSON,
+// metrics) never goes through it.
+package locale
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Clock formats accepted by -clock and decimal separators accepted by
+// -decimal.
+const (
+	Clock12h     = "12h"
+	Clock24h     = "24h"
+	DecimalPoint = "point"
+	DecimalComma = "comma"
+)
+
+// IsValidClock reports whether name is a supported clock format.
+func IsValidClock(name string) bool {
+	return name == Clock12h || name == Clock24h
+}
+
+// IsValidDecimal reports whether name is a supported decimal separator.
+func IsValidDecimal(name string) bool {
+	return name == DecimalPoint || name == DecimalComma
+}
+
+// Format is how numbers and clock times are written. The zero value is the
+// plain C locale style: 24-hour clock, decimal point, no digit grouping.
+type Format struct {
+	Hour12 bool   // 3:04 PM instead of 15:04
+	Comma  bool   // Decimal comma: 1,5 instead of 1.5
+	Group  string // Digit group separator: "1,234" with ","; "" = none
+}
+
+// Locales with a 12-hour clock; every other locale uses 24 hours.
+var hour12Locales = map[string]bool{
+	"en_US": true, "en_CA": true, "en_AU": true, "en_NZ": true, "en_PH": true, "en_IN": true,
+	"es_MX": true, "es_US": true, "hi_IN": true, "bn_BD": true, "ur_PK": true, "ko_KR": true,
+	"ar_EG": true, "ar_SA": true, "fil_PH": true,
+}
+
+// nbsp is the no-break space grouping digits in many locales, keeping a
+// number on one line.
+const nbsp = "\u00a0"
+
+// Languages writing a decimal comma, with their digit group separator.
+// Languages missing here write a decimal point and group with commas.
+var commaLanguages = map[string]string{
+	"de": ".", "es": ".", "it": ".", "nl": ".", "pt": ".", "da": ".", "el": ".", "id": ".",
+	"tr": ".", "ro": ".", "hr": ".", "sl": ".", "sr": ".", "vi": ".", "ca": ".", "az": ".",
+	"fr": nbsp, "ru": nbsp, "pl": nbsp, "cs": nbsp, "sk": nbsp, "sv": nbsp,
+	"fi": nbsp, "nb": nbsp, "nn": nbsp, "no": nbsp, "uk": nbsp, "hu": nbsp,
+	"bg": nbsp, "lt": nbsp, "lv": nbsp, "et": nbsp, "be": nbsp, "kk": nbsp,
+}
+
+// pointLocales write a decimal point although their language does not.
+var pointLocales = map[string]string{
+	"es_MX": ",", "es_US": ",", "de_CH": "'", "it_CH": "'",
+}
+
+// Detect returns the format of the user's locale, read with getenv from
+// LC_ALL, then LC_NUMERIC or LC_TIME, then LANG. An unset, C or POSIX
+// locale yields the zero Format.
+func Detect(getenv func(string) string) Format {
+	var f Format
+	if name := localeName(getenv, "LC_NUMERIC"); name != "" {
+		lang, _, _ := strings.Cut(name, "_")
+		f.Group = ","
+		if group, ok := pointLocales[name]; ok {
+			f.Group = group
+		} else if group, ok := commaLanguages[lang]; ok {
+			f.Comma, f.Group = true, group
+		}
+	}
+	f.Hour12 = hour12Locales[localeName(getenv, "LC_TIME")]
+	return f
+}
+
+// localeName returns the locale of category as language_TERRITORY, without
+// the character set or modifier, or "" for the C locale.
+func localeName(getenv func(string) string, category string) string {
+	for _, env := range []string{"LC_ALL", category, "LANG"} {
+		value := getenv(env)
+		if value == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(value, ".")
+		name, _, _ = strings.Cut(name, "@")
+		if name == "C" || name == "POSIX" {
+			return ""
+		}
+		return name
+	}
+	return ""
+}
+
+// WithClock returns f with the clock format name, Clock12h or Clock24h.
+func (f Format) WithClock(name string) Format {
+	f.Hour12 = name == Clock12h
+	return f
+}
+
+// WithDecimal returns f with the decimal separator name, DecimalPoint or
+// DecimalComma. A digit group separator that would clash with it swaps to
+// the other.
+func (f Format) WithDecimal(name string) Format {
+	f.Comma = name == DecimalComma
+	switch {
+	case f.Comma && f.Group == ",":
+		f.Group = "."
+	case !f.Comma && f.Group == ".":
+		f.Group = ","
+	}
+	return f
+}
+
+// Int formats n with digit grouping.
+func (f Format) Int(n int) string {
+	return f.group(strconv.Itoa(n))
+}
+
+// Float formats v with prec decimals, digit grouping and the decimal
+// separator.
+func (f Format) Float(v float64, prec int) string {
+	s := strconv.FormatFloat(v, 'f', prec, 64)
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	whole = f.group(whole)
+	if !hasFrac {
+		return whole
+	}
+	if f.Comma {
+		return whole + "," + frac
+	}
+	return whole + "." + frac
+}
+
+// Percent formats v as a percentage with prec decimals.
+func (f Format) Percent(v float64, prec int) string {
+	return f.Float(v, prec) + "%"
+}
+
+// group inserts the digit group separator into an integer, every three
+// digits from the right.
+func (f Format) group(digits string) string {
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	if f.Group == "" || len(digits) <= 3 {
+		return sign + digits
+	}
+	var b strings.Builder
+	b.WriteString(sign)
+	head := len(digits) % 3
+	if head > 0 {
+		b.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if i > 0 {
+			b.WriteString(f.Group)
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
+
+// Time formats t with a time.Format layout written for a 24-hour clock,
+// switching its hour to a 12-hour clock with AM/PM if f asks for one.
+func (f Format) Time(t time.Time, layout string) string {
+	return t.Format(f.clockLayout(layout))
+}
+
+// clockLayout returns layout, written for a 24-hour clock, adapted to f.
+func (f Format) clockLayout(layout string) string {
+	if !f.Hour12 {
+		return layout
+	}
+	for _, clock := range []string{"15:04:05.000", "15:04:05", "15:04", "15:00"} {
+		if i := strings.Index(layout, clock); i >= 0 {
+			return layout[:i] + "3" + clock[2:] + " PM" + layout[i+len(clock):]
+		}
+	}
+	return layout
+}
@@ -0,0 +1,92 @@
+package locale
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want Format
+	}{
+		{nil, Format{}},
+		{map[string]string{"LANG": "C.UTF-8"}, Format{}},
+		{map[string]string{"LANG": "en_US.UTF-8"}, Format{Hour12: true, Group: ","}},
+		{map[string]string{"LANG": "en_GB.UTF-8"}, Format{Group: ","}},
+		{map[string]string{"LANG": "de_DE.UTF-8"}, Format{Comma: true, Group: "."}},
+		{map[string]string{"LANG": "fr_FR.UTF-8@euro"}, Format{Comma: true, Group: nbsp}},
+		{map[string]string{"LANG": "de_CH.UTF-8"}, Format{Group: "'"}},
+		{map[string]string{"LANG": "de_DE.UTF-8", "LC_ALL": "POSIX"}, Format{}},
+		{map[string]string{"LANG": "en_US.UTF-8", "LC_NUMERIC": "de_DE.UTF-8"}, Format{Hour12: true, Comma: true, Group: "."}},
+		{map[string]string{"LANG": "de_DE.UTF-8", "LC_TIME": "en_US.UTF-8"}, Format{Hour12: true, Comma: true, Group: "."}},
+	}
+	for _, tt := range tests {
+		got := Detect(func(key string) string { return tt.env[key] })
+		if got != tt.want {
+			t.Fatalf("Detect(%v) = %+v, want %+v", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestWithDecimal(t *testing.T) {
+	f := Format{Group: ","}.WithDecimal(DecimalComma)
+	if !f.Comma || f.Group != "." {
+		t.Fatalf("WithDecimal(comma) = %+v, want comma with . groups", f)
+	}
+	f = f.WithDecimal(DecimalPoint)
+	if f.Comma || f.Group != "," {
+		t.Fatalf("WithDecimal(point) = %+v, want point with , groups", f)
+	}
+	if f := (Format{Group: nbsp}).WithDecimal(DecimalComma); f.Group != nbsp {
+		t.Fatalf("WithDecimal(comma) group = %q, want no-break space kept", f.Group)
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	plain := Format{}
+	us := Format{Group: ","}
+	de := Format{Comma: true, Group: "."}
+	tests := []struct {
+		got, want string
+	}{
+		{plain.Int(1234567), "1234567"},
+		{us.Int(1234567), "1,234,567"},
+		{us.Int(123), "123"},
+		{us.Int(-1234), "-1,234"},
+		{de.Int(123456), "123.456"},
+		{plain.Float(1234.5, 1), "1234.5"},
+		{us.Float(1234.56, 2), "1,234.56"},
+		{de.Float(1234.56, 2), "1.234,56"},
+		{de.Float(18.25, 0), "18"},
+		{de.Percent(0.4, 1), "0,4%"},
+		{us.Percent(99.95, 2), "99.95%"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Fatalf("got %q, want %q", tt.got, tt.want)
+		}
+	}
+}
+
+func TestTime(t *testing.T) {
+	ts := time.Date(2026, 3, 1, 15, 4, 5, 0, time.UTC)
+	h12 := Format{Hour12: true}
+	tests := []struct {
+		f      Format
+		layout string
+		want   string
+	}{
+		{Format{}, "15:04:05", "15:04:05"},
+		{h12, "15:04:05", "3:04:05 PM"},
+		{h12, "15:04", "3:04 PM"},
+		{h12, "15:00", "3:00 PM"},
+		{h12, "2006-01-02 15:04:05 MST", "2026-03-01 3:04:05 PM UTC"},
+		{h12, "2006-01-02", "2026-03-01"},
+	}
+	for _, tt := range tests {
+		if got := tt.f.Time(ts, tt.layout); got != tt.want {
+			t.Fatalf("Time(%q) = %q, want %q", tt.layout, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBufferSize is how many records the TUI log pane keeps.
+const DefaultBufferSize = 500
+
+// Entry is one log record as kept by a Buffer.
+type Entry struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	Attrs   string // key=value pairs in text handler syntax
+}
+
+// String formats the entry as a single line.
+func (e Entry) String() string {
+	s := e.Time.Format("15:04:05") + " " + fmt.Sprintf("%-5s", e.Level) + " " + e.Message
+	if e.Attrs != "" {
+		s += " " + e.Attrs
+	}
+	return s
+}
+
+// Buffer keeps the most recent log records in memory. It is safe for
+// concurrent use.
+type Buffer struct {
+	mu      sync.Mutex
+	entries []Entry // Ring of up to size entries; next is the oldest once full
+	next    int
+	size    int
+}
+
+// NewBuffer creates a buffer keeping the last size records.
+func NewBuffer(size int) *Buffer {
+	if size < 1 {
+		size = 1
+	}
+	return &Buffer{size: size}
+}
+
+// add appends e, replacing the oldest entry when full.
+func (b *Buffer) add(e Entry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.entries) < b.size {
+		b.entries = append(b.entries, e)
+		return
+	}
+	b.entries[b.next] = e
+	b.next = (b.next + 1) % b.size
+}
+
+// Entries returns the kept records, oldest first.
+func (b *Buffer) Entries() []Entry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]Entry, 0, len(b.entries))
+	out = append(out, b.entries[b.next:]...)
+	return append(out, b.entries[:b.next]...)
+}
+
+// bufferHandler is the slog.Handler feeding a Buffer.
+type bufferHandler struct {
+	buf    *Buffer
+	level  slog.Level
+	attrs  string // Preformatted attributes from WithAttrs
+	prefix string // Group prefix for attribute keys, "group."
+}
+
+func (h *bufferHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *bufferHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		appendAttr(&b, h.prefix, a)
+		return true
+	})
+	h.buf.add(Entry{Time: r.Time, Level: r.Level, Message: r.Message, Attrs: b.String()})
+	return nil
+}
+
+func (h *bufferHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	b.WriteString(h.attrs)
+	for _, a := range attrs {
+		appendAttr(&b, h.prefix, a)
+	}
+	c := *h
+	c.attrs = b.String()
+	return &c
+}
+
+func (h *bufferHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := *h
+	c.prefix += name + "."
+	return &c
+}
+
+// appendAttr writes a as space-separated key=value pairs, flattening groups.
+func appendAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			appendAttr(b, prefix, ga)
+		}
+		return
+	}
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	s := v.String()
+	if s == "" || strings.ContainsAny(s, " \t\"=") {
+		s = fmt.Sprintf("%q", s)
+	}
+	b.WriteString(prefix + a.Key + "=" + s)
+}
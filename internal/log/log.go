@@ -0,0 +1,94 @@
+// Package log builds the structured runtime logger: records go to a text
+// writer such as stderr or a log file, and to an in-memory Buffer the TUI
+// shows in its log pane.
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// ErrInvalidLevel is returned for an unknown log level name.
+var ErrInvalidLevel = errors.New("log level must be debug, info, warn or error")
+
+// ParseLevel parses a level name: debug, info, warn (or warning) or error.
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("%w: %q", ErrInvalidLevel, s)
+}
+
+// New returns a logger writing records at level or above as text to w and
+// into buf. Either may be nil.
+func New(w io.Writer, level slog.Level, buf *Buffer) *slog.Logger {
+	var handlers []slog.Handler
+	if w != nil {
+		handlers = append(handlers, slog.NewTextHandler(w, &slog.HandlerOptions{Level: level}))
+	}
+	if buf != nil {
+		handlers = append(handlers, &bufferHandler{buf: buf, level: level})
+	}
+	switch len(handlers) {
+	case 0:
+		return Discard()
+	case 1:
+		return slog.New(handlers[0])
+	}
+	return slog.New(tee(handlers))
+}
+
+// Discard returns a logger that drops every record, the default of
+// components until they are handed a real one.
+func Discard() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
+
+// tee passes records on to every handler that is enabled for them.
+type tee []slog.Handler
+
+func (t tee) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t tee) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range t {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t tee) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(tee, len(t))
+	for i, h := range t {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (t tee) WithGroup(name string) slog.Handler {
+	out := make(tee, len(t))
+	for i, h := range t {
+		out[i] = h.WithGroup(name)
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want slog.Level
+	}{
+		{"debug", slog.LevelDebug},
+		{"INFO", slog.LevelInfo},
+		{"warn", slog.LevelWarn},
+		{"warning", slog.LevelWarn},
+		{" error ", slog.LevelError},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if err != nil || got != tt.want {
+			t.Fatalf("ParseLevel(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); !errors.Is(err, ErrInvalidLevel) {
+		t.Fatalf("ParseLevel(verbose) error = %v, want ErrInvalidLevel", err)
+	}
+}
+
+func TestNewTeesRecords(t *testing.T) {
+	var out bytes.Buffer
+	buf := NewBuffer(10)
+	logger := New(&out, slog.LevelInfo, buf).With("component", "exporter")
+
+	logger.Debug("hidden")
+	logger.Warn("push failed", "error", "connection refused", slog.Group("req", "status", 503))
+
+	if strings.Contains(out.String(), "hidden") {
+		t.Fatalf("debug record written at info level: %q", out.String())
+	}
+	if !strings.Contains(out.String(), `level=WARN msg="push failed" component=exporter error="connection refused" req.status=503`) {
+		t.Fatalf("text output = %q", out.String())
+	}
+
+	entries := buf.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("buffer kept %d entries, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.Level != slog.LevelWarn || e.Message != "push failed" ||
+		e.Attrs != `component=exporter error="connection refused" req.status=503` {
+		t.Fatalf("entry = %+v", e)
+	}
+	if s := e.String(); !strings.HasSuffix(s, ` WARN  push failed component=exporter error="connection refused" req.status=503`) {
+		t.Fatalf("String() = %q", s)
+	}
+}
+
+func TestBufferKeepsNewest(t *testing.T) {
+	buf := NewBuffer(3)
+	logger := New(nil, slog.LevelInfo, buf)
+	for _, msg := range []string{"a", "b", "c", "d", "e"} {
+		logger.Info(msg)
+	}
+
+	var got []string
+	for _, e := range buf.Entries() {
+		got = append(got, e.Message)
+	}
+	if strings.Join(got, "") != "cde" {
+		t.Fatalf("entries = %v, want [c d e]", got)
+	}
+}
@@ -0,0 +1,309 @@
+// Package maintenance parses scheduled maintenance windows, during which
+// loss is recorded but does not count toward SLA figures.
+//
+// Two syntaxes are accepted:
+//
+//	[days] HH:MM-HH:MM              time range, e.g. "Sun 02:00-04:00",
+//	                                "Mon-Fri 22:30-01:00" or "03:00-03:15"
+//	MIN HOUR DOM MON DOW DURATION   cron schedule plus length, e.g.
+//	                                "0 2 * * 0 2h" or "*/30 * * * * 5m"
+//
+// Days are Mon..Sun as a single day, a range or a comma-separated list;
+// without days the range applies every day. Ranges may cross midnight, in
+// which case the days name the day the window starts.
+package maintenance
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidWindow is returned for window specifications that cannot be parsed.
+var ErrInvalidWindow = errors.New("invalid maintenance window")
+
+// maxCronDuration bounds the length of cron windows.
+const maxCronDuration = 24 * time.Hour
+
+// Window is a recurring maintenance window.
+type Window interface {
+	// Contains reports whether t, in the schedule's time zone, falls inside the window.
+	Contains(t time.Time) bool
+	String() string
+}
+
+// Schedule is a set of maintenance windows evaluated in one time zone.
+type Schedule struct {
+	windows []Window
+	loc     *time.Location
+}
+
+// Parse parses window specifications into a schedule evaluated in loc.
+// No specifications yield an empty schedule that contains nothing.
+func Parse(specs []string, loc *time.Location) (*Schedule, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	s := &Schedule{loc: loc}
+	for _, spec := range specs {
+		w, err := ParseWindow(spec)
+		if err != nil {
+			return nil, err
+		}
+		s.windows = append(s.windows, w)
+	}
+	return s, nil
+}
+
+// Contains reports whether t falls inside any window.
+func (s *Schedule) Contains(t time.Time) bool {
+	if s == nil {
+		return false
+	}
+	t = t.In(s.loc)
+	for _, w := range s.windows {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of windows.
+func (s *Schedule) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.windows)
+}
+
+// ParseWindow parses a single window specification.
+func ParseWindow(spec string) (Window, error) {
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 1:
+		return parseRange(allDays(), fields[0], spec)
+	case 2:
+		days, err := parseDays(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %v", ErrInvalidWindow, spec, err)
+		}
+		return parseRange(days, fields[1], spec)
+	case 6:
+		return parseCron(fields, spec)
+	}
+	return nil, fmt.Errorf("%w %q: expected \"[days] HH:MM-HH:MM\" or \"MIN HOUR DOM MON DOW DURATION\"", ErrInvalidWindow, spec)
+}
+
+// rangeWindow is a daily time range on selected weekdays.
+type rangeWindow struct {
+	spec  string
+	days  [7]bool // Indexed by time.Weekday
+	start int     // Minutes after midnight
+	end   int
+}
+
+// Contains implements Window.
+func (w rangeWindow) Contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	if w.start < w.end {
+		return w.days[t.Weekday()] && m >= w.start && m < w.end
+	}
+	// Crosses midnight: the evening part belongs to the start day
+	yesterday := (t.Weekday() + 6) % 7
+	return (w.days[t.Weekday()] && m >= w.start) || (w.days[yesterday] && m < w.end)
+}
+
+// String implements Window.
+func (w rangeWindow) String() string { return w.spec }
+
+// parseRange parses "HH:MM-HH:MM" for the given days.
+func parseRange(days [7]bool, s, spec string) (Window, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return nil, fmt.Errorf("%w %q: expected HH:MM-HH:MM", ErrInvalidWindow, spec)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %v", ErrInvalidWindow, spec, err)
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %v", ErrInvalidWindow, spec, err)
+	}
+	if start == end {
+		return nil, fmt.Errorf("%w %q: window is empty", ErrInvalidWindow, spec)
+	}
+	return rangeWindow{spec: spec, days: days, start: start, end: end}, nil
+}
+
+// parseClock parses HH:MM into minutes after midnight; 24:00 is accepted as an end.
+func parseClock(s string) (int, error) {
+	if s == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// dayNames maps lowercase day abbreviations to weekdays.
+var dayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// allDays returns a day set with every weekday selected.
+func allDays() [7]bool {
+	return [7]bool{true, true, true, true, true, true, true}
+}
+
+// parseDays parses "Mon", "Mon-Fri" or "Sat,Sun" into a day set. Ranges
+// wrap around the week, so "Fri-Mon" covers the weekend.
+func parseDays(s string) ([7]bool, error) {
+	var days [7]bool
+	for _, part := range strings.Split(strings.ToLower(s), ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, ok := dayNames[from]
+		if !ok {
+			return days, fmt.Errorf("unknown day %q", from)
+		}
+		last := first
+		if isRange {
+			if last, ok = dayNames[to]; !ok {
+				return days, fmt.Errorf("unknown day %q", to)
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// cronWindow starts at every minute matched by a cron expression and lasts
+// for a fixed duration.
+type cronWindow struct {
+	spec     string
+	minute   [60]bool
+	hour     [24]bool
+	dom      [32]bool // Indexed by day of month, 1-31
+	month    [13]bool // Indexed by month, 1-12
+	dow      [7]bool  // Indexed by time.Weekday
+	anyDom   bool
+	anyDow   bool
+	duration time.Duration
+}
+
+// Contains implements Window by looking back over the window length for a
+// matching start minute.
+func (w *cronWindow) Contains(t time.Time) bool {
+	start := t.Truncate(time.Minute)
+	for back := time.Duration(0); back < w.duration; back += time.Minute {
+		if w.matches(start.Add(-back)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether a window starts at minute t. As in cron, when
+// both day of month and day of week are restricted either may match.
+func (w *cronWindow) matches(t time.Time) bool {
+	if !w.minute[t.Minute()] || !w.hour[t.Hour()] || !w.month[t.Month()] {
+		return false
+	}
+	domOK, dowOK := w.dom[t.Day()], w.dow[t.Weekday()]
+	switch {
+	case w.anyDom && w.anyDow:
+		return true
+	case w.anyDom:
+		return dowOK
+	case w.anyDow:
+		return domOK
+	default:
+		return domOK || dowOK
+	}
+}
+
+// String implements Window.
+func (w *cronWindow) String() string { return w.spec }
+
+// parseCron parses the five cron fields plus a duration.
+func parseCron(fields []string, spec string) (Window, error) {
+	w := &cronWindow{spec: spec, anyDom: fields[2] == "*", anyDow: fields[4] == "*"}
+
+	d, err := time.ParseDuration(fields[5])
+	if err != nil || d < time.Minute || d > maxCronDuration || d%time.Minute != 0 {
+		return nil, fmt.Errorf("%w %q: duration must be whole minutes between 1m and 24h", ErrInvalidWindow, spec)
+	}
+	w.duration = d
+
+	var dow [8]bool // cron allows 7 as well as 0 for Sunday
+	for _, f := range []struct {
+		name     string
+		field    string
+		min, max int
+		set      []bool
+	}{
+		{"minute", fields[0], 0, 59, w.minute[:]},
+		{"hour", fields[1], 0, 23, w.hour[:]},
+		{"day of month", fields[2], 1, 31, w.dom[:]},
+		{"month", fields[3], 1, 12, w.month[:]},
+		{"day of week", fields[4], 0, 7, dow[:]},
+	} {
+		if err := parseCronField(f.field, f.min, f.max, f.set); err != nil {
+			return nil, fmt.Errorf("%w %q: %s: %v", ErrInvalidWindow, spec, f.name, err)
+		}
+	}
+	copy(w.dow[:], dow[:7])
+	w.dow[time.Sunday] = dow[0] || dow[7]
+	return w, nil
+}
+
+// parseCronField marks the values selected by a cron field ("*", "5",
+// "1-5", "*/15", "0-30/10" or a comma-separated list of these).
+func parseCronField(field string, lo, hi int, set []bool) error {
+	for _, part := range strings.Split(field, ",") {
+		expr, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid step %q", stepStr)
+			}
+			step = n
+		}
+
+		from, to := lo, hi
+		if expr != "*" {
+			a, b, isRange := strings.Cut(expr, "-")
+			n, err := strconv.Atoi(a)
+			if err != nil {
+				return fmt.Errorf("invalid value %q", a)
+			}
+			from, to = n, n
+			if isRange {
+				if to, err = strconv.Atoi(b); err != nil {
+					return fmt.Errorf("invalid value %q", b)
+				}
+			} else if hasStep {
+				to = hi
+			}
+		}
+		if from < lo || to > hi || from > to {
+			return fmt.Errorf("%q out of range %d-%d", part, lo, hi)
+		}
+		for v := from; v <= to; v += step {
+			set[v] = true
+		}
+	}
+	return nil
+}
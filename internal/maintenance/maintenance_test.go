@@ -0,0 +1,91 @@
+package maintenance
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseWindowContains(t *testing.T) {
+	// 2024-01-07 is a Sunday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 1, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		spec string
+		t    time.Time
+		want bool
+	}{
+		{"03:00-03:15", at(3, 3, 0), true},
+		{"03:00-03:15", at(3, 3, 15), false},
+		{"Sun 02:00-04:00", at(7, 2, 30), true},
+		{"Sun 02:00-04:00", at(8, 2, 30), false},
+		{"Mon-Fri 22:30-01:00", at(5, 23, 0), true},  // Friday evening
+		{"Mon-Fri 22:30-01:00", at(6, 0, 30), true},  // ...spills into Saturday
+		{"Mon-Fri 22:30-01:00", at(6, 23, 0), false}, // Saturday evening
+		{"Mon-Fri 22:30-01:00", at(1, 0, 30), false}, // Monday after Sunday
+		{"Sat,Sun 00:00-24:00", at(6, 12, 0), true},
+		{"Fri-Mon 12:00-13:00", at(8, 12, 0), true},
+		{"Fri-Mon 12:00-13:00", at(9, 12, 0), false},
+		{"0 2 * * 0 2h", at(7, 3, 59), true},
+		{"0 2 * * 7 2h", at(7, 4, 0), false},
+		{"0 2 * * 0 2h", at(8, 2, 30), false},
+		{"*/30 * * * * 5m", at(3, 10, 34), true},
+		{"*/30 * * * * 5m", at(3, 10, 35), false},
+		{"0 23 1 * * 2h", at(2, 0, 30), true},     // Crosses into the next day
+		{"0 12 15 * 1 1h", at(1, 12, 10), true},   // Monday, not the 15th
+		{"0-10/5 9 * 1 * 1m", at(3, 9, 10), true}, // January only
+	}
+
+	for _, tt := range tests {
+		w, err := ParseWindow(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseWindow(%q): %v", tt.spec, err)
+		}
+		if got := w.Contains(tt.t); got != tt.want {
+			t.Fatalf("%q.Contains(%v) = %v, want %v", tt.spec, tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestParseWindowErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"03:00",
+		"03:00-03:00",
+		"25:00-26:00",
+		"Funday 01:00-02:00",
+		"Mon-Xyz 01:00-02:00",
+		"0 2 * * 0",
+		"60 2 * * 0 1h",
+		"0 2 * * 0 48h",
+		"0 2 * * 0 30s",
+		"0 2 * * 0 90s",
+		"*/0 * * * * 1m",
+		"5-1 * * * * 1m",
+	} {
+		if _, err := ParseWindow(spec); !errors.Is(err, ErrInvalidWindow) {
+			t.Fatalf("ParseWindow(%q) err = %v, want ErrInvalidWindow", spec, err)
+		}
+	}
+}
+
+func TestScheduleUsesLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*3600)
+	s, err := Parse([]string{"02:00-03:00"}, loc)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !s.Contains(time.Date(2024, 1, 3, 0, 30, 0, 0, time.UTC)) {
+		t.Fatalf("expected 00:30 UTC to be 02:30 in the schedule's zone")
+	}
+	if s.Contains(time.Date(2024, 1, 3, 2, 30, 0, 0, time.UTC)) {
+		t.Fatalf("expected 02:30 UTC to be outside the window")
+	}
+
+	var empty *Schedule
+	if empty.Contains(time.Now()) || empty.Len() != 0 {
+		t.Fatalf("nil schedule must contain nothing")
+	}
+}
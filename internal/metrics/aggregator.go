@@ -0,0 +1,285 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+// MinuteSlots is the number of per-minute buckets kept: 24 hours.
+const MinuteSlots = 1440
+
+// Histogram layout used to estimate percentiles of merged minutes. Bin i
+// holds RTTs up to histogramBase·histogramGrowth^i ms; the last bin is open.
+const (
+	histogramBins   = 64
+	histogramBase   = 0.1
+	histogramGrowth = 1.25
+)
+
+// Windows are the trailing aggregation windows exported as the "bucket"
+// dimension, shortest first.
+var Windows = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+	time.Hour,
+	24 * time.Hour,
+}
+
+// Bucket summarizes the samples of one aggregation period.
+type Bucket struct {
+	Start       time.Time
+	Width       time.Duration
+	Samples     int
+	Timeouts    int
+	LossPercent float64
+	MinMs       float64
+	AvgMs       float64
+	MaxMs       float64
+	P50Ms       float64 // Estimated from a log-scale histogram, never above MaxMs
+	P95Ms       float64 // Estimated likewise
+	P99Ms       float64 // Estimated likewise
+	Maintenance int     // Samples taken during a maintenance window
+}
+
+// periodSlot accumulates the samples of one period: a minute, hour or day.
+type periodSlot struct {
+	start       time.Time // Zero when unused
+	samples     int
+	timeouts    int
+	maintenance int
+	sumMs       float64
+	minMs       float64
+	maxMs       float64
+	hist        [histogramBins]uint32
+}
+
+// Aggregator rolls samples into per-minute buckets over the last 24 hours,
+// in fixed memory regardless of the sample rate.
+type Aggregator struct {
+	mu     sync.RWMutex
+	slots  [MinuteSlots]periodSlot
+	newest time.Time // Start of the most recent minute seen
+}
+
+// NewAggregator creates an empty per-minute aggregator.
+func NewAggregator() *Aggregator {
+	return &Aggregator{}
+}
+
+// Add records a sample in the bucket of its minute. Samples older than the
+// 24 hours kept are ignored.
+func (a *Aggregator) Add(sample types.Sample) {
+	start := sample.Timestamp.Truncate(time.Minute)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.newest.IsZero() && !start.After(a.newest.Add(-MinuteSlots*time.Minute)) {
+		return
+	}
+	if start.After(a.newest) {
+		a.newest = start
+	}
+
+	slot := &a.slots[slotIndex(start)]
+	if !slot.start.Equal(start) {
+		*slot = periodSlot{start: start}
+	}
+	slot.add(sample)
+}
+
+// Reset discards all buckets.
+func (a *Aggregator) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.slots = [MinuteSlots]periodSlot{}
+	a.newest = time.Time{}
+}
+
+// Buckets returns the last 24 hours rolled up into buckets of the given
+// width (a whole number of minutes), oldest first. The range runs from the
+// oldest bucket with data to the newest one; periods without samples are
+// included with Samples == 0 so positions map to time.
+func (a *Aggregator) Buckets(width time.Duration) []Bucket {
+	width = width.Truncate(time.Minute)
+	if width <= 0 {
+		width = time.Minute
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.newest.IsZero() {
+		return nil
+	}
+
+	last := a.newest.Truncate(width)
+	first := last
+	for i := range a.slots {
+		if s := &a.slots[i]; s.samples > 0 && a.live(s) && s.start.Truncate(width).Before(first) {
+			first = s.start.Truncate(width)
+		}
+	}
+
+	n := int(last.Sub(first)/width) + 1
+	merged := make([]periodSlot, n)
+	for i := range a.slots {
+		s := &a.slots[i]
+		if s.samples == 0 || !a.live(s) {
+			continue
+		}
+		merged[int(s.start.Truncate(width).Sub(first)/width)].merge(s)
+	}
+
+	buckets := make([]Bucket, n)
+	for i := range merged {
+		buckets[i] = merged[i].bucket(first.Add(time.Duration(i)*width), width)
+	}
+	return buckets
+}
+
+// Window summarizes the trailing window ending with the newest minute; a
+// one-minute window is the current, still filling minute.
+func (a *Aggregator) Window(d time.Duration) Bucket {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.newest.IsZero() {
+		return Bucket{Width: d}
+	}
+
+	from := a.newest.Add(-d + time.Minute)
+	var merged periodSlot
+	for i := range a.slots {
+		s := &a.slots[i]
+		if s.samples > 0 && a.live(s) && !s.start.Before(from) {
+			merged.merge(s)
+		}
+	}
+	return merged.bucket(from, d)
+}
+
+// live reports whether a slot belongs to the 24 hours ending at the newest
+// minute, rather than holding data from an earlier lap of the ring.
+func (a *Aggregator) live(s *periodSlot) bool {
+	return s.start.After(a.newest.Add(-MinuteSlots * time.Minute))
+}
+
+// add records one sample.
+func (s *periodSlot) add(sample types.Sample) {
+	s.samples++
+	if sample.Maintenance {
+		s.maintenance++
+	}
+	if sample.Timeout {
+		s.timeouts++
+		return
+	}
+	ms := sample.RTTMs()
+	if s.samples-s.timeouts == 1 || ms < s.minMs {
+		s.minMs = ms
+	}
+	if ms > s.maxMs {
+		s.maxMs = ms
+	}
+	s.sumMs += ms
+	s.hist[histogramBin(ms)]++
+}
+
+// merge adds the samples of other into s.
+func (s *periodSlot) merge(other *periodSlot) {
+	success := other.samples - other.timeouts
+	if success > 0 {
+		if s.samples-s.timeouts == 0 || other.minMs < s.minMs {
+			s.minMs = other.minMs
+		}
+		if other.maxMs > s.maxMs {
+			s.maxMs = other.maxMs
+		}
+	}
+	s.samples += other.samples
+	s.timeouts += other.timeouts
+	s.maintenance += other.maintenance
+	s.sumMs += other.sumMs
+	for i, c := range other.hist {
+		s.hist[i] += c
+	}
+}
+
+// bucket converts accumulated samples into a Bucket.
+func (s *periodSlot) bucket(start time.Time, width time.Duration) Bucket {
+	b := Bucket{Start: start, Width: width, Samples: s.samples, Timeouts: s.timeouts, Maintenance: s.maintenance}
+	if s.samples > 0 {
+		b.LossPercent = float64(s.timeouts) / float64(s.samples) * 100
+	}
+	success := s.samples - s.timeouts
+	if success > 0 {
+		b.MinMs = s.minMs
+		b.MaxMs = s.maxMs
+		b.AvgMs = s.sumMs / float64(success)
+		b.P50Ms = s.percentile(50, success)
+		b.P95Ms = s.percentile(95, success)
+		b.P99Ms = s.percentile(99, success)
+	}
+	return b
+}
+
+// percentile estimates the p-th percentile of successful RTTs as the upper
+// bound of the histogram bin holding it, clamped to the observed range.
+func (s *periodSlot) percentile(p float64, success int) float64 {
+	rank := uint32(math.Ceil(p / 100 * float64(success)))
+	var seen uint32
+	for i, c := range s.hist {
+		seen += c
+		if seen >= rank {
+			return math.Max(s.minMs, math.Min(s.maxMs, histogramUpper(i)))
+		}
+	}
+	return s.maxMs
+}
+
+// slotIndex returns the ring position of a minute.
+func slotIndex(start time.Time) int {
+	return int((start.Unix() / 60) % MinuteSlots)
+}
+
+// histogramBin returns the histogram bin of an RTT in milliseconds.
+func histogramBin(ms float64) int {
+	if ms <= histogramBase {
+		return 0
+	}
+	bin := int(math.Ceil(math.Log(ms/histogramBase) / math.Log(histogramGrowth)))
+	return min(bin, histogramBins-1)
+}
+
+// histogramUpper returns the upper bound of a histogram bin in milliseconds.
+func histogramUpper(bin int) float64 {
+	if bin >= histogramBins-1 {
+		return math.Inf(1)
+	}
+	return histogramBase * math.Pow(histogramGrowth, float64(bin))
+}
+
+// WindowName formats a window as used for the "bucket" dimension: "1m",
+// "5m", "1h", "24h".
+func WindowName(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+	return fmt.Sprintf("%dm", d/time.Minute)
+}
+
+// ParseWindow parses a bucket width such as "1m", "5m" or "1h". The width
+// must be a whole number of minutes no longer than 24 hours.
+func ParseWindow(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil || d < time.Minute || d > MinuteSlots*time.Minute || d%time.Minute != 0 {
+		return 0, fmt.Errorf("bucket must be whole minutes between 1m and 24h, got %q", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+func TestAggregator_MinuteBuckets(t *testing.T) {
+	a := NewAggregator()
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	for i := 1; i <= 20; i++ {
+		a.Add(types.Sample{Timestamp: base.Add(time.Duration(i) * time.Second), RTT: time.Duration(i) * time.Millisecond})
+	}
+	a.Add(types.Sample{Timestamp: base.Add(30 * time.Second), Timeout: true})
+	a.Add(types.Sample{Timestamp: base.Add(2*time.Minute + time.Second), RTT: 50 * time.Millisecond})
+
+	buckets := a.Buckets(time.Minute)
+	if len(buckets) != 3 {
+		t.Fatalf("len(buckets)=%d, want 3", len(buckets))
+	}
+
+	b := buckets[0]
+	if !b.Start.Equal(base) || b.Samples != 21 || b.Timeouts != 1 {
+		t.Fatalf("first bucket = %+v", b)
+	}
+	if b.MinMs != 1 || b.MaxMs != 20 || b.AvgMs != 10.5 {
+		t.Fatalf("min/avg/max = %v/%v/%v, want 1/10.5/20", b.MinMs, b.AvgMs, b.MaxMs)
+	}
+	// True p95 is 19ms; the histogram estimate is within one bin above it
+	if b.P95Ms < 19 || b.P95Ms > 20 {
+		t.Fatalf("P95Ms=%v, want within [19, 20]", b.P95Ms)
+	}
+	if b.P50Ms < 10 || b.P50Ms > 12.5 || b.P99Ms != 20 {
+		t.Fatalf("P50Ms=%v P99Ms=%v, want p50 within [10, 12.5] and p99 capped at the max", b.P50Ms, b.P99Ms)
+	}
+	if buckets[1].Samples != 0 {
+		t.Fatalf("gap bucket = %+v, want empty", buckets[1])
+	}
+	if buckets[2].Samples != 1 || buckets[2].P95Ms != 50 {
+		t.Fatalf("last bucket = %+v", buckets[2])
+	}
+}
+
+func TestAggregator_RollupAndWindow(t *testing.T) {
+	a := NewAggregator()
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	for m := 0; m < 10; m++ {
+		ts := base.Add(time.Duration(m) * time.Minute)
+		a.Add(types.Sample{Timestamp: ts, RTT: 10 * time.Millisecond})
+		a.Add(types.Sample{Timestamp: ts.Add(time.Second), Timeout: m%2 == 0})
+	}
+
+	buckets := a.Buckets(5 * time.Minute)
+	if len(buckets) != 2 {
+		t.Fatalf("len(buckets)=%d, want 2", len(buckets))
+	}
+	if buckets[0].Samples != 10 || buckets[0].Timeouts != 3 || buckets[1].Timeouts != 2 {
+		t.Fatalf("buckets = %+v", buckets)
+	}
+
+	w := a.Window(time.Minute)
+	if w.Samples != 2 || w.Timeouts != 0 {
+		t.Fatalf("1m window = %+v, want the last minute only", w)
+	}
+	w = a.Window(24 * time.Hour)
+	if w.Samples != 20 || w.LossPercent != 25 {
+		t.Fatalf("24h window = %+v, want 20 samples at 25%% loss", w)
+	}
+}
+
+func TestAggregator_KeepsOnly24Hours(t *testing.T) {
+	a := NewAggregator()
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	a.Add(types.Sample{Timestamp: base, RTT: time.Millisecond})
+	a.Add(types.Sample{Timestamp: base.Add(24 * time.Hour), RTT: 2 * time.Millisecond})
+	// Too old once the newer minute is known
+	a.Add(types.Sample{Timestamp: base.Add(time.Second), RTT: 3 * time.Millisecond})
+
+	buckets := a.Buckets(time.Minute)
+	if len(buckets) != 1 || buckets[0].Samples != 1 || buckets[0].MaxMs != 2 {
+		t.Fatalf("buckets = %+v, want only the newest minute", buckets)
+	}
+
+	a.Reset()
+	if got := a.Buckets(time.Minute); got != nil {
+		t.Fatalf("after Reset buckets = %+v, want nil", got)
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"1m", time.Minute, false},
+		{"15m", 15 * time.Minute, false},
+		{"24h", 24 * time.Hour, false},
+		{"30s", 0, true},
+		{"90s", 0, true},
+		{"48h", 0, true},
+		{"abc", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseWindow(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Fatalf("ParseWindow(%q) = %v, %v; want %v, err=%v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	for _, w := range Windows {
+		if d, err := ParseWindow(WindowName(w)); err != nil || d != w {
+			t.Fatalf("WindowName(%v) does not round-trip: %v, %v", w, d, err)
+		}
+	}
+}
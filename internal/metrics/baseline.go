@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+// DefaultCalibrationSamples is how many replies a Calibrator learns the
+// baseline from unless told otherwise.
+const DefaultCalibrationSamples = 60
+
+// Baseline is the typical latency of a target, against which the delta view
+// shows regressions.
+type Baseline struct {
+	P50Ms   float64
+	P95Ms   float64
+	Samples int // Replies it was learned from; 0 when given rather than learned
+}
+
+// IsZero reports whether no baseline is known.
+func (b Baseline) IsZero() bool {
+	return b.P50Ms == 0
+}
+
+// Calibrator learns the baseline of a target from its first replies.
+// Timeouts and samples taken during maintenance do not count, as they are
+// not the latency the target usually has. It is safe for concurrent use.
+type Calibrator struct {
+	n int
+
+	mu       sync.RWMutex
+	calc     *PercentileCalculator
+	baseline Baseline
+	done     bool // Learned from replies, or given with Set
+	learned  bool // Learned from replies
+}
+
+// NewCalibrator creates a Calibrator learning the baseline from n replies.
+func NewCalibrator(n int) *Calibrator {
+	return &Calibrator{n: max(n, 1), calc: NewPercentileCalculator()}
+}
+
+// Add records a sample until the baseline is learned.
+func (c *Calibrator) Add(sample types.Sample) {
+	if sample.Timeout || sample.Maintenance {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.done {
+		return
+	}
+	c.calc.Add(sample.RTT)
+	if c.calc.Count() >= c.n {
+		c.baseline = Baseline{P50Ms: c.calc.P50(), P95Ms: c.calc.P95(), Samples: c.calc.Count()}
+		c.done, c.learned = true, true
+		c.calc.Reset()
+	}
+}
+
+// Baseline returns the learned or given baseline, the seeded one while
+// calibrating, or zero.
+func (c *Calibrator) Baseline() Baseline {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.baseline
+}
+
+// Progress returns how many replies were recorded so far and how many the
+// baseline is learned from.
+func (c *Calibrator) Progress() (have, want int) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.done {
+		return c.n, c.n
+	}
+	return c.calc.Count(), c.n
+}
+
+// Learned returns the baseline learned from replies, false while
+// calibrating or if it was given with Set.
+func (c *Calibrator) Learned() (Baseline, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.baseline, c.learned
+}
+
+// Set uses b as the baseline instead of learning one.
+func (c *Calibrator) Set(b Baseline) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.baseline, c.done, c.learned = b, true, false
+	c.calc.Reset()
+}
+
+// Seed uses b, learned in an earlier run, as the baseline until this run's
+// replies have taught a fresh one.
+func (c *Calibrator) Seed(b Baseline) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.done {
+		c.baseline = b
+	}
+}
+
+// Reset forgets the baseline and calibrates again, when the target changes.
+func (c *Calibrator) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.baseline, c.done, c.learned = Baseline{}, false, false
+	c.calc.Reset()
+}
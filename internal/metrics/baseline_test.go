@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+func TestCalibrator(t *testing.T) {
+	c := NewCalibrator(10)
+	for i := range 9 {
+		c.Add(types.Sample{RTT: time.Duration(20+i) * time.Millisecond})
+		c.Add(types.Sample{Timeout: true})
+		c.Add(types.Sample{RTT: time.Second, Maintenance: true})
+	}
+	if b := c.Baseline(); !b.IsZero() {
+		t.Fatalf("Baseline() after 9 of 10 replies = %+v, want zero", b)
+	}
+	if have, want := c.Progress(); have != 9 || want != 10 {
+		t.Fatalf("Progress() = %d, %d, want 9, 10 (timeouts and maintenance not counted)", have, want)
+	}
+
+	c.Add(types.Sample{RTT: 29 * time.Millisecond})
+	b := c.Baseline()
+	if b.Samples != 10 || b.P50Ms < 24 || b.P50Ms > 25 || b.P95Ms < 28 || b.P95Ms > 29 {
+		t.Fatalf("Baseline() = %+v, want p50 24-25ms and p95 28-29ms from 10 replies", b)
+	}
+
+	// Learned once: later replies do not move it
+	c.Add(types.Sample{RTT: 500 * time.Millisecond})
+	if got := c.Baseline(); got != b {
+		t.Fatalf("Baseline() after calibration = %+v, want %+v unchanged", got, b)
+	}
+
+	c.Reset()
+	if have, _ := c.Progress(); have != 0 || !c.Baseline().IsZero() {
+		t.Fatalf("after Reset() Progress() = %d, Baseline() = %+v, want calibrating from 0", have, c.Baseline())
+	}
+	c.Set(Baseline{P50Ms: 12})
+	if got := c.Baseline(); got.P50Ms != 12 {
+		t.Fatalf("Baseline() after Set = %+v, want p50 12ms", got)
+	}
+	if _, ok := c.Learned(); ok {
+		t.Fatalf("Learned() of a given baseline = true, want false")
+	}
+}
+
+func TestCalibratorSeed(t *testing.T) {
+	c := NewCalibrator(2)
+	stored := Baseline{P50Ms: 40, P95Ms: 60, Samples: 60}
+	c.Seed(stored)
+	if got := c.Baseline(); got != stored {
+		t.Fatalf("Baseline() after Seed = %+v, want the stored %+v", got, stored)
+	}
+	if _, ok := c.Learned(); ok {
+		t.Fatalf("Learned() while calibrating = true, want false")
+	}
+
+	// This run's replies replace the stored baseline once learned
+	c.Add(types.Sample{RTT: 20 * time.Millisecond})
+	c.Add(types.Sample{RTT: 20 * time.Millisecond})
+	b, ok := c.Learned()
+	if !ok || b.P50Ms != 20 || c.Baseline() != b {
+		t.Fatalf("Learned() = %+v, %v, Baseline() = %+v, want p50 20ms learned", b, ok, c.Baseline())
+	}
+	c.Seed(stored)
+	if got := c.Baseline(); got != b {
+		t.Fatalf("Baseline() after a late Seed = %+v, want the learned %+v", got, b)
+	}
+}
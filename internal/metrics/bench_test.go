@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"math/rand/v2"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+// benchRTT returns a pseudo-random RTT around 20ms with a long tail.
+func benchRTT(r *rand.Rand) time.Duration {
+	return time.Duration(15+r.ExpFloat64()*5) * time.Millisecond
+}
+
+func BenchmarkPercentileCalculator(b *testing.B) {
+	r := rand.New(rand.NewPCG(1, 2))
+	p := NewPercentileCalculator()
+	for range 10000 {
+		p.Add(benchRTT(r))
+	}
+	for b.Loop() {
+		// Every Add unsorts the samples, so each call pays for a sort
+		p.Add(benchRTT(r))
+		_ = p.GetPercentiles()
+	}
+}
+
+func BenchmarkEngineAddStats(b *testing.B) {
+	r := rand.New(rand.NewPCG(1, 2))
+	e := NewEngine()
+	start := time.Unix(0, 0)
+	seq := 0
+	for b.Loop() {
+		seq++
+		sample := types.Sample{Sequence: seq, Timestamp: start.Add(time.Duration(seq) * 100 * time.Millisecond)}
+		if r.IntN(100) == 0 {
+			sample.Timeout = true
+		} else {
+			sample.RTT = benchRTT(r)
+		}
+		e.Add(sample)
+		_ = e.Stats()
+	}
+}
+
+// BenchmarkEngineConcurrentStats reads stats on every CPU while a sample
+// arrives every millisecond, as the TUI, exporter and servers read them
+// while the probe loop feeds the engine.
+func BenchmarkEngineConcurrentStats(b *testing.B) {
+	r := rand.New(rand.NewPCG(1, 2))
+	e := NewEngine()
+	for seq := range 10000 {
+		e.Add(types.Sample{Sequence: seq, RTT: benchRTT(r)})
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		tick := time.NewTicker(time.Millisecond)
+		defer tick.Stop()
+		for seq := 10000; ; seq++ {
+			select {
+			case <-done:
+				return
+			case <-tick.C:
+				e.Add(types.Sample{Sequence: seq, RTT: benchRTT(r)})
+			}
+		}
+	})
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = e.Stats()
+		}
+	})
+	close(done)
+	wg.Wait()
+}
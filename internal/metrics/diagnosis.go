@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"slices"
+
+	"github.com/pbv7/pingheat/internal/buffer"
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+// Verdict is the conclusion of a Diagnoser.
+type Verdict int
+
+const (
+	VerdictMeasuring Verdict = iota // Too few samples yet
+	VerdictHealthy                  // Both paths are fine
+	VerdictLocal                    // The gateway is slow or lossy: Wi-Fi or LAN
+	VerdictISP                      // Only the path beyond the gateway suffers
+)
+
+// String returns the verdict as shown to users.
+func (v Verdict) String() string {
+	switch v {
+	case VerdictHealthy:
+		return "Healthy"
+	case VerdictLocal:
+		return "Wi-Fi/LAN problem"
+	case VerdictISP:
+		return "ISP problem"
+	default:
+		return "Measuring"
+	}
+}
+
+// Diagnosis thresholds. A gateway a few meters away answers in a few
+// milliseconds, so tens of milliseconds there already mean a congested or
+// noisy local link.
+const (
+	diagnoseWindow     = 60  // Recent samples compared per path
+	diagnoseMinSamples = 10  // Samples per path before a verdict
+	diagnoseBadLoss    = 2.0 // Loss percent that marks a path bad
+	diagnoseGatewayP95 = 50  // p95 in ms above which the gateway is bad
+)
+
+// PathStats summarizes the recent samples of one diagnosed path.
+type PathStats struct {
+	Samples     int
+	LossPercent float64
+	P95Ms       float64 // 0 without replies
+}
+
+// Diagnosis compares the local network with the path beyond it.
+type Diagnosis struct {
+	Verdict Verdict
+	Gateway PathStats
+	Anchor  PathStats
+}
+
+// Diagnoser tells local network problems from ISP problems by probing the
+// default gateway alongside a public anchor: loss or latency that already
+// shows at the gateway is local, loss that only shows beyond it is not.
+type Diagnoser struct {
+	gateway *buffer.RingBuffer[types.Sample]
+	anchor  *buffer.RingBuffer[types.Sample]
+	slowMs  float64 // Latency the anchor may add to the gateway's p95
+}
+
+// NewDiagnoser creates a diagnoser that calls the anchor path bad when its
+// p95 exceeds the gateway's by more than slowMs.
+func NewDiagnoser(slowMs float64) *Diagnoser {
+	return &Diagnoser{
+		gateway: buffer.NewRingBuffer[types.Sample](diagnoseWindow),
+		anchor:  buffer.NewRingBuffer[types.Sample](diagnoseWindow),
+		slowMs:  slowMs,
+	}
+}
+
+// AddGateway records a sample of the gateway.
+func (d *Diagnoser) AddGateway(sample types.Sample) {
+	d.gateway.Push(sample)
+}
+
+// AddAnchor records a sample of the public anchor.
+func (d *Diagnoser) AddAnchor(sample types.Sample) {
+	d.anchor.Push(sample)
+}
+
+// ResetAnchor forgets the anchor's samples, when its target changes.
+func (d *Diagnoser) ResetAnchor() {
+	d.anchor.Clear()
+}
+
+// Diagnosis returns the verdict over the recent samples of both paths.
+func (d *Diagnoser) Diagnosis() Diagnosis {
+	diag := Diagnosis{
+		Gateway: pathStats(d.gateway.All()),
+		Anchor:  pathStats(d.anchor.All()),
+	}
+	gw, anchor := diag.Gateway, diag.Anchor
+	switch {
+	case gw.Samples < diagnoseMinSamples || anchor.Samples < diagnoseMinSamples:
+		diag.Verdict = VerdictMeasuring
+	case gw.LossPercent > diagnoseBadLoss || gw.P95Ms > diagnoseGatewayP95:
+		diag.Verdict = VerdictLocal
+	case anchor.LossPercent > diagnoseBadLoss || anchor.P95Ms-gw.P95Ms > d.slowMs:
+		diag.Verdict = VerdictISP
+	default:
+		diag.Verdict = VerdictHealthy
+	}
+	return diag
+}
+
+// pathStats summarizes samples.
+func pathStats(samples []types.Sample) PathStats {
+	ps := PathStats{Samples: len(samples)}
+	var rtts []float64
+	for _, s := range samples {
+		if !s.Timeout {
+			rtts = append(rtts, float64(s.RTT.Microseconds())/1000)
+		}
+	}
+	if ps.Samples > 0 {
+		ps.LossPercent = float64(ps.Samples-len(rtts)) / float64(ps.Samples) * 100
+	}
+	if len(rtts) > 0 {
+		slices.Sort(rtts)
+		ps.P95Ms = rtts[min(len(rtts)-1, len(rtts)*95/100)]
+	}
+	return ps
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+func TestDiagnoser(t *testing.T) {
+	reply := func(ms int) types.Sample { return types.Sample{RTT: time.Duration(ms) * time.Millisecond} }
+	lost := types.Sample{Timeout: true}
+
+	tests := []struct {
+		name            string
+		gateway, anchor func(i int) types.Sample
+		n               int
+		want            Verdict
+	}{
+		{"too few samples", func(int) types.Sample { return reply(2) }, func(int) types.Sample { return reply(20) }, 5, VerdictMeasuring},
+		{"healthy", func(int) types.Sample { return reply(2) }, func(int) types.Sample { return reply(20) }, 30, VerdictHealthy},
+		{"lossy Wi-Fi", func(i int) types.Sample {
+			if i%5 == 0 {
+				return lost
+			}
+			return reply(3)
+		}, func(i int) types.Sample {
+			if i%5 == 0 {
+				return lost
+			}
+			return reply(20)
+		}, 30, VerdictLocal},
+		{"slow Wi-Fi", func(int) types.Sample { return reply(120) }, func(int) types.Sample { return reply(140) }, 30, VerdictLocal},
+		{"ISP loss", func(int) types.Sample { return reply(2) }, func(i int) types.Sample {
+			if i%10 == 0 {
+				return lost
+			}
+			return reply(20)
+		}, 30, VerdictISP},
+		{"ISP latency", func(int) types.Sample { return reply(2) }, func(int) types.Sample { return reply(400) }, 30, VerdictISP},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDiagnoser(150)
+			for i := range tt.n {
+				d.AddGateway(tt.gateway(i))
+				d.AddAnchor(tt.anchor(i))
+			}
+			if got := d.Diagnosis(); got.Verdict != tt.want {
+				t.Fatalf("Diagnosis() = %+v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiagnoserResetAnchor(t *testing.T) {
+	d := NewDiagnoser(150)
+	for range 20 {
+		d.AddGateway(types.Sample{RTT: time.Millisecond})
+		d.AddAnchor(types.Sample{Timeout: true})
+	}
+	d.ResetAnchor()
+	got := d.Diagnosis()
+	if got.Verdict != VerdictMeasuring || got.Anchor.Samples != 0 || got.Gateway.Samples != 20 {
+		t.Fatalf("Diagnosis() after ResetAnchor = %+v, want the anchor measured afresh", got)
+	}
+}
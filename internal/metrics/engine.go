@@ -3,6 +3,7 @@ package metrics
 import (
 	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pbv7/pingheat/internal/types"
@@ -10,9 +11,19 @@ import (
 
 // Thresholds for brownout detection
 const (
-	BrownoutThresholdMs = 200 // RTT > 200ms is considered brownout
+	BrownoutThresholdMs = 200 // Default: RTT > 200ms is considered brownout
+
+	// WeakSignalDbm is the Wi-Fi signal at or below which links tend to
+	// lose packets and retry: samples taken at it count as weak-signal.
+	WeakSignalDbm = -70
 )
 
+// OutageBuckets are the upper bounds of the outage duration histogram.
+var OutageBuckets = [...]time.Duration{
+	time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second, 30 * time.Second,
+	time.Minute, 2 * time.Minute, 5 * time.Minute, 15 * time.Minute, time.Hour,
+}
+
 // Stats holds computed metrics.
 type Stats struct {
 	// Sample counts
@@ -20,6 +31,9 @@ type Stats struct {
 	TotalTimeouts int
 	TotalSuccess  int
 
+	// Timeouts by failure kind, indexed by types.ErrorKind (ErrorNone is unused)
+	ErrorCounts [types.ErrorKindCount]int
+
 	// Loss and availability
 	LossPercent  float64
 	AvailPercent float64 // 100 - LossPercent
@@ -50,25 +64,75 @@ type Stats struct {
 	Percentiles Percentiles
 
 	// Outage and instability patterns
-	LossBursts      int // Number of separate timeout burst events
-	BrownoutSamples int // Number of high-latency samples (> 200ms)
-	BrownoutBursts  int // Number of brownout events (transitions to high latency)
+	LossBursts      int  // Number of separate timeout burst events
+	BrownoutSamples int  // Number of high-latency samples (above the brownout threshold)
+	BrownoutBursts  int  // Number of brownout events (transitions to high latency)
 	InBrownout      bool // Currently in brownout state
 
+	// Finished loss bursts, from the first missed to the first recovered
+	// sample: how many lasted at most each OutageBuckets bound and above the
+	// previous one (the last entry counts longer ones), their total and the
+	// longest. Downtime adds the current burst up to its latest missed sample.
+	OutageCounts  [len(OutageBuckets) + 1]int
+	OutageTotal   time.Duration
+	LongestOutage time.Duration
+	Downtime      time.Duration
+
+	// Mean time between failures, the time without loss per loss burst, and
+	// mean time to recover from a finished one; 0 until there is one
+	MTBF time.Duration
+	MTTR time.Duration
+
+	// System clock jumps (suspend and resume, NTP steps) and the time they
+	// skipped, which counts neither as an outage nor toward MTBF
+	ClockJumps  int
+	SkippedTime time.Duration
+
+	// Intervals probed with -burst whose probes have all returned, and
+	// those of them that lost some or all of their probes
+	BurstIntervals       int
+	PartialLossIntervals int
+	FullLossIntervals    int
+
+	// Wi-Fi signal with -wifi: the latest recorded one in dBm (0 = none),
+	// and the samples taken at or below WeakSignalDbm and how many of them
+	// were lost, to compare with the overall loss
+	SignalDbm          int
+	WeakSignalSamples  int
+	WeakSignalTimeouts int
+
 	// Timing
 	StartTime        time.Time
 	LastSuccessTime  time.Time
 	LastTimeoutTime  time.Time
 	TimeSinceTimeout time.Duration // Time since last timeout (0 if never timed out)
 	UptimeSeconds    float64       // Seconds since monitoring started
+
+	// Messages the app discarded because a consumer fell behind, over the
+	// whole session including earlier targets (set by the app, not the engine)
+	DroppedUISamples int // Samples not delivered to the TUI
+	DroppedStats     int // Stats updates not delivered to the TUI
+
+	// Ping output lines that were neither a sample nor a known banner or
+	// statistics line, over the whole session (set by the app)
+	ParserMisses int
 }
 
-// Engine computes metrics from ping samples.
+// Engine computes metrics from ping samples. Add and Reset serialize on a
+// mutex, while Stats returns the last computed snapshot without taking it
+// as long as no sample arrived since, so readers neither wait for each
+// other nor for Add.
 type Engine struct {
 	mu sync.RWMutex
 
+	// Stats published for lock-free reads, valid while gen, which every
+	// change bumps, still matches the one they were computed at
+	gen  atomic.Uint64
+	snap atomic.Pointer[statsSnapshot]
+
 	totalSamples   int
 	totalTimeouts  int
+	errorCounts    [types.ErrorKindCount]int
 	minRTT         time.Duration
 	maxRTT         time.Duration
 	sumRTT         time.Duration
@@ -88,19 +152,113 @@ type Engine struct {
 	brownoutBursts  int  // Number of brownout events
 	inBrownout      bool // Currently in brownout
 
+	// Outage durations: when the current loss burst started, and the
+	// finished ones by OutageBuckets bound
+	outageStart   time.Time
+	outageCounts  [len(OutageBuckets) + 1]int
+	outageTotal   time.Duration
+	longestOutage time.Duration
+
+	// Clock jumps and the gaps in the timeline they left
+	clockJumps  int
+	skippedTime time.Duration
+
+	// Burst tracking: tallies of intervals whose probes are still arriving,
+	// by sequence number, and counts of finished ones
+	bursts            map[int]burstTally
+	burstIntervals    int
+	partialLossBursts int
+	fullLossBursts    int
+
+	// Wi-Fi signal tracking
+	lastSignal         int
+	weakSignalSamples  int
+	weakSignalTimeouts int
+
 	// Timing
 	startTime       time.Time
+	firstSampleTime time.Time // Timestamp of the first sample
 	lastSuccessTime time.Time
 	lastTimeoutTime time.Time
+
+	// Percentiles, which sorting makes the costly part of stats, recomputed
+	// at most once per minRecompute (see SetMinRecompute)
+	minRecompute time.Duration
+	pct          Percentiles
+	pctCount     int // Calculator values pct was computed from; -1 = none
+	pctAt        time.Time
+
+	// brownoutMs is the brownout threshold; 0 means BrownoutThresholdMs
+	brownoutMs float64
+}
+
+// statsSnapshot is stats published for lock-free reads, computed when the
+// engine's generation was gen.
+type statsSnapshot struct {
+	stats Stats
+	gen   uint64
+}
+
+// at returns the snapshot's stats with the times that only depend on the
+// clock brought up to now.
+func (s *statsSnapshot) at(now time.Time) Stats {
+	stats := s.stats
+	stats.UptimeSeconds = now.Sub(stats.StartTime).Seconds()
+	if !stats.LastTimeoutTime.IsZero() {
+		stats.TimeSinceTimeout = now.Sub(stats.LastTimeoutTime)
+	}
+	return stats
+}
+
+// invalidate marks published stats stale. Callers hold the write lock.
+func (e *Engine) invalidate() {
+	e.gen.Add(1)
+}
+
+// burstTally counts the probes of one interval sent with -burst.
+type burstTally struct {
+	seen, lost int
 }
 
+// maxPendingBursts bounds how many intervals may have probes outstanding;
+// older ones are dropped, as their remaining probes are not coming.
+const maxPendingBursts = 64
+
 // NewEngine creates a new metrics engine.
 func NewEngine() *Engine {
 	return &Engine{
 		minRTT:      time.Duration(math.MaxInt64),
 		percentiles: NewPercentileCalculator(),
 		startTime:   time.Now(),
+		pctCount:    -1,
+	}
+}
+
+// SetMinRecompute lets Stats reuse percentiles computed less than d ago
+// even though samples arrived since, so high sample rates do not sort all
+// RTTs for every sample. Everything else stays exact. 0 (the default)
+// recomputes them whenever samples arrived.
+func (e *Engine) SetMinRecompute(d time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.minRecompute = d
+}
+
+// SetBrownoutThreshold makes samples slower than ms count as brownout from
+// now on; 0 restores BrownoutThresholdMs. Samples already counted stay.
+func (e *Engine) SetBrownoutThreshold(ms float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.brownoutMs = ms
+}
+
+// brownoutThreshold returns the brownout threshold in milliseconds. The
+// caller must hold the lock.
+func (e *Engine) brownoutThreshold() float64 {
+	if e.brownoutMs > 0 {
+		return e.brownoutMs
 	}
+	return BrownoutThresholdMs
 }
 
 // Add processes a new ping sample.
@@ -108,16 +266,37 @@ func (e *Engine) Add(sample types.Sample) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	e.invalidate()
 	e.totalSamples++
+	if e.firstSampleTime.IsZero() {
+		e.firstSampleTime = sample.Timestamp
+	}
+	if sample.ClockJump {
+		e.skipGap(sample.Timestamp)
+	}
+	if sample.Burst > 1 {
+		e.addBurst(sample)
+	}
+	if sample.Signal != 0 {
+		e.lastSignal = sample.Signal
+		if sample.Signal <= WeakSignalDbm {
+			e.weakSignalSamples++
+			if sample.Timeout {
+				e.weakSignalTimeouts++
+			}
+		}
+	}
 
 	if sample.Timeout {
 		e.totalTimeouts++
+		e.errorCounts[sample.Kind()]++
 		e.lastTimeoutTime = sample.Timestamp
 
 		// Track loss bursts (new burst when transitioning from success to timeout)
 		if !e.inTimeoutBurst {
 			e.lossBursts++
 			e.inTimeoutBurst = true
+			e.outageStart = sample.Timestamp
 		}
 
 		// Exit brownout on timeout
@@ -137,12 +316,15 @@ func (e *Engine) Add(sample types.Sample) {
 
 	// Successful ping
 	e.lastSuccessTime = sample.Timestamp
+	if e.inTimeoutBurst {
+		e.endOutage(sample.Timestamp)
+	}
 	e.inTimeoutBurst = false // End timeout burst on success
 	rtt := sample.RTT
 
 	// Check for brownout (high latency)
 	rttMs := float64(rtt.Microseconds()) / 1000.0
-	if rttMs > BrownoutThresholdMs {
+	if rttMs > e.brownoutThreshold() {
 		e.brownoutSamples++
 		if !e.inBrownout {
 			e.brownoutBursts++
@@ -189,10 +371,124 @@ func (e *Engine) Add(sample types.Sample) {
 	e.percentiles.Add(rtt)
 }
 
-// Stats returns the current computed metrics.
+// meanTimes returns the MTBF and MTTR given the downtime so far. Sample
+// timestamps measure the session, so replays and demos at speed get the
+// times they simulate. Callers must hold mu.
+func (e *Engine) meanTimes(downtime time.Duration) (mtbf, mttr time.Duration) {
+	finished := 0
+	for _, n := range e.outageCounts {
+		finished += n
+	}
+	if finished > 0 {
+		mttr = e.outageTotal / time.Duration(finished)
+	}
+	if e.lossBursts > 0 && !e.firstSampleTime.IsZero() {
+		last := e.lastSuccessTime
+		if e.lastTimeoutTime.After(last) {
+			last = e.lastTimeoutTime
+		}
+		if up := last.Sub(e.firstSampleTime) - downtime - e.skippedTime; up > 0 {
+			mtbf = up / time.Duration(e.lossBursts)
+		}
+	}
+	return mtbf, mttr
+}
+
+// skipGap leaves the time since the last sample, which a clock jump at the
+// sample at now skipped, out of outages and MTBF: a loss burst in progress
+// ends at its last missed sample before the gap. Callers must hold mu.
+func (e *Engine) skipGap(now time.Time) {
+	e.clockJumps++
+	last := e.lastSuccessTime
+	if e.lastTimeoutTime.After(last) {
+		last = e.lastTimeoutTime
+	}
+	if !last.IsZero() && now.After(last) {
+		e.skippedTime += now.Sub(last)
+	}
+	if e.inTimeoutBurst {
+		e.endOutage(e.lastTimeoutTime)
+		e.inTimeoutBurst = false
+	}
+}
+
+// endOutage records the duration of the loss burst a reply at end ended.
+// Callers must hold mu.
+func (e *Engine) endOutage(end time.Time) {
+	d := end.Sub(e.outageStart)
+	if e.outageStart.IsZero() || d <= 0 {
+		// Started before a restored snapshot kept outage starts
+		return
+	}
+	i := 0
+	for i < len(OutageBuckets) && d > OutageBuckets[i] {
+		i++
+	}
+	e.outageCounts[i]++
+	e.outageTotal += d
+	e.longestOutage = max(e.longestOutage, d)
+}
+
+// addBurst counts a probe towards its interval, and the interval once all
+// of its probes have returned. Probes of overlapping intervals may arrive
+// interleaved, so intervals are told apart by sequence number.
+func (e *Engine) addBurst(sample types.Sample) {
+	if e.bursts == nil {
+		e.bursts = make(map[int]burstTally)
+	}
+	t := e.bursts[sample.Sequence]
+	t.seen++
+	if sample.Timeout {
+		t.lost++
+	}
+	if t.seen < sample.Burst {
+		e.bursts[sample.Sequence] = t
+		for seq := range e.bursts {
+			if seq <= sample.Sequence-maxPendingBursts {
+				delete(e.bursts, seq)
+			}
+		}
+		return
+	}
+
+	delete(e.bursts, sample.Sequence)
+	e.burstIntervals++
+	switch {
+	case t.lost == t.seen:
+		e.fullLossBursts++
+	case t.lost > 0:
+		e.partialLossBursts++
+	}
+}
+
+// Stats returns the current computed metrics. Percentiles may lag by the
+// samples of the last SetMinRecompute interval.
 func (e *Engine) Stats() Stats {
-	e.mu.RLock()
-	defer e.mu.RUnlock()
+	return e.stats(false)
+}
+
+// ExactStats returns the current metrics with up to date percentiles, such
+// as for a final summary.
+func (e *Engine) ExactStats() Stats {
+	return e.stats(true)
+}
+
+// stats returns the published snapshot if it is current, and computes the
+// metrics otherwise.
+func (e *Engine) stats(exact bool) Stats {
+	now := time.Now()
+	if s := e.snap.Load(); s != nil && s.gen == e.gen.Load() {
+		return s.at(now)
+	}
+
+	// Write lock: computing percentiles sorts the calculator in place
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// Another reader may have computed them while this one waited
+	if s := e.snap.Load(); s != nil && s.gen == e.gen.Load() {
+		return s.at(now)
+	}
 
 	successCount := e.totalSamples - e.totalTimeouts
 
@@ -200,6 +496,7 @@ func (e *Engine) Stats() Stats {
 		TotalSamples:    e.totalSamples,
 		TotalTimeouts:   e.totalTimeouts,
 		TotalSuccess:    successCount,
+		ErrorCounts:     e.errorCounts,
 		CurrentStreak:   e.currentStreak,
 		LongestSuccess:  e.longestSuccess,
 		LongestTimeout:  e.longestTimeout,
@@ -208,8 +505,28 @@ func (e *Engine) Stats() Stats {
 		BrownoutBursts:  e.brownoutBursts,
 		InBrownout:      e.inBrownout,
 		StartTime:       e.startTime,
-		UptimeSeconds:   time.Since(e.startTime).Seconds(),
+		UptimeSeconds:   now.Sub(e.startTime).Seconds(),
+
+		OutageCounts:  e.outageCounts,
+		OutageTotal:   e.outageTotal,
+		LongestOutage: e.longestOutage,
+		Downtime:      e.outageTotal,
+		ClockJumps:    e.clockJumps,
+		SkippedTime:   e.skippedTime,
+
+		BurstIntervals:       e.burstIntervals,
+		PartialLossIntervals: e.partialLossBursts,
+		FullLossIntervals:    e.fullLossBursts,
+
+		SignalDbm:          e.lastSignal,
+		WeakSignalSamples:  e.weakSignalSamples,
+		WeakSignalTimeouts: e.weakSignalTimeouts,
+	}
+
+	if e.inTimeoutBurst && !e.outageStart.IsZero() {
+		stats.Downtime += e.lastTimeoutTime.Sub(e.outageStart)
 	}
+	stats.MTBF, stats.MTTR = e.meanTimes(stats.Downtime)
 
 	if e.totalSamples > 0 {
 		stats.LossPercent = float64(e.totalTimeouts) / float64(e.totalSamples) * 100
@@ -221,7 +538,12 @@ func (e *Engine) Stats() Stats {
 		stats.MaxRTT = e.maxRTT
 		stats.AvgRTT = e.sumRTT / time.Duration(successCount)
 		stats.LastRTT = e.lastRTT
-		stats.Percentiles = e.percentiles.GetPercentiles()
+		if e.pctCount != e.percentiles.Count() && (exact || e.pctCount < 0 || now.Sub(e.pctAt) >= e.minRecompute) {
+			e.pct = e.percentiles.GetPercentiles()
+			e.pctCount = e.percentiles.Count()
+			e.pctAt = now
+		}
+		stats.Percentiles = e.pct
 
 		// Calculate variance and standard deviation
 		// Variance = E[X²] - (E[X])²
@@ -252,9 +574,13 @@ func (e *Engine) Stats() Stats {
 
 	if !e.lastTimeoutTime.IsZero() {
 		stats.LastTimeoutTime = e.lastTimeoutTime
-		stats.TimeSinceTimeout = time.Since(e.lastTimeoutTime)
+		stats.TimeSinceTimeout = now.Sub(e.lastTimeoutTime)
 	}
 
+	// Only publish what later calls may return as is
+	if successCount == 0 || e.pctCount == e.percentiles.Count() {
+		e.snap.Store(&statsSnapshot{stats: stats, gen: e.gen.Load()})
+	}
 	return stats
 }
 
@@ -265,6 +591,7 @@ func (e *Engine) Reset() {
 
 	e.totalSamples = 0
 	e.totalTimeouts = 0
+	e.errorCounts = [types.ErrorKindCount]int{}
 	e.minRTT = time.Duration(math.MaxInt64)
 	e.maxRTT = 0
 	e.sumRTT = 0
@@ -280,8 +607,24 @@ func (e *Engine) Reset() {
 	e.brownoutSamples = 0
 	e.brownoutBursts = 0
 	e.inBrownout = false
+	e.outageStart = time.Time{}
+	e.outageCounts = [len(OutageBuckets) + 1]int{}
+	e.outageTotal = 0
+	e.longestOutage = 0
+	e.clockJumps = 0
+	e.skippedTime = 0
+	e.bursts = nil
+	e.burstIntervals = 0
+	e.partialLossBursts = 0
+	e.fullLossBursts = 0
+	e.lastSignal = 0
+	e.weakSignalSamples = 0
+	e.weakSignalTimeouts = 0
 	e.percentiles.Reset()
+	e.invalidate()
+	e.pctCount = -1
 	e.startTime = time.Now()
+	e.firstSampleTime = time.Time{}
 	e.lastSuccessTime = time.Time{}
 	e.lastTimeoutTime = time.Time{}
 }
@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"sync"
 	"testing"
 	"time"
 
@@ -38,13 +39,26 @@ func TestEngine_Basic(t *testing.T) {
 	}
 }
 
+func TestEngine_BrownoutThreshold(t *testing.T) {
+	e := NewEngine()
+	e.Add(types.Sample{RTT: 150 * time.Millisecond})
+	e.SetBrownoutThreshold(100)
+	e.Add(types.Sample{RTT: 150 * time.Millisecond})
+	e.SetBrownoutThreshold(0)
+	e.Add(types.Sample{RTT: 150 * time.Millisecond})
+
+	if stats := e.Stats(); stats.BrownoutSamples != 1 {
+		t.Errorf("BrownoutSamples = %d, want 1", stats.BrownoutSamples)
+	}
+}
+
 func TestEngine_Timeouts(t *testing.T) {
 	e := NewEngine()
 
 	e.Add(types.Sample{RTT: 10 * time.Millisecond})
 	e.Add(types.Sample{Timeout: true})
 	e.Add(types.Sample{RTT: 20 * time.Millisecond})
-	e.Add(types.Sample{Timeout: true})
+	e.Add(types.Sample{Timeout: true, ErrorKind: types.ErrorNetUnreachable})
 
 	stats := e.Stats()
 
@@ -59,6 +73,128 @@ func TestEngine_Timeouts(t *testing.T) {
 	if stats.LossPercent != 50 {
 		t.Errorf("LossPercent = %f, want 50", stats.LossPercent)
 	}
+
+	if stats.ErrorCounts[types.ErrorTimeout] != 1 || stats.ErrorCounts[types.ErrorNetUnreachable] != 1 {
+		t.Errorf("ErrorCounts = %v, want 1 timeout and 1 net unreachable", stats.ErrorCounts)
+	}
+}
+
+func TestEngine_Bursts(t *testing.T) {
+	e := NewEngine()
+
+	reply := func(seq int) types.Sample {
+		return types.Sample{Sequence: seq, Burst: 3, RTT: 10 * time.Millisecond}
+	}
+	lost := func(seq int) types.Sample {
+		return types.Sample{Sequence: seq, Burst: 3, Timeout: true}
+	}
+	// Interval 2 overlaps interval 1, whose lost probe arrives last
+	for _, s := range []types.Sample{
+		reply(1), reply(1), reply(2), reply(2), reply(2), lost(1),
+		lost(3), lost(3), lost(3),
+		reply(4), reply(4), // Still in flight
+	} {
+		e.Add(s)
+	}
+
+	stats := e.Stats()
+	if stats.BurstIntervals != 3 || stats.PartialLossIntervals != 1 || stats.FullLossIntervals != 1 {
+		t.Fatalf("burst intervals = %d (%d partial, %d full loss), want 3 (1, 1)",
+			stats.BurstIntervals, stats.PartialLossIntervals, stats.FullLossIntervals)
+	}
+	if stats.TotalSamples != 11 || stats.TotalTimeouts != 4 {
+		t.Fatalf("samples = %d with %d timeouts, want every probe counted", stats.TotalSamples, stats.TotalTimeouts)
+	}
+}
+
+func TestEngine_Outages(t *testing.T) {
+	e := NewEngine()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// A 2s outage, a 40s one, and one still going since 90s
+	lost := map[int]bool{1: true, 2: true, 10: true, 11: true, 50: true, 90: true, 91: true, 92: true}
+	for _, sec := range []int{0, 1, 2, 3, 10, 11, 50, 51, 90, 91, 92} {
+		ts := start.Add(time.Duration(sec) * time.Second)
+		if lost[sec] {
+			e.Add(types.Sample{Timestamp: ts, Timeout: true})
+		} else {
+			e.Add(types.Sample{Timestamp: ts, RTT: 10 * time.Millisecond})
+		}
+	}
+
+	stats := e.Stats()
+	var want [len(OutageBuckets) + 1]int
+	want[1] = 1 // 2s
+	want[5] = 1 // 41s
+	if stats.OutageCounts != want {
+		t.Fatalf("OutageCounts = %v, want %v", stats.OutageCounts, want)
+	}
+	if stats.OutageTotal != 43*time.Second || stats.LongestOutage != 41*time.Second {
+		t.Fatalf("outage total = %v, longest = %v, want 43s and 41s", stats.OutageTotal, stats.LongestOutage)
+	}
+	// The current outage counts up to its latest missed sample
+	if stats.Downtime != 45*time.Second {
+		t.Fatalf("Downtime = %v, want 45s", stats.Downtime)
+	}
+	// 47s without loss over 3 outages; 43s to recover from 2
+	if stats.MTBF != 47*time.Second/3 || stats.MTTR != 43*time.Second/2 {
+		t.Fatalf("MTBF = %v, MTTR = %v, want %v and %v", stats.MTBF, stats.MTTR, 47*time.Second/3, 43*time.Second/2)
+	}
+
+	e.Reset()
+	if stats := e.Stats(); stats.OutageTotal != 0 || stats.Downtime != 0 || stats.OutageCounts != [len(OutageBuckets) + 1]int{} {
+		t.Fatalf("after Reset outages = %v, downtime %v, want none", stats.OutageCounts, stats.Downtime)
+	}
+}
+
+func TestEngine_ClockJump(t *testing.T) {
+	e := NewEngine()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Loss going into a suspend, and after an hour asleep
+	for _, s := range []types.Sample{
+		{Timestamp: start, RTT: 10 * time.Millisecond},
+		{Timestamp: start.Add(time.Second), Timeout: true},
+		{Timestamp: start.Add(2 * time.Second), Timeout: true},
+		{Timestamp: start.Add(3600 * time.Second), Timeout: true, ClockJump: true},
+		{Timestamp: start.Add(3601 * time.Second), RTT: 10 * time.Millisecond},
+	} {
+		e.Add(s)
+	}
+
+	stats := e.Stats()
+	if stats.ClockJumps != 1 || stats.SkippedTime != 3598*time.Second {
+		t.Fatalf("clock jumps = %d, skipped %v, want 1 and 3598s", stats.ClockJumps, stats.SkippedTime)
+	}
+	// The gap splits the loss into two 1s outages rather than an hour-long one
+	if stats.LossBursts != 2 || stats.OutageTotal != 2*time.Second || stats.LongestOutage != time.Second {
+		t.Fatalf("outages = %d totaling %v, longest %v, want 2 totaling 2s, longest 1s",
+			stats.LossBursts, stats.OutageTotal, stats.LongestOutage)
+	}
+	if stats.MTBF != 500*time.Millisecond {
+		t.Fatalf("MTBF = %v, want 500ms", stats.MTBF)
+	}
+}
+
+func TestEngine_WeakSignal(t *testing.T) {
+	e := NewEngine()
+	for _, s := range []types.Sample{
+		{RTT: 10 * time.Millisecond}, // Not recorded
+		{RTT: 10 * time.Millisecond, Signal: -55},
+		{Timeout: true, Signal: WeakSignalDbm},
+		{RTT: 30 * time.Millisecond, Signal: -78},
+		{RTT: 12 * time.Millisecond, Signal: -60},
+	} {
+		e.Add(s)
+	}
+
+	stats := e.Stats()
+	if stats.SignalDbm != -60 || stats.WeakSignalSamples != 2 || stats.WeakSignalTimeouts != 1 {
+		t.Fatalf("signal = %ddBm, weak = %d with %d lost, want -60dBm, 2 with 1 lost",
+			stats.SignalDbm, stats.WeakSignalSamples, stats.WeakSignalTimeouts)
+	}
+	e.Reset()
+	if stats := e.Stats(); stats.SignalDbm != 0 || stats.WeakSignalSamples != 0 {
+		t.Fatalf("after Reset signal = %ddBm, weak = %d, want none", stats.SignalDbm, stats.WeakSignalSamples)
+	}
 }
 
 func TestEngine_Streaks(t *testing.T) {
@@ -175,3 +311,81 @@ func TestEngine_Reset(t *testing.T) {
 		t.Errorf("TotalSamples after reset = %d, want 0", stats.TotalSamples)
 	}
 }
+
+func TestEngine_MinRecompute(t *testing.T) {
+	e := NewEngine()
+	e.SetMinRecompute(time.Hour)
+	e.Add(types.Sample{RTT: 10 * time.Millisecond})
+	if p := e.Stats().Percentiles.P50; p != 10 {
+		t.Fatalf("first P50 = %v, want 10", p)
+	}
+
+	// Counters stay exact; percentiles wait for the interval
+	e.Add(types.Sample{RTT: 30 * time.Millisecond})
+	stats := e.Stats()
+	if stats.TotalSamples != 2 || stats.MaxRTTMs != 30 {
+		t.Fatalf("stats = %d samples, max %vms, want 2 and 30", stats.TotalSamples, stats.MaxRTTMs)
+	}
+	if stats.Percentiles.P50 != 10 {
+		t.Fatalf("rate-limited P50 = %v, want the cached 10", stats.Percentiles.P50)
+	}
+	if p := e.ExactStats().Percentiles.P50; p != 20 {
+		t.Fatalf("exact P50 = %v, want 20", p)
+	}
+	if p := e.Stats().Percentiles.P50; p != 20 {
+		t.Fatalf("P50 after ExactStats = %v, want 20", p)
+	}
+
+	// A reset never shows percentiles of the previous samples
+	e.Reset()
+	e.Add(types.Sample{RTT: 50 * time.Millisecond})
+	if p := e.Stats().Percentiles.P50; p != 50 {
+		t.Fatalf("P50 after Reset = %v, want 50", p)
+	}
+}
+
+func TestEngine_StatsCached(t *testing.T) {
+	e := NewEngine()
+	e.Add(types.Sample{Timestamp: time.Now(), Timeout: true})
+	first := e.Stats()
+	time.Sleep(time.Millisecond)
+	second := e.Stats()
+	if second.TotalSamples != 1 || second.UptimeSeconds <= first.UptimeSeconds {
+		t.Fatalf("cached stats: %d samples, uptime %v then %v; want 1 and a growing uptime",
+			second.TotalSamples, first.UptimeSeconds, second.UptimeSeconds)
+	}
+	e.Add(types.Sample{RTT: time.Millisecond})
+	if n := e.Stats().TotalSamples; n != 2 {
+		t.Fatalf("TotalSamples after Add = %d, want 2", n)
+	}
+}
+
+func TestEngine_ConcurrentStats(t *testing.T) {
+	e := NewEngine()
+	const n = 2000
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			prev := 0
+			for prev < n {
+				stats := e.Stats()
+				// Published snapshots never go back in time
+				if stats.TotalSamples < prev || stats.TotalSuccess+stats.TotalTimeouts != stats.TotalSamples {
+					t.Errorf("Stats() = %d samples (%d ok, %d lost) after %d",
+						stats.TotalSamples, stats.TotalSuccess, stats.TotalTimeouts, prev)
+					return
+				}
+				prev = stats.TotalSamples
+			}
+		})
+	}
+	for seq := range n {
+		e.Add(types.Sample{Sequence: seq, RTT: time.Millisecond, Timeout: seq%10 == 0})
+	}
+	wg.Wait()
+
+	if stats := e.Stats(); stats.TotalSamples != n || stats.TotalTimeouts != n/10 {
+		t.Fatalf("Stats() = %d samples, %d timeouts, want %d and %d", stats.TotalSamples, stats.TotalTimeouts, n, n/10)
+	}
+}
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+// MinGap and GapIntervals decide when the time between two samples is a gap
+// in the session, such as the machine sleeping, rather than slow probing: it
+// must be longer than both MinGap and GapIntervals probe intervals.
+const (
+	MinGap       = time.Minute
+	GapIntervals = 10
+)
+
+// IsGap reports whether next follows prev after a gap in the session: a
+// clock jump, or more time than MinGap and GapIntervals intervals. The
+// interval is the one next recorded, or interval when it recorded none.
+func IsGap(prev, next types.Sample, interval time.Duration) bool {
+	if next.ClockJump {
+		return true
+	}
+	if next.Interval > 0 {
+		interval = next.Interval
+	}
+	return next.Timestamp.Sub(prev.Timestamp) > max(MinGap, GapIntervals*interval)
+}
+
+// Segment is a stretch of a session between gaps.
+type Segment struct {
+	Start time.Time
+	End   time.Time
+	Stats Stats
+}
+
+// Segments splits samples, oldest first, at gaps in the session and returns
+// the statistics of each stretch.
+func Segments(samples []types.Sample, interval time.Duration) []Segment {
+	var segments []Segment
+	var engine *Engine
+	flush := func(end time.Time) {
+		segments[len(segments)-1].End = end
+		segments[len(segments)-1].Stats = engine.Stats()
+	}
+	for i, s := range samples {
+		if i == 0 || IsGap(samples[i-1], s, interval) {
+			if i > 0 {
+				flush(samples[i-1].Timestamp)
+			}
+			segments = append(segments, Segment{Start: s.Timestamp})
+			engine = NewEngine()
+		}
+		// A segment starts fresh, so its first sample skips nothing
+		s.ClockJump = false
+		engine.Add(s)
+	}
+	if len(samples) > 0 {
+		flush(samples[len(samples)-1].Timestamp)
+	}
+	return segments
+}
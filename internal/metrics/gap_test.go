@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+func TestIsGap(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	prev := types.Sample{Timestamp: start}
+	at := func(d time.Duration) types.Sample { return types.Sample{Timestamp: start.Add(d)} }
+
+	tests := []struct {
+		name     string
+		next     types.Sample
+		interval time.Duration
+		want     bool
+	}{
+		{name: "next probe", next: at(time.Second), interval: time.Second},
+		{name: "under a minute", next: at(50 * time.Second), interval: time.Second},
+		{name: "suspend", next: at(10 * time.Minute), interval: time.Second, want: true},
+		{name: "slow probing", next: at(2 * time.Minute), interval: time.Minute},
+		{name: "ten slow intervals", next: at(11 * time.Minute), interval: time.Minute, want: true},
+		{name: "recorded interval", next: types.Sample{Timestamp: start.Add(2 * time.Minute), Interval: time.Minute}, interval: time.Second},
+		{name: "clock jump", next: types.Sample{Timestamp: start.Add(time.Second), ClockJump: true}, interval: time.Second, want: true},
+	}
+	for _, tt := range tests {
+		if got := IsGap(prev, tt.next, tt.interval); got != tt.want {
+			t.Fatalf("%s: IsGap() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSegments(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var samples []types.Sample
+	for _, sec := range []int{0, 1, 2, 3600, 3601} {
+		samples = append(samples, types.Sample{
+			Timestamp: start.Add(time.Duration(sec) * time.Second),
+			RTT:       10 * time.Millisecond,
+			Timeout:   sec == 3601,
+		})
+	}
+
+	segments := Segments(samples, time.Second)
+	if len(segments) != 2 {
+		t.Fatalf("got %d segments, want 2", len(segments))
+	}
+	first, second := segments[0], segments[1]
+	if !first.Start.Equal(start) || !first.End.Equal(start.Add(2*time.Second)) || first.Stats.TotalSamples != 3 {
+		t.Fatalf("first segment = %v-%v with %d samples", first.Start, first.End, first.Stats.TotalSamples)
+	}
+	if !second.Start.Equal(start.Add(time.Hour)) || second.Stats.TotalSamples != 2 || second.Stats.TotalTimeouts != 1 {
+		t.Fatalf("second segment = %v with %d samples, %d lost", second.Start, second.Stats.TotalSamples, second.Stats.TotalTimeouts)
+	}
+	if got := Segments(nil, time.Second); len(got) != 0 {
+		t.Fatalf("Segments(nil) = %v, want none", got)
+	}
+}
@@ -0,0 +1,37 @@
+package metrics
+
+import "time"
+
+// Health describes pingheat itself rather than the network, to tell a slow
+// or overloaded pipeline apart from a slow target.
+type Health struct {
+	Goroutines int
+	HeapBytes  uint64 // Heap memory occupied by objects
+
+	// Time the distributor spent on each sample, from receiving it to
+	// handing it to every consumer
+	ProcessedSamples uint64
+	ProcessingTime   time.Duration // Total over ProcessedSamples
+	ProcessingMax    time.Duration // Slowest single sample
+
+	Channels []ChannelDepth
+
+	// Messages the TUI skipped because it fell behind
+	DroppedUISamples int
+	DroppedStats     int
+}
+
+// ChannelDepth is how full one of the pipeline's buffered channels is.
+type ChannelDepth struct {
+	Name string
+	Len  int
+	Cap  int
+}
+
+// ProcessingAvg returns the mean time spent distributing a sample.
+func (h Health) ProcessingAvg() time.Duration {
+	if h.ProcessedSamples == 0 {
+		return 0
+	}
+	return h.ProcessingTime / time.Duration(h.ProcessedSamples)
+}
@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+// HourOfDay summarizes every sample taken during one hour of the day, across
+// all days of a session, to show recurring problems such as a congested
+// evening.
+type HourOfDay struct {
+	Hour        int // 0-23 in the tracker's time zone
+	Samples     int
+	Timeouts    int
+	LossPercent float64
+	AvgMs       float64
+	P95Ms       float64 // Estimated from a log-scale histogram
+
+	// Replies per latency bucket: Buckets[i] counts RTTs up to bounds[i]
+	// and above the previous bound, the last entry RTTs above every bound.
+	// Estimated from the histogram, so they sum to Samples - Timeouts.
+	Buckets []int
+}
+
+// HourOfDayTracker accumulates samples by hour of the day in a time zone,
+// in fixed memory however long the session runs.
+type HourOfDayTracker struct {
+	mu    sync.RWMutex
+	loc   *time.Location
+	hours [24]periodSlot
+}
+
+// NewHourOfDayTracker creates a tracker whose hours follow loc.
+func NewHourOfDayTracker(loc *time.Location) *HourOfDayTracker {
+	if loc == nil {
+		loc = time.Local
+	}
+	return &HourOfDayTracker{loc: loc}
+}
+
+// Add records a sample in its hour of the day. Samples taken during
+// maintenance do not count, as in the SLA table.
+func (t *HourOfDayTracker) Add(sample types.Sample) {
+	if sample.Maintenance {
+		return
+	}
+	hour := sample.Timestamp.In(t.loc).Hour()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.hours[hour].add(sample)
+}
+
+// Reset discards all samples.
+func (t *HourOfDayTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.hours = [24]periodSlot{}
+}
+
+// Hours returns the 24 hours of the day, midnight first, with replies
+// counted into latency buckets split at bounds (ascending, in milliseconds).
+func (t *HourOfDayTracker) Hours(bounds []float64) []HourOfDay {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	hours := make([]HourOfDay, len(t.hours))
+	for i := range t.hours {
+		s := &t.hours[i]
+		b := s.bucket(time.Time{}, time.Hour)
+		hours[i] = HourOfDay{
+			Hour:        i,
+			Samples:     b.Samples,
+			Timeouts:    b.Timeouts,
+			LossPercent: b.LossPercent,
+			AvgMs:       b.AvgMs,
+			P95Ms:       b.P95Ms,
+			Buckets:     s.split(bounds),
+		}
+	}
+	return hours
+}
+
+// split counts successful RTTs into the buckets delimited by bounds.
+func (s *periodSlot) split(bounds []float64) []int {
+	buckets := make([]int, len(bounds)+1)
+	prev := 0
+	for i, bound := range bounds {
+		n := int(math.Round(s.atMost(bound)))
+		buckets[i] = max(0, n-prev)
+		prev = max(prev, n)
+	}
+	buckets[len(bounds)] = s.samples - s.timeouts - prev
+	return buckets
+}
+
+// atMost estimates how many successful RTTs were at most ms, interpolating
+// within the histogram bin that holds ms.
+func (s *periodSlot) atMost(ms float64) float64 {
+	success := s.samples - s.timeouts
+	switch {
+	case success == 0 || ms < s.minMs:
+		return 0
+	case ms >= s.maxMs:
+		return float64(success)
+	}
+
+	bin := histogramBin(ms)
+	var below float64
+	for _, c := range s.hist[:bin] {
+		below += float64(c)
+	}
+	lower := 0.0
+	if bin > 0 {
+		lower = histogramUpper(bin - 1)
+	}
+	upper := histogramUpper(bin)
+	// The observed range narrows the bin, and is the only bound of the open one
+	lower = math.Max(lower, s.minMs)
+	upper = math.Min(upper, s.maxMs)
+	if upper <= lower {
+		return below + float64(s.hist[bin])
+	}
+	return below + float64(s.hist[bin])*(ms-lower)/(upper-lower)
+}
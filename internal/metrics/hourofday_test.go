@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+func TestHourOfDayTracker(t *testing.T) {
+	tracker := NewHourOfDayTracker(time.UTC)
+	// Two days: evenings are slow and lossy, mornings fast
+	for day := range 2 {
+		evening := time.Date(2024, 1, 1+day, 19, 0, 0, 0, time.UTC)
+		morning := time.Date(2024, 1, 1+day, 8, 0, 0, 0, time.UTC)
+		for i := range 100 {
+			tracker.Add(types.Sample{Timestamp: morning.Add(time.Duration(i) * time.Second), RTT: 10 * time.Millisecond})
+			s := types.Sample{Timestamp: evening.Add(time.Duration(i) * time.Second), RTT: 250 * time.Millisecond}
+			if i%10 == 0 {
+				s.RTT, s.Timeout = 0, true
+			}
+			tracker.Add(s)
+		}
+	}
+	tracker.Add(types.Sample{Timestamp: time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC), Timeout: true, Maintenance: true})
+
+	hours := tracker.Hours([]float64{30, 80, 150, 300})
+	if len(hours) != 24 {
+		t.Fatalf("len(Hours) = %d, want 24", len(hours))
+	}
+	if h := hours[3]; h.Samples != 0 {
+		t.Fatalf("maintenance sample counted: %+v", h)
+	}
+
+	morning := hours[8]
+	if morning.Samples != 200 || morning.Timeouts != 0 || morning.Buckets[0] != 200 {
+		t.Fatalf("08:00 = %+v, want 200 replies in the first band", morning)
+	}
+	evening := hours[19]
+	if evening.Samples != 200 || evening.LossPercent != 10 || evening.Buckets[3] != 180 {
+		t.Fatalf("19:00 = %+v, want 10%% loss and 180 replies in the fourth band", evening)
+	}
+
+	tracker.Reset()
+	if h := tracker.Hours(nil)[8]; h.Samples != 0 || len(h.Buckets) != 1 {
+		t.Fatalf("after Reset 08:00 = %+v", h)
+	}
+}
+
+func TestPeriodSlotSplit(t *testing.T) {
+	var s periodSlot
+	for ms := 1; ms <= 100; ms++ {
+		s.add(types.Sample{RTT: time.Duration(ms) * time.Millisecond})
+	}
+
+	got := s.split([]float64{25, 50, 75})
+	want := []int{25, 25, 25, 25}
+	for i := range want {
+		// Interpolating within a histogram bin is approximate
+		if d := got[i] - want[i]; d < -2 || d > 2 {
+			t.Fatalf("split = %v, want about %v", got, want)
+		}
+	}
+	if sum := got[0] + got[1] + got[2] + got[3]; sum != 100 {
+		t.Fatalf("split = %v sums to %d, want 100", got, sum)
+	}
+}
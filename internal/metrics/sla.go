@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+// Number of SLA periods kept.
+const (
+	SLAHours = 48
+	SLADays  = 31
+)
+
+// SLAPeriod is the availability and latency of one hour or calendar day.
+type SLAPeriod struct {
+	Start        time.Time
+	Samples      int
+	Timeouts     int
+	AvailPercent float64
+	P95Ms        float64 // Estimated from a log-scale histogram
+}
+
+// SLATracker computes availability and p95 per hour and per calendar day,
+// aligned to the wall clock of a time zone, for checking uptime commitments.
+type SLATracker struct {
+	mu    sync.RWMutex
+	loc   *time.Location
+	hours []periodSlot // Oldest first, at most SLAHours
+	days  []periodSlot // Oldest first, at most SLADays
+}
+
+// NewSLATracker creates a tracker whose hours and days follow loc.
+func NewSLATracker(loc *time.Location) *SLATracker {
+	if loc == nil {
+		loc = time.Local
+	}
+	return &SLATracker{loc: loc}
+}
+
+// Add records a sample in its hour and day. Samples taken during
+// maintenance do not count.
+func (t *SLATracker) Add(sample types.Sample) {
+	if sample.Maintenance {
+		return
+	}
+	ts := sample.Timestamp.In(t.loc)
+	hour := time.Date(ts.Year(), ts.Month(), ts.Day(), ts.Hour(), 0, 0, 0, t.loc)
+	day := time.Date(ts.Year(), ts.Month(), ts.Day(), 0, 0, 0, 0, t.loc)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.hours = addToPeriod(t.hours, hour, sample, SLAHours)
+	t.days = addToPeriod(t.days, day, sample, SLADays)
+}
+
+// addToPeriod records a sample in the period starting at start, appending a
+// new period when needed and keeping at most limit periods.
+func addToPeriod(periods []periodSlot, start time.Time, sample types.Sample, limit int) []periodSlot {
+	// Samples normally land in the newest period; search back for late ones
+	for i := len(periods) - 1; i >= 0; i-- {
+		if periods[i].start.Equal(start) {
+			periods[i].add(sample)
+			return periods
+		}
+		if periods[i].start.Before(start) {
+			break
+		}
+	}
+	if len(periods) > 0 && start.Before(periods[len(periods)-1].start) {
+		// Older than the newest period and not tracked: too late to count
+		return periods
+	}
+
+	periods = append(periods, periodSlot{start: start})
+	periods[len(periods)-1].add(sample)
+	if len(periods) > limit {
+		periods = append(periods[:0], periods[len(periods)-limit:]...)
+	}
+	return periods
+}
+
+// Hours returns the tracked hours, oldest first.
+func (t *SLATracker) Hours() []SLAPeriod {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return slaPeriods(t.hours)
+}
+
+// Days returns the tracked calendar days, oldest first.
+func (t *SLATracker) Days() []SLAPeriod {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return slaPeriods(t.days)
+}
+
+// Reset discards all periods.
+func (t *SLATracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.hours = nil
+	t.days = nil
+}
+
+// slaPeriods converts accumulated periods to SLAPeriods.
+func slaPeriods(slots []periodSlot) []SLAPeriod {
+	periods := make([]SLAPeriod, len(slots))
+	for i := range slots {
+		b := slots[i].bucket(slots[i].start, 0)
+		periods[i] = SLAPeriod{
+			Start:        b.Start,
+			Samples:      b.Samples,
+			Timeouts:     b.Timeouts,
+			AvailPercent: 100 - b.LossPercent,
+			P95Ms:        b.P95Ms,
+		}
+	}
+	return periods
+}
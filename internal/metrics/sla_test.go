@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+func TestSLATracker_HoursAndDays(t *testing.T) {
+	loc := time.FixedZone("UTC+5:30", 5*3600+1800)
+	tr := NewSLATracker(loc)
+
+	// 23:10 to 00:50 local time: two hours, two calendar days
+	base := time.Date(2024, 1, 2, 23, 10, 0, 0, loc)
+	for i := range 100 {
+		tr.Add(types.Sample{
+			Timestamp: base.Add(time.Duration(i) * time.Minute),
+			RTT:       10 * time.Millisecond,
+			Timeout:   i%10 == 0,
+		})
+	}
+
+	hours := tr.Hours()
+	if len(hours) != 2 {
+		t.Fatalf("len(hours)=%d, want 2", len(hours))
+	}
+	if !hours[1].Start.Equal(time.Date(2024, 1, 3, 0, 0, 0, 0, loc)) {
+		t.Fatalf("second hour starts at %v, want local midnight", hours[1].Start)
+	}
+	if hours[0].Samples != 50 || hours[0].AvailPercent != 90 || hours[0].P95Ms != 10 {
+		t.Fatalf("first hour = %+v", hours[0])
+	}
+
+	days := tr.Days()
+	if len(days) != 2 || days[0].Samples != 50 || days[1].Samples != 50 {
+		t.Fatalf("days = %+v, want 50 samples on each side of midnight", days)
+	}
+
+	// Late sample for the previous hour still counts; one before it does not
+	tr.Add(types.Sample{Timestamp: base.Add(time.Minute), Timeout: true})
+	tr.Add(types.Sample{Timestamp: base.Add(-time.Hour)})
+	if hours = tr.Hours(); len(hours) != 2 || hours[0].Timeouts != 6 {
+		t.Fatalf("after late samples hours = %+v", hours)
+	}
+
+	tr.Reset()
+	if len(tr.Hours()) != 0 || len(tr.Days()) != 0 {
+		t.Fatalf("expected no periods after Reset")
+	}
+}
+
+func TestSLATracker_KeepsLimit(t *testing.T) {
+	tr := NewSLATracker(time.UTC)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for h := range SLAHours + 5 {
+		tr.Add(types.Sample{Timestamp: base.Add(time.Duration(h) * time.Hour), RTT: time.Millisecond})
+	}
+
+	hours := tr.Hours()
+	if len(hours) != SLAHours {
+		t.Fatalf("len(hours)=%d, want %d", len(hours), SLAHours)
+	}
+	if !hours[0].Start.Equal(base.Add(5 * time.Hour)) {
+		t.Fatalf("oldest hour = %v, want the 6th", hours[0].Start)
+	}
+}
+
+func TestSLATracker_SkipsMaintenance(t *testing.T) {
+	tr := NewSLATracker(time.UTC)
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	tr.Add(types.Sample{Timestamp: base, RTT: time.Millisecond})
+	tr.Add(types.Sample{Timestamp: base.Add(time.Second), Timeout: true, Maintenance: true})
+
+	hours := tr.Hours()
+	if len(hours) != 1 || hours[0].Samples != 1 || hours[0].AvailPercent != 100 {
+		t.Fatalf("hours = %+v, want the maintenance timeout excluded", hours)
+	}
+}
@@ -0,0 +1,199 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+// snapshotVersion is bumped whenever EngineSnapshot changes incompatibly.
+const snapshotVersion = 1
+
+// ErrSnapshotVersion is returned when restoring a snapshot written by an
+// incompatible version.
+var ErrSnapshotVersion = errors.New("unsupported engine snapshot version")
+
+// EngineSnapshot is the complete state of an Engine. It encodes to JSON, so
+// it can be written to disk and restored after a restart.
+type EngineSnapshot struct {
+	Version int `json:"version"`
+
+	TotalSamples   int            `json:"total_samples"`
+	TotalTimeouts  int            `json:"total_timeouts"`
+	ErrorCounts    map[string]int `json:"error_counts,omitempty"` // By types.ErrorKind name
+	MinRTT         time.Duration  `json:"min_rtt_ns"`             // Zero when there were no successes
+	MaxRTT         time.Duration  `json:"max_rtt_ns"`
+	SumRTT         time.Duration  `json:"sum_rtt_ns"`
+	SumRTTSquares  float64        `json:"sum_rtt_squares_us2"`
+	LastRTT        time.Duration  `json:"last_rtt_ns"`
+	SumJitter      time.Duration  `json:"sum_jitter_ns"`
+	JitterCount    int            `json:"jitter_count"`
+	CurrentStreak  int            `json:"current_streak"`
+	LongestSuccess int            `json:"longest_success"`
+	LongestTimeout int            `json:"longest_timeout"`
+	RTTValuesMs    []float64      `json:"rtt_values_ms"` // Percentile state
+
+	LossBursts      int  `json:"loss_bursts"`
+	InTimeoutBurst  bool `json:"in_timeout_burst"`
+	BrownoutSamples int  `json:"brownout_samples"`
+	BrownoutBursts  int  `json:"brownout_bursts"`
+	InBrownout      bool `json:"in_brownout"`
+
+	// Outage durations; OutageCounts has an entry per OutageBuckets bound
+	// and one for longer outages
+	OutageStart   time.Time     `json:"outage_start"`
+	OutageCounts  []int         `json:"outage_counts,omitempty"`
+	OutageTotal   time.Duration `json:"outage_total_ns,omitempty"`
+	LongestOutage time.Duration `json:"longest_outage_ns,omitempty"`
+
+	// Clock jumps and the time they skipped
+	ClockJumps  int           `json:"clock_jumps,omitempty"`
+	SkippedTime time.Duration `json:"skipped_time_ns,omitempty"`
+
+	// Finished -burst intervals; those still in flight are not kept
+	BurstIntervals       int `json:"burst_intervals,omitempty"`
+	PartialLossIntervals int `json:"partial_loss_intervals,omitempty"`
+	FullLossIntervals    int `json:"full_loss_intervals,omitempty"`
+
+	// Wi-Fi signal with -wifi
+	SignalDbm          int `json:"signal_dbm,omitempty"`
+	WeakSignalSamples  int `json:"weak_signal_samples,omitempty"`
+	WeakSignalTimeouts int `json:"weak_signal_timeouts,omitempty"`
+
+	StartTime       time.Time `json:"start_time"`
+	FirstSampleTime time.Time `json:"first_sample_time"`
+	LastSuccessTime time.Time `json:"last_success_time"`
+	LastTimeoutTime time.Time `json:"last_timeout_time"`
+}
+
+// Snapshot returns a copy of the engine's state.
+func (e *Engine) Snapshot() EngineSnapshot {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	s := EngineSnapshot{
+		Version:         snapshotVersion,
+		TotalSamples:    e.totalSamples,
+		TotalTimeouts:   e.totalTimeouts,
+		MaxRTT:          e.maxRTT,
+		SumRTT:          e.sumRTT,
+		SumRTTSquares:   e.sumRTTSquares,
+		LastRTT:         e.lastRTT,
+		SumJitter:       e.sumJitter,
+		JitterCount:     e.jitterCount,
+		CurrentStreak:   e.currentStreak,
+		LongestSuccess:  e.longestSuccess,
+		LongestTimeout:  e.longestTimeout,
+		RTTValuesMs:     slices.Clone(e.percentiles.values),
+		LossBursts:      e.lossBursts,
+		InTimeoutBurst:  e.inTimeoutBurst,
+		BrownoutSamples: e.brownoutSamples,
+		BrownoutBursts:  e.brownoutBursts,
+		InBrownout:      e.inBrownout,
+		OutageStart:     e.outageStart,
+		OutageTotal:     e.outageTotal,
+		LongestOutage:   e.longestOutage,
+		ClockJumps:      e.clockJumps,
+		SkippedTime:     e.skippedTime,
+		StartTime:       e.startTime,
+		FirstSampleTime: e.firstSampleTime,
+		LastSuccessTime: e.lastSuccessTime,
+		LastTimeoutTime: e.lastTimeoutTime,
+
+		BurstIntervals:       e.burstIntervals,
+		PartialLossIntervals: e.partialLossBursts,
+		FullLossIntervals:    e.fullLossBursts,
+
+		SignalDbm:          e.lastSignal,
+		WeakSignalSamples:  e.weakSignalSamples,
+		WeakSignalTimeouts: e.weakSignalTimeouts,
+	}
+	if e.outageCounts != [len(OutageBuckets) + 1]int{} {
+		s.OutageCounts = slices.Clone(e.outageCounts[:])
+	}
+	for _, kind := range types.ErrorKinds {
+		if n := e.errorCounts[kind]; n > 0 {
+			if s.ErrorCounts == nil {
+				s.ErrorCounts = make(map[string]int)
+			}
+			s.ErrorCounts[kind.String()] = n
+		}
+	}
+	if e.totalSamples > e.totalTimeouts {
+		s.MinRTT = e.minRTT
+	}
+	return s
+}
+
+// Restore replaces the engine's state with a snapshot. Statistics then
+// continue from where the snapshot was taken, including uptime.
+func (e *Engine) Restore(s EngineSnapshot) error {
+	if s.Version != snapshotVersion {
+		return fmt.Errorf("%w: %d", ErrSnapshotVersion, s.Version)
+	}
+	if s.TotalSamples < 0 || s.TotalTimeouts < 0 || s.TotalTimeouts > s.TotalSamples {
+		return fmt.Errorf("invalid engine snapshot: %d timeouts of %d samples", s.TotalTimeouts, s.TotalSamples)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.invalidate()
+	e.pctCount = -1
+	e.totalSamples = s.TotalSamples
+	e.totalTimeouts = s.TotalTimeouts
+	e.errorCounts = [types.ErrorKindCount]int{}
+	for name, n := range s.ErrorCounts {
+		if kind := types.ParseErrorKind(name); kind != types.ErrorNone {
+			e.errorCounts[kind] = n
+		}
+	}
+	e.minRTT = time.Duration(math.MaxInt64)
+	if s.TotalSamples > s.TotalTimeouts {
+		e.minRTT = s.MinRTT
+	}
+	e.maxRTT = s.MaxRTT
+	e.sumRTT = s.SumRTT
+	e.sumRTTSquares = s.SumRTTSquares
+	e.lastRTT = s.LastRTT
+	e.sumJitter = s.SumJitter
+	e.jitterCount = s.JitterCount
+	e.currentStreak = s.CurrentStreak
+	e.longestSuccess = s.LongestSuccess
+	e.longestTimeout = s.LongestTimeout
+	e.percentiles.Reset()
+	for _, ms := range s.RTTValuesMs {
+		e.percentiles.AddMs(ms)
+	}
+	e.lossBursts = s.LossBursts
+	e.inTimeoutBurst = s.InTimeoutBurst
+	e.brownoutSamples = s.BrownoutSamples
+	e.brownoutBursts = s.BrownoutBursts
+	e.inBrownout = s.InBrownout
+	e.outageStart = s.OutageStart
+	e.outageCounts = [len(OutageBuckets) + 1]int{}
+	copy(e.outageCounts[:], s.OutageCounts)
+	e.outageTotal = s.OutageTotal
+	e.longestOutage = s.LongestOutage
+	e.clockJumps = s.ClockJumps
+	e.skippedTime = s.SkippedTime
+	e.bursts = nil
+	e.burstIntervals = s.BurstIntervals
+	e.partialLossBursts = s.PartialLossIntervals
+	e.fullLossBursts = s.FullLossIntervals
+	e.lastSignal = s.SignalDbm
+	e.weakSignalSamples = s.WeakSignalSamples
+	e.weakSignalTimeouts = s.WeakSignalTimeouts
+	e.startTime = s.StartTime
+	if e.startTime.IsZero() {
+		e.startTime = time.Now()
+	}
+	e.firstSampleTime = s.FirstSampleTime
+	e.lastSuccessTime = s.LastSuccessTime
+	e.lastTimeoutTime = s.LastTimeoutTime
+	return nil
+}
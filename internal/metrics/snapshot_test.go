@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+func TestEngineSnapshotRestore(t *testing.T) {
+	src := NewEngine()
+	now := time.Now()
+	for i, rtt := range []time.Duration{10, 30, 0, 20, 250} {
+		src.Add(types.Sample{Timestamp: now.Add(time.Duration(i) * time.Second), RTT: rtt * time.Millisecond, Timeout: rtt == 0, ErrorKind: types.ErrorHostUnreachable})
+	}
+
+	// Round-trip through JSON as a daemon persisting its state would
+	data, err := json.Marshal(src.Snapshot())
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	var snap EngineSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	dst := NewEngine()
+	if err := dst.Restore(snap); err != nil {
+		t.Fatalf("Restore() error: %v", err)
+	}
+
+	want, got := src.Stats(), dst.Stats()
+	if got.TotalSamples != want.TotalSamples || got.TotalTimeouts != want.TotalTimeouts ||
+		got.MinRTT != want.MinRTT || got.MaxRTT != want.MaxRTT || got.AvgRTT != want.AvgRTT ||
+		got.Jitter != want.Jitter || got.Percentiles != want.Percentiles ||
+		got.ErrorCounts != want.ErrorCounts || got.LossBursts != want.LossBursts || got.BrownoutBursts != want.BrownoutBursts ||
+		got.OutageCounts != want.OutageCounts || got.LongestOutage != want.LongestOutage || got.Downtime != want.Downtime ||
+		!got.StartTime.Equal(want.StartTime) {
+		t.Fatalf("restored stats = %+v, want %+v", got, want)
+	}
+
+	// Both engines continue identically
+	next := types.Sample{Timestamp: now.Add(5 * time.Second), RTT: 5 * time.Millisecond}
+	src.Add(next)
+	dst.Add(next)
+	if got, want := dst.Stats(), src.Stats(); got.MinRTT != want.MinRTT || got.CurrentStreak != want.CurrentStreak || got.Percentiles != want.Percentiles {
+		t.Fatalf("after restore stats diverge: %+v vs %+v", got, want)
+	}
+}
+
+func TestEngineRestoreRejectsBadSnapshots(t *testing.T) {
+	e := NewEngine()
+	if err := e.Restore(EngineSnapshot{Version: snapshotVersion + 1}); !errors.Is(err, ErrSnapshotVersion) {
+		t.Fatalf("expected ErrSnapshotVersion, got %v", err)
+	}
+	if err := e.Restore(EngineSnapshot{Version: snapshotVersion, TotalSamples: 1, TotalTimeouts: 2}); err == nil {
+		t.Fatalf("expected error for more timeouts than samples")
+	}
+
+	// An empty snapshot restores a fresh engine
+	if err := e.Restore(EngineSnapshot{Version: snapshotVersion}); err != nil {
+		t.Fatalf("Restore(empty) error: %v", err)
+	}
+	e.Add(types.Sample{Timestamp: time.Now(), RTT: 7 * time.Millisecond})
+	if st := e.Stats(); st.MinRTT != 7*time.Millisecond {
+		t.Fatalf("MinRTT=%v after empty restore, want 7ms", st.MinRTT)
+	}
+}
@@ -0,0 +1,151 @@
+// Package mtu finds the path MTU to a host with don't-fragment probes of
+// increasing size. A path whose MTU is smaller than its ends believe, such
+// as a tunnel or PPPoE link whose "fragmentation needed" errors are
+// filtered, drops large packets silently: an MTU blackhole.
+package mtu
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/ping"
+)
+
+// Bytes an echo request adds to its data: the IP header and the 8-byte ICMP
+// header.
+const (
+	HeaderIPv4 = 28
+	HeaderIPv6 = 48
+)
+
+// Search defaults. IPv6 links carry at least 1280 bytes.
+const (
+	DefaultMinIPv4 = 1200
+	DefaultMinIPv6 = 1280
+	DefaultMax     = 1500
+	DefaultStep    = 20
+	DefaultTries   = 3
+)
+
+// ProbeFunc sends one don't-fragment probe, size bytes long including the
+// headers, and returns its sample. An error stops the search.
+type ProbeFunc func(ctx context.Context, size int) (ping.Sample, error)
+
+// Options bound the search.
+type Options struct {
+	Min   int // Smallest size probed
+	Max   int // Largest size probed, usually the local link MTU
+	Step  int // Size increment of the sweep from Min to Max
+	Tries int // Probes per size; a size passes with any reply
+}
+
+// Size holds the probes of one packet size.
+type Size struct {
+	Size    int
+	Samples []ping.Sample // One per try, in order
+}
+
+// Received returns how many probes of s got a reply.
+func (s Size) Received() int {
+	n := 0
+	for _, sample := range s.Samples {
+		if !sample.Timeout {
+			n++
+		}
+	}
+	return n
+}
+
+// Result is the outcome of a search.
+type Result struct {
+	MTU   int    // Largest size with replies below the first without; 0 if none got one
+	Sizes []Size // Every size probed, smallest first
+}
+
+// errNoReplies is returned by Discover when even the smallest size got no
+// reply, so the host does not answer at all.
+var errNoReplies = errors.New("no replies at the smallest size; the host may not answer pings")
+
+// Discover sweeps the sizes from Min to Max in Steps, then bisects between
+// the largest size with replies and the first without to find the exact
+// path MTU. Sizes above the first failing one are still swept, which shows
+// a path that drops only some sizes.
+func Discover(ctx context.Context, probe ProbeFunc, opts Options) (Result, error) {
+	var r Result
+	try := func(size int) (bool, error) {
+		s := Size{Size: size}
+		for range opts.Tries {
+			sample, err := probe(ctx, size)
+			if err != nil {
+				return false, err
+			}
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
+			s.Samples = append(s.Samples, sample)
+		}
+		i, _ := slices.BinarySearchFunc(r.Sizes, size, func(s Size, size int) int { return s.Size - size })
+		r.Sizes = slices.Insert(r.Sizes, i, s)
+		return s.Received() > 0, nil
+	}
+
+	lo, hi := 0, 0 // Largest passing size below the first failing one, and that one
+	for size := opts.Min; size <= opts.Max; size = nextSize(size, opts) {
+		ok, err := try(size)
+		if err != nil {
+			return r, err
+		}
+		switch {
+		case ok && hi == 0:
+			lo = size
+		case !ok && hi == 0:
+			hi = size
+		}
+	}
+	if lo == 0 {
+		return r, errNoReplies
+	}
+	if hi == 0 {
+		r.MTU = lo
+		return r, nil
+	}
+
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+		ok, err := try(mid)
+		if err != nil {
+			return r, err
+		}
+		if ok {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	r.MTU = lo
+	return r, nil
+}
+
+// nextSize returns the size swept after size: Step more, capped at Max so
+// Max itself is probed, or past Max once it was.
+func nextSize(size int, opts Options) int {
+	if size < opts.Max && size+opts.Step > opts.Max {
+		return opts.Max
+	}
+	return size + opts.Step
+}
+
+// Pinger returns a ProbeFunc pinging target once per call with the system
+// ping command, header being the bytes of headers its packets carry.
+func Pinger(target string, header int, timeout time.Duration) ProbeFunc {
+	seq := 0
+	return func(ctx context.Context, size int) (ping.Sample, error) {
+		seq++
+		s := ping.NewScheduler(target, time.Second)
+		s.SetTimeout(timeout)
+		s.SetDontFragment(size - header)
+		return s.Probe(ctx, seq)
+	}
+}
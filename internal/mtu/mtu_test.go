@@ -0,0 +1,106 @@
+package mtu
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/ping"
+	"github.com/pbv7/pingheat/internal/ui/colors"
+)
+
+// pathProbe answers probes up to mtu bytes, like a path with that MTU.
+func pathProbe(mtu int, sent *[]int) ProbeFunc {
+	return func(_ context.Context, size int) (ping.Sample, error) {
+		*sent = append(*sent, size)
+		if size > mtu {
+			return ping.Sample{Timeout: true}, nil
+		}
+		return ping.Sample{RTT: 20 * time.Millisecond}, nil
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	tests := []struct {
+		name string
+		path int
+		want int
+	}{
+		{"pppoe", 1492, 1492},
+		{"wireguard", 1420, 1420},
+		{"on a step", 1400, 1400},
+		{"full", 1500, 1500},
+		{"above max", 9000, 1500},
+	}
+	opts := Options{Min: 1200, Max: 1500, Step: 20, Tries: 2}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []int
+			r, err := Discover(context.Background(), pathProbe(tt.path, &sent), opts)
+			if err != nil {
+				t.Fatalf("Discover() error = %v", err)
+			}
+			if r.MTU != tt.want {
+				t.Fatalf("MTU = %d, want %d", r.MTU, tt.want)
+			}
+			// The sweep covers the whole range, with Max itself
+			if r.Sizes[0].Size != 1200 || r.Sizes[len(r.Sizes)-1].Size != 1500 {
+				t.Fatalf("sizes %d-%d, want 1200-1500", r.Sizes[0].Size, r.Sizes[len(r.Sizes)-1].Size)
+			}
+			for i, s := range r.Sizes {
+				if i > 0 && s.Size <= r.Sizes[i-1].Size {
+					t.Fatalf("sizes not ascending at %d: %d after %d", i, s.Size, r.Sizes[i-1].Size)
+				}
+				if len(s.Samples) != 2 {
+					t.Fatalf("size %d has %d samples, want 2", s.Size, len(s.Samples))
+				}
+			}
+			if len(sent) > 2*(16+5) {
+				t.Fatalf("sent %d probes, want a sweep and a bisection", len(sent))
+			}
+		})
+	}
+}
+
+func TestDiscoverNoReplies(t *testing.T) {
+	var sent []int
+	_, err := Discover(context.Background(), pathProbe(0, &sent), Options{Min: 1200, Max: 1300, Step: 50, Tries: 1})
+	if !errors.Is(err, errNoReplies) {
+		t.Fatalf("Discover() error = %v, want %v", err, errNoReplies)
+	}
+}
+
+func TestDiscoverProbeError(t *testing.T) {
+	failed := errors.New("no ping")
+	probe := func(context.Context, int) (ping.Sample, error) { return ping.Sample{}, failed }
+	if _, err := Discover(context.Background(), probe, Options{Min: 1200, Max: 1300, Step: 50, Tries: 1}); !errors.Is(err, failed) {
+		t.Fatalf("Discover() error = %v, want %v", err, failed)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var sent []int
+	r, err := Discover(context.Background(), pathProbe(1492, &sent), Options{Min: 1400, Max: 1500, Step: 50, Tries: 3})
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, "example.com", HeaderIPv4, r, colors.DefaultThresholds()); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"Path MTU to example.com: 1492 bytes (1464 bytes of ICMP data)",
+		" 1450  ███     3/3\n",
+		" 1492  ███     3/3  <- path MTU\n",
+		" 1493  ×××     0/3\n",
+		"Packets over 1492 bytes got no reply",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package mtu
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pbv7/pingheat/internal/ping"
+	"github.com/pbv7/pingheat/internal/ui/colors"
+)
+
+// Write prints the path MTU found to target and a heatmap of the sizes
+// probed: one cell per probe, colored by its RTT like the live heatmap, or
+// marked lost.
+func Write(w io.Writer, target string, header int, r Result, t colors.Thresholds) error {
+	var b strings.Builder
+	if r.MTU > 0 {
+		fmt.Fprintf(&b, "Path MTU to %s: %d bytes (%d bytes of ICMP data)\n\n", target, r.MTU, r.MTU-header)
+	} else {
+		fmt.Fprintf(&b, "Path MTU to %s: unknown\n\n", target)
+	}
+
+	width := len("Probes")
+	for _, s := range r.Sizes {
+		width = max(width, len(s.Samples))
+	}
+	fmt.Fprintf(&b, "%5s  %-*s  %s\n", "Size", width, "Probes", "Replies")
+	for _, s := range r.Sizes {
+		fmt.Fprintf(&b, "%5d  ", s.Size)
+		for _, sample := range s.Samples {
+			b.WriteString(cell(sample, t))
+		}
+		fmt.Fprintf(&b, "%s  %d/%d", strings.Repeat(" ", width-len(s.Samples)), s.Received(), len(s.Samples))
+		if s.Size == r.MTU {
+			b.WriteString("  <- path MTU")
+		}
+		b.WriteString("\n")
+	}
+
+	if r.MTU > 0 && r.MTU < r.Sizes[len(r.Sizes)-1].Size {
+		fmt.Fprintf(&b, "\nPackets over %d bytes got no reply with don't-fragment set. If the local link MTU is larger,\n", r.MTU)
+		b.WriteString("a tunnel, VPN or PPPoE link on the way drops them; lower the MTU or clamp the TCP MSS.\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// cell renders one probe: a block colored by its latency band, or a cross
+// for a probe without a reply.
+func cell(s ping.Sample, t colors.Thresholds) string {
+	if s.Timeout {
+		return lipgloss.NewStyle().Foreground(colors.ColorTimeout).Render("×")
+	}
+	return lipgloss.NewStyle().Foreground(t.Classify(s.RTT)).Render("█")
+}
@@ -0,0 +1,112 @@
+// Package netinfo discovers the local network's default gateway and DNS
+// resolver, so they can be probed by name: pingheat gateway, pingheat dns.
+package netinfo
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// Target keywords resolved by Resolve.
+const (
+	KeywordGateway = "gateway" // The default gateway
+	KeywordDNS     = "dns"     // The first configured DNS resolver
+)
+
+// ErrNotFound is returned when the system has no default gateway or DNS
+// resolver, or pingheat cannot read it on this platform.
+var ErrNotFound = errors.New("not found")
+
+// Resolve returns the address target stands for if it is a keyword, with
+// ok=false for any other target.
+func Resolve(target string) (addr string, ok bool, err error) {
+	var a netip.Addr
+	switch strings.ToLower(target) {
+	case KeywordGateway:
+		a, err = defaultGateway()
+		if err != nil {
+			return "", true, fmt.Errorf("default gateway: %w", err)
+		}
+	case KeywordDNS:
+		a, err = dnsServer()
+		if err != nil {
+			return "", true, fmt.Errorf("DNS resolver: %w", err)
+		}
+	default:
+		return "", false, nil
+	}
+	return a.String(), true, nil
+}
+
+// parseProcRoute returns the gateway of the default route in the format of
+// Linux's /proc/net/route: tab-separated, addresses in little-endian hex.
+// The route with the lowest metric wins.
+func parseProcRoute(r io.Reader) (netip.Addr, error) {
+	var best netip.Addr
+	bestMetric := -1
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // Header
+	for scanner.Scan() {
+		f := strings.Fields(scanner.Text())
+		if len(f) < 7 || f[1] != "00000000" {
+			continue
+		}
+		gw, err := hex.DecodeString(f[2])
+		if err != nil || len(gw) != 4 {
+			continue
+		}
+		var metric int
+		if _, err := fmt.Sscan(f[6], &metric); err != nil {
+			continue
+		}
+		slices.Reverse(gw)
+		addr := netip.AddrFrom4([4]byte(gw))
+		if addr.IsUnspecified() || (bestMetric >= 0 && metric >= bestMetric) {
+			continue
+		}
+		best, bestMetric = addr, metric
+	}
+	if !best.IsValid() {
+		return netip.Addr{}, ErrNotFound
+	}
+	return best, nil
+}
+
+// parseRouteGet returns the gateway from the output of BSD and macOS
+// "route -n get default".
+func parseRouteGet(out string) (netip.Addr, error) {
+	for line := range strings.Lines(out) {
+		value, ok := strings.CutPrefix(strings.TrimSpace(line), "gateway:")
+		if !ok {
+			continue
+		}
+		// Link-local gateways carry a zone, such as fe80::1%en0
+		if addr, err := netip.ParseAddr(strings.TrimSpace(value)); err == nil {
+			return addr, nil
+		}
+	}
+	return netip.Addr{}, ErrNotFound
+}
+
+// parseResolvConf returns the first nameserver of a resolv.conf that is not
+// a loopback address. Local stubs such as systemd-resolved only forward
+// queries, so probing them says nothing about the network.
+func parseResolvConf(r io.Reader) (netip.Addr, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		f := strings.Fields(scanner.Text())
+		if len(f) < 2 || f[0] != "nameserver" {
+			continue
+		}
+		if addr, err := netip.ParseAddr(f[1]); err == nil && !addr.IsLoopback() {
+			return addr, nil
+		}
+	}
+	return netip.Addr{}, ErrNotFound
+}
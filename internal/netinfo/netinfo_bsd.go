@@ -0,0 +1,17 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package netinfo
+
+import (
+	"net/netip"
+	"os/exec"
+)
+
+// defaultGateway asks route(8) for the gateway of the default route.
+func defaultGateway() (netip.Addr, error) {
+	out, err := exec.Command("route", "-n", "get", "default").Output()
+	if err != nil {
+		return netip.Addr{}, ErrNotFound // No default route
+	}
+	return parseRouteGet(string(out))
+}
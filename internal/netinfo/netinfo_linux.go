@@ -0,0 +1,16 @@
+package netinfo
+
+import (
+	"net/netip"
+	"os"
+)
+
+// defaultGateway returns the IPv4 gateway of the main routing table.
+func defaultGateway() (netip.Addr, error) {
+	f, err := os.Open("/proc/net/route")
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	defer func() { _ = f.Close() }()
+	return parseProcRoute(f)
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !windows && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package netinfo
+
+import "net/netip"
+
+// defaultGateway is not implemented on this platform.
+func defaultGateway() (netip.Addr, error) {
+	return netip.Addr{}, ErrNotFound
+}
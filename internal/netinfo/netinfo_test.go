@@ -0,0 +1,70 @@
+package netinfo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseProcRoute(t *testing.T) {
+	table := `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+wlan0	00000000	0101A8C0	0003	0	0	600	00000000	0	0	0
+eth0	00000000	010200C0	0003	0	0	100	00000000	0	0	0
+eth0	000200C0	00000000	0001	0	0	100	00FFFFFF	0	0	0
+`
+	addr, err := parseProcRoute(strings.NewReader(table))
+	if err != nil || addr.String() != "192.0.2.1" {
+		t.Fatalf("parseProcRoute() = %v, %v, want the lower-metric 192.0.2.1", addr, err)
+	}
+
+	noDefault := "Iface\tDestination\tGateway\nlo\t0000007F\t00000000\t0001\t0\t0\t0\t000000FF\n"
+	if _, err := parseProcRoute(strings.NewReader(noDefault)); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("parseProcRoute() without a default route error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestParseRouteGet(t *testing.T) {
+	out := `   route to: default
+destination: default
+       mask: default
+    gateway: 192.168.1.1
+  interface: en0
+`
+	addr, err := parseRouteGet(out)
+	if err != nil || addr.String() != "192.168.1.1" {
+		t.Fatalf("parseRouteGet() = %v, %v, want 192.168.1.1", addr, err)
+	}
+	if _, err := parseRouteGet("route: writing to routing socket: not in table\n"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("parseRouteGet() without a gateway error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestParseResolvConf(t *testing.T) {
+	tests := []struct {
+		name, conf, want string
+	}{
+		{"first server", "# comment\nsearch lan\nnameserver 9.9.9.9\nnameserver 1.1.1.1\n", "9.9.9.9"},
+		{"local stub skipped", "nameserver 127.0.0.53\nnameserver 2001:db8::53\n", "2001:db8::53"},
+		{"only a stub", "nameserver 127.0.0.53\noptions edns0\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, err := parseResolvConf(strings.NewReader(tt.conf))
+			if tt.want == "" {
+				if !errors.Is(err, ErrNotFound) {
+					t.Fatalf("parseResolvConf() = %v, %v, want ErrNotFound", addr, err)
+				}
+				return
+			}
+			if err != nil || addr.String() != tt.want {
+				t.Fatalf("parseResolvConf() = %v, %v, want %s", addr, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveOtherTargets(t *testing.T) {
+	if addr, ok, err := Resolve("example.com"); ok || err != nil || addr != "" {
+		t.Fatalf("Resolve(example.com) = %q, %v, %v, want no keyword", addr, ok, err)
+	}
+}
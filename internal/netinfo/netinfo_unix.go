@@ -0,0 +1,32 @@
+//go:build !windows
+
+package netinfo
+
+import (
+	"errors"
+	"net/netip"
+	"os"
+)
+
+// resolvConfs are read in order; systemd-resolved keeps the upstream
+// servers behind its local stub in the second one.
+var resolvConfs = []string{"/etc/resolv.conf", "/run/systemd/resolve/resolv.conf"}
+
+// dnsServer returns the first resolver of the system's resolv.conf.
+func dnsServer() (netip.Addr, error) {
+	for _, path := range resolvConfs {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		addr, err := parseResolvConf(f)
+		_ = f.Close()
+		if err == nil {
+			return addr, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return netip.Addr{}, err
+		}
+	}
+	return netip.Addr{}, ErrNotFound
+}
@@ -0,0 +1,63 @@
+package netinfo
+
+import (
+	"errors"
+	"net/netip"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// defaultGateway returns the first gateway of an adapter that is up.
+func defaultGateway() (netip.Addr, error) {
+	return firstAdapterAddress(func(a *windows.IpAdapterAddresses) []*windows.SocketAddress {
+		var addrs []*windows.SocketAddress
+		for gw := a.FirstGatewayAddress; gw != nil; gw = gw.Next {
+			addrs = append(addrs, &gw.Address)
+		}
+		return addrs
+	})
+}
+
+// dnsServer returns the first DNS server of an adapter that is up.
+func dnsServer() (netip.Addr, error) {
+	return firstAdapterAddress(func(a *windows.IpAdapterAddresses) []*windows.SocketAddress {
+		var addrs []*windows.SocketAddress
+		for dns := a.FirstDnsServerAddress; dns != nil; dns = dns.Next {
+			addrs = append(addrs, &dns.Address)
+		}
+		return addrs
+	})
+}
+
+// firstAdapterAddress returns the first usable address that list yields for
+// the adapters in the system's binding order, skipping loopback and
+// adapters that are down.
+func firstAdapterAddress(list func(*windows.IpAdapterAddresses) []*windows.SocketAddress) (netip.Addr, error) {
+	size := uint32(15000) // Recommended initial size
+	var buf []byte
+	for {
+		buf = make([]byte, size)
+		err := windows.GetAdaptersAddresses(windows.AF_UNSPEC, windows.GAA_FLAG_INCLUDE_GATEWAYS, 0,
+			(*windows.IpAdapterAddresses)(unsafe.Pointer(&buf[0])), &size)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, windows.ERROR_BUFFER_OVERFLOW) {
+			return netip.Addr{}, err
+		}
+	}
+
+	for a := (*windows.IpAdapterAddresses)(unsafe.Pointer(&buf[0])); a != nil; a = a.Next {
+		if a.OperStatus != windows.IfOperStatusUp || a.IfType == windows.IF_TYPE_SOFTWARE_LOOPBACK {
+			continue
+		}
+		for _, sa := range list(a) {
+			addr, ok := netip.AddrFromSlice(sa.IP())
+			if ok && addr.Unmap().IsValid() && !addr.IsUnspecified() && !addr.IsLoopback() {
+				return addr.Unmap(), nil
+			}
+		}
+	}
+	return netip.Addr{}, ErrNotFound
+}
@@ -0,0 +1,167 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+// Supported stream formats.
+const (
+	FormatJSON = "json" // One JSON object per line (JSON Lines)
+	FormatCSV  = "csv"  // Header row followed by one row per sample
+)
+
+// csvHeader lists CSV columns in output order, before one column per
+// static label.
+var csvHeader = []string{"timestamp", "seq", "rtt_ms", "timeout"}
+
+// IsValidFormat reports whether format is a supported stream format.
+func IsValidFormat(format string) bool {
+	return format == FormatJSON || format == FormatCSV
+}
+
+// Record is the serialized form of a sample.
+// RTTMs is nil for timeouts so consumers never mistake a loss for 0ms.
+type Record struct {
+	Timestamp time.Time `json:"timestamp"`
+	Seq       int       `json:"seq"`
+	RTTMs     *float64  `json:"rtt_ms"`
+	Timeout   bool      `json:"timeout"`
+
+	// Maintenance marks samples taken during a maintenance window
+	Maintenance bool `json:"maintenance,omitempty"`
+
+	// ClockJump marks the first sample after the system clock jumped
+	ClockJump bool `json:"clock_jump,omitempty"`
+
+	// IntervalMs is the probe interval in adaptive mode
+	IntervalMs float64 `json:"interval_ms,omitempty"`
+
+	// Error names the failure kind of a timeout (e.g. "host_unreachable")
+	Error string `json:"error,omitempty"`
+
+	// Burst is the number of probes sent in the interval with -burst
+	Burst int `json:"burst,omitempty"`
+
+	// WiFiDbm is the Wi-Fi signal strength when the sample was taken
+	WiFiDbm int `json:"wifi_dbm,omitempty"`
+
+	// Labels are the static labels of the target, such as site or isp
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// NewRecord converts a sample into its serialized form.
+func NewRecord(s types.Sample) Record {
+	r := Record{
+		Timestamp:   s.Timestamp,
+		Seq:         s.Sequence,
+		Timeout:     s.Timeout,
+		Maintenance: s.Maintenance,
+		ClockJump:   s.ClockJump,
+		Error:       s.Kind().String(),
+		IntervalMs:  float64(s.Interval.Milliseconds()),
+		Burst:       s.Burst,
+		WiFiDbm:     s.Signal,
+	}
+	if !s.Timeout {
+		ms := s.RTTMs()
+		r.RTTMs = &ms
+	}
+	return r
+}
+
+// Sample converts a record back into a sample.
+func (r Record) Sample() types.Sample {
+	s := types.Sample{
+		Timestamp:   r.Timestamp,
+		Sequence:    r.Seq,
+		Timeout:     r.Timeout,
+		Maintenance: r.Maintenance,
+		ClockJump:   r.ClockJump,
+		ErrorKind:   types.ParseErrorKind(r.Error),
+		Interval:    time.Duration(math.Round(r.IntervalMs * float64(time.Millisecond))),
+		Burst:       r.Burst,
+		Signal:      r.WiFiDbm,
+	}
+	if !r.Timeout && r.RTTMs != nil {
+		s.RTT = time.Duration(math.Round(*r.RTTMs * float64(time.Millisecond)))
+	}
+	return s
+}
+
+// Writer streams samples to an io.Writer, one line per sample.
+type Writer struct {
+	format      string
+	json        *json.Encoder
+	csv         *csv.Writer
+	wroteHeader bool
+
+	// Static labels added to every record, and their names in CSV column order
+	labels     map[string]string
+	labelNames []string
+}
+
+// NewWriter creates a Writer for the given format.
+func NewWriter(w io.Writer, format string) (*Writer, error) {
+	switch format {
+	case FormatJSON:
+		return &Writer{format: format, json: json.NewEncoder(w)}, nil
+	case FormatCSV:
+		return &Writer{format: format, csv: csv.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported output format %q (want json or csv)", format)
+	}
+}
+
+// SetLabels adds static labels, such as site or isp, to every record: as a
+// "labels" object in JSON, as columns sorted by name in CSV. Call it before
+// the first Write.
+func (w *Writer) SetLabels(labels map[string]string) {
+	w.labels = labels
+	w.labelNames = slices.Sorted(maps.Keys(labels))
+}
+
+// Write emits one sample. Each line is flushed immediately so the stream
+// can be consumed live by pipes such as jq.
+func (w *Writer) Write(s types.Sample) error {
+	r := NewRecord(s)
+	r.Labels = w.labels
+	if w.format == FormatJSON {
+		return w.json.Encode(r)
+	}
+
+	if !w.wroteHeader {
+		if err := w.csv.Write(slices.Concat(csvHeader, w.labelNames)); err != nil {
+			return err
+		}
+		w.wroteHeader = true
+	}
+
+	rtt := ""
+	if r.RTTMs != nil {
+		rtt = strconv.FormatFloat(*r.RTTMs, 'f', 3, 64)
+	}
+	row := []string{
+		r.Timestamp.Format(time.RFC3339Nano),
+		strconv.Itoa(r.Seq),
+		rtt,
+		strconv.FormatBool(r.Timeout),
+	}
+	for _, name := range w.labelNames {
+		row = append(row, w.labels[name])
+	}
+	if err := w.csv.Write(row); err != nil {
+		return err
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
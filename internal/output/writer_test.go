@@ -0,0 +1,112 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+var testSamples = []types.Sample{
+	{Timestamp: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), Sequence: 1, RTT: 14300 * time.Microsecond},
+	{Timestamp: time.Date(2024, 1, 2, 10, 0, 1, 0, time.UTC), Sequence: 2, Timeout: true, Burst: 3, Signal: -72},
+}
+
+func TestWriterJSON(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, FormatJSON)
+	if err != nil {
+		t.Fatalf("NewWriter() error: %v", err)
+	}
+	for _, s := range testSamples {
+		if err := w.Write(s); err != nil {
+			t.Fatalf("Write() error: %v", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), buf.String())
+	}
+	if lines[0] != `{"timestamp":"2024-01-02T10:00:00Z","seq":1,"rtt_ms":14.3,"timeout":false}` {
+		t.Fatalf("unexpected first line: %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `"rtt_ms":null,"timeout":true`) {
+		t.Fatalf("expected null rtt for timeout, got %s", lines[1])
+	}
+	if !strings.Contains(lines[1], `"error":"timeout"`) {
+		t.Fatalf("expected error kind for timeout, got %s", lines[1])
+	}
+	if !strings.Contains(lines[1], `"burst":3`) {
+		t.Fatalf("expected burst size, got %s", lines[1])
+	}
+	if !strings.Contains(lines[1], `"wifi_dbm":-72`) {
+		t.Fatalf("expected Wi-Fi signal, got %s", lines[1])
+	}
+
+	var r Record
+	if err := json.Unmarshal([]byte(lines[0]), &r); err != nil {
+		t.Fatalf("Unmarshal() error: %v", err)
+	}
+	if got := r.Sample(); got != testSamples[0] {
+		t.Fatalf("round trip = %+v, want %+v", got, testSamples[0])
+	}
+}
+
+func TestWriterCSV(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, FormatCSV)
+	if err != nil {
+		t.Fatalf("NewWriter() error: %v", err)
+	}
+	for _, s := range testSamples {
+		if err := w.Write(s); err != nil {
+			t.Fatalf("Write() error: %v", err)
+		}
+	}
+
+	want := "timestamp,seq,rtt_ms,timeout\n" +
+		"2024-01-02T10:00:00Z,1,14.300,false\n" +
+		"2024-01-02T10:00:01Z,2,,true\n"
+	if buf.String() != want {
+		t.Fatalf("CSV output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestWriterLabels(t *testing.T) {
+	labels := map[string]string{"site": "ams", "isp": "acme"}
+
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf, FormatJSON)
+	w.SetLabels(labels)
+	if err := w.Write(testSamples[0]); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"labels":{"isp":"acme","site":"ams"}`) {
+		t.Fatalf("JSON output = %s, want the labels", buf.String())
+	}
+
+	buf.Reset()
+	w, _ = NewWriter(&buf, FormatCSV)
+	w.SetLabels(labels)
+	if err := w.Write(testSamples[0]); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	want := "timestamp,seq,rtt_ms,timeout,isp,site\n" +
+		"2024-01-02T10:00:00Z,1,14.300,false,acme,ams\n"
+	if buf.String() != want {
+		t.Fatalf("CSV output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestNewWriterRejectsUnknownFormat(t *testing.T) {
+	if _, err := NewWriter(&bytes.Buffer{}, "xml"); err == nil {
+		t.Fatalf("expected error for unknown format")
+	}
+	if IsValidFormat("xml") || !IsValidFormat(FormatCSV) {
+		t.Fatalf("IsValidFormat mismatch")
+	}
+}
@@ -0,0 +1,25 @@
+package parser
+
+import "testing"
+
+func BenchmarkParseLine(b *testing.B) {
+	tests := []struct {
+		name   string
+		parser Parser
+		line   string
+	}{
+		{"linux_reply", NewLinux(), "64 bytes from 8.8.8.8: icmp_seq=1 ttl=118 time=14.3 ms"},
+		{"linux_timeout", NewLinux(), "no answer yet for icmp_seq=2"},
+		{"darwin_reply", NewDarwin(), "64 bytes from 8.8.8.8: icmp_seq=0 ttl=118 time=14.236 ms"},
+		{"darwin_timeout", NewDarwin(), "Request timeout for icmp_seq 5"},
+		{"windows_reply", NewWindows(), "Reply from 8.8.8.8: bytes=32 time=14ms TTL=118"},
+		{"windows_timeout", NewWindows(), "Request timed out."},
+	}
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			for b.Loop() {
+				tt.parser.ParseLine(tt.line)
+			}
+		})
+	}
+}
@@ -19,9 +19,10 @@ type Darwin struct {
 func NewDarwin() *Darwin {
 	return &Darwin{
 		// macOS uses icmp_seq starting from 0
-		replyPattern: regexp.MustCompile(`icmp_seq=(\d+).*time=([0-9.]+)\s*ms`),
+		// Localized iputils builds translate "time" and may use a decimal comma
+		replyPattern: regexp.MustCompile(`icmp_seq=(\d+).*` + timeWords + `\s*=\s*([0-9]+(?:[.,][0-9]+)?)\s*` + msUnits),
 		// Matches: Request timeout for icmp_seq 0
-		timeoutPattern: regexp.MustCompile(`(?i)request timeout|no answer|time.*exceeded|unreachable`),
+		timeoutPattern: regexp.MustCompile(`(?i)request timeout|no answer|time.*exceeded|unreachable|prohibited|packet filtered`),
 	}
 }
 
@@ -43,12 +44,13 @@ func (p *Darwin) ParseLine(line string) (types.Sample, bool) {
 	}
 
 	// Check for timeout patterns
-	if p.timeoutPattern.MatchString(line) {
+	if p.timeoutPattern.MatchString(line) || localTimeoutPattern.MatchString(line) {
 		return types.Sample{
 			Timestamp: time.Now(),
 			Sequence:  -1,
 			RTT:       0,
 			Timeout:   true,
+			ErrorKind: classifyError(line),
 		}, true
 	}
 
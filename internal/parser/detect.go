@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"sync"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+// detectLines is how many lines Detect inspects before settling on its
+// first candidate when no format recognized a reply.
+const detectLines = 20
+
+// Detect identifies the output format at runtime. Until a candidate
+// recognizes a reply it tries them all, in order, on every line; then it
+// locks onto that parser. This copes with a ping that does not match the
+// host OS, e.g. under WSL or Cygwin or with a non-native ping first in PATH.
+// It is safe for concurrent use.
+type Detect struct {
+	mu         sync.Mutex
+	candidates []Parser
+	locked     Parser
+	lines      int
+}
+
+// NewDetect creates a detecting parser. The first candidate is preferred
+// for ambiguous lines and used if detection does not succeed.
+func NewDetect(candidates ...Parser) *Detect {
+	return &Detect{candidates: candidates}
+}
+
+// ParseLine implements Parser.
+func (d *Detect) ParseLine(line string) (types.Sample, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.locked != nil {
+		return d.locked.ParseLine(line)
+	}
+
+	// Timeouts look alike across formats, so only a reply decides
+	var timeout types.Sample
+	var found bool
+	for _, p := range d.candidates {
+		sample, ok := p.ParseLine(line)
+		if !ok {
+			continue
+		}
+		if !sample.Timeout {
+			d.locked = p
+			return sample, true
+		}
+		if !found {
+			timeout, found = sample, true
+		}
+	}
+
+	d.lines++
+	if d.lines >= detectLines && len(d.candidates) > 0 {
+		d.locked = d.candidates[0]
+	}
+	return timeout, found
+}
+
+// Detected returns the parser detection locked onto, or nil while it is
+// still undecided.
+func (d *Detect) Detected() Parser {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.locked
+}
@@ -0,0 +1,41 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDetectLocksOntoMatchingFormat(t *testing.T) {
+	d := NewDetect(NewLinux(), NewWindows())
+
+	// A Windows timeout before any reply is still reported
+	if s, ok := d.ParseLine("Request timed out."); !ok || !s.Timeout {
+		t.Fatalf("timeout before detection = %+v, %v; want a timeout", s, ok)
+	}
+	if d.Detected() != nil {
+		t.Fatalf("detection locked on a timeout line")
+	}
+
+	s, ok := d.ParseLine("Reply from 8.8.8.8: bytes=32 time=14ms TTL=118")
+	if !ok || s.RTT != 14*time.Millisecond {
+		t.Fatalf("Windows reply = %+v, %v; want 14ms", s, ok)
+	}
+	if _, isWindows := d.Detected().(*Windows); !isWindows {
+		t.Fatalf("Detected() = %T, want *Windows", d.Detected())
+	}
+
+	// Once locked, other formats are ignored
+	if _, ok := d.ParseLine("64 bytes from 8.8.8.8: icmp_seq=1 ttl=118 time=14.3 ms"); ok {
+		t.Fatalf("Linux reply parsed after locking onto Windows")
+	}
+}
+
+func TestDetectFallsBackToFirstCandidate(t *testing.T) {
+	d := NewDetect(NewLinux(), NewWindows())
+	for range detectLines {
+		d.ParseLine("PING 8.8.8.8 (8.8.8.8) 56(84) bytes of data.")
+	}
+	if _, isLinux := d.Detected().(*Linux); !isLinux {
+		t.Fatalf("Detected() = %T after %d unrecognized lines, want *Linux", d.Detected(), detectLines)
+	}
+}
@@ -0,0 +1,163 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// locale lists the words a localized ping prints instead of the English
+// ones. Forcing LC_ALL=C and code page 437 covers most systems, but some
+// vendor and embedded pings, and Windows display languages without an
+// English message table, still translate their output.
+type locale struct {
+	tag         string   // BCP 47 language tag, for reference only
+	reply       []string // Windows "Reply from"
+	time        []string // Key of the RTT field, "time=14ms"
+	timeout     []string // Windows "Request timed out."
+	hostUnreach []string // "Destination host unreachable"
+	netUnreach  []string // "Destination net unreachable"
+}
+
+// locales are matched case-insensitively as substrings. iputils keeps the
+// icmp_seq field untranslated, so Linux replies only need the time key.
+var locales = []locale{
+	{
+		tag:         "de",
+		reply:       []string{"Antwort von"},
+		time:        []string{"Zeit"},
+		timeout:     []string{"Zeitüberschreitung der Anforderung"},
+		hostUnreach: []string{"Zielhost nicht erreichbar", "Zielhost ist nicht erreichbar"},
+		netUnreach:  []string{"Zielnetz nicht erreichbar", "Zielnetzwerk nicht erreichbar"},
+	},
+	{
+		tag:         "fr",
+		reply:       []string{"Réponse de"},
+		time:        []string{"temps"},
+		timeout:     []string{"Délai d'attente de la demande dépassé"},
+		hostUnreach: []string{"Impossible de joindre l'hôte de destination", "Hôte de destination injoignable"},
+		netUnreach:  []string{"Impossible de joindre le réseau de destination", "Réseau de destination injoignable"},
+	},
+	{
+		tag:         "es",
+		reply:       []string{"Respuesta desde"},
+		time:        []string{"tiempo"},
+		timeout:     []string{"Tiempo de espera agotado para esta solicitud"},
+		hostUnreach: []string{"Host de destino inaccesible"},
+		netUnreach:  []string{"Red de destino inaccesible"},
+	},
+	{
+		tag:         "it",
+		reply:       []string{"Risposta da"},
+		time:        []string{"durata", "tempo"},
+		timeout:     []string{"Richiesta scaduta"},
+		hostUnreach: []string{"Host di destinazione non raggiungibile"},
+		netUnreach:  []string{"Rete di destinazione non raggiungibile"},
+	},
+	{
+		tag:         "pt",
+		reply:       []string{"Resposta de"},
+		time:        []string{"tempo"},
+		timeout:     []string{"Esgotado o tempo limite do pedido", "Tempo limite do pedido esgotado"},
+		hostUnreach: []string{"Host de destino inacessível"},
+		netUnreach:  []string{"Rede de destino inacessível"},
+	},
+	{
+		tag:         "nl",
+		reply:       []string{"Antwoord van"},
+		time:        []string{"tijd"},
+		timeout:     []string{"Time-out bij opdracht"},
+		hostUnreach: []string{"Doelhost onbereikbaar", "Doelhost is onbereikbaar"},
+		netUnreach:  []string{"Doelnetwerk onbereikbaar", "Doelnetwerk is onbereikbaar"},
+	},
+	{
+		tag:         "sv",
+		reply:       []string{"Svar från"},
+		time:        []string{"tid"},
+		timeout:     []string{"Tidsgränsen för begäran överskreds"},
+		hostUnreach: []string{"Målvärden kan inte nås"},
+		netUnreach:  []string{"Målnätverket kan inte nås"},
+	},
+	{
+		tag:         "pl",
+		reply:       []string{"Odpowiedź z"},
+		time:        []string{"czas"},
+		timeout:     []string{"Upłynął limit czasu żądania"},
+		hostUnreach: []string{"Host docelowy jest nieosiągalny"},
+		netUnreach:  []string{"Sieć docelowa jest nieosiągalna"},
+	},
+	{
+		tag:         "ru",
+		reply:       []string{"Ответ от"},
+		time:        []string{"время"},
+		timeout:     []string{"Превышен интервал ожидания"},
+		hostUnreach: []string{"Заданный узел недоступен"},
+		netUnreach:  []string{"Заданная сеть недоступна"},
+	},
+	{
+		tag:         "tr",
+		reply:       []string{"yanıtı"},
+		time:        []string{"süre"},
+		timeout:     []string{"İstek zaman aşımına uğradı"},
+		hostUnreach: []string{"Hedef ana bilgisayara ulaşılamıyor"},
+		netUnreach:  []string{"Hedef ağa ulaşılamıyor"},
+	},
+	{
+		tag:         "zh-Hans",
+		reply:       []string{"的回复"},
+		time:        []string{"时间"},
+		timeout:     []string{"请求超时"},
+		hostUnreach: []string{"无法访问目标主机"},
+		netUnreach:  []string{"无法访问目标网"},
+	},
+	{
+		tag:         "zh-Hant",
+		reply:       []string{"回覆自"},
+		time:        []string{"時間"},
+		timeout:     []string{"要求等候逾時"},
+		hostUnreach: []string{"目的地主機無法連線"},
+		netUnreach:  []string{"目的地網路無法連線"},
+	},
+	{
+		tag:         "ja",
+		reply:       []string{"からの応答"},
+		time:        []string{"時間"},
+		timeout:     []string{"要求がタイムアウトしました"},
+		hostUnreach: []string{"宛先ホストに到達できません"},
+		netUnreach:  []string{"宛先ネットワークに到達できません"},
+	},
+	{
+		tag:         "ko",
+		reply:       []string{"의 응답"},
+		time:        []string{"시간"},
+		timeout:     []string{"요청 시간이 만료되었습니다"},
+		hostUnreach: []string{"대상 호스트에 연결할 수 없습니다"},
+		netUnreach:  []string{"대상 네트워크에 연결할 수 없습니다"},
+	},
+}
+
+// Fragments of the reply and timeout patterns covering English and every
+// locale. Some locales print "ms" in their own script.
+var (
+	replyWords = localeWords([]string{"Reply from"}, func(l locale) []string { return l.reply })
+	timeWords  = localeWords([]string{"time"}, func(l locale) []string { return l.time })
+	msUnits    = `(?:ms|мс|毫秒|ミリ秒)`
+
+	localTimeoutPattern = regexp.MustCompile(localeWords(nil, func(l locale) []string {
+		return append(append(append([]string(nil), l.timeout...), l.hostUnreach...), l.netUnreach...)
+	}))
+	localHostUnreachPattern = regexp.MustCompile(localeWords(nil, func(l locale) []string { return l.hostUnreach }))
+	localNetUnreachPattern  = regexp.MustCompile(localeWords(nil, func(l locale) []string { return l.netUnreach }))
+)
+
+// localeWords returns a case-insensitive alternation of english and the
+// words field selects from every locale.
+func localeWords(english []string, field func(locale) []string) string {
+	words := append([]string(nil), english...)
+	for _, l := range locales {
+		words = append(words, field(l)...)
+	}
+	for i, w := range words {
+		words[i] = regexp.QuoteMeta(w)
+	}
+	return `(?i:` + strings.Join(words, "|") + `)`
+}
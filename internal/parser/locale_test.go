@@ -0,0 +1,112 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+func TestWindowsLocalizedReplies(t *testing.T) {
+	tests := []struct {
+		tag  string
+		line string
+		want time.Duration
+	}{
+		{"de", "Antwort von 8.8.8.8: Bytes=32 Zeit=14ms TTL=118", 14 * time.Millisecond},
+		{"de", "Antwort von 192.168.1.1: Bytes=32 Zeit<1ms TTL=64", time.Millisecond},
+		{"fr", "Réponse de 8.8.8.8 : octets=32 temps=14 ms TTL=118", 14 * time.Millisecond},
+		{"es", "Respuesta desde 8.8.8.8: bytes=32 tiempo=14ms TTL=118", 14 * time.Millisecond},
+		{"it", "Risposta da 8.8.8.8: byte=32 durata=14ms TTL=118", 14 * time.Millisecond},
+		{"pt", "Resposta de 8.8.8.8: bytes=32 tempo=14ms TTL=118", 14 * time.Millisecond},
+		{"nl", "Antwoord van 8.8.8.8: bytes=32 tijd=14ms TTL=118", 14 * time.Millisecond},
+		{"sv", "Svar från 8.8.8.8: byte=32 tid=14ms TTL=118", 14 * time.Millisecond},
+		{"pl", "Odpowiedź z 8.8.8.8: bajtów=32 czas=14ms TTL=118", 14 * time.Millisecond},
+		{"ru", "Ответ от 8.8.8.8: число байт=32 время=14мс TTL=118", 14 * time.Millisecond},
+		{"tr", "8.8.8.8 yanıtı: bayt=32 süre=14ms TTL=118", 14 * time.Millisecond},
+		{"zh-Hans", "来自 8.8.8.8 的回复: 字节=32 时间=14ms TTL=118", 14 * time.Millisecond},
+		{"zh-Hant", "回覆自 8.8.8.8: 位元組=32 時間=14ms TTL=118", 14 * time.Millisecond},
+		{"ja", "8.8.8.8 からの応答: バイト数 =32 時間 =14ms TTL=118", 14 * time.Millisecond},
+		{"ja", "192.168.1.1 からの応答: バイト数 =32 時間 <1ms TTL=64", time.Millisecond},
+		{"ko", "8.8.8.8의 응답: 바이트=32 시간=14ms TTL=118", 14 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		s, ok := NewWindows().ParseLine(tt.line)
+		if !ok || s.Timeout || s.RTT != tt.want {
+			t.Fatalf("%s: ParseLine(%q) = %+v, %v; want reply in %v", tt.tag, tt.line, s, ok, tt.want)
+		}
+	}
+}
+
+func TestLinuxLocalizedReplies(t *testing.T) {
+	tests := []struct {
+		tag  string
+		line string
+		want time.Duration
+	}{
+		{"de", "64 Bytes von 8.8.8.8: icmp_seq=1 ttl=118 Zeit=14,3 ms", 14300 * time.Microsecond},
+		{"fr", "64 octets de 8.8.8.8 : icmp_seq=1 ttl=118 temps=14,3 ms", 14300 * time.Microsecond},
+		{"ru", "64 байт от 8.8.8.8: icmp_seq=1 ttl=118 время=14.3 мс", 14300 * time.Microsecond},
+		{"zh-Hans", "64 字节，来自 8.8.8.8: icmp_seq=1 ttl=118 时间=14.3 毫秒", 14300 * time.Microsecond},
+	}
+
+	for _, tt := range tests {
+		for _, p := range []Parser{NewLinux(), NewDarwin()} {
+			s, ok := p.ParseLine(tt.line)
+			if !ok || s.Timeout || s.Sequence != 1 || s.RTT != tt.want {
+				t.Fatalf("%s: %T.ParseLine(%q) = %+v, %v; want seq 1 in %v", tt.tag, p, tt.line, s, ok, tt.want)
+			}
+		}
+	}
+}
+
+func TestLocalizedFailures(t *testing.T) {
+	tests := []struct {
+		tag  string
+		line string
+		want types.ErrorKind
+	}{
+		{"de", "Zeitüberschreitung der Anforderung.", types.ErrorTimeout},
+		{"de", "Antwort von 192.168.1.1: Zielhost nicht erreichbar.", types.ErrorHostUnreachable},
+		{"de", "Antwort von 192.168.1.1: Zielnetz nicht erreichbar.", types.ErrorNetUnreachable},
+		{"fr", "Délai d'attente de la demande dépassé.", types.ErrorTimeout},
+		{"fr", "Réponse de 192.168.1.1 : Impossible de joindre l'hôte de destination.", types.ErrorHostUnreachable},
+		{"es", "Tiempo de espera agotado para esta solicitud.", types.ErrorTimeout},
+		{"es", "Respuesta desde 192.168.1.1: Red de destino inaccesible.", types.ErrorNetUnreachable},
+		{"it", "Richiesta scaduta.", types.ErrorTimeout},
+		{"pt", "Esgotado o tempo limite do pedido.", types.ErrorTimeout},
+		{"nl", "Time-out bij opdracht.", types.ErrorTimeout},
+		{"sv", "Tidsgränsen för begäran överskreds.", types.ErrorTimeout},
+		{"pl", "Upłynął limit czasu żądania.", types.ErrorTimeout},
+		{"ru", "Превышен интервал ожидания для запроса.", types.ErrorTimeout},
+		{"ru", "Ответ от 192.168.1.1: Заданный узел недоступен.", types.ErrorHostUnreachable},
+		{"tr", "İstek zaman aşımına uğradı.", types.ErrorTimeout},
+		{"zh-Hans", "请求超时。", types.ErrorTimeout},
+		{"zh-Hans", "来自 192.168.1.1 的回复: 无法访问目标主机。", types.ErrorHostUnreachable},
+		{"zh-Hant", "要求等候逾時。", types.ErrorTimeout},
+		{"ja", "要求がタイムアウトしました。", types.ErrorTimeout},
+		{"ja", "192.168.1.1 からの応答: 宛先ネットワークに到達できません。", types.ErrorNetUnreachable},
+		{"ko", "요청 시간이 만료되었습니다.", types.ErrorTimeout},
+		{"fr", "From 192.168.1.1 icmp_seq=1 Hôte de destination injoignable", types.ErrorHostUnreachable},
+	}
+
+	for _, tt := range tests {
+		for _, p := range []Parser{NewWindows(), NewLinux(), NewDarwin()} {
+			s, ok := p.ParseLine(tt.line)
+			if !ok || !s.Timeout || s.ErrorKind != tt.want {
+				t.Fatalf("%s: %T.ParseLine(%q) = %+v, %v; want %v", tt.tag, p, tt.line, s, ok, tt.want)
+			}
+		}
+	}
+}
+
+func TestDetectLocalizedWindows(t *testing.T) {
+	d := New()
+	if _, ok := d.ParseLine("Antwort von 8.8.8.8: Bytes=32 Zeit=14ms TTL=118"); !ok {
+		t.Fatalf("localized Windows reply not detected")
+	}
+	if _, isWindows := d.(*Detect).Detected().(*Windows); !isWindows {
+		t.Fatalf("Detected() = %T, want *Windows", d.(*Detect).Detected())
+	}
+}
@@ -0,0 +1,30 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// noisePattern matches the banner and statistics lines of the supported
+// ping implementations, which never carry a sample.
+var noisePattern = regexp.MustCompile(`^(?:` +
+	`PING6?[ (]` + // Linux/macOS banner
+	`|Pinging ` + // Windows banner
+	`|--- .* ping statistics ---` +
+	`|\d+ packets transmitted` +
+	`|rtt min/` + // Linux summary
+	`|round-trip ` + // macOS summary
+	`|Ping statistics for ` + // Windows summary
+	`|Packets: Sent` +
+	`|Approximate round trip` +
+	`|Minimum = ` +
+	`|\^C$|Control-C$` +
+	`)`)
+
+// IsNoise reports whether line is known ping output without timing info,
+// such as a banner, a statistics line or a blank line. Lines that neither
+// parse nor are noise point at an unsupported output format.
+func IsNoise(line string) bool {
+	line = strings.TrimSpace(line)
+	return line == "" || noisePattern.MatchString(line)
+}
@@ -0,0 +1,40 @@
+package parser
+
+import "testing"
+
+func TestIsNoise(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"", true},
+		{"   ", true},
+		{"PING 8.8.8.8 (8.8.8.8) 56(84) bytes of data.", true},
+		{"PING 8.8.8.8 (8.8.8.8): 56 data bytes", true},
+		{"PING6(56=40+8+8 bytes) ::1 --> ::1", true},
+		{"PING ::1(::1) 56 data bytes", true},
+		{"--- 8.8.8.8 ping statistics ---", true},
+		{"4 packets transmitted, 4 received, 0% packet loss, time 3004ms", true},
+		{"4 packets transmitted, 4 packets received, 0.0% packet loss", true},
+		{"rtt min/avg/max/mdev = 10.1/12.3/14.3/1.5 ms", true},
+		{"round-trip min/avg/max/stddev = 10.1/12.3/14.3/1.5 ms", true},
+		{"Pinging 8.8.8.8 with 32 bytes of data:", true},
+		{"Ping statistics for 8.8.8.8:", true},
+		{"    Packets: Sent = 4, Received = 4, Lost = 0 (0% loss),", true},
+		{"Approximate round trip times in milli-seconds:", true},
+		{"    Minimum = 10ms, Maximum = 14ms, Average = 12ms", true},
+		{"Control-C", true},
+		{"^C", true},
+
+		{"64 bytes from 8.8.8.8: icmp_seq=1 ttl=118 time=14.3 ms", false},
+		{"Request timeout for icmp_seq 2", false},
+		{"Reply from 8.8.8.8: bytes=32 zeit=14ms TTL=118", false},
+		{"64 Bytes von 8.8.8.8: icmp_seq=1 ttl=118 Zeit=14,3 ms", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsNoise(tt.line); got != tt.want {
+			t.Fatalf("IsNoise(%q)=%v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"regexp"
 	"runtime"
 	"time"
 
@@ -15,18 +16,43 @@ type Parser interface {
 	ParseLine(line string) (types.Sample, bool)
 }
 
-// New returns a Parser appropriate for the current platform.
+// New returns a Parser that detects the output format, preferring the one
+// native to the current platform.
 func New() Parser {
 	switch runtime.GOOS {
 	case "darwin":
-		return NewDarwin()
+		return NewDetect(NewDarwin(), NewLinux(), NewWindows())
 	case "windows":
-		return NewWindows()
+		return NewDetect(NewWindows(), NewLinux(), NewDarwin())
 	default: // linux and others
-		return NewLinux()
+		return NewDetect(NewLinux(), NewDarwin(), NewWindows())
 	}
 }
 
+// errorPatterns classify failure lines, most specific first.
+var errorPatterns = []struct {
+	kind    types.ErrorKind
+	pattern *regexp.Regexp
+}{
+	{types.ErrorNetUnreachable, regexp.MustCompile(`(?i)net(work)? unreachable`)},
+	{types.ErrorHostUnreachable, regexp.MustCompile(`(?i)host unreachable`)},
+	{types.ErrorTTLExceeded, regexp.MustCompile(`(?i)time to live exceeded|ttl expired|time exceeded`)},
+	{types.ErrorAdminProhibited, regexp.MustCompile(`(?i)prohibited|packet filtered`)},
+	{types.ErrorNetUnreachable, localNetUnreachPattern},
+	{types.ErrorHostUnreachable, localHostUnreachPattern},
+}
+
+// classifyError returns the failure kind reported by a timeout line,
+// falling back to ErrorTimeout.
+func classifyError(line string) types.ErrorKind {
+	for _, p := range errorPatterns {
+		if p.pattern.MatchString(line) {
+			return p.kind
+		}
+	}
+	return types.ErrorTimeout
+}
+
 // parseDuration parses a floating point milliseconds string into time.Duration.
 func parseDuration(ms string) (time.Duration, error) {
 	var f float64
@@ -66,7 +92,7 @@ func parseFloatManual(s string, f *float64) (int, error) {
 			} else {
 				result = result*10 + float64(c-'0')
 			}
-		} else if c == '.' && !inDecimal {
+		} else if (c == '.' || c == ',') && !inDecimal { // Some locales use a decimal comma
 			inDecimal = true
 		} else {
 			break
@@ -3,6 +3,8 @@ package parser
 import (
 	"testing"
 	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
 )
 
 func TestLinuxParser(t *testing.T) {
@@ -202,3 +204,37 @@ func TestWindowsParser(t *testing.T) {
 		})
 	}
 }
+
+func TestParsersClassifyErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		parser Parser
+		line   string
+		want   types.ErrorKind
+	}{
+		{"linux timeout", NewLinux(), "no answer yet for icmp_seq=3", types.ErrorTimeout},
+		{"linux host", NewLinux(), "From 10.0.0.1 icmp_seq=1 Destination Host Unreachable", types.ErrorHostUnreachable},
+		{"linux net", NewLinux(), "From 10.0.0.1 icmp_seq=1 Destination Net Unreachable", types.ErrorNetUnreachable},
+		{"linux ttl", NewLinux(), "From 10.0.0.1 icmp_seq=1 Time to live exceeded", types.ErrorTTLExceeded},
+		{"linux filtered", NewLinux(), "From 10.0.0.1 icmp_seq=1 Packet filtered", types.ErrorAdminProhibited},
+		{"linux prohibited", NewLinux(), "From 10.0.0.1 icmp_seq=1 Destination Host Prohibited", types.ErrorAdminProhibited},
+		{"darwin timeout", NewDarwin(), "Request timeout for icmp_seq 0", types.ErrorTimeout},
+		{"darwin host", NewDarwin(), "92 bytes from 10.0.0.1: Destination Host Unreachable", types.ErrorHostUnreachable},
+		{"darwin prohibited", NewDarwin(), "92 bytes from 10.0.0.1: Communication prohibited by filter", types.ErrorAdminProhibited},
+		{"windows timeout", NewWindows(), "Request timed out.", types.ErrorTimeout},
+		{"windows host", NewWindows(), "Reply from 10.0.0.1: Destination host unreachable.", types.ErrorHostUnreachable},
+		{"windows net", NewWindows(), "Reply from 10.0.0.1: Destination net unreachable.", types.ErrorNetUnreachable},
+		{"windows ttl", NewWindows(), "Reply from 10.0.0.1: TTL expired in transit.", types.ErrorTTLExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sample, ok := tt.parser.ParseLine(tt.line)
+			if !ok || !sample.Timeout {
+				t.Fatalf("ParseLine(%q) = %+v, %v; want a timeout", tt.line, sample, ok)
+			}
+			if sample.ErrorKind != tt.want {
+				t.Fatalf("ErrorKind = %v, want %v", sample.ErrorKind, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+// ErrInvalidSpec is returned for custom formats that cannot be used.
+var ErrInvalidSpec = errors.New("invalid parser spec")
+
+// Spec describes a custom ping output format for NewRegex.
+//
+// Example for a router that prints "!  seq 4  rtt 12.5":
+//
+//	parser:
+//	  reply: '^!\s+seq (?P<seq>\d+)\s+rtt (?P<time>[0-9.]+)'
+//	  timeout: '^\.'
+type Spec struct {
+	// Reply matches a successful reply. It must capture the RTT in
+	// milliseconds as the named group "time"; "seq" is optional.
+	Reply string `yaml:"reply"`
+	// Timeout matches a lost probe (optional).
+	Timeout string `yaml:"timeout"`
+}
+
+// Regex parses output with user-supplied regular expressions. It is safe
+// for concurrent use.
+type Regex struct {
+	replyPattern   *regexp.Regexp
+	timeoutPattern *regexp.Regexp // nil when timeouts are not reported
+	timeIndex      int
+	seqIndex       int // -1 without a "seq" group
+}
+
+// NewRegex compiles a custom format.
+func NewRegex(spec Spec) (*Regex, error) {
+	reply, err := regexp.Compile(spec.Reply)
+	if err != nil {
+		return nil, fmt.Errorf("%w: reply: %v", ErrInvalidSpec, err)
+	}
+	p := &Regex{
+		replyPattern: reply,
+		timeIndex:    reply.SubexpIndex("time"),
+		seqIndex:     reply.SubexpIndex("seq"),
+	}
+	if spec.Reply == "" || p.timeIndex < 0 {
+		return nil, fmt.Errorf("%w: reply must capture the RTT as (?P<time>...)", ErrInvalidSpec)
+	}
+	if spec.Timeout != "" {
+		if p.timeoutPattern, err = regexp.Compile(spec.Timeout); err != nil {
+			return nil, fmt.Errorf("%w: timeout: %v", ErrInvalidSpec, err)
+		}
+	}
+	return p, nil
+}
+
+// ParseLine implements Parser.
+func (p *Regex) ParseLine(line string) (types.Sample, bool) {
+	if matches := p.replyPattern.FindStringSubmatch(line); matches != nil {
+		rtt, err := parseDuration(matches[p.timeIndex])
+		if err != nil || matches[p.timeIndex] == "" {
+			return types.Sample{}, false
+		}
+		seq := -1
+		if p.seqIndex >= 0 {
+			if n, err := strconv.Atoi(matches[p.seqIndex]); err == nil {
+				seq = n
+			}
+		}
+		return types.Sample{
+			Timestamp: time.Now(),
+			Sequence:  seq,
+			RTT:       rtt,
+			Timeout:   false,
+		}, true
+	}
+
+	if p.timeoutPattern != nil && p.timeoutPattern.MatchString(line) {
+		return types.Sample{
+			Timestamp: time.Now(),
+			Sequence:  -1,
+			RTT:       0,
+			Timeout:   true,
+			ErrorKind: classifyError(line),
+		}, true
+	}
+
+	return types.Sample{}, false
+}
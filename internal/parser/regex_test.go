@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRegexParser(t *testing.T) {
+	p, err := NewRegex(Spec{
+		Reply:   `^!\s+seq (?P<seq>\d+)\s+rtt (?P<time>[0-9.]+)`,
+		Timeout: `^\.`,
+	})
+	if err != nil {
+		t.Fatalf("NewRegex() error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		line    string
+		wantOK  bool
+		wantSeq int
+		wantRTT time.Duration
+		wantTO  bool
+	}{
+		{name: "reply", line: "!  seq 4  rtt 12.5", wantOK: true, wantSeq: 4, wantRTT: 12500 * time.Microsecond},
+		{name: "timeout", line: ". seq 5", wantOK: true, wantSeq: -1, wantTO: true},
+		{name: "other", line: "Type escape sequence to abort.", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, ok := p.ParseLine(tt.line)
+			if ok != tt.wantOK {
+				t.Fatalf("ParseLine(%q) ok=%v, want %v", tt.line, ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if s.Sequence != tt.wantSeq || s.RTT != tt.wantRTT || s.Timeout != tt.wantTO {
+				t.Fatalf("ParseLine(%q) = %+v", tt.line, s)
+			}
+		})
+	}
+}
+
+func TestNewRegexRejectsInvalidSpecs(t *testing.T) {
+	for _, spec := range []Spec{
+		{},
+		{Reply: `time=([0-9.]+)`},
+		{Reply: `(?P<time>[0-9.]+`},
+		{Reply: `(?P<time>[0-9.]+)`, Timeout: `(`},
+	} {
+		if _, err := NewRegex(spec); !errors.Is(err, ErrInvalidSpec) {
+			t.Fatalf("NewRegex(%+v): expected ErrInvalidSpec, got %v", spec, err)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// timestampPattern matches the Unix time iputils ping -D prints before each
+// line, e.g. "[1700000000.123456] 64 bytes from ...".
+var timestampPattern = regexp.MustCompile(`^\[(\d+)\.(\d{1,9})\]\s*`)
+
+// SplitTimestamp splits the ping -D timestamp off line. It returns when ping
+// printed the line and the rest of it, or ok false and line unchanged when
+// the line carries no timestamp.
+func SplitTimestamp(line string) (ts time.Time, rest string, ok bool) {
+	m := timestampPattern.FindStringSubmatchIndex(line)
+	if m == nil {
+		return time.Time{}, line, false
+	}
+	sec, err := strconv.ParseInt(line[m[2]:m[3]], 10, 64)
+	if err != nil {
+		return time.Time{}, line, false
+	}
+	frac := line[m[4]:m[5]]
+	nsec, _ := strconv.Atoi(frac + "000000000"[len(frac):])
+	return time.Unix(sec, int64(nsec)), line[m[1]:], true
+}
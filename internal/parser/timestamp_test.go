@@ -0,0 +1,43 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSplitTimestamp(t *testing.T) {
+	tests := []struct {
+		line   string
+		want   time.Time
+		rest   string
+		wantOK bool
+	}{
+		{
+			line:   "[1700000000.123456] 64 bytes from 8.8.8.8: icmp_seq=1 ttl=118 time=14.3 ms",
+			want:   time.Unix(1700000000, 123456000),
+			rest:   "64 bytes from 8.8.8.8: icmp_seq=1 ttl=118 time=14.3 ms",
+			wantOK: true,
+		},
+		{
+			line:   "[1700000000.5] no answer yet for icmp_seq=2",
+			want:   time.Unix(1700000000, 500000000),
+			rest:   "no answer yet for icmp_seq=2",
+			wantOK: true,
+		},
+		{
+			line: "64 bytes from 8.8.8.8: icmp_seq=1 ttl=118 time=14.3 ms",
+			rest: "64 bytes from 8.8.8.8: icmp_seq=1 ttl=118 time=14.3 ms",
+		},
+		{
+			line: "[bracketed] text",
+			rest: "[bracketed] text",
+		},
+	}
+
+	for _, tt := range tests {
+		ts, rest, ok := SplitTimestamp(tt.line)
+		if ok != tt.wantOK || rest != tt.rest || !ts.Equal(tt.want) {
+			t.Fatalf("SplitTimestamp(%q) = %v, %q, %v, want %v, %q, %v", tt.line, ts, rest, ok, tt.want, tt.rest, tt.wantOK)
+		}
+	}
+}
@@ -21,9 +21,11 @@ func NewWindows() *Windows {
 	return &Windows{
 		// Windows format: Reply from x.x.x.x: bytes=32 time=14ms TTL=118
 		// Note: Windows may show time<1ms for very fast responses
-		replyPattern: regexp.MustCompile(`Reply from.*time[<=]?(\d+)\s*ms`),
+		// Display languages without an English message table translate the
+		// words, e.g. "Antwort von 8.8.8.8: Bytes=32 Zeit=14ms TTL=118"
+		replyPattern: regexp.MustCompile(replyWords + `.*` + timeWords + `\s*[<=]?\s*(\d+)\s*` + msUnits),
 		// Matches: Request timed out.
-		timeoutPattern: regexp.MustCompile(`(?i)request timed out|destination.*unreachable|transmit failed|general failure`),
+		timeoutPattern: regexp.MustCompile(`(?i)request timed out|destination.*unreachable|ttl expired|prohibited|transmit failed|general failure`),
 		seqCounter:     0,
 	}
 }
@@ -44,13 +46,14 @@ func (p *Windows) ParseLine(line string) (types.Sample, bool) {
 	}
 
 	// Check for timeout patterns
-	if p.timeoutPattern.MatchString(line) {
+	if p.timeoutPattern.MatchString(line) || localTimeoutPattern.MatchString(line) {
 		p.seqCounter++
 		return types.Sample{
 			Timestamp: time.Now(),
 			Sequence:  p.seqCounter,
 			RTT:       0,
 			Timeout:   true,
+			ErrorKind: classifyError(line),
 		}, true
 	}
 
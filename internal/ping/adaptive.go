@@ -0,0 +1,123 @@
+package ping
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/parser"
+)
+
+// Outage detection thresholds of Adaptive.
+const (
+	adaptiveOutageAfter  = 3 // Consecutive losses that start an outage
+	adaptiveRecoverAfter = 3 // Consecutive replies that end it
+)
+
+// Adaptive probes at a shorter interval during outages, to time the
+// recovery precisely, and returns to the configured interval afterwards.
+// Each switch restarts the underlying prober. Samples record the interval
+// they were taken at.
+type Adaptive struct {
+	interval  time.Duration
+	fast      time.Duration
+	newProber func(interval time.Duration) Prober
+
+	// Settings handed on to every prober that supports them
+	parser parser.Parser
+	misses *atomic.Uint64
+	debug  *DebugLog
+}
+
+// NewAdaptive creates an adaptive prober running newProber at interval,
+// or at fast during outages.
+func NewAdaptive(interval, fast time.Duration, newProber func(interval time.Duration) Prober) *Adaptive {
+	return &Adaptive{interval: interval, fast: fast, newProber: newProber}
+}
+
+// SetParser sets the parser of the underlying probers.
+func (a *Adaptive) SetParser(p parser.Parser) {
+	a.parser = p
+}
+
+// SetMissCounter sets the miss counter of the underlying probers.
+func (a *Adaptive) SetMissCounter(c *atomic.Uint64) {
+	a.misses = c
+}
+
+// SetDebugLog sets the raw output log of the underlying probers.
+func (a *Adaptive) SetDebugLog(l *DebugLog) {
+	a.debug = l
+}
+
+// Run probes until ctx is cancelled or the underlying prober stops.
+func (a *Adaptive) Run(ctx context.Context, samples chan<- Sample) error {
+	interval := a.interval
+	for {
+		next, err := a.runAt(ctx, interval, samples)
+		if next == 0 {
+			return err
+		}
+		interval = next
+	}
+}
+
+// runAt runs a prober at interval until the outage state changes, and
+// returns the interval to continue at. It returns 0 when ctx is cancelled
+// or the prober stopped on its own.
+func (a *Adaptive) runAt(ctx context.Context, interval time.Duration, samples chan<- Sample) (time.Duration, error) {
+	p := a.newProber(interval)
+	if ps, ok := p.(interface{ SetParser(parser.Parser) }); ok && a.parser != nil {
+		ps.SetParser(a.parser)
+	}
+	if mc, ok := p.(interface{ SetMissCounter(*atomic.Uint64) }); ok && a.misses != nil {
+		mc.SetMissCounter(a.misses)
+	}
+	if dl, ok := p.(interface{ SetDebugLog(*DebugLog) }); ok && a.debug != nil {
+		dl.SetDebugLog(a.debug)
+	}
+
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	probed := make(chan Sample)
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Run(runCtx, probed)
+	}()
+	// The prober must have stopped before the next one starts
+	restart := func(next time.Duration) (time.Duration, error) {
+		stop()
+		<-done
+		return next, nil
+	}
+
+	inOutage := interval == a.fast
+	streak := 0 // Consecutive samples pointing to the other state
+	for {
+		select {
+		case err := <-done:
+			return 0, err
+		case <-ctx.Done():
+			return restart(0)
+		case sample := <-probed:
+			sample.Interval = interval
+			select {
+			case samples <- sample:
+			case <-ctx.Done():
+				return restart(0)
+			}
+
+			if sample.Timeout != inOutage {
+				streak++
+			} else {
+				streak = 0
+			}
+			switch {
+			case !inOutage && streak >= adaptiveOutageAfter:
+				return restart(a.fast)
+			case inOutage && streak >= adaptiveRecoverAfter:
+				return restart(a.interval)
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package ping
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scriptedProber emits timeouts or replies until cancelled.
+type scriptedProber struct {
+	timeout bool
+}
+
+func (p scriptedProber) Run(ctx context.Context, samples chan<- Sample) error {
+	for {
+		select {
+		case samples <- Sample{Timeout: p.timeout, RTT: time.Millisecond}:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func TestAdaptiveShortensIntervalDuringOutage(t *testing.T) {
+	var mu sync.Mutex
+	var started []time.Duration
+	a := NewAdaptive(time.Second, 200*time.Millisecond, func(interval time.Duration) Prober {
+		mu.Lock()
+		defer mu.Unlock()
+		started = append(started, interval)
+		// The slow prober only sees an outage, the fast one the recovery
+		return scriptedProber{timeout: interval == time.Second}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	samples := make(chan Sample)
+	done := make(chan error, 1)
+	go func() { done <- a.Run(ctx, samples) }()
+
+	var got []Sample
+	for len(got) < 2*(adaptiveOutageAfter+adaptiveRecoverAfter) {
+		got = append(got, <-samples)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	for i, sample := range got {
+		want := time.Second
+		if i%6 >= adaptiveOutageAfter {
+			want = 200 * time.Millisecond
+		}
+		if sample.Interval != want || sample.Timeout != (want == time.Second) {
+			t.Fatalf("sample %d = %+v, want interval %v", i, sample, want)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(started) < 4 || started[0] != time.Second || started[1] != 200*time.Millisecond || started[2] != time.Second {
+		t.Fatalf("probers started at %v, want alternating 1s and 200ms", started)
+	}
+}
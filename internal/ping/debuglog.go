@@ -0,0 +1,69 @@
+package ping
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Output streams recorded by DebugLog.
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// DebugLog records the raw output lines of the ping command, each with its
+// time, stream, and whether the parser matched it, so unsupported output
+// formats can be reported. It is safe for concurrent use.
+type DebugLog struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+	now    func() time.Time
+}
+
+// NewDebugLog creates a debug log writing to w.
+func NewDebugLog(w io.Writer) *DebugLog {
+	return &DebugLog{w: w, now: time.Now}
+}
+
+// OpenDebugLog creates a debug log appending to the file at path.
+func OpenDebugLog(path string) (*DebugLog, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	l := NewDebugLog(f)
+	l.closer = f
+	return l, nil
+}
+
+// Line records one output line of stream.
+func (l *DebugLog) Line(stream, line string, matched bool) {
+	result := "miss"
+	if matched {
+		result = "match"
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.w == nil {
+		return
+	}
+	// Write errors are ignored; the log must never stall probing
+	_, _ = fmt.Fprintf(l.w, "%s %s %-5s %s\n", l.now().Format(time.RFC3339Nano), stream, result, line)
+}
+
+// Close stops recording and closes the file opened by OpenDebugLog.
+// Lines recorded afterwards are dropped.
+func (l *DebugLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w = nil
+	if l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
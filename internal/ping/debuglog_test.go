@@ -0,0 +1,50 @@
+package ping
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDebugLogLine(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewDebugLog(&buf)
+	l.now = func() time.Time { return time.Date(2026, 10, 17, 9, 30, 0, 123000000, time.UTC) }
+
+	l.Line(StreamStdout, "64 bytes from 8.8.8.8: icmp_seq=1 ttl=118 time=14.3 ms", true)
+	l.Line(StreamStderr, "ping: sendto: No route to host", false)
+
+	want := "2026-10-17T09:30:00.123Z stdout match 64 bytes from 8.8.8.8: icmp_seq=1 ttl=118 time=14.3 ms\n" +
+		"2026-10-17T09:30:00.123Z stderr miss  ping: sendto: No route to host\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("log=\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestOpenDebugLogAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ping.log")
+	for _, line := range []string{"first", "second"} {
+		l, err := OpenDebugLog(path)
+		if err != nil {
+			t.Fatalf("OpenDebugLog() error: %v", err)
+		}
+		l.Line(StreamStdout, line, false)
+		if err := l.Close(); err != nil {
+			t.Fatalf("Close() error: %v", err)
+		}
+		// Lines after Close are dropped, not written to a closed file
+		l.Line(StreamStdout, "late", false)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], " first") || !strings.HasSuffix(lines[1], " second") {
+		t.Fatalf("log lines=%q, want first and second", lines)
+	}
+}
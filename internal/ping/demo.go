@@ -0,0 +1,272 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/log"
+)
+
+// ErrInvalidDemo is returned for a malformed demo specification.
+var ErrInvalidDemo = errors.New("invalid demo specification")
+
+// DemoSpec shapes the synthetic samples of a Demo. Loss and brownouts come
+// in bursts, like on a real network, and take the given share of samples on
+// average.
+type DemoSpec struct {
+	Latency     time.Duration // Base RTT
+	Jitter      time.Duration // Typical variation above the base RTT
+	Loss        float64       // Percent of samples lost
+	BurstLen    int           // Mean samples per loss burst
+	Brownout    float64       // Percent of samples in a brownout
+	BrownoutRTT time.Duration // RTT added during a brownout
+	BrownoutLen int           // Mean samples per brownout
+	Seed        uint64        // Random seed; the same seed repeats the same samples (0 = random)
+}
+
+// DefaultDemoSpec returns a mostly healthy link with occasional short
+// outages and brownouts.
+func DefaultDemoSpec() DemoSpec {
+	return DemoSpec{
+		Latency:     20 * time.Millisecond,
+		Jitter:      3 * time.Millisecond,
+		Loss:        0.5,
+		BurstLen:    4,
+		Brownout:    1,
+		BrownoutRTT: 180 * time.Millisecond,
+		BrownoutLen: 20,
+	}
+}
+
+// ParseDemoSpec parses comma-separated KEY=VALUE settings overriding
+// DefaultDemoSpec, e.g. "latency=40ms,jitter=10ms,loss=2%,seed=7". Keys are
+// latency, jitter, loss, burst, brownout, brownout-rtt, brownout-len and
+// seed. An empty string returns the defaults.
+func ParseDemoSpec(s string) (DemoSpec, error) {
+	spec := DefaultDemoSpec()
+	if strings.TrimSpace(s) == "" {
+		return spec, nil
+	}
+	for _, field := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			return DemoSpec{}, fmt.Errorf("%w: %q: want KEY=VALUE", ErrInvalidDemo, field)
+		}
+		var err error
+		switch key {
+		case "latency":
+			spec.Latency, err = parseDemoDuration(value)
+		case "jitter":
+			spec.Jitter, err = parseDemoDuration(value)
+		case "loss":
+			spec.Loss, err = parseDemoPercent(value)
+		case "burst":
+			spec.BurstLen, err = parseDemoCount(value)
+		case "brownout":
+			spec.Brownout, err = parseDemoPercent(value)
+		case "brownout-rtt":
+			spec.BrownoutRTT, err = parseDemoDuration(value)
+		case "brownout-len":
+			spec.BrownoutLen, err = parseDemoCount(value)
+		case "seed":
+			spec.Seed, err = strconv.ParseUint(value, 10, 64)
+		default:
+			return DemoSpec{}, fmt.Errorf("%w: unknown key %q", ErrInvalidDemo, key)
+		}
+		if err != nil {
+			return DemoSpec{}, fmt.Errorf("%w: %s=%q", ErrInvalidDemo, key, value)
+		}
+	}
+	return spec, nil
+}
+
+func parseDemoDuration(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, ErrInvalidDemo
+	}
+	return d, nil
+}
+
+func parseDemoPercent(value string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil || v < 0 || v > 100 {
+		return 0, ErrInvalidDemo
+	}
+	return v, nil
+}
+
+func parseDemoCount(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, ErrInvalidDemo
+	}
+	return n, nil
+}
+
+// Demo generates synthetic samples from a DemoSpec once per interval,
+// without any network access: for screenshots, UI development and
+// repeatable tests of alerting rules.
+type Demo struct {
+	interval time.Duration
+	spec     DemoSpec
+	rng      *rand.Rand
+	logger   *slog.Logger
+
+	// Remaining samples of the current loss burst and brownout
+	lossLeft     int
+	brownoutLeft int
+
+	// Scenario being played, if any
+	phases    []demoPhase
+	phase     int // Index into phases, -1 before the first
+	phaseLeft int // Samples left in the current phase
+	loop      bool
+	speed     float64
+
+	probes int // Samples per interval; 0 and 1 emit one
+}
+
+// NewDemo creates a demo prober emitting a sample every interval.
+func NewDemo(interval time.Duration, spec DemoSpec) *Demo {
+	seed := spec.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	return &Demo{
+		interval: interval,
+		spec:     spec,
+		rng:      rand.New(rand.NewPCG(seed, seed)),
+		logger:   log.Discard(),
+		speed:    1,
+	}
+}
+
+// NewScenarioDemo creates a demo prober playing sc, with spec providing the
+// settings its phases leave out. Sample timestamps are simulated: they
+// advance by interval per sample however fast the scenario plays.
+func NewScenarioDemo(interval time.Duration, spec DemoSpec, sc Scenario) *Demo {
+	d := NewDemo(interval, spec)
+	d.phases = sc.phases(interval, spec)
+	d.phase = -1
+	d.loop = sc.Loop
+	d.speed = sc.speed()
+	return d
+}
+
+// SetLogger makes the demo log the phases of its scenario to l.
+func (d *Demo) SetLogger(l *slog.Logger) {
+	d.logger = l.With("component", "demo")
+}
+
+// SetBurst makes the demo emit n samples per interval sharing a sequence
+// number, as the Scheduler does.
+func (d *Demo) SetBurst(n int) {
+	d.probes = min(n, MaxBurst)
+}
+
+// Run emits samples until ctx is cancelled, the first one right away. A
+// scenario that does not loop ends after its last phase.
+func (d *Demo) Run(ctx context.Context, samples chan<- Sample) error {
+	ticker := time.NewTicker(max(1, time.Duration(float64(d.interval)/d.speed)))
+	defer ticker.Stop()
+
+	start := time.Now()
+	now := start
+	for seq := 1; d.advance(); seq++ {
+		if d.phases != nil {
+			now = start.Add(time.Duration(seq-1) * d.interval)
+		}
+		burst := max(1, d.probes)
+		for i := range burst {
+			sample := d.next(seq, now.Add(time.Duration(i)*burstSpacing))
+			if burst > 1 {
+				sample.Burst = burst
+			}
+			select {
+			case samples <- sample:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		select {
+		case now = <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return nil
+}
+
+// advance moves the scenario on by one sample, switching phases as they run
+// out. It reports false once a scenario without loop is over.
+func (d *Demo) advance() bool {
+	if d.phases == nil {
+		return true
+	}
+	if d.phaseLeft > 0 {
+		d.phaseLeft--
+		return true
+	}
+	next := d.phase + 1
+	if next == len(d.phases) {
+		if !d.loop {
+			d.logger.Info("demo scenario finished")
+			return false
+		}
+		next = 0
+	}
+	p := d.phases[next]
+	d.phase, d.phaseLeft, d.spec = next, p.samples-1, p.spec
+	d.lossLeft, d.brownoutLeft = 0, 0 // Bursts do not spill into the next phase
+	d.logger.Info("demo phase", "phase", p.name, "samples", p.samples)
+	return true
+}
+
+// next returns the sample with sequence seq taken at ts.
+func (d *Demo) next(seq int, ts time.Time) Sample {
+	sample := Sample{Timestamp: ts, Sequence: seq}
+
+	if d.lossLeft == 0 && d.start(d.spec.Loss, d.spec.BurstLen) {
+		d.lossLeft = d.burst(d.spec.BurstLen)
+	}
+	if d.brownoutLeft == 0 && d.start(d.spec.Brownout, d.spec.BrownoutLen) {
+		d.brownoutLeft = d.burst(d.spec.BrownoutLen)
+	}
+
+	rtt := d.spec.Latency + time.Duration(math.Abs(d.rng.NormFloat64())*float64(d.spec.Jitter))
+	if d.brownoutLeft > 0 {
+		d.brownoutLeft--
+		rtt += d.spec.BrownoutRTT
+	}
+	if d.lossLeft > 0 {
+		d.lossLeft--
+		sample.Timeout = true
+		return sample
+	}
+	sample.RTT = rtt
+	return sample
+}
+
+// start reports whether a burst of mean length meanLen starts at this sample,
+// so that percent of all samples are in one on average. Bursts follow each
+// other back to back at 100%.
+func (d *Demo) start(percent float64, meanLen int) bool {
+	if percent <= 0 {
+		return false
+	}
+	p := percent / 100
+	return d.rng.Float64() < p/(float64(max(1, meanLen))*(1-p)+p)
+}
+
+// burst returns the length of a burst, uniform around meanLen.
+func (d *Demo) burst(meanLen int) int {
+	return 1 + d.rng.IntN(max(1, 2*meanLen-1))
+}
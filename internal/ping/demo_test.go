@@ -0,0 +1,127 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseDemoSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    func(*DemoSpec)
+		wantErr bool
+	}{
+		{spec: ""},
+		{spec: "latency=40ms, jitter=10ms", want: func(s *DemoSpec) { s.Latency, s.Jitter = 40*time.Millisecond, 10*time.Millisecond }},
+		{spec: "loss=2%,burst=10", want: func(s *DemoSpec) { s.Loss, s.BurstLen = 2, 10 }},
+		{spec: "brownout=5,brownout-rtt=300ms,brownout-len=60", want: func(s *DemoSpec) {
+			s.Brownout, s.BrownoutRTT, s.BrownoutLen = 5, 300*time.Millisecond, 60
+		}},
+		{spec: "seed=42", want: func(s *DemoSpec) { s.Seed = 42 }},
+		{spec: "loss=101%", wantErr: true},
+		{spec: "burst=0", wantErr: true},
+		{spec: "latency=-1ms", wantErr: true},
+		{spec: "latency", wantErr: true},
+		{spec: "speed=fast", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseDemoSpec(tt.spec)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidDemo) {
+					t.Fatalf("ParseDemoSpec(%q) error = %v, want ErrInvalidDemo", tt.spec, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDemoSpec(%q) error = %v", tt.spec, err)
+			}
+			want := DefaultDemoSpec()
+			if tt.want != nil {
+				tt.want(&want)
+			}
+			if got != want {
+				t.Fatalf("ParseDemoSpec(%q) = %+v, want %+v", tt.spec, got, want)
+			}
+		})
+	}
+}
+
+func TestDemoSeedRepeats(t *testing.T) {
+	spec := DefaultDemoSpec()
+	spec.Seed = 7
+	a, b := NewDemo(time.Second, spec), NewDemo(time.Second, spec)
+	ts := time.Now()
+	for i := range 10000 {
+		if sa, sb := a.next(i, ts), b.next(i, ts); sa != sb {
+			t.Fatalf("sample %d differs for the same seed: %+v vs %+v", i, sa, sb)
+		}
+	}
+}
+
+func TestDemoShape(t *testing.T) {
+	spec := DemoSpec{
+		Latency:     20 * time.Millisecond,
+		Jitter:      2 * time.Millisecond,
+		Loss:        2,
+		BurstLen:    5,
+		Brownout:    4,
+		BrownoutRTT: 200 * time.Millisecond,
+		BrownoutLen: 10,
+		Seed:        1,
+	}
+	d := NewDemo(time.Second, spec)
+
+	const n = 200000
+	var lost, brownout, bursts int
+	prevLost := false
+	for i := range n {
+		s := d.next(i, time.Now())
+		switch {
+		case s.Timeout:
+			lost++
+			if !prevLost {
+				bursts++
+			}
+		case s.RTT >= spec.Latency+spec.BrownoutRTT:
+			brownout++
+		case s.RTT < spec.Latency:
+			t.Fatalf("RTT %v below the base latency", s.RTT)
+		}
+		prevLost = s.Timeout
+	}
+
+	if pct := float64(lost) / n * 100; pct < 1.5 || pct > 2.5 {
+		t.Fatalf("loss = %.2f%%, want about 2%%", pct)
+	}
+	if mean := float64(lost) / float64(bursts); mean < 3 {
+		t.Fatalf("mean loss burst = %.1f samples, want bursts of about 5", mean)
+	}
+	if pct := float64(brownout) / n * 100; pct < 3 || pct > 5 {
+		t.Fatalf("brownout = %.2f%%, want about 4%%", pct)
+	}
+}
+
+func TestDemoRun(t *testing.T) {
+	d := NewDemo(time.Hour, DefaultDemoSpec())
+	samples := make(chan Sample, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx, samples) }()
+
+	// The first sample comes right away, not after an interval
+	select {
+	case s := <-samples:
+		if s.Sequence != 1 {
+			t.Fatalf("first sample = %+v, want sequence 1", s)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no sample emitted")
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error = %v, want nil on cancel", err)
+	}
+}
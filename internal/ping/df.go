@@ -0,0 +1,55 @@
+package ping
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// withDF makes the single-shot probe command name send payload bytes of
+// ICMP data with the don't-fragment bit set: -M do on Linux, -D on macOS
+// and -f on Windows. IPv6 packets are never fragmented on the way, so for
+// them only the size is set.
+func withDF(goos, name string, args []string, target string, payload int) []string {
+	size := strconv.Itoa(payload)
+	switch {
+	case goos == "windows":
+		df := " -l " + size + " "
+		if !isIPv6Literal(target) {
+			df = " -f" + df
+		}
+		args = slices.Clone(args)
+		args[len(args)-1] = strings.Replace(args[len(args)-1], "ping -n 1 ", "ping -n 1"+df, 1)
+		return args
+	case goos == "darwin" && name == "ping":
+		return slices.Insert(args, len(args)-1, "-D", "-s", size)
+	case goos == "darwin":
+		return slices.Insert(args, len(args)-1, "-s", size)
+	default:
+		return slices.Insert(args, len(args)-1, "-M", "do", "-s", size)
+	}
+}
+
+// SetDontFragment makes the scheduler send payload bytes of ICMP data per
+// probe with the don't-fragment bit set, for path MTU discovery.
+func (s *Scheduler) SetDontFragment(payload int) {
+	s.payload = payload
+}
+
+// Probe sends one probe now and waits for its reply, for callers pacing
+// probes themselves. Only a failure to send probes at all is an error.
+func (s *Scheduler) Probe(ctx context.Context, seq int) (Sample, error) {
+	target := normalizeTarget(s.target)
+	if s.send != nil {
+		return s.send(ctx, target, seq, time.Now())
+	}
+	if runtime.GOOS == "windows" {
+		if err := validateWindowsTarget(target); err != nil {
+			return Sample{}, err
+		}
+	}
+	return s.probe(ctx, target, seq, time.Now())
+}
@@ -0,0 +1,56 @@
+package ping
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWithDF(t *testing.T) {
+	tests := []struct {
+		goos, name, target string
+		args, want         []string
+	}{
+		{"linux", "ping", "host", []string{"-c", "1", "host"}, []string{"-c", "1", "-M", "do", "-s", "1464", "host"}},
+		{"darwin", "ping", "host", []string{"-c", "1", "host"}, []string{"-c", "1", "-D", "-s", "1464", "host"}},
+		{"darwin", "ping6", "::1", []string{"-c", "1", "::1"}, []string{"-c", "1", "-s", "1464", "::1"}},
+		{"windows", "cmd.exe", "host", []string{"/C", "chcp 437 >nul & ping -n 1 -w 1000 host"},
+			[]string{"/C", "chcp 437 >nul & ping -n 1 -f -l 1464 -w 1000 host"}},
+		{"windows", "cmd.exe", "::1", []string{"/C", "chcp 437 >nul & ping -n 1 -w 1000 ::1"},
+			[]string{"/C", "chcp 437 >nul & ping -n 1 -l 1464 -w 1000 ::1"}},
+	}
+	for _, tt := range tests {
+		if got := withDF(tt.goos, tt.name, tt.args, tt.target, 1464); !slices.Equal(got, tt.want) {
+			t.Fatalf("withDF(%s, %s) = %q, want %q", tt.goos, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSchedulerProbeDontFragment(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("helper output uses unix-like ping format")
+	}
+
+	var args []string
+	factory := testCommandFactory("1472 bytes from 8.8.8.8: icmp_seq=1 ttl=118 time=14.3 ms", "", 0)
+	s := NewScheduler("8.8.8.8", time.Second)
+	s.cmdFactory = func(ctx context.Context, name string, a ...string) *exec.Cmd {
+		args = a
+		return factory(ctx, name, a...)
+	}
+	s.SetDontFragment(1444)
+
+	sample, err := s.Probe(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("Probe() error = %v", err)
+	}
+	if sample.Timeout || sample.Sequence != 7 {
+		t.Fatalf("sample = %+v, want a reply to sequence 7", sample)
+	}
+	if !slices.Contains(args, "1444") {
+		t.Fatalf("ping args %v lack the payload size", args)
+	}
+}
@@ -0,0 +1,32 @@
+package ping
+
+import "github.com/pbv7/pingheat/internal/types"
+
+// IP status codes reported by the Windows ICMP API (ipexport.h).
+const (
+	ipSuccess              = 0
+	ipDestNetUnreachable   = 11002
+	ipDestHostUnreachable  = 11003
+	ipDestProhibited       = 11004 // IP_DEST_PROT_UNREACHABLE for IPv4
+	ipReqTimedOut          = 11010
+	ipTTLExpiredTransit    = 11013
+	ipTTLExpiredReassembly = 11014
+)
+
+// icmpStatusKind maps an ICMP API status to the failure kind of a sample.
+func icmpStatusKind(status uint32) types.ErrorKind {
+	switch status {
+	case ipSuccess:
+		return types.ErrorNone
+	case ipDestNetUnreachable:
+		return types.ErrorNetUnreachable
+	case ipDestHostUnreachable:
+		return types.ErrorHostUnreachable
+	case ipDestProhibited:
+		return types.ErrorAdminProhibited
+	case ipTTLExpiredTransit, ipTTLExpiredReassembly:
+		return types.ErrorTTLExceeded
+	default: // ipReqTimedOut and local failures
+		return types.ErrorTimeout
+	}
+}
@@ -0,0 +1,27 @@
+package ping
+
+import (
+	"testing"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+func TestICMPStatusKind(t *testing.T) {
+	tests := []struct {
+		status uint32
+		want   types.ErrorKind
+	}{
+		{ipSuccess, types.ErrorNone},
+		{ipReqTimedOut, types.ErrorTimeout},
+		{ipDestNetUnreachable, types.ErrorNetUnreachable},
+		{ipDestHostUnreachable, types.ErrorHostUnreachable},
+		{ipDestProhibited, types.ErrorAdminProhibited},
+		{ipTTLExpiredTransit, types.ErrorTTLExceeded},
+		{11050, types.ErrorTimeout}, // IP_GENERAL_FAILURE
+	}
+	for _, tt := range tests {
+		if got := icmpStatusKind(tt.status); got != tt.want {
+			t.Fatalf("icmpStatusKind(%d) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package ping
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/pbv7/pingheat/internal/parser"
+	"github.com/pbv7/pingheat/internal/types"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	iphlpapi            = windows.NewLazySystemDLL("iphlpapi.dll")
+	procIcmpCreateFile  = iphlpapi.NewProc("IcmpCreateFile")
+	procIcmpCloseHandle = iphlpapi.NewProc("IcmpCloseHandle")
+	procIcmpSendEcho    = iphlpapi.NewProc("IcmpSendEcho")
+)
+
+// icmpPayload is the echo request data, the same 32 bytes ping.exe sends.
+var icmpPayload = []byte("abcdefghijklmnopqrstuvwabcdefghi")
+
+// ipOptionInformation mirrors IP_OPTION_INFORMATION.
+type ipOptionInformation struct {
+	TTL         uint8
+	TOS         uint8
+	Flags       uint8
+	OptionsSize uint8
+	OptionsData uintptr
+}
+
+// icmpEchoReply mirrors ICMP_ECHO_REPLY.
+type icmpEchoReply struct {
+	Address       uint32
+	Status        uint32
+	RoundTripTime uint32
+	DataSize      uint16
+	Reserved      uint16
+	Data          uintptr
+	Options       ipOptionInformation
+}
+
+// icmpReplySize fits one reply, its data and an ICMP error message.
+var icmpReplySize = int(unsafe.Sizeof(icmpEchoReply{})) + len(icmpPayload) + 8
+
+// ICMPProber sends echo requests through the Windows ICMP API. Unlike
+// ping.exe, which always waits a second between requests, it honors the
+// configured interval.
+type ICMPProber struct {
+	target   string
+	interval time.Duration
+	timeout  time.Duration // 0 = DefaultTimeout, or ping.exe's default after a fallback
+	parser   parser.Parser // Custom parser; forces the ping.exe fallback
+	debug    *DebugLog     // Raw output log of the ping.exe fallback
+	tos      int           // IPv4 TOS of the requests; 0 leaves them unmarked
+}
+
+// NewICMPProber creates a prober for target.
+func NewICMPProber(target string, interval time.Duration) *ICMPProber {
+	return &ICMPProber{target: target, interval: interval}
+}
+
+// SetTimeout sets how long a request waits for its reply before it counts
+// as lost (default DefaultTimeout).
+func (p *ICMPProber) SetTimeout(d time.Duration) {
+	p.timeout = d
+}
+
+// SetTOS marks the echo requests with an IPv4 TOS byte. Windows sends it
+// only where a QoS policy or the DisableUserTOSSetting registry value
+// allows, and not for the ping.exe fallback.
+func (p *ICMPProber) SetTOS(tos int) {
+	p.tos = tos
+}
+
+// SetParser makes the prober run ping.exe and parse its output with p,
+// for setups that rely on a custom output format.
+func (p *ICMPProber) SetParser(ps parser.Parser) {
+	p.parser = ps
+}
+
+// SetDebugLog records the output of the ping.exe fallback in l. The ICMP
+// API itself has no text output to record.
+func (p *ICMPProber) SetDebugLog(l *DebugLog) {
+	p.debug = l
+}
+
+// Run sends one echo request per interval until ctx is cancelled. Requests
+// overlap when replies take longer than the interval, so the schedule never
+// drifts. Targets without an IPv4 address fall back to ping.exe.
+func (p *ICMPProber) Run(ctx context.Context, samples chan<- Sample) error {
+	addr, ok := p.resolve(ctx)
+	if !ok || p.parser != nil {
+		r := NewRunner(p.target, p.interval)
+		r.SetTimeout(p.timeout)
+		if p.parser != nil {
+			r.SetParser(p.parser)
+		}
+		if p.debug != nil {
+			r.SetDebugLog(p.debug)
+		}
+		return r.Run(ctx, samples)
+	}
+
+	handle, _, err := procIcmpCreateFile.Call()
+	if windows.Handle(handle) == windows.InvalidHandle {
+		return fmt.Errorf("IcmpCreateFile: %w", err)
+	}
+	defer func() { _, _, _ = procIcmpCloseHandle.Call(handle) }()
+
+	// In-flight requests must finish before the handle is closed
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for seq := 1; ; seq++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sample := echo(handle, addr, seq, cmp.Or(p.timeout, DefaultTimeout), p.tos)
+			select {
+			case samples <- sample:
+			case <-ctx.Done():
+			}
+		}()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// resolve returns the first IPv4 address of the target in network byte order.
+func (p *ICMPProber) resolve(ctx context.Context) (uint32, bool) {
+	ips, err := net.DefaultResolver.LookupIP(ctx, "ip4", normalizeTarget(p.target))
+	if err != nil || len(ips) == 0 {
+		return 0, false
+	}
+	ip4 := ips[0].To4()
+	return *(*uint32)(unsafe.Pointer(&ip4[0])), true
+}
+
+// echo sends one echo request, marked with tos unless it is 0, and waits
+// for its reply.
+func echo(handle uintptr, addr uint32, seq int, timeout time.Duration, tos int) Sample {
+	reply := make([]byte, icmpReplySize)
+	var options *ipOptionInformation
+	if tos != 0 {
+		options = &ipOptionInformation{TTL: 128, TOS: uint8(tos)}
+	}
+	sent := time.Now()
+	ret, _, err := procIcmpSendEcho.Call(
+		handle,
+		uintptr(addr),
+		uintptr(unsafe.Pointer(&icmpPayload[0])),
+		uintptr(len(icmpPayload)),
+		uintptr(unsafe.Pointer(options)),
+		uintptr(unsafe.Pointer(&reply[0])),
+		uintptr(len(reply)),
+		uintptr(timeout.Milliseconds()),
+	)
+	// RoundTripTime only has millisecond resolution; the measured time
+	// is close enough and keeps sub-millisecond replies distinct
+	rtt := time.Since(sent)
+
+	sample := Sample{Timestamp: sent, Sequence: seq}
+	status := uint32(ipReqTimedOut)
+	if ret != 0 {
+		status = (*icmpEchoReply)(unsafe.Pointer(&reply[0])).Status
+	} else if errno, ok := err.(syscall.Errno); ok {
+		status = uint32(errno)
+	}
+	if kind := icmpStatusKind(status); kind != types.ErrorNone {
+		sample.Timeout = true
+		sample.ErrorKind = kind
+		return sample
+	}
+	sample.RTT = rtt
+	return sample
+}
@@ -0,0 +1,258 @@
+package ping
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/output"
+)
+
+// ModeExecPrefix starts a -mode that runs an external probe plugin, as in
+// exec:/usr/local/bin/sdwan-probe --site hq.
+const ModeExecPrefix = "exec:"
+
+// maxPluginStderr bounds the plugin stderr kept for error messages.
+const maxPluginStderr = 4096
+
+// Factory creates a prober sending one probe to target per interval.
+type Factory func(target string, interval time.Duration) Prober
+
+var (
+	pluginsMu sync.RWMutex
+	plugins   = make(map[string]Factory)
+)
+
+// Register makes a probe backend available as -mode name. Custom builds
+// call it from the init function of a package linked into the binary. It
+// panics if name is empty, contains a colon or is already taken.
+func Register(name string, factory Factory) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	if name == "" || strings.Contains(name, ":") || factory == nil {
+		panic(fmt.Sprintf("ping: invalid probe backend %q", name))
+	}
+	if _, dup := plugins[name]; dup || name == ModeICMP || name == ModeUDP {
+		panic(fmt.Sprintf("ping: probe backend %q registered twice", name))
+	}
+	plugins[name] = factory
+}
+
+// Modes returns the names of the built-in and registered probe modes.
+func Modes() []string {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	return append([]string{ModeICMP, ModeUDP}, slices.Sorted(maps.Keys(plugins))...)
+}
+
+// IsValidMode reports whether mode names a probe mode: a built-in one, a
+// registered backend or an exec: plugin.
+func IsValidMode(mode string) bool {
+	if mode == ModeICMP || mode == ModeUDP {
+		return true
+	}
+	if command, ok := strings.CutPrefix(mode, ModeExecPrefix); ok {
+		return strings.TrimSpace(command) != ""
+	}
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	_, ok := plugins[mode]
+	return ok
+}
+
+// NewPlugin returns the prober of a registered mode or an exec: plugin, or
+// false if mode names neither.
+func NewPlugin(mode, target string, interval time.Duration) (Prober, bool) {
+	if command, ok := strings.CutPrefix(mode, ModeExecPrefix); ok {
+		args := strings.Fields(command)
+		if len(args) == 0 {
+			return nil, false
+		}
+		return NewExec(args, target, interval), true
+	}
+	pluginsMu.RLock()
+	factory, ok := plugins[mode]
+	pluginsMu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return factory(target, interval), true
+}
+
+// Exec runs an external probe plugin. The plugin gets the target as its last
+// argument and PINGHEAT_TARGET, PINGHEAT_INTERVAL and PINGHEAT_TIMEOUT (Go
+// durations such as 1s; the timeout is empty unless set) in its
+// environment, probes until it is killed, and writes one JSON object per
+// probe to stdout in the format of -o json:
+//
+//	{"rtt_ms": 12.5}
+//	{"timeout": true, "error": "host_unreachable"}
+//
+// timestamp and seq are optional; samples without them are stamped when
+// read and numbered in order. Other lines are counted as parser misses.
+type Exec struct {
+	args       []string
+	target     string
+	interval   time.Duration
+	timeout    time.Duration
+	cmdFactory commandFactory
+	misses     *atomic.Uint64 // Lines that yielded no sample; nil disables counting
+	debug      *DebugLog      // Raw output log; nil disables it
+}
+
+// errNoResult is returned for a record that is neither a reply nor a loss.
+var errNoResult = errors.New("record has neither rtt_ms nor timeout")
+
+// NewExec creates a prober running the plugin command args.
+func NewExec(args []string, target string, interval time.Duration) *Exec {
+	return &Exec{
+		args:       args,
+		target:     target,
+		interval:   interval,
+		cmdFactory: exec.CommandContext,
+	}
+}
+
+// SetTimeout sets the reply timeout passed to the plugin (0 = its default).
+func (e *Exec) SetTimeout(d time.Duration) {
+	e.timeout = d
+}
+
+// SetMissCounter makes the prober count stdout lines that are not samples
+// in c.
+func (e *Exec) SetMissCounter(c *atomic.Uint64) {
+	e.misses = c
+}
+
+// SetDebugLog makes the prober record every stdout and stderr line of the
+// plugin in l.
+func (e *Exec) SetDebugLog(l *DebugLog) {
+	e.debug = l
+}
+
+// Run starts the plugin and sends its samples to the channel until the
+// context is cancelled or the plugin exits.
+func (e *Exec) Run(ctx context.Context, samples chan<- Sample) error {
+	args := append(slices.Clone(e.args[1:]), e.target)
+	cmd := e.cmdFactory(ctx, e.args[0], args...)
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	timeout := ""
+	if e.timeout > 0 {
+		timeout = e.timeout.String()
+	}
+	cmd.Env = append(cmd.Env,
+		"PINGHEAT_TARGET="+e.target,
+		"PINGHEAT_INTERVAL="+e.interval.String(),
+		"PINGHEAT_TIMEOUT="+timeout,
+	)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start probe plugin %q: %w", e.args[0], err)
+	}
+
+	// Wait closes the pipes, so both readers must finish first
+	var readers sync.WaitGroup
+	readers.Add(2)
+	go func() {
+		defer readers.Done()
+		seq := 0
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			line := scanner.Text()
+			sample, err := parseRecord(line, time.Now())
+			if e.debug != nil {
+				e.debug.Line(StreamStdout, line, err == nil)
+			}
+			if err != nil {
+				if e.misses != nil && strings.TrimSpace(line) != "" {
+					e.misses.Add(1)
+				}
+				continue
+			}
+			seq++
+			if sample.Sequence == 0 {
+				sample.Sequence = seq
+			}
+			select {
+			case samples <- sample:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var stderrBuf []byte
+	go func() {
+		defer readers.Done()
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if e.debug != nil {
+				e.debug.Line(StreamStderr, line, false)
+			}
+			if len(stderrBuf) < maxPluginStderr {
+				stderrBuf = append(stderrBuf, line...)
+				stderrBuf = append(stderrBuf, '\n')
+			}
+		}
+	}()
+
+	drained := make(chan struct{})
+	go func() {
+		readers.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-ctx.Done():
+	}
+	err = cmd.Wait()
+	if ctx.Err() != nil {
+		// Context was cancelled, not an error
+		return nil
+	}
+	if err != nil {
+		if len(stderrBuf) > 0 {
+			return fmt.Errorf("probe plugin failed: %w (stderr: %s)", err, strings.TrimSpace(string(stderrBuf)))
+		}
+		return fmt.Errorf("probe plugin %q failed: %w", e.args[0], err)
+	}
+	return nil
+}
+
+// parseRecord decodes one line of plugin output. A record without a
+// timestamp is stamped with now.
+func parseRecord(line string, now time.Time) (Sample, error) {
+	var rec output.Record
+	if err := json.Unmarshal([]byte(line), &rec); err != nil {
+		return Sample{}, err
+	}
+	if !rec.Timeout && rec.RTTMs == nil {
+		return Sample{}, errNoResult
+	}
+	sample := rec.Sample()
+	if sample.Timestamp.IsZero() {
+		sample.Timestamp = now
+	}
+	return sample, nil
+}
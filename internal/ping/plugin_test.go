@@ -0,0 +1,145 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+func TestRegister(t *testing.T) {
+	Register("test-sdwan", func(target string, interval time.Duration) Prober {
+		return NewDemo(interval, DemoSpec{})
+	})
+	t.Cleanup(func() {
+		pluginsMu.Lock()
+		delete(plugins, "test-sdwan")
+		pluginsMu.Unlock()
+	})
+
+	if !IsValidMode("test-sdwan") || !slices.Contains(Modes(), "test-sdwan") {
+		t.Fatalf("registered mode missing: valid=%v modes=%v", IsValidMode("test-sdwan"), Modes())
+	}
+	if p, ok := NewPlugin("test-sdwan", "hq", time.Second); !ok || p == nil {
+		t.Fatalf("NewPlugin(test-sdwan) = %v, %v", p, ok)
+	}
+	for _, name := range []string{"test-sdwan", ModeICMP, "bad:name"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("Register(%q) did not panic", name)
+				}
+			}()
+			Register(name, func(string, time.Duration) Prober { return nil })
+		}()
+	}
+}
+
+func TestIsValidMode(t *testing.T) {
+	tests := []struct {
+		mode string
+		want bool
+	}{
+		{ModeICMP, true},
+		{ModeUDP, true},
+		{"exec:/usr/bin/probe --site hq", true},
+		{"exec:", false},
+		{"exec:  ", false},
+		{"tcp", false},
+	}
+	for _, tt := range tests {
+		if got := IsValidMode(tt.mode); got != tt.want {
+			t.Fatalf("IsValidMode(%q) = %v, want %v", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestParseRecord(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		line    string
+		want    Sample
+		wantErr bool
+	}{
+		{line: `{"rtt_ms": 12.5}`, want: Sample{Timestamp: now, RTT: 12500 * time.Microsecond}},
+		{line: `{"timeout": true, "error": "host_unreachable", "seq": 7}`,
+			want: Sample{Timestamp: now, Sequence: 7, Timeout: true, ErrorKind: types.ErrorHostUnreachable}},
+		{line: `{"timestamp": "2024-01-02T03:04:05Z", "rtt_ms": 1}`,
+			want: Sample{Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), RTT: time.Millisecond}},
+		{line: `{"seq": 1}`, wantErr: true},
+		{line: `probing hq...`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseRecord(tt.line, now)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("parseRecord(%q) error = %v, wantErr %v", tt.line, err, tt.wantErr)
+		}
+		if !tt.wantErr && !got.Timestamp.Equal(tt.want.Timestamp) {
+			t.Fatalf("parseRecord(%q) timestamp = %v, want %v", tt.line, got.Timestamp, tt.want.Timestamp)
+		}
+		got.Timestamp = tt.want.Timestamp
+		if !tt.wantErr && got != tt.want {
+			t.Fatalf("parseRecord(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestExecRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("helper process output")
+	}
+
+	e := NewExec([]string{"probe", "--site", "hq"}, "example.com", time.Second)
+	e.SetTimeout(500 * time.Millisecond)
+	var args []string
+	var cmd *exec.Cmd
+	factory := testCommandFactory("starting\n{\"rtt_ms\": 14.3}\n{\"timeout\": true}\n", "", 0)
+	e.cmdFactory = func(ctx context.Context, name string, a ...string) *exec.Cmd {
+		args = append([]string{name}, a...)
+		cmd = factory(ctx, name, a...)
+		return cmd
+	}
+	var misses atomic.Uint64
+	e.SetMissCounter(&misses)
+
+	samples := make(chan Sample, 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := e.Run(ctx, samples); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if want := []string{"probe", "--site", "hq", "example.com"}; !slices.Equal(args, want) {
+		t.Fatalf("plugin args = %q, want %q", args, want)
+	}
+	if !slices.Contains(cmd.Env, "PINGHEAT_INTERVAL=1s") || !slices.Contains(cmd.Env, "PINGHEAT_TIMEOUT=500ms") {
+		t.Fatalf("plugin environment lacks the interval or timeout")
+	}
+	first, second := <-samples, <-samples
+	if first.RTT != 14300*time.Microsecond || first.Sequence != 1 || !second.Timeout || second.Sequence != 2 {
+		t.Fatalf("samples = %+v, %+v", first, second)
+	}
+	if n := misses.Load(); n != 1 {
+		t.Fatalf("misses = %d, want 1", n)
+	}
+}
+
+func TestExecRunFails(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("helper process output")
+	}
+
+	e := NewExec([]string{"probe"}, "example.com", time.Second)
+	e.cmdFactory = testCommandFactory("", "no API token", 1)
+	err := e.Run(context.Background(), make(chan Sample))
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || !strings.Contains(err.Error(), "no API token") {
+		t.Fatalf("Run() error = %v, want the exit status with stderr", err)
+	}
+}
@@ -0,0 +1,15 @@
+package ping
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultTimeout is how long a probe waits for its reply before it counts
+// as lost, where pingheat rather than the ping command decides.
+const DefaultTimeout = 2 * time.Second
+
+// Prober emits ping samples until the context is cancelled.
+type Prober interface {
+	Run(ctx context.Context, samples chan<- Sample) error
+}
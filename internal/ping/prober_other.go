@@ -0,0 +1,10 @@
+//go:build !windows
+
+package ping
+
+import "time"
+
+// NewProber returns the platform's prober: the system ping command.
+func NewProber(target string, interval time.Duration) Prober {
+	return NewRunner(target, interval)
+}
@@ -0,0 +1,9 @@
+package ping
+
+import "time"
+
+// NewProber returns the platform's prober: the ICMP API on Windows, where
+// ping.exe cannot send faster than once a second.
+func NewProber(target string, interval time.Duration) Prober {
+	return NewICMPProber(target, interval)
+}
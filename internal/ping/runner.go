@@ -3,13 +3,19 @@ package ping
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"net"
 	"os"
 	"os/exec"
 	"regexp"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pbv7/pingheat/internal/parser"
@@ -21,8 +27,20 @@ type Runner struct {
 	interval   time.Duration
 	parser     parser.Parser
 	cmdFactory commandFactory
+	misses     *atomic.Uint64 // Output lines that yielded no sample; nil disables counting
+	timeout    time.Duration  // Reply timeout passed to ping; 0 keeps its default
+	debug      *DebugLog      // Raw output log; nil disables it
+	tos        int            // IPv4 TOS or IPv6 traffic class of the probes; 0 leaves them unmarked
+	unstamped  atomic.Bool    // ping rejected -D, so lines carry no timestamps
 }
 
+// errNoTimestamps is returned by run when ping does not know -D.
+var errNoTimestamps = errors.New("ping does not support -D timestamps")
+
+// badOptionPattern matches the usage error of a ping without -D, such as
+// BusyBox's.
+var badOptionPattern = regexp.MustCompile(`(?i)(invalid|unrecognized|unknown|illegal) option`)
+
 // NewRunner creates a new ping runner.
 func NewRunner(target string, interval time.Duration) *Runner {
 	return &Runner{
@@ -36,6 +54,18 @@ func NewRunner(target string, interval time.Duration) *Runner {
 // Run starts the ping process and sends samples to the channel.
 // It blocks until the context is cancelled.
 func (r *Runner) Run(ctx context.Context, samples chan<- Sample) error {
+	err := r.run(ctx, samples)
+	if errors.Is(err, errNoTimestamps) {
+		// Fall back to stamping lines as they are read
+		r.unstamped.Store(true)
+		err = r.run(ctx, samples)
+	}
+	return err
+}
+
+// run runs ping once. On Linux it asks ping for -D timestamps unless an
+// earlier run found it does not support them.
+func (r *Runner) run(ctx context.Context, samples chan<- Sample) error {
 	var cmd *exec.Cmd
 	cmdFactory := r.commandFactory()
 	var cmdName string
@@ -50,13 +80,19 @@ func (r *Runner) Run(ctx context.Context, samples chan<- Sample) error {
 		if err := validateWindowsTarget(target); err != nil {
 			return err
 		}
-		cmdLine := "chcp 437 >nul & ping -t " + escapeCmdArg(target)
+		cmdLine := "chcp 437 >nul & ping -t "
+		if r.timeout > 0 {
+			cmdLine += "-w " + strconv.FormatInt(r.timeout.Milliseconds(), 10) + " "
+		}
+		cmdLine += escapeCmdArg(target)
 		cmdName = "cmd.exe"
 		args = []string{"/C", cmdLine}
 		cmd = cmdFactory(ctx, cmdName, args...)
 	} else {
 		// Linux/macOS: Force C locale for English output
-		cmdName, args = r.buildCommand(target)
+		stamp := runtime.GOOS == "linux" && !r.unstamped.Load()
+		cmdName, args = buildCommandForOS(runtime.GOOS, target, r.interval, r.timeout, stamp)
+		args = withTOS(runtime.GOOS, cmdName, args, r.tos)
 		cmd = cmdFactory(ctx, cmdName, args...)
 		if cmd.Env == nil {
 			cmd.Env = os.Environ()
@@ -79,17 +115,28 @@ func (r *Runner) Run(ctx context.Context, samples chan<- Sample) error {
 		return fmt.Errorf("failed to start ping command '%s %v': %w", cmdName, args, err)
 	}
 
+	// Wait closes the pipes, so both readers must finish first
+	var readers sync.WaitGroup
+	readers.Add(2)
+
 	// Read stdout in a goroutine
 	go func() {
+		defer readers.Done()
 		scanner := bufio.NewScanner(stdout)
 		for scanner.Scan() {
 			line := scanner.Text()
-			if sample, ok := r.parser.ParseLine(line); ok {
+			sample, text, ok := r.parseLine(line)
+			if r.debug != nil {
+				r.debug.Line(StreamStdout, line, ok)
+			}
+			if ok {
 				select {
 				case samples <- sample:
 				case <-ctx.Done():
 					return
 				}
+			} else if r.misses != nil && !parser.IsNoise(text) {
+				r.misses.Add(1)
 			}
 		}
 	}()
@@ -97,6 +144,7 @@ func (r *Runner) Run(ctx context.Context, samples chan<- Sample) error {
 	// Read stderr (mostly for debugging)
 	stderrBuf := make([]byte, 0, 1024)
 	go func() {
+		defer readers.Done()
 		scanner := bufio.NewScanner(stderr)
 		for scanner.Scan() {
 			line := scanner.Text()
@@ -104,7 +152,11 @@ func (r *Runner) Run(ctx context.Context, samples chan<- Sample) error {
 			stderrBuf = append(stderrBuf, '\n')
 
 			// Parse stderr too - some systems report timeouts here
-			if sample, ok := r.parser.ParseLine(line); ok {
+			sample, _, ok := r.parseLine(line)
+			if r.debug != nil {
+				r.debug.Line(StreamStderr, line, ok)
+			}
+			if ok {
 				select {
 				case samples <- sample:
 				case <-ctx.Done():
@@ -114,13 +166,26 @@ func (r *Runner) Run(ctx context.Context, samples chan<- Sample) error {
 		}
 	}()
 
-	// Wait for process to exit
+	// Wait for process to exit. A cancelled ping may leave a child holding
+	// the pipes open (cmd.exe on Windows), so only Wait can end the readers.
+	drained := make(chan struct{})
+	go func() {
+		readers.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-ctx.Done():
+	}
 	err = cmd.Wait()
 	if ctx.Err() != nil {
 		// Context was cancelled, not an error
 		return nil
 	}
 	if err != nil {
+		if slices.Contains(args, "-D") && badOptionPattern.Match(stderrBuf) {
+			return errNoTimestamps
+		}
 		// Include stderr output in the error message
 		if len(stderrBuf) > 0 {
 			return fmt.Errorf("ping command failed: %w (stderr: %s)", err, string(stderrBuf))
@@ -130,29 +195,89 @@ func (r *Runner) Run(ctx context.Context, samples chan<- Sample) error {
 	return nil
 }
 
-// buildCommand builds platform-specific ping command and arguments.
-func (r *Runner) buildCommand(target string) (string, []string) {
-	return buildCommandForOS(runtime.GOOS, target, r.interval)
+// parseLine parses a line of ping output and returns its sample and the
+// line without a -D timestamp. A stamped line takes its time from ping
+// rather than from when it was read, so output flushed in bursts keeps its
+// timeline; replies are stamped on receipt, so their RTT is taken off to get
+// the send time like other probers report.
+func (r *Runner) parseLine(line string) (Sample, string, bool) {
+	ts, text, stamped := parser.SplitTimestamp(line)
+	sample, ok := r.parser.ParseLine(text)
+	if ok && stamped {
+		sample.Timestamp = ts
+		if !sample.Timeout {
+			sample.Timestamp = ts.Add(-sample.RTT)
+		}
+	}
+	return sample, text, ok
+}
+
+// SetMissCounter makes the runner count stdout lines the parser did not
+// turn into a sample in c. Known banner and statistics lines are not
+// counted.
+func (r *Runner) SetMissCounter(c *atomic.Uint64) {
+	r.misses = c
+}
+
+// SetDebugLog makes the runner record every stdout and stderr line of
+// the ping command in l.
+func (r *Runner) SetDebugLog(l *DebugLog) {
+	r.debug = l
+}
+
+// SetParser replaces output format detection with p.
+func (r *Runner) SetParser(p parser.Parser) {
+	r.parser = p
+}
+
+// SetTimeout sets how long ping waits for each reply (0 = its default).
+func (r *Runner) SetTimeout(d time.Duration) {
+	r.timeout = d
+}
+
+// SetTOS marks the echo requests with an IPv4 TOS or IPv6 traffic class
+// byte (ping -Q on Linux, -z on macOS).
+func (r *Runner) SetTOS(tos int) {
+	r.tos = tos
 }
 
 // buildCommandForOS returns the ping command and args for a specific OS.
-func buildCommandForOS(goos, target string, interval time.Duration) (string, []string) {
+// A zero timeout keeps the ping command's default. With stamp, Linux ping
+// prints the Unix time before each line (-D); other systems ignore it.
+func buildCommandForOS(goos, target string, interval, timeout time.Duration, stamp bool) (string, []string) {
 	intervalSec := interval.Seconds()
+	ms := strconv.FormatInt(timeout.Milliseconds(), 10)
 
 	switch goos {
 	case "darwin":
 		// macOS: ping6 handles IPv6 literals; ping handles IPv4/hostnames.
+		// -W is in milliseconds; ping6 has no wait option.
 		if isIPv6Literal(target) {
 			return "ping6", []string{"-i", formatFloat(intervalSec), target}
 		}
-		return "ping", []string{"-i", formatFloat(intervalSec), target}
+		args := []string{"-i", formatFloat(intervalSec)}
+		if timeout > 0 {
+			args = append(args, "-W", ms)
+		}
+		return "ping", append(args, target)
 	case "windows":
 		// Windows: ping -t target (continuous ping)
 		// Windows doesn't support custom intervals well, so we use -t for continuous
-		return "ping", []string{"-t", target}
+		args := []string{"-t"}
+		if timeout > 0 {
+			args = append(args, "-w", ms)
+		}
+		return "ping", append(args, target)
 	default:
 		// Linux: ping -i interval target
-		args := []string{"-i", formatFloat(intervalSec), target}
+		args := []string{"-i", formatFloat(intervalSec)}
+		if stamp {
+			args = append([]string{"-D"}, args...)
+		}
+		if timeout > 0 {
+			args = append(args, "-W", waitSeconds(timeout))
+		}
+		args = append(args, target)
 		if isIPv6Literal(target) {
 			return "ping", append([]string{"-6"}, args...)
 		}
@@ -160,6 +285,12 @@ func buildCommandForOS(goos, target string, interval time.Duration) (string, []s
 	}
 }
 
+// waitSeconds formats a timeout for Linux ping -W, which takes whole seconds
+// on older iputils and BusyBox.
+func waitSeconds(timeout time.Duration) string {
+	return strconv.Itoa(int(math.Max(1, math.Ceil(timeout.Seconds()))))
+}
+
 // formatFloat formats a float with minimal precision.
 func formatFloat(f float64) string {
 	if f == float64(int(f)) {
@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"reflect"
 	"runtime"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -64,6 +67,8 @@ func TestBuildCommandForOS(t *testing.T) {
 		name     string
 		goos     string
 		target   string
+		timeout  time.Duration
+		stamp    bool
 		wantCmd  string
 		wantArgs []string
 	}{
@@ -102,11 +107,51 @@ func TestBuildCommandForOS(t *testing.T) {
 			wantCmd:  "ping",
 			wantArgs: []string{"-t", "example.com"},
 		},
+		{
+			name:     "darwin-timeout",
+			goos:     "darwin",
+			target:   "192.0.2.1",
+			timeout:  1500 * time.Millisecond,
+			wantCmd:  "ping",
+			wantArgs: []string{"-i", "1", "-W", "1500", "192.0.2.1"},
+		},
+		{
+			name:     "linux-timeout",
+			goos:     "linux",
+			target:   "2001:db8::1",
+			timeout:  1500 * time.Millisecond,
+			wantCmd:  "ping",
+			wantArgs: []string{"-6", "-i", "1", "-W", "2", "2001:db8::1"},
+		},
+		{
+			name:     "linux-stamp",
+			goos:     "linux",
+			target:   "192.0.2.1",
+			stamp:    true,
+			wantCmd:  "ping",
+			wantArgs: []string{"-D", "-i", "1", "192.0.2.1"},
+		},
+		{
+			name:     "darwin-stamp",
+			goos:     "darwin",
+			target:   "192.0.2.1",
+			stamp:    true,
+			wantCmd:  "ping",
+			wantArgs: []string{"-i", "1", "192.0.2.1"},
+		},
+		{
+			name:     "windows-timeout",
+			goos:     "windows",
+			target:   "example.com",
+			timeout:  500 * time.Millisecond,
+			wantCmd:  "ping",
+			wantArgs: []string{"-t", "-w", "500", "example.com"},
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			cmd, args := buildCommandForOS(tc.goos, tc.target, interval)
+			cmd, args := buildCommandForOS(tc.goos, tc.target, interval, tc.timeout, tc.stamp)
 			if cmd != tc.wantCmd {
 				t.Fatalf("buildCommandForOS cmd = %q, want %q", cmd, tc.wantCmd)
 			}
@@ -147,8 +192,11 @@ func TestRunnerRunParsesOutput(t *testing.T) {
 	}
 
 	stdoutLines := []string{
+		"PING 8.8.8.8 (8.8.8.8): 56 data bytes",
 		"64 bytes from 8.8.8.8: icmp_seq=1 ttl=118 time=14.3 ms",
 		"Request timeout for icmp_seq 2",
+		"reply 3 from 8.8.8.8 in 14.3",
+		"--- 8.8.8.8 ping statistics ---",
 	}
 	stdout := strings.Join(stdoutLines, "\n")
 
@@ -158,6 +206,8 @@ func TestRunnerRunParsesOutput(t *testing.T) {
 		parser:     parser.New(),
 		cmdFactory: testCommandFactory(stdout, "", 0),
 	}
+	var misses atomic.Uint64
+	r.SetMissCounter(&misses)
 
 	samples := make(chan Sample, 2)
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -190,6 +240,87 @@ func TestRunnerRunParsesOutput(t *testing.T) {
 	if !hasTimeout || !hasSuccess {
 		t.Fatalf("expected both timeout and success samples, got: %+v", got)
 	}
+	// Banner and statistics lines are known; only the garbled reply is a miss
+	deadline := time.Now().Add(2 * time.Second)
+	for misses.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := misses.Load(); n != 1 {
+		t.Fatalf("misses=%d, want 1", n)
+	}
+}
+
+func TestRunnerTimestamps(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("helper output uses unix-like ping format")
+	}
+
+	// Both lines were flushed together, long after ping printed them
+	r := &Runner{
+		target:   "example.com",
+		interval: time.Second,
+		parser:   parser.New(),
+		cmdFactory: testCommandFactory(
+			"[1700000000.250000] 64 bytes from 8.8.8.8: icmp_seq=1 ttl=118 time=50.0 ms\n"+
+				"[1700000002.000000] no answer yet for icmp_seq=2", "", 0),
+	}
+	samples := make(chan Sample, 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := r.Run(ctx, samples); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	// A reply is stamped when it arrives; its sample records the send time
+	for _, want := range []time.Time{time.Unix(1700000000, 200_000_000), time.Unix(1700000002, 0)} {
+		select {
+		case s := <-samples:
+			if !s.Timestamp.Equal(want) {
+				t.Fatalf("sample %d timestamp = %v, want %v", s.Sequence, s.Timestamp, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for samples")
+		}
+	}
+}
+
+func TestRunnerWithoutTimestamps(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("only Linux ping is asked for -D timestamps")
+	}
+
+	// Like BusyBox ping, reject -D and otherwise reply
+	var calls [][]string
+	r := &Runner{
+		target:   "example.com",
+		interval: time.Second,
+		parser:   parser.New(),
+		cmdFactory: func(ctx context.Context, name string, args ...string) *exec.Cmd {
+			calls = append(calls, args)
+			if slices.Contains(args, "-D") {
+				return testCommandFactory("", "ping: invalid option -- 'D'", 1)(ctx, name, args...)
+			}
+			return testCommandFactory("64 bytes from 8.8.8.8: icmp_seq=1 ttl=118 time=14.3 ms", "", 0)(ctx, name, args...)
+		},
+	}
+	samples := make(chan Sample, 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := r.Run(ctx, samples); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if len(calls) != 2 || slices.Contains(calls[1], "-D") {
+		t.Fatalf("ping calls = %q, want a retry without -D", calls)
+	}
+
+	// Later runs skip -D straight away
+	calls = nil
+	if err := r.Run(ctx, samples); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if len(calls) != 1 || slices.Contains(calls[0], "-D") {
+		t.Fatalf("ping calls = %q, want one without -D", calls)
+	}
 }
 
 func testCommandFactory(stdout, stderr string, exitCode int) commandFactory {
@@ -197,6 +328,7 @@ func testCommandFactory(stdout, stderr string, exitCode int) commandFactory {
 		cmd := exec.CommandContext(ctx, os.Args[0], "-test.run=TestHelperProcess", "--")
 		cmd.Env = append(os.Environ(),
 			"GO_WANT_HELPER_PROCESS=1",
+			"GORACE=atexit_sleep_ms=0", // Exit promptly under -race
 			"PINGHELPER_STDOUT="+stdout,
 			"PINGHELPER_STDERR="+stderr,
 			fmt.Sprintf("PINGHELPER_EXIT=%d", exitCode),
@@ -223,3 +355,60 @@ func TestHelperProcess(t *testing.T) {
 	}
 	os.Exit(exitCode)
 }
+
+func TestRunnerDebugLog(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("helper output uses unix-like ping format")
+	}
+
+	path := filepath.Join(t.TempDir(), "ping.log")
+	debug, err := OpenDebugLog(path)
+	if err != nil {
+		t.Fatalf("OpenDebugLog() error: %v", err)
+	}
+	defer func() { _ = debug.Close() }()
+
+	r := &Runner{
+		target:   "example.com",
+		interval: time.Second,
+		parser:   parser.New(),
+		cmdFactory: testCommandFactory(
+			"PING 8.8.8.8 (8.8.8.8): 56 data bytes\n64 bytes from 8.8.8.8: icmp_seq=1 ttl=118 time=14.3 ms",
+			"ping: warning: odd output", 0),
+	}
+	r.SetDebugLog(debug)
+
+	samples := make(chan Sample, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := r.Run(ctx, samples); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	want := []string{
+		"stdout miss  PING 8.8.8.8 (8.8.8.8): 56 data bytes",
+		"stdout match 64 bytes from 8.8.8.8: icmp_seq=1 ttl=118 time=14.3 ms",
+		"stderr miss  ping: warning: odd output",
+	}
+	// The readers may still be draining the pipes after Run returns
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile() error: %v", err)
+		}
+		missing := 0
+		for _, w := range want {
+			if !strings.Contains(string(data), " "+w+"\n") {
+				missing++
+			}
+		}
+		if missing == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("debug log=\n%s\nwant lines %q", data, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -1,6 +1,66 @@
 package ping
 
-import "github.com/pbv7/pingheat/internal/types"
+import (
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
 
 // Sample is an alias for types.Sample.
 type Sample = types.Sample
+
+// Sample record flags.
+const (
+	flagTimeout = 1 << iota
+	flagMaintenance
+	flagClockJump
+)
+
+// SampleCodec encodes samples as fixed 32-byte records for file-backed
+// history: timestamp (Unix ns), sequence, RTT (ns), flags, error kind,
+// probe interval (ms), burst size and Wi-Fi signal (dBm).
+type SampleCodec struct{}
+
+// Size implements buffer.Codec.
+func (SampleCodec) Size() int { return 32 }
+
+// Encode implements buffer.Codec.
+func (SampleCodec) Encode(dst []byte, s Sample) {
+	binary.LittleEndian.PutUint64(dst[0:], uint64(s.Timestamp.UnixNano()))
+	binary.LittleEndian.PutUint64(dst[8:], uint64(s.Sequence))
+	binary.LittleEndian.PutUint64(dst[16:], uint64(s.RTT))
+	var flags byte
+	if s.Timeout {
+		flags |= flagTimeout
+	}
+	if s.Maintenance {
+		flags |= flagMaintenance
+	}
+	if s.ClockJump {
+		flags |= flagClockJump
+	}
+	dst[24] = flags
+	dst[25] = byte(s.ErrorKind)
+	binary.LittleEndian.PutUint32(dst[26:], uint32(s.Interval.Milliseconds()))
+	dst[30] = byte(min(s.Burst, MaxBurst))
+	dst[31] = byte(int8(max(s.Signal, math.MinInt8)))
+}
+
+// Decode implements buffer.Codec.
+func (SampleCodec) Decode(src []byte) Sample {
+	flags := src[24]
+	return Sample{
+		Timestamp:   time.Unix(0, int64(binary.LittleEndian.Uint64(src[0:]))),
+		Sequence:    int(int64(binary.LittleEndian.Uint64(src[8:]))),
+		RTT:         time.Duration(binary.LittleEndian.Uint64(src[16:])),
+		Timeout:     flags&flagTimeout != 0,
+		Maintenance: flags&flagMaintenance != 0,
+		ClockJump:   flags&flagClockJump != 0,
+		ErrorKind:   types.ErrorKind(src[25]),
+		Interval:    time.Duration(binary.LittleEndian.Uint32(src[26:])) * time.Millisecond,
+		Burst:       int(src[30]),
+		Signal:      int(int8(src[31])),
+	}
+}
@@ -0,0 +1,26 @@
+package ping
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+func TestSampleCodecRoundTrip(t *testing.T) {
+	codec := SampleCodec{}
+	tests := []Sample{
+		{Timestamp: time.Unix(1700000000, 123456789), Sequence: 42, RTT: 14300 * time.Microsecond, Burst: 5, Signal: -67},
+		{Timestamp: time.Unix(1700000001, 0), Sequence: 43, Timeout: true, Maintenance: true, ClockJump: true, ErrorKind: types.ErrorTTLExceeded, Interval: 200 * time.Millisecond},
+	}
+	for _, want := range tests {
+		buf := make([]byte, codec.Size())
+		codec.Encode(buf, want)
+		got := codec.Decode(buf)
+		if !got.Timestamp.Equal(want.Timestamp) || got.Sequence != want.Sequence || got.RTT != want.RTT ||
+			got.Timeout != want.Timeout || got.Maintenance != want.Maintenance || got.ClockJump != want.ClockJump || got.ErrorKind != want.ErrorKind || got.Interval != want.Interval ||
+			got.Burst != want.Burst || got.Signal != want.Signal {
+			t.Fatalf("Decode(Encode(%+v)) = %+v", want, got)
+		}
+	}
+}
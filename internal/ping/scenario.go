@@ -0,0 +1,183 @@
+package ping
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"go.yaml.in/yaml/v2"
+)
+
+// ErrInvalidScenario is returned for a malformed demo scenario.
+var ErrInvalidScenario = errors.New("invalid demo scenario")
+
+// maxScenarioSpeed bounds Scenario.Speed so the ticker stays meaningful.
+const maxScenarioSpeed = 3600
+
+// Scenario is a scripted timeline of network conditions for a Demo, to check
+// that alert rules and SLO definitions fire when they should.
+//
+// Example:
+//
+//	speed: 60        # an hour plays in a minute
+//	loop: false      # end the session after the last phase
+//	phases:
+//	  - name: healthy
+//	    duration: 10m
+//	  - name: brownout
+//	    duration: 5m
+//	    latency: 150ms
+//	    jitter: 30ms
+//	  - name: outage
+//	    duration: 2m
+//	    loss: 100%
+//	  - name: recovery
+//	    duration: 10m
+type Scenario struct {
+	// Simulated time per real time: sample timestamps advance by the
+	// interval while samples are emitted Speed times faster (0 = 1)
+	Speed float64 `yaml:"speed"`
+
+	// Start over after the last phase instead of ending the probe loop
+	Loop bool `yaml:"loop"`
+
+	Phases []Phase `yaml:"phases"`
+}
+
+// Phase is a stretch of a Scenario. Settings left out keep the value of the
+// -demo specification, not of the previous phase.
+type Phase struct {
+	Name        string         `yaml:"name"`
+	Duration    time.Duration  `yaml:"duration"`
+	Latency     *time.Duration `yaml:"latency"`
+	Jitter      *time.Duration `yaml:"jitter"`
+	Loss        *Percent       `yaml:"loss"`
+	Burst       *int           `yaml:"burst"`
+	Brownout    *Percent       `yaml:"brownout"`
+	BrownoutRTT *time.Duration `yaml:"brownout-rtt"`
+	BrownoutLen *int           `yaml:"brownout-len"`
+}
+
+// Percent is a share from 0 to 100, written in YAML as 5% or 5.
+type Percent float64
+
+// UnmarshalYAML accepts a number with an optional percent sign.
+func (p *Percent) UnmarshalYAML(unmarshal func(any) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	v, err := parseDemoPercent(s)
+	if err != nil {
+		return fmt.Errorf("percent %q: want a number from 0 to 100", s)
+	}
+	*p = Percent(v)
+	return nil
+}
+
+// LoadScenario reads and validates a scenario file.
+func LoadScenario(path string) (Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Scenario{}, err
+	}
+	sc, err := ParseScenario(data)
+	if err != nil {
+		return Scenario{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return sc, nil
+}
+
+// ParseScenario parses and validates a YAML scenario.
+func ParseScenario(data []byte) (Scenario, error) {
+	var sc Scenario
+	if err := yaml.UnmarshalStrict(data, &sc); err != nil {
+		return Scenario{}, fmt.Errorf("%w: %v", ErrInvalidScenario, err)
+	}
+	if err := sc.validate(); err != nil {
+		return Scenario{}, err
+	}
+	return sc, nil
+}
+
+// validate rejects values ParseDemoSpec would also reject.
+func (sc Scenario) validate() error {
+	if sc.Speed < 0 || sc.Speed > maxScenarioSpeed {
+		return fmt.Errorf("%w: speed %g: want 0 to %d", ErrInvalidScenario, sc.Speed, maxScenarioSpeed)
+	}
+	if len(sc.Phases) == 0 {
+		return fmt.Errorf("%w: no phases", ErrInvalidScenario)
+	}
+	for i, p := range sc.Phases {
+		name := p.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		switch {
+		case p.Duration <= 0:
+			return fmt.Errorf("%w: phase %s: duration must be positive", ErrInvalidScenario, name)
+		case negative(p.Latency), negative(p.Jitter), negative(p.BrownoutRTT):
+			return fmt.Errorf("%w: phase %s: durations must not be negative", ErrInvalidScenario, name)
+		case p.Burst != nil && *p.Burst < 1, p.BrownoutLen != nil && *p.BrownoutLen < 1:
+			return fmt.Errorf("%w: phase %s: burst lengths must be at least 1", ErrInvalidScenario, name)
+		}
+	}
+	return nil
+}
+
+func negative(d *time.Duration) bool {
+	return d != nil && *d < 0
+}
+
+// speed returns Speed with the default applied.
+func (sc Scenario) speed() float64 {
+	if sc.Speed == 0 {
+		return 1
+	}
+	return sc.Speed
+}
+
+// apply returns base with the settings of the phase.
+func (p Phase) apply(base DemoSpec) DemoSpec {
+	spec := base
+	set(&spec.Latency, p.Latency)
+	set(&spec.Jitter, p.Jitter)
+	if p.Loss != nil {
+		spec.Loss = float64(*p.Loss)
+	}
+	set(&spec.BurstLen, p.Burst)
+	if p.Brownout != nil {
+		spec.Brownout = float64(*p.Brownout)
+	}
+	set(&spec.BrownoutRTT, p.BrownoutRTT)
+	set(&spec.BrownoutLen, p.BrownoutLen)
+	return spec
+}
+
+func set[T any](dst *T, v *T) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+// demoPhase is a Phase resolved against the demo's interval and base spec.
+type demoPhase struct {
+	name    string
+	samples int
+	spec    DemoSpec
+}
+
+// phases resolves the scenario for samples taken every interval.
+func (sc Scenario) phases(interval time.Duration, base DemoSpec) []demoPhase {
+	phases := make([]demoPhase, len(sc.Phases))
+	for i, p := range sc.Phases {
+		name := p.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		samples := int((p.Duration + interval - 1) / interval)
+		phases[i] = demoPhase{name: name, samples: max(1, samples), spec: p.apply(base)}
+	}
+	return phases
+}
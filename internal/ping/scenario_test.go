@@ -0,0 +1,128 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseScenario(t *testing.T) {
+	sc, err := ParseScenario([]byte(`
+speed: 60
+phases:
+  - name: healthy
+    duration: 10m
+  - name: outage
+    duration: 2m
+    loss: 100%
+    latency: 150ms
+  - duration: 1m
+    brownout: 5
+    brownout-len: 30
+`))
+	if err != nil {
+		t.Fatalf("ParseScenario() error = %v", err)
+	}
+	if sc.Speed != 60 || sc.Loop || len(sc.Phases) != 3 {
+		t.Fatalf("scenario = %+v", sc)
+	}
+	outage := sc.Phases[1]
+	if outage.Duration != 2*time.Minute || *outage.Loss != 100 || *outage.Latency != 150*time.Millisecond {
+		t.Fatalf("outage phase = %+v", outage)
+	}
+
+	base := DefaultDemoSpec()
+	if got := sc.Phases[0].apply(base); got != base {
+		t.Fatalf("phase without settings = %+v, want the base %+v", got, base)
+	}
+	got := sc.Phases[2].apply(base)
+	if got.Brownout != 5 || got.BrownoutLen != 30 || got.Loss != base.Loss {
+		t.Fatalf("phase = %+v, want brownout settings over the base", got)
+	}
+}
+
+func TestParseScenarioInvalid(t *testing.T) {
+	tests := map[string]string{
+		"no phases":          "speed: 2\n",
+		"no duration":        "phases: [{name: a}]\n",
+		"negative latency":   "phases: [{duration: 1m, latency: -1ms}]\n",
+		"loss over 100":      "phases: [{duration: 1m, loss: 150%}]\n",
+		"zero burst":         "phases: [{duration: 1m, burst: 0}]\n",
+		"speed out of range": "speed: 100000\nphases: [{duration: 1m}]\n",
+		"unknown key":        "phases: [{duration: 1m, rtt: 5ms}]\n",
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseScenario([]byte(data)); !errors.Is(err, ErrInvalidScenario) {
+				t.Fatalf("ParseScenario(%q) error = %v, want ErrInvalidScenario", data, err)
+			}
+		})
+	}
+}
+
+func TestScenarioDemoPhases(t *testing.T) {
+	loss := Percent(100)
+	latency := 200 * time.Millisecond
+	sc := Scenario{Phases: []Phase{
+		{Name: "healthy", Duration: 3 * time.Second},
+		{Name: "outage", Duration: 2 * time.Second, Loss: &loss},
+		{Name: "slow", Duration: 1500 * time.Millisecond, Latency: &latency},
+	}}
+	spec := DefaultDemoSpec()
+	spec.Loss, spec.Brownout, spec.Jitter = 0, 0, 0
+	d := NewScenarioDemo(time.Second, spec, sc)
+
+	var got []Sample
+	for seq := 1; d.advance(); seq++ {
+		got = append(got, d.next(seq, time.Time{}))
+	}
+
+	// 3 healthy, 2 lost, and the slow phase rounded up to 2 samples
+	if len(got) != 7 {
+		t.Fatalf("scenario produced %d samples, want 7", len(got))
+	}
+	for i, s := range got {
+		switch {
+		case i < 3 && (s.Timeout || s.RTT != spec.Latency):
+			t.Fatalf("sample %d = %+v, want a healthy reply", i, s)
+		case i >= 3 && i < 5 && !s.Timeout:
+			t.Fatalf("sample %d = %+v, want a timeout during the outage", i, s)
+		case i >= 5 && s.RTT != latency:
+			t.Fatalf("sample %d = %+v, want the slow phase's latency", i, s)
+		}
+	}
+}
+
+func TestScenarioDemoLoops(t *testing.T) {
+	sc := Scenario{Loop: true, Phases: []Phase{{Duration: time.Second}}}
+	d := NewScenarioDemo(time.Second, DefaultDemoSpec(), sc)
+	for i := range 10 {
+		if !d.advance() {
+			t.Fatalf("looping scenario ended after %d samples", i)
+		}
+	}
+}
+
+func TestScenarioDemoRun(t *testing.T) {
+	// At full speed the interval between samples is a millisecond or less
+	sc := Scenario{Speed: maxScenarioSpeed, Phases: []Phase{{Duration: 2 * time.Second}}}
+	d := NewScenarioDemo(time.Second, DefaultDemoSpec(), sc)
+	samples := make(chan Sample, 10)
+
+	if err := d.Run(context.Background(), samples); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	close(samples)
+
+	var got []Sample
+	for s := range samples {
+		got = append(got, s)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Run() emitted %d samples, want 2", len(got))
+	}
+	if gap := got[1].Timestamp.Sub(got[0].Timestamp); gap != time.Second {
+		t.Fatalf("timestamps %v apart, want the simulated interval", gap)
+	}
+}
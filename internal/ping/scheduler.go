@@ -0,0 +1,303 @@
+package ping
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/parser"
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+// processGrace is how long a single-shot ping may run past its timeout,
+// for process start-up and name resolution, before it is killed.
+const processGrace = time.Second
+
+// MaxBurst is the most probes sent per interval with SetBurst.
+const MaxBurst = 16
+
+// burstSpacing separates the probes of a burst so they do not queue behind
+// each other on the first hop; short intervals space them more tightly.
+const burstSpacing = 10 * time.Millisecond
+
+// Scheduler issues one single-shot ping per interval tick instead of
+// relying on the ping command's own timing. Samples are evenly spaced,
+// carry the tick's timestamp and sequence number, and a probe without a
+// reply within the timeout is recorded as loss.
+type Scheduler struct {
+	target     string
+	interval   time.Duration
+	timeout    time.Duration
+	cmdFactory commandFactory
+	misses     *atomic.Uint64 // Output lines that yielded no sample; nil disables counting
+	debug      *DebugLog      // Raw output log; nil disables it
+	burst      int            // Probes per tick; 0 and 1 send one
+	tos        int            // IPv4 TOS or IPv6 traffic class of the probes; 0 leaves them unmarked
+	payload    int            // ICMP data bytes of don't-fragment probes; 0 = ping's default size, fragmentable
+	send       probeFunc      // Sends a probe other than a ping command; nil runs ping
+
+	mu     sync.Mutex // Serializes parsing; probes overlap and parsers keep state
+	parser parser.Parser
+}
+
+// probeFunc sends one probe with sequence seq at sent and waits for its
+// reply. An error means probes cannot be sent at all and stops the run.
+type probeFunc func(ctx context.Context, target string, seq int, sent time.Time) (Sample, error)
+
+// NewScheduler creates a strict-timing scheduler.
+func NewScheduler(target string, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		target:   target,
+		interval: interval,
+		timeout:  DefaultTimeout,
+		parser:   parser.New(),
+	}
+}
+
+// SetParser replaces output format detection with p.
+func (s *Scheduler) SetParser(p parser.Parser) {
+	s.parser = p
+}
+
+// SetTimeout sets how long a probe waits for its reply before it counts as
+// lost (default DefaultTimeout).
+func (s *Scheduler) SetTimeout(d time.Duration) {
+	s.timeout = d
+}
+
+// SetMissCounter makes the scheduler count stdout lines the parser did not
+// turn into a sample in c. Known banner and statistics lines are not
+// counted.
+func (s *Scheduler) SetMissCounter(c *atomic.Uint64) {
+	s.misses = c
+}
+
+// SetDebugLog makes the scheduler record every stdout and stderr line of
+// the ping commands in l.
+func (s *Scheduler) SetDebugLog(l *DebugLog) {
+	s.debug = l
+}
+
+// SetTOS marks the probes with an IPv4 TOS or IPv6 traffic class byte, to
+// compare latency under different QoS markings.
+func (s *Scheduler) SetTOS(tos int) {
+	s.tos = tos
+}
+
+// SetBurst makes the scheduler send n probes per tick instead of one, a few
+// milliseconds apart, to measure loss more finely in short sessions. They
+// share the tick's sequence number and carry the burst size.
+func (s *Scheduler) SetBurst(n int) {
+	s.burst = min(n, MaxBurst)
+}
+
+// Run starts one probe, or a burst of them, per tick until ctx is cancelled.
+// Probes overlap when a reply takes longer than the interval, so the
+// schedule never drifts.
+func (s *Scheduler) Run(ctx context.Context, samples chan<- Sample) error {
+	target := normalizeTarget(s.target)
+	send := s.send
+	if send == nil {
+		send = s.probe
+	}
+	if s.send == nil && runtime.GOOS == "windows" {
+		if err := validateWindowsTarget(target); err != nil {
+			return err
+		}
+	}
+
+	// The first probe that cannot start the ping command stops the run
+	failed := make(chan error, 1)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	burst := max(1, s.burst)
+	spacing := min(burstSpacing, s.interval/time.Duration(2*burst))
+
+	for seq := 1; ; seq++ {
+		wg.Go(func() {
+			burst, err := probeBurst(ctx, send, target, seq, burst, spacing)
+			if ctx.Err() != nil {
+				return // Killed by shutdown, not lost
+			}
+			if err != nil {
+				select {
+				case failed <- err:
+				default:
+				}
+				return
+			}
+			// A burst is emitted together, in the order its probes were sent
+			for _, sample := range burst {
+				select {
+				case samples <- sample:
+				case <-ctx.Done():
+					return
+				}
+			}
+		})
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-failed:
+			return err
+		case <-ticker.C:
+		}
+	}
+}
+
+// probeBurst sends n probes spacing apart, all with sequence seq, and waits
+// for every one of them. A single probe is not marked as a burst.
+func probeBurst(ctx context.Context, send probeFunc, target string, seq, n int, spacing time.Duration) ([]Sample, error) {
+	samples := make([]Sample, n)
+	errs := make([]error, n)
+	tick := time.Now()
+
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Go(func() {
+			sent := tick.Add(time.Duration(i) * spacing)
+			if i > 0 && !sleepUntil(ctx, sent) {
+				errs[i] = ctx.Err()
+				return
+			}
+			samples[i], errs[i] = send(ctx, target, seq, sent)
+			if n > 1 {
+				samples[i].Burst = n
+			}
+		})
+	}
+	wg.Wait()
+	return samples, errors.Join(errs...)
+}
+
+// sleepUntil waits for t and reports false if ctx was cancelled first.
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// probe runs a single-shot ping and returns its sample. Only a failure to
+// start the command is an error; anything without a reply is loss.
+func (s *Scheduler) probe(ctx context.Context, target string, seq int, tick time.Time) (Sample, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout+processGrace)
+	defer cancel()
+
+	name, args := buildProbeCommandForOS(runtime.GOOS, target, s.timeout)
+	args = withTOS(runtime.GOOS, name, args, s.tos)
+	if s.payload > 0 {
+		args = withDF(runtime.GOOS, name, args, target, s.payload)
+	}
+	factory := s.cmdFactory
+	if factory == nil {
+		factory = exec.CommandContext
+	}
+	cmd := factory(ctx, name, args...)
+	if runtime.GOOS != "windows" {
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		cmd.Env = append(cmd.Env, "LC_ALL=C", "LANG=C")
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return Sample{}, err
+	}
+	// stderr is only read for the debug log; otherwise it is discarded
+	var stderrDone chan struct{}
+	if s.debug != nil {
+		stderr, err := cmd.StderrPipe()
+		if err != nil {
+			return Sample{}, err
+		}
+		stderrDone = make(chan struct{})
+		go func() {
+			defer close(stderrDone)
+			scanner := bufio.NewScanner(stderr)
+			for scanner.Scan() {
+				s.debug.Line(StreamStderr, scanner.Text(), false)
+			}
+		}()
+	}
+	if err := cmd.Start(); err != nil {
+		return Sample{}, fmt.Errorf("failed to start ping command '%s %v': %w", name, args, err)
+	}
+
+	// A reply wins over error lines; with neither the probe timed out
+	result := Sample{Timestamp: tick, Sequence: seq, Timeout: true}
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		line := scanner.Text()
+		s.mu.Lock()
+		parsed, ok := s.parser.ParseLine(line)
+		s.mu.Unlock()
+		if s.debug != nil {
+			s.debug.Line(StreamStdout, line, ok)
+		}
+		switch {
+		case !ok:
+			if s.misses != nil && !parser.IsNoise(line) {
+				s.misses.Add(1)
+			}
+		case !parsed.Timeout:
+			result.Timeout = false
+			result.RTT = parsed.RTT
+			result.ErrorKind = parsed.ErrorKind
+		case result.Timeout:
+			result.ErrorKind = parsed.ErrorKind
+		}
+	}
+	if stderrDone != nil {
+		<-stderrDone // Wait closes the pipe, so it must be drained first
+	}
+	// ping exits non-zero without a reply; that is the loss recorded above
+	_ = cmd.Wait()
+
+	if result.Timeout && result.ErrorKind == types.ErrorNone {
+		result.ErrorKind = types.ErrorTimeout
+	}
+	return result, nil
+}
+
+// buildProbeCommandForOS returns the command sending a single echo request
+// that waits at most timeout for the reply.
+func buildProbeCommandForOS(goos, target string, timeout time.Duration) (string, []string) {
+	ms := strconv.FormatInt(timeout.Milliseconds(), 10)
+
+	switch goos {
+	case "darwin":
+		// macOS: -W is in milliseconds; ping6 has no wait option
+		if isIPv6Literal(target) {
+			return "ping6", []string{"-c", "1", target}
+		}
+		return "ping", []string{"-c", "1", "-W", ms, target}
+	case "windows":
+		// Windows: see Runner.Run for why ping runs through cmd.exe
+		return "cmd.exe", []string{"/C", "chcp 437 >nul & ping -n 1 -w " + ms + " " + escapeCmdArg(target)}
+	default:
+		args := []string{"-c", "1", "-W", waitSeconds(timeout), target}
+		if isIPv6Literal(target) {
+			return "ping", append([]string{"-6"}, args...)
+		}
+		return "ping", args
+	}
+}
@@ -0,0 +1,131 @@
+package ping
+
+import (
+	"context"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/parser"
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+func TestBuildProbeCommandForOS(t *testing.T) {
+	tests := []struct {
+		goos     string
+		target   string
+		wantName string
+		wantArgs []string
+	}{
+		{"linux", "8.8.8.8", "ping", []string{"-c", "1", "-W", "2", "8.8.8.8"}},
+		{"linux", "::1", "ping", []string{"-6", "-c", "1", "-W", "2", "::1"}},
+		{"darwin", "8.8.8.8", "ping", []string{"-c", "1", "-W", "2000", "8.8.8.8"}},
+		{"darwin", "::1", "ping6", []string{"-c", "1", "::1"}},
+		{"windows", "host%1", "cmd.exe", []string{"/C", "chcp 437 >nul & ping -n 1 -w 2000 host^%1"}},
+	}
+	for _, tt := range tests {
+		name, args := buildProbeCommandForOS(tt.goos, tt.target, 2*time.Second)
+		if name != tt.wantName || !reflect.DeepEqual(args, tt.wantArgs) {
+			t.Fatalf("buildProbeCommandForOS(%s, %s) = %s %v, want %s %v",
+				tt.goos, tt.target, name, args, tt.wantName, tt.wantArgs)
+		}
+	}
+}
+
+func TestSchedulerRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("helper output uses unix-like ping format")
+	}
+
+	tests := []struct {
+		name     string
+		stdout   string
+		exitCode int
+		wantKind types.ErrorKind
+	}{
+		{"reply", "PING 8.8.8.8 (8.8.8.8): 56 data bytes\n64 bytes from 8.8.8.8: icmp_seq=0 ttl=118 time=14.3 ms", 0, types.ErrorNone},
+		{"silent loss", "PING 8.8.8.8 (8.8.8.8): 56 data bytes", 1, types.ErrorTimeout},
+		{"unreachable", "From 10.0.0.1 icmp_seq=1 Destination Net Unreachable", 1, types.ErrorNetUnreachable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Scheduler{
+				target:     "example.com",
+				interval:   100 * time.Millisecond,
+				timeout:    time.Second,
+				parser:     parser.New(),
+				cmdFactory: testCommandFactory(tt.stdout, "", tt.exitCode),
+			}
+			samples := make(chan Sample, 100)
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+
+			if err := s.Run(ctx, samples); err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+			close(samples)
+
+			var got []Sample
+			for sample := range samples {
+				got = append(got, sample)
+			}
+			if len(got) == 0 {
+				t.Fatalf("expected samples")
+			}
+			for _, sample := range got {
+				if sample.Kind() != tt.wantKind || sample.Sequence < 1 || sample.Timestamp.IsZero() {
+					t.Fatalf("sample = %+v, want kind %v with tick sequence and time", sample, tt.wantKind)
+				}
+			}
+		})
+	}
+}
+
+func TestSchedulerRunReportsStartFailure(t *testing.T) {
+	s := NewScheduler("example.com", 50*time.Millisecond)
+	s.cmdFactory = func(ctx context.Context, _ string, _ ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "/nonexistent/ping")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	if err := s.Run(ctx, make(chan Sample, 10)); err == nil {
+		t.Fatalf("expected start error")
+	}
+}
+
+func TestSchedulerRunBurst(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("helper output uses unix-like ping format")
+	}
+
+	s := &Scheduler{
+		target:     "example.com",
+		interval:   200 * time.Millisecond,
+		timeout:    time.Second,
+		parser:     parser.New(),
+		cmdFactory: testCommandFactory("64 bytes from 8.8.8.8: icmp_seq=0 ttl=118 time=14.3 ms", "", 0),
+	}
+	s.SetBurst(3)
+	samples := make(chan Sample, 100)
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	if err := s.Run(ctx, samples); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	close(samples)
+
+	perSeq := make(map[int]int)
+	for sample := range samples {
+		if sample.Burst != 3 {
+			t.Fatalf("sample = %+v, want burst size 3", sample)
+		}
+		perSeq[sample.Sequence]++
+	}
+	if perSeq[1] != 3 {
+		t.Fatalf("first tick produced %d samples, want 3 sharing its sequence", perSeq[1])
+	}
+}
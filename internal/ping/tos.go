@@ -0,0 +1,72 @@
+package ping
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MaxTOS is the largest IPv4 TOS or IPv6 traffic class byte: the DSCP in its
+// upper six bits, ECN in the lower two.
+const MaxTOS = 255
+
+// dscpNames are the standard per-hop behaviors by DSCP (RFC 2474, 2597, 3246,
+// 5865 and 8622).
+var dscpNames = map[string]int{
+	"be": 0, "df": 0, "le": 1,
+	"cs1": 8, "cs2": 16, "cs3": 24, "cs4": 32, "cs5": 40, "cs6": 48, "cs7": 56,
+	"af11": 10, "af12": 12, "af13": 14,
+	"af21": 18, "af22": 20, "af23": 22,
+	"af31": 26, "af32": 28, "af33": 30,
+	"af41": 34, "af42": 36, "af43": 38,
+	"va": 44, "ef": 46,
+}
+
+// ParseDSCP parses a DSCP, by name (EF, AF41, CS1, BE; any case) or as a
+// number from 0 to 63, and returns its TOS byte.
+func ParseDSCP(s string) (int, error) {
+	if dscp, ok := dscpNames[strings.ToLower(s)]; ok {
+		return dscp << 2, nil
+	}
+	dscp, err := strconv.Atoi(s)
+	if err != nil || dscp < 0 || dscp > 63 {
+		return 0, fmt.Errorf("unknown DSCP %q (want a name such as EF, AF41, CS1 or BE, or 0-63)", s)
+	}
+	return dscp << 2, nil
+}
+
+// DSCPName names the DSCP of a TOS byte, as in "ef", or gives its number
+// when it has no standard name.
+func DSCPName(tos int) string {
+	dscp := tos >> 2
+	names := make([]string, 0, len(dscpNames))
+	for name, d := range dscpNames {
+		if d == dscp {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return strconv.Itoa(dscp)
+	}
+	// "be" and "df" share 0; prefer the better known name
+	slices.Sort(names)
+	return names[0]
+}
+
+// withTOS inserts the options marking the probes of the ping command name
+// with tos before the target, the last of args: -Q on Linux, -z on macOS.
+// macOS ping6 cannot mark its probes. Windows marks through the ICMP API
+// instead, as the network stack ignores ping.exe's -v.
+func withTOS(goos, name string, args []string, tos int) []string {
+	if tos == 0 || name != "ping" {
+		return args
+	}
+	switch goos {
+	case "linux":
+		return slices.Insert(args, len(args)-1, "-Q", strconv.Itoa(tos))
+	case "darwin":
+		return slices.Insert(args, len(args)-1, "-z", strconv.Itoa(tos))
+	}
+	return args
+}
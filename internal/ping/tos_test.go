@@ -0,0 +1,58 @@
+package ping
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseDSCP(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{in: "EF", want: 184},
+		{in: "af41", want: 136},
+		{in: "CS1", want: 32},
+		{in: "be", want: 0},
+		{in: "46", want: 184},
+		{in: "63", want: 252},
+		{in: "64", wantErr: true},
+		{in: "-1", wantErr: true},
+		{in: "gold", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDSCP(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Fatalf("ParseDSCP(%q) = %d, %v; want %d, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestDSCPName(t *testing.T) {
+	for tos, want := range map[int]string{0: "be", 184: "ef", 185: "ef", 136: "af41", 4: "le", 8: "2"} {
+		if got := DSCPName(tos); got != want {
+			t.Fatalf("DSCPName(%d) = %q, want %q", tos, got, want)
+		}
+	}
+}
+
+func TestWithTOS(t *testing.T) {
+	tests := []struct {
+		goos, name string
+		tos        int
+		want       []string
+	}{
+		{"linux", "ping", 184, []string{"-c", "1", "-Q", "184", "host"}},
+		{"darwin", "ping", 184, []string{"-c", "1", "-z", "184", "host"}},
+		{"darwin", "ping6", 184, []string{"-c", "1", "host"}},
+		{"windows", "cmd.exe", 184, []string{"-c", "1", "host"}},
+		{"linux", "ping", 0, []string{"-c", "1", "host"}},
+	}
+	for _, tt := range tests {
+		got := withTOS(tt.goos, tt.name, []string{"-c", "1", "host"}, tt.tos)
+		if !slices.Equal(got, tt.want) {
+			t.Fatalf("withTOS(%s, %s, %d) = %v, want %v", tt.goos, tt.name, tt.tos, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package ping
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+// Probe modes selected with -mode.
+const (
+	ModeICMP = "icmp" // Echo requests through ping or the ICMP API
+	ModeUDP  = "udp"  // Datagrams to a UDP port
+)
+
+// DefaultUDPPort is the port of the echo service (RFC 862).
+const DefaultUDPPort = 7
+
+// udpMagic starts every UDP probe so replies are told from stray datagrams.
+var udpMagic = []byte("pingheat")
+
+// NewUDPScheduler creates a scheduler that sends one datagram per tick to
+// port on target instead of running ping. An echo server answers with the
+// same datagram; a closed port answers with an ICMP port unreachable error,
+// which times the path just as well. This measures paths where firewalls or
+// QoS treat UDP differently from ICMP.
+func NewUDPScheduler(target string, interval time.Duration, port int) *Scheduler {
+	s := NewScheduler(target, interval)
+	s.send = func(ctx context.Context, target string, seq int, sent time.Time) (Sample, error) {
+		return probeUDP(ctx, target, port, seq, sent, s.timeout, s.tos), nil
+	}
+	return s
+}
+
+// probeUDP sends one datagram, marked with tos unless it is 0, and waits up
+// to timeout for its echo or for the port to be refused. It cannot fail to
+// start like a ping command can: a target that does not resolve is recorded
+// as loss.
+func probeUDP(ctx context.Context, target string, port, seq int, sent time.Time, timeout time.Duration, tos int) Sample {
+	result := Sample{Timestamp: sent, Sequence: seq, Timeout: true, ErrorKind: types.ErrorTimeout}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var dialer net.Dialer
+	if tos != 0 {
+		dialer.Control = func(network, _ string, c syscall.RawConn) error {
+			return setTOS(network, c, tos)
+		}
+	}
+	conn, err := dialer.DialContext(ctx, "udp", net.JoinHostPort(target, strconv.Itoa(port)))
+	if err != nil {
+		result.ErrorKind = udpErrorKind(err)
+		return result
+	}
+	defer func() { _ = conn.Close() }()
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	payload := binary.BigEndian.AppendUint64(bytes.Clone(udpMagic), uint64(seq))
+	start := time.Now()
+	if _, err := conn.Write(payload); err != nil {
+		result.ErrorKind = udpErrorKind(err)
+		return result
+	}
+	buf := make([]byte, 1500)
+	for {
+		n, err := conn.Read(buf)
+		switch {
+		case err == nil && !bytes.Equal(buf[:n], payload):
+			continue // A late echo of an earlier probe, or not an echo
+		case err == nil, udpRefused(err):
+			result.Timeout = false
+			result.ErrorKind = types.ErrorNone
+			result.RTT = time.Since(start)
+			return result
+		default:
+			result.ErrorKind = udpErrorKind(err)
+			return result
+		}
+	}
+}
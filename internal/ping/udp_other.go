@@ -0,0 +1,44 @@
+//go:build !windows
+
+package ping
+
+import (
+	"errors"
+	"syscall"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+// udpRefused reports whether err is the ICMP port unreachable error a
+// connected UDP socket receives from a closed port.
+func udpRefused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// udpErrorKind maps a UDP socket error to the failure kind of a sample.
+func udpErrorKind(err error) types.ErrorKind {
+	switch {
+	case errors.Is(err, syscall.ENETUNREACH):
+		return types.ErrorNetUnreachable
+	case errors.Is(err, syscall.EHOSTUNREACH):
+		return types.ErrorHostUnreachable
+	default:
+		return types.ErrorTimeout
+	}
+}
+
+// setTOS marks the packets of the socket c with an IPv4 TOS or, on network
+// udp6, an IPv6 traffic class byte.
+func setTOS(network string, c syscall.RawConn, tos int) error {
+	level, opt := syscall.IPPROTO_IP, syscall.IP_TOS
+	if network == "udp6" {
+		level, opt = syscall.IPPROTO_IPV6, syscall.IPV6_TCLASS
+	}
+	var err error
+	if cerr := c.Control(func(fd uintptr) {
+		err = syscall.SetsockoptInt(int(fd), level, opt, tos)
+	}); cerr != nil {
+		return cerr
+	}
+	return err
+}
@@ -0,0 +1,118 @@
+package ping
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+func TestProbeUDPEcho(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error = %v", err)
+	}
+	defer conn.Close()
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = conn.WriteTo([]byte("noise"), addr) // Not an echo, skipped
+			_, _ = conn.WriteTo(buf[:n], addr)
+		}
+	}()
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+
+	sample := probeUDP(context.Background(), "127.0.0.1", port, 3, time.Now(), time.Second, 0)
+	if sample.Timeout || sample.RTT <= 0 || sample.Sequence != 3 {
+		t.Fatalf("sample = %+v, want a reply to sequence 3", sample)
+	}
+}
+
+func TestProbeUDPMarked(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error = %v", err)
+	}
+	defer conn.Close()
+	go func() {
+		buf := make([]byte, 1500)
+		n, addr, err := conn.ReadFrom(buf)
+		if err == nil {
+			_, _ = conn.WriteTo(buf[:n], addr)
+		}
+	}()
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+
+	ef, _ := ParseDSCP("EF")
+	sample := probeUDP(context.Background(), "127.0.0.1", port, 1, time.Now(), time.Second, ef)
+	if sample.Timeout {
+		t.Fatalf("sample = %+v, want a reply to the EF-marked probe", sample)
+	}
+}
+
+func TestProbeUDPRefused(t *testing.T) {
+	// A port nothing listens on any more answers with port unreachable
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error = %v", err)
+	}
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	conn.Close()
+
+	sample := probeUDP(context.Background(), "127.0.0.1", port, 1, time.Now(), time.Second, 0)
+	if sample.Timeout {
+		t.Fatalf("sample = %+v, want the refused port to count as a reply", sample)
+	}
+}
+
+func TestProbeUDPTimeout(t *testing.T) {
+	// A listener that never answers
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error = %v", err)
+	}
+	defer conn.Close()
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+
+	sample := probeUDP(context.Background(), "127.0.0.1", port, 1, time.Now(), 100*time.Millisecond, 0)
+	if !sample.Timeout || sample.ErrorKind != types.ErrorTimeout {
+		t.Fatalf("sample = %+v, want a timeout", sample)
+	}
+}
+
+func TestUDPSchedulerRun(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error = %v", err)
+	}
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	conn.Close()
+
+	s := NewUDPScheduler("127.0.0.1", 100*time.Millisecond, port)
+	s.SetBurst(2)
+	samples := make(chan Sample, 100)
+	ctx, cancel := context.WithTimeout(context.Background(), 350*time.Millisecond)
+	defer cancel()
+
+	if err := s.Run(ctx, samples); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	close(samples)
+
+	n := 0
+	for sample := range samples {
+		if sample.Timeout || sample.Burst != 2 {
+			t.Fatalf("sample = %+v, want a refused-port reply in a burst of 2", sample)
+		}
+		n++
+	}
+	if n < 4 {
+		t.Fatalf("Run() emitted %d samples, want at least 2 bursts", n)
+	}
+}
@@ -0,0 +1,48 @@
+package ping
+
+import (
+	"errors"
+	"syscall"
+
+	"github.com/pbv7/pingheat/internal/types"
+	"golang.org/x/sys/windows"
+)
+
+// udpRefused reports whether err is the ICMP port unreachable error a
+// connected UDP socket receives from a closed port; Winsock reports it as
+// a reset connection.
+func udpRefused(err error) bool {
+	return errors.Is(err, windows.WSAECONNRESET)
+}
+
+// udpErrorKind maps a UDP socket error to the failure kind of a sample.
+func udpErrorKind(err error) types.ErrorKind {
+	switch {
+	case errors.Is(err, windows.WSAENETUNREACH):
+		return types.ErrorNetUnreachable
+	case errors.Is(err, windows.WSAEHOSTUNREACH):
+		return types.ErrorHostUnreachable
+	default:
+		return types.ErrorTimeout
+	}
+}
+
+// ipv6TClass is the IPV6_TCLASS socket option, missing from x/sys/windows.
+const ipv6TClass = 39
+
+// setTOS marks the packets of the socket c with an IPv4 TOS or, on network
+// udp6, an IPv6 traffic class byte. Windows ignores both unless a QoS
+// policy or the DisableUserTOSSetting registry value allows it.
+func setTOS(network string, c syscall.RawConn, tos int) error {
+	level, opt := windows.IPPROTO_IP, windows.IP_TOS
+	if network == "udp6" {
+		level, opt = windows.IPPROTO_IPV6, ipv6TClass
+	}
+	var err error
+	if cerr := c.Control(func(fd uintptr) {
+		err = windows.SetsockoptInt(windows.Handle(fd), level, opt, tos)
+	}); cerr != nil {
+		return cerr
+	}
+	return err
+}
@@ -2,15 +2,27 @@ package pprof
 
 import (
 	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
 	"net/http"
 	"net/http/pprof"
+	"sort"
+	"sync"
 	"time"
 )
 
-// Server provides pprof endpoints.
+// shutdownTimeout bounds waiting for requests in flight, such as a CPU
+// profile, when the server stops; they are cut off after it.
+const shutdownTimeout = 3 * time.Second
+
+// Server provides pprof endpoints and expvar-style variables.
 type Server struct {
 	addr   string
 	server *http.Server
+
+	mu   sync.RWMutex
+	vars map[string]func() any // Published with Publish, per server
 }
 
 // NewServer creates a new pprof server.
@@ -24,13 +36,20 @@ func NewServer(addr string) *Server {
 func (s *Server) Start(ctx context.Context) error {
 	s.server = s.newServer()
 
+	stopped := make(chan struct{})
 	go func() {
+		defer close(stopped)
 		<-ctx.Done()
-		_ = s.server.Shutdown(context.Background())
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := s.server.Shutdown(shutdownCtx); err != nil {
+			_ = s.server.Close()
+		}
 	}()
 
 	err := s.server.ListenAndServe()
 	if err == http.ErrServerClosed {
+		<-stopped
 		return nil
 	}
 	return err
@@ -46,6 +65,7 @@ func (s *Server) newServer() *http.Server {
 	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
 	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
 	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/vars", s.handleVars)
 
 	return &http.Server{
 		Addr:              s.addr,
@@ -55,3 +75,50 @@ func (s *Server) newServer() *http.Server {
 		IdleTimeout:       60 * time.Second,
 	}
 }
+
+// Publish adds a variable to /debug/vars. f is called on every request and
+// its result encoded as JSON. Unlike expvar.Publish, names are scoped to this
+// server, so several servers may publish the same name.
+func (s *Server) Publish(name string, f func() any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.vars == nil {
+		s.vars = make(map[string]func() any)
+	}
+	s.vars[name] = f
+}
+
+// handleVars serves the global expvar variables (cmdline, memstats) merged
+// with the server's own, in the same JSON layout as expvar.Handler.
+func (s *Server) handleVars(w http.ResponseWriter, r *http.Request) {
+	vars := make(map[string]string)
+	expvar.Do(func(kv expvar.KeyValue) {
+		vars[kv.Key] = kv.Value.String()
+	})
+
+	s.mu.RLock()
+	for name, f := range s.vars {
+		b, err := json.Marshal(f())
+		if err != nil {
+			b, _ = json.Marshal(err.Error())
+		}
+		vars[name] = string(b)
+	}
+	s.mu.RUnlock()
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	fmt.Fprintf(w, "{\n")
+	for i, name := range names {
+		if i > 0 {
+			fmt.Fprintf(w, ",\n")
+		}
+		fmt.Fprintf(w, "%q: %s", name, vars[name])
+	}
+	fmt.Fprintf(w, "\n}\n")
+}
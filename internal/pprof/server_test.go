@@ -1,6 +1,7 @@
 package pprof
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -32,3 +33,26 @@ func TestServerHandlersAndTimeouts(t *testing.T) {
 		t.Fatalf("pprof status=%d, want 200", rec.Code)
 	}
 }
+
+func TestServerVars(t *testing.T) {
+	s := NewServer("127.0.0.1:6060")
+	s.Publish("pingheat_samples_processed", func() any { return 42 })
+	server := s.newServer()
+
+	rec := httptest.NewRecorder()
+	server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("vars status=%d, want 200", rec.Code)
+	}
+
+	var vars map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &vars); err != nil {
+		t.Fatalf("vars body is not JSON: %v", err)
+	}
+	if got := string(vars["pingheat_samples_processed"]); got != "42" {
+		t.Fatalf("pingheat_samples_processed=%s, want 42", got)
+	}
+	if _, ok := vars["memstats"]; !ok {
+		t.Fatalf("vars missing memstats")
+	}
+}
@@ -0,0 +1,81 @@
+package prefs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/pbv7/pingheat/internal/ui/colors"
+	"github.com/pbv7/pingheat/internal/userfile"
+)
+
+// fileName is the preferences file name inside the pingheat config directory.
+const fileName = "ui.json"
+
+// Preferences holds UI state persisted between runs.
+// It is kept separate from the main configuration so that saving UI state
+// never rewrites user-authored settings.
+type Preferences struct {
+	ViewMode    string             `json:"view_mode,omitempty"`
+	Orientation string             `json:"orientation,omitempty"` // Heatmap time in rows or columns
+	Thresholds  *colors.Thresholds `json:"thresholds,omitempty"`
+	Legend      *bool              `json:"legend,omitempty"` // Legend bar shown beneath the heatmap
+}
+
+// Merge returns p updated with every field that is set in other.
+func (p Preferences) Merge(other Preferences) Preferences {
+	if other.ViewMode != "" {
+		p.ViewMode = other.ViewMode
+	}
+	if other.Orientation != "" {
+		p.Orientation = other.Orientation
+	}
+	if other.Thresholds != nil {
+		p.Thresholds = other.Thresholds
+	}
+	if other.Legend != nil {
+		p.Legend = other.Legend
+	}
+	return p
+}
+
+// DefaultPath returns the per-user preferences path
+// ($XDG_CONFIG_HOME/pingheat/ui.json on Linux, the platform equivalent elsewhere).
+func DefaultPath() (string, error) {
+	return userfile.ConfigPath(fileName)
+}
+
+// Load reads preferences from path. A missing file yields empty preferences.
+func Load(path string) (Preferences, error) {
+	var p Preferences
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return p, err
+	}
+
+	if err := json.Unmarshal(data, &p); err != nil {
+		return Preferences{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if p.Thresholds != nil {
+		if err := p.Thresholds.Validate(); err != nil {
+			return Preferences{}, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return p, nil
+}
+
+// Save writes preferences to path, creating the parent directory if needed,
+// without ever leaving a truncated file behind.
+func Save(path string, p Preferences) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return userfile.WriteAtomic(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,67 @@
+package prefs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pbv7/pingheat/internal/ui/colors"
+)
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "ui.json")
+	th := colors.Thresholds{Excellent: 10, Good: 20, Fair: 40, Poor: 80}
+
+	if err := Save(path, Preferences{ViewMode: "braille", Thresholds: &th}); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if got.ViewMode != "braille" {
+		t.Fatalf("ViewMode=%q, want braille", got.ViewMode)
+	}
+	if got.Thresholds == nil || *got.Thresholds != th {
+		t.Fatalf("Thresholds=%v, want %v", got.Thresholds, th)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	got, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if got.ViewMode != "" || got.Thresholds != nil {
+		t.Fatalf("expected empty preferences, got %+v", got)
+	}
+}
+
+func TestLoadRejectsInvalidThresholds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ui.json")
+	data := `{"thresholds":{"excellent":50,"good":10,"fair":20,"poor":30}}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(path); err == nil {
+		t.Fatalf("expected error for invalid thresholds")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	th := colors.DefaultThresholds()
+	base := Preferences{ViewMode: "blocks", Thresholds: &th}
+
+	got := base.Merge(Preferences{ViewMode: "braille"})
+	if got.ViewMode != "braille" || got.Thresholds != &th {
+		t.Fatalf("unexpected merge result: %+v", got)
+	}
+
+	legend := false
+	got = got.Merge(Preferences{Orientation: "columns", Legend: &legend})
+	if got.Legend == nil || *got.Legend || got.Orientation != "columns" || got.ViewMode != "braille" {
+		t.Fatalf("unexpected merge result: %+v", got)
+	}
+}
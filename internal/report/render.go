@@ -0,0 +1,427 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/locale"
+	"github.com/pbv7/pingheat/internal/ui/colors"
+)
+
+// Supported report formats.
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatCSV      = "csv" // Only the hour-of-day breakdown, one row per hour
+)
+
+// IsValidFormat reports whether format is a supported report format.
+func IsValidFormat(format string) bool {
+	return format == FormatText || format == FormatMarkdown || format == FormatHTML || format == FormatCSV
+}
+
+// Write renders the report in the given format.
+func Write(w io.Writer, r Report, format string) error {
+	switch format {
+	case FormatText:
+		return writeText(w, r)
+	case FormatMarkdown:
+		return writeMarkdown(w, r)
+	case FormatHTML:
+		return writeHTML(w, r)
+	case FormatCSV:
+		return writeCSV(w, r)
+	default:
+		return fmt.Errorf("unsupported report format %q (want text, markdown, html or csv)", format)
+	}
+}
+
+// summaryRows returns label/value pairs shared by all formats.
+func summaryRows(r Report) [][2]string {
+	s, f := r.Stats, r.Format
+	rows := [][2]string{
+		{"Start", formatTime(f, r.Start)},
+		{"End", formatTime(f, r.End)},
+		{"Duration", r.Duration.Round(time.Second).String()},
+		{"Samples", f.Int(s.TotalSamples)},
+		{"Received", f.Int(s.TotalSuccess)},
+		{"Loss", f.Percent(s.LossPercent, 2)},
+		{"Outages", f.Int(s.LossBursts)},
+		{"Longest drop", f.Int(s.LongestTimeout) + " samples"},
+		{"Brownouts", f.Int(s.BrownoutBursts)},
+	}
+	if s.TotalSuccess > 0 {
+		rows = append(rows,
+			[2]string{"RTT min/avg/max", f.Float(s.MinRTTMs, 2) + " / " + f.Float(s.AvgRTTMs, 2) + " / " + f.Float(s.MaxRTTMs, 2) + " ms"},
+			[2]string{"Std dev", f.Float(s.StdDevMs, 2) + " ms"},
+			[2]string{"Jitter", f.Float(s.JitterMs, 2) + " ms"},
+		)
+	}
+	return rows
+}
+
+// percentileRows returns the percentile table.
+func percentileRows(r Report) [][2]string {
+	p, f := r.Stats.Percentiles, r.Format
+	return [][2]string{
+		{"p50", f.Float(p.P50, 2) + " ms"},
+		{"p90", f.Float(p.P90, 2) + " ms"},
+		{"p95", f.Float(p.P95, 2) + " ms"},
+		{"p99", f.Float(p.P99, 2) + " ms"},
+	}
+}
+
+// byHourHeader returns the column names of the hour-of-day table.
+func byHourHeader(t colors.Thresholds) []string {
+	header := []string{"Hour", "Samples", "Loss", "Avg", "p95"}
+	for _, bound := range bandBounds(t) {
+		header = append(header, fmt.Sprintf("≤%gms", bound))
+	}
+	return append(header, fmt.Sprintf(">%gms", t.Poor))
+}
+
+// byHourRows returns the hour-of-day table for the hours with samples, with
+// each latency band as a share of the hour's samples.
+func byHourRows(r Report) [][]string {
+	f := r.Format
+	var rows [][]string
+	for _, h := range r.ByHour {
+		if h.Samples == 0 {
+			continue
+		}
+		row := []string{
+			f.Time(time.Date(2000, 1, 1, h.Hour, 0, 0, 0, time.UTC), "15:00"),
+			f.Int(h.Samples),
+			f.Percent(h.LossPercent, 2),
+			f.Float(h.AvgMs, 2) + "ms",
+			f.Float(h.P95Ms, 2) + "ms",
+		}
+		for _, n := range h.Buckets {
+			row = append(row, f.Percent(float64(n)/float64(h.Samples)*100, 1))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// segmentRows returns the session segments table, or nil for a session
+// without gaps.
+func segmentRows(r Report) [][]string {
+	if len(r.Segments) < 2 {
+		return nil
+	}
+	f := r.Format
+	rows := make([][]string, 0, len(r.Segments))
+	for _, seg := range r.Segments {
+		rows = append(rows, []string{
+			formatTime(f, seg.Start),
+			formatTime(f, seg.End),
+			seg.End.Sub(seg.Start).Round(time.Second).String(),
+			f.Int(seg.Stats.TotalSamples),
+			f.Percent(seg.Stats.LossPercent, 2),
+			f.Float(seg.Stats.AvgRTTMs, 2) + "ms",
+			f.Float(seg.Stats.Percentiles.P95, 2) + "ms",
+		})
+	}
+	return rows
+}
+
+// hourlyRows returns the hourly breakdown table.
+func hourlyRows(r Report) [][]string {
+	f := r.Format
+	rows := make([][]string, 0, len(r.Hours))
+	for _, h := range r.Hours {
+		rows = append(rows, []string{
+			f.Time(h.Start, "2006-01-02 15:00"),
+			f.Int(h.Samples),
+			f.Percent(h.LossPercent, 2),
+			f.Float(h.AvgMs, 2) + "ms",
+			f.Float(h.P95Ms, 2) + "ms",
+			f.Float(h.MaxMs, 2) + "ms",
+		})
+	}
+	return rows
+}
+
+// hourlyHeader names the columns of the hourly breakdown table.
+var hourlyHeader = []string{"Hour", "Samples", "Loss", "Avg", "p95", "Max"}
+
+// outageRows returns the outage table, with the times written by clock.
+func outageRows(r Report, clock func(time.Time) string) [][]string {
+	rows := make([][]string, 0, len(r.Outages))
+	for _, o := range r.Outages {
+		rows = append(rows, []string{
+			clock(o.Start),
+			clock(o.End),
+			r.Format.Int(o.Lost),
+			o.Duration.Round(time.Millisecond).String(),
+		})
+	}
+	return rows
+}
+
+// outageHeader names the columns of the outage table.
+var outageHeader = []string{"Start", "End", "Lost", "Duration"}
+
+// segmentHeader names the columns of the session segments table.
+var segmentHeader = []string{"Start", "End", "Duration", "Samples", "Loss", "Avg", "p95"}
+
+// formatTime formats a report timestamp, or "-" when unset.
+func formatTime(f locale.Format, t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return f.Time(t, "2006-01-02 15:04:05 MST")
+}
+
+// writeText renders an aligned plain-text report.
+func writeText(w io.Writer, r Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "pingheat report: %s\n\n", r.Title)
+	for _, row := range summaryRows(r) {
+		fmt.Fprintf(tw, "%s:\t%s\n", row[0], row[1])
+	}
+
+	fmt.Fprintf(tw, "\nPercentiles\n")
+	for _, row := range percentileRows(r) {
+		fmt.Fprintf(tw, "%s:\t%s\n", row[0], row[1])
+	}
+
+	if rows := segmentRows(r); rows != nil {
+		fmt.Fprintf(tw, "\nSegments (%d, split at gaps)\n", len(rows))
+		fmt.Fprintf(tw, "%s\n", strings.Join(segmentHeader, "\t"))
+		for _, row := range rows {
+			fmt.Fprintf(tw, "%s\n", strings.Join(row, "\t"))
+		}
+	}
+
+	fmt.Fprintf(tw, "\nHourly breakdown\n")
+	fmt.Fprintf(tw, "%s\n", strings.Join(hourlyHeader, "\t"))
+	for _, row := range hourlyRows(r) {
+		fmt.Fprintf(tw, "%s\n", strings.Join(row, "\t"))
+	}
+
+	fmt.Fprintf(tw, "\nBy hour of day\n")
+	fmt.Fprintf(tw, "%s\n", strings.Join(byHourHeader(r.Thresholds), "\t"))
+	for _, row := range byHourRows(r) {
+		fmt.Fprintf(tw, "%s\n", strings.Join(row, "\t"))
+	}
+
+	fmt.Fprintf(tw, "\nOutages (%d)\n", len(r.Outages))
+	if len(r.Outages) > 0 {
+		fmt.Fprintf(tw, "%s\n", strings.Join(outageHeader, "\t"))
+	}
+	clock := func(t time.Time) string { return r.Format.Time(t, "15:04:05") }
+	for _, row := range outageRows(r, clock) {
+		fmt.Fprintf(tw, "%s\n", strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// writeMarkdown renders GitHub-flavored Markdown tables.
+func writeMarkdown(w io.Writer, r Report) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# pingheat report: %s\n\n", r.Title)
+	b.WriteString("| Metric | Value |\n| ------ | ----- |\n")
+	for _, row := range summaryRows(r) {
+		fmt.Fprintf(&b, "| %s | %s |\n", row[0], row[1])
+	}
+
+	b.WriteString("\n## Percentiles\n\n| Percentile | RTT |\n| ---------- | --- |\n")
+	for _, row := range percentileRows(r) {
+		fmt.Fprintf(&b, "| %s | %s |\n", row[0], row[1])
+	}
+
+	if rows := segmentRows(r); rows != nil {
+		fmt.Fprintf(&b, "\n## Segments (%d, split at gaps)\n\n", len(rows))
+		fmt.Fprintf(&b, "| %s |\n|%s\n", strings.Join(segmentHeader, " | "), strings.Repeat(" --- |", len(segmentHeader)))
+		for _, row := range rows {
+			fmt.Fprintf(&b, "| %s |\n", strings.Join(row, " | "))
+		}
+	}
+
+	b.WriteString("\n## Hourly breakdown\n\n| Hour | Samples | Loss | Avg | p95 | Max |\n")
+	b.WriteString("| ---- | ------- | ---- | --- | --- | --- |\n")
+	for _, row := range hourlyRows(r) {
+		fmt.Fprintf(&b, "| %s |\n", strings.Join(row, " | "))
+	}
+
+	b.WriteString("\n## By hour of day\n\n")
+	header := byHourHeader(r.Thresholds)
+	fmt.Fprintf(&b, "| %s |\n|%s\n", strings.Join(header, " | "), strings.Repeat(" --- |", len(header)))
+	for _, row := range byHourRows(r) {
+		fmt.Fprintf(&b, "| %s |\n", strings.Join(row, " | "))
+	}
+
+	fmt.Fprintf(&b, "\n## Outages (%d)\n\n", len(r.Outages))
+	if len(r.Outages) > 0 {
+		b.WriteString("| Start | End | Lost | Duration |\n| ----- | --- | ---- | -------- |\n")
+		clock := func(t time.Time) string { return formatTime(r.Format, t) }
+		for _, row := range outageRows(r, clock) {
+			fmt.Fprintf(&b, "| %s |\n", strings.Join(row, " | "))
+		}
+	} else {
+		b.WriteString("No outages recorded.\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// htmlTemplate renders a self-contained HTML report.
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>pingheat report: {{.Report.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.8em; text-align: left; }
+th { background: #f0f0f0; }
+.legend span { display: inline-block; width: 0.9em; height: 0.9em; margin: 0 0.3em 0 1em; vertical-align: middle; }
+.legend span:first-child { margin-left: 0; }
+figure { margin: 0 0 1.5em 0; }
+</style>
+</head>
+<body>
+<h1>pingheat report: {{.Report.Title}}</h1>
+<table>
+{{range .Summary}}<tr><th>{{index . 0}}</th><td>{{index . 1}}</td></tr>
+{{end}}</table>
+{{if .Heatmap}}<h2>Heatmap</h2>
+<figure>
+{{.Heatmap}}
+<figcaption class="legend">{{range .Legend}}<span style="background: {{.Color}}"></span>{{.Label}}{{end}}</figcaption>
+</figure>
+<h2>Latency</h2>
+<figure>
+{{.Graph}}
+<figcaption>Average (blue) and maximum (orange) RTT; marks below the axis show lost samples.</figcaption>
+</figure>
+{{end}}<h2>Percentiles</h2>
+<table>
+{{range .Percentiles}}<tr><th>{{index . 0}}</th><td>{{index . 1}}</td></tr>
+{{end}}</table>
+{{if .Segments}}<h2>Segments ({{len .Segments}}, split at gaps)</h2>
+<table>
+<tr>{{range .SegmentHeader}}<th>{{.}}</th>{{end}}</tr>
+{{range .Segments}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+{{end}}<h2>Hourly breakdown</h2>
+<table>
+<tr>{{range .HourlyHeader}}<th>{{.}}</th>{{end}}</tr>
+{{range .Hourly}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+<h2>By hour of day</h2>
+<table>
+<tr>{{range .ByHourHeader}}<th>{{.}}</th>{{end}}</tr>
+{{range .ByHour}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+<h2>Outages ({{len .Report.Outages}})</h2>
+{{if .Report.Outages}}<table>
+<tr>{{range .OutageHeader}}<th>{{.}}</th>{{end}}</tr>
+{{range .Outages}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>{{else}}<p>No outages recorded.</p>{{end}}
+</body>
+</html>
+`))
+
+// legendEntry is one swatch of the HTML heatmap legend.
+type legendEntry struct {
+	Color template.CSS
+	Label string
+}
+
+// legend describes the heatmap colors for the given thresholds.
+func legend(t colors.Thresholds) []legendEntry {
+	return []legendEntry{
+		{template.CSS(colors.ColorExcellent), fmt.Sprintf("≤%gms", t.Excellent)},
+		{template.CSS(colors.ColorGood), fmt.Sprintf("≤%gms", t.Good)},
+		{template.CSS(colors.ColorFair), fmt.Sprintf("≤%gms", t.Fair)},
+		{template.CSS(colors.ColorPoor), fmt.Sprintf("≤%gms", t.Poor)},
+		{template.CSS(colors.ColorBad), fmt.Sprintf(">%gms", t.Poor)},
+		{template.CSS(colors.ColorTimeout), "Lost"},
+	}
+}
+
+// writeHTML renders a self-contained HTML page with an inline SVG heatmap and
+// latency graph, suitable for attaching to a support ticket.
+func writeHTML(w io.Writer, r Report) error {
+	return htmlTemplate.Execute(w, struct {
+		Report        Report
+		Summary       [][2]string
+		Percentiles   [][2]string
+		Heatmap       template.HTML
+		Graph         template.HTML
+		Legend        []legendEntry
+		ByHour        [][]string
+		ByHourHeader  []string
+		Segments      [][]string
+		SegmentHeader []string
+		Hourly        [][]string
+		HourlyHeader  []string
+		Outages       [][]string
+		OutageHeader  []string
+	}{
+		Report:        r,
+		Summary:       summaryRows(r),
+		Percentiles:   percentileRows(r),
+		Heatmap:       heatmapSVG(r.Samples, r.Thresholds),
+		Graph:         latencySVG(r.Samples),
+		Legend:        legend(r.Thresholds),
+		ByHour:        byHourRows(r),
+		ByHourHeader:  byHourHeader(r.Thresholds),
+		Segments:      segmentRows(r),
+		SegmentHeader: segmentHeader,
+		Hourly:        hourlyRows(r),
+		HourlyHeader:  hourlyHeader,
+		Outages:       outageRows(r, func(t time.Time) string { return formatTime(r.Format, t) }),
+		OutageHeader:  outageHeader,
+	})
+}
+
+// writeCSV writes the hour-of-day breakdown, one row per hour including
+// those without samples, with replies counted per latency band, for charting
+// in a spreadsheet.
+func writeCSV(w io.Writer, r Report) error {
+	cw := csv.NewWriter(w)
+	header := []string{"hour", "samples", "lost", "loss_percent", "avg_ms", "p95_ms"}
+	for _, bound := range bandBounds(r.Thresholds) {
+		header = append(header, fmt.Sprintf("le_%gms", bound))
+	}
+	header = append(header, fmt.Sprintf("gt_%gms", r.Thresholds.Poor))
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	float := func(v float64) string { return strconv.FormatFloat(v, 'f', 3, 64) }
+	for _, h := range r.ByHour {
+		row := []string{
+			strconv.Itoa(h.Hour),
+			strconv.Itoa(h.Samples),
+			strconv.Itoa(h.Timeouts),
+			float(h.LossPercent),
+			float(h.AvgMs),
+			float(h.P95Ms),
+		}
+		for _, n := range h.Buckets {
+			row = append(row, strconv.Itoa(n))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
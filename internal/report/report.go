@@ -0,0 +1,208 @@
+package report
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/locale"
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/output"
+	"github.com/pbv7/pingheat/internal/types"
+	"github.com/pbv7/pingheat/internal/ui/colors"
+)
+
+// Report is the analysis of a recorded session.
+type Report struct {
+	Title    string
+	Start    time.Time
+	End      time.Time
+	Duration time.Duration
+	Stats    metrics.Stats
+	Hours    []Period
+	ByHour   []metrics.HourOfDay // Samples by hour of the day in local time, midnight first
+	Outages  []Outage
+	Segments []metrics.Segment // Stretches between gaps such as sleeps; one without gaps
+	Samples  []types.Sample    // Recorded samples, oldest first
+
+	// Thresholds color the HTML heatmap; Build sets the defaults.
+	Thresholds colors.Thresholds
+
+	// Format writes the numbers and times of the text, Markdown and HTML
+	// reports; the zero value is 24-hour and plain digits. CSV ignores it.
+	Format locale.Format
+}
+
+// Period summarizes samples within one time bucket.
+type Period struct {
+	Start       time.Time
+	Samples     int
+	Timeouts    int
+	LossPercent float64
+	AvgMs       float64
+	P95Ms       float64
+	MaxMs       float64
+}
+
+// Outage is a run of consecutive timeouts.
+type Outage struct {
+	Start    time.Time     // First lost sample
+	End      time.Time     // First reply after the run, or the last lost sample if none followed
+	Lost     int           // Number of lost samples
+	Duration time.Duration // End - Start
+}
+
+// Load reads samples recorded with -o json (one JSON object per line).
+// Blank lines are skipped; malformed lines are reported with their line number.
+func Load(r io.Reader) ([]types.Sample, error) {
+	var samples []types.Sample
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var rec output.Record
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		samples = append(samples, rec.Sample())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+// Build analyzes samples into a Report. Samples are sorted by timestamp first
+// so concatenated recordings are handled correctly.
+func Build(title string, samples []types.Sample) Report {
+	sorted := make([]types.Sample, len(samples))
+	copy(sorted, samples)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	r := Report{Title: title, Samples: sorted, Thresholds: colors.DefaultThresholds()}
+	if len(sorted) == 0 {
+		return r
+	}
+
+	engine := metrics.NewEngine()
+	for _, s := range sorted {
+		engine.Add(s)
+	}
+
+	r.Start = sorted[0].Timestamp
+	r.End = sorted[len(sorted)-1].Timestamp
+	r.Duration = r.End.Sub(r.Start)
+	r.Stats = engine.Stats()
+	// Session timing comes from the recording, not from when the report ran
+	r.Stats.StartTime = r.Start
+	r.Stats.UptimeSeconds = r.Duration.Seconds()
+	r.Stats.TimeSinceTimeout = 0
+
+	r.Hours = buildPeriods(sorted, time.Hour)
+	r.ByHour = buildHoursOfDay(sorted, r.Thresholds)
+	r.Outages = findOutages(sorted)
+	// Recordings keep the interval only in adaptive mode, so gaps are
+	// judged by clock jumps and metrics.MinGap otherwise
+	r.Segments = metrics.Segments(sorted, 0)
+	return r
+}
+
+// buildPeriods groups samples into consecutive buckets of the given size.
+func buildPeriods(samples []types.Sample, size time.Duration) []Period {
+	var periods []Period
+	var pc *metrics.PercentileCalculator
+	var sumMs float64
+
+	flush := func() {
+		if len(periods) == 0 {
+			return
+		}
+		p := &periods[len(periods)-1]
+		if p.Samples > 0 {
+			p.LossPercent = float64(p.Timeouts) / float64(p.Samples) * 100
+		}
+		if n := pc.Count(); n > 0 {
+			p.AvgMs = sumMs / float64(n)
+			p.P95Ms = pc.P95()
+		}
+	}
+
+	for _, s := range samples {
+		start := s.Timestamp.Truncate(size)
+		if len(periods) == 0 || !periods[len(periods)-1].Start.Equal(start) {
+			flush()
+			periods = append(periods, Period{Start: start})
+			pc = metrics.NewPercentileCalculator()
+			sumMs = 0
+		}
+
+		p := &periods[len(periods)-1]
+		p.Samples++
+		if s.Timeout {
+			p.Timeouts++
+			continue
+		}
+		ms := s.RTTMs()
+		pc.AddMs(ms)
+		sumMs += ms
+		if ms > p.MaxMs {
+			p.MaxMs = ms
+		}
+	}
+	flush()
+	return periods
+}
+
+// buildHoursOfDay sums samples by hour of the day across all days, with
+// replies split into the heatmap's latency bands.
+func buildHoursOfDay(samples []types.Sample, t colors.Thresholds) []metrics.HourOfDay {
+	tracker := metrics.NewHourOfDayTracker(time.Local)
+	for _, s := range samples {
+		tracker.Add(s)
+	}
+	return tracker.Hours(bandBounds(t))
+}
+
+// bandBounds returns the upper bounds of the latency bands below the last,
+// open one.
+func bandBounds(t colors.Thresholds) []float64 {
+	return []float64{t.Excellent, t.Good, t.Fair, t.Poor}
+}
+
+// findOutages returns every run of consecutive timeouts.
+func findOutages(samples []types.Sample) []Outage {
+	var outages []Outage
+	var current *Outage
+
+	for _, s := range samples {
+		if s.Timeout {
+			if current == nil {
+				outages = append(outages, Outage{Start: s.Timestamp})
+				current = &outages[len(outages)-1]
+			}
+			current.Lost++
+			current.End = s.Timestamp
+			continue
+		}
+		if current != nil {
+			current.End = s.Timestamp
+			current.Duration = current.End.Sub(current.Start)
+			current = nil
+		}
+	}
+	if current != nil {
+		current.Duration = current.End.Sub(current.Start)
+	}
+	return outages
+}
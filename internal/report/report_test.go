@@ -0,0 +1,233 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/locale"
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+var base = time.Date(2024, 1, 2, 10, 59, 58, 0, time.UTC)
+
+func sample(seq int, rttMs int, timeout bool) types.Sample {
+	return types.Sample{
+		Timestamp: base.Add(time.Duration(seq) * time.Second),
+		Sequence:  seq,
+		RTT:       time.Duration(rttMs) * time.Millisecond,
+		Timeout:   timeout,
+	}
+}
+
+func TestLoad(t *testing.T) {
+	input := `{"timestamp":"2024-01-02T10:00:00Z","seq":1,"rtt_ms":12.5,"timeout":false}
+
+{"timestamp":"2024-01-02T10:00:01Z","seq":2,"rtt_ms":null,"timeout":true}
+`
+	samples, err := Load(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(samples) != 2 {
+		t.Fatalf("len(samples) = %d, want 2", len(samples))
+	}
+	if samples[0].RTT != 12500*time.Microsecond || samples[0].Timeout {
+		t.Fatalf("samples[0] = %+v", samples[0])
+	}
+	if !samples[1].Timeout {
+		t.Fatalf("samples[1].Timeout = false, want true")
+	}
+}
+
+func TestLoadMalformedLine(t *testing.T) {
+	input := `{"timestamp":"2024-01-02T10:00:00Z","seq":1,"rtt_ms":12.5,"timeout":false}
+not json
+`
+	_, err := Load(strings.NewReader(input))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("Load() error = %v, want line 2 error", err)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	// Spans the 10:00 and 11:00 hours with one two-sample outage
+	samples := []types.Sample{
+		sample(3, 40, false),
+		sample(0, 10, false),
+		sample(1, 0, true),
+		sample(2, 0, true),
+		sample(4, 20, false),
+	}
+
+	r := Build("test", samples)
+
+	if r.Stats.TotalSamples != 5 || r.Stats.TotalSuccess != 3 {
+		t.Fatalf("stats totals = %d/%d, want 5/3", r.Stats.TotalSamples, r.Stats.TotalSuccess)
+	}
+	if r.Duration != 4*time.Second {
+		t.Fatalf("Duration = %v, want 4s", r.Duration)
+	}
+	if r.Stats.UptimeSeconds != 4 {
+		t.Fatalf("UptimeSeconds = %v, want 4", r.Stats.UptimeSeconds)
+	}
+
+	if len(r.Hours) != 2 {
+		t.Fatalf("len(Hours) = %d, want 2", len(r.Hours))
+	}
+	first := r.Hours[0]
+	if first.Samples != 2 || first.Timeouts != 1 || first.LossPercent != 50 || first.AvgMs != 10 {
+		t.Fatalf("Hours[0] = %+v", first)
+	}
+	second := r.Hours[1]
+	if second.Samples != 3 || second.Timeouts != 1 || second.MaxMs != 40 || second.AvgMs != 30 {
+		t.Fatalf("Hours[1] = %+v", second)
+	}
+
+	if len(r.Outages) != 1 {
+		t.Fatalf("len(Outages) = %d, want 1", len(r.Outages))
+	}
+	o := r.Outages[0]
+	if o.Lost != 2 || o.Duration != 2*time.Second {
+		t.Fatalf("Outages[0] = %+v, want 2 lost over 2s", o)
+	}
+}
+
+func TestBuildTrailingOutage(t *testing.T) {
+	r := Build("test", []types.Sample{sample(0, 10, false), sample(1, 0, true), sample(2, 0, true)})
+
+	if len(r.Outages) != 1 {
+		t.Fatalf("len(Outages) = %d, want 1", len(r.Outages))
+	}
+	if o := r.Outages[0]; o.Lost != 2 || o.Duration != time.Second {
+		t.Fatalf("Outages[0] = %+v, want 2 lost over 1s", o)
+	}
+}
+
+func TestBuildEmpty(t *testing.T) {
+	r := Build("empty", nil)
+	if r.Stats.TotalSamples != 0 || len(r.Hours) != 0 || len(r.Outages) != 0 {
+		t.Fatalf("Build(nil) = %+v, want empty report", r)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, r, FormatText); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+}
+
+func TestBuildSegments(t *testing.T) {
+	// The machine slept for an hour after the third sample
+	samples := []types.Sample{sample(0, 10, false), sample(1, 0, true), sample(2, 10, false), sample(3602, 20, false)}
+	r := Build("test", samples)
+	if len(r.Segments) != 2 || r.Segments[0].Stats.TotalSamples != 3 || r.Segments[1].Stats.TotalSamples != 1 {
+		t.Fatalf("Segments = %+v, want 3 samples and 1", r.Segments)
+	}
+
+	for format, want := range map[string]string{
+		FormatText:     "Segments (2, split at gaps)",
+		FormatMarkdown: "## Segments (2, split at gaps)",
+		FormatHTML:     "<h2>Segments (2, split at gaps)</h2>",
+	} {
+		var buf bytes.Buffer
+		if err := Write(&buf, r, format); err != nil {
+			t.Fatalf("Write(%s) error = %v", format, err)
+		}
+		if !strings.Contains(buf.String(), want) || !strings.Contains(buf.String(), "33.33%") {
+			t.Fatalf("%s output missing segments:\n%s", format, buf.String())
+		}
+	}
+
+	// A session without gaps has a single segment and no table
+	var buf bytes.Buffer
+	if err := Write(&buf, Build("test", samples[:3]), FormatText); err != nil || strings.Contains(buf.String(), "Segments") {
+		t.Fatalf("Write() error = %v, output:\n%s", err, buf.String())
+	}
+}
+
+func TestWriteFormats(t *testing.T) {
+	r := Build("session.jsonl", []types.Sample{sample(0, 10, false), sample(1, 0, true), sample(2, 30, false)})
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{FormatText, []string{"pingheat report: session.jsonl", "Hourly breakdown", "By hour of day", "Outages (1)", "p95:"}},
+		{FormatMarkdown, []string{"# pingheat report: session.jsonl", "| Loss | 33.33% |", "## By hour of day", "## Outages (1)"}},
+		{FormatHTML, []string{"<!DOCTYPE html>", "<h2>Outages (1)</h2>", "<td>33.33%</td>", "<h2>Heatmap</h2>", "<svg", "<th>&gt;300ms</th>"}},
+		{FormatCSV, []string{"hour,samples,lost,loss_percent,avg_ms,p95_ms,le_30ms,le_80ms,le_150ms,le_300ms,gt_300ms\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, r, tt.format); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Fatalf("output missing %q:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestWriteLocaleFormat(t *testing.T) {
+	r := Build("session.jsonl", []types.Sample{sample(0, 10, false), sample(1, 0, true), sample(2, 30, false)})
+	r.Format = locale.Format{Hour12: true, Comma: true, Group: "."}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, r, FormatMarkdown); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	for _, want := range []string{"| Loss | 33,33% |", "| Start | 2024-01-02 10:59:58 AM UTC |", "| 2024-01-02 11:00 AM |", "| p50 | 20,00 ms |"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("output missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := Write(&buf, r, FormatCSV); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if strings.Contains(buf.String(), "AM") || strings.Contains(buf.String(), "33,33") {
+		t.Fatalf("CSV is localized:\n%s", buf.String())
+	}
+}
+
+func TestWriteCSVByHour(t *testing.T) {
+	r := Build("session.jsonl", []types.Sample{sample(2, 10, false), sample(3, 0, true), sample(4, 400, false)})
+	hour := r.Start.Local().Hour()
+
+	var buf bytes.Buffer
+	if err := Write(&buf, r, FormatCSV); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 25 {
+		t.Fatalf("CSV has %d lines, want a header and 24 hours:\n%s", len(lines), buf.String())
+	}
+	want := fmt.Sprintf("%d,3,1,33.333,", hour)
+	if row := lines[1+hour]; !strings.HasPrefix(row, want) || !strings.HasSuffix(row, ",1,0,0,0,1") {
+		t.Fatalf("row for %02d:00 = %q, want %q... with one reply in the first and last bands", hour, row, want)
+	}
+}
+
+func TestWriteHTMLEscapesTitle(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, Build("<script>", nil), FormatHTML); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if strings.Contains(buf.String(), "<script>") {
+		t.Fatalf("title not escaped:\n%s", buf.String())
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, Report{}, "pdf"); err == nil {
+		t.Fatalf("Write(pdf) error = nil, want error")
+	}
+}
@@ -0,0 +1,164 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/types"
+	"github.com/pbv7/pingheat/internal/ui/colors"
+)
+
+// Heatmap layout. Long recordings are packed so the grid never exceeds
+// heatmapColumns × heatmapMaxRows cells; each cell then shows its worst sample.
+const (
+	heatmapColumns = 60
+	heatmapMaxRows = 120
+	heatmapCell    = 10 // px
+	heatmapGap     = 1  // px
+)
+
+// Latency graph layout.
+const (
+	graphWidth   = 900 // px
+	graphHeight  = 200 // px
+	graphPadding = 40  // px, room for axis labels
+)
+
+// cell is one heatmap square or graph point covering one or more samples.
+type cell struct {
+	start   time.Time
+	samples int
+	lost    int
+	maxMs   float64
+	sumMs   float64
+}
+
+// avgMs returns the mean RTT of the replies in the cell.
+func (c cell) avgMs() float64 {
+	if n := c.samples - c.lost; n > 0 {
+		return c.sumMs / float64(n)
+	}
+	return 0
+}
+
+// bucketSamples splits samples into at most maxCells equally sized cells.
+func bucketSamples(samples []types.Sample, maxCells int) []cell {
+	if len(samples) == 0 {
+		return nil
+	}
+	perCell := (len(samples) + maxCells - 1) / maxCells
+
+	cells := make([]cell, 0, (len(samples)+perCell-1)/perCell)
+	for i := 0; i < len(samples); i += perCell {
+		end := min(i+perCell, len(samples))
+		c := cell{start: samples[i].Timestamp}
+		for _, s := range samples[i:end] {
+			c.samples++
+			if s.Timeout {
+				c.lost++
+				continue
+			}
+			ms := s.RTTMs()
+			c.sumMs += ms
+			c.maxMs = max(c.maxMs, ms)
+		}
+		cells = append(cells, c)
+	}
+	return cells
+}
+
+// heatmapSVG renders samples as a grid of colored squares, oldest first,
+// left to right and top to bottom, matching the terminal heatmap.
+func heatmapSVG(samples []types.Sample, thresholds colors.Thresholds) template.HTML {
+	cells := bucketSamples(samples, heatmapColumns*heatmapMaxRows)
+	if len(cells) == 0 {
+		return ""
+	}
+
+	step := heatmapCell + heatmapGap
+	rows := (len(cells) + heatmapColumns - 1) / heatmapColumns
+	width := heatmapColumns * step
+	height := rows * step
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" role="img">`,
+		width, height, width, height)
+	for i, c := range cells {
+		x := (i % heatmapColumns) * step
+		y := (i / heatmapColumns) * step
+
+		color := thresholds.ClassifyMs(c.maxMs)
+		label := fmt.Sprintf("%s: max %.2fms", c.start.Format("2006-01-02 15:04:05"), c.maxMs)
+		if c.lost > 0 {
+			// Any loss in a cell outranks latency, as in the terminal view
+			color = colors.ColorTimeout
+			label = fmt.Sprintf("%s: %d of %d lost", c.start.Format("2006-01-02 15:04:05"), c.lost, c.samples)
+		}
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"><title>%s</title></rect>`,
+			x, y, heatmapCell, heatmapCell, color, label)
+	}
+	b.WriteString(`</svg>`)
+
+	return template.HTML(b.String()) // Built from numbers and timestamps only
+}
+
+// latencySVG renders average and maximum RTT over time, with lost samples
+// marked along the bottom edge.
+func latencySVG(samples []types.Sample) template.HTML {
+	cells := bucketSamples(samples, graphWidth)
+	if len(cells) == 0 {
+		return ""
+	}
+
+	var peak float64
+	for _, c := range cells {
+		peak = max(peak, c.maxMs)
+	}
+	if peak == 0 {
+		peak = 1
+	}
+
+	plotW := float64(graphWidth - graphPadding)
+	plotH := float64(graphHeight - graphPadding)
+	x := func(i int) float64 {
+		if len(cells) == 1 {
+			return graphPadding
+		}
+		return graphPadding + float64(i)*plotW/float64(len(cells)-1)
+	}
+	y := func(ms float64) float64 {
+		return plotH - ms/peak*plotH
+	}
+
+	var avg, worst, loss strings.Builder
+	for i, c := range cells {
+		if c.lost > 0 {
+			fmt.Fprintf(&loss, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f"/>`, x(i), plotH, x(i), plotH+8)
+		}
+		if c.lost == c.samples {
+			continue
+		}
+		fmt.Fprintf(&avg, "%.1f,%.1f ", x(i), y(c.avgMs()))
+		fmt.Fprintf(&worst, "%.1f,%.1f ", x(i), y(c.maxMs))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" role="img">`,
+		graphWidth, graphHeight, graphWidth, graphHeight)
+	fmt.Fprintf(&b, `<line x1="%d" y1="0" x2="%d" y2="%.1f" stroke="#999"/>`, graphPadding, graphPadding, plotH)
+	fmt.Fprintf(&b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#999"/>`, graphPadding, plotH, graphWidth, plotH)
+	fmt.Fprintf(&b, `<text x="%d" y="12" font-size="11" text-anchor="end">%.0fms</text>`, graphPadding-4, peak)
+	fmt.Fprintf(&b, `<text x="%d" y="%.1f" font-size="11" text-anchor="end">0</text>`, graphPadding-4, plotH)
+	fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="11">%s</text>`,
+		graphPadding, graphHeight-8, cells[0].start.Format("2006-01-02 15:04"))
+	fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="11" text-anchor="end">%s</text>`,
+		graphWidth, graphHeight-8, cells[len(cells)-1].start.Format("2006-01-02 15:04"))
+	fmt.Fprintf(&b, `<polyline fill="none" stroke="#FF8C00" stroke-width="1" points="%s"/>`, strings.TrimSpace(worst.String()))
+	fmt.Fprintf(&b, `<polyline fill="none" stroke="#1f77b4" stroke-width="1.5" points="%s"/>`, strings.TrimSpace(avg.String()))
+	fmt.Fprintf(&b, `<g stroke="%s" stroke-width="2">%s</g>`, colors.ColorTimeout, loss.String())
+	b.WriteString(`</svg>`)
+
+	return template.HTML(b.String()) // Built from numbers and timestamps only
+}
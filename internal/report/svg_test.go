@@ -0,0 +1,72 @@
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pbv7/pingheat/internal/types"
+	"github.com/pbv7/pingheat/internal/ui/colors"
+)
+
+func TestBucketSamples(t *testing.T) {
+	samples := []types.Sample{
+		sample(0, 10, false),
+		sample(1, 30, false),
+		sample(2, 0, true),
+		sample(3, 50, false),
+		sample(4, 70, false),
+	}
+
+	cells := bucketSamples(samples, 2)
+	if len(cells) != 2 {
+		t.Fatalf("len(cells) = %d, want 2", len(cells))
+	}
+	if c := cells[0]; c.samples != 3 || c.lost != 1 || c.maxMs != 30 || c.avgMs() != 20 {
+		t.Fatalf("cells[0] = %+v", c)
+	}
+	if c := cells[1]; c.samples != 2 || c.lost != 0 || c.maxMs != 70 || c.avgMs() != 60 {
+		t.Fatalf("cells[1] = %+v", c)
+	}
+
+	if cells := bucketSamples(nil, 10); cells != nil {
+		t.Fatalf("bucketSamples(nil) = %v, want nil", cells)
+	}
+}
+
+func TestHeatmapSVG(t *testing.T) {
+	svg := string(heatmapSVG([]types.Sample{sample(0, 10, false), sample(1, 0, true)}, colors.DefaultThresholds()))
+
+	if got := strings.Count(svg, "<rect"); got != 2 {
+		t.Fatalf("rect count = %d, want 2", got)
+	}
+	if !strings.Contains(svg, string(colors.ColorExcellent)) || !strings.Contains(svg, string(colors.ColorTimeout)) {
+		t.Fatalf("heatmap missing expected colors:\n%s", svg)
+	}
+}
+
+func TestHeatmapSVGPacksLongRecordings(t *testing.T) {
+	samples := make([]types.Sample, heatmapColumns*heatmapMaxRows*3)
+	for i := range samples {
+		samples[i] = sample(i, 10, false)
+	}
+
+	svg := string(heatmapSVG(samples, colors.DefaultThresholds()))
+	if got := strings.Count(svg, "<rect"); got != heatmapColumns*heatmapMaxRows {
+		t.Fatalf("rect count = %d, want %d", got, heatmapColumns*heatmapMaxRows)
+	}
+}
+
+func TestLatencySVG(t *testing.T) {
+	svg := string(latencySVG([]types.Sample{sample(0, 10, false), sample(1, 0, true), sample(2, 20, false)}))
+
+	if strings.Count(svg, "<polyline") != 2 {
+		t.Fatalf("expected avg and max polylines:\n%s", svg)
+	}
+	if !strings.Contains(svg, "20ms") {
+		t.Fatalf("missing peak label:\n%s", svg)
+	}
+
+	if latencySVG(nil) != "" {
+		t.Fatalf("latencySVG(nil) should be empty")
+	}
+}
@@ -0,0 +1,186 @@
+package screenshot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// style is the SGR state of a run of text. Colors are "#RRGGBB", or empty
+// for the terminal default.
+type style struct {
+	fg, bg    string
+	bold      bool
+	faint     bool
+	italic    bool
+	underline bool
+	reverse   bool
+}
+
+// span is a run of text in one style.
+type span struct {
+	text  string
+	style style
+}
+
+// parse splits a frame rendered with ANSI escape sequences into lines of
+// styled spans. SGR sequences set the style; any other escape sequence is
+// dropped.
+func parse(frame string) [][]span {
+	var lines [][]span
+	for line := range strings.SplitSeq(strings.TrimSuffix(frame, "\n"), "\n") {
+		lines = append(lines, parseLine(line))
+	}
+	return lines
+}
+
+// parseLine parses one line of a frame. Each line starts plain, as lipgloss
+// resets the style at the end of every line.
+func parseLine(line string) []span {
+	var spans []span
+	var cur style
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			spans = append(spans, span{text: text.String(), style: cur})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		if line[i] != '\x1b' {
+			if line[i] != '\r' {
+				text.WriteByte(line[i])
+			}
+			continue
+		}
+		if i+1 >= len(line) || line[i+1] != '[' {
+			continue // Lone ESC or a sequence other than CSI
+		}
+		end := i + 2
+		for end < len(line) && (line[end] < 0x40 || line[end] > 0x7e) {
+			end++
+		}
+		if end >= len(line) {
+			break
+		}
+		if line[end] == 'm' {
+			flush()
+			cur = applySGR(cur, line[i+2:end])
+		}
+		i = end
+	}
+	flush()
+	return spans
+}
+
+// applySGR returns s updated by the parameters of an SGR sequence.
+func applySGR(s style, params string) style {
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		code, _ := strconv.Atoi(codes[i])
+		switch {
+		case code == 0:
+			s = style{}
+		case code == 1:
+			s.bold = true
+		case code == 2:
+			s.faint = true
+		case code == 3:
+			s.italic = true
+		case code == 4:
+			s.underline = true
+		case code == 7:
+			s.reverse = true
+		case code == 22:
+			s.bold, s.faint = false, false
+		case code == 23:
+			s.italic = false
+		case code == 24:
+			s.underline = false
+		case code == 27:
+			s.reverse = false
+		case code >= 30 && code <= 37:
+			s.fg = ansiColor(code - 30)
+		case code >= 90 && code <= 97:
+			s.fg = ansiColor(code - 90 + 8)
+		case code >= 40 && code <= 47:
+			s.bg = ansiColor(code - 40)
+		case code >= 100 && code <= 107:
+			s.bg = ansiColor(code - 100 + 8)
+		case code == 39:
+			s.fg = ""
+		case code == 49:
+			s.bg = ""
+		case code == 38 || code == 48:
+			color, n := extendedColor(codes[i+1:])
+			i += n
+			if code == 38 {
+				s.fg = color
+			} else {
+				s.bg = color
+			}
+		}
+	}
+	return s
+}
+
+// extendedColor parses the arguments of an extended color, "5;N" or
+// "2;R;G;B", returning the color and how many arguments it took.
+func extendedColor(args []string) (string, int) {
+	if len(args) == 0 {
+		return "", 0
+	}
+	switch args[0] {
+	case "5":
+		if len(args) < 2 {
+			return "", len(args)
+		}
+		n, _ := strconv.Atoi(args[1])
+		return ansiColor(n), 2
+	case "2":
+		if len(args) < 4 {
+			return "", len(args)
+		}
+		var rgb [3]int
+		for i := range rgb {
+			rgb[i], _ = strconv.Atoi(args[1+i])
+		}
+		return hex(rgb[0], rgb[1], rgb[2]), 4
+	}
+	return "", 1
+}
+
+// basicColors are the 16 standard ANSI colors as xterm draws them.
+var basicColors = [16]string{
+	"#000000", "#CD0000", "#00CD00", "#CDCD00", "#0000EE", "#CD00CD", "#00CDCD", "#E5E5E5",
+	"#7F7F7F", "#FF0000", "#00FF00", "#FFFF00", "#5C5CFF", "#FF00FF", "#00FFFF", "#FFFFFF",
+}
+
+// ansiColor returns color n of the 256-color palette.
+func ansiColor(n int) string {
+	switch {
+	case n < 0 || n > 255:
+		return ""
+	case n < 16:
+		return basicColors[n]
+	case n < 232:
+		// 6×6×6 color cube
+		n -= 16
+		level := func(v int) int {
+			if v == 0 {
+				return 0
+			}
+			return 55 + v*40
+		}
+		return hex(level(n/36), level(n/6%6), level(n%6))
+	default:
+		v := 8 + (n-232)*10
+		return hex(v, v, v)
+	}
+}
+
+// hex formats a color as "#RRGGBB".
+func hex(r, g, b int) string {
+	return fmt.Sprintf("#%02X%02X%02X", r&0xff, g&0xff, b&0xff)
+}
@@ -0,0 +1,221 @@
+// Package screenshot saves a rendered terminal frame to a file, as the raw
+// ANSI text and optionally converted to HTML or SVG for sharing.
+package screenshot
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pbv7/pingheat/internal/userfile"
+)
+
+// Supported screenshot formats. Every screenshot is saved as ANSI text; HTML
+// and SVG add a converted copy next to it.
+const (
+	FormatANSI = "ansi"
+	FormatHTML = "html"
+	FormatSVG  = "svg"
+)
+
+// IsValidFormat reports whether format is a supported screenshot format.
+func IsValidFormat(format string) bool {
+	return format == FormatANSI || format == FormatHTML || format == FormatSVG
+}
+
+// Colors of the terminal default foreground and background in converted
+// screenshots.
+const (
+	defaultFG = "#D0D0D0"
+	defaultBG = "#1C1C1C"
+)
+
+// SVG character cell, in px.
+const (
+	svgFontSize = 14
+	svgCharW    = 8.4
+	svgLineH    = 17
+	svgPadding  = 8
+)
+
+// Save writes frame to dir as pingheat-YYYYMMDD-HHMMSS.ans, stamped with
+// now, and with a converted copy unless format is FormatANSI. A name
+// already taken gets a numbered suffix rather than being overwritten. It
+// returns the paths written.
+func Save(dir, frame, format string, now time.Time) ([]string, error) {
+	f, err := userfile.CreateNew(filepath.Join(dir, "pingheat-"+now.Format("20060102-150405")+".ans"))
+	if err != nil {
+		return nil, fmt.Errorf("screenshot: %w", err)
+	}
+	if _, err := io.WriteString(f, frame); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("screenshot: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("screenshot: %w", err)
+	}
+	paths := []string{f.Name()}
+	if format == FormatANSI {
+		return paths, nil
+	}
+
+	// The converted copy shares the name of the ANSI one
+	f, err = userfile.CreateNew(strings.TrimSuffix(f.Name(), ".ans") + "." + format)
+	if err != nil {
+		return paths, fmt.Errorf("screenshot: %w", err)
+	}
+	if err := Write(f, frame, format); err != nil {
+		_ = f.Close()
+		return paths, err
+	}
+	if err := f.Close(); err != nil {
+		return paths, fmt.Errorf("screenshot: %w", err)
+	}
+	return append(paths, f.Name()), nil
+}
+
+// Write converts frame to the given format.
+func Write(w io.Writer, frame, format string) error {
+	switch format {
+	case FormatANSI:
+		_, err := io.WriteString(w, frame)
+		return err
+	case FormatHTML:
+		return writeHTML(w, parse(frame))
+	case FormatSVG:
+		return writeSVG(w, parse(frame))
+	default:
+		return fmt.Errorf("unsupported screenshot format %q (want ansi, html or svg)", format)
+	}
+}
+
+// colors returns the foreground and background of s with the terminal
+// defaults filled in and reverse video applied.
+func (s style) colors() (fg, bg string) {
+	fg, bg = s.fg, s.bg
+	if fg == "" {
+		fg = defaultFG
+	}
+	if bg == "" {
+		bg = defaultBG
+	}
+	if s.reverse {
+		fg, bg = bg, fg
+	}
+	return fg, bg
+}
+
+// css returns the inline CSS of a span in style s.
+func (s style) css() string {
+	var b strings.Builder
+	fg, bg := s.colors()
+	if fg != defaultFG {
+		fmt.Fprintf(&b, "color:%s;", fg)
+	}
+	if bg != defaultBG {
+		fmt.Fprintf(&b, "background:%s;", bg)
+	}
+	if s.bold {
+		b.WriteString("font-weight:bold;")
+	}
+	if s.faint {
+		b.WriteString("opacity:0.6;")
+	}
+	if s.italic {
+		b.WriteString("font-style:italic;")
+	}
+	if s.underline {
+		b.WriteString("text-decoration:underline;")
+	}
+	return b.String()
+}
+
+// writeHTML renders lines as a self-contained HTML page.
+func writeHTML(w io.Writer, lines [][]span) error {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>pingheat</title></head>\n")
+	fmt.Fprintf(&b, "<body style=\"margin:0;background:%s\">\n", defaultBG)
+	fmt.Fprintf(&b, "<pre style=\"margin:0;padding:8px;color:%s;font-family:Menlo,Consolas,'DejaVu Sans Mono',monospace;line-height:1.2\">", defaultFG)
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		for _, sp := range line {
+			text := html.EscapeString(sp.text)
+			if css := sp.style.css(); css != "" {
+				fmt.Fprintf(&b, "<span style=\"%s\">%s</span>", css, text)
+			} else {
+				b.WriteString(text)
+			}
+		}
+	}
+	b.WriteString("</pre>\n</body></html>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeSVG renders lines as an SVG image with a monospace grid. Each span is
+// stretched to its number of cells, so box drawing and block characters
+// line up whatever font the viewer picks.
+func writeSVG(w io.Writer, lines [][]span) error {
+	cols := 0
+	for _, line := range lines {
+		n := 0
+		for _, sp := range line {
+			n += utf8.RuneCountInString(sp.text)
+		}
+		cols = max(cols, n)
+	}
+	width := float64(cols)*svgCharW + 2*svgPadding
+	height := len(lines)*svgLineH + 2*svgPadding
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%d" viewBox="0 0 %g %d">`+"\n", width, height, width, height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", defaultBG)
+	fmt.Fprintf(&b, `<g font-family="Menlo,Consolas,'DejaVu Sans Mono',monospace" font-size="%d" xml:space="preserve">`+"\n", svgFontSize)
+	for row, line := range lines {
+		y := svgPadding + row*svgLineH
+		col := 0
+		for _, sp := range line {
+			n := utf8.RuneCountInString(sp.text)
+			x := svgPadding + float64(col)*svgCharW
+			spanW := float64(n) * svgCharW
+			col += n
+			fg, bg := sp.style.colors()
+			if bg != defaultBG {
+				fmt.Fprintf(&b, `<rect x="%g" y="%d" width="%g" height="%d" fill="%s"/>`+"\n", x, y, spanW, svgLineH, bg)
+			}
+			if strings.TrimSpace(sp.text) == "" {
+				continue
+			}
+			// Full blocks, most of a heatmap, are drawn as solid cells as
+			// fonts leave gaps between block glyphs
+			if strings.Trim(sp.text, "█") == "" {
+				fmt.Fprintf(&b, `<rect x="%g" y="%d" width="%g" height="%d" fill="%s"/>`+"\n", x, y, spanW, svgLineH, fg)
+				continue
+			}
+			attrs := fmt.Sprintf(`fill="%s"`, fg)
+			if sp.style.bold {
+				attrs += ` font-weight="bold"`
+			}
+			if sp.style.faint {
+				attrs += ` fill-opacity="0.6"`
+			}
+			if sp.style.italic {
+				attrs += ` font-style="italic"`
+			}
+			if sp.style.underline {
+				attrs += ` text-decoration="underline"`
+			}
+			fmt.Fprintf(&b, `<text x="%g" y="%d" textLength="%g" lengthAdjust="spacingAndGlyphs" %s>%s</text>`+"\n",
+				x, y+svgLineH-4, spanW, attrs, html.EscapeString(sp.text))
+		}
+	}
+	b.WriteString("</g>\n</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
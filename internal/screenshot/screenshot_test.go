@@ -0,0 +1,115 @@
+package screenshot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// frame is two lines as lipgloss renders them: a truecolor block, a
+// 256-color label on a basic background, and plain text.
+const frame = "\x1b[38;2;0;255;0m██\x1b[0m ok\n\x1b[1;38;5;196;44mbad\x1b[0m <x>\n"
+
+func TestParse(t *testing.T) {
+	lines := parse(frame)
+	if len(lines) != 2 {
+		t.Fatalf("parse returned %d lines, want 2", len(lines))
+	}
+
+	want := [][]span{
+		{{text: "██", style: style{fg: "#00FF00"}}, {text: " ok"}},
+		{{text: "bad", style: style{fg: "#FF0000", bg: "#0000EE", bold: true}}, {text: " <x>"}},
+	}
+	for i := range want {
+		if len(lines[i]) != len(want[i]) {
+			t.Fatalf("line %d = %+v, want %+v", i, lines[i], want[i])
+		}
+		for j := range want[i] {
+			if lines[i][j] != want[i][j] {
+				t.Fatalf("line %d span %d = %+v, want %+v", i, j, lines[i][j], want[i][j])
+			}
+		}
+	}
+}
+
+func TestAnsiColor(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{1, "#CD0000"},
+		{9, "#FF0000"},
+		{16, "#000000"},
+		{196, "#FF0000"},
+		{231, "#FFFFFF"},
+		{232, "#080808"},
+		{255, "#EEEEEE"},
+		{256, ""},
+	}
+	for _, tt := range tests {
+		if got := ansiColor(tt.n); got != tt.want {
+			t.Fatalf("ansiColor(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{FormatANSI, []string{frame}},
+		{FormatHTML, []string{`<span style="color:#00FF00;">██</span> ok`, `font-weight:bold;`, "&lt;x&gt;"}},
+		{FormatSVG, []string{`fill="#00FF00"/>`, `fill="#0000EE"/>`, `font-weight="bold">bad</text>`, "&lt;x&gt;"}},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		if err := Write(&b, frame, tt.format); err != nil {
+			t.Fatalf("Write(%s): %v", tt.format, err)
+		}
+		for _, w := range tt.want {
+			if !strings.Contains(b.String(), w) {
+				t.Fatalf("Write(%s) lacks %q:\n%s", tt.format, w, b.String())
+			}
+		}
+	}
+	if err := Write(&strings.Builder{}, frame, "png"); err == nil {
+		t.Fatalf("Write(png) succeeded, want an error")
+	}
+}
+
+func TestSave(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 1, 2, 10, 4, 5, 0, time.UTC)
+
+	paths, err := Save(dir, frame, FormatSVG, now)
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	want := []string{
+		filepath.Join(dir, "pingheat-20240102-100405.ans"),
+		filepath.Join(dir, "pingheat-20240102-100405.svg"),
+	}
+	if len(paths) != len(want) || paths[0] != want[0] || paths[1] != want[1] {
+		t.Fatalf("Save wrote %v, want %v", paths, want)
+	}
+	raw, err := os.ReadFile(paths[0])
+	if err != nil || string(raw) != frame {
+		t.Fatalf("ANSI screenshot = %q (%v), want the frame as is", raw, err)
+	}
+
+	// A second screenshot within the same second gets its own files
+	paths, err = Save(dir, frame, FormatSVG, now)
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	want = []string{
+		filepath.Join(dir, "pingheat-20240102-100405-2.ans"),
+		filepath.Join(dir, "pingheat-20240102-100405-2.svg"),
+	}
+	if len(paths) != len(want) || paths[0] != want[0] || paths[1] != want[1] {
+		t.Fatalf("second Save wrote %v, want %v", paths, want)
+	}
+}
@@ -0,0 +1,239 @@
+// Package script runs user hooks written in Starlark, a small dialect of
+// Python, for every sample. A script defines
+//
+//	def on_sample(sample, stats):
+//	    ...
+//
+// and may call set_gauge(name, value, help="") to export a derived metric as
+// pingheat_script_<name>, notify(message) to send a message through the
+// alert notifiers, and print(...) to write to the runtime log. Module-level
+// values are frozen once the script loaded; the predeclared dict state keeps
+// values between calls.
+package script
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/log"
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/types"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+// ErrInvalidScript is returned for scripts that cannot be loaded.
+var ErrInvalidScript = errors.New("invalid script")
+
+const (
+	// hookName is the function a script must define.
+	hookName = "on_sample"
+
+	// maxSteps bounds the work of one hook call, so a runaway loop cannot
+	// stall sampling.
+	maxSteps = 1_000_000
+
+	// maxGauges bounds how many gauges a script may export.
+	maxGauges = 100
+
+	// defaultHelp describes gauges set without help text.
+	defaultHelp = "Set by the script hook"
+)
+
+// gaugeNamePattern matches the names set_gauge accepts, which become
+// pingheat_script_<name>.
+var gaugeNamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// fileOptions allows while loops and top-level if/for statements, which
+// Starlark disables by default.
+var fileOptions = &syntax.FileOptions{While: true, TopLevelControl: true, Set: true}
+
+// Gauge is a value a script exported with set_gauge.
+type Gauge struct {
+	Name  string // Without the pingheat_script_ prefix
+	Help  string
+	Value float64
+}
+
+// Hooks runs the on_sample hook of a script. Update must not be called
+// concurrently; Gauges may be read at any time.
+type Hooks struct {
+	path    string
+	hook    starlark.Callable
+	globals starlark.StringDict
+	logger  *slog.Logger
+	notify  func(text string) // nil logs the messages only
+	lastErr string            // Last hook error, logged once until it changes
+
+	mu     sync.RWMutex
+	gauges map[string]Gauge
+}
+
+// Load runs the script at path and returns its hooks.
+func Load(path string) (*Hooks, error) {
+	h := &Hooks{path: path, logger: log.Discard(), gauges: make(map[string]Gauge)}
+	h.globals = starlark.StringDict{
+		"state":     starlark.NewDict(0),
+		"set_gauge": starlark.NewBuiltin("set_gauge", h.setGauge),
+		"notify":    starlark.NewBuiltin("notify", h.sendNotify),
+	}
+
+	globals, err := starlark.ExecFileOptions(fileOptions, h.thread(), path, nil, h.globals)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidScript, describe(err))
+	}
+	hook, ok := globals[hookName].(starlark.Callable)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s does not define %s(sample, stats)", ErrInvalidScript, path, hookName)
+	}
+	h.hook = hook
+	return h, nil
+}
+
+// SetLogger sets the logger for print output and hook errors.
+func (h *Hooks) SetLogger(l *slog.Logger) {
+	h.logger = l.With("component", "script")
+}
+
+// SetNotify makes notify send messages through fn, such as the alert
+// dispatcher. Call it before Update.
+func (h *Hooks) SetNotify(fn func(text string)) {
+	h.notify = fn
+}
+
+// Update calls the hook with the sample and the statistics after it. An
+// error is logged once until the hook fails differently or recovers.
+func (h *Hooks) Update(sample types.Sample, stats metrics.Stats) {
+	_, err := starlark.Call(h.thread(), h.hook, starlark.Tuple{sampleValue(sample), statsValue(stats)}, nil)
+	msg := ""
+	if err != nil {
+		msg = describe(err)
+	}
+	if msg != h.lastErr && msg != "" {
+		h.logger.Warn("script hook failed", "script", h.path, "error", msg)
+	}
+	h.lastErr = msg
+}
+
+// Gauges returns the gauges the script set, sorted by name.
+func (h *Hooks) Gauges() []Gauge {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return slices.SortedFunc(maps.Values(h.gauges), func(a, b Gauge) int { return cmp.Compare(a.Name, b.Name) })
+}
+
+// thread returns a fresh thread for one run, with its own step budget.
+func (h *Hooks) thread() *starlark.Thread {
+	t := &starlark.Thread{
+		Name:  h.path,
+		Print: func(_ *starlark.Thread, msg string) { h.logger.Info(msg, "script", h.path) },
+	}
+	t.SetMaxExecutionSteps(maxSteps)
+	return t
+}
+
+// setGauge implements set_gauge(name, value, help="").
+func (h *Hooks) setGauge(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name, help string
+	var value starlark.Value
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &name, "value", &value, "help?", &help); err != nil {
+		return nil, err
+	}
+	if !gaugeNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("%s: invalid name %q (want lowercase letters, digits and underscores)", b.Name(), name)
+	}
+	v, ok := starlark.AsFloat(value)
+	if !ok {
+		return nil, fmt.Errorf("%s: value for %q must be a number, not %s", b.Name(), name, value.Type())
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	g, exists := h.gauges[name]
+	if !exists && len(h.gauges) >= maxGauges {
+		return nil, fmt.Errorf("%s: more than %d gauges", b.Name(), maxGauges)
+	}
+	g.Name, g.Value = name, v
+	if help != "" {
+		g.Help = help
+	} else if g.Help == "" {
+		g.Help = defaultHelp
+	}
+	h.gauges[name] = g
+	return starlark.None, nil
+}
+
+// sendNotify implements notify(message).
+func (h *Hooks) sendNotify(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var text string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "message", &text); err != nil {
+		return nil, err
+	}
+	h.logger.Warn("script notification", "script", h.path, "message", text)
+	if h.notify != nil {
+		h.notify(text)
+	}
+	return starlark.None, nil
+}
+
+// sampleValue exposes a sample to the hook. rtt_ms is None for timeouts.
+func sampleValue(s types.Sample) starlark.Value {
+	rtt := starlark.Value(starlark.None)
+	if !s.Timeout {
+		rtt = starlark.Float(s.RTTMs())
+	}
+	kind := ""
+	if s.Timeout {
+		kind = s.Kind().String()
+	}
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"timestamp":   starlark.Float(float64(s.Timestamp.UnixNano()) / float64(time.Second)),
+		"seq":         starlark.MakeInt(s.Sequence),
+		"rtt_ms":      rtt,
+		"timeout":     starlark.Bool(s.Timeout),
+		"error":       starlark.String(kind),
+		"maintenance": starlark.Bool(s.Maintenance),
+		"clock_jump":  starlark.Bool(s.ClockJump),
+	})
+}
+
+// statsValue exposes the session statistics to the hook.
+func statsValue(s metrics.Stats) starlark.Value {
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"samples":      starlark.MakeInt(s.TotalSamples),
+		"timeouts":     starlark.MakeInt(s.TotalTimeouts),
+		"loss_percent": starlark.Float(s.LossPercent),
+		"min_rtt_ms":   starlark.Float(s.MinRTTMs),
+		"avg_rtt_ms":   starlark.Float(s.AvgRTTMs),
+		"max_rtt_ms":   starlark.Float(s.MaxRTTMs),
+		"stddev_ms":    starlark.Float(s.StdDevMs),
+		"jitter_ms":    starlark.Float(s.JitterMs),
+		"p50_ms":       starlark.Float(s.Percentiles.P50),
+		"p90_ms":       starlark.Float(s.Percentiles.P90),
+		"p95_ms":       starlark.Float(s.Percentiles.P95),
+		"p99_ms":       starlark.Float(s.Percentiles.P99),
+		"streak":       starlark.MakeInt(s.CurrentStreak),
+		"outages":      starlark.MakeInt(s.LossBursts),
+		"downtime_s":   starlark.Float(s.Downtime.Seconds()),
+		"mtbf_s":       starlark.Float(s.MTBF.Seconds()),
+		"mttr_s":       starlark.Float(s.MTTR.Seconds()),
+	})
+}
+
+// describe formats a script error with its Starlark backtrace when it has
+// one, which names the failing line.
+func describe(err error) string {
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		return evalErr.Backtrace()
+	}
+	return err.Error()
+}
@@ -0,0 +1,98 @@
+package script
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/log"
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+func writeScript(t *testing.T, src string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "hooks.star")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestHooks(t *testing.T) {
+	path := writeScript(t, `
+def on_sample(sample, stats):
+    state["seen"] = state.get("seen", 0) + 1
+    set_gauge("seen", state["seen"])
+    set_gauge("score", 100 - stats.loss_percent * 10 - stats.jitter_ms, help="Connection score")
+    if sample.timeout and not state.get("notified"):
+        state["notified"] = True
+        notify("lost seq %d: %s" % (sample.seq, sample.error))
+`)
+	h, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	var sent []string
+	h.SetNotify(func(text string) { sent = append(sent, text) })
+
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	h.Update(types.Sample{Timestamp: now, Sequence: 1, RTT: 10 * time.Millisecond}, metrics.Stats{JitterMs: 2})
+	h.Update(types.Sample{Timestamp: now, Sequence: 2, Timeout: true}, metrics.Stats{LossPercent: 5, JitterMs: 2})
+	h.Update(types.Sample{Timestamp: now, Sequence: 3, Timeout: true}, metrics.Stats{LossPercent: 6, JitterMs: 2})
+
+	gauges := h.Gauges()
+	want := []Gauge{{Name: "score", Help: "Connection score", Value: 38}, {Name: "seen", Help: defaultHelp, Value: 3}}
+	if len(gauges) != len(want) || gauges[0] != want[0] || gauges[1] != want[1] {
+		t.Fatalf("Gauges() = %+v, want %+v", gauges, want)
+	}
+	if len(sent) != 1 || sent[0] != "lost seq 2: timeout" {
+		t.Fatalf("notifications = %q, want one for seq 2", sent)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := map[string]string{
+		"syntax":  "def on_sample(sample, stats)\n",
+		"no hook": "x = 1\n",
+		"runtime": "fail(\"broken\")\n",
+	}
+	for name, src := range tests {
+		if _, err := Load(writeScript(t, src)); !errors.Is(err, ErrInvalidScript) {
+			t.Fatalf("%s: Load() error = %v, want ErrInvalidScript", name, err)
+		}
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.star")); !errors.Is(err, ErrInvalidScript) {
+		t.Fatalf("missing file: Load() error = %v, want ErrInvalidScript", err)
+	}
+}
+
+func TestHookFailuresLogged(t *testing.T) {
+	h, err := Load(writeScript(t, `
+def on_sample(sample, stats):
+    if sample.seq == 2:
+        set_gauge("Bad-Name", 1)
+    while True:
+        if sample.seq != 3:
+            break
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	var logged bytes.Buffer
+	h.SetLogger(log.New(&logged, slog.LevelInfo, nil))
+
+	// A failure is logged once until it changes; runaway loops are cut off
+	for _, seq := range []int{1, 2, 2, 3} {
+		h.Update(types.Sample{Sequence: seq}, metrics.Stats{})
+	}
+	out := logged.String()
+	if strings.Count(out, "script hook failed") != 2 || !strings.Contains(out, "invalid name") || !strings.Contains(out, "too many steps") {
+		t.Fatalf("log = %q, want the bad gauge and the runaway loop once each", out)
+	}
+}
@@ -0,0 +1,38 @@
+// Package sysmem reports how much memory the system has available, to
+// keep history buffers from outgrowing it.
+package sysmem
+
+import (
+	"bufio"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupported is returned where the platform offers no way to tell.
+var ErrUnsupported = errors.New("available memory unknown on this platform")
+
+// Available returns the memory in bytes pingheat may use without pushing the
+// system into swap: MemAvailable on Linux, free physical memory on Windows
+// and the physical memory on macOS, which counts memory used by caches as
+// free.
+func Available() (uint64, error) {
+	return available()
+}
+
+// parseMeminfo returns MemAvailable from /proc/meminfo.
+func parseMeminfo(meminfo string) (uint64, error) {
+	sc := bufio.NewScanner(strings.NewReader(meminfo))
+	for sc.Scan() {
+		value, ok := strings.CutPrefix(sc.Text(), "MemAvailable:")
+		if !ok {
+			continue
+		}
+		kb, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimSpace(value), " kB"), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return kb * 1024, nil
+	}
+	return 0, ErrUnsupported
+}
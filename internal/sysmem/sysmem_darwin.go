@@ -0,0 +1,8 @@
+package sysmem
+
+import "golang.org/x/sys/unix"
+
+// available returns the physical memory.
+func available() (uint64, error) {
+	return unix.SysctlUint64("hw.memsize")
+}
@@ -0,0 +1,12 @@
+package sysmem
+
+import "os"
+
+// available reads MemAvailable from /proc/meminfo.
+func available() (uint64, error) {
+	data, err := os.ReadFile("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	return parseMeminfo(string(data))
+}
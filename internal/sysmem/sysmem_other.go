@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !windows
+
+package sysmem
+
+// available is not implemented on this platform.
+func available() (uint64, error) {
+	return 0, ErrUnsupported
+}
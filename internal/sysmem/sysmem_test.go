@@ -0,0 +1,17 @@
+package sysmem
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMeminfo(t *testing.T) {
+	got, err := parseMeminfo("MemTotal:       16303548 kB\nMemFree:         1021432 kB\nMemAvailable:    9731204 kB\n")
+	if err != nil || got != 9731204*1024 {
+		t.Fatalf("parseMeminfo() = %d, %v, want %d", got, err, 9731204*1024)
+	}
+	// Kernels before 3.14 do not report MemAvailable
+	if _, err := parseMeminfo("MemTotal:       16303548 kB\n"); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("parseMeminfo() without MemAvailable error = %v, want ErrUnsupported", err)
+	}
+}
@@ -0,0 +1,49 @@
+package types
+
+// ErrorKind classifies why a probe got no reply.
+type ErrorKind uint8
+
+const (
+	ErrorNone            ErrorKind = iota // Reply received
+	ErrorTimeout                          // No reply before the timeout
+	ErrorHostUnreachable                  // ICMP host unreachable
+	ErrorNetUnreachable                   // ICMP network unreachable
+	ErrorTTLExceeded                      // ICMP time exceeded in transit
+	ErrorAdminProhibited                  // ICMP communication administratively prohibited
+
+	// ErrorKindCount is the number of kinds, for arrays indexed by ErrorKind.
+	ErrorKindCount = iota
+)
+
+// errorKindNames holds the metric label and serialized name of each kind.
+var errorKindNames = [ErrorKindCount]string{
+	ErrorNone:            "",
+	ErrorTimeout:         "timeout",
+	ErrorHostUnreachable: "host_unreachable",
+	ErrorNetUnreachable:  "net_unreachable",
+	ErrorTTLExceeded:     "ttl_exceeded",
+	ErrorAdminProhibited: "admin_prohibited",
+}
+
+// ErrorKinds lists the failure kinds in display order.
+var ErrorKinds = []ErrorKind{
+	ErrorTimeout, ErrorHostUnreachable, ErrorNetUnreachable, ErrorTTLExceeded, ErrorAdminProhibited,
+}
+
+// String returns the kind's name ("" for ErrorNone and unknown kinds).
+func (k ErrorKind) String() string {
+	if int(k) >= len(errorKindNames) {
+		return ""
+	}
+	return errorKindNames[k]
+}
+
+// ParseErrorKind returns the kind named s, or ErrorNone if there is none.
+func ParseErrorKind(s string) ErrorKind {
+	for k, name := range errorKindNames {
+		if name != "" && name == s {
+			return ErrorKind(k)
+		}
+	}
+	return ErrorNone
+}
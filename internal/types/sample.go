@@ -1,6 +1,12 @@
 package types
 
-import "time"
+import (
+	"time"
+	"unsafe"
+)
+
+// SampleBytes is the memory a Sample takes in a history buffer.
+const SampleBytes = int(unsafe.Sizeof(Sample{}))
 
 // Sample represents a single ping measurement.
 type Sample struct {
@@ -8,6 +14,31 @@ type Sample struct {
 	Sequence  int
 	RTT       time.Duration
 	Timeout   bool
+
+	// ErrorKind tells why a timeout sample got no reply. Sources that
+	// cannot tell leave it at ErrorNone; use Kind to read it.
+	ErrorKind ErrorKind
+
+	// Interval is the probe interval the sample was taken at, recorded in
+	// adaptive mode where it changes during outages (0 = not recorded).
+	Interval time.Duration
+
+	// Burst is the number of probes sent together in the interval of this
+	// one with -burst; they share its Sequence (0 = not bursting).
+	Burst int
+
+	// Signal is the Wi-Fi signal strength in dBm when the sample was taken
+	// with -wifi (0 = not recorded).
+	Signal int
+
+	// Maintenance is set for samples taken during a scheduled maintenance
+	// window: they are recorded and shown but do not count toward SLA.
+	Maintenance bool
+
+	// ClockJump is set on the first sample after the system clock jumped,
+	// on a suspend and resume or an NTP step. The time since the previous
+	// sample is a gap in the timeline, not an outage.
+	ClockJump bool
 }
 
 // IsTimeout returns true if this sample represents a timeout.
@@ -15,6 +46,19 @@ func (s Sample) IsTimeout() bool {
 	return s.Timeout
 }
 
+// Kind returns the failure kind of the sample: ErrorNone for replies and
+// ErrorTimeout for timeouts without a more specific kind.
+func (s Sample) Kind() ErrorKind {
+	switch {
+	case !s.Timeout:
+		return ErrorNone
+	case s.ErrorKind == ErrorNone:
+		return ErrorTimeout
+	default:
+		return s.ErrorKind
+	}
+}
+
 // RTTMs returns the RTT in milliseconds.
 func (s Sample) RTTMs() float64 {
 	if s.Timeout {
@@ -16,3 +16,28 @@ func TestRTTMs(t *testing.T) {
 		t.Fatalf("RTTMs() timeout = %v, want -1", got)
 	}
 }
+
+func TestSampleKind(t *testing.T) {
+	tests := []struct {
+		sample Sample
+		want   ErrorKind
+	}{
+		{Sample{RTT: time.Millisecond}, ErrorNone},
+		{Sample{Timeout: true}, ErrorTimeout},
+		{Sample{Timeout: true, ErrorKind: ErrorTTLExceeded}, ErrorTTLExceeded},
+	}
+	for _, tt := range tests {
+		if got := tt.sample.Kind(); got != tt.want {
+			t.Fatalf("Kind(%+v) = %v, want %v", tt.sample, got, tt.want)
+		}
+	}
+
+	for _, k := range ErrorKinds {
+		if got := ParseErrorKind(k.String()); got != k {
+			t.Fatalf("ParseErrorKind(%q) = %v, want %v", k.String(), got, k)
+		}
+	}
+	if got := ParseErrorKind(""); got != ErrorNone {
+		t.Fatalf("ParseErrorKind(\"\") = %v, want none", got)
+	}
+}
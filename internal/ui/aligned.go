@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/buffer"
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/ping"
+)
+
+// alignedLabelWidth is the width of the "15:04 " label starting each row of
+// the aligned view.
+const alignedLabelWidth = 6
+
+// alignedSlot returns how much time each cell of an aligned row covers: one
+// interval, or several when a minute of samples does not fit in the width.
+func (m Model) alignedSlot(cols int) time.Duration {
+	interval := max(m.config.Interval, time.Millisecond)
+	slots := max(1, cols-alignedLabelWidth)
+	perMinute := int((time.Minute + interval - 1) / interval)
+	perSlot := (perMinute + slots - 1) / slots
+	return interval * time.Duration(perSlot)
+}
+
+// renderAligned renders the aligned view: one row per minute, oldest on top,
+// with each sample placed in the cell for its offset into the minute. The
+// same column is the same second on every row, so a cell left empty is time
+// without a sample, and periodic trouble lines up vertically. A cell holding
+// several samples takes the color of the worst.
+func (m Model) renderAligned(cols, rows int) string {
+	lines := make([]string, rows)
+	for r := range lines {
+		lines[r] = strings.Repeat(" ", cols)
+	}
+
+	first, hasFirst := m.alignedStart()
+	start, end, ok := m.visibleRange(m.itemCount())
+	if hasFirst && ok {
+		slot := m.alignedSlot(cols)
+		slots := min(cols-alignedLabelWidth, int((time.Minute+slot-1)/slot))
+		for r := range min(rows, end-start+1) {
+			minute := first.Add(time.Duration(start+r) * time.Minute)
+			lines[r] = m.alignedRow(minute, slot, slots, cols)
+		}
+	}
+
+	return HeatmapBorderStyle.Render(strings.Join(lines, "\n")) + "\n"
+}
+
+// alignedRow renders the row of the minute starting at minute, cols wide.
+func (m Model) alignedRow(minute time.Time, slot time.Duration, slots, cols int) string {
+	if slots < 1 {
+		return strings.Repeat(" ", cols)
+	}
+	var row strings.Builder
+	row.WriteString(LabelStyle.Render(minute.In(m.config.Location()).Format("15:04") + " "))
+
+	idx := buffer.FindByTime(m.samples, minute, sampleTime)
+	prev, hasPrev := m.samples.Get(idx - 1)
+	end := minute.Add(time.Minute)
+	cell := make([]ping.Sample, 0, 8)
+	current, gap := 0, false
+	flush := func(next int) {
+		if len(cell) > 0 {
+			row.WriteString(m.renderCell(cell, gap))
+			current++
+			cell, gap = cell[:0], false
+		}
+		row.WriteString(strings.Repeat(" ", max(0, next-current)))
+		current = max(current, next)
+	}
+	m.samples.RangeFrom(idx, func(_ int, sample ping.Sample) bool {
+		if !sample.Timestamp.Before(end) {
+			return false
+		}
+		// A sample behind the one before, after a clock step, joins its cell
+		n := max(min(int(sample.Timestamp.Sub(minute)/slot), slots-1), current)
+		if n != current {
+			flush(n)
+		}
+		if sample.ClockJump || hasPrev && metrics.IsGap(prev, sample, m.config.Interval) {
+			gap = true
+		}
+		prev, hasPrev = sample, true
+		cell = append(cell, sample)
+		return true
+	})
+	flush(cols - alignedLabelWidth)
+	return row.String()
+}
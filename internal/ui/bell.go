@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Outage transitions announced with -bell.
+const (
+	bellDown = "down"
+	bellUp   = "up"
+)
+
+// bellCommandTimeout bounds a -bell command, so a stuck player cannot pile
+// up processes over a flapping link.
+const bellCommandTimeout = 30 * time.Second
+
+// ringBell rings the terminal bell. Tests replace it.
+var ringBell = func() {
+	_, _ = os.Stdout.WriteString("\a")
+}
+
+// isDown reports whether the target lost enough samples in a row to count as
+// down, as shown by the outage banner.
+func (m Model) isDown() bool {
+	return m.config.DownAfter > 0 && m.downCount >= m.config.DownAfter
+}
+
+// bellCmd announces a pending transition with -bell: the terminal bell, or
+// the -bell command with PINGHEAT_EVENT set to "down" or "up". An outage
+// starting less than the cooldown after the last announced one stays quiet,
+// and so does its end; the end of an announced outage is always announced.
+// It clears the pending transition.
+func (m *Model) bellCmd() tea.Cmd {
+	event := m.bellEvent
+	m.bellEvent = ""
+	if event == "" || !m.config.Bell {
+		return nil
+	}
+	switch event {
+	case bellDown:
+		if !m.lastBell.IsZero() && m.now.Sub(m.lastBell) < m.config.BellCooldown {
+			return nil
+		}
+		m.lastBell, m.bellDown = m.now, true
+	case bellUp:
+		if !m.bellDown {
+			return nil
+		}
+		m.bellDown = false
+	}
+
+	args := strings.Fields(m.config.BellCommand)
+	if len(args) == 0 {
+		ringBell()
+		return nil
+	}
+	target := m.config.Target
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), bellCommandTimeout)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Env = append(os.Environ(), "PINGHEAT_EVENT="+event, "PINGHEAT_TARGET="+target)
+		if err := cmd.Run(); err != nil {
+			return ErrorMsg{Err: fmt.Errorf("bell command: %w", err)}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/ping"
+)
+
+// benchSample returns the i-th sample of a synthetic session with some loss.
+func benchSample(i int) ping.Sample {
+	sample := ping.Sample{Sequence: i + 1, Timestamp: time.Unix(0, 0).Add(time.Duration(i) * time.Second)}
+	if i%97 == 0 {
+		sample.Timeout = true
+	} else {
+		sample.RTT = time.Duration(10+i%80) * time.Millisecond
+	}
+	return sample
+}
+
+func BenchmarkRenderHeatmap(b *testing.B) {
+	tests := []struct {
+		name      string
+		newSample bool // A sample arrives before every frame
+	}{
+		{"unchanged", false},
+		{"new_sample", true},
+	}
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			model := newTestModel()
+			model.width = 200
+			model.height = 60
+			n := 20000
+			for i := range n {
+				model.samples.Push(benchSample(i))
+			}
+			for b.Loop() {
+				if tt.newSample {
+					model.samples.Push(benchSample(n))
+					n++
+				}
+				_ = model.renderHeatmap()
+			}
+		})
+	}
+}
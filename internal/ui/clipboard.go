@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/muesli/termenv"
+)
+
+// copyToClipboard asks the terminal to put text on the system clipboard
+// with an OSC 52 escape sequence. Terminals that do not support it, or
+// have it turned off, ignore the sequence. Tests replace it.
+var copyToClipboard = termenv.Copy
+
+// statsSummary returns a plain-text summary of the session for pasting into
+// a chat or ticket.
+func (m Model) statsSummary() string {
+	s, f := m.stats, m.config.Format
+	var b strings.Builder
+	fmt.Fprintf(&b, "pingheat %s\n", m.config.Target)
+	fmt.Fprintf(&b, "Duration: %v (%s samples)\n",
+		time.Duration(s.UptimeSeconds*float64(time.Second)).Round(time.Second), f.Int(s.TotalSamples))
+	fmt.Fprintf(&b, "Loss: %s (%s lost)\n", f.Percent(s.LossPercent, 2), f.Int(s.TotalTimeouts))
+	if s.TotalSuccess > 0 {
+		fmt.Fprintf(&b, "p95: %sms (avg %sms, max %sms)\n", f.Float(s.Percentiles.P95, 1), f.Float(s.AvgRTTMs, 1), f.Float(s.MaxRTTMs, 1))
+	} else {
+		b.WriteString("p95: -\n")
+	}
+	fmt.Fprintf(&b, "Outages: %s (downtime %v)\n", f.Int(s.LossBursts), s.Downtime.Round(time.Second))
+	return b.String()
+}
@@ -1,20 +1,72 @@
 package colors
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/pbv7/pingheat/internal/types"
 )
 
 // RTT thresholds in milliseconds
 const (
-	ThresholdExcellent = 30   // 0-30ms: Green
-	ThresholdGood      = 80   // 30-80ms: Light Green
-	ThresholdFair      = 150  // 80-150ms: Yellow
-	ThresholdPoor      = 300  // 150-300ms: Orange
+	ThresholdExcellent = 30  // 0-30ms: Green
+	ThresholdGood      = 80  // 30-80ms: Light Green
+	ThresholdFair      = 150 // 80-150ms: Yellow
+	ThresholdPoor      = 300 // 150-300ms: Orange
 	// >300ms: Red
 )
 
+// Thresholds holds the upper bounds (in milliseconds) of each RTT color band.
+type Thresholds struct {
+	Excellent float64 `json:"excellent" yaml:"excellent"`
+	Good      float64 `json:"good" yaml:"good"`
+	Fair      float64 `json:"fair" yaml:"fair"`
+	Poor      float64 `json:"poor" yaml:"poor"`
+}
+
+// DefaultThresholds returns the built-in RTT color bands.
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		Excellent: ThresholdExcellent,
+		Good:      ThresholdGood,
+		Fair:      ThresholdFair,
+		Poor:      ThresholdPoor,
+	}
+}
+
+// Validate checks that thresholds are positive and strictly ascending.
+func (t Thresholds) Validate() error {
+	if t.Excellent <= 0 || t.Excellent >= t.Good || t.Good >= t.Fair || t.Fair >= t.Poor {
+		return fmt.Errorf("thresholds must be positive and ascending (excellent < good < fair < poor), got %v/%v/%v/%v",
+			t.Excellent, t.Good, t.Fair, t.Poor)
+	}
+	return nil
+}
+
+// Classify returns the color classification for an RTT duration.
+func (t Thresholds) Classify(rtt time.Duration) lipgloss.Color {
+	return t.ClassifyMs(float64(rtt.Microseconds()) / 1000.0)
+}
+
+// ClassifyMs returns the color classification for an RTT in milliseconds.
+func (t Thresholds) ClassifyMs(ms float64) lipgloss.Color {
+	switch {
+	case ms < 0:
+		return ColorTimeout
+	case ms <= t.Excellent:
+		return ColorExcellent
+	case ms <= t.Good:
+		return ColorGood
+	case ms <= t.Fair:
+		return ColorFair
+	case ms <= t.Poor:
+		return ColorPoor
+	default:
+		return ColorBad
+	}
+}
+
 // Colors for different RTT ranges
 var (
 	ColorExcellent = lipgloss.Color("#00FF00") // Green
@@ -25,6 +77,77 @@ var (
 	ColorTimeout   = lipgloss.Color("#8B008B") // Dark Magenta - stands out but flows with heatmap
 )
 
+// Colors for failures more specific than a timeout
+var (
+	ColorHostUnreachable = lipgloss.Color("#FF00FF") // Magenta - close to timeout, brighter
+	ColorNetUnreachable  = lipgloss.Color("#6A5ACD") // Slate Blue
+	ColorTTLExceeded     = lipgloss.Color("#00CED1") // Dark Turquoise - usually a routing loop
+	ColorProhibited      = lipgloss.Color("#A9A9A9") // Gray - filtered by policy
+)
+
+// ErrorColor returns the color of a failed sample of the given kind.
+func ErrorColor(kind types.ErrorKind) lipgloss.Color {
+	switch kind {
+	case types.ErrorHostUnreachable:
+		return ColorHostUnreachable
+	case types.ErrorNetUnreachable:
+		return ColorNetUnreachable
+	case types.ErrorTTLExceeded:
+		return ColorTTLExceeded
+	case types.ErrorAdminProhibited:
+		return ColorProhibited
+	default:
+		return ColorTimeout
+	}
+}
+
+// Colors of the delta view, diverging around the baseline: blues for
+// replies faster than usual, near white around it and warm colors for
+// regressions
+var (
+	ColorDeltaFaster = lipgloss.Color("#1E90FF") // Dodger Blue
+	ColorDeltaFast   = lipgloss.Color("#87CEFA") // Light Sky Blue
+	ColorDeltaSame   = lipgloss.Color("#E0E0E0") // Near white
+	ColorDeltaSlow   = lipgloss.Color("#FFD700") // Gold
+	ColorDeltaSlower = lipgloss.Color("#FF6347") // Tomato
+	ColorDeltaWorse  = lipgloss.Color("#DC143C") // Crimson
+)
+
+// Delta view bands, as the share by which an RTT differs from the baseline:
+// within DeltaSame either way is usual, up to DeltaSlow and DeltaSlower
+// above it a mild and a clear regression, beyond that a severe one. Faster
+// than DeltaFaster below it stands out as unusually fast.
+const (
+	DeltaFaster = 0.25
+	DeltaSame   = 0.1
+	DeltaSlow   = 0.5
+	DeltaSlower = 1.0
+)
+
+// ClassifyDelta returns the delta view color of an RTT of ms against a
+// baseline of baselineMs. The difference is measured against the baseline
+// but at least floorMs, so sub-millisecond jitter on a LAN stays neutral.
+func ClassifyDelta(ms, baselineMs, floorMs float64) lipgloss.Color {
+	if ms < 0 {
+		return ColorTimeout
+	}
+	d := (ms - baselineMs) / max(baselineMs, floorMs)
+	switch {
+	case d < -DeltaFaster:
+		return ColorDeltaFaster
+	case d < -DeltaSame:
+		return ColorDeltaFast
+	case d <= DeltaSame:
+		return ColorDeltaSame
+	case d <= DeltaSlow:
+		return ColorDeltaSlow
+	case d <= DeltaSlower:
+		return ColorDeltaSlower
+	default:
+		return ColorDeltaWorse
+	}
+}
+
 // Background colors (dimmer versions)
 var (
 	BGExcellent = lipgloss.Color("#004400")
@@ -41,22 +164,10 @@ func Classify(rtt time.Duration) lipgloss.Color {
 	return ClassifyMs(ms)
 }
 
-// ClassifyMs returns the color classification for an RTT in milliseconds.
+// ClassifyMs returns the color classification for an RTT in milliseconds
+// using the default thresholds.
 func ClassifyMs(ms float64) lipgloss.Color {
-	switch {
-	case ms < 0:
-		return ColorTimeout
-	case ms <= ThresholdExcellent:
-		return ColorExcellent
-	case ms <= ThresholdGood:
-		return ColorGood
-	case ms <= ThresholdFair:
-		return ColorFair
-	case ms <= ThresholdPoor:
-		return ColorPoor
-	default:
-		return ColorBad
-	}
+	return DefaultThresholds().ClassifyMs(ms)
 }
 
 // ClassifyBG returns the background color for an RTT duration.
@@ -83,12 +194,187 @@ func ClassifyBGMs(ms float64) lipgloss.Color {
 	}
 }
 
+// SmokeShades are the background shades behind burst cells, from none to
+// heavy, showing how far the probes of an interval spread out or were lost.
+var SmokeShades = []lipgloss.Color{"", "#303030", "#4E4E4E", "#767676"}
+
+// Smoke returns the background shade of smoke level n, clamped to the
+// shades available; level 0 has no background.
+func Smoke(n int) lipgloss.Color {
+	return SmokeShades[max(0, min(n, len(SmokeShades)-1))]
+}
+
+// Dim returns a darker version of a "#RRGGBB" color, used for samples that
+// do not count toward SLA. Other color forms are returned unchanged.
+func Dim(c lipgloss.Color) lipgloss.Color {
+	s := string(c)
+	if len(s) != 7 || s[0] != '#' {
+		return c
+	}
+	var r, g, b uint8
+	if _, err := fmt.Sscanf(s, "#%02x%02x%02x", &r, &g, &b); err != nil {
+		return c
+	}
+	return lipgloss.Color(fmt.Sprintf("#%02X%02X%02X", r/3, g/3, b/3))
+}
+
+// ascii selects plain ASCII cell characters for terminals whose font or
+// encoding lacks the block and braille glyphs.
+var ascii bool
+
+// SetASCII switches the cell characters to plain ASCII: # for a full cell,
+// o and . for partly filled ones, and | for a gap. It must be called before
+// the UI starts.
+func SetASCII(on bool) {
+	ascii = on
+}
+
+// ASCII reports whether cell characters are plain ASCII.
+func ASCII() bool {
+	return ascii
+}
+
+// noColor selects glyphs that tell the latency bands apart without color.
+var noColor bool
+
+// SetNoColor makes full heatmap cells show the glyph of their band (see
+// BandChar) rather than one block in the band's color. It must be called
+// before the UI starts.
+func SetNoColor(on bool) {
+	noColor = on
+}
+
+// NoColor reports whether cells are drawn for a terminal without colors.
+func NoColor() bool {
+	return noColor
+}
+
+// BandChar returns the glyph standing for the band of color c when colors
+// are off: denser for slower bands, a cross for timeouts and failures.
+func BandChar(c lipgloss.Color) string {
+	unicode, plain := "×", "x"
+	switch c {
+	case ColorExcellent, ColorDeltaFaster, ColorDeltaFast, ColorDeltaSame:
+		unicode, plain = "·", "."
+	case ColorGood, ColorDeltaSlow:
+		unicode, plain = "░", ":"
+	case ColorFair, ColorDeltaSlower:
+		unicode, plain = "▒", "+"
+	case ColorPoor:
+		unicode, plain = "▓", "*"
+	case ColorBad, ColorDeltaWorse:
+		unicode, plain = "█", "#"
+	}
+	if ascii {
+		return plain
+	}
+	return unicode
+}
+
+// CellChar returns the character of a full heatmap cell in color c: a block,
+// or the band glyph when colors are off.
+func CellChar(c lipgloss.Color) string {
+	if noColor {
+		return BandChar(c)
+	}
+	return HeatmapChar(false)
+}
+
+// UseANSIPalette replaces the RTT and failure colors with the 16 standard
+// ANSI colors. On 16-color terminals this keeps neighboring bands apart,
+// where converting the RGB colors would map several to the same one.
+func UseANSIPalette() {
+	ColorExcellent = lipgloss.Color("10") // Bright green
+	ColorGood = lipgloss.Color("2")       // Green
+	ColorFair = lipgloss.Color("11")      // Bright yellow
+	ColorPoor = lipgloss.Color("3")       // Yellow, shown as orange or brown by most themes
+	ColorBad = lipgloss.Color("9")        // Bright red
+	ColorTimeout = lipgloss.Color("5")    // Magenta
+	ColorHostUnreachable = lipgloss.Color("13")
+	ColorNetUnreachable = lipgloss.Color("4")
+	ColorTTLExceeded = lipgloss.Color("6")
+	ColorProhibited = lipgloss.Color("8")
+	ColorDeltaFaster = lipgloss.Color("12") // Bright blue
+	ColorDeltaFast = lipgloss.Color("14")   // Bright cyan
+	ColorDeltaSame = lipgloss.Color("15")   // Bright white
+	ColorDeltaSlow = lipgloss.Color("11")
+	ColorDeltaSlower = lipgloss.Color("3")
+	ColorDeltaWorse = lipgloss.Color("9")
+	SmokeShades = []lipgloss.Color{"", "0", "8", "8"}
+}
+
 // HeatmapChar returns a character representing the RTT level.
 // Uses filled block (█) for all states to maintain visual flow.
 func HeatmapChar(timeout bool) string {
+	if ascii {
+		return "#"
+	}
 	return "█"
 }
 
+// GapChar returns the character marking the heatmap cell of the first
+// sample after a gap in the session, such as the machine sleeping or the
+// system clock jumping.
+func GapChar() string {
+	if ascii {
+		return "|"
+	}
+	return "┆"
+}
+
+// brailleDots lists braille dot bits in fill order: left column top to bottom,
+// then right column top to bottom.
+var brailleDots = [8]rune{0x01, 0x02, 0x04, 0x40, 0x08, 0x10, 0x20, 0x80}
+
+// BrailleChar returns a braille character with the first n dots (0-8) filled
+// in column-major order, so partially filled cells read like a filling bar.
+// In ASCII mode a full cell is #, half or more o and less than half a dot.
+func BrailleChar(n int) string {
+	if n <= 0 {
+		return " "
+	}
+	if n > len(brailleDots) {
+		n = len(brailleDots)
+	}
+	if ascii {
+		return asciiFill(n, len(brailleDots))
+	}
+	r := rune(0x2800)
+	for _, dot := range brailleDots[:n] {
+		r |= dot
+	}
+	return string(r)
+}
+
+// burstBlocks are the blocks from an eighth to full height.
+var burstBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// BurstChar returns a block as high as the share of a burst of n probes that
+// got a reply, rounded up so a single reply still shows. A burst without
+// replies gets a full block, to be colored like a timeout.
+func BurstChar(replies, n int) string {
+	if n <= 0 || replies <= 0 || replies >= n {
+		return HeatmapChar(false)
+	}
+	if ascii {
+		return asciiFill(replies, n)
+	}
+	i := (replies*len(burstBlocks) + n - 1) / n
+	return string(burstBlocks[i-1])
+}
+
+// asciiFill returns the ASCII character of a cell filled k out of n.
+func asciiFill(k, n int) string {
+	switch {
+	case k >= n:
+		return "#"
+	case 2*k >= n:
+		return "o"
+	default:
+		return "."
+	}
+}
+
 // ForTimeout returns true if the value represents a timeout.
 func ForTimeout(ms float64) bool {
 	return ms < 0
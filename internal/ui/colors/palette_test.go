@@ -3,6 +3,9 @@ package colors
 import (
 	"testing"
 	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pbv7/pingheat/internal/types"
 )
 
 func TestClassifyMsThresholds(t *testing.T) {
@@ -29,6 +32,29 @@ func TestClassifyMsThresholds(t *testing.T) {
 	}
 }
 
+func TestClassifyDelta(t *testing.T) {
+	tests := []struct {
+		ms, baseline float64
+		want         lipgloss.Color
+	}{
+		{-1, 100, ColorTimeout},
+		{70, 100, ColorDeltaFaster},
+		{85, 100, ColorDeltaFast},
+		{105, 100, ColorDeltaSame},
+		{140, 100, ColorDeltaSlow},
+		{200, 100, ColorDeltaSlower},
+		{201, 100, ColorDeltaWorse},
+		// Against a 30ms floor, a LAN doubling from 1ms to 2ms is usual
+		{2, 1, ColorDeltaSame},
+		{20, 1, ColorDeltaSlower},
+	}
+	for _, tt := range tests {
+		if got := ClassifyDelta(tt.ms, tt.baseline, ThresholdExcellent); got != tt.want {
+			t.Fatalf("ClassifyDelta(%v, %v) = %v, want %v", tt.ms, tt.baseline, got, tt.want)
+		}
+	}
+}
+
 func TestClassifyAndBackground(t *testing.T) {
 	if Classify(50*time.Millisecond) != ColorGood {
 		t.Fatalf("expected good color for 50ms")
@@ -46,3 +72,141 @@ func TestForTimeout(t *testing.T) {
 		t.Fatalf("expected no timeout for zero ms")
 	}
 }
+
+func TestBrailleChar(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{n: 0, want: " "},
+		{n: 1, want: "⠁"},
+		{n: 4, want: "⡇"},
+		{n: 8, want: "⣿"},
+		{n: 12, want: "⣿"},
+	}
+
+	for _, tc := range tests {
+		if got := BrailleChar(tc.n); got != tc.want {
+			t.Fatalf("BrailleChar(%d) = %q, want %q", tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestBurstChar(t *testing.T) {
+	tests := []struct {
+		replies, n int
+		want       string
+	}{
+		{replies: 0, n: 4, want: "█"},
+		{replies: 1, n: 4, want: "▂"},
+		{replies: 2, n: 4, want: "▄"},
+		{replies: 3, n: 4, want: "▆"},
+		{replies: 4, n: 4, want: "█"},
+		{replies: 1, n: 16, want: "▁"},
+	}
+
+	for _, tc := range tests {
+		if got := BurstChar(tc.replies, tc.n); got != tc.want {
+			t.Fatalf("BurstChar(%d, %d) = %q, want %q", tc.replies, tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestASCIIChars(t *testing.T) {
+	SetASCII(true)
+	defer SetASCII(false)
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"heatmap", HeatmapChar(false), "#"},
+		{"gap", GapChar(), "|"},
+		{"braille empty", BrailleChar(0), " "},
+		{"braille 3/8", BrailleChar(3), "."},
+		{"braille 4/8", BrailleChar(4), "o"},
+		{"braille full", BrailleChar(8), "#"},
+		{"burst 1/4", BurstChar(1, 4), "."},
+		{"burst 3/4", BurstChar(3, 4), "o"},
+		{"burst lost", BurstChar(0, 4), "#"},
+	}
+	for _, tc := range tests {
+		if tc.got != tc.want {
+			t.Fatalf("%s = %q, want %q", tc.name, tc.got, tc.want)
+		}
+	}
+}
+
+func TestBandChar(t *testing.T) {
+	SetNoColor(true)
+	defer SetNoColor(false)
+
+	tests := []struct {
+		color lipgloss.Color
+		want  string
+		ascii string
+	}{
+		{ColorExcellent, "·", "."},
+		{ColorGood, "░", ":"},
+		{ColorFair, "▒", "+"},
+		{ColorPoor, "▓", "*"},
+		{ColorBad, "█", "#"},
+		{ColorTimeout, "×", "x"},
+		{ColorTTLExceeded, "×", "x"},
+	}
+	for _, tc := range tests {
+		if got := CellChar(tc.color); got != tc.want {
+			t.Fatalf("CellChar(%s) = %q, want %q", tc.color, got, tc.want)
+		}
+		SetASCII(true)
+		got := CellChar(tc.color)
+		SetASCII(false)
+		if got != tc.ascii {
+			t.Fatalf("ASCII CellChar(%s) = %q, want %q", tc.color, got, tc.ascii)
+		}
+	}
+}
+
+func TestThresholdsCustom(t *testing.T) {
+	th := Thresholds{Excellent: 5, Good: 10, Fair: 20, Poor: 40}
+	if err := th.Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	if th.ClassifyMs(8) != ColorGood {
+		t.Fatalf("expected good color for 8ms with custom thresholds")
+	}
+	if th.Classify(50*time.Millisecond) != ColorBad {
+		t.Fatalf("expected bad color for 50ms with custom thresholds")
+	}
+
+	if err := (Thresholds{Excellent: 10, Good: 5, Fair: 20, Poor: 40}).Validate(); err == nil {
+		t.Fatalf("expected error for non-ascending thresholds")
+	}
+	if err := DefaultThresholds().Validate(); err != nil {
+		t.Fatalf("default thresholds invalid: %v", err)
+	}
+}
+
+func TestDim(t *testing.T) {
+	if got := Dim(lipgloss.Color("#FF9900")); got != "#553300" {
+		t.Fatalf("Dim(#FF9900)=%v, want #553300", got)
+	}
+	if got := Dim(lipgloss.Color("205")); got != "205" {
+		t.Fatalf("Dim(205)=%v, want unchanged", got)
+	}
+}
+
+func TestErrorColor(t *testing.T) {
+	if got := ErrorColor(types.ErrorTimeout); got != ColorTimeout {
+		t.Fatalf("ErrorColor(timeout)=%v, want %v", got, ColorTimeout)
+	}
+	seen := map[lipgloss.Color]bool{}
+	for _, kind := range types.ErrorKinds {
+		c := ErrorColor(kind)
+		if seen[c] {
+			t.Fatalf("ErrorColor(%v)=%v is shared with another kind", kind, c)
+		}
+		seen[c] = true
+	}
+}
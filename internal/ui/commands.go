@@ -0,0 +1,265 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pbv7/pingheat/internal/output"
+	"github.com/pbv7/pingheat/internal/ping"
+	"github.com/pbv7/pingheat/internal/userfile"
+)
+
+// command is a command of the ":" command line. A command with an action
+// does what its key does and is described by its binding; any other runs
+// with the rest of the line as its argument.
+type command struct {
+	name   string
+	args   string // Argument synopsis shown in the hint
+	desc   string
+	action action
+	run    func(m Model, arg string) (tea.Model, tea.Cmd)
+}
+
+// description returns what c does.
+func (c command) description() string {
+	if b, ok := bindingFor(c.action); ok {
+		return b.desc
+	}
+	return c.desc
+}
+
+// commands lists the ":" commands in the order hints show them.
+var commands = []command{
+	{name: "target", args: "HOST [clear]", desc: "Switch target (clear drops the history)", run: Model.cmdTarget},
+	{name: "interval", args: "DURATION", desc: "Change the probe interval", run: Model.cmdInterval},
+	{name: "export", args: "csv|json [FILE]", desc: "Write the history to a file", run: Model.cmdExport},
+	{name: "marker", args: "NOTE", desc: "Log a note with the time", run: Model.cmdMarker},
+	{name: "view", args: "[blocks|braille|minutes|aligned]", desc: "Set or cycle the view", run: Model.cmdView},
+	{name: "jump", args: "TIME", desc: "Jump to time (15:04, -15m)", run: Model.cmdJump},
+	{name: "settings", action: actSettings},
+	{name: "sla", action: actSLA},
+	{name: "trend", action: actTrend},
+	{name: "hours", action: actHours},
+	{name: "log", action: actLogs},
+	{name: "legend", action: actLegend},
+	{name: "delta", action: actDelta},
+	{name: "orientation", action: actOrientation},
+	{name: "screenshot", action: actScreenshot},
+	{name: "copy", action: actCopy},
+	{name: "clear", action: actClear},
+	{name: "help", action: actHelp},
+	{name: "quit", action: actQuit},
+}
+
+// findCommands returns the commands named name or, failing that, starting
+// with it.
+func findCommands(name string) []command {
+	var found []command
+	for _, c := range commands {
+		if c.name == name {
+			return []command{c}
+		}
+		if strings.HasPrefix(c.name, name) {
+			found = append(found, c)
+		}
+	}
+	return found
+}
+
+// runCommand runs a ":" command line. A command may be shortened to any
+// unambiguous prefix, as in ":q".
+func (m Model) runCommand(line string) (tea.Model, tea.Cmd) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+	if name == "" {
+		return m, nil
+	}
+	found := findCommands(name)
+	if len(found) != 1 {
+		m.statusMsg = fmt.Sprintf("Unknown command %q", name)
+		if len(found) > 1 {
+			m.statusMsg = fmt.Sprintf("Ambiguous command %q", name)
+		}
+		m.statusErr = true
+		return m, nil
+	}
+
+	c := found[0]
+	if c.action != actNone {
+		return m.runAction(c.action)
+	}
+	return c.run(m, strings.TrimSpace(arg))
+}
+
+// completeCommand completes the command name being typed as far as the
+// matching commands agree, adding a space once it is unambiguous.
+func completeCommand(input string) string {
+	if strings.Contains(input, " ") {
+		return input
+	}
+	found := findCommands(input)
+	if len(found) == 0 {
+		return input
+	}
+	if len(found) == 1 {
+		return found[0].name + " "
+	}
+	prefix := found[0].name
+	for _, c := range found[1:] {
+		for !strings.HasPrefix(c.name, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// commandHint describes what the command line input can become: the
+// matching command names while several match, then the usage of the one
+// command left.
+func commandHint(input string) string {
+	name, _, _ := strings.Cut(input, " ")
+	found := findCommands(name)
+	switch len(found) {
+	case 0:
+		return "unknown command"
+	case 1:
+		c := found[0]
+		return strings.TrimSpace(c.name+" "+c.args) + " " + glyph("—", "-") + " " + c.description()
+	}
+	names := make([]string, len(found))
+	for i, c := range found {
+		names[i] = c.name
+	}
+	return strings.Join(names, " ")
+}
+
+// cmdTarget runs ":target HOST [clear]".
+func (m Model) cmdTarget(arg string) (tea.Model, tea.Cmd) {
+	fields := strings.Fields(arg)
+	if len(fields) == 0 || len(fields) > 2 || len(fields) == 2 && fields[1] != "clear" {
+		return m.commandError("usage: target HOST [clear]")
+	}
+	if m.switchTarget == nil {
+		return m.commandError("Target switching is not available")
+	}
+	return m, m.switchTargetCmd(fields[0], len(fields) == 2)
+}
+
+// cmdInterval runs ":interval DURATION".
+func (m Model) cmdInterval(arg string) (tea.Model, tea.Cmd) {
+	d, err := time.ParseDuration(arg)
+	if err != nil {
+		return m.commandError("usage: interval DURATION, e.g. 500ms")
+	}
+	if m.setInterval == nil {
+		return m.commandError("Changing the interval is not available")
+	}
+	setInterval := m.setInterval
+	return m, func() tea.Msg {
+		return IntervalChangedMsg{Interval: d, Err: setInterval(d)}
+	}
+}
+
+// cmdExport runs ":export csv|json [FILE]", writing the samples in the
+// history in the format of -output. Without a file name, it writes
+// pingheat-YYYYMMDD-HHMMSS.csv (or .jsonl) to the working directory.
+func (m Model) cmdExport(arg string) (tea.Model, tea.Cmd) {
+	format, path, _ := strings.Cut(arg, " ")
+	if !output.IsValidFormat(format) {
+		return m.commandError("usage: export csv|json [FILE]")
+	}
+	path = strings.TrimSpace(path)
+	if path == "" {
+		ext := map[string]string{output.FormatCSV: ".csv", output.FormatJSON: ".jsonl"}[format]
+		path = "pingheat-" + m.now.Format("20060102-150405") + ext
+	}
+
+	samples, labels := m.samples.All(), m.config.Labels
+	return m, func() tea.Msg {
+		path, err := exportSamples(path, format, samples, labels)
+		if err != nil {
+			return ErrorMsg{Err: err}
+		}
+		return StatusMsg{Message: fmt.Sprintf("Exported %d samples to %s", len(samples), path)}
+	}
+}
+
+// exportSamples writes samples to a new file at path, or next to it with a
+// numbered suffix if path exists, and returns the path written.
+func exportSamples(path, format string, samples []ping.Sample, labels map[string]string) (string, error) {
+	f, err := userfile.CreateNew(path)
+	if err != nil {
+		return "", fmt.Errorf("export: %w", err)
+	}
+	w, err := output.NewWriter(f, format)
+	if err != nil {
+		_ = f.Close()
+		return "", err
+	}
+	w.SetLabels(labels)
+	for _, s := range samples {
+		if err := w.Write(s); err != nil {
+			_ = f.Close()
+			return "", fmt.Errorf("export: %w", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("export: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// cmdMarker runs ":marker NOTE". The note may be quoted.
+func (m Model) cmdMarker(arg string) (tea.Model, tea.Cmd) {
+	note := arg
+	if unquoted, err := strconv.Unquote(arg); err == nil {
+		note = unquoted
+	}
+	if note == "" {
+		return m.commandError("usage: marker NOTE")
+	}
+	if m.mark == nil {
+		return m.commandError("Markers are not available")
+	}
+	m.mark(note)
+	m.statusMsg = "Marker: " + note
+	m.statusErr = false
+	return m, nil
+}
+
+// cmdView runs ":view [MODE]", cycling the views like "v" without a mode.
+func (m Model) cmdView(arg string) (tea.Model, tea.Cmd) {
+	mode := (m.viewMode + 1) % viewMode(len(viewModeNames))
+	if arg != "" {
+		i := slices.Index(viewModeNames, arg)
+		if i < 0 {
+			return m.commandError("usage: view [" + strings.Join(viewModeNames, "|") + "]")
+		}
+		mode = viewMode(i)
+	}
+	m.viewMode = mode
+	m.scrollPos = 0
+	m.statusMsg = "View: " + m.viewMode.String()
+	m.statusErr = false
+	return m, nil
+}
+
+// cmdJump runs ":jump TIME", as the "/" prompt.
+func (m Model) cmdJump(arg string) (tea.Model, tea.Cmd) {
+	if arg == "" {
+		return m.commandError("usage: jump TIME")
+	}
+	m.jumpTo(arg)
+	return m, nil
+}
+
+// commandError shows err, a usage or why a command cannot run, in the
+// status bar.
+func (m Model) commandError(err string) (tea.Model, tea.Cmd) {
+	m.statusMsg = err
+	m.statusErr = true
+	return m, nil
+}
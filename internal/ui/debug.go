@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// renderDebug renders pingheat's own health: runtime, distributor latency,
+// channel depths and dropped messages.
+func (m Model) renderDebug() string {
+	h := m.health()
+
+	var b strings.Builder
+	b.WriteString(TitleStyle.Render("Debug"))
+	b.WriteString("\n\n")
+
+	row := func(label, value string) {
+		b.WriteString(LabelStyle.Render(fmt.Sprintf("%-14s", label)))
+		b.WriteString(value)
+		b.WriteString("\n")
+	}
+	row("Goroutines", ValueStyle.Render(fmt.Sprintf("%d", h.Goroutines)))
+	row("Heap", ValueStyle.Render(formatBytes(h.HeapBytes)))
+	row("Processing", ValueStyle.Render(fmt.Sprintf("avg %s  max %s  (%d samples)",
+		formatLatency(h.ProcessingAvg()), formatLatency(h.ProcessingMax), h.ProcessedSamples)))
+	for _, c := range h.Channels {
+		style := ValueStyle
+		if c.Cap > 0 && c.Len*2 >= c.Cap {
+			style = WarnValueStyle
+		}
+		row("Channel "+c.Name, style.Render(fmt.Sprintf("%d/%d", c.Len, c.Cap)))
+	}
+	dropped := ValueStyle
+	if h.DroppedUISamples > 0 || h.DroppedStats > 0 {
+		dropped = WarnValueStyle
+	}
+	b.WriteString(LabelStyle.Render(fmt.Sprintf("%-14s", "Dropped")))
+	b.WriteString(dropped.Render(fmt.Sprintf("ui %d  stats %d", h.DroppedUISamples, h.DroppedStats)))
+
+	return HelpOverlayStyle.Render(b.String())
+}
+
+// formatBytes formats n with a binary unit.
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// formatLatency formats a processing time, rounded to a readable precision.
+func formatLatency(d time.Duration) string {
+	switch {
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	case d >= time.Microsecond:
+		return d.Round(100 * time.Nanosecond).String()
+	}
+	return d.String()
+}
@@ -0,0 +1,112 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/ui/colors"
+)
+
+// heatmapLayout is everything besides the samples that decides how a
+// heatmap row renders. Any change to it drops the cached rows.
+type heatmapLayout struct {
+	cols       int
+	mode       viewMode
+	perCell    int
+	thresholds colors.Thresholds
+	baseline   metrics.Baseline // Of the delta view; zero for absolute colors
+	interval   time.Duration
+}
+
+// rowKey identifies the samples drawn in a heatmap row. Samples never change
+// once received, so a row showing the same ones renders the same. The
+// sample before the row is part of the key as it decides the first gap.
+type rowKey struct {
+	prev, first, last int64 // Timestamps in Unix nanoseconds; prev is 0 if none
+	firstSeq, lastSeq int
+	n                 int // Samples in the row
+}
+
+// heatmapCache keeps the rendered rows of the last frame so a frame only
+// re-renders the rows whose samples changed, and reuses the whole frame
+// when none did. Between samples, and while scrolled away from live, every
+// tick redraws an unchanged heatmap. It is only used from the UI goroutine.
+type heatmapCache struct {
+	layout heatmapLayout
+	keys   []rowKey
+	rows   []string
+	frame  string // Bordered grid of the rows; empty after a change
+}
+
+// newHeatmapCache creates an empty heatmap cache.
+func newHeatmapCache() *heatmapCache {
+	return &heatmapCache{}
+}
+
+// render returns the bordered heatmap of m, which must have a fixed number
+// of samples per cell.
+func (c *heatmapCache) render(m Model, cols, rows int) string {
+	layout := heatmapLayout{
+		cols:       cols,
+		mode:       m.viewMode,
+		perCell:    m.samplesPerCell(),
+		thresholds: m.config.Thresholds,
+		baseline:   m.deltaBaseline(),
+		interval:   m.config.Interval,
+	}
+	if layout != c.layout || len(c.rows) != rows {
+		c.layout = layout
+		c.keys = make([]rowKey, rows)
+		c.rows = make([]string, rows)
+		c.frame = ""
+	}
+
+	start, end, ok := m.visibleRange(m.samples.Len())
+	perRow := cols * layout.perCell
+	for r := range rows {
+		var key rowKey
+		rowStart, rowEnd := start+r*perRow, min(start+(r+1)*perRow-1, end)
+		if ok && rowStart <= rowEnd {
+			key = m.rowKey(rowStart, rowEnd)
+		}
+		if key == c.keys[r] && c.rows[r] != "" {
+			continue
+		}
+
+		var row strings.Builder
+		written := 0
+		if key.n > 0 {
+			m.walkCells(rowStart, rowEnd, func(cell string, _ time.Time) {
+				row.WriteString(cell)
+				written++
+			})
+		}
+		row.WriteString(strings.Repeat(" ", cols-written))
+		c.keys[r], c.rows[r] = key, row.String()
+		c.frame = ""
+	}
+
+	if c.frame == "" {
+		c.frame = HeatmapBorderStyle.Render(strings.Join(c.rows, "\n")) + "\n"
+	}
+	return c.frame
+}
+
+// rowKey returns the key of the row holding the samples at indices start to
+// end.
+func (m Model) rowKey(start, end int) rowKey {
+	first, _ := m.samples.Get(start)
+	last, _ := m.samples.Get(end)
+	key := rowKey{
+		first:    first.Timestamp.UnixNano(),
+		last:     last.Timestamp.UnixNano(),
+		firstSeq: first.Sequence,
+		lastSeq:  last.Sequence,
+		n:        end - start + 1,
+	}
+	if prev, ok := m.samples.Get(start - 1); ok {
+		key.prev = prev.Timestamp.UnixNano()
+	}
+	return key
+}
@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/ui/colors"
+)
+
+// hoursWorst is how many of the worst hours the hour-of-day view names.
+const hoursWorst = 3
+
+// renderHours renders the hour-of-day histogram: for each hour of the day,
+// how its samples spread over the latency bands and loss, so problems that
+// recur at the same time every day stand out.
+func (m Model) renderHours() string {
+	th := m.config.Thresholds
+	hours := m.hours.Hours([]float64{th.Excellent, th.Good, th.Fair, th.Poor})
+
+	var b strings.Builder
+	b.WriteString(TitleStyle.Render("By Hour of Day"))
+	b.WriteString("\n\n")
+
+	// Worst band on top; lost samples are not in Buckets
+	rows := []struct {
+		label string
+		color lipgloss.Color
+		count func(metrics.HourOfDay) int
+	}{
+		{"lost", colors.ColorTimeout, func(h metrics.HourOfDay) int { return h.Timeouts }},
+		{fmt.Sprintf(">%gms", th.Poor), colors.ColorBad, bucketCount(4)},
+		{fmt.Sprintf("%s%gms", glyph("≤", "<="), th.Poor), colors.ColorPoor, bucketCount(3)},
+		{fmt.Sprintf("%s%gms", glyph("≤", "<="), th.Fair), colors.ColorFair, bucketCount(2)},
+		{fmt.Sprintf("%s%gms", glyph("≤", "<="), th.Good), colors.ColorGood, bucketCount(1)},
+		{fmt.Sprintf("%s%gms", glyph("≤", "<="), th.Excellent), colors.ColorExcellent, bucketCount(0)},
+	}
+	for _, row := range rows {
+		b.WriteString(LabelStyle.Render(fmt.Sprintf("%8s ", row.label)))
+		style := lipgloss.NewStyle().Foreground(row.color)
+		for _, h := range hours {
+			if h.Samples == 0 {
+				b.WriteString("  ")
+				continue
+			}
+			b.WriteString(style.Render(strings.Repeat(shade(float64(row.count(h))/float64(h.Samples)), 2)))
+		}
+		b.WriteString("\n")
+	}
+
+	axis := []byte(strings.Repeat(" ", 48))
+	for hour := 0; hour < 24; hour += 6 {
+		copy(axis[hour*2:], fmt.Sprintf("%02d", hour))
+	}
+	b.WriteString(LabelStyle.Render(fmt.Sprintf("%8s %s", "", axis)))
+	b.WriteString("\n\n")
+
+	worst := worstHours(hours, hoursWorst)
+	if len(worst) == 0 {
+		msg := "No samples yet"
+		if slices.ContainsFunc(hours, func(h metrics.HourOfDay) bool { return h.Samples > 0 }) {
+			msg = fmt.Sprintf("No lost samples or RTTs above %gms", th.Poor)
+		}
+		b.WriteString(LabelStyle.Render(msg))
+		return HelpOverlayStyle.Render(b.String())
+	}
+	b.WriteString(LabelStyle.Render(fmt.Sprintf("Worst hours (lost or >%gms):", th.Poor)))
+	for _, h := range worst {
+		b.WriteString("\n")
+		p95 := "-"
+		if h.Samples > h.Timeouts {
+			p95 = fmt.Sprintf("%.1fms", h.P95Ms)
+		}
+		fmt.Fprintf(&b, "  %s %s",
+			ValueStyle.Render(fmt.Sprintf("%02d:00", h.Hour)),
+			LabelStyle.Render(fmt.Sprintf("%5.1f%% bad  %5.2f%% loss  p95 %s  (%d samples)",
+				badPercent(h), h.LossPercent, p95, h.Samples)))
+	}
+
+	return HelpOverlayStyle.Render(b.String())
+}
+
+// bucketCount returns a counter of the replies in one latency band.
+func bucketCount(i int) func(metrics.HourOfDay) int {
+	return func(h metrics.HourOfDay) int { return h.Buckets[i] }
+}
+
+// shade renders the share of an hour's samples in a band as block density.
+func shade(share float64) string {
+	if colors.ASCII() {
+		return asciiShade(share)
+	}
+	switch {
+	case share <= 0:
+		return "·"
+	case share < 0.1:
+		return "░"
+	case share < 0.3:
+		return "▒"
+	case share < 0.6:
+		return "▓"
+	default:
+		return "█"
+	}
+}
+
+// asciiShade is shade for terminals limited to ASCII.
+func asciiShade(share float64) string {
+	switch {
+	case share <= 0:
+		return "."
+	case share < 0.1:
+		return ":"
+	case share < 0.3:
+		return "o"
+	case share < 0.6:
+		return "O"
+	default:
+		return "#"
+	}
+}
+
+// badPercent returns the share of an hour's samples lost or above the poor
+// threshold.
+func badPercent(h metrics.HourOfDay) float64 {
+	if h.Samples == 0 {
+		return 0
+	}
+	return float64(h.Timeouts+h.Buckets[len(h.Buckets)-1]) / float64(h.Samples) * 100
+}
+
+// worstHours returns up to n hours with samples that had any bad ones,
+// worst first.
+func worstHours(hours []metrics.HourOfDay, n int) []metrics.HourOfDay {
+	var worst []metrics.HourOfDay
+	for _, h := range hours {
+		if h.Samples > 0 && badPercent(h) > 0 {
+			worst = append(worst, h)
+		}
+	}
+	slices.SortStableFunc(worst, func(a, b metrics.HourOfDay) int {
+		return cmp.Compare(badPercent(b), badPercent(a))
+	})
+	return worst[:min(n, len(worst))]
+}
@@ -0,0 +1,136 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/buffer"
+	"github.com/pbv7/pingheat/internal/ping"
+)
+
+var errInvalidJump = errors.New("expected a time (15:04, 2006-01-02 15:04) or offset (-15m)")
+
+// jumpLayouts are the absolute time formats accepted by the jump prompt.
+// Layouts without a date are resolved against the current day.
+var jumpLayouts = []struct {
+	layout  string
+	hasDate bool
+}{
+	{time.RFC3339, true},
+	{"2006-01-02 15:04:05", true},
+	{"2006-01-02 15:04", true},
+	{"2006-01-02T15:04:05", true},
+	{"15:04:05", false},
+	{"15:04", false},
+}
+
+// parseJumpTarget resolves jump prompt input into an absolute time.
+// Durations are offsets into the past: "-15m" and "15m" both mean 15 minutes ago.
+// Clock times without a date refer to the most recent occurrence of that time.
+func parseJumpTarget(input string, now time.Time, loc *time.Location) (time.Time, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return time.Time{}, errInvalidJump
+	}
+
+	if d, err := time.ParseDuration(input); err == nil {
+		if d > 0 {
+			d = -d
+		}
+		return now.Add(d), nil
+	}
+
+	for _, l := range jumpLayouts {
+		t, err := time.ParseInLocation(l.layout, input, loc)
+		if err != nil {
+			continue
+		}
+		if l.hasDate {
+			return t, nil
+		}
+		local := now.In(loc)
+		t = time.Date(local.Year(), local.Month(), local.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc)
+		if t.After(now) {
+			t = t.AddDate(0, 0, -1)
+		}
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %q", errInvalidJump, input)
+}
+
+// indexAtOrAfter returns the buffer index of the first sample at or after t.
+// Returns the newest index when every sample is older than t.
+func (m Model) indexAtOrAfter(t time.Time) int {
+	return min(buffer.FindByTime(m.samples, t, sampleTime), m.samples.Len()-1)
+}
+
+// sampleTime returns when a sample was taken.
+func sampleTime(s ping.Sample) time.Time {
+	return s.Timestamp
+}
+
+// jumpTo scrolls the heatmap so the sample nearest to the input time is the
+// first visible cell and reports the outcome in the status bar.
+func (m *Model) jumpTo(input string) {
+	target, err := parseJumpTarget(input, m.now, m.config.Location())
+	if err != nil {
+		m.statusMsg = err.Error()
+		m.statusErr = true
+		return
+	}
+
+	if m.samples.Len() == 0 {
+		m.statusMsg = "No samples to search"
+		m.statusErr = true
+		return
+	}
+
+	switch m.viewMode {
+	case viewMinutes:
+		m.jumpToMinute(target)
+		return
+	case viewAligned:
+		m.jumpToRow(target)
+		return
+	}
+
+	idx := m.indexAtOrAfter(target)
+	m.scrollToIndex(idx)
+	sample, _ := m.samples.Get(idx)
+	m.statusMsg = "Jumped to " + m.formatClock(sample.Timestamp)
+	m.statusErr = false
+}
+
+// jumpToMinute scrolls the minutes view so the bucket holding t, or the
+// first one after it, is the first visible cell.
+func (m *Model) jumpToMinute(t time.Time) {
+	buckets := m.minutes.Buckets(time.Minute)
+	if len(buckets) == 0 {
+		m.statusMsg = "No samples to search"
+		m.statusErr = true
+		return
+	}
+
+	idx := sort.Search(len(buckets), func(i int) bool {
+		return buckets[i].Start.Add(buckets[i].Width).After(t)
+	})
+	idx = min(idx, len(buckets)-1)
+	m.scrollToIndex(idx)
+	m.statusMsg = "Jumped to " + m.formatClock(buckets[idx].Start)
+	m.statusErr = false
+}
+
+// jumpToRow scrolls the aligned view so the row of the minute holding t is
+// the first visible row, clamped to the minutes held.
+func (m *Model) jumpToRow(t time.Time) {
+	first, _ := m.alignedStart()
+	idx := int(t.Truncate(time.Minute).Sub(first) / time.Minute)
+	idx = min(max(idx, 0), m.itemCount()-1)
+	m.scrollToIndex(idx)
+	m.statusMsg = "Jumped to " + m.formatClock(first.Add(time.Duration(idx)*time.Minute))
+	m.statusErr = false
+}
@@ -0,0 +1,153 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+)
+
+// action is what a key binding does.
+type action int
+
+const (
+	actNone action = iota
+	actScrollUp
+	actScrollDown
+	actPageUp
+	actPageDown
+	actOldest
+	actNewest
+	actView
+	actOrientation
+	actJump
+	actTarget
+	actTargetClear
+	actCommand
+	actSLA
+	actTrend
+	actHours
+	actLogs
+	actLegend
+	actDelta
+	actClear
+	actSettings
+	actScreenshot
+	actCopy
+	actDebug
+	actHelp
+	actClose
+	actQuit
+)
+
+// binding binds keys, as tea.KeyMsg.String() names them, to an action.
+// views and when limit where the help overlay lists it; the keys work
+// everywhere.
+type binding struct {
+	keys   []string
+	action action
+	desc   string
+	views  []viewMode       // nil = every view
+	when   func(Model) bool // nil = always
+}
+
+// keymap lists the key bindings in help overlay order. An action may have
+// several bindings, e.g. described differently per view.
+var keymap = []binding{
+	{keys: []string{"up", "k"}, action: actScrollUp, desc: "Scroll up (older)"},
+	{keys: []string{"down", "j"}, action: actScrollDown, desc: "Scroll down (newer)"},
+	{keys: []string{"pgup"}, action: actPageUp, desc: "Page up"},
+	{keys: []string{"pgdown"}, action: actPageDown, desc: "Page down"},
+	{keys: []string{"home", "g"}, action: actOldest, desc: "Go to oldest"},
+	{keys: []string{"end", "G"}, action: actNewest, desc: "Go to newest"},
+	{keys: []string{"v"}, action: actView, desc: "Cycle view (blocks/braille/minutes/aligned)"},
+	{keys: []string{"V"}, action: actOrientation, desc: "Toggle time in rows/columns",
+		views: []viewMode{viewBlocks, viewBraille, viewMinutes}},
+	{keys: []string{"/"}, action: actJump, desc: "Jump to time (15:04, -15m)",
+		views: []viewMode{viewBlocks, viewBraille}},
+	{keys: []string{"/"}, action: actJump, desc: "Jump to minute (15:04, -15m)",
+		views: []viewMode{viewMinutes, viewAligned}},
+	{keys: []string{"o"}, action: actTarget, desc: "Switch target",
+		when: func(m Model) bool { return m.switchTarget != nil }},
+	{keys: []string{"O"}, action: actTargetClear, desc: "Switch target, clearing history",
+		when: func(m Model) bool { return m.switchTarget != nil }},
+	{keys: []string{":"}, action: actCommand, desc: "Command line (Tab completes)"},
+	{keys: []string{"a"}, action: actSLA, desc: "Toggle SLA table"},
+	{keys: []string{"t"}, action: actTrend, desc: "Toggle percentile trend"},
+	{keys: []string{"H"}, action: actHours, desc: "Toggle latency by hour of day"},
+	{keys: []string{"L"}, action: actLogs, desc: "Toggle log"},
+	{keys: []string{"l"}, action: actLegend, desc: "Toggle legend bar"},
+	{keys: []string{"d"}, action: actDelta, desc: "Toggle latency delta vs baseline",
+		when: func(m Model) bool { return m.calibrator != nil }},
+	{keys: []string{"c"}, action: actClear, desc: "Clear history"},
+	{keys: []string{"s"}, action: actSettings, desc: "Edit thresholds and SLO"},
+	{keys: []string{"S"}, action: actScreenshot, desc: "Save a screenshot"},
+	{keys: []string{"y"}, action: actCopy, desc: "Copy stats summary"},
+	{keys: []string{"ctrl+d"}, action: actDebug, desc: "Toggle debug panel",
+		when: func(m Model) bool { return m.health != nil }},
+	{keys: []string{"?", "h"}, action: actHelp, desc: "Toggle help"},
+	{keys: []string{"esc"}, action: actClose, desc: "Close panels"},
+	{keys: []string{"q", "ctrl+c"}, action: actQuit, desc: "Quit"},
+}
+
+// actionFor returns the action bound to key, or actNone.
+func actionFor(key string) action {
+	for _, b := range keymap {
+		if slices.Contains(b.keys, key) {
+			return b.action
+		}
+	}
+	return actNone
+}
+
+// bindingFor returns the first binding of act.
+func bindingFor(act action) (binding, bool) {
+	for _, b := range keymap {
+		if b.action == act {
+			return b, true
+		}
+	}
+	return binding{}, false
+}
+
+// applies reports whether the help overlay of m lists b.
+func (b binding) applies(m Model) bool {
+	if b.views != nil && !slices.Contains(b.views, m.viewMode) {
+		return false
+	}
+	return b.when == nil || b.when(m)
+}
+
+// label returns the keys of b as the help overlay shows them, e.g. "↑/k".
+func (b binding) label() string {
+	labels := make([]string, 0, len(b.keys))
+	for _, k := range b.keys {
+		if l := keyLabel(k); l != "" {
+			labels = append(labels, l)
+		}
+	}
+	return strings.Join(labels, "/")
+}
+
+// keyLabel returns how the help overlay names key. Arrows have no ASCII
+// name and are left out there, as their letter keys do the same.
+func keyLabel(key string) string {
+	switch key {
+	case "up":
+		return glyph("↑", "")
+	case "down":
+		return glyph("↓", "")
+	case "pgup":
+		return "PgUp"
+	case "pgdown":
+		return "PgDn"
+	case "home":
+		return "Home"
+	case "end":
+		return "End"
+	case "esc":
+		return "Esc"
+	}
+	if mod, k, ok := strings.Cut(key, "+"); ok {
+		return strings.ToUpper(mod[:1]) + mod[1:] + "+" + strings.ToUpper(k)
+	}
+	return key
+}
@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// renderLogs renders the most recent runtime log records that fit the
+// terminal, newest last, colored by level.
+func (m Model) renderLogs() string {
+	var b strings.Builder
+	b.WriteString(TitleStyle.Render("Log"))
+	b.WriteString("\n\n")
+
+	var entries []string
+	var levels []slog.Level
+	if m.logs != nil {
+		for _, e := range m.logs.Entries() {
+			entries = append(entries, e.String())
+			levels = append(levels, e.Level)
+		}
+	}
+	if len(entries) == 0 {
+		b.WriteString(LabelStyle.Render("No log records yet"))
+		return HelpOverlayStyle.Render(b.String())
+	}
+
+	// Title and blank line take 2 lines and the overlay border and padding
+	// 4 more; border and padding also take 6 columns
+	fit := max(1, m.height-6)
+	width := max(20, m.width-6)
+	start := max(0, len(entries)-fit)
+	for i := start; i < len(entries); i++ {
+		if i > start {
+			b.WriteString("\n")
+		}
+		line := entries[i]
+		if lipgloss.Width(line) > width {
+			line = truncate(line, width-1) + glyph("…", "~")
+		}
+		b.WriteString(logLevelStyle(levels[i]).Render(line))
+	}
+	return HelpOverlayStyle.Render(b.String())
+}
+
+// logLevelStyle returns the style of records at level.
+func logLevelStyle(level slog.Level) lipgloss.Style {
+	switch {
+	case level >= slog.LevelError:
+		return BadValueStyle
+	case level >= slog.LevelWarn:
+		return WarnValueStyle
+	case level < slog.LevelInfo:
+		return LabelStyle
+	}
+	return ValueStyle
+}
+
+// truncate returns the first n runes of s.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:max(0, n)])
+}
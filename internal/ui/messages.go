@@ -1,16 +1,19 @@
 package ui
 
 import (
+	"time"
+
 	"github.com/pbv7/pingheat/internal/metrics"
 	"github.com/pbv7/pingheat/internal/ping"
 )
 
-// SampleMsg is sent when a new ping sample is received.
+// SampleMsg delivers a single sample. The live feed does not use it: queued
+// samples are drained in batches on every TickMsg.
 type SampleMsg struct {
 	Sample ping.Sample
 }
 
-// MetricsMsg is sent when metrics are updated.
+// MetricsMsg delivers a stats update outside the per-tick drain.
 type MetricsMsg struct {
 	Stats metrics.Stats
 }
@@ -21,8 +24,23 @@ type StatusMsg struct {
 	IsError bool
 }
 
-// TickMsg is sent periodically to trigger UI updates.
-type TickMsg struct{}
+// TickMsg is sent periodically to drain queued samples and refresh the UI.
+type TickMsg struct {
+	Time time.Time
+}
+
+// TargetSwitchedMsg reports the result of switching to a new target.
+type TargetSwitchedMsg struct {
+	Target       string
+	ClearHistory bool
+	Err          error
+}
+
+// IntervalChangedMsg reports the result of changing the probe interval.
+type IntervalChangedMsg struct {
+	Interval time.Duration
+	Err      error
+}
 
 // ErrorMsg is sent when an error occurs.
 type ErrorMsg struct {
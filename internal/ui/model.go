@@ -4,10 +4,96 @@ import (
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pbv7/pingheat/internal/alert"
 	"github.com/pbv7/pingheat/internal/buffer"
 	"github.com/pbv7/pingheat/internal/config"
+	"github.com/pbv7/pingheat/internal/enrich"
+	"github.com/pbv7/pingheat/internal/log"
 	"github.com/pbv7/pingheat/internal/metrics"
 	"github.com/pbv7/pingheat/internal/ping"
+	"github.com/pbv7/pingheat/internal/prefs"
+	"github.com/pbv7/pingheat/internal/ui/colors"
+	"github.com/pbv7/pingheat/internal/wifi"
+)
+
+// viewMode selects how samples are packed into heatmap cells.
+type viewMode int
+
+const (
+	viewBlocks  viewMode = iota // One sample per cell
+	viewBraille                 // 2×4 samples per cell drawn as braille dots
+	viewMinutes                 // One minute per cell, covering up to 24 hours
+	viewAligned                 // One minute per row, samples placed by time
+)
+
+// viewModeNames maps view modes to their flag/config names, in cycle order.
+var viewModeNames = []string{
+	viewBlocks:  "blocks",
+	viewBraille: "braille",
+	viewMinutes: "minutes",
+	viewAligned: "aligned",
+}
+
+// String returns the flag/config name of the view mode.
+func (v viewMode) String() string {
+	if int(v) < len(viewModeNames) {
+		return viewModeNames[v]
+	}
+	return viewModeNames[viewBlocks]
+}
+
+// parseViewMode returns the view mode for a name, falling back to blocks.
+func parseViewMode(name string) viewMode {
+	for i, n := range viewModeNames {
+		if n == name {
+			return viewMode(i)
+		}
+	}
+	return viewBlocks
+}
+
+// IsValidViewMode reports whether name is a known view mode.
+func IsValidViewMode(name string) bool {
+	for _, n := range viewModeNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Heatmap orientations: time fills rows left to right, or columns top to
+// bottom like a waterfall, with clock times beneath the columns.
+const (
+	OrientationRows    = "rows"
+	OrientationColumns = "columns"
+)
+
+// IsValidOrientation reports whether name is a known heatmap orientation.
+func IsValidOrientation(name string) bool {
+	return name == OrientationRows || name == OrientationColumns
+}
+
+// MaxFPS is the highest UI refresh rate -fps accepts.
+const MaxFPS = 60
+
+// Once no sample has arrived for idleAfter, the UI refreshes at idleFPS at
+// most, so a stalled or slow session wakes the CPU less. The first new sample
+// restores the configured rate.
+const (
+	idleAfter = 2 * time.Second
+	idleFPS   = 2
+)
+
+// promptKind identifies what the status bar input prompt is collecting.
+type promptKind int
+
+const (
+	promptNone        promptKind = iota
+	promptJump                   // "/" jump to time
+	promptTarget                 // "o" switch target, keeping history
+	promptTargetClear            // "O" switch target, clearing history
+	promptCommand                // ":" command line
 )
 
 // Model is the Bubble Tea model for the UI.
@@ -16,74 +102,293 @@ type Model struct {
 	config config.Config
 
 	// Data
-	samples *buffer.RingBuffer[ping.Sample]
+	samples buffer.Buffer[ping.Sample]
+	minutes *metrics.Aggregator       // Per-minute buckets for the minutes view
+	sla     *metrics.SLATracker       // Availability per hour and day for the SLA table
+	hours   *metrics.HourOfDayTracker // Latency by hour of the day
+	slo     *alert.Tracker            // Latency SLO error budget; nil without -slo
 	stats   metrics.Stats
 
+	// Thresholds at startup, to detect changes made during the session
+	initialThresholds colors.Thresholds
+
 	// UI state
 	width      int
 	height     int
 	scrollPos  int
+	newSamples int // Samples received while scrolled away from live
+	viewMode   viewMode
+	columns    bool // Time flows down columns instead of along rows
 	showHelp   bool
+	helpScroll int // First help line shown when it does not fit
+	showLegend bool
+	delta      bool // Color latency by its difference from the baseline
+	showSLA    bool
+	showHours  bool
+	showTrend  bool
+	showLogs   bool
+	showDebug  bool
 	statusMsg  string
 	statusErr  bool
+	prompt     promptKind
+	input      string
 	quitting   bool
 	lastUpdate time.Time
+	startTime  time.Time // Session start, for elapsed time
+	now        time.Time // Wall clock as of the last tick
+
+	// Settings overlay: the selected row, and whether it is being edited
+	// in input
+	showSettings bool
+	settingsRow  int
+	editing      bool
+
+	// Current run of lost samples, for the outage banner
+	downCount int
+	downSince time.Time
+
+	// Outage transition waiting for the -bell announcement, when the last
+	// outage was announced and whether the current one was
+	bellEvent string
+	lastBell  time.Time
+	bellDown  bool
+
+	// heatmap keeps the rows of the last frame; nil renders every frame
+	// from scratch
+	heatmap *heatmapCache
 
 	// Channels for receiving data
 	sampleChan  <-chan ping.Sample
 	metricsChan <-chan metrics.Stats
+
+	// switchTarget asks the app to probe a new target; nil disables "o"
+	switchTarget func(target string) error
+
+	// setInterval asks the app to probe at a new interval; nil disables
+	// ":interval"
+	setInterval func(d time.Duration) error
+
+	// mark records a ":marker" note in the runtime log; nil disables it
+	mark func(note string)
+
+	// parserMisses reads the live unparsed line count, which keeps growing
+	// even when no sample gets through; nil falls back to the stats
+	parserMisses func() int
+
+	// logs holds the runtime log shown by "L"; nil shows an empty pane
+	logs *log.Buffer
+
+	// health reads pingheat's own state for the ctrl+d debug panel; nil
+	// disables the panel
+	health func() metrics.Health
+
+	// diagnosis reads the -diagnose verdict shown in the header; nil
+	// without -diagnose
+	diagnosis func() metrics.Diagnosis
+
+	// wifi reads the Wi-Fi link shown in the stats panel; nil without -wifi
+	wifi func() wifi.Link
+
+	// targetInfo reads the -enrich lookup shown in the header; nil without
+	// -enrich
+	targetInfo func() enrich.Info
+
+	// calibrator learns the baseline the delta view compares latency to;
+	// nil disables the delta view
+	calibrator *metrics.Calibrator
+
+	// applySettings hands settings changed in the overlay to the app; nil
+	// applies them to the UI only
+	applySettings func(Settings)
 }
 
 // NewModel creates a new UI model.
 func NewModel(cfg config.Config, sampleChan <-chan ping.Sample, metricsChan <-chan metrics.Stats) Model {
+	var slo *alert.Tracker
+	if cfg.SLO != nil {
+		slo = alert.NewTracker(*cfg.SLO)
+	}
 	return Model{
-		config:      cfg,
-		samples:     buffer.NewRingBuffer[ping.Sample](cfg.HistorySize),
-		sampleChan:  sampleChan,
-		metricsChan: metricsChan,
-		showHelp:    cfg.ShowHelp,
-		lastUpdate:  time.Now(),
+		config:            cfg,
+		initialThresholds: cfg.Thresholds,
+		samples:           buffer.NewRingBuffer[ping.Sample](cfg.HistorySize),
+		minutes:           metrics.NewAggregator(),
+		sla:               metrics.NewSLATracker(cfg.Location()),
+		hours:             metrics.NewHourOfDayTracker(cfg.Location()),
+		slo:               slo,
+		sampleChan:        sampleChan,
+		metricsChan:       metricsChan,
+		showHelp:          cfg.ShowHelp,
+		showLegend:        cfg.ShowLegend,
+		delta:             cfg.Delta,
+		columns:           cfg.Orientation == OrientationColumns,
+		viewMode:          parseViewMode(cfg.ViewMode),
+		lastUpdate:        time.Now(),
+		startTime:         time.Now(),
+		now:               time.Now(),
+		heatmap:           newHeatmapCache(),
 	}
 }
 
 // Init initializes the model.
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(
-		m.listenForSamples(),
-		m.listenForMetrics(),
-		m.tick(),
-	)
+	return m.tick()
 }
 
-// listenForSamples returns a command that waits for samples.
-func (m Model) listenForSamples() tea.Cmd {
-	return func() tea.Msg {
-		sample, ok := <-m.sampleChan
-		if !ok {
-			return nil
+// drain consumes every sample and stats update queued since the last tick.
+// Batching per tick keeps the message rate constant however fast samples
+// arrive; only the newest stats matter, so older ones are skipped.
+func (m *Model) drain() {
+	received := false
+drainSamples:
+	for {
+		select {
+		case sample, ok := <-m.sampleChan:
+			if !ok {
+				m.sampleChan = nil // Closed on shutdown; nil blocks forever
+				break drainSamples
+			}
+			m.addSample(sample)
+			received = true
+		default:
+			break drainSamples
 		}
-		return SampleMsg{Sample: sample}
 	}
-}
+	if received {
+		m.lastUpdate = time.Now()
+	}
 
-// listenForMetrics returns a command that waits for metrics updates.
-func (m Model) listenForMetrics() tea.Cmd {
-	return func() tea.Msg {
-		stats, ok := <-m.metricsChan
-		if !ok {
-			return nil
+drainStats:
+	for {
+		select {
+		case stats, ok := <-m.metricsChan:
+			if !ok {
+				m.metricsChan = nil
+				break drainStats
+			}
+			m.stats = stats
+		default:
+			break drainStats
 		}
-		return MetricsMsg{Stats: stats}
 	}
 }
 
 // tick returns a command that triggers periodic updates.
 func (m Model) tick() tea.Cmd {
-	return tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg {
-		return TickMsg{}
+	return tea.Tick(m.tickInterval(), func(t time.Time) tea.Msg {
+		return TickMsg{Time: t}
 	})
 }
 
+// tickInterval returns the time until the next refresh: one frame at -fps,
+// or at idleFPS once samples have stopped arriving.
+func (m Model) tickInterval() time.Duration {
+	fps := max(m.config.FPS, 1)
+	if m.now.Sub(m.lastUpdate) >= idleAfter {
+		fps = min(fps, idleFPS)
+	}
+	return time.Second / time.Duration(fps)
+}
+
+// Preferences returns the UI state worth restoring on the next launch.
+// Thresholds are only included when changed during the session, so values
+// that came from a profile are not persisted as user preferences.
+func (m Model) Preferences() prefs.Preferences {
+	p := prefs.Preferences{ViewMode: m.viewMode.String(), Orientation: m.orientation(), Legend: &m.showLegend}
+	if m.config.Thresholds != m.initialThresholds {
+		th := m.config.Thresholds
+		p.Thresholds = &th
+	}
+	return p
+}
+
+// SetHistory replaces the in-memory sample history, e.g. with a file-backed
+// buffer restored from an earlier session. Its samples are replayed into the
+// minutes view, SLA table, hour-of-day view and SLO status.
+func (m *Model) SetHistory(history buffer.Buffer[ping.Sample]) {
+	m.samples = history
+	m.minutes.Reset()
+	m.sla.Reset()
+	m.hours.Reset()
+	if m.slo != nil {
+		m.slo.Reset()
+	}
+	history.Range(func(_ int, sample ping.Sample) bool {
+		m.minutes.Add(sample)
+		m.sla.Add(sample)
+		m.hours.Add(sample)
+		if m.slo != nil {
+			m.slo.Add(sample)
+		}
+		return true
+	})
+	m.scrollPos = 0
+}
+
+// SetTargetSwitcher enables switching targets from the UI. fn stops the
+// current probe and starts probing the new target.
+func (m *Model) SetTargetSwitcher(fn func(target string) error) {
+	m.switchTarget = fn
+}
+
+// SetIntervalSetter enables changing the probe interval from the command
+// line. fn restarts the probe at the new interval.
+func (m *Model) SetIntervalSetter(fn func(d time.Duration) error) {
+	m.setInterval = fn
+}
+
+// SetMarker enables ":marker", which hands a note to fn to be recorded
+// with the time it was made.
+func (m *Model) SetMarker(fn func(note string)) {
+	m.mark = fn
+}
+
+// SetParserMisses makes the status bar warn about unparsed ping output as
+// reported by fn, without waiting for a sample to refresh the stats.
+func (m *Model) SetParserMisses(fn func() int) {
+	m.parserMisses = fn
+}
+
+// SetLogs sets the runtime log records shown in the log pane.
+func (m *Model) SetLogs(logs *log.Buffer) {
+	m.logs = logs
+}
+
+// SetHealth enables the debug panel showing pingheat's own health as
+// reported by fn.
+func (m *Model) SetHealth(fn func() metrics.Health) {
+	m.health = fn
+}
+
+// SetDiagnosis shows the verdict of -diagnose, as reported by fn, in the
+// header.
+func (m *Model) SetDiagnosis(fn func() metrics.Diagnosis) {
+	m.diagnosis = fn
+}
+
+// SetTargetInfo shows the target's hostname, AS and country, as reported
+// by fn, in the header.
+func (m *Model) SetTargetInfo(fn func() enrich.Info) {
+	m.targetInfo = fn
+}
+
+// SetCalibrator enables the delta view, comparing latency to the baseline
+// c learns.
+func (m *Model) SetCalibrator(c *metrics.Calibrator) {
+	m.calibrator = c
+}
+
+// SetWiFi shows the Wi-Fi link reported by fn in the stats panel.
+func (m *Model) SetWiFi(fn func() wifi.Link) {
+	m.wifi = fn
+}
+
+// SetSettingsApplier makes settings changed in the overlay take effect
+// beyond the UI through fn, e.g. in brownout detection and alerting.
+func (m *Model) SetSettingsApplier(fn func(Settings)) {
+	m.applySettings = fn
+}
+
 // SetSize sets the terminal size.
 func (m *Model) SetSize(width, height int) {
 	m.width = width
@@ -94,6 +399,15 @@ func (m *Model) SetSize(width, height int) {
 func (m Model) GridDimensions() (cols, rows int) {
 	// Reserve space for header (1 line), stats (2 lines), status bar (1 line), and borders (2 lines)
 	availableHeight := m.height - 7
+	if m.showLegend {
+		availableHeight--
+	}
+	if m.bannerText() != "" {
+		availableHeight--
+	}
+	if m.columns && m.viewMode != viewAligned {
+		availableHeight-- // Time axis
+	}
 	if availableHeight < 1 {
 		availableHeight = 1
 	}
@@ -107,43 +421,183 @@ func (m Model) GridDimensions() (cols, rows int) {
 	return availableWidth, availableHeight
 }
 
-// VisibleSamples returns the samples currently visible in the heatmap.
-func (m Model) VisibleSamples() []ping.Sample {
+// orientation returns the flag/config name of the heatmap orientation.
+func (m Model) orientation() string {
+	if m.columns {
+		return OrientationColumns
+	}
+	return OrientationRows
+}
+
+// samplesPerCell returns how many samples each heatmap cell encodes. With
+// -burst each block holds the probes of one interval.
+func (m Model) samplesPerCell() int {
+	if m.viewMode == viewBraille {
+		return 8
+	}
+	return max(1, m.config.Burst)
+}
+
+// visibleCapacity returns how many samples fit in the heatmap at once.
+func (m Model) visibleCapacity() int {
 	cols, rows := m.GridDimensions()
-	visibleCount := cols * rows
+	if m.viewMode == viewAligned {
+		return rows
+	}
+	return cols * rows * m.samplesPerCell()
+}
 
-	totalSamples := m.samples.Len()
-	if totalSamples == 0 {
-		return nil
+// itemCount returns how many heatmap items can be scrolled through: samples,
+// minute buckets in the minutes view, or minutes in the aligned view.
+func (m Model) itemCount() int {
+	switch m.viewMode {
+	case viewMinutes:
+		return len(m.minutes.Buckets(time.Minute))
+	case viewAligned:
+		first, ok := m.alignedStart()
+		if !ok {
+			return 0
+		}
+		last, _ := m.samples.GetLast()
+		return int(last.Timestamp.Truncate(time.Minute).Sub(first)/time.Minute) + 1
+	}
+	return m.samples.Len()
+}
+
+// alignedStart returns the minute of the oldest sample, which starts the
+// first row of the aligned view.
+func (m Model) alignedStart() (time.Time, bool) {
+	first, ok := m.samples.Get(0)
+	if !ok {
+		return time.Time{}, false
+	}
+	return first.Timestamp.Truncate(time.Minute), true
+}
+
+// visibleRange returns the inclusive index range of the visible items out of
+// total, or ok=false when there is nothing to show.
+func (m Model) visibleRange(total int) (start, end int, ok bool) {
+	if total == 0 {
+		return 0, 0, false
 	}
 
 	// Calculate the start index based on scroll position
-	maxScroll := totalSamples - visibleCount
+	maxScroll := total - m.visibleCapacity()
 	if maxScroll < 0 {
 		maxScroll = 0
 	}
 
-	startIdx := maxScroll - m.scrollPos
-	if startIdx < 0 {
-		startIdx = 0
+	start = maxScroll - m.scrollPos
+	if start < 0 {
+		start = 0
 	}
 
-	endIdx := startIdx + visibleCount
-	if endIdx > totalSamples {
-		endIdx = totalSamples
+	end = start + m.visibleCapacity()
+	if end > total {
+		end = total
 	}
+	return start, end - 1, true
+}
 
-	return m.samples.GetRange(startIdx, endIdx-1)
+// VisibleSamples returns the samples currently visible in the heatmap.
+func (m Model) VisibleSamples() []ping.Sample {
+	start, end, ok := m.visibleRange(m.samples.Len())
+	if !ok {
+		return nil
+	}
+	return m.samples.GetRange(start, end)
+}
+
+// VisibleBuckets returns the minute buckets currently visible in the minutes view.
+func (m Model) VisibleBuckets() []metrics.Bucket {
+	buckets := m.minutes.Buckets(time.Minute)
+	start, end, ok := m.visibleRange(len(buckets))
+	if !ok {
+		return nil
+	}
+	return buckets[start : end+1]
 }
 
 // CanScrollUp returns true if scrolling up is possible.
 func (m Model) CanScrollUp() bool {
-	cols, rows := m.GridDimensions()
-	visibleCount := cols * rows
-	maxScroll := m.samples.Len() - visibleCount
+	visibleCount := m.visibleCapacity()
+	maxScroll := m.itemCount() - visibleCount
 	return m.scrollPos < maxScroll
 }
 
+// maxScroll returns the largest valid scroll position.
+func (m Model) maxScroll() int {
+	maxScroll := m.itemCount() - m.visibleCapacity()
+	if maxScroll < 0 {
+		return 0
+	}
+	return maxScroll
+}
+
+// scrollToIndex scrolls so that the sample at buffer index idx (0 is oldest)
+// is the first visible sample, clamped to the valid scroll range.
+func (m *Model) scrollToIndex(idx int) {
+	maxScroll := m.maxScroll()
+	pos := maxScroll - idx
+	if pos < 0 {
+		pos = 0
+	}
+	if pos > maxScroll {
+		pos = maxScroll
+	}
+	m.scrollPos = pos
+}
+
+// addSample records a new sample and keeps the viewport anchored.
+func (m *Model) addSample(sample ping.Sample) {
+	prev, hadPrev := m.samples.GetLast()
+	m.samples.Push(sample)
+	m.minutes.Add(sample)
+	m.sla.Add(sample)
+	m.hours.Add(sample)
+	if m.slo != nil {
+		m.slo.Add(sample)
+	}
+	wasDown := m.isDown()
+	if !sample.Timeout {
+		m.downCount = 0
+	} else if m.downCount++; m.downCount == 1 {
+		m.downSince = sample.Timestamp
+	}
+	if down := m.isDown(); down != wasDown {
+		m.bellEvent = bellUp
+		if down {
+			m.bellEvent = bellDown
+		}
+	}
+
+	// The minute views only move when a new minute starts
+	if (m.viewMode == viewMinutes || m.viewMode == viewAligned) && hadPrev &&
+		prev.Timestamp.Truncate(time.Minute).Equal(sample.Timestamp.Truncate(time.Minute)) {
+		return
+	}
+	m.anchorScroll()
+}
+
+// clearHistory discards all samples and minute buckets.
+func (m *Model) clearHistory() {
+	m.samples.Clear()
+	m.minutes.Reset()
+	m.scrollPos = 0
+}
+
+// anchorScroll keeps the viewport on the same samples after a push while the
+// user is scrolled back, so live capture does not drag the view along.
+func (m *Model) anchorScroll() {
+	if m.scrollPos == 0 {
+		return
+	}
+	m.newSamples++
+	if m.scrollPos < m.maxScroll() {
+		m.scrollPos++
+	}
+}
+
 // CanScrollDown returns true if scrolling down is possible.
 func (m Model) CanScrollDown() bool {
 	return m.scrollPos > 0
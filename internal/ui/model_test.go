@@ -1,13 +1,27 @@
 package ui
 
 import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pbv7/pingheat/internal/alert"
+	"github.com/pbv7/pingheat/internal/buffer"
 	"github.com/pbv7/pingheat/internal/config"
+	"github.com/pbv7/pingheat/internal/enrich"
+	"github.com/pbv7/pingheat/internal/locale"
+	"github.com/pbv7/pingheat/internal/log"
 	"github.com/pbv7/pingheat/internal/metrics"
 	"github.com/pbv7/pingheat/internal/ping"
+	"github.com/pbv7/pingheat/internal/types"
+	"github.com/pbv7/pingheat/internal/ui/colors"
+	"github.com/pbv7/pingheat/internal/wifi"
 )
 
 func newTestModel() Model {
@@ -109,6 +123,39 @@ func TestRenderStatsWaiting(t *testing.T) {
 	}
 }
 
+func TestSLOStatus(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.SLO = &alert.SLO{Objective: 99, Threshold: 100 * time.Millisecond, Window: time.Hour}
+	model := NewModel(cfg, make(chan ping.Sample), make(chan metrics.Stats))
+	model.width = 120
+	model.height = 30
+	model.stats.TotalSamples = 100
+
+	base := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	for i := range 100 {
+		updated, _ := model.Update(SampleMsg{Sample: ping.Sample{
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+			RTT:       10 * time.Millisecond,
+			Timeout:   i%2 == 0,
+		}})
+		model = updated.(Model)
+	}
+	model.now = base.Add(100 * time.Second)
+
+	out := model.View()
+	for _, want := range []string{"SLO:", "50.00% budget 0%", "SLOFastBurn (page)"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("view missing %q:\n%s", want, out)
+		}
+	}
+
+	updated, _ := model.Update(TargetSwitchedMsg{Target: "example.org"})
+	model = updated.(Model)
+	if s := model.slo.Status(model.now); s.Samples != 0 {
+		t.Fatalf("expected SLO to restart for the new target, got %d samples", s.Samples)
+	}
+}
+
 func TestPlaceOverlay(t *testing.T) {
 	background := "12345\nabcde"
 	overlay := "XX\nYY"
@@ -150,4 +197,1447 @@ func TestRenderStatusBar(t *testing.T) {
 	if !strings.Contains(out, "Scroll: 1") {
 		t.Fatalf("expected scroll info")
 	}
+
+	model.stats.DroppedUISamples = 7
+	out = model.renderStatusBar()
+	if !strings.Contains(out, "7 samples dropped") {
+		t.Fatalf("expected dropped samples warning, got %q", out)
+	}
+
+	// A few stray lines among many samples are not worth a warning
+	model.stats.TotalSamples = 1000
+	model.stats.ParserMisses = 12
+	if out = model.renderStatusBar(); strings.Contains(out, "unparsed") {
+		t.Fatalf("unexpected parser warning for 12 of 1000 lines: %q", out)
+	}
+	// The live counter wins over stats that stopped with the last sample
+	model.SetParserMisses(func() int { return 150 })
+	if out = model.renderStatusBar(); !strings.Contains(out, "150 unparsed ping lines") {
+		t.Fatalf("expected parser warning, got %q", out)
+	}
+
+	model.samples.Push(ping.Sample{Timeout: true, Interval: 200 * time.Millisecond})
+	out = model.renderStatusBar()
+	if !strings.Contains(out, "probing every 200ms") {
+		t.Fatalf("expected adaptive interval notice, got %q", out)
+	}
+}
+
+func TestBrailleViewPacksSamples(t *testing.T) {
+	model := newTestModel()
+	model.width = 10
+	model.height = 10
+	model.viewMode = viewBraille
+
+	for i := 1; i <= 200; i++ {
+		model.samples.Push(ping.Sample{Sequence: i, RTT: 10 * time.Millisecond})
+	}
+
+	visible := model.VisibleSamples()
+	if len(visible) != 144 {
+		t.Fatalf("VisibleSamples len=%d, want 144 (18 cells × 8)", len(visible))
+	}
+	if visible[len(visible)-1].Sequence != 200 {
+		t.Fatalf("last visible sequence=%d, want 200", visible[len(visible)-1].Sequence)
+	}
+}
+
+func TestBurstCells(t *testing.T) {
+	model := newTestModel()
+	model.config.Burst = 4
+	model.width = 10
+	model.height = 10
+
+	// The first interval is cut off by the history, the second lost half
+	model.samples.Push(ping.Sample{Sequence: 1, Burst: 4, RTT: 10 * time.Millisecond})
+	for i := range 4 {
+		model.samples.Push(ping.Sample{Sequence: 2, Burst: 4, RTT: 10 * time.Millisecond, Timeout: i%2 == 1})
+	}
+	for range 4 {
+		model.samples.Push(ping.Sample{Sequence: 3, Burst: 4, Timeout: true})
+	}
+
+	grid := model.renderHeatmap()
+	if got := strings.Count(grid, "▄"); got != 1 {
+		t.Fatalf("heatmap has %d half-filled cells, want the half-lost interval:\n%s", got, grid)
+	}
+	if got := strings.Count(grid, "█"); got != 2 {
+		t.Fatalf("heatmap has %d full cells, want the cut-off and the lost interval:\n%s", got, grid)
+	}
+}
+
+func TestBurstSmoke(t *testing.T) {
+	model := newTestModel()
+	model.config.Burst = 4
+	th := model.config.Thresholds
+
+	burst := func(rtts ...time.Duration) []ping.Sample {
+		samples := make([]ping.Sample, len(rtts))
+		for i, rtt := range rtts {
+			samples[i] = ping.Sample{Sequence: 1, Burst: len(rtts), RTT: rtt, Timeout: rtt < 0}
+		}
+		return samples
+	}
+	ms := func(v float64) time.Duration { return time.Duration(v * float64(time.Millisecond)) }
+
+	tests := []struct {
+		name    string
+		samples []ping.Sample
+		color   lipgloss.Color
+		smoke   int
+	}{
+		{"steady", burst(ms(10), ms(10), ms(11), ms(10)), colors.ColorExcellent, 0},
+		{"lan jitter stays clear", burst(ms(0.2), ms(0.9), ms(0.3), ms(0.4)), colors.ColorExcellent, 0},
+		{"one spike", burst(ms(10), ms(10), ms(10), ms(th.Poor+50)), colors.ColorExcellent, 3},
+		{"moderate spread", burst(ms(100), ms(110), ms(120), ms(125)), th.ClassifyMs(115), 1},
+		{"one lost", burst(ms(10), -1, ms(10), ms(10)), colors.ColorExcellent, 1},
+		{"half lost", burst(ms(10), -1, -1, ms(10)), colors.ColorExcellent, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, color, smoke := model.burstCell(tt.samples)
+			if color != tt.color {
+				t.Fatalf("color = %v, want %v", color, tt.color)
+			}
+			if smoke != colors.Smoke(tt.smoke) {
+				t.Fatalf("smoke = %q, want level %d (%q)", smoke, tt.smoke, colors.Smoke(tt.smoke))
+			}
+		})
+	}
+}
+
+func TestMinutesView(t *testing.T) {
+	model := newTestModel()
+	model.width = 10
+	model.height = 10
+	model.viewMode = viewMinutes
+
+	// 30 minutes with two samples each; minute 10 loses one
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	for m := 0; m < 30; m++ {
+		ts := base.Add(time.Duration(m) * time.Minute)
+		updated, _ := model.Update(SampleMsg{Sample: ping.Sample{Timestamp: ts, RTT: 10 * time.Millisecond}})
+		model = updated.(Model)
+		updated, _ = model.Update(SampleMsg{Sample: ping.Sample{Timestamp: ts.Add(time.Second), Timeout: m == 10}})
+		model = updated.(Model)
+	}
+
+	visible := model.VisibleBuckets()
+	if len(visible) != 18 {
+		t.Fatalf("VisibleBuckets len=%d, want 18 (6×3 cells)", len(visible))
+	}
+	if !visible[len(visible)-1].Start.Equal(base.Add(29 * time.Minute)) {
+		t.Fatalf("last visible bucket starts at %v, want minute 29", visible[len(visible)-1].Start)
+	}
+	if !model.CanScrollUp() {
+		t.Fatalf("expected older minutes to scroll to")
+	}
+	if !strings.Contains(model.renderHeatmap(), "█") {
+		t.Fatalf("minutes heatmap has no cells")
+	}
+
+	model.config.UTC = true
+	model.jumpTo("2024-01-02T10:10:30Z")
+	visible = model.VisibleBuckets()
+	if !visible[0].Start.Equal(base.Add(10*time.Minute)) || visible[0].Timeouts != 1 {
+		t.Fatalf("after jump first bucket = %+v, want minute 10 with a timeout", visible[0])
+	}
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	model = updated.(Model)
+	if got := model.VisibleBuckets(); got != nil {
+		t.Fatalf("after clear VisibleBuckets = %+v, want none", got)
+	}
+}
+
+func TestWorstColor(t *testing.T) {
+	model := newTestModel()
+	samples := []ping.Sample{
+		{RTT: 10 * time.Millisecond},
+		{RTT: 400 * time.Millisecond},
+	}
+	if got := model.worstColor(samples); got != colors.ColorBad {
+		t.Fatalf("worstColor=%v, want %v", got, colors.ColorBad)
+	}
+
+	samples = append(samples, ping.Sample{Timeout: true})
+	if got := model.worstColor(samples); got != colors.ColorTimeout {
+		t.Fatalf("worstColor with timeout=%v, want %v", got, colors.ColorTimeout)
+	}
+
+	samples = []ping.Sample{{RTT: 10 * time.Millisecond}, {Timeout: true, ErrorKind: types.ErrorTTLExceeded}}
+	if got := model.worstColor(samples); got != colors.ColorTTLExceeded {
+		t.Fatalf("worstColor with TTL exceeded=%v, want %v", got, colors.ColorTTLExceeded)
+	}
+}
+
+func TestGapsMarked(t *testing.T) {
+	model := newTestModel()
+	model.width = 10
+	model.height = 10
+
+	model.samples.Push(ping.Sample{Sequence: 1, RTT: 10 * time.Millisecond})
+	model.samples.Push(ping.Sample{Sequence: 2, RTT: 10 * time.Millisecond, ClockJump: true})
+	model.samples.Push(ping.Sample{Sequence: 3, RTT: 10 * time.Millisecond})
+	if got := strings.Count(model.renderHeatmap(), colors.GapChar()); got != 1 {
+		t.Fatalf("heatmap has %d clock jump marks, want 1", got)
+	}
+
+	// A long silence is a gap too
+	model.samples.Clear()
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	for i, at := range []time.Duration{0, time.Second, time.Hour, time.Hour + time.Second} {
+		model.samples.Push(ping.Sample{Sequence: i, Timestamp: start.Add(at), RTT: 10 * time.Millisecond})
+	}
+	if got := strings.Count(model.renderHeatmap(), colors.GapChar()); got != 1 {
+		t.Fatalf("heatmap has %d gap marks, want 1", got)
+	}
+
+	model.stats.TotalSamples = 3
+	model.stats.ClockJumps = 1
+	model.stats.SkippedTime = 90 * time.Minute
+	if out := model.renderStats(); !strings.Contains(out, "1 (1h30m0s skipped)") {
+		t.Fatalf("expected clock jumps in stats, got %q", out)
+	}
+}
+
+func TestRenderStatsErrorCounts(t *testing.T) {
+	model := newTestModel()
+	model.stats.TotalSamples = 4
+	model.stats.TotalTimeouts = 3
+	model.stats.ErrorCounts[types.ErrorTimeout] = 1
+	model.stats.ErrorCounts[types.ErrorHostUnreachable] = 2
+	out := model.renderStats()
+	if !strings.Contains(out, "2 host unreachable") || strings.Contains(out, "1 timeout") {
+		t.Fatalf("expected host unreachable count only, got %q", out)
+	}
+}
+
+func TestRenderStatsWiFi(t *testing.T) {
+	model := newTestModel()
+	model.stats.TotalSamples = 10
+	model.stats.TotalTimeouts = 1
+	model.stats.LossPercent = 10
+	model.stats.WeakSignalSamples = 4
+	model.stats.WeakSignalTimeouts = 1
+	model.SetWiFi(func() wifi.Link { return wifi.Link{Signal: -72, Noise: -95, Channel: 6} })
+
+	out := model.renderStats()
+	for _, want := range []string{"Wi-Fi: -72dBm SNR 23dB ch6", "Weak-signal loss: 25.0%/4"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("renderStats() = %q, want %q", out, want)
+		}
+	}
+
+	model.SetWiFi(func() wifi.Link { return wifi.Link{} })
+	if out := model.renderStats(); !strings.Contains(out, "Wi-Fi: disconnected") {
+		t.Fatalf("renderStats() without a link = %q, want disconnected", out)
+	}
+}
+
+func TestHeaderTargetInfo(t *testing.T) {
+	model := newTestModel()
+	model.config.Target = "8.8.8.8"
+	var info enrich.Info
+	model.SetTargetInfo(func() enrich.Info { return info })
+	if out := model.renderHeader(); strings.Contains(out, "(") {
+		t.Fatalf("renderHeader() before the lookup = %q, want no target info", out)
+	}
+
+	info = enrich.Info{Target: "8.8.8.8", Addr: "8.8.8.8", Hostname: "dns.google", ASN: 15169,
+		ASName: "GOOGLE - Google LLC", Country: "US"}
+	if out := model.renderHeader(); !strings.Contains(out, "8.8.8.8 (dns.google, AS15169 GOOGLE, US)") {
+		t.Fatalf("renderHeader() = %q, want the hostname, AS and country", out)
+	}
+}
+
+func TestDeltaView(t *testing.T) {
+	model := newTestModel()
+	calibrator := metrics.NewCalibrator(10)
+	model.SetCalibrator(calibrator)
+	slow := []ping.Sample{{RTT: 400 * time.Millisecond}}
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	model = updated.(Model)
+	if !model.delta {
+		t.Fatalf("d did not switch to the delta view")
+	}
+
+	// Absolute colors and the progress while calibrating
+	if got := model.worstColor(slow); got != colors.ColorBad {
+		t.Fatalf("worstColor() while calibrating = %v, want the absolute %v", got, colors.ColorBad)
+	}
+	if out := model.renderHeader(); !strings.Contains(out, "Delta: calibrating 0/10") {
+		t.Fatalf("renderHeader() = %q, want the calibration progress", out)
+	}
+
+	calibrator.Seed(metrics.Baseline{P50Ms: 90})
+	if out := model.renderHeader(); !strings.Contains(out, "vs stored baseline 90.0ms") {
+		t.Fatalf("renderHeader() = %q, want the stored baseline", out)
+	}
+
+	calibrator.Set(metrics.Baseline{P50Ms: 100, P95Ms: 150})
+	if got := model.worstColor(slow); got != colors.ColorDeltaWorse {
+		t.Fatalf("worstColor() of 400ms vs 100ms = %v, want %v", got, colors.ColorDeltaWorse)
+	}
+	if got := model.worstColor([]ping.Sample{{RTT: 60 * time.Millisecond}}); got != colors.ColorDeltaFaster {
+		t.Fatalf("worstColor() of 60ms vs 100ms = %v, want %v", got, colors.ColorDeltaFaster)
+	}
+	if got := model.p95Color(160); got != colors.ColorDeltaSame {
+		t.Fatalf("p95Color() of 160ms vs a 150ms p95 = %v, want %v", got, colors.ColorDeltaSame)
+	}
+	model.stats.TotalSamples, model.stats.TotalSuccess = 10, 10
+	model.stats.Percentiles.P50 = 130
+	if out := model.renderHeader(); !strings.Contains(out, "Delta: p50 +30.0ms vs baseline 100.0ms") {
+		t.Fatalf("renderHeader() = %q, want the p50 delta", out)
+	}
+	if out := model.legendScale(); !strings.Contains(out, "of 100.0ms") {
+		t.Fatalf("legendScale() = %q, want the delta bands", out)
+	}
+
+	model.delta = false
+	if got := model.worstColor(slow); got != colors.ColorBad {
+		t.Fatalf("worstColor() outside the delta view = %v, want %v", got, colors.ColorBad)
+	}
+}
+
+func TestStatusBarClock(t *testing.T) {
+	model := newTestModel()
+	model.width = 120
+	model.config.UTC = true
+	model.startTime = time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	model.now = model.startTime.Add(90 * time.Second)
+	model.stats.TotalSamples = 45
+
+	out := model.renderStatusBar()
+	for _, want := range []string{"10:01:30Z", "up 0:01:30", "0.5/s"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in status bar, got %q", want, out)
+		}
+	}
+}
+
+func TestLocaleFormats(t *testing.T) {
+	model := newTestModel()
+	model.width = 160
+	model.config.Format = locale.Format{Hour12: true, Comma: true, Group: "."}
+	model.startTime = time.Date(2024, 1, 2, 15, 0, 0, 0, time.Local)
+	model.now = model.startTime.Add(90 * time.Second)
+	model.stats = metrics.Stats{
+		TotalSamples:  12345,
+		TotalSuccess:  12300,
+		TotalTimeouts: 45,
+		LossPercent:   0.36,
+		AvgRTT:        18250 * time.Microsecond,
+	}
+
+	stats := model.renderStats()
+	for _, want := range []string{"Sent: 12.345", "Loss: 0,4%", "Avg: 18,2ms"} {
+		if !strings.Contains(stats, want) {
+			t.Fatalf("expected %q in stats, got %q", want, stats)
+		}
+	}
+	status := model.renderStatusBar()
+	for _, want := range []string{"3:01:30 PM", "137,2/s"} {
+		if !strings.Contains(status, want) {
+			t.Fatalf("expected %q in status bar, got %q", want, status)
+		}
+	}
+}
+
+func TestFormatElapsed(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: 0, want: "0:00:00"},
+		{d: 61 * time.Second, want: "0:01:01"},
+		{d: 26*time.Hour + 5*time.Second, want: "1d02:00:05"},
+	}
+	for _, tc := range tests {
+		if got := formatElapsed(tc.d); got != tc.want {
+			t.Fatalf("formatElapsed(%v) = %q, want %q", tc.d, got, tc.want)
+		}
+	}
+}
+
+func TestParseJumpTarget(t *testing.T) {
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "relative", input: "-15m", want: now.Add(-15 * time.Minute)},
+		{name: "unsigned relative", input: "1h", want: now.Add(-time.Hour)},
+		{name: "clock today", input: "09:30", want: time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)},
+		{name: "clock yesterday", input: "11:00:00", want: time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{name: "date time", input: "2024-01-01 23:59", want: time.Date(2024, 1, 1, 23, 59, 0, 0, time.UTC)},
+		{name: "garbage", input: "yesterday", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseJumpTarget(tc.input, now, time.UTC)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseJumpTarget(%q) err=%v, wantErr=%v", tc.input, err, tc.wantErr)
+			}
+			if !tc.wantErr && !got.Equal(tc.want) {
+				t.Fatalf("parseJumpTarget(%q) = %v, want %v", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestJumpPromptScrollsToTime(t *testing.T) {
+	model := newTestModel()
+	model.width = 10
+	model.height = 10
+	model.config.UTC = true
+
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 60; i++ {
+		model.samples.Push(ping.Sample{Sequence: i, Timestamp: start.Add(time.Duration(i) * time.Second)})
+	}
+	model.now = start.Add(60 * time.Second)
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	model = updated.(Model)
+	if model.prompt != promptJump {
+		t.Fatalf("expected jump prompt to open")
+	}
+	for _, r := range "10:00:20" {
+		updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		model = updated.(Model)
+	}
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updated.(Model)
+
+	if model.prompt != promptNone {
+		t.Fatalf("expected prompt to close after enter")
+	}
+	visible := model.VisibleSamples()
+	if visible[0].Sequence != 20 {
+		t.Fatalf("first visible sequence=%d, want 20 (status %q)", visible[0].Sequence, model.statusMsg)
+	}
+}
+
+func TestScrolledViewStaysAnchored(t *testing.T) {
+	model := newTestModel()
+	model.width = 10
+	model.height = 10
+
+	for i := 1; i <= 30; i++ {
+		model.samples.Push(ping.Sample{Sequence: i})
+	}
+	model.scrollPos = 5
+	before := model.VisibleSamples()
+
+	for i := 31; i <= 33; i++ {
+		updated, _ := model.Update(SampleMsg{Sample: ping.Sample{Sequence: i}})
+		model = updated.(Model)
+	}
+
+	after := model.VisibleSamples()
+	if after[0].Sequence != before[0].Sequence {
+		t.Fatalf("viewport moved: first sequence %d, want %d", after[0].Sequence, before[0].Sequence)
+	}
+	if model.newSamples != 3 {
+		t.Fatalf("newSamples=%d, want 3", model.newSamples)
+	}
+	if out := model.renderStatusBar(); !strings.Contains(out, "3 new samples") {
+		t.Fatalf("expected new samples indicator, got %q", out)
+	}
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("G")})
+	model = updated.(Model)
+	if model.scrollPos != 0 || model.newSamples != 0 {
+		t.Fatalf("expected live view after G, got scrollPos=%d newSamples=%d", model.scrollPos, model.newSamples)
+	}
+}
+
+func TestPreferencesReflectState(t *testing.T) {
+	model := newTestModel()
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("v")})
+	model = updated.(Model)
+
+	p := model.Preferences()
+	if p.ViewMode != "braille" {
+		t.Fatalf("ViewMode=%q, want braille", p.ViewMode)
+	}
+	if p.Thresholds != nil {
+		t.Fatalf("Thresholds=%v, want nil when unchanged", p.Thresholds)
+	}
+
+	model.config.Thresholds = colors.Thresholds{Excellent: 10, Good: 20, Fair: 40, Poor: 80}
+	p = model.Preferences()
+	if p.Thresholds == nil || *p.Thresholds != model.config.Thresholds {
+		t.Fatalf("Thresholds=%v, want %v", p.Thresholds, model.config.Thresholds)
+	}
+}
+
+func TestSwitchTargetPrompt(t *testing.T) {
+	tests := []struct {
+		key         string
+		wantSamples int
+	}{
+		{"o", 3}, // Keep history
+		{"O", 0}, // Clear history
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			model := newTestModel()
+			model.config.Target = "1.1.1.1"
+			for i := 0; i < 3; i++ {
+				model.samples.Push(ping.Sample{Sequence: i})
+			}
+			var switched string
+			model.SetTargetSwitcher(func(target string) error {
+				switched = target
+				return nil
+			})
+
+			updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(tt.key)})
+			model = updated.(Model)
+			for _, r := range "8.8.8.8" {
+				updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+				model = updated.(Model)
+			}
+			updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+			model = updated.(Model)
+			if cmd == nil {
+				t.Fatalf("expected a switch command")
+			}
+
+			updated, _ = model.Update(cmd())
+			model = updated.(Model)
+			if switched != "8.8.8.8" || model.config.Target != "8.8.8.8" {
+				t.Fatalf("switched=%q target=%q, want 8.8.8.8", switched, model.config.Target)
+			}
+			if got := model.samples.Len(); got != tt.wantSamples {
+				t.Fatalf("samples=%d, want %d", got, tt.wantSamples)
+			}
+		})
+	}
+}
+
+func TestSwitchTargetErrors(t *testing.T) {
+	model := newTestModel()
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("o")})
+	model = updated.(Model)
+	if model.prompt != promptNone || !model.statusErr {
+		t.Fatalf("expected an error without a target switcher")
+	}
+
+	model.config.Target = "1.1.1.1"
+	updated, _ = model.Update(TargetSwitchedMsg{Target: "bad", Err: config.ErrInvalidTarget})
+	model = updated.(Model)
+	if model.config.Target != "1.1.1.1" || !model.statusErr {
+		t.Fatalf("failed switch changed target to %q", model.config.Target)
+	}
+}
+
+func TestSLATable(t *testing.T) {
+	model := newTestModel()
+	model.width = 80
+	model.height = 30
+	model.config.UTC = true
+	model.sla = metrics.NewSLATracker(time.UTC)
+
+	base := time.Date(2024, 1, 2, 23, 0, 0, 0, time.UTC)
+	for i := range 120 {
+		updated, _ := model.Update(SampleMsg{Sample: ping.Sample{
+			Timestamp: base.Add(time.Duration(i) * time.Minute),
+			RTT:       10 * time.Millisecond,
+			Timeout:   i == 0,
+		}})
+		model = updated.(Model)
+	}
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+	model = updated.(Model)
+	if !model.showSLA {
+		t.Fatalf("expected SLA table after a")
+	}
+
+	out := model.View()
+	for _, want := range []string{"2024-01-02 23:00", "98.333%", "2024-01-03 00:00", "100.000%", "2024-01-02 ", "10.0ms"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("SLA table missing %q:\n%s", want, out)
+		}
+	}
+
+	updated, _ = model.Update(TargetSwitchedMsg{Target: "example.org"})
+	model = updated.(Model)
+	if len(model.sla.Hours()) != 0 {
+		t.Fatalf("expected SLA to restart for the new target")
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updated.(Model).showSLA {
+		t.Fatalf("expected esc to close the SLA table")
+	}
+}
+
+func TestHoursView(t *testing.T) {
+	model := newTestModel()
+	model.width = 100
+	model.height = 30
+	model.hours = metrics.NewHourOfDayTracker(time.UTC)
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("H")})
+	model = updated.(Model)
+	if out := model.View(); !strings.Contains(out, "No samples yet") {
+		t.Fatalf("empty hour-of-day view:\n%s", out)
+	}
+
+	evening := time.Date(2024, 1, 2, 19, 0, 0, 0, time.UTC)
+	for i := range 20 {
+		updated, _ = model.Update(SampleMsg{Sample: ping.Sample{
+			Timestamp: evening.Add(time.Duration(i) * time.Minute),
+			RTT:       500 * time.Millisecond,
+			Timeout:   i < 5,
+		}})
+		model = updated.(Model)
+	}
+	out := model.View()
+	for _, want := range []string{"By Hour of Day", "lost", "18", "19:00", "100.0% bad", "25.00% loss"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("hour-of-day view missing %q:\n%s", want, out)
+		}
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updated.(Model).showHours {
+		t.Fatalf("expected esc to close the hour-of-day view")
+	}
+}
+
+func TestTrendChart(t *testing.T) {
+	model := newTestModel()
+	model.width = 100
+	model.height = 30
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	model = updated.(Model)
+	if out := model.View(); !strings.Contains(out, "No replies yet") {
+		t.Fatalf("empty trend chart:\n%s", out)
+	}
+
+	// Three hours of latency rising from 20ms to about 80ms
+	base := time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)
+	for i := range 180 {
+		updated, _ = model.Update(SampleMsg{Sample: ping.Sample{
+			Timestamp: base.Add(time.Duration(i) * time.Minute),
+			RTT:       time.Duration(20+i/3) * time.Millisecond,
+		}})
+		model = updated.(Model)
+	}
+	out := model.View()
+	for _, want := range []string{"Latency Trend", "100ms", "0ms", "09:00", "3m per column", "p95 drift +"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("trend chart missing %q:\n%s", want, out)
+		}
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updated.(Model).showTrend {
+		t.Fatalf("expected esc to close the trend chart")
+	}
+}
+
+func TestTrendHelpers(t *testing.T) {
+	for _, tt := range []struct {
+		in, want float64
+	}{{0.7, 1}, {1, 1}, {37, 50}, {120, 200}, {501, 1000}} {
+		if got := niceCeil(tt.in); got != tt.want {
+			t.Fatalf("niceCeil(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	for _, tt := range []struct {
+		in   time.Duration
+		want string
+	}{{time.Minute, "1m"}, {10 * time.Minute, "10m"}, {time.Hour, "1h"}, {70 * time.Minute, "1h10m"}} {
+		if got := formatWidth(tt.in); got != tt.want {
+			t.Fatalf("formatWidth(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if got := axisLabel(2500); got != "2.5s" {
+		t.Fatalf("axisLabel(2500) = %q, want 2.5s", got)
+	}
+}
+
+func TestLogPane(t *testing.T) {
+	model := newTestModel()
+	model.width = 100
+	model.height = 12
+	logs := log.NewBuffer(log.DefaultBufferSize)
+	model.SetLogs(logs)
+	logger := log.New(nil, slog.LevelInfo, logs)
+	for i := range 20 {
+		logger.Info("probing", "seq", i)
+	}
+	logger.Warn("remote write failed", "error", "timeout")
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("L")})
+	model = updated.(Model)
+	out := model.View()
+	if !strings.Contains(out, "remote write failed error=timeout") {
+		t.Fatalf("log pane missing newest record:\n%s", out)
+	}
+	// Only the newest records that fit are shown
+	if strings.Contains(out, "seq=0\n") || strings.Contains(out, "seq=0 ") {
+		t.Fatalf("log pane shows records that do not fit:\n%s", out)
+	}
+
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updated.(Model).showLogs {
+		t.Fatalf("expected esc to close the log pane")
+	}
+}
+
+func TestDebugPanel(t *testing.T) {
+	model := newTestModel()
+	model.width = 100
+	model.height = 20
+	ctrlD := tea.KeyMsg{Type: tea.KeyCtrlD}
+
+	// Without a health source the panel stays hidden
+	updated, _ := model.Update(ctrlD)
+	if updated.(Model).showDebug {
+		t.Fatalf("expected no debug panel without a health source")
+	}
+
+	model.SetHealth(func() metrics.Health {
+		return metrics.Health{
+			Goroutines:       9,
+			HeapBytes:        3 << 20,
+			ProcessedSamples: 2,
+			ProcessingTime:   100 * time.Microsecond,
+			Channels:         []metrics.ChannelDepth{{Name: "ui", Len: 700, Cap: 1024}},
+			DroppedUISamples: 5,
+		}
+	})
+	updated, _ = model.Update(ctrlD)
+	model = updated.(Model)
+	out := model.View()
+	for _, want := range []string{"Goroutines", "3.0 MiB", "avg 50µs", "700/1024", "ui 5  stats 0"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("debug panel missing %q:\n%s", want, out)
+		}
+	}
+
+	updated, _ = model.Update(ctrlD)
+	if updated.(Model).showDebug {
+		t.Fatalf("expected ctrl+d to close the debug panel")
+	}
+}
+
+func TestTickDrainsQueuedSamples(t *testing.T) {
+	samples := make(chan ping.Sample, 10)
+	stats := make(chan metrics.Stats, 10)
+	model := NewModel(config.DefaultConfig(), samples, stats)
+
+	for i := range 5 {
+		samples <- ping.Sample{Sequence: i, Timestamp: time.Now(), RTT: time.Millisecond}
+		stats <- metrics.Stats{TotalSamples: i + 1}
+	}
+	updated, cmd := model.Update(TickMsg{Time: time.Now()})
+	model = updated.(Model)
+
+	if n := model.samples.Len(); n != 5 {
+		t.Fatalf("samples=%d after tick, want 5", n)
+	}
+	if model.stats.TotalSamples != 5 {
+		t.Fatalf("TotalSamples=%d, want the newest stats (5)", model.stats.TotalSamples)
+	}
+	if cmd == nil {
+		t.Fatalf("expected the next tick to be scheduled")
+	}
+
+	// Closed channels stop being drained without blocking
+	close(samples)
+	close(stats)
+	updated, _ = model.Update(TickMsg{Time: time.Now()})
+	if n := updated.(Model).samples.Len(); n != 5 {
+		t.Fatalf("samples=%d after close, want 5", n)
+	}
+}
+
+func TestSetHistoryReplaysSamples(t *testing.T) {
+	model := newTestModel()
+	history := buffer.NewRingBuffer[ping.Sample](10)
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		history.Push(ping.Sample{Sequence: i, Timestamp: start.Add(time.Duration(i) * time.Minute), RTT: 5 * time.Millisecond})
+	}
+
+	model.SetHistory(history)
+
+	if n := model.samples.Len(); n != 3 {
+		t.Fatalf("samples=%d, want 3", n)
+	}
+	if n := len(model.minutes.Buckets(time.Minute)); n != 3 {
+		t.Fatalf("minute buckets=%d, want 3 replayed from history", n)
+	}
+}
+
+func TestRenderCellCharCached(t *testing.T) {
+	color := lipgloss.Color("#123456")
+	want := lipgloss.NewStyle().Foreground(color).Render("█")
+	for range 2 {
+		if got := renderCellChar(color, "█"); got != want {
+			t.Fatalf("renderCellChar = %q, want %q", got, want)
+		}
+	}
+	if _, ok := cellCache.Load(cellKey{color: color, char: "█"}); !ok {
+		t.Fatalf("rendered cell was not cached")
+	}
+}
+
+func TestHeatmapCacheMatchesFullRender(t *testing.T) {
+	model := newTestModel()
+	model.width = 30
+	model.height = 12
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	push := func(from, to int) {
+		for i := from; i < to; i++ {
+			sample := ping.Sample{Sequence: i + 1, Timestamp: start.Add(time.Duration(i) * time.Second)}
+			switch {
+			case i%13 == 0:
+				sample.Timeout = true
+			case i == 40:
+				// Leave a gap before this sample
+				sample.Timestamp = sample.Timestamp.Add(time.Minute)
+			default:
+				sample.RTT = time.Duration(5+i*7%300) * time.Millisecond
+			}
+			model.samples.Push(sample)
+		}
+	}
+	check := func(step string) {
+		t.Helper()
+		full := model
+		full.heatmap = nil
+		if got, want := model.renderHeatmap(), full.renderHeatmap(); got != want {
+			t.Fatalf("%s: cached heatmap differs:\n%s\nwant:\n%s", step, got, want)
+		}
+	}
+
+	check("empty")
+	push(0, 30)
+	check("partial")
+	check("unchanged")
+	push(30, 200)
+	check("full")
+	model.scrollPos = 7
+	check("scrolled")
+	model.viewMode = viewBraille
+	model.scrollPos = 0
+	check("braille")
+	model.config.Thresholds.Excellent = 10
+	check("thresholds")
+}
+
+func TestTickIntervalAdapts(t *testing.T) {
+	model := newTestModel()
+	model.config.FPS = 20
+	model.lastUpdate = time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		since time.Duration // Since the last sample
+		want  time.Duration
+	}{
+		{0, 50 * time.Millisecond},
+		{idleAfter - time.Millisecond, 50 * time.Millisecond},
+		{idleAfter, time.Second / idleFPS},
+	}
+	for _, tt := range tests {
+		model.now = model.lastUpdate.Add(tt.since)
+		if got := model.tickInterval(); got != tt.want {
+			t.Fatalf("tickInterval %v after a sample = %v, want %v", tt.since, got, tt.want)
+		}
+	}
+
+	// A rate below the idle one is kept
+	model.config.FPS = 1
+	if got := model.tickInterval(); got != time.Second {
+		t.Fatalf("tickInterval at 1fps = %v, want 1s", got)
+	}
+}
+
+func TestUnicodeTerminal(t *testing.T) {
+	tests := []struct {
+		name string
+		goos string
+		env  map[string]string
+		want bool
+	}{
+		{"no hints", "linux", nil, true},
+		{"utf-8 locale", "linux", map[string]string{"LANG": "en_US.UTF-8"}, true},
+		{"utf8 locale with modifier", "linux", map[string]string{"LC_ALL": "de_DE.utf8@euro"}, true},
+		{"C locale", "linux", map[string]string{"LANG": "C"}, true},
+		{"latin-1 locale", "linux", map[string]string{"LANG": "en_US.ISO-8859-1"}, false},
+		{"LC_ALL wins", "linux", map[string]string{"LC_ALL": "en_US.ISO-8859-1", "LANG": "en_US.UTF-8"}, false},
+		{"linux console", "linux", map[string]string{"TERM": "linux", "LANG": "en_US.UTF-8"}, false},
+		{"dumb", "darwin", map[string]string{"TERM": "dumb"}, false},
+		{"vt100", "linux", map[string]string{"TERM": "vt100"}, false},
+		{"windows console", "windows", nil, false},
+		{"windows terminal", "windows", map[string]string{"WT_SESSION": "1"}, true},
+		{"mintty", "windows", map[string]string{"TERM": "xterm-256color"}, true},
+	}
+	for _, tt := range tests {
+		getenv := func(name string) string { return tt.env[name] }
+		if got := unicodeTerminal(tt.goos, getenv); got != tt.want {
+			t.Fatalf("%s: unicodeTerminal = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestASCIIHeatmap(t *testing.T) {
+	border, overlay := HeatmapBorderStyle, HelpOverlayStyle
+	SetupTerminal(true, false)
+	defer func() {
+		colors.SetASCII(false)
+		HeatmapBorderStyle, HelpOverlayStyle = border, overlay
+	}()
+
+	model := newTestModel()
+	model.width = 20
+	model.height = 10
+	model.samples.Push(ping.Sample{Sequence: 1, RTT: 5 * time.Millisecond})
+	model.samples.Push(ping.Sample{Sequence: 2, Timeout: true})
+
+	for _, r := range model.renderHeatmap() + model.renderStatusBar() {
+		if r > 0x7f && r != 0x1b {
+			t.Fatalf("ASCII heatmap contains %q", r)
+		}
+	}
+}
+
+func TestNoColorHeatmapShowsBands(t *testing.T) {
+	colors.SetNoColor(true)
+	defer colors.SetNoColor(false)
+
+	model := newTestModel()
+	model.width = 20
+	model.height = 10
+	model.viewMode = viewBraille
+	for i, rtt := range []time.Duration{5, 5, 5, 5, 5, 5, 5, 5, 500} {
+		model.samples.Push(ping.Sample{Sequence: i + 1, RTT: rtt * time.Millisecond})
+	}
+	model.samples.Push(ping.Sample{Sequence: 10, Timeout: true})
+
+	heatmap := model.renderHeatmap()
+	for _, want := range []string{"·", "×"} {
+		if !strings.Contains(heatmap, want) {
+			t.Fatalf("no-color heatmap lacks %q:\n%s", want, heatmap)
+		}
+	}
+}
+
+func TestLegendToggle(t *testing.T) {
+	model := newTestModel()
+	model.width = 120
+	model.height = 20
+	model.config.Thresholds = colors.Thresholds{Excellent: 12, Good: 34, Fair: 56, Poor: 78}
+	_, rows := model.GridDimensions()
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l")})
+	model = updated.(Model)
+	if !model.showLegend {
+		t.Fatalf("l did not show the legend")
+	}
+	if _, got := model.GridDimensions(); got != rows-1 {
+		t.Fatalf("heatmap rows with legend = %d, want %d", got, rows-1)
+	}
+	view := model.View()
+	for _, want := range []string{"<12ms", "<78ms", ">78ms", "timeout"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("view with legend lacks %q", want)
+		}
+	}
+	if lines := strings.Count(view, "\n") + 1; lines > model.height {
+		t.Fatalf("view with legend is %d lines, taller than %d", lines, model.height)
+	}
+	if p := model.Preferences(); p.Legend == nil || !*p.Legend {
+		t.Fatalf("Preferences().Legend = %v, want true", p.Legend)
+	}
+}
+
+func TestColumnsOrientation(t *testing.T) {
+	model := newTestModel()
+	model.width = 24
+	model.height = 12
+	model.config.UTC = true
+	model.columns = true
+	cols, rows := model.GridDimensions()
+	if cols < timeAxisEvery+5 {
+		t.Fatalf("grid too narrow for the test: %d columns", cols)
+	}
+
+	// Fill the first timeAxisEvery columns and one cell of the next
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	for i := range rows*timeAxisEvery + 1 {
+		model.samples.Push(ping.Sample{Sequence: i + 1, Timestamp: start.Add(time.Duration(i) * time.Minute), RTT: 5 * time.Millisecond})
+	}
+
+	lines := strings.Split(strings.TrimSuffix(model.renderHeatmap(), "\n"), "\n")
+	if len(lines) != rows+3 {
+		t.Fatalf("heatmap has %d lines, want %d grid rows, 2 borders and the axis", len(lines), rows)
+	}
+	// Time fills the first column top to bottom, so the top row holds a
+	// cell in column timeAxisEvery while the second row ends before it
+	top := []rune(lines[1])
+	second := []rune(lines[2])
+	if top[1+timeAxisEvery] == ' ' || second[1+timeAxisEvery] != ' ' {
+		t.Fatalf("cells not filled by column:\n%s", strings.Join(lines, "\n"))
+	}
+	axis := lines[len(lines)-1]
+	if !strings.HasPrefix(axis, " 10:00") || !strings.Contains(axis, start.Add(time.Duration(rows*timeAxisEvery)*time.Minute).Format("15:04")) {
+		t.Fatalf("time axis = %q", axis)
+	}
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("V")})
+	if updated.(Model).columns {
+		t.Fatalf("V did not switch back to rows")
+	}
+	if got := model.Preferences().Orientation; got != OrientationColumns {
+		t.Fatalf("Preferences().Orientation = %q, want columns", got)
+	}
+}
+
+func TestAlignedView(t *testing.T) {
+	model := newTestModel()
+	model.width = 24
+	model.height = 12
+	model.config.UTC = true
+	model.config.Interval = 10 * time.Second
+	model.viewMode = viewAligned
+
+	// Two full minutes missing 10:00:30, then one sample into the third
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	for i := range 12 {
+		if i == 3 {
+			continue
+		}
+		model.addSample(ping.Sample{Sequence: i + 1, Timestamp: start.Add(time.Duration(i) * 10 * time.Second), RTT: 5 * time.Millisecond})
+	}
+	model.addSample(ping.Sample{Sequence: 13, Timestamp: start.Add(2*time.Minute + 5*time.Second), RTT: 5 * time.Millisecond})
+
+	if got := model.itemCount(); got != 3 {
+		t.Fatalf("itemCount() = %d, want 3 minutes", got)
+	}
+	lines := strings.Split(strings.TrimSuffix(model.renderHeatmap(), "\n"), "\n")
+	cell := colors.HeatmapChar(false)
+	// Border, then the label; the cells start at rune 1+alignedLabelWidth
+	want := []string{
+		"10:00 " + strings.Repeat(cell, 3) + " " + strings.Repeat(cell, 2),
+		"10:01 " + strings.Repeat(cell, 6),
+		"10:02 " + cell + "     ",
+	}
+	for i, w := range want {
+		got := string([]rune(lines[1+i])[1 : 1+len([]rune(w))])
+		if got != w {
+			t.Fatalf("row %d = %q, want %q:\n%s", i, got, w, strings.Join(lines, "\n"))
+		}
+	}
+
+	// With one row in view, jumping to 10:01:30 shows the 10:01 row
+	model.height = 8
+	model.jumpToRow(start.Add(90 * time.Second))
+	if got := model.renderHeatmap(); !strings.Contains(got, "10:01") || strings.Contains(got, "10:00") {
+		t.Fatalf("after jump, heatmap = %q", got)
+	}
+}
+
+func TestScreenshotKey(t *testing.T) {
+	model := newTestModel()
+	model.width = 40
+	model.height = 12
+	model.config.ScreenshotDir = t.TempDir()
+	model.config.ScreenshotFormat = "html"
+
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("S")})
+	if cmd == nil {
+		t.Fatalf("S returned no command")
+	}
+	msg, ok := cmd().(StatusMsg)
+	if !ok || !strings.Contains(msg.Message, ".ans") || !strings.Contains(msg.Message, ".html") {
+		t.Fatalf("screenshot message = %+v, want both files", msg)
+	}
+	matches, _ := filepath.Glob(filepath.Join(model.config.ScreenshotDir, "pingheat-*"))
+	if len(matches) != 2 {
+		t.Fatalf("screenshot files = %v, want .ans and .html", matches)
+	}
+}
+
+func TestCopyStatsSummary(t *testing.T) {
+	var copied string
+	orig := copyToClipboard
+	copyToClipboard = func(s string) { copied = s }
+	defer func() { copyToClipboard = orig }()
+
+	model := newTestModel()
+	model.config.Target = "example.com"
+	model.stats = metrics.Stats{
+		TotalSamples:  3600,
+		TotalSuccess:  3582,
+		TotalTimeouts: 18,
+		LossPercent:   0.5,
+		UptimeSeconds: 3600,
+		Percentiles:   metrics.Percentiles{P95: 23.44},
+		LossBursts:    2,
+		Downtime:      12 * time.Second,
+	}
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	for _, want := range []string{"example.com", "Duration: 1h0m0s (3600 samples)", "Loss: 0.50% (18 lost)", "p95: 23.4ms", "Outages: 2 (downtime 12s)"} {
+		if !strings.Contains(copied, want) {
+			t.Fatalf("copied summary lacks %q:\n%s", want, copied)
+		}
+	}
+	if updated.(Model).statusErr || updated.(Model).statusMsg == "" {
+		t.Fatalf("no status after copying")
+	}
+}
+
+func TestOutageBanner(t *testing.T) {
+	model := newTestModel()
+	model.width = 60
+	model.height = 20
+	model.config.Target = "example.com"
+	model.config.DownAfter = 3
+	_, rowsBefore := model.GridDimensions()
+
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	model.addSample(ping.Sample{Sequence: 1, Timestamp: start, RTT: 5 * time.Millisecond})
+	for i := range 3 {
+		if model.bannerText() != "" {
+			t.Fatalf("banner after %d lost samples, want it after 3", i)
+		}
+		model.addSample(ping.Sample{Sequence: i + 2, Timestamp: start.Add(time.Duration(i+1) * time.Second), Timeout: true})
+	}
+	model.now = start.Add(90 * time.Second)
+
+	banner := model.bannerText()
+	if !strings.Contains(banner, "example.com DOWN for 0:01:29 (3 lost)") {
+		t.Fatalf("banner = %q", banner)
+	}
+	if lines := strings.Split(model.View(), "\n"); !strings.Contains(lines[0], "DOWN") {
+		t.Fatalf("banner is not the top line:\n%s", strings.Join(lines, "\n"))
+	}
+	if _, rows := model.GridDimensions(); rows != rowsBefore-1 {
+		t.Fatalf("grid has %d rows with the banner, want %d", rows, rowsBefore-1)
+	}
+
+	model.addSample(ping.Sample{Sequence: 5, Timestamp: start.Add(4 * time.Second), RTT: 5 * time.Millisecond})
+	if banner := model.bannerText(); banner != "" {
+		t.Fatalf("banner after recovery = %q", banner)
+	}
+}
+
+func TestBellOnOutageTransitions(t *testing.T) {
+	rings := 0
+	orig := ringBell
+	ringBell = func() { rings++ }
+	defer func() { ringBell = orig }()
+
+	model := newTestModel()
+	model.config.DownAfter = 2
+	model.config.Bell = true
+	model.config.BellCooldown = time.Minute
+
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	step := func(offset time.Duration, timeout bool) {
+		model.now = start.Add(offset)
+		model.addSample(ping.Sample{Timestamp: model.now, Timeout: timeout, RTT: 5 * time.Millisecond})
+		model.bellCmd()
+	}
+
+	step(0, true)
+	if rings != 0 {
+		t.Fatalf("bell rang after one lost sample, want it at the second")
+	}
+	step(time.Second, true)
+	step(2*time.Second, true)
+	if rings != 1 {
+		t.Fatalf("bell rang %d times going down, want once", rings)
+	}
+	// The end of an announced outage rings even within the cooldown
+	step(3*time.Second, false)
+	if rings != 2 {
+		t.Fatalf("bell rang %d times after recovery, want 2", rings)
+	}
+	// An outage within the cooldown stays quiet, and so does its end
+	step(4*time.Second, true)
+	step(5*time.Second, true)
+	step(6*time.Second, false)
+	if rings != 2 {
+		t.Fatalf("bell rang %d times for an outage within the cooldown, want 2", rings)
+	}
+	step(2*time.Minute, true)
+	step(2*time.Minute+time.Second, true)
+	if rings != 3 {
+		t.Fatalf("bell rang %d times, want 3 after the cooldown", rings)
+	}
+
+	model.config.Bell = false
+	step(5*time.Minute, false)
+	if rings != 3 {
+		t.Fatalf("bell rang although -bell is off")
+	}
+}
+
+func TestSettingsOverlayAppliesLive(t *testing.T) {
+	model := newTestModel()
+	slo := alert.SLO{Objective: 99, Threshold: 100 * time.Millisecond, Window: time.Hour}
+	model.slo = alert.NewTracker(slo)
+	model.width, model.height = 80, 30
+	var applied Settings
+	model.SetSettingsApplier(func(s Settings) { applied = s })
+
+	press := func(keys ...tea.KeyMsg) {
+		for _, k := range keys {
+			updated, _ := model.Update(k)
+			model = updated.(Model)
+		}
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+	backspace := tea.KeyMsg{Type: tea.KeyBackspace}
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+
+	press(runes("s"))
+	if !model.showSettings || !strings.Contains(model.View(), "Brownout") {
+		t.Fatalf("expected the settings overlay to open")
+	}
+
+	// A poor threshold below fair is rejected
+	press(runes("j"), runes("j"), runes("j"), enter, backspace, backspace, backspace, runes("40"), enter)
+	if !model.editing || !model.statusErr || model.config.Thresholds.Poor != colors.DefaultThresholds().Poor {
+		t.Fatalf("expected an invalid threshold to be rejected, got %+v (%q)", model.config.Thresholds, model.statusMsg)
+	}
+	press(backspace, backspace, runes("400ms"), enter)
+	if model.editing || model.config.Thresholds.Poor != 400 || applied.Thresholds.Poor != 400 {
+		t.Fatalf("poor = %v, applied %v, want 400", model.config.Thresholds.Poor, applied.Thresholds.Poor)
+	}
+
+	press(runes("j"), enter, backspace, backspace, backspace, runes("250"), enter)
+	if model.config.Brownout != 250*time.Millisecond || applied.Brownout != 250*time.Millisecond {
+		t.Fatalf("brownout = %v, applied %v, want 250ms", model.config.Brownout, applied.Brownout)
+	}
+
+	press(runes("j"), enter)
+	for range model.input {
+		press(backspace)
+	}
+	press(runes("99.9%<50ms/1h"), enter)
+	if got := model.slo.SLO(); got.Objective != 99.9 || applied.SLO == nil || *applied.SLO != got {
+		t.Fatalf("slo = %v, applied %v, want 99.9%%<50ms/1h", got, applied.SLO)
+	}
+
+	// Without -profile there is nowhere to save to
+	press(runes("w"))
+	if !model.statusErr {
+		t.Fatalf("expected saving without a profile to fail")
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyEsc})
+	if model.showSettings {
+		t.Fatalf("expected esc to close the settings overlay")
+	}
+}
+
+func TestSettingsOverlaySavesProfile(t *testing.T) {
+	model := newTestModel()
+	model.config.ConfigPath = filepath.Join(t.TempDir(), "config.yaml")
+	model.config.Profile = "wan"
+	model.config.Thresholds.Poor = 400
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	_, cmd := updated.(Model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+	if cmd == nil {
+		t.Fatalf("expected a save command")
+	}
+	if msg := cmd(); msg != (StatusMsg{Message: "Settings saved to profile wan in " + model.config.ConfigPath}) {
+		t.Fatalf("unexpected message %#v", msg)
+	}
+
+	f, err := config.LoadFile(model.config.ConfigPath)
+	if err != nil {
+		t.Fatalf("LoadFile() error: %v", err)
+	}
+	if th := f.Profiles["wan"].Thresholds; th == nil || th.Poor != 400 {
+		t.Fatalf("saved thresholds = %+v, want poor 400", th)
+	}
+}
+
+func TestCommandLine(t *testing.T) {
+	model := newTestModel()
+	var notes []string
+	model.SetMarker(func(note string) { notes = append(notes, note) })
+	var interval time.Duration
+	model.SetIntervalSetter(func(d time.Duration) error { interval = d; return nil })
+
+	run := func(line string) tea.Cmd {
+		t.Helper()
+		updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(":")})
+		model = updated.(Model)
+		if model.prompt != promptCommand {
+			t.Fatalf("expected the command line to open")
+		}
+		model.input = line
+		updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		model = updated.(Model)
+		return cmd
+	}
+
+	run("view aligned")
+	if model.viewMode != viewAligned {
+		t.Fatalf("view = %v, want aligned", model.viewMode)
+	}
+	run("leg")
+	if !model.showLegend {
+		t.Fatalf("expected :leg to toggle the legend like l")
+	}
+	run(`marker "router reboot"`)
+	if len(notes) != 1 || notes[0] != "router reboot" {
+		t.Fatalf("notes = %q, want [router reboot]", notes)
+	}
+	if cmd := run("interval 500ms"); cmd != nil {
+		updated, _ := model.Update(cmd())
+		model = updated.(Model)
+	}
+	if interval != 500*time.Millisecond || model.config.Interval != 500*time.Millisecond {
+		t.Fatalf("interval = %v, config %v, want 500ms", interval, model.config.Interval)
+	}
+
+	for _, line := range []string{"frobnicate", "c", "interval soon", "export xml"} {
+		if run(line); !model.statusErr {
+			t.Fatalf("expected an error for %q, got %q", line, model.statusMsg)
+		}
+	}
+}
+
+func TestCommandExport(t *testing.T) {
+	model := newTestModel()
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		model.samples.Push(ping.Sample{Sequence: i, Timestamp: start.Add(time.Duration(i) * time.Second), RTT: 10 * time.Millisecond})
+	}
+	path := filepath.Join(t.TempDir(), "history.csv")
+
+	_, cmd := model.runCommand("export csv " + path)
+	if msg := cmd(); msg != (StatusMsg{Message: "Exported 3 samples to " + path}) {
+		t.Fatalf("unexpected message %#v", msg)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 4 || lines[0] != "timestamp,seq,rtt_ms,timeout" {
+		t.Fatalf("exported CSV = %q, want a header and 3 rows", data)
+	}
+
+	// A second export to the same name leaves the first one alone
+	_, cmd = model.runCommand("export json " + path)
+	next := filepath.Join(filepath.Dir(path), "history-2.csv")
+	if msg := cmd(); msg != (StatusMsg{Message: "Exported 3 samples to " + next}) {
+		t.Fatalf("unexpected message %#v", msg)
+	}
+	if again, err := os.ReadFile(path); err != nil || string(again) != string(data) {
+		t.Fatalf("first export = %q, %v, want it unchanged", again, err)
+	}
+}
+
+func TestCommandCompletion(t *testing.T) {
+	tests := []struct {
+		input, completed, hint string
+	}{
+		{"int", "interval ", "interval DURATION — Change the probe interval"},
+		{"s", "s", "settings sla screenshot"},
+		{"tr", "trend ", "trend — Toggle percentile trend"},
+		{"interval 5", "interval 5", "interval DURATION — Change the probe interval"},
+		{"zz", "zz", "unknown command"},
+	}
+	for _, tt := range tests {
+		if got := completeCommand(tt.input); got != tt.completed {
+			t.Errorf("completeCommand(%q) = %q, want %q", tt.input, got, tt.completed)
+		}
+		if got := commandHint(tt.input); got != tt.hint {
+			t.Errorf("commandHint(%q) = %q, want %q", tt.input, got, tt.hint)
+		}
+	}
+}
+
+func TestHelpListsBindingsPerView(t *testing.T) {
+	model := newTestModel()
+	help := strings.Join(model.helpLines(), "\n")
+	for _, want := range []string{"Toggle time in rows/columns", "Jump to time", "↑/k"} {
+		if !strings.Contains(help, want) {
+			t.Fatalf("blocks view help lacks %q:\n%s", want, help)
+		}
+	}
+	// Nothing to switch targets with, and no health to debug
+	for _, unwanted := range []string{"Switch target", "Ctrl+D"} {
+		if strings.Contains(help, unwanted) {
+			t.Fatalf("help lists unavailable %q", unwanted)
+		}
+	}
+
+	model.viewMode = viewAligned
+	help = strings.Join(model.helpLines(), "\n")
+	if strings.Contains(help, "rows/columns") || !strings.Contains(help, "Jump to minute") {
+		t.Fatalf("aligned view help should drop V and jump to minutes:\n%s", help)
+	}
+}
+
+func TestHelpPaging(t *testing.T) {
+	model := newTestModel()
+	model.width, model.height = 80, 20
+	press := func(key string) {
+		updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		model = updated.(Model)
+	}
+
+	press("?")
+	lines := len(model.helpLines())
+	page := model.helpPage(lines)
+	if page >= lines {
+		t.Fatalf("expected %d help lines not to fit a 20 line terminal", lines)
+	}
+	if out := model.renderHelp(); !strings.Contains(out, fmt.Sprintf("1-%d of %d", page, lines)) {
+		t.Fatalf("expected a page indicator, got:\n%s", out)
+	}
+
+	press("j")
+	if model.helpScroll != 1 || model.scrollPos != 0 {
+		t.Fatalf("helpScroll = %d, scrollPos = %d, want the help to scroll", model.helpScroll, model.scrollPos)
+	}
+	press("G")
+	if model.helpScroll != lines-page {
+		t.Fatalf("helpScroll = %d, want the last page at %d", model.helpScroll, lines-page)
+	}
+	if out := model.renderHelp(); !strings.Contains(out, "Errors:") {
+		t.Fatalf("expected the last page to end with the legend, got:\n%s", out)
+	}
+	press("?")
+	press("?")
+	if model.helpScroll != 0 {
+		t.Fatalf("expected the help to reopen at the top, got %d", model.helpScroll)
+	}
+}
+
+func TestKeymapKeysAreUnique(t *testing.T) {
+	bound := make(map[string]action)
+	for _, b := range keymap {
+		for _, k := range b.keys {
+			if act, ok := bound[k]; ok && act != b.action {
+				t.Fatalf("key %q is bound to two actions", k)
+			}
+			bound[k] = b.action
+		}
+	}
 }
@@ -0,0 +1,196 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pbv7/pingheat/internal/fleet"
+	"github.com/pbv7/pingheat/internal/ui/colors"
+)
+
+const (
+	// overviewRefresh is how often the overview polls the aggregator.
+	overviewRefresh = time.Second
+
+	// overviewStaleAfter marks a series stale when no batch arrived for this long.
+	overviewStaleAfter = 10 * time.Second
+
+	// overviewMaxName caps the width of the agent/target column.
+	overviewMaxName = 32
+
+	// overviewStatsWidth is the width of the loss/avg/p95/seen columns,
+	// including their separating spaces.
+	overviewStatsWidth = 37
+)
+
+// SeriesSource returns the current fleet snapshot with up to recent samples
+// per series.
+type SeriesSource func(recent int) []fleet.Series
+
+// OverviewModel is the Bubble Tea model for the aggregator overview: one row
+// per agent/target with a heatmap strip of its most recent samples.
+type OverviewModel struct {
+	source     SeriesSource
+	addr       string
+	thresholds colors.Thresholds
+
+	series []fleet.Series
+	width  int
+	height int
+	offset int // First visible series row
+	now    time.Time
+}
+
+// NewOverviewModel creates an overview of the series reported by source.
+func NewOverviewModel(addr string, thresholds colors.Thresholds, source SeriesSource) OverviewModel {
+	return OverviewModel{
+		source:     source,
+		addr:       addr,
+		thresholds: thresholds,
+		now:        time.Now(),
+	}
+}
+
+// Init starts the refresh loop.
+func (m OverviewModel) Init() tea.Cmd {
+	return func() tea.Msg { return TickMsg{Time: time.Now()} }
+}
+
+// Update handles messages and updates the model.
+func (m OverviewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		case "up", "k":
+			if m.offset > 0 {
+				m.offset--
+			}
+		case "down", "j":
+			if m.offset < len(m.series)-m.visibleRows() {
+				m.offset++
+			}
+		}
+		return m, nil
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case TickMsg:
+		m.now = msg.Time
+		// The strip width depends on the names being shown, so request enough
+		// for any layout and trim when rendering
+		m.series = m.source(max(m.width, 1))
+		m.offset = max(0, min(m.offset, len(m.series)-m.visibleRows()))
+		return m, tea.Tick(overviewRefresh, func(t time.Time) tea.Msg {
+			return TickMsg{Time: t}
+		})
+	}
+
+	return m, nil
+}
+
+// nameWidth returns the width of the agent/target column.
+func (m OverviewModel) nameWidth() int {
+	w := lipgloss.Width(seriesName(fleet.Series{Agent: "agent", Target: "target"}))
+	for _, s := range m.series {
+		w = max(w, lipgloss.Width(seriesName(s)))
+	}
+	return min(w, overviewMaxName)
+}
+
+// stripWidth returns how many samples fit in each row's heatmap strip.
+func (m OverviewModel) stripWidth() int {
+	return max(m.width-m.nameWidth()-overviewStatsWidth-2, 10)
+}
+
+// visibleRows returns how many series rows fit between header and status bar.
+func (m OverviewModel) visibleRows() int {
+	return max(m.height-3, 1)
+}
+
+// seriesName labels a series in the overview.
+func seriesName(s fleet.Series) string {
+	return s.Agent + glyph(" → ", " -> ") + s.Target
+}
+
+// View renders the overview.
+func (m OverviewModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(TitleStyle.Render("pingheat aggregator") + " " +
+		TargetStyle.Render(fmt.Sprintf("%s%s%d series", m.addr, glyph(" · ", " - "), len(m.series))) + "\n")
+
+	nameW := m.nameWidth()
+	b.WriteString(LabelStyle.Render(padRight(seriesName(fleet.Series{Agent: "agent", Target: "target"}), nameW)+"  "+padRight("recent", m.stripWidth())+
+		fmt.Sprintf(" %7s %9s %9s %8s", "loss", "avg", "p95", "seen")) + "\n")
+
+	if len(m.series) == 0 {
+		b.WriteString(LabelStyle.Render("Waiting for agents to push samples...") + "\n")
+	}
+
+	end := min(m.offset+m.visibleRows(), len(m.series))
+	for _, s := range m.series[m.offset:end] {
+		b.WriteString(m.renderRow(s, nameW) + "\n")
+	}
+
+	status := glyph("↑/↓", "j/k") + " scroll" + separator() + "q quit"
+	b.WriteString(StatusBarStyle.Render(status))
+	return b.String()
+}
+
+// renderRow renders one series: name, heatmap strip and key stats.
+func (m OverviewModel) renderRow(s fleet.Series, nameW int) string {
+	name := seriesName(s)
+	if lipgloss.Width(name) > nameW {
+		name = string([]rune(name)[:nameW-1]) + glyph("…", "~")
+	}
+
+	recent := s.Recent[max(0, len(s.Recent)-m.stripWidth()):]
+
+	var strip strings.Builder
+	for _, sample := range recent {
+		color := colors.ErrorColor(sample.Kind())
+		if !sample.Timeout {
+			color = m.thresholds.Classify(sample.RTT)
+		}
+		strip.WriteString(renderCellChar(color, colors.CellChar(color)))
+	}
+	if pad := m.stripWidth() - len(recent); pad > 0 {
+		strip.WriteString(strings.Repeat(" ", pad))
+	}
+
+	lossStyle := GoodValueStyle
+	if s.Stats.LossPercent > 5 {
+		lossStyle = BadValueStyle
+	} else if s.Stats.LossPercent > 1 {
+		lossStyle = WarnValueStyle
+	}
+
+	age := m.now.Sub(s.LastSeen)
+	seenStyle := ValueStyle
+	if age > overviewStaleAfter {
+		seenStyle = BadValueStyle
+	}
+
+	return fmt.Sprintf("%s  %s %s %s %s %s",
+		padRight(name, nameW), strip.String(),
+		lossStyle.Render(fmt.Sprintf("%6.1f%%", s.Stats.LossPercent)),
+		ValueStyle.Render(fmt.Sprintf("%7.1fms", s.Stats.AvgRTTMs)),
+		ValueStyle.Render(fmt.Sprintf("%7.1fms", s.Stats.Percentiles.P95)),
+		seenStyle.Render(fmt.Sprintf("%8s", formatElapsed(age))))
+}
+
+// padRight pads s with spaces to the given display width.
+func padRight(s string, width int) string {
+	if pad := width - lipgloss.Width(s); pad > 0 {
+		return s + strings.Repeat(" ", pad)
+	}
+	return s
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pbv7/pingheat/internal/fleet"
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/types"
+	"github.com/pbv7/pingheat/internal/ui/colors"
+)
+
+func TestOverviewRendersSeries(t *testing.T) {
+	now := time.Now()
+	var requested int
+	source := func(recent int) []fleet.Series {
+		requested = recent
+		return []fleet.Series{
+			{
+				Agent:    "probe-1",
+				Target:   "1.1.1.1",
+				Stats:    metrics.Stats{LossPercent: 2.5, AvgRTTMs: 12.3},
+				Recent:   []types.Sample{{RTT: 5 * time.Millisecond}, {Timeout: true}},
+				LastSeen: now,
+			},
+			{Agent: "probe-2", Target: "8.8.8.8", LastSeen: now.Add(-time.Minute)},
+		}
+	}
+
+	var model tea.Model = NewOverviewModel(":9100", colors.DefaultThresholds(), source)
+	model, _ = model.Update(tea.WindowSizeMsg{Width: 120, Height: 20})
+	model, _ = model.Update(TickMsg{Time: now})
+
+	m := model.(OverviewModel)
+	if requested < m.stripWidth() {
+		t.Fatalf("requested %d recent samples, want at least strip width %d", requested, m.stripWidth())
+	}
+
+	view := m.View()
+	for _, want := range []string{":9100 · 2 series", "probe-1 → 1.1.1.1", "probe-2 → 8.8.8.8", "2.5%", "12.3ms", "0:01:00"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("view missing %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestOverviewEmpty(t *testing.T) {
+	m := NewOverviewModel(":9100", colors.DefaultThresholds(), func(int) []fleet.Series { return nil })
+	if !strings.Contains(m.View(), "Waiting for agents") {
+		t.Fatalf("expected waiting message:\n%s", m.View())
+	}
+}
+
+func TestOverviewRowsFitWidth(t *testing.T) {
+	long := make([]types.Sample, 500)
+	source := func(int) []fleet.Series {
+		return []fleet.Series{{Agent: "probe-1", Target: "1.1.1.1", Recent: long, LastSeen: time.Now()}}
+	}
+
+	var model tea.Model = NewOverviewModel(":9100", colors.DefaultThresholds(), source)
+	model, _ = model.Update(tea.WindowSizeMsg{Width: 100, Height: 20})
+	model, _ = model.Update(TickMsg{Time: time.Now()})
+
+	for i, line := range strings.Split(model.View(), "\n") {
+		if w := lipgloss.Width(line); w > 100 {
+			t.Fatalf("line %d is %d columns wide, want <= 100", i, w)
+		}
+	}
+}
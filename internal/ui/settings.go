@@ -0,0 +1,255 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pbv7/pingheat/internal/alert"
+	"github.com/pbv7/pingheat/internal/config"
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/ui/colors"
+)
+
+// Settings are the values edited in the settings overlay, handed to the
+// app when applied.
+type Settings struct {
+	Thresholds colors.Thresholds
+	Brownout   time.Duration // 0 = metrics.BrownoutThresholdMs
+	SLO        *alert.SLO    // nil without -slo
+}
+
+// settingField is a row of the settings overlay.
+type settingField int
+
+const (
+	fieldExcellent settingField = iota
+	fieldGood
+	fieldFair
+	fieldPoor
+	fieldBrownout
+	fieldSLO // Only with -slo
+)
+
+// settingLabels are the labels of the settings overlay rows.
+var settingLabels = [...]string{"Excellent", "Good", "Fair", "Poor", "Brownout", "SLO"}
+
+// settingFields returns the rows of the settings overlay.
+func (m Model) settingFields() int {
+	if m.slo != nil {
+		return len(settingLabels)
+	}
+	return int(fieldSLO)
+}
+
+// Settings returns the settings currently in effect.
+func (m Model) Settings() Settings {
+	s := Settings{Thresholds: m.config.Thresholds, Brownout: m.config.Brownout}
+	if m.slo != nil {
+		slo := m.slo.SLO()
+		s.SLO = &slo
+	}
+	return s
+}
+
+// brownoutMs returns the brownout threshold in milliseconds.
+func (m Model) brownoutMs() float64 {
+	if m.config.Brownout > 0 {
+		return float64(m.config.Brownout) / float64(time.Millisecond)
+	}
+	return metrics.BrownoutThresholdMs
+}
+
+// settingValue returns the value of field as it is edited.
+func (m Model) settingValue(field settingField) string {
+	th := m.config.Thresholds
+	switch field {
+	case fieldExcellent:
+		return strconv.FormatFloat(th.Excellent, 'f', -1, 64)
+	case fieldGood:
+		return strconv.FormatFloat(th.Good, 'f', -1, 64)
+	case fieldFair:
+		return strconv.FormatFloat(th.Fair, 'f', -1, 64)
+	case fieldPoor:
+		return strconv.FormatFloat(th.Poor, 'f', -1, 64)
+	case fieldBrownout:
+		return strconv.FormatFloat(m.brownoutMs(), 'f', -1, 64)
+	case fieldSLO:
+		if m.slo != nil {
+			return m.slo.SLO().String()
+		}
+	}
+	return ""
+}
+
+// parseMs parses a latency in milliseconds, with or without the unit.
+func parseMs(s string) (float64, error) {
+	ms, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "ms"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a latency in ms", s)
+	}
+	return ms, nil
+}
+
+// applySetting validates input as the new value of field and applies it
+// live: to the heatmap colors, the SLO status and, through the applier, to
+// the app's brownout detection and alerting.
+func (m *Model) applySetting(field settingField, input string) error {
+	switch field {
+	case fieldSLO:
+		slo, err := alert.ParseSLO(strings.TrimSpace(input))
+		if err != nil {
+			return err
+		}
+		m.slo.SetSLO(slo)
+		m.config.SLO = &slo
+
+	case fieldBrownout:
+		ms, err := parseMs(input)
+		if err != nil {
+			return err
+		}
+		if ms <= 0 {
+			return errors.New("brownout must be positive")
+		}
+		m.config.Brownout = time.Duration(ms * float64(time.Millisecond))
+
+	default:
+		ms, err := parseMs(input)
+		if err != nil {
+			return err
+		}
+		th := m.config.Thresholds
+		bounds := [...]*float64{&th.Excellent, &th.Good, &th.Fair, &th.Poor}
+		*bounds[field] = ms
+		if err := th.Validate(); err != nil {
+			return err
+		}
+		m.config.Thresholds = th
+	}
+
+	if m.applySettings != nil {
+		m.applySettings(m.Settings())
+	}
+	return nil
+}
+
+// handleSettingsKey moves through, edits and saves the settings overlay.
+func (m Model) handleSettingsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.editing {
+		switch msg.Type {
+		case tea.KeyCtrlC:
+			m.quitting = true
+			return m, tea.Quit
+
+		case tea.KeyEsc:
+			m.editing = false
+			m.input = ""
+
+		case tea.KeyEnter:
+			// A rejected value stays in the editor to be fixed
+			field := settingField(m.settingsRow)
+			if err := m.applySetting(field, m.input); err != nil {
+				m.statusMsg = err.Error()
+				m.statusErr = true
+				return m, nil
+			}
+			m.editing = false
+			m.input = ""
+			m.statusMsg = settingLabels[field] + " set to " + m.settingValue(field)
+			m.statusErr = false
+
+		case tea.KeyBackspace:
+			if r := []rune(m.input); len(r) > 0 {
+				m.input = string(r[:len(r)-1])
+			}
+
+		case tea.KeyRunes:
+			m.input += string(msg.Runes)
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		m.quitting = true
+		return m, tea.Quit
+
+	case "esc", "s":
+		m.showSettings = false
+
+	case "up", "k":
+		m.settingsRow = max(0, m.settingsRow-1)
+
+	case "down", "j":
+		m.settingsRow = min(m.settingFields()-1, m.settingsRow+1)
+
+	case "enter":
+		m.editing = true
+		m.input = m.settingValue(settingField(m.settingsRow))
+
+	case "w":
+		if m.config.Profile == "" {
+			m.statusMsg = "Settings can only be saved to a profile (-profile)"
+			m.statusErr = true
+			return m, nil
+		}
+		return m, m.saveSettingsCmd()
+	}
+	return m, nil
+}
+
+// saveSettingsCmd writes the settings to the profile in the configuration
+// file in the background.
+func (m Model) saveSettingsCmd() tea.Cmd {
+	path, profile, s := m.config.ConfigPath, m.config.Profile, m.Settings()
+	slo := ""
+	if s.SLO != nil {
+		slo = s.SLO.String()
+	}
+	return func() tea.Msg {
+		if err := config.SaveProfileSettings(path, profile, s.Thresholds, s.Brownout, slo); err != nil {
+			return ErrorMsg{Err: err}
+		}
+		return StatusMsg{Message: fmt.Sprintf("Settings saved to profile %s in %s", profile, path)}
+	}
+}
+
+// renderSettings renders the settings overlay: one row per setting, the
+// selected one highlighted and, while edited, showing the input.
+func (m Model) renderSettings() string {
+	var b strings.Builder
+	b.WriteString(TitleStyle.Render("Settings"))
+	b.WriteString("\n\n")
+
+	units := [...]string{"ms", "ms", "ms", "ms", "ms", ""}
+	for i := range m.settingFields() {
+		field := settingField(i)
+		value := m.settingValue(field) + units[field]
+		style := ValueStyle
+		marker := "  "
+		if i == m.settingsRow {
+			style = HelpKeyStyle
+			marker = glyph("▸ ", "> ")
+			if m.editing {
+				value = m.input + glyph("█", "_")
+			}
+		}
+		b.WriteString(marker)
+		b.WriteString(LabelStyle.Render(fmt.Sprintf("%-10s", settingLabels[field])))
+		b.WriteString(style.Render(value))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	help := "enter edit  w save to profile  esc close"
+	if m.editing {
+		help = "enter apply  esc cancel"
+	}
+	b.WriteString(HelpDescStyle.Render(help))
+
+	return HelpOverlayStyle.Render(b.String())
+}
@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pbv7/pingheat/internal/metrics"
+)
+
+// SLA table limits: the most recent hours and days shown.
+const (
+	slaMaxHours = 12
+	slaMaxDays  = 7
+)
+
+// renderSLA renders the table of availability and p95 per hour and per
+// calendar day.
+func (m Model) renderSLA() string {
+	hours := m.sla.Hours()
+	days := m.sla.Days()
+	days = days[max(0, len(days)-slaMaxDays):]
+
+	// Title, blank line and two section headers plus their spacing take 6
+	// lines; the overlay border and padding take 4 more
+	fit := m.height - len(days) - 10
+	hours = hours[max(0, len(hours)-min(slaMaxHours, max(1, fit))):]
+
+	var b strings.Builder
+	b.WriteString(TitleStyle.Render("SLA"))
+	b.WriteString("\n\n")
+
+	b.WriteString(LabelStyle.Render(fmt.Sprintf("%-16s %9s %9s %8s", "Hour", "Avail", "p95", "Samples")))
+	b.WriteString("\n")
+	for _, p := range hours {
+		b.WriteString(m.renderSLARow(m.formatPeriod(p, "2006-01-02 15:04"), p))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(LabelStyle.Render(fmt.Sprintf("%-16s %9s %9s %8s", "Day", "Avail", "p95", "Samples")))
+	for _, p := range days {
+		b.WriteString("\n")
+		b.WriteString(m.renderSLARow(m.formatPeriod(p, "2006-01-02"), p))
+	}
+
+	return HelpOverlayStyle.Render(b.String())
+}
+
+// renderSLARow renders one period, coloring availability against common
+// commitments: 99.9% and above is good, below 99% is bad.
+func (m Model) renderSLARow(label string, p metrics.SLAPeriod) string {
+	availStyle := GoodValueStyle
+	if p.AvailPercent < 99.9 {
+		availStyle = WarnValueStyle
+	}
+	if p.AvailPercent < 99 {
+		availStyle = BadValueStyle
+	}
+
+	p95 := LabelStyle.Render(fmt.Sprintf("%9s", "-"))
+	if p.Samples > p.Timeouts {
+		color := m.config.Thresholds.ClassifyMs(p.P95Ms)
+		p95 = lipgloss.NewStyle().Foreground(color).Render(fmt.Sprintf("%7.1fms", p.P95Ms))
+	}
+
+	return fmt.Sprintf("%s %s %s %s",
+		ValueStyle.Render(fmt.Sprintf("%-16s", label)),
+		availStyle.Render(fmt.Sprintf("%8.3f%%", p.AvailPercent)),
+		p95,
+		ValueStyle.Render(fmt.Sprintf("%8d", p.Samples)))
+}
+
+// formatPeriod formats the start of a period in the display time zone.
+func (m Model) formatPeriod(p metrics.SLAPeriod, layout string) string {
+	return p.Start.In(m.config.Location()).Format(layout)
+}
@@ -1,6 +1,10 @@
 package ui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+)
 
 // Style definitions for the UI
 var (
@@ -42,6 +46,17 @@ var (
 				Background(lipgloss.Color("#1A1A1A")).
 				Padding(0, 1)
 
+	StatusWarnStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFFF00")).
+			Background(lipgloss.Color("#1A1A1A")).
+			Padding(0, 1)
+
+	// AlertBannerStyle marks the banner across the top during an incident
+	AlertBannerStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("#FFFFFF")).
+				Background(lipgloss.Color("#AF0000"))
+
 	// Help styles
 	HelpKeyStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#5F5FD7")).
@@ -62,3 +77,36 @@ var (
 				Padding(1, 2).
 				Background(lipgloss.Color("#1A1A1A"))
 )
+
+// cellKey identifies a rendered heatmap cell.
+type cellKey struct {
+	color lipgloss.Color
+	bg    lipgloss.Color
+	char  string
+}
+
+// cellCache holds rendered heatmap cells. Cells come from a small palette
+// and character set, so each combination is rendered once rather than
+// building a Style and its escape sequences for every cell of every frame.
+var cellCache sync.Map // cellKey -> string
+
+// renderCellChar returns char in the foreground color.
+func renderCellChar(color lipgloss.Color, char string) string {
+	return renderCellOn(color, "", char)
+}
+
+// renderCellOn returns char in the foreground color on the background bg,
+// or on the terminal's background if bg is empty.
+func renderCellOn(color, bg lipgloss.Color, char string) string {
+	key := cellKey{color, bg, char}
+	if s, ok := cellCache.Load(key); ok {
+		return s.(string)
+	}
+	style := lipgloss.NewStyle().Foreground(color)
+	if bg != "" {
+		style = style.Background(bg)
+	}
+	s := style.Render(char)
+	cellCache.Store(key, s)
+	return s
+}
@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/pbv7/pingheat/internal/ui/colors"
+)
+
+// SetupTerminal adapts the UI to the terminal it runs in. Without Unicode
+// support, or with forceASCII (-ascii), cells, borders and symbols are drawn
+// in plain ASCII. With noColor (-no-color) or $NO_COLOR set, colors are off
+// and heatmap cells show a glyph per latency band. On 16-color terminals the
+// RTT bands use the standard ANSI colors; 256-color terminals get the
+// nearest matches of the RGB colors, which lipgloss picks by itself. It must
+// be called before the UI starts.
+func SetupTerminal(forceASCII, noColor bool) {
+	if forceASCII || !unicodeTerminal(runtime.GOOS, os.Getenv) {
+		colors.SetASCII(true)
+		HeatmapBorderStyle = HeatmapBorderStyle.Border(lipgloss.ASCIIBorder())
+		HelpOverlayStyle = HelpOverlayStyle.Border(lipgloss.ASCIIBorder())
+	}
+	// See https://no-color.org: set and not empty turns colors off
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		colors.SetNoColor(true)
+		lipgloss.SetColorProfile(termenv.Ascii)
+		return
+	}
+	if lipgloss.ColorProfile() == termenv.ANSI {
+		colors.UseANSIPalette()
+	}
+}
+
+// unicodeTerminal guesses from the environment whether the terminal can show
+// the block, braille and box drawing characters. Consoles such as the Linux
+// virtual console and dumb terminals cannot, nor can terminals running in a
+// locale with another character set. An unset or plain C locale says nothing
+// about the terminal, so it does not count against it. On Windows the legacy
+// console lacks the glyphs, while Windows Terminal and terminals setting
+// TERM, such as mintty, have them.
+func unicodeTerminal(goos string, getenv func(string) string) bool {
+	term := getenv("TERM")
+	switch {
+	case term == "dumb", term == "linux", strings.HasPrefix(term, "vt"):
+		return false
+	case goos == "windows":
+		return term != "" || getenv("WT_SESSION") != "" || getenv("TERM_PROGRAM") != ""
+	}
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		locale := getenv(name)
+		if locale == "" {
+			continue
+		}
+		_, charset, ok := strings.Cut(locale, ".")
+		if !ok {
+			return true
+		}
+		charset, _, _ = strings.Cut(charset, "@")
+		charset = strings.ToLower(strings.ReplaceAll(charset, "-", ""))
+		return charset == "utf8"
+	}
+	return true
+}
+
+// glyph returns symbol, or fallback when the UI is limited to ASCII.
+func glyph(symbol, fallback string) string {
+	if colors.ASCII() {
+		return fallback
+	}
+	return symbol
+}
+
+// separator returns the separator between status bar fields.
+func separator() string {
+	return glyph(" │ ", " | ")
+}
@@ -0,0 +1,175 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/ui/colors"
+)
+
+// trendSeries are the percentiles plotted by the trend chart, drawn in this
+// order so the median stays visible where the lines meet. Without colors
+// each is drawn with its own mark.
+var trendSeries = []struct {
+	label string
+	color lipgloss.Color
+	mark  string
+	value func(metrics.Bucket) float64
+}{
+	{"p99", lipgloss.Color("#FF5F87"), "^", func(b metrics.Bucket) float64 { return b.P99Ms }},
+	{"p95", lipgloss.Color("#FFAF00"), "+", func(b metrics.Bucket) float64 { return b.P95Ms }},
+	{"p50", lipgloss.Color("#00AFFF"), "o", func(b metrics.Bucket) float64 { return b.P50Ms }},
+}
+
+// trendDot returns the point drawn for a series with the given mark.
+func trendDot(mark string) string {
+	if colors.NoColor() {
+		return mark
+	}
+	return glyph("•", "*")
+}
+
+// trendLabelWidth is the width of the chart's y-axis labels.
+const trendLabelWidth = 8
+
+// renderTrend renders p50, p95 and p99 per minute over the last 24 hours as
+// a line chart, with several minutes merged per column when they do not fit,
+// to show whether latency drifts during a session.
+func (m Model) renderTrend() string {
+	// The overlay border and padding take 8 columns and 4 lines; title,
+	// axis, time labels and legend take 7 lines
+	cols := max(10, m.width-trendLabelWidth-10)
+	rows := max(4, m.height-11)
+
+	minutes := len(m.minutes.Buckets(time.Minute))
+	width := time.Duration(max(1, (minutes+cols-1)/cols)) * time.Minute
+	buckets := m.minutes.Buckets(width)
+
+	var b strings.Builder
+	b.WriteString(TitleStyle.Render("Latency Trend"))
+	b.WriteString("\n\n")
+
+	top := 0.0
+	for _, bk := range buckets {
+		top = math.Max(top, bk.P99Ms)
+	}
+	if top == 0 {
+		b.WriteString(LabelStyle.Render("No replies yet"))
+		return HelpOverlayStyle.Render(b.String())
+	}
+	top = niceCeil(top)
+
+	grid := make([][]string, rows)
+	for r := range grid {
+		grid[r] = make([]string, len(buckets))
+		for c := range grid[r] {
+			grid[r][c] = " "
+		}
+	}
+	lost := lipgloss.NewStyle().Foreground(colors.ColorTimeout).Render(glyph("×", "x"))
+	for c, bk := range buckets {
+		if bk.Samples > 0 && bk.Timeouts == bk.Samples {
+			grid[rows-1][c] = lost
+			continue
+		}
+		if bk.Samples == 0 {
+			continue
+		}
+		for _, s := range trendSeries {
+			r := rows - 1 - int(math.Round(s.value(bk)/top*float64(rows-1)))
+			grid[r][c] = renderCellChar(s.color, trendDot(s.mark))
+		}
+	}
+
+	for r, line := range grid {
+		label := ""
+		if r == 0 || r == rows/2 || r == rows-1 {
+			label = axisLabel(top * float64(rows-1-r) / float64(rows-1))
+		}
+		b.WriteString(LabelStyle.Render(fmt.Sprintf("%*s %s", trendLabelWidth-2, label, glyph("│", "|"))))
+		b.WriteString(strings.Join(line, ""))
+		b.WriteString("\n")
+	}
+	b.WriteString(LabelStyle.Render(strings.Repeat(" ", trendLabelWidth-1) + glyph("└", "+") + strings.Repeat(glyph("─", "-"), len(buckets))))
+	b.WriteString("\n")
+
+	start := m.config.Format.Time(buckets[0].Start.In(m.config.Location()), "15:04")
+	end := m.config.Format.Time(buckets[len(buckets)-1].Start.In(m.config.Location()), "15:04")
+	gap := max(1, len(buckets)-len(start)-len(end))
+	b.WriteString(LabelStyle.Render(strings.Repeat(" ", trendLabelWidth) + start + strings.Repeat(" ", gap) + end))
+	b.WriteString("\n")
+
+	for i := len(trendSeries) - 1; i >= 0; i-- {
+		s := trendSeries[i]
+		b.WriteString(lipgloss.NewStyle().Foreground(s.color).Render(trendDot(s.mark)))
+		b.WriteString(" " + s.label + "  ")
+	}
+	b.WriteString(LabelStyle.Render(fmt.Sprintf("%s per column", formatWidth(width))))
+	if drift, mean, ok := p95Drift(buckets); ok {
+		// Warn when p95 rose by a tenth over the chart, not at every wobble
+		span := buckets[len(buckets)-1].Start.Sub(buckets[0].Start).Hours()
+		style := ValueStyle
+		if drift*span > mean/10 {
+			style = WarnValueStyle
+		}
+		b.WriteString(LabelStyle.Render("  p95 drift "))
+		b.WriteString(style.Render(fmt.Sprintf("%+.1fms/h", drift)))
+	}
+
+	return HelpOverlayStyle.Render(b.String())
+}
+
+// niceCeil rounds a chart maximum up to 1, 2 or 5 times a power of ten.
+func niceCeil(v float64) float64 {
+	exp := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, f := range []float64{1, 2, 5, 10} {
+		if v <= f*exp {
+			return f * exp
+		}
+	}
+	return 10 * exp
+}
+
+// axisLabel formats a y-axis value to fit its column.
+func axisLabel(ms float64) string {
+	if ms >= 1000 {
+		return fmt.Sprintf("%.3gs", ms/1000)
+	}
+	return fmt.Sprintf("%.3gms", ms)
+}
+
+// formatWidth formats a column width such as "1m", "1h30m" or "2h".
+func formatWidth(d time.Duration) string {
+	s := strings.TrimSuffix(d.String(), "0s")
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// p95Drift returns the slope of a least-squares fit of p95 over time, in
+// milliseconds per hour, and the mean p95. It reports false with fewer than
+// three points.
+func p95Drift(buckets []metrics.Bucket) (slope, mean float64, ok bool) {
+	var n, sumX, sumY, sumXY, sumXX float64
+	for _, b := range buckets {
+		if b.Samples == b.Timeouts {
+			continue
+		}
+		x := b.Start.Sub(buckets[0].Start).Hours()
+		n++
+		sumX += x
+		sumY += b.P95Ms
+		sumXY += x * b.P95Ms
+		sumXX += x * x
+	}
+	den := n*sumXX - sumX*sumX
+	if n < 3 || den == 0 {
+		return 0, 0, false
+	}
+	return (n*sumXY - sumX*sumY) / den, sumY / n, true
+}
@@ -1,16 +1,24 @@
 package ui
 
 import (
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pbv7/pingheat/internal/screenshot"
 )
 
 // Update handles messages and updates the model.
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		return m.handleKeypress(msg)
+		updated, cmd := m.handleKeypress(msg)
+		if um, ok := updated.(Model); ok && um.scrollPos == 0 {
+			// Back at live: nothing is pending anymore
+			um.newSamples = 0
+			return um, cmd
+		}
+		return updated, cmd
 
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
@@ -18,13 +26,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case SampleMsg:
-		m.samples.Push(msg.Sample)
+		m.addSample(msg.Sample)
 		m.lastUpdate = time.Now()
-		return m, m.listenForSamples()
+		return m, m.bellCmd()
 
 	case MetricsMsg:
 		m.stats = msg.Stats
-		return m, m.listenForMetrics()
+		return m, nil
 
 	case StatusMsg:
 		m.statusMsg = msg.Message
@@ -32,7 +40,43 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case TickMsg:
-		return m, m.tick()
+		if !msg.Time.IsZero() {
+			m.now = msg.Time
+		}
+		m.drain()
+		return m, tea.Batch(m.tick(), m.bellCmd())
+
+	case TargetSwitchedMsg:
+		if msg.Err != nil {
+			m.statusMsg = msg.Err.Error()
+			m.statusErr = true
+			return m, nil
+		}
+		m.config.Target = msg.Target
+		m.downCount = 0
+		m.sla.Reset()
+		m.hours.Reset()
+		if m.slo != nil {
+			m.slo.Reset()
+		}
+		if msg.ClearHistory {
+			m.clearHistory()
+			m.newSamples = 0
+		}
+		m.statusMsg = "Target: " + msg.Target
+		m.statusErr = false
+		return m, nil
+
+	case IntervalChangedMsg:
+		if msg.Err != nil {
+			m.statusMsg = msg.Err.Error()
+			m.statusErr = true
+			return m, nil
+		}
+		m.config.Interval = msg.Interval
+		m.statusMsg = "Interval: " + msg.Interval.String()
+		m.statusErr = false
+		return m, nil
 
 	case ErrorMsg:
 		m.statusMsg = msg.Err.Error()
@@ -45,69 +89,240 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // handleKeypress processes keyboard input.
 func (m Model) handleKeypress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "q", "ctrl+c":
+	if m.prompt != promptNone {
+		return m.handlePromptKey(msg)
+	}
+	if m.showSettings {
+		return m.handleSettingsKey(msg)
+	}
+
+	act := actionFor(msg.String())
+	if m.showHelp && m.scrollHelp(act) {
+		return m, nil
+	}
+	return m.runAction(act)
+}
+
+// runAction does what a key bound to act does.
+func (m Model) runAction(act action) (tea.Model, tea.Cmd) {
+	switch act {
+	case actQuit:
 		m.quitting = true
 		return m, tea.Quit
 
-	case "?", "h":
+	case actHelp:
 		m.showHelp = !m.showHelp
+		m.helpScroll = 0
+		return m, nil
+
+	case actSLA:
+		m.showSLA = !m.showSLA
+		return m, nil
+
+	case actTrend:
+		m.showTrend = !m.showTrend
+		return m, nil
+
+	case actHours:
+		m.showHours = !m.showHours
+		return m, nil
+
+	case actLogs:
+		m.showLogs = !m.showLogs
+		return m, nil
+
+	case actLegend:
+		m.showLegend = !m.showLegend
+		return m, nil
+
+	case actDelta:
+		if m.calibrator != nil {
+			m.delta = !m.delta
+		}
+		return m, nil
+
+	case actOrientation:
+		m.columns = !m.columns
+		return m, nil
+
+	case actScreenshot:
+		return m, m.screenshotCmd()
+
+	case actCopy:
+		copyToClipboard(m.statsSummary())
+		m.statusMsg = "Stats summary sent to the clipboard"
+		m.statusErr = false
+		return m, nil
+
+	case actSettings:
+		m.showSettings = true
+		m.settingsRow = 0
+		return m, nil
+
+	case actDebug:
+		// Debug panel, for diagnosing pingheat itself
+		m.showDebug = !m.showDebug && m.health != nil
 		return m, nil
 
-	case "c":
+	case actClear:
 		// Clear samples and reset scroll
-		m.samples.Clear()
-		m.scrollPos = 0
+		m.clearHistory()
 		m.statusMsg = "Cleared"
 		m.statusErr = false
 		return m, nil
 
-	case "up", "k":
+	case actScrollUp:
 		if m.CanScrollUp() {
 			m.scrollPos++
 		}
 		return m, nil
 
-	case "down", "j":
+	case actScrollDown:
 		if m.CanScrollDown() {
 			m.scrollPos--
 		}
 		return m, nil
 
-	case "pgup":
+	case actJump:
+		m.prompt = promptJump
+		m.input = ""
+		return m, nil
+
+	case actCommand:
+		m.prompt = promptCommand
+		m.input = ""
+		return m, nil
+
+	case actTarget, actTargetClear:
+		if m.switchTarget == nil {
+			m.statusMsg = "Target switching is not available"
+			m.statusErr = true
+			return m, nil
+		}
+		m.prompt = promptTarget
+		if act == actTargetClear {
+			m.prompt = promptTargetClear
+		}
+		m.input = ""
+		return m, nil
+
+	case actView:
+		// Cycle heatmap view modes and keep the scroll position in range
+		m.viewMode = (m.viewMode + 1) % viewMode(len(viewModeNames))
+		m.scrollPos = 0
+		m.statusMsg = "View: " + m.viewMode.String()
+		m.statusErr = false
+		return m, nil
+
+	case actPageUp:
 		_, rows := m.GridDimensions()
 		for i := 0; i < rows && m.CanScrollUp(); i++ {
 			m.scrollPos++
 		}
 		return m, nil
 
-	case "pgdown":
+	case actPageDown:
 		_, rows := m.GridDimensions()
 		for i := 0; i < rows && m.CanScrollDown(); i++ {
 			m.scrollPos--
 		}
 		return m, nil
 
-	case "home", "g":
+	case actOldest:
 		// Scroll to oldest
-		cols, rows := m.GridDimensions()
-		visibleCount := cols * rows
-		maxScroll := m.samples.Len() - visibleCount
+		visibleCount := m.visibleCapacity()
+		maxScroll := m.itemCount() - visibleCount
 		if maxScroll > 0 {
 			m.scrollPos = maxScroll
 		}
 		return m, nil
 
-	case "end", "G":
+	case actNewest:
 		// Scroll to newest
 		m.scrollPos = 0
 		return m, nil
 
-	case "esc":
-		if m.showHelp {
-			m.showHelp = false
+	case actClose:
+		m.showHelp = false
+		m.showSLA = false
+		m.showHours = false
+		m.showTrend = false
+		m.showLogs = false
+		m.showDebug = false
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// switchTargetCmd asks the app to probe a new target in the background.
+func (m Model) switchTargetCmd(target string, clearHistory bool) tea.Cmd {
+	if target == "" || target == m.config.Target {
+		return nil
+	}
+	switchTarget := m.switchTarget
+	return func() tea.Msg {
+		return TargetSwitchedMsg{Target: target, ClearHistory: clearHistory, Err: switchTarget(target)}
+	}
+}
+
+// screenshotCmd saves the current frame in the background.
+func (m Model) screenshotCmd() tea.Cmd {
+	frame, dir, format, now := m.View(), m.config.ScreenshotDir, m.config.ScreenshotFormat, m.now
+	return func() tea.Msg {
+		paths, err := screenshot.Save(dir, frame, format, now)
+		if err != nil {
+			return ErrorMsg{Err: err}
+		}
+		return StatusMsg{Message: "Screenshot saved: " + strings.Join(paths, ", ")}
+	}
+}
+
+// handlePromptKey edits and submits the status bar input prompt.
+func (m Model) handlePromptKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		m.quitting = true
+		return m, tea.Quit
+
+	case tea.KeyEsc:
+		m.prompt = promptNone
+		m.input = ""
+		return m, nil
+
+	case tea.KeyEnter:
+		kind, input := m.prompt, m.input
+		m.prompt = promptNone
+		m.input = ""
+		switch kind {
+		case promptJump:
+			m.jumpTo(input)
+		case promptTarget, promptTargetClear:
+			return m, m.switchTargetCmd(strings.TrimSpace(input), kind == promptTargetClear)
+		case promptCommand:
+			return m.runCommand(input)
 		}
 		return m, nil
+
+	case tea.KeyTab:
+		if m.prompt == promptCommand {
+			m.input = completeCommand(m.input)
+		}
+		return m, nil
+
+	case tea.KeyBackspace:
+		if r := []rune(m.input); len(r) > 0 {
+			m.input = string(r[:len(r)-1])
+		}
+		return m, nil
+
+	case tea.KeySpace:
+		m.input += " "
+		return m, nil
+
+	case tea.KeyRunes:
+		m.input += string(msg.Runes)
+		return m, nil
 	}
 
 	return m, nil
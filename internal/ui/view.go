@@ -2,11 +2,17 @@ package ui
 
 import (
 	"fmt"
+	"math"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/ping"
+	"github.com/pbv7/pingheat/internal/types"
 	"github.com/pbv7/pingheat/internal/ui/colors"
+	"github.com/pbv7/pingheat/internal/wifi"
 )
 
 // View renders the UI.
@@ -21,6 +27,12 @@ func (m Model) View() string {
 
 	var b strings.Builder
 
+	// Incident banner, only while one lasts
+	if banner := m.bannerText(); banner != "" {
+		b.WriteString(AlertBannerStyle.Width(m.width).MaxWidth(m.width).Render(banner))
+		b.WriteString("\n")
+	}
+
 	// Header
 	b.WriteString(m.renderHeader())
 	b.WriteString("\n")
@@ -31,6 +43,9 @@ func (m Model) View() string {
 
 	// Heatmap
 	b.WriteString(m.renderHeatmap())
+	if m.showLegend {
+		b.WriteString(m.renderLegend())
+	}
 
 	// Status bar
 	b.WriteString(m.renderStatusBar())
@@ -39,6 +54,24 @@ func (m Model) View() string {
 	if m.showHelp {
 		return m.renderHelpOverlay(b.String())
 	}
+	if m.showSLA {
+		return m.renderCentered(m.renderSLA(), b.String())
+	}
+	if m.showTrend {
+		return m.renderCentered(m.renderTrend(), b.String())
+	}
+	if m.showHours {
+		return m.renderCentered(m.renderHours(), b.String())
+	}
+	if m.showLogs {
+		return m.renderCentered(m.renderLogs(), b.String())
+	}
+	if m.showDebug {
+		return m.renderCentered(m.renderDebug(), b.String())
+	}
+	if m.showSettings {
+		return m.renderCentered(m.renderSettings(), b.String())
+	}
 
 	return b.String()
 }
@@ -47,9 +80,71 @@ func (m Model) View() string {
 func (m Model) renderHeader() string {
 	title := TitleStyle.Render("pingheat")
 	target := TargetStyle.Render(m.config.Target)
+	if m.config.Mode == ping.ModeUDP {
+		target += LabelStyle.Render(fmt.Sprintf(" udp/%d", m.config.UDPPort))
+	}
+	if m.targetInfo != nil {
+		if summary := m.targetInfo().Summary(); summary != "" {
+			target += LabelStyle.Render(" (" + summary + ")")
+		}
+	}
+	if m.diagnosis != nil {
+		target += "  " + m.renderDiagnosis(m.diagnosis())
+	}
+	if m.delta && m.calibrator != nil {
+		target += "  " + m.renderDelta()
+	}
 	return fmt.Sprintf("%s %s", title, target)
 }
 
+// renderDelta renders how far the median latency is from the baseline of
+// the delta view, or the calibration progress while it is being learned
+// without a stored one.
+func (m Model) renderDelta() string {
+	label := LabelStyle.Render("Delta: ")
+	base := m.calibrator.Baseline()
+	have, want := m.calibrator.Progress()
+	if base.IsZero() {
+		return label + LabelStyle.Render(fmt.Sprintf("calibrating %d/%d", have, want))
+	}
+	f := m.config.Format
+	name := " vs baseline "
+	if have < want {
+		name = " vs stored baseline " // From an earlier run, until this one's is learned
+	}
+	vs := LabelStyle.Render(name + f.Float(base.P50Ms, 1) + "ms")
+	if m.stats.TotalSuccess == 0 {
+		return label + LabelStyle.Render("p50 -") + vs
+	}
+	p50 := m.stats.Percentiles.P50
+	sign := "+"
+	if p50 < base.P50Ms {
+		sign = "-"
+	}
+	color := colors.ClassifyDelta(p50, base.P50Ms, m.config.Thresholds.Excellent)
+	return label + LabelStyle.Render("p50 ") +
+		lipgloss.NewStyle().Foreground(color).Render(sign+f.Float(math.Abs(p50-base.P50Ms), 1)+"ms") + vs
+}
+
+// renderDiagnosis renders the -diagnose verdict with the loss and p95 of the
+// path it blames.
+func (m Model) renderDiagnosis(d metrics.Diagnosis) string {
+	label := LabelStyle.Render("Diagnosis: ")
+	path := func(name string, p metrics.PathStats) string {
+		return LabelStyle.Render(fmt.Sprintf(" (%s %.1f%% loss, p95 %.0fms)", name, p.LossPercent, p.P95Ms))
+	}
+	switch d.Verdict {
+	case metrics.VerdictHealthy:
+		return label + GoodValueStyle.Render(d.Verdict.String())
+	case metrics.VerdictLocal:
+		return label + BadValueStyle.Render(d.Verdict.String()) + path("gateway", d.Gateway)
+	case metrics.VerdictISP:
+		return label + BadValueStyle.Render(d.Verdict.String()) + path(m.config.Target, d.Anchor)
+	default:
+		return label + LabelStyle.Render(d.Verdict.String()+glyph("…", "..."))
+	}
+}
+
 // renderStats renders the statistics lines.
 func (m Model) renderStats() string {
 	if m.stats.TotalSamples == 0 {
@@ -60,7 +155,7 @@ func (m Model) renderStats() string {
 	line1 := []string{
 		fmt.Sprintf("%s %s",
 			LabelStyle.Render("Sent:"),
-			ValueStyle.Render(fmt.Sprintf("%d", m.stats.TotalSamples))),
+			ValueStyle.Render(m.config.Format.Int(m.stats.TotalSamples))),
 	}
 
 	// Loss percentage with color coding
@@ -73,7 +168,7 @@ func (m Model) renderStats() string {
 	}
 	line1 = append(line1, fmt.Sprintf("%s %s",
 		LabelStyle.Render("Loss:"),
-		lossStyle.Render(fmt.Sprintf("%.1f%%", m.stats.LossPercent))))
+		lossStyle.Render(m.config.Format.Percent(m.stats.LossPercent, 1))))
 
 	// RTT stats (only if we have successful pings)
 	if m.stats.TotalSamples > m.stats.TotalTimeouts {
@@ -88,7 +183,7 @@ func (m Model) renderStats() string {
 				LabelStyle.Render("Max:"),
 				m.colorizeRTT(m.stats.MaxRTT)),
 			fmt.Sprintf("%s %s",
-				LabelStyle.Render("σ:"),
+				LabelStyle.Render(glyph("σ:", "SD:")),
 				m.colorizeRTT(m.stats.StdDev)),
 			fmt.Sprintf("%s %s",
 				LabelStyle.Render("Jitter:"),
@@ -96,6 +191,10 @@ func (m Model) renderStats() string {
 		)
 	}
 
+	if m.wifi != nil {
+		line1 = append(line1, fmt.Sprintf("%s %s", LabelStyle.Render("Wi-Fi:"), m.renderWiFi(m.wifi())))
+	}
+
 	// Second line: percentiles and instability
 	var line2 []string
 
@@ -121,7 +220,44 @@ func (m Model) renderStats() string {
 	if m.stats.LossBursts > 0 {
 		line2 = append(line2, fmt.Sprintf("%s %s",
 			LabelStyle.Render("Outages:"),
-			BadValueStyle.Render(fmt.Sprintf("%d", m.stats.LossBursts))))
+			BadValueStyle.Render(m.config.Format.Int(m.stats.LossBursts))))
+		// How often the target fails and how long it takes to come back
+		if m.stats.MTBF > 0 {
+			line2 = append(line2, fmt.Sprintf("%s %s",
+				LabelStyle.Render("MTBF:"),
+				ValueStyle.Render(formatMeanTime(m.stats.MTBF))))
+		}
+		if m.stats.MTTR > 0 {
+			line2 = append(line2, fmt.Sprintf("%s %s",
+				LabelStyle.Render("MTTR:"),
+				WarnValueStyle.Render(formatMeanTime(m.stats.MTTR))))
+		}
+	}
+
+	// Suspends and clock steps, whose gaps count as neither up nor down
+	if m.stats.ClockJumps > 0 {
+		line2 = append(line2, fmt.Sprintf("%s %s",
+			LabelStyle.Render("Clock jumps:"),
+			WarnValueStyle.Render(fmt.Sprintf("%d (%s skipped)", m.stats.ClockJumps, formatMeanTime(m.stats.SkippedTime)))))
+	}
+
+	// Intervals of a burst that lost some probes but not all
+	if m.stats.PartialLossIntervals > 0 {
+		line2 = append(line2, fmt.Sprintf("%s %s",
+			LabelStyle.Render("Partial:"),
+			WarnValueStyle.Render(fmt.Sprintf("%d/%d", m.stats.PartialLossIntervals, m.stats.BurstIntervals))))
+	}
+
+	// Loss while the Wi-Fi signal was weak, against the overall loss above
+	if m.stats.WeakSignalSamples > 0 {
+		loss := float64(m.stats.WeakSignalTimeouts) / float64(m.stats.WeakSignalSamples) * 100
+		style := WarnValueStyle
+		if loss > m.stats.LossPercent {
+			style = BadValueStyle
+		}
+		line2 = append(line2, fmt.Sprintf("%s %s",
+			LabelStyle.Render("Weak-signal loss:"),
+			style.Render(fmt.Sprintf("%.1f%%/%d", loss, m.stats.WeakSignalSamples))))
 	}
 
 	if m.stats.LongestTimeout > 0 {
@@ -130,6 +266,14 @@ func (m Model) renderStats() string {
 			BadValueStyle.Render(fmt.Sprintf("%d", m.stats.LongestTimeout))))
 	}
 
+	if errs := m.renderErrorCounts(); errs != "" {
+		line2 = append(line2, fmt.Sprintf("%s %s", LabelStyle.Render("Errors:"), errs))
+	}
+
+	if slo := m.renderSLO(); slo != "" {
+		line2 = append(line2, fmt.Sprintf("%s %s", LabelStyle.Render("SLO:"), slo))
+	}
+
 	if m.stats.BrownoutBursts > 0 {
 		line2 = append(line2, fmt.Sprintf("%s %s",
 			LabelStyle.Render("Brownouts:"),
@@ -154,19 +298,63 @@ func (m Model) renderStats() string {
 	return result
 }
 
+// renderWiFi returns the signal of a Wi-Fi link, colored by strength, with
+// its signal-to-noise ratio and channel where known.
+func (m Model) renderWiFi(l wifi.Link) string {
+	if l.Signal == 0 {
+		return LabelStyle.Render("disconnected")
+	}
+	style := GoodValueStyle
+	switch {
+	case l.Signal <= metrics.WeakSignalDbm:
+		style = BadValueStyle
+	case l.Signal <= metrics.WeakSignalDbm+10:
+		style = WarnValueStyle
+	}
+	s := style.Render(fmt.Sprintf("%ddBm", l.Signal))
+	if snr := l.SNR(); snr != 0 {
+		s += LabelStyle.Render(fmt.Sprintf(" SNR %ddB", snr))
+	}
+	if l.Channel != 0 {
+		s += LabelStyle.Render(fmt.Sprintf(" ch%d", l.Channel))
+	}
+	return s
+}
+
+// renderSLO returns the SLO compliance and remaining error budget, colored
+// by how much of the budget is left, or "" without an SLO.
+func (m Model) renderSLO() string {
+	if m.slo == nil {
+		return ""
+	}
+	status := m.slo.Status(m.now)
+	if status.Samples == 0 {
+		return ""
+	}
+	style := GoodValueStyle
+	switch {
+	case status.BudgetRemaining <= 0:
+		style = BadValueStyle
+	case status.BudgetRemaining < 0.5:
+		style = WarnValueStyle
+	}
+	f := m.config.Format
+	return style.Render(f.Percent(status.CompliancePercent, 2) + " budget " + f.Percent(max(status.BudgetRemaining, 0)*100, 0))
+}
+
 // colorizeRTTMs returns a styled RTT string from milliseconds value.
 func (m Model) colorizeRTTMs(ms float64) string {
-	color := colors.ClassifyMs(ms)
+	color := m.config.Thresholds.ClassifyMs(ms)
 	style := lipgloss.NewStyle().Foreground(color)
-	return style.Render(fmt.Sprintf("%.1fms", ms))
+	return style.Render(m.config.Format.Float(ms, 1) + "ms")
 }
 
 // colorizeRTT returns a styled RTT string.
 func (m Model) colorizeRTT(d time.Duration) string {
 	ms := float64(d.Microseconds()) / 1000.0
-	color := colors.ClassifyMs(ms)
+	color := m.config.Thresholds.ClassifyMs(ms)
 	style := lipgloss.NewStyle().Foreground(color)
-	return style.Render(fmt.Sprintf("%.1fms", ms))
+	return style.Render(m.config.Format.Float(ms, 1) + "ms")
 }
 
 // renderHeatmap renders the main heatmap grid.
@@ -175,47 +363,347 @@ func (m Model) renderHeatmap() string {
 	if cols <= 0 || rows <= 0 {
 		return ""
 	}
+	switch m.viewMode {
+	case viewMinutes:
+		return m.renderMinutes(cols, rows)
+	case viewAligned:
+		return m.renderAligned(cols, rows)
+	}
+	// Burst cells end with their interval, so rows only line up with fixed
+	// sample ranges, which the row cache needs, when cells have a fixed size
+	if m.heatmap != nil && !m.columns && (m.viewMode != viewBlocks || m.samplesPerCell() == 1) {
+		return m.heatmap.render(m, cols, rows)
+	}
 
-	samples := m.VisibleSamples()
-	sampleIdx := 0
+	var cells []string
+	var starts []time.Time
+	if start, end, ok := m.visibleRange(m.samples.Len()); ok {
+		m.walkCells(start, end, func(cell string, first time.Time) {
+			cells = append(cells, cell)
+			starts = append(starts, first)
+		})
+	}
+	return m.layoutGrid(cells, starts, cols, rows)
+}
 
-	var grid strings.Builder
+// walkCells renders the cells of the samples at indices start to end,
+// passing each to emit with the time of its first sample. The samples are
+// walked in place rather than copied out. A cell holding the first sample
+// after a gap is marked as a separator.
+func (m Model) walkCells(start, end int, emit func(cell string, first time.Time)) {
+	cell := make([]ping.Sample, 0, m.samplesPerCell())
+	gap := false
+	prev, hasPrev := m.samples.Get(start - 1)
+	m.samples.RangeFrom(start, func(i int, sample ping.Sample) bool {
+		if i > end {
+			return false
+		}
+		// Burst cells end with their interval, even when the oldest
+		// interval is only partly in view
+		if m.viewMode == viewBlocks && len(cell) > 0 && sample.Sequence != cell[0].Sequence {
+			emit(m.renderCell(cell, gap), cell[0].Timestamp)
+			cell, gap = cell[:0], false
+		}
+		if sample.ClockJump || hasPrev && metrics.IsGap(prev, sample, m.config.Interval) {
+			gap = true
+		}
+		prev, hasPrev = sample, true
+		cell = append(cell, sample)
+		if len(cell) == cap(cell) {
+			emit(m.renderCell(cell, gap), cell[0].Timestamp)
+			cell, gap = cell[:0], false
+		}
+		return true
+	})
+	if len(cell) > 0 {
+		emit(m.renderCell(cell, gap), cell[0].Timestamp)
+	}
+}
+
+// renderMinutes renders the minutes view: one cell per minute bucket, colored
+// like its worst sample would be: any loss wins, otherwise p95 is classified.
+func (m Model) renderMinutes(cols, rows int) string {
+	buckets := m.VisibleBuckets()
+
+	cells := make([]string, len(buckets))
+	starts := make([]time.Time, len(buckets))
+	for i, b := range buckets {
+		starts[i] = b.Start
+		if b.Samples == 0 {
+			cells[i] = " "
+			continue
+		}
+		color := m.p95Color(b.P95Ms)
+		if b.Timeouts > 0 {
+			color = colors.ColorTimeout
+		}
+		char := colors.CellChar(color)
+		if b.Maintenance == b.Samples {
+			color = colors.Dim(color)
+		}
+		cells[i] = renderCellChar(color, char)
+	}
+	return m.layoutGrid(cells, starts, cols, rows)
+}
 
-	for row := 0; row < rows; row++ {
-		for col := 0; col < cols; col++ {
-			if sampleIdx < len(samples) {
-				sample := samples[sampleIdx]
-				char := colors.HeatmapChar(sample.Timeout)
-
-				var color lipgloss.Color
-				if sample.Timeout {
-					color = colors.ColorTimeout
-				} else {
-					color = colors.Classify(sample.RTT)
-				}
-
-				style := lipgloss.NewStyle().Foreground(color)
-				grid.WriteString(style.Render(char))
-				sampleIdx++
+// layoutGrid arranges cells in time order on a bordered grid, filling rows
+// left to right or, in the columns orientation, columns top to bottom with
+// a time axis beneath. starts holds the time of each cell for the axis.
+func (m Model) layoutGrid(cells []string, starts []time.Time, cols, rows int) string {
+	var grid strings.Builder
+	for row := range rows {
+		if row > 0 {
+			grid.WriteString("\n")
+		}
+		for col := range cols {
+			idx := row*cols + col
+			if m.columns {
+				idx = col*rows + row
+			}
+			if idx < len(cells) {
+				grid.WriteString(cells[idx])
 			} else {
-				// Empty cell
 				grid.WriteString(" ")
 			}
 		}
-		if row < rows-1 {
-			grid.WriteString("\n")
+	}
+
+	out := HeatmapBorderStyle.Render(grid.String()) + "\n"
+	if m.columns {
+		out += m.renderTimeAxis(starts, cols, rows)
+	}
+	return out
+}
+
+// timeAxisEvery is how many columns apart the time axis labels are.
+const timeAxisEvery = 10
+
+// renderTimeAxis renders the clock time of the first cell of every
+// timeAxisEvery-th column, aligned with the columns beneath the heatmap.
+func (m Model) renderTimeAxis(starts []time.Time, cols, rows int) string {
+	axis := []byte(strings.Repeat(" ", cols))
+	for col := 0; col < cols; col += timeAxisEvery {
+		idx := col * rows
+		if idx >= len(starts) || starts[idx].IsZero() {
+			break
+		}
+		label := starts[idx].In(m.config.Location()).Format("15:04")
+		if col+len(label) > cols {
+			break
+		}
+		copy(axis[col:], label)
+	}
+	// The left border takes the first screen column
+	return LabelStyle.Render(" "+string(axis)) + "\n"
+}
+
+// renderCell renders the samples packed into a single heatmap cell.
+// In braille mode each dot is one sample and the cell takes the color of
+// its worst sample, so a single spike or timeout is never hidden. A burst
+// cell fills as far as its probes got replies, colored by their median, with
+// smoke behind it for the spread and loss. Cells taken entirely during
+// maintenance are dimmed, and a cell after a gap in the session is marked
+// as a separator.
+func (m Model) renderCell(samples []ping.Sample, gap bool) string {
+	char := colors.HeatmapChar(samples[0].Timeout)
+	color := m.worstColor(samples)
+	var smoke lipgloss.Color
+	switch {
+	case gap:
+		char = colors.GapChar()
+	case m.viewMode == viewBraille:
+		char = colors.BrailleChar(len(samples))
+	case m.config.Burst > 1 && samples[0].Burst > 1:
+		char, color, smoke = m.burstCell(samples)
+	}
+	// Without colors the band glyph carries the latency instead of the fill
+	if colors.NoColor() && !gap {
+		char, smoke = colors.BandChar(color), ""
+	}
+
+	if inMaintenance(samples) {
+		color = colors.Dim(color)
+	}
+	return renderCellOn(color, smoke, char)
+}
+
+// burstCell returns the character, color and smoke of a cell holding one
+// burst, drawn like a smokeping graph: the block is colored by the median
+// reply, partial loss lowers it instead of hiding the replies' latency, and
+// a background shade darkens with the spread of the replies and the share
+// of probes lost. The share is of the probes in view, as the oldest burst
+// may be cut off.
+func (m Model) burstCell(samples []ping.Sample) (string, lipgloss.Color, lipgloss.Color) {
+	rtts := make([]time.Duration, 0, len(samples))
+	for _, sample := range samples {
+		if !sample.Timeout {
+			rtts = append(rtts, sample.RTT)
+		}
+	}
+	if len(rtts) == 0 {
+		return colors.BurstChar(0, len(samples)), m.worstColor(samples), ""
+	}
+	slices.Sort(rtts)
+	median := rtts[len(rtts)/2]
+	if len(rtts)%2 == 0 {
+		median = (rtts[len(rtts)/2-1] + rtts[len(rtts)/2]) / 2
+	}
+	smoke := m.smokeLevel(rtts[len(rtts)-1]-rtts[0], median, len(samples)-len(rtts), len(samples))
+	return colors.BurstChar(len(rtts), len(samples)), m.rttColor(median), colors.Smoke(smoke)
+}
+
+// smokeLevel returns how heavy the smoke behind a burst cell is, from 0 to
+// 3: the heavier of its spread, measured against the median but at least
+// the excellent threshold so sub-millisecond jitter on a LAN stays clear,
+// and its loss, a level per third of the probes.
+func (m Model) smokeLevel(spread, median time.Duration, lost, n int) int {
+	scale := max(median.Seconds()*1000, m.config.Thresholds.Excellent)
+	ratio := spread.Seconds() * 1000 / scale
+	level := 0
+	switch {
+	case ratio >= 0.5:
+		level = 3
+	case ratio >= 0.25:
+		level = 2
+	case ratio >= 0.1:
+		level = 1
+	}
+	return max(level, (3*lost+n-1)/n)
+}
+
+// inMaintenance reports whether every sample was taken during maintenance.
+func inMaintenance(samples []ping.Sample) bool {
+	for _, sample := range samples {
+		if !sample.Maintenance {
+			return false
 		}
 	}
+	return true
+}
 
-	// Apply border
-	return HeatmapBorderStyle.Render(grid.String()) + "\n"
+// errorKindLabels are short display names of the failure kinds.
+var errorKindLabels = map[types.ErrorKind]string{
+	types.ErrorTimeout:         "timeout",
+	types.ErrorHostUnreachable: "host unreachable",
+	types.ErrorNetUnreachable:  "net unreachable",
+	types.ErrorTTLExceeded:     "TTL exceeded",
+	types.ErrorAdminProhibited: "prohibited",
+}
+
+// renderErrorCounts renders the number of failures of each kind other than
+// plain timeouts, in the kind's color, or "" if there were none.
+func (m Model) renderErrorCounts() string {
+	var parts []string
+	for _, kind := range types.ErrorKinds[1:] {
+		if n := m.stats.ErrorCounts[kind]; n > 0 {
+			parts = append(parts, lipgloss.NewStyle().Foreground(colors.ErrorColor(kind)).
+				Render(fmt.Sprintf("%d %s", n, errorKindLabels[kind])))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// worstColor returns the color of the worst sample: any failure wins,
+// colored by its kind, otherwise the highest RTT is classified.
+func (m Model) worstColor(samples []ping.Sample) lipgloss.Color {
+	var maxRTT time.Duration
+	for _, sample := range samples {
+		if sample.Timeout {
+			return colors.ErrorColor(sample.Kind())
+		}
+		if sample.RTT > maxRTT {
+			maxRTT = sample.RTT
+		}
+	}
+	return m.rttColor(maxRTT)
+}
+
+// deltaBaseline returns the baseline the delta view compares latency to,
+// zero when cells show absolute latency: the view is off, or its baseline
+// is still being learned.
+func (m Model) deltaBaseline() metrics.Baseline {
+	if !m.delta || m.calibrator == nil {
+		return metrics.Baseline{}
+	}
+	return m.calibrator.Baseline()
+}
+
+// rttColor returns the color of a reply: by how far it is from the
+// baseline's p50 in the delta view, by the thresholds otherwise.
+func (m Model) rttColor(rtt time.Duration) lipgloss.Color {
+	ms := float64(rtt.Microseconds()) / 1000
+	if base := m.deltaBaseline(); !base.IsZero() {
+		return colors.ClassifyDelta(ms, base.P50Ms, m.config.Thresholds.Excellent)
+	}
+	return m.config.Thresholds.ClassifyMs(ms)
+}
+
+// p95Color returns the color of the p95 of a minute: by how far it is from
+// the baseline's p95 in the delta view, by the thresholds otherwise.
+func (m Model) p95Color(ms float64) lipgloss.Color {
+	base := m.deltaBaseline()
+	if base.IsZero() {
+		return m.config.Thresholds.ClassifyMs(ms)
+	}
+	if base.P95Ms == 0 {
+		base.P95Ms = base.P50Ms // Given without a p95
+	}
+	return colors.ClassifyDelta(ms, base.P95Ms, m.config.Thresholds.Excellent)
+}
+
+// The status bar warns once unparsed ping lines reach parserMissWarn and
+// parserMissWarnRatio of the samples. A few stray lines are normal; many
+// point at an unsupported output format.
+const (
+	parserMissWarn      = 10
+	parserMissWarnRatio = 0.1
+)
+
+// missCount returns the number of unparsed ping output lines.
+func (m Model) missCount() int {
+	if m.parserMisses != nil {
+		return m.parserMisses()
+	}
+	return m.stats.ParserMisses
+}
+
+// parserMissesHigh reports whether unparsed lines are common enough that
+// samples are likely being lost to an unsupported output format.
+func (m Model) parserMissesHigh() bool {
+	n := m.missCount()
+	return n >= parserMissWarn && float64(n) >= parserMissWarnRatio*float64(m.stats.TotalSamples)
+}
+
+// bannerText returns the text of the incident banner, or "" when there is
+// no incident: the target lost the last -down-after samples in a row, or an
+// SLO alert is firing. It clears itself on recovery.
+func (m Model) bannerText() string {
+	var parts []string
+	if m.config.DownAfter > 0 && m.downCount >= m.config.DownAfter {
+		parts = append(parts, fmt.Sprintf("%s %s DOWN for %s (%d lost)", glyph("⚠", "!"),
+			m.config.Target, formatElapsed(m.now.Sub(m.downSince)), m.downCount))
+	}
+	if m.slo != nil {
+		for _, a := range m.slo.Status(m.now).Firing() {
+			parts = append(parts, fmt.Sprintf("%s %s (%s): %s", glyph("🔥", "!!"), a.Name, a.Severity, a.Summary))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " " + strings.Join(parts, separator())
 }
 
 // renderStatusBar renders the status bar at the bottom.
 func (m Model) renderStatusBar() string {
 	// Left side: status message or scroll info
 	var left string
-	if m.statusMsg != "" {
+	if m.prompt != promptNone {
+		left = StatusBarStyle.Render(m.promptLabel() + m.input + glyph("█", "_"))
+		if m.prompt == promptCommand {
+			left += LabelStyle.Render("  " + commandHint(m.input))
+		}
+	} else if m.statusMsg != "" {
 		if m.statusErr {
 			left = StatusErrorStyle.Render(m.statusMsg)
 		} else {
@@ -226,11 +714,29 @@ func (m Model) renderStatusBar() string {
 		if m.CanScrollUp() || m.CanScrollDown() {
 			scrollInfo = fmt.Sprintf("Scroll: %d", m.scrollPos)
 		}
+		if m.newSamples > 0 {
+			scrollInfo += fmt.Sprintf("%s%s new samples (G: live)", separator(), m.config.Format.Int(m.newSamples))
+		}
 		left = StatusBarStyle.Render(scrollInfo)
 	}
+	if m.prompt == promptNone && m.stats.DroppedUISamples > 0 {
+		left += StatusWarnStyle.Render(fmt.Sprintf("%s %s samples dropped, display is lossy", glyph("⚠", "!"), m.config.Format.Int(m.stats.DroppedUISamples)))
+	}
+	if m.prompt == promptNone && m.parserMissesHigh() {
+		left += StatusWarnStyle.Render(fmt.Sprintf("%s %d unparsed ping lines (see -debug-log)", glyph("⚠", "!"), m.missCount()))
+	}
+	// Adaptive mode probes faster while an outage lasts
+	if last, ok := m.samples.GetLast(); ok && last.Interval > 0 && last.Interval < m.config.Interval {
+		left += StatusWarnStyle.Render(fmt.Sprintf("%s outage: probing every %v", glyph("⚡", "!"), last.Interval))
+	}
 
-	// Right side: help hint
-	right := StatusBarStyle.Render("Press ? for help")
+	// Right side: clock, elapsed session time, sample rate and help hint
+	right := StatusBarStyle.Render(strings.Join([]string{
+		m.formatClock(m.now),
+		"up " + formatElapsed(m.now.Sub(m.startTime)),
+		m.config.Format.Float(m.samplesPerSecond(), 1) + "/s",
+		"Press ? for help",
+	}, separator()))
 
 	// Calculate padding
 	leftLen := lipgloss.Width(left)
@@ -243,16 +749,79 @@ func (m Model) renderStatusBar() string {
 	return left + strings.Repeat(" ", padding) + right
 }
 
+// promptLabel returns the prefix shown before the prompt input.
+func (m Model) promptLabel() string {
+	switch m.prompt {
+	case promptJump:
+		return "Jump to: /"
+	case promptTarget:
+		return "Target: "
+	case promptTargetClear:
+		return "Target (clear history): "
+	case promptCommand:
+		return ":"
+	}
+	return ""
+}
+
+// formatClock formats a wall-clock time in local time or UTC, on a 12- or
+// 24-hour clock, per config.
+func (m Model) formatClock(t time.Time) string {
+	if m.config.UTC && m.config.Format.Hour12 {
+		return m.config.Format.Time(t.UTC(), "15:04:05") + " UTC"
+	}
+	if m.config.UTC {
+		return t.UTC().Format("15:04:05Z")
+	}
+	return m.config.Format.Time(t.Local(), "15:04:05")
+}
+
+// samplesPerSecond returns the average sample rate over the session.
+func (m Model) samplesPerSecond() float64 {
+	elapsed := m.now.Sub(m.startTime).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(m.stats.TotalSamples) / elapsed
+}
+
+// formatElapsed formats a duration as H:MM:SS, growing a day prefix past 24h.
+func formatElapsed(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	total := int(d.Seconds())
+	days := total / 86400
+	h := (total % 86400) / 3600
+	mins := (total % 3600) / 60
+	secs := total % 60
+	if days > 0 {
+		return fmt.Sprintf("%dd%02d:%02d:%02d", days, h, mins, secs)
+	}
+	return fmt.Sprintf("%d:%02d:%02d", h, mins, secs)
+}
+
+// formatMeanTime formats an MTBF or MTTR to the second, or to the
+// millisecond below one.
+func formatMeanTime(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
+
 // renderHelpOverlay renders the help overlay on top of the main view.
 func (m Model) renderHelpOverlay(base string) string {
-	help := m.renderHelp()
+	return m.renderCentered(m.renderHelp(), base)
+}
 
-	// Center the help overlay
-	helpWidth := lipgloss.Width(help)
-	helpHeight := lipgloss.Height(help)
+// renderCentered places an overlay in the middle of the main view.
+func (m Model) renderCentered(overlay, base string) string {
+	overlayWidth := lipgloss.Width(overlay)
+	overlayHeight := lipgloss.Height(overlay)
 
-	x := (m.width - helpWidth) / 2
-	y := (m.height - helpHeight) / 2
+	x := (m.width - overlayWidth) / 2
+	y := (m.height - overlayHeight) / 2
 
 	if x < 0 {
 		x = 0
@@ -261,52 +830,149 @@ func (m Model) renderHelpOverlay(base string) string {
 		y = 0
 	}
 
-	// Overlay the help on top of the base view
-	return placeOverlay(x, y, help, base)
+	// Overlay on top of the base view
+	return placeOverlay(x, y, overlay, base)
 }
 
-// renderHelp renders the help content.
-func (m Model) renderHelp() string {
-	keys := []struct {
-		key  string
-		desc string
-	}{
-		{"↑/k", "Scroll up (older)"},
-		{"↓/j", "Scroll down (newer)"},
-		{"PgUp", "Page up"},
-		{"PgDn", "Page down"},
-		{"Home/g", "Go to oldest"},
-		{"End/G", "Go to newest"},
-		{"c", "Clear history"},
-		{"?/h", "Toggle help"},
-		{"q", "Quit"},
+// legendScale renders the color of each latency band with its threshold.
+func (m Model) legendScale() string {
+	if base := m.deltaBaseline(); !base.IsZero() {
+		return m.deltaScale(base)
 	}
+	th := m.config.Thresholds
+	var b strings.Builder
+	b.WriteString(swatch(colors.ColorExcellent))
+	fmt.Fprintf(&b, " <%gms ", th.Excellent)
+	b.WriteString(swatch(colors.ColorGood))
+	fmt.Fprintf(&b, " <%gms ", th.Good)
+	b.WriteString(swatch(colors.ColorFair))
+	fmt.Fprintf(&b, " <%gms ", th.Fair)
+	b.WriteString(swatch(colors.ColorPoor))
+	fmt.Fprintf(&b, " <%gms ", th.Poor)
+	b.WriteString(swatch(colors.ColorBad))
+	fmt.Fprintf(&b, " >%gms ", th.Poor)
+	b.WriteString(swatch(colors.ColorTimeout))
+	b.WriteString(" timeout")
+	return b.String()
+}
 
+// deltaScale renders the color of each delta view band with its share of
+// the baseline.
+func (m Model) deltaScale(base metrics.Baseline) string {
+	pct := func(v float64) string { return m.config.Format.Float(v*100, 0) + "%" }
 	var b strings.Builder
-	b.WriteString(TitleStyle.Render("Keyboard Shortcuts"))
-	b.WriteString("\n\n")
+	b.WriteString(swatch(colors.ColorDeltaFaster))
+	fmt.Fprintf(&b, " <-%s ", pct(colors.DeltaFaster))
+	b.WriteString(swatch(colors.ColorDeltaFast))
+	fmt.Fprintf(&b, " <-%s ", pct(colors.DeltaSame))
+	b.WriteString(swatch(colors.ColorDeltaSame))
+	fmt.Fprintf(&b, " %s%s ", glyph("±", "+-"), pct(colors.DeltaSame))
+	b.WriteString(swatch(colors.ColorDeltaSlow))
+	fmt.Fprintf(&b, " <+%s ", pct(colors.DeltaSlow))
+	b.WriteString(swatch(colors.ColorDeltaSlower))
+	fmt.Fprintf(&b, " <+%s ", pct(colors.DeltaSlower))
+	b.WriteString(swatch(colors.ColorDeltaWorse))
+	fmt.Fprintf(&b, " >+%s ", pct(colors.DeltaSlower))
+	b.WriteString(swatch(colors.ColorTimeout))
+	b.WriteString(" timeout")
+	b.WriteString(LabelStyle.Render(" of " + m.config.Format.Float(base.P50Ms, 1) + "ms"))
+	return b.String()
+}
 
-	for _, k := range keys {
-		b.WriteString(HelpKeyStyle.Render(fmt.Sprintf("%8s", k.key)))
-		b.WriteString("  ")
-		b.WriteString(HelpDescStyle.Render(k.desc))
-		b.WriteString("\n")
+// renderLegend renders the legend row shown beneath the heatmap with "l",
+// cut off at the screen width.
+func (m Model) renderLegend() string {
+	legend := " " + m.legendScale()
+	if m.viewMode == viewMinutes {
+		legend += LabelStyle.Render("  (p95 per minute)")
 	}
+	return lipgloss.NewStyle().MaxWidth(m.width).Render(legend) + "\n"
+}
 
-	b.WriteString("\n")
-	b.WriteString(LabelStyle.Render("Legend: "))
-	b.WriteString(lipgloss.NewStyle().Foreground(colors.ColorExcellent).Render("█"))
-	b.WriteString(" <30ms ")
-	b.WriteString(lipgloss.NewStyle().Foreground(colors.ColorGood).Render("█"))
-	b.WriteString(" <80ms ")
-	b.WriteString(lipgloss.NewStyle().Foreground(colors.ColorFair).Render("█"))
-	b.WriteString(" <150ms ")
-	b.WriteString(lipgloss.NewStyle().Foreground(colors.ColorPoor).Render("█"))
-	b.WriteString(" <300ms ")
-	b.WriteString(lipgloss.NewStyle().Foreground(colors.ColorBad).Render("█"))
-	b.WriteString(" >300ms ")
-	b.WriteString(lipgloss.NewStyle().Foreground(colors.ColorTimeout).Render("█"))
-	b.WriteString(" timeout")
+// swatch renders a full cell in color c for the legend.
+func swatch(c lipgloss.Color) string {
+	return renderCellChar(c, colors.CellChar(c))
+}
+
+// helpChrome is the height of the help overlay around its lines: border,
+// padding, and the title with the blank line beneath it.
+const helpChrome = 6
+
+// helpLines returns the lines of the help overlay: the bindings that apply
+// in the current view, then the color legend.
+func (m Model) helpLines() []string {
+	var lines []string
+	for _, b := range keymap {
+		if b.applies(m) {
+			lines = append(lines, HelpKeyStyle.Render(fmt.Sprintf("%8s", b.label()))+"  "+HelpDescStyle.Render(b.desc))
+		}
+	}
+
+	lines = append(lines, "", LabelStyle.Render("Legend: ")+m.legendScale())
+	var errs strings.Builder
+	errs.WriteString(LabelStyle.Render("Errors: "))
+	for _, kind := range types.ErrorKinds[1:] {
+		errs.WriteString(swatch(colors.ErrorColor(kind)))
+		fmt.Fprintf(&errs, " %s ", errorKindLabels[kind])
+	}
+	return append(lines, errs.String())
+}
+
+// helpPage returns how many help lines fit the terminal at once. When not
+// all do, two more lines go to the page indicator.
+func (m Model) helpPage(lines int) int {
+	if m.height <= 0 || lines <= m.height-helpChrome {
+		return lines
+	}
+	return max(1, m.height-helpChrome-2)
+}
+
+// scrollHelp pages through the help overlay with the scroll keys,
+// reporting whether act was one. When the help fits, the keys scroll the
+// heatmap as usual.
+func (m *Model) scrollHelp(act action) bool {
+	lines := len(m.helpLines())
+	page := m.helpPage(lines)
+	maxScroll := lines - page
+	if maxScroll <= 0 {
+		return false
+	}
+	switch act {
+	case actScrollUp:
+		m.helpScroll--
+	case actScrollDown:
+		m.helpScroll++
+	case actPageUp:
+		m.helpScroll -= page
+	case actPageDown:
+		m.helpScroll += page
+	case actOldest:
+		m.helpScroll = 0
+	case actNewest:
+		m.helpScroll = maxScroll
+	default:
+		return false
+	}
+	m.helpScroll = min(max(m.helpScroll, 0), maxScroll)
+	return true
+}
+
+// renderHelp renders the help content, a page at a time when it is taller
+// than the terminal.
+func (m Model) renderHelp() string {
+	lines := m.helpLines()
+	page := m.helpPage(len(lines))
+	start := min(max(m.helpScroll, 0), len(lines)-page)
+
+	var b strings.Builder
+	b.WriteString(TitleStyle.Render("Keyboard Shortcuts"))
+	b.WriteString(LabelStyle.Render(" (" + m.viewMode.String() + " view)"))
+	b.WriteString("\n\n")
+	b.WriteString(strings.Join(lines[start:start+page], "\n"))
+	if page < len(lines) {
+		b.WriteString("\n\n")
+		b.WriteString(HelpDescStyle.Render(fmt.Sprintf("%d-%d of %d, j/k or PgUp/PgDn to scroll", start+1, start+page, len(lines))))
+	}
 
 	return HelpOverlayStyle.Render(b.String())
 }
@@ -0,0 +1,122 @@
+// Package userfile locates the files pingheat keeps per user and writes
+// them safely.
+package userfile
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// dirName is the pingheat directory inside the per-user directories.
+const dirName = "pingheat"
+
+// ConfigPath returns the path of the per-user configuration file name
+// ($XDG_CONFIG_HOME/pingheat/name on Linux, the platform equivalent
+// elsewhere).
+func ConfigPath(name string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, dirName, name), nil
+}
+
+// DataPath returns the path of the per-user data file name
+// ($XDG_DATA_HOME/pingheat/name on Linux, the platform equivalent
+// elsewhere).
+func DataPath(name string) (string, error) {
+	dir, err := dataDir(runtime.GOOS, os.Getenv)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, dirName, name), nil
+}
+
+// dataDir returns the per-user data directory on goos, read with getenv:
+// $XDG_DATA_HOME or ~/.local/share on Unix, Application Support on macOS
+// and %LocalAppData% on Windows. Go has no os.UserDataDir.
+func dataDir(goos string, getenv func(string) string) (string, error) {
+	switch goos {
+	case "windows":
+		if dir := getenv("LocalAppData"); dir != "" {
+			return dir, nil
+		}
+		return "", errors.New("%LocalAppData% is not defined")
+	case "darwin", "ios":
+		if home := getenv("HOME"); home != "" {
+			return filepath.Join(home, "Library", "Application Support"), nil
+		}
+		return "", errors.New("$HOME is not defined")
+	}
+	if dir := getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	if home := getenv("HOME"); home != "" {
+		return filepath.Join(home, ".local", "share"), nil
+	}
+	return "", errors.New("neither $XDG_DATA_HOME nor $HOME are defined")
+}
+
+// WriteAtomic writes data to path with permissions perm, creating the
+// parent directory if needed. The data goes to a temporary file in the same
+// directory, synced to disk before it is renamed over path, so a crash
+// mid-write cannot leave a truncated file behind.
+func WriteAtomic(path string, data []byte, perm fs.FileMode) (err error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	if _, err = f.Write(data); err != nil {
+		return err
+	}
+	if err = f.Chmod(perm); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// maxSuffix bounds the names CreateNew tries.
+const maxSuffix = 1000
+
+// CreateNew creates a file at path for writing, never replacing one: if the
+// name is taken it tries -2, -3 and so on before the extension, so
+// pingheat.csv becomes pingheat-2.csv. The returned file's Name is the path
+// used.
+func CreateNew(path string) (*os.File, error) {
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(path, ext)
+	for n := 1; ; n++ {
+		name := path
+		if n > 1 {
+			name = stem + "-" + strconv.Itoa(n) + ext
+		}
+		f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o666)
+		if errors.Is(err, fs.ErrExist) && n < maxSuffix {
+			continue
+		}
+		return f, err
+	}
+}
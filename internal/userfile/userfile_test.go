@@ -0,0 +1,78 @@
+package userfile
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestDataDir(t *testing.T) {
+	tests := []struct {
+		goos string
+		env  map[string]string
+		want string
+	}{
+		{"linux", map[string]string{"HOME": "/home/ann"}, filepath.Join("/home/ann", ".local", "share")},
+		{"linux", map[string]string{"HOME": "/home/ann", "XDG_DATA_HOME": "/data"}, "/data"},
+		// A relative XDG_DATA_HOME is invalid and ignored
+		{"freebsd", map[string]string{"HOME": "/home/ann", "XDG_DATA_HOME": "data"}, filepath.Join("/home/ann", ".local", "share")},
+		{"darwin", map[string]string{"HOME": "/Users/ann"}, filepath.Join("/Users/ann", "Library", "Application Support")},
+		{"windows", map[string]string{"LocalAppData": `C:\Users\ann\AppData\Local`}, `C:\Users\ann\AppData\Local`},
+	}
+	for _, tt := range tests {
+		got, err := dataDir(tt.goos, func(k string) string { return tt.env[k] })
+		if err != nil || got != tt.want {
+			t.Fatalf("dataDir(%s, %v)=%q, %v, want %q", tt.goos, tt.env, got, err, tt.want)
+		}
+	}
+	if _, err := dataDir("linux", func(string) string { return "" }); err == nil {
+		t.Fatalf("dataDir() without HOME: expected an error")
+	}
+}
+
+func TestWriteAtomic(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested")
+	path := filepath.Join(dir, "state.json")
+	for _, content := range []string{"first\n", "second\n"} {
+		if err := WriteAtomic(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteAtomic() error: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) != content {
+			t.Fatalf("ReadFile()=%q, %v, want %q", data, err, content)
+		}
+	}
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		if err != nil || info.Mode().Perm() != 0o600 {
+			t.Fatalf("Stat()=%v, %v, want mode 0600", info.Mode().Perm(), err)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("ReadDir()=%v, %v, want only the written file", entries, err)
+	}
+}
+
+func TestCreateNew(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "export.csv")
+	if err := os.WriteFile(path, []byte("kept\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"export-2.csv", "export-3.csv"} {
+		f, err := CreateNew(path)
+		if err != nil {
+			t.Fatalf("CreateNew() error: %v", err)
+		}
+		f.Close()
+		if f.Name() != filepath.Join(dir, want) {
+			t.Fatalf("CreateNew() created %s, want %s", f.Name(), want)
+		}
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "kept\n" {
+		t.Fatalf("existing file = %q, %v, want it untouched", data, err)
+	}
+}
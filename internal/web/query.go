@@ -0,0 +1,222 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/buffer"
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/output"
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+// minStep is the finest downsampling step of a range query.
+const minStep = time.Second
+
+// RangeResponse is the body of GET /api/v1/samples with from, to or step:
+// the samples in [from, to), or with a step, one point per step that has
+// samples. Next is the query of the following page, empty on the last one.
+type RangeResponse struct {
+	From    time.Time       `json:"from"`
+	To      time.Time       `json:"to"`
+	Step    string          `json:"step,omitempty"`
+	Samples []output.Record `json:"samples,omitempty"`
+	Points  []BucketEntry   `json:"points,omitempty"`
+	Next    string          `json:"next,omitempty"`
+}
+
+// rangeQuery is a parsed range query.
+type rangeQuery struct {
+	from, to time.Time
+	step     time.Duration // 0 = raw samples
+	limit    int
+}
+
+// isRangeQuery reports whether a samples request asks for a time range
+// rather than the live feed's entries after an ID.
+func isRangeQuery(q url.Values) bool {
+	return q.Has("from") || q.Has("to") || q.Has("step")
+}
+
+// parseRangeQuery reads from and to (RFC 3339 or Unix seconds; default the
+// whole history up to now), step and limit.
+func parseRangeQuery(q url.Values, now time.Time) (rangeQuery, error) {
+	rq := rangeQuery{to: now, limit: maxSamplesPerResponse}
+	var err error
+	if v := q.Get("from"); v != "" {
+		if rq.from, err = parseTime(v); err != nil {
+			return rangeQuery{}, errors.New("invalid from parameter")
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if rq.to, err = parseTime(v); err != nil {
+			return rangeQuery{}, errors.New("invalid to parameter")
+		}
+	}
+	if !rq.from.Before(rq.to) {
+		return rangeQuery{}, errors.New("from must be before to")
+	}
+	if v := q.Get("step"); v != "" {
+		if rq.step, err = time.ParseDuration(v); err != nil || rq.step < minStep {
+			return rangeQuery{}, errors.New("invalid step parameter: want a duration of at least 1s")
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if rq.limit, err = parseLimit(v); err != nil {
+			return rangeQuery{}, err
+		}
+	}
+	return rq, nil
+}
+
+// parseLimit reads a page size from 1 to maxSamplesPerResponse.
+func parseLimit(v string) (int, error) {
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > maxSamplesPerResponse {
+		return 0, errors.New("invalid limit parameter: want 1 to " + strconv.Itoa(maxSamplesPerResponse))
+	}
+	return n, nil
+}
+
+// parseTime reads an RFC 3339 time or Unix seconds.
+func parseTime(v string) (time.Time, error) {
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		return time.Unix(0, int64(secs*float64(time.Second))), nil
+	}
+	return time.Parse(time.RFC3339Nano, v)
+}
+
+// handleRange serves the samples of a time range, raw or downsampled, from
+// the persistent history if set and the samples kept in memory otherwise.
+func (s *Server) handleRange(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	rq, err := parseRangeQuery(q, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := RangeResponse{From: rq.from, To: rq.to}
+	var next time.Time
+	if rq.step > 0 {
+		resp.Step = rq.step.String()
+		resp.Points, next = s.points(rq)
+	} else {
+		resp.Samples, next = s.samplesBetween(rq)
+	}
+	if !next.IsZero() {
+		q.Set("from", next.Format(time.RFC3339Nano))
+		resp.Next = r.URL.Path + "?" + q.Encode()
+	}
+	writeJSON(w, resp)
+}
+
+// rangeSamples calls fn for each sample in [from, to), oldest first, until
+// fn returns false.
+func (s *Server) rangeSamples(from, to time.Time, fn func(types.Sample) bool) {
+	s.mu.RLock()
+	history := s.persisted
+	s.mu.RUnlock()
+	if history != nil {
+		buffer.RangeByTime(history, from, to, func(v types.Sample) time.Time { return v.Timestamp },
+			func(_ int, v types.Sample) bool { return fn(v) })
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	buffer.RangeByTime(s.history, from, to, func(e Entry) time.Time { return e.Timestamp },
+		func(_ int, e Entry) bool { return fn(e.Sample()) })
+}
+
+// samplesBetween returns up to limit samples of the range, and the time of
+// the first one left out (zero if none).
+func (s *Server) samplesBetween(rq rangeQuery) ([]output.Record, time.Time) {
+	records := []output.Record{}
+	var next time.Time
+	s.rangeSamples(rq.from, rq.to, func(v types.Sample) bool {
+		if len(records) == rq.limit {
+			next = v.Timestamp
+			return false
+		}
+		records = append(records, output.NewRecord(v))
+		return true
+	})
+	return records, next
+}
+
+// points returns up to limit points of the range, one per step with
+// samples, and the start of the first step left out (zero if none).
+func (s *Server) points(rq rangeQuery) ([]BucketEntry, time.Time) {
+	points := []BucketEntry{}
+	var next time.Time
+	var cur *stepPoint
+	flush := func() {
+		if cur != nil {
+			points = append(points, cur.entry())
+		}
+	}
+	s.rangeSamples(rq.from, rq.to, func(v types.Sample) bool {
+		start := v.Timestamp.Truncate(rq.step)
+		if cur != nil && start.Equal(cur.start) {
+			cur.add(v)
+			return true
+		}
+		flush()
+		if len(points) == rq.limit {
+			next, cur = start, nil
+			return false
+		}
+		cur = newStepPoint(start)
+		cur.add(v)
+		return true
+	})
+	flush()
+	return points, next
+}
+
+// stepPoint accumulates the samples of one step.
+type stepPoint struct {
+	start    time.Time
+	samples  int
+	timeouts int
+	sum      float64
+	min, max float64
+	rtts     *metrics.PercentileCalculator
+}
+
+func newStepPoint(start time.Time) *stepPoint {
+	return &stepPoint{start: start, rtts: metrics.NewPercentileCalculator()}
+}
+
+func (p *stepPoint) add(v types.Sample) {
+	p.samples++
+	if v.Timeout {
+		p.timeouts++
+		return
+	}
+	ms := v.RTTMs()
+	if p.rtts.Count() == 0 || ms < p.min {
+		p.min = ms
+	}
+	p.max = max(p.max, ms)
+	p.sum += ms
+	p.rtts.AddMs(ms)
+}
+
+func (p *stepPoint) entry() BucketEntry {
+	e := BucketEntry{
+		Start:       p.start,
+		Samples:     p.samples,
+		Timeouts:    p.timeouts,
+		LossPercent: float64(p.timeouts) / float64(p.samples) * 100,
+	}
+	if n := p.rtts.Count(); n > 0 {
+		e.MinMs, e.MaxMs, e.AvgMs = p.min, p.max, p.sum/float64(n)
+		e.P95Ms = p.rtts.P95()
+	}
+	return e
+}
@@ -0,0 +1,111 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/buffer"
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+var rangeBase = time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+// rangeSamples are 10 samples a second apart; the fourth is lost.
+func rangeSamples() []types.Sample {
+	samples := make([]types.Sample, 10)
+	for i := range samples {
+		samples[i] = types.Sample{
+			Timestamp: rangeBase.Add(time.Duration(i) * time.Second),
+			Sequence:  i + 1,
+			RTT:       time.Duration(i+1) * time.Millisecond,
+			Timeout:   i == 3,
+		}
+	}
+	return samples
+}
+
+func getRange(t *testing.T, s *Server, path string) RangeResponse {
+	t.Helper()
+	rec := get(t, s.handler(), path)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s status = %d: %s", path, rec.Code, rec.Body)
+	}
+	var resp RangeResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode range: %v", err)
+	}
+	return resp
+}
+
+func TestServerRangeRawPages(t *testing.T) {
+	s := newTestServer()
+	for _, v := range rangeSamples() {
+		s.Update(v, metrics.Stats{})
+	}
+
+	from := rangeBase.Add(2 * time.Second).Format(time.RFC3339)
+	to := rangeBase.Add(9 * time.Second).Format(time.RFC3339)
+	path := "/api/v1/samples?" + url.Values{"from": {from}, "to": {to}, "limit": {"4"}}.Encode()
+
+	var seqs []int
+	for pages := 0; path != ""; pages++ {
+		if pages == 3 {
+			t.Fatalf("more than 2 pages, next = %s", path)
+		}
+		resp := getRange(t, s, path)
+		for _, r := range resp.Samples {
+			seqs = append(seqs, r.Seq)
+		}
+		path = resp.Next
+	}
+	// Samples 3 to 9: [from, to) leaves out the one at to
+	if len(seqs) != 7 || seqs[0] != 3 || seqs[6] != 9 {
+		t.Fatalf("paged sequences = %v, want 3 to 9", seqs)
+	}
+}
+
+func TestServerRangeSteps(t *testing.T) {
+	history := buffer.NewRingBuffer[types.Sample](100)
+	history.PushBatch(rangeSamples())
+	s := newTestServer()
+	s.SetHistory(history)
+
+	// Unix seconds; points come from the history, not the empty memory
+	path := "/api/v1/samples?step=5s&from=" + strconv.FormatInt(rangeBase.Unix(), 10) +
+		"&to=" + url.QueryEscape(rangeBase.Add(time.Minute).Format(time.RFC3339))
+	resp := getRange(t, s, path)
+	if resp.Step != "5s" || len(resp.Points) != 2 || resp.Next != "" {
+		t.Fatalf("range = %+v, want two 5s points", resp)
+	}
+	first := resp.Points[0]
+	if first.Samples != 5 || first.Timeouts != 1 || first.LossPercent != 20 || first.MinMs != 1 || first.MaxMs != 5 || first.AvgMs != 2.75 {
+		t.Fatalf("first point = %+v, want 5 samples with 1 lost, 1-5ms averaging 2.75ms", first)
+	}
+
+	resp = getRange(t, s, path+"&limit=1")
+	if len(resp.Points) != 1 || resp.Next == "" {
+		t.Fatalf("limited range = %+v, want one point and a next page", resp)
+	}
+	if next := getRange(t, s, resp.Next); len(next.Points) != 1 || !next.Points[0].Start.Equal(rangeBase.Add(5*time.Second)) {
+		t.Fatalf("next page = %+v, want the second point", next)
+	}
+}
+
+func TestServerRangeInvalid(t *testing.T) {
+	for _, query := range []string{
+		"from=yesterday",
+		"from=2024-01-02T10:00:00Z&to=2024-01-02T09:00:00Z",
+		"step=10ms",
+		"step=1m&limit=0",
+		"after=1&limit=5000",
+	} {
+		if rec := get(t, newTestServer().handler(), "/api/v1/samples?"+query); rec.Code != http.StatusBadRequest {
+			t.Fatalf("GET ?%s status = %d, want 400", query, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package web
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/buffer"
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/output"
+	"github.com/pbv7/pingheat/internal/types"
+	"github.com/pbv7/pingheat/internal/ui/colors"
+)
+
+// DefaultHistory is the number of recent samples kept for browser clients.
+const DefaultHistory = 3600
+
+// maxSamplesPerResponse caps a single /api/v1/samples response.
+const maxSamplesPerResponse = 1000
+
+// shutdownTimeout is how long requests in flight may finish on shutdown;
+// streams end right away since they use the server's context.
+const shutdownTimeout = 3 * time.Second
+
+//go:embed static
+var staticFiles embed.FS
+
+// Entry is a sample with a server-assigned, strictly increasing ID that
+// clients use to request only what they have not seen yet.
+type Entry struct {
+	ID uint64 `json:"id"`
+	output.Record
+}
+
+// SamplesResponse is the body of GET /api/v1/samples.
+type SamplesResponse struct {
+	Samples []Entry `json:"samples"`
+	LastID  uint64  `json:"last_id"`
+}
+
+// StatsResponse is the body of GET /api/v1/stats.
+type StatsResponse struct {
+	Target         string            `json:"target"`
+	Interval       string            `json:"interval"`
+	Thresholds     colors.Thresholds `json:"thresholds"`
+	TotalSamples   int               `json:"total_samples"`
+	TotalTimeouts  int               `json:"total_timeouts"`
+	LossPercent    float64           `json:"loss_percent"`
+	MinRTTMs       float64           `json:"min_rtt_ms"`
+	AvgRTTMs       float64           `json:"avg_rtt_ms"`
+	MaxRTTMs       float64           `json:"max_rtt_ms"`
+	StdDevMs       float64           `json:"stddev_ms"`
+	JitterMs       float64           `json:"jitter_ms"`
+	LastRTTMs      float64           `json:"last_rtt_ms"`
+	P50Ms          float64           `json:"p50_ms"`
+	P90Ms          float64           `json:"p90_ms"`
+	P95Ms          float64           `json:"p95_ms"`
+	P99Ms          float64           `json:"p99_ms"`
+	CurrentStreak  int               `json:"current_streak"`
+	LossBursts     int               `json:"loss_bursts"`
+	BrownoutBursts int               `json:"brownout_bursts"`
+	InBrownout     bool              `json:"in_brownout"`
+	UptimeSeconds  float64           `json:"uptime_seconds"`
+}
+
+// BucketEntry summarizes the samples of one period in GET /api/v1/buckets.
+type BucketEntry struct {
+	Start       time.Time `json:"start"`
+	Samples     int       `json:"samples"`
+	Timeouts    int       `json:"timeouts"`
+	LossPercent float64   `json:"loss_percent"`
+	MinMs       float64   `json:"min_ms"`
+	AvgMs       float64   `json:"avg_ms"`
+	MaxMs       float64   `json:"max_ms"`
+	P95Ms       float64   `json:"p95_ms"`
+}
+
+// BucketsResponse is the body of GET /api/v1/buckets.
+type BucketsResponse struct {
+	Target  string        `json:"target"`
+	Bucket  string        `json:"bucket"`
+	Buckets []BucketEntry `json:"buckets"`
+}
+
+// Server serves a small browser UI and the JSON feed it polls.
+type Server struct {
+	addr       string
+	interval   time.Duration
+	thresholds colors.Thresholds
+	server     *http.Server
+
+	mu      sync.RWMutex
+	target  string
+	stats   metrics.Stats
+	history *buffer.RingBuffer[Entry]
+	lastID  uint64
+	streams map[chan Entry]struct{} // Subscribers of /api/v1/stream
+
+	aggregator *metrics.Aggregator // Per-minute buckets; nil disables /api/v1/buckets
+
+	// Persistent history serving range queries beyond the samples kept in
+	// memory; nil serves them from memory
+	persisted buffer.Buffer[types.Sample]
+}
+
+// NewServer creates a web UI server for the given target.
+func NewServer(addr, target string, interval time.Duration, thresholds colors.Thresholds) *Server {
+	return &Server{
+		addr:       addr,
+		target:     target,
+		interval:   interval,
+		thresholds: thresholds,
+		history:    buffer.NewRingBuffer[Entry](DefaultHistory),
+		streams:    make(map[chan Entry]struct{}),
+	}
+}
+
+// Start starts the HTTP server and blocks until ctx is cancelled.
+func (s *Server) Start(ctx context.Context) error {
+	s.server = s.newServer()
+	// Cancel long-lived stream requests on shutdown
+	s.server.BaseContext = func(net.Listener) context.Context { return ctx }
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := s.server.Shutdown(shutdownCtx); err != nil {
+			_ = s.server.Close()
+		}
+	}()
+
+	err := s.server.ListenAndServe()
+	if err == http.ErrServerClosed {
+		<-stopped
+		return nil
+	}
+	return err
+}
+
+// Update records a new sample and the stats after it.
+func (s *Server) Update(sample types.Sample, stats metrics.Stats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastID++
+	entry := Entry{ID: s.lastID, Record: output.NewRecord(sample)}
+	s.history.Push(entry)
+	s.stats = stats
+	s.publish(entry)
+}
+
+// SetTarget reports a new target to browser clients.
+func (s *Server) SetTarget(target string) {
+	s.mu.Lock()
+	s.target = target
+	s.mu.Unlock()
+}
+
+// SetInterval reports a new probe interval to browser clients.
+func (s *Server) SetInterval(interval time.Duration) {
+	s.mu.Lock()
+	s.interval = interval
+	s.mu.Unlock()
+}
+
+// SetAggregator serves the per-minute buckets of a at /api/v1/buckets.
+func (s *Server) SetAggregator(a *metrics.Aggregator) {
+	s.mu.Lock()
+	s.aggregator = a
+	s.mu.Unlock()
+}
+
+// SetHistory serves time-range queries of /api/v1/samples from h, such as
+// the history file, instead of the samples kept in memory.
+func (s *Server) SetHistory(h buffer.Buffer[types.Sample]) {
+	s.mu.Lock()
+	s.persisted = h
+	s.mu.Unlock()
+}
+
+// newServer builds the HTTP server and handlers.
+func (s *Server) newServer() *http.Server {
+	return &http.Server{
+		Addr:              s.addr,
+		Handler:           s.handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		IdleTimeout:       60 * time.Second,
+	}
+}
+
+// handler returns the HTTP routes.
+func (s *Server) handler() http.Handler {
+	static, _ := fs.Sub(staticFiles, "static")
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /", http.FileServerFS(static))
+	mux.HandleFunc("GET /api/v1/stats", s.handleStats)
+	mux.HandleFunc("GET /api/v1/samples", s.handleSamples)
+	mux.HandleFunc("GET /api/v1/stream", s.handleStream)
+	mux.HandleFunc("GET /api/v1/buckets", s.handleBuckets)
+	return mux
+}
+
+// handleStats serves the current statistics.
+func (s *Server) handleStats(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, s.statsResponse())
+}
+
+// statsResponse builds the stats payload from the latest update.
+func (s *Server) statsResponse() StatsResponse {
+	s.mu.RLock()
+	st, target, interval := s.stats, s.target, s.interval
+	s.mu.RUnlock()
+
+	return StatsResponse{
+		Target:         target,
+		Interval:       interval.String(),
+		Thresholds:     s.thresholds,
+		TotalSamples:   st.TotalSamples,
+		TotalTimeouts:  st.TotalTimeouts,
+		LossPercent:    st.LossPercent,
+		MinRTTMs:       st.MinRTTMs,
+		AvgRTTMs:       st.AvgRTTMs,
+		MaxRTTMs:       st.MaxRTTMs,
+		StdDevMs:       st.StdDevMs,
+		JitterMs:       st.JitterMs,
+		LastRTTMs:      st.LastRTTMs,
+		P50Ms:          st.Percentiles.P50,
+		P90Ms:          st.Percentiles.P90,
+		P95Ms:          st.Percentiles.P95,
+		P99Ms:          st.Percentiles.P99,
+		CurrentStreak:  st.CurrentStreak,
+		LossBursts:     st.LossBursts,
+		BrownoutBursts: st.BrownoutBursts,
+		InBrownout:     st.InBrownout,
+		UptimeSeconds:  st.UptimeSeconds,
+	}
+}
+
+// handleSamples serves samples newer than the "after" ID (default: all kept),
+// oldest first, up to "limit" (default and at most maxSamplesPerResponse).
+// With from, to or step it serves a time range instead, see handleRange.
+func (s *Server) handleSamples(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if isRangeQuery(q) {
+		s.handleRange(w, r)
+		return
+	}
+
+	limit := maxSamplesPerResponse
+	if v := q.Get("limit"); v != "" {
+		n, err := parseLimit(v)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	var after uint64
+	if v := q.Get("after"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid after parameter", http.StatusBadRequest)
+			return
+		}
+		after = n
+	}
+
+	s.mu.RLock()
+	resp := SamplesResponse{Samples: s.samplesAfter(after, limit), LastID: s.lastID}
+	s.mu.RUnlock()
+
+	writeJSON(w, resp)
+}
+
+// samplesAfter returns up to limit kept entries with ID > after, oldest first.
+// Callers must hold mu.
+func (s *Server) samplesAfter(after uint64, limit int) []Entry {
+	n := s.history.Len()
+	if n == 0 || after >= s.lastID {
+		return []Entry{}
+	}
+
+	// IDs are contiguous, so the position of "after" is computed directly
+	oldest := s.lastID - uint64(n) + 1
+	start := 0
+	if after >= oldest {
+		start = int(after - oldest + 1)
+	}
+	end := min(start+limit, n) - 1 // GetRange is inclusive
+	return s.history.GetRange(start, end)
+}
+
+// handleBuckets serves the last 24 hours rolled up into buckets of the
+// "bucket" width (default 1m), oldest first.
+func (s *Server) handleBuckets(w http.ResponseWriter, r *http.Request) {
+	width := time.Minute
+	if v := r.URL.Query().Get("bucket"); v != "" {
+		d, err := metrics.ParseWindow(v)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		width = d
+	}
+
+	s.mu.RLock()
+	agg, target := s.aggregator, s.target
+	s.mu.RUnlock()
+	if agg == nil {
+		http.Error(w, "buckets are not available", http.StatusNotFound)
+		return
+	}
+
+	resp := BucketsResponse{Target: target, Bucket: metrics.WindowName(width), Buckets: []BucketEntry{}}
+	for _, b := range agg.Buckets(width) {
+		resp.Buckets = append(resp.Buckets, BucketEntry{
+			Start:       b.Start,
+			Samples:     b.Samples,
+			Timeouts:    b.Timeouts,
+			LossPercent: b.LossPercent,
+			MinMs:       b.MinMs,
+			AvgMs:       b.AvgMs,
+			MaxMs:       b.MaxMs,
+			P95Ms:       b.P95Ms,
+		})
+	}
+	writeJSON(w, resp)
+}
+
+// writeJSON encodes v as the response body.
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(v)
+}
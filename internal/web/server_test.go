@@ -0,0 +1,148 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/types"
+	"github.com/pbv7/pingheat/internal/ui/colors"
+)
+
+func newTestServer() *Server {
+	return NewServer(":0", "example.com", time.Second, colors.DefaultThresholds())
+}
+
+func get(t *testing.T, h http.Handler, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestServerIndex(t *testing.T) {
+	rec := get(t, newTestServer().handler(), "/")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET / status = %d, want 200", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "<canvas") {
+		t.Fatalf("GET / body missing heatmap canvas")
+	}
+}
+
+func TestServerStats(t *testing.T) {
+	s := newTestServer()
+	s.Update(types.Sample{RTT: 12 * time.Millisecond}, metrics.Stats{
+		TotalSamples: 1,
+		AvgRTTMs:     12,
+		Percentiles:  metrics.Percentiles{P95: 12},
+	})
+
+	rec := get(t, s.handler(), "/api/v1/stats")
+	var resp StatsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode stats: %v", err)
+	}
+	if resp.Target != "example.com" || resp.TotalSamples != 1 || resp.P95Ms != 12 || resp.Interval != "1s" {
+		t.Fatalf("stats = %+v", resp)
+	}
+	if resp.Thresholds != colors.DefaultThresholds() {
+		t.Fatalf("thresholds = %+v, want defaults", resp.Thresholds)
+	}
+
+	s.SetInterval(500 * time.Millisecond)
+	if resp := s.statsResponse(); resp.Interval != "500ms" {
+		t.Fatalf("interval after SetInterval = %q, want 500ms", resp.Interval)
+	}
+}
+
+func TestServerSamplesAfter(t *testing.T) {
+	s := newTestServer()
+	for i := range 5 {
+		s.Update(types.Sample{Sequence: i, RTT: time.Duration(i) * time.Millisecond, Timeout: i == 2}, metrics.Stats{})
+	}
+
+	tests := []struct {
+		path    string
+		wantIDs []uint64
+	}{
+		{"/api/v1/samples", []uint64{1, 2, 3, 4, 5}},
+		{"/api/v1/samples?after=3", []uint64{4, 5}},
+		{"/api/v1/samples?after=5", []uint64{}},
+		{"/api/v1/samples?after=99", []uint64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			var resp SamplesResponse
+			if err := json.NewDecoder(get(t, s.handler(), tt.path).Body).Decode(&resp); err != nil {
+				t.Fatalf("decode samples: %v", err)
+			}
+			if resp.LastID != 5 {
+				t.Fatalf("LastID = %d, want 5", resp.LastID)
+			}
+			if len(resp.Samples) != len(tt.wantIDs) {
+				t.Fatalf("got %d samples, want %d", len(resp.Samples), len(tt.wantIDs))
+			}
+			for i, id := range tt.wantIDs {
+				if resp.Samples[i].ID != id {
+					t.Fatalf("samples[%d].ID = %d, want %d", i, resp.Samples[i].ID, id)
+				}
+			}
+		})
+	}
+}
+
+func TestServerSamplesAfterEviction(t *testing.T) {
+	s := newTestServer()
+	for range DefaultHistory + 10 {
+		s.Update(types.Sample{RTT: time.Millisecond}, metrics.Stats{})
+	}
+
+	s.mu.RLock()
+	entries := s.samplesAfter(5, maxSamplesPerResponse)
+	s.mu.RUnlock()
+
+	// IDs 1-10 were evicted; the oldest kept entry is 11
+	if len(entries) != maxSamplesPerResponse || entries[0].ID != 11 {
+		t.Fatalf("got %d entries starting at %d, want %d starting at 11", len(entries), entries[0].ID, maxSamplesPerResponse)
+	}
+}
+
+func TestServerSamplesInvalidAfter(t *testing.T) {
+	rec := get(t, newTestServer().handler(), "/api/v1/samples?after=abc")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+}
+
+func TestServerBuckets(t *testing.T) {
+	s := newTestServer()
+	if rec := get(t, s.handler(), "/api/v1/buckets"); rec.Code != http.StatusNotFound {
+		t.Fatalf("without aggregator status = %d, want 404", rec.Code)
+	}
+
+	agg := metrics.NewAggregator()
+	s.SetAggregator(agg)
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	for m := range 10 {
+		agg.Add(types.Sample{Timestamp: base.Add(time.Duration(m) * time.Minute), RTT: 5 * time.Millisecond})
+	}
+
+	rec := get(t, s.handler(), "/api/v1/buckets?bucket=5m")
+	var resp BucketsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode buckets: %v", err)
+	}
+	if resp.Bucket != "5m" || len(resp.Buckets) != 2 || resp.Buckets[0].Samples != 5 || resp.Buckets[1].AvgMs != 5 {
+		t.Fatalf("buckets = %+v", resp)
+	}
+
+	if rec := get(t, s.handler(), "/api/v1/buckets?bucket=10s"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid bucket status = %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,159 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// streamStatsInterval is how often /api/v1/stream pushes a stats event.
+	streamStatsInterval = time.Second
+
+	// streamBuffer is the number of samples queued per stream client. A client
+	// that falls further behind is disconnected and catches up by reconnecting
+	// with Last-Event-ID.
+	streamBuffer = 64
+)
+
+// subscribe registers a new stream client and returns its sample channel.
+func (s *Server) subscribe() chan Entry {
+	ch := make(chan Entry, streamBuffer)
+	s.mu.Lock()
+	s.streams[ch] = struct{}{}
+	s.mu.Unlock()
+	return ch
+}
+
+// unsubscribe removes a stream client if it is still registered.
+func (s *Server) unsubscribe(ch chan Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.streams[ch]; ok {
+		delete(s.streams, ch)
+		close(ch)
+	}
+}
+
+// publish delivers an entry to every stream client. Callers must hold mu.
+func (s *Server) publish(entry Entry) {
+	for ch := range s.streams {
+		select {
+		case ch <- entry:
+		default:
+			// Slow client: drop it rather than block the sample pipeline
+			delete(s.streams, ch)
+			close(ch)
+		}
+	}
+}
+
+// handleStream serves Server-Sent Events: a "sample" event (with the entry ID
+// as the event ID) for every new sample and a "stats" event every second.
+//
+// A reconnecting client that sends Last-Event-ID first receives the samples it
+// missed that are still kept. A new client may ask for recent history with
+// ?backlog=N.
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	backlog, err := parseBacklog(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Subscribe before reading history so no sample falls in between
+	ch := s.subscribe()
+	defer s.unsubscribe(ch)
+
+	s.mu.RLock()
+	lastID := s.lastID
+	var replay []Entry
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		if after, err := strconv.ParseUint(v, 10, 64); err == nil {
+			replay = s.samplesAfter(after, DefaultHistory)
+		}
+	} else if backlog > 0 && lastID > 0 {
+		replay = s.samplesAfter(lastID-min(uint64(backlog), lastID), DefaultHistory)
+	}
+	s.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	sent := uint64(0)
+	for _, e := range replay {
+		if err := writeEvent(w, "sample", e.ID, e); err != nil {
+			return
+		}
+		sent = e.ID
+	}
+	if err := writeEvent(w, "stats", 0, s.statsResponse()); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(streamStatsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-ch:
+			if !ok {
+				return
+			}
+			if e.ID <= sent {
+				continue // Already delivered as part of the replay
+			}
+			if err := writeEvent(w, "sample", e.ID, e); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-ticker.C:
+			if err := writeEvent(w, "stats", 0, s.statsResponse()); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// parseBacklog reads the optional backlog query parameter.
+func parseBacklog(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("backlog")
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid backlog parameter %q", v)
+	}
+	return min(n, DefaultHistory), nil
+}
+
+// writeEvent writes one SSE event. An id of 0 omits the id field so stats
+// events do not move the client's Last-Event-ID.
+func writeEvent(w http.ResponseWriter, event string, id uint64, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if id > 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
@@ -0,0 +1,137 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/metrics"
+	"github.com/pbv7/pingheat/internal/types"
+)
+
+// sseEvent is a parsed Server-Sent Event.
+type sseEvent struct {
+	id, event, data string
+}
+
+// openStream connects to the stream endpoint and returns a function that
+// reads the next event.
+func openStream(t *testing.T, url string, header http.Header) func() sseEvent {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	return func() sseEvent {
+		t.Helper()
+		var ev sseEvent
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("read event: %v", err)
+			}
+			line = strings.TrimRight(line, "\n")
+			switch {
+			case line == "":
+				return ev
+			case strings.HasPrefix(line, "id: "):
+				ev.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				ev.event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				ev.data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}
+}
+
+func TestStreamBacklogAndLiveSamples(t *testing.T) {
+	s := newTestServer()
+	for range 3 {
+		s.Update(types.Sample{RTT: time.Millisecond}, metrics.Stats{TotalSamples: 1})
+	}
+	srv := httptest.NewServer(s.handler())
+	t.Cleanup(srv.Close) // Runs after the stream body is closed
+
+	next := openStream(t, srv.URL+"/api/v1/stream?backlog=2", nil)
+
+	for _, wantID := range []string{"2", "3"} {
+		if ev := next(); ev.event != "sample" || ev.id != wantID {
+			t.Fatalf("backlog event = %+v, want sample %s", ev, wantID)
+		}
+	}
+	if ev := next(); ev.event != "stats" || !strings.Contains(ev.data, `"target":"example.com"`) {
+		t.Fatalf("event = %+v, want initial stats", ev)
+	}
+
+	s.Update(types.Sample{RTT: 5 * time.Millisecond}, metrics.Stats{})
+	ev := next()
+	for ev.event == "stats" {
+		ev = next()
+	}
+	if ev.id != "4" || !strings.Contains(ev.data, `"rtt_ms":5`) {
+		t.Fatalf("live event = %+v, want sample 4", ev)
+	}
+}
+
+func TestStreamResumesFromLastEventID(t *testing.T) {
+	s := newTestServer()
+	for range 5 {
+		s.Update(types.Sample{RTT: time.Millisecond}, metrics.Stats{})
+	}
+	srv := httptest.NewServer(s.handler())
+	t.Cleanup(srv.Close) // Runs after the stream body is closed
+
+	next := openStream(t, srv.URL+"/api/v1/stream", http.Header{"Last-Event-ID": {"3"}})
+
+	for _, wantID := range []string{"4", "5"} {
+		if ev := next(); ev.event != "sample" || ev.id != wantID {
+			t.Fatalf("replayed event = %+v, want sample %s", ev, wantID)
+		}
+	}
+}
+
+func TestStreamInvalidBacklog(t *testing.T) {
+	rec := get(t, newTestServer().handler(), "/api/v1/stream?backlog=-1")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+}
+
+func TestPublishDropsSlowClients(t *testing.T) {
+	s := newTestServer()
+	ch := s.subscribe()
+
+	for range streamBuffer + 1 {
+		s.Update(types.Sample{}, metrics.Stats{})
+	}
+
+	// The buffered entries are still readable, then the channel is closed
+	for range streamBuffer {
+		<-ch
+	}
+	if _, ok := <-ch; ok {
+		t.Fatalf("expected slow client channel to be closed")
+	}
+	s.unsubscribe(ch) // Must not panic on an already dropped client
+}
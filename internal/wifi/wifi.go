@@ -0,0 +1,238 @@
+// Package wifi reads the signal of the Wi-Fi link the host is connected
+// through, so latency and loss can be told apart from radio problems.
+package wifi
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pbv7/pingheat/internal/log"
+)
+
+// ErrNotFound is returned when the host has no connected Wi-Fi interface, or
+// pingheat cannot read it on this platform.
+var ErrNotFound = errors.New("no Wi-Fi link found")
+
+// PollInterval is how often a Monitor reads the link. Reading it runs a
+// system tool on most platforms, so not at every probe.
+const PollInterval = 5 * time.Second
+
+// Link describes a connected Wi-Fi link. Values a platform does not report
+// are zero.
+type Link struct {
+	Interface string
+	SSID      string
+	Signal    int // RSSI in dBm
+	Noise     int // Noise floor in dBm
+	Channel   int
+}
+
+// SNR returns the signal-to-noise ratio in dB, or 0 without a noise floor.
+func (l Link) SNR() int {
+	if l.Signal == 0 || l.Noise == 0 {
+		return 0
+	}
+	return l.Signal - l.Noise
+}
+
+// Read returns the current Wi-Fi link.
+func Read(ctx context.Context) (Link, error) {
+	return readLink(ctx)
+}
+
+// Monitor polls the Wi-Fi link in the background and keeps the latest read.
+type Monitor struct {
+	interval time.Duration
+	read     func(context.Context) (Link, error)
+	logger   *slog.Logger
+
+	mu   sync.RWMutex
+	link Link
+}
+
+// NewMonitor creates a Monitor that reads the link every interval.
+func NewMonitor(interval time.Duration) *Monitor {
+	return &Monitor{interval: interval, read: Read, logger: log.Discard()}
+}
+
+// SetLogger sets the logger for read failures. Call it before Run.
+func (m *Monitor) SetLogger(logger *slog.Logger) {
+	m.logger = logger
+}
+
+// Link returns the latest read, or a zero Link while disconnected.
+func (m *Monitor) Link() Link {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.link
+}
+
+// Run reads the link until ctx is cancelled. Failures clear the link and are
+// logged when they change, not at every poll.
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	var lastErr string
+	for {
+		link, err := m.read(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			link = Link{}
+			if err.Error() != lastErr {
+				m.logger.Warn("cannot read Wi-Fi link", "err", err)
+			}
+			lastErr = err.Error()
+		} else {
+			lastErr = ""
+		}
+		m.mu.Lock()
+		m.link = link
+		m.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// parseProcWireless returns the first interface of Linux's
+// /proc/net/wireless with its signal and noise level in dBm. Drivers that
+// do not report noise write -256.
+func parseProcWireless(r io.Reader) (Link, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		name, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		f := strings.Fields(rest)
+		if len(f) < 4 {
+			continue
+		}
+		signal, err := strconv.ParseFloat(strings.TrimSuffix(f[2], "."), 64)
+		if err != nil || signal >= 0 {
+			continue // Header line, or a driver reporting a relative level
+		}
+		link := Link{Interface: strings.TrimSpace(name), Signal: int(signal)}
+		if noise, err := strconv.ParseFloat(strings.TrimSuffix(f[3], "."), 64); err == nil && noise < 0 && noise > -256 {
+			link.Noise = int(noise)
+		}
+		return link, nil
+	}
+	return Link{}, ErrNotFound
+}
+
+// parseIWLink adds the SSID and channel from the output of Linux's
+// "iw dev IFACE link" to link.
+func parseIWLink(out string, link Link) Link {
+	for line := range strings.Lines(out) {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "SSID":
+			link.SSID = value
+		case "freq":
+			// Newer iw versions print the frequency with a decimal part
+			mhz, err := strconv.ParseFloat(value, 64)
+			if err == nil {
+				link.Channel = channel(int(mhz))
+			}
+		}
+	}
+	return link
+}
+
+// channel returns the Wi-Fi channel of a center frequency in MHz.
+func channel(mhz int) int {
+	switch {
+	case mhz == 2484:
+		return 14
+	case mhz >= 2412 && mhz < 2484:
+		return (mhz - 2407) / 5
+	case mhz >= 5955 && mhz <= 7115:
+		return (mhz - 5950) / 5
+	case mhz >= 5000 && mhz < 5955:
+		return (mhz - 5000) / 5
+	default:
+		return 0
+	}
+}
+
+// parseAirport returns the link described by the output of macOS's
+// "airport -I".
+func parseAirport(out string) (Link, error) {
+	var link Link
+	for line := range strings.Lines(out) {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "agrCtlRSSI":
+			link.Signal, _ = strconv.Atoi(value)
+		case "agrCtlNoise":
+			link.Noise, _ = strconv.Atoi(value)
+		case "SSID":
+			link.SSID = value
+		case "channel":
+			// Such as 36,80: the primary channel and the channel width
+			primary, _, _ := strings.Cut(value, ",")
+			link.Channel, _ = strconv.Atoi(primary)
+		}
+	}
+	// Disconnected: "AirPort: Off" or an RSSI of 0
+	if link.Signal == 0 {
+		return Link{}, ErrNotFound
+	}
+	return link, nil
+}
+
+// parseNetsh returns the first connected interface in the output of
+// Windows' "netsh wlan show interfaces". Windows reports the signal as a
+// quality percentage, converted to dBm on its linear scale from -100dBm
+// (0%) to -50dBm (100%); it does not report noise.
+func parseNetsh(out string) (Link, error) {
+	var link Link
+	for line := range strings.Lines(out) {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch key {
+		case "Name":
+			if link.Signal != 0 {
+				return link, nil // The next interface starts
+			}
+			link = Link{Interface: value}
+		case "SSID":
+			link.SSID = value
+		case "Channel":
+			link.Channel, _ = strconv.Atoi(value)
+		case "Signal":
+			if pct, err := strconv.Atoi(strings.TrimSuffix(value, "%")); err == nil {
+				link.Signal = pct/2 - 100
+			}
+		}
+	}
+	if link.Signal == 0 {
+		return Link{}, ErrNotFound
+	}
+	return link, nil
+}
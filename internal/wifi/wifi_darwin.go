@@ -0,0 +1,19 @@
+package wifi
+
+import (
+	"context"
+	"os/exec"
+)
+
+// airport is the path of the airport utility, which macOS 14.4 and later
+// no longer ship.
+const airport = "/System/Library/PrivateFrameworks/Apple80211.framework/Versions/Current/Resources/airport"
+
+// readLink asks airport(8) for the current link.
+func readLink(ctx context.Context) (Link, error) {
+	out, err := exec.CommandContext(ctx, airport, "-I").Output()
+	if err != nil {
+		return Link{}, ErrNotFound
+	}
+	return parseAirport(string(out))
+}
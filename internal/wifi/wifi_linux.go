@@ -0,0 +1,25 @@
+package wifi
+
+import (
+	"context"
+	"os"
+	"os/exec"
+)
+
+// readLink reads signal and noise from /proc/net/wireless, and the SSID and
+// channel from iw(8) when it is installed.
+func readLink(ctx context.Context) (Link, error) {
+	f, err := os.Open("/proc/net/wireless")
+	if err != nil {
+		return Link{}, ErrNotFound
+	}
+	defer f.Close()
+	link, err := parseProcWireless(f)
+	if err != nil {
+		return Link{}, err
+	}
+	if out, err := exec.CommandContext(ctx, "iw", "dev", link.Interface, "link").Output(); err == nil {
+		link = parseIWLink(string(out), link)
+	}
+	return link, nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !windows
+
+package wifi
+
+import "context"
+
+// readLink is not implemented on this platform.
+func readLink(context.Context) (Link, error) {
+	return Link{}, ErrNotFound
+}
@@ -0,0 +1,126 @@
+package wifi
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseProcWireless(t *testing.T) {
+	table := `Inter-| sta-|   Quality        |   Discarded packets               | Missed | WE
+ face | tus | link level noise |  nwid  crypt   frag  retry   misc | beacon | 22
+ wlan0: 0000   54.  -56.  -256        0      0      0      0      0        0
+`
+	link, err := parseProcWireless(strings.NewReader(table))
+	want := Link{Interface: "wlan0", Signal: -56}
+	if err != nil || link != want {
+		t.Fatalf("parseProcWireless() = %+v, %v, want %+v without the unknown noise", link, err, want)
+	}
+
+	withNoise := strings.Replace(table, "-256", "-92.", 1)
+	if link, _ := parseProcWireless(strings.NewReader(withNoise)); link.Noise != -92 || link.SNR() != 36 {
+		t.Fatalf("parseProcWireless() = %+v, want noise -92 and SNR 36", link)
+	}
+
+	header := strings.Join(strings.SplitN(table, "\n", 3)[:2], "\n")
+	if _, err := parseProcWireless(strings.NewReader(header)); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("parseProcWireless() without interfaces error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestParseIWLink(t *testing.T) {
+	out := `Connected to 00:11:22:33:44:55 (on wlan0)
+	SSID: home
+	freq: 5180.0
+	signal: -56 dBm
+`
+	link := parseIWLink(out, Link{Interface: "wlan0", Signal: -56})
+	if link.SSID != "home" || link.Channel != 36 {
+		t.Fatalf("parseIWLink() = %+v, want SSID home on channel 36", link)
+	}
+}
+
+func TestChannel(t *testing.T) {
+	tests := map[int]int{2412: 1, 2437: 6, 2484: 14, 5180: 36, 5825: 165, 5955: 1, 6115: 33, 900: 0}
+	for mhz, want := range tests {
+		if got := channel(mhz); got != want {
+			t.Fatalf("channel(%d) = %d, want %d", mhz, got, want)
+		}
+	}
+}
+
+func TestParseAirport(t *testing.T) {
+	out := `     agrCtlRSSI: -61
+     agrExtRSSI: 0
+    agrCtlNoise: -94
+    agrExtNoise: 0
+          state: running
+           SSID: office
+        channel: 149,80
+`
+	link, err := parseAirport(out)
+	want := Link{SSID: "office", Signal: -61, Noise: -94, Channel: 149}
+	if err != nil || link != want {
+		t.Fatalf("parseAirport() = %+v, %v, want %+v", link, err, want)
+	}
+	if _, err := parseAirport("AirPort: Off\n"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("parseAirport() while off error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestParseNetsh(t *testing.T) {
+	out := `
+There are 2 interfaces on the system:
+
+    Name                   : Wi-Fi
+    State                  : connected
+    SSID                   : home
+    Channel                : 44
+    Signal                 : 80%
+
+    Name                   : Wi-Fi 2
+    State                  : connected
+    SSID                   : guest
+    Signal                 : 40%
+`
+	link, err := parseNetsh(out)
+	want := Link{Interface: "Wi-Fi", SSID: "home", Signal: -60, Channel: 44}
+	if err != nil || link != want {
+		t.Fatalf("parseNetsh() = %+v, %v, want %+v", link, err, want)
+	}
+	disconnected := "    Name                   : Wi-Fi\n    State                  : disconnected\n"
+	if _, err := parseNetsh(disconnected); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("parseNetsh() while disconnected error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMonitorRun(t *testing.T) {
+	reads := make(chan struct{}, 10)
+	calls := 0
+	m := NewMonitor(time.Millisecond)
+	m.read = func(context.Context) (Link, error) {
+		calls++
+		defer func() { reads <- struct{}{} }()
+		if calls == 1 {
+			return Link{Signal: -50}, nil
+		}
+		return Link{}, ErrNotFound
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.Run(ctx)
+	}()
+	<-reads
+	<-reads // The failed read has been stored once the next one starts
+	<-reads
+	cancel()
+	<-done
+	if link := m.Link(); link != (Link{}) {
+		t.Fatalf("Link() after a failed read = %+v, want none", link)
+	}
+}
@@ -0,0 +1,15 @@
+package wifi
+
+import (
+	"context"
+	"os/exec"
+)
+
+// readLink asks netsh for the first connected wireless interface.
+func readLink(ctx context.Context) (Link, error) {
+	out, err := exec.CommandContext(ctx, "netsh", "wlan", "show", "interfaces").Output()
+	if err != nil {
+		return Link{}, ErrNotFound // The WLAN service is not running
+	}
+	return parseNetsh(string(out))
+}
@@ -0,0 +1,912 @@
+// Control API for a long-running pingheat instance, enabled with -grpc.
+//
+// Regenerate the Go code with `make proto`.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: pkg/api/controlv1/control.proto
+
+package controlv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Probe struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Id       string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Target   string                 `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	Interval *durationpb.Duration   `protobuf:"bytes,3,opt,name=interval,proto3" json:"interval,omitempty"`
+	// True for the probe started from the command line.
+	Primary       bool                   `protobuf:"varint,4,opt,name=primary,proto3" json:"primary,omitempty"`
+	StartedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Probe) Reset() {
+	*x = Probe{}
+	mi := &file_pkg_api_controlv1_control_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Probe) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Probe) ProtoMessage() {}
+
+func (x *Probe) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_controlv1_control_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Probe.ProtoReflect.Descriptor instead.
+func (*Probe) Descriptor() ([]byte, []int) {
+	return file_pkg_api_controlv1_control_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Probe) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Probe) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *Probe) GetInterval() *durationpb.Duration {
+	if x != nil {
+		return x.Interval
+	}
+	return nil
+}
+
+func (x *Probe) GetPrimary() bool {
+	if x != nil {
+		return x.Primary
+	}
+	return false
+}
+
+func (x *Probe) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+type ListProbesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListProbesRequest) Reset() {
+	*x = ListProbesRequest{}
+	mi := &file_pkg_api_controlv1_control_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListProbesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListProbesRequest) ProtoMessage() {}
+
+func (x *ListProbesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_controlv1_control_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListProbesRequest.ProtoReflect.Descriptor instead.
+func (*ListProbesRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_controlv1_control_proto_rawDescGZIP(), []int{1}
+}
+
+type ListProbesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Probes        []*Probe               `protobuf:"bytes,1,rep,name=probes,proto3" json:"probes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListProbesResponse) Reset() {
+	*x = ListProbesResponse{}
+	mi := &file_pkg_api_controlv1_control_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListProbesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListProbesResponse) ProtoMessage() {}
+
+func (x *ListProbesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_controlv1_control_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListProbesResponse.ProtoReflect.Descriptor instead.
+func (*ListProbesResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_controlv1_control_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListProbesResponse) GetProbes() []*Probe {
+	if x != nil {
+		return x.Probes
+	}
+	return nil
+}
+
+type StartProbeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Host name or IP address to ping.
+	Target string `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	// Ping interval; defaults to 1s. Must be between 100ms and 1h.
+	Interval      *durationpb.Duration `protobuf:"bytes,2,opt,name=interval,proto3" json:"interval,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartProbeRequest) Reset() {
+	*x = StartProbeRequest{}
+	mi := &file_pkg_api_controlv1_control_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartProbeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartProbeRequest) ProtoMessage() {}
+
+func (x *StartProbeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_controlv1_control_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartProbeRequest.ProtoReflect.Descriptor instead.
+func (*StartProbeRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_controlv1_control_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *StartProbeRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *StartProbeRequest) GetInterval() *durationpb.Duration {
+	if x != nil {
+		return x.Interval
+	}
+	return nil
+}
+
+type StartProbeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Probe         *Probe                 `protobuf:"bytes,1,opt,name=probe,proto3" json:"probe,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartProbeResponse) Reset() {
+	*x = StartProbeResponse{}
+	mi := &file_pkg_api_controlv1_control_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartProbeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartProbeResponse) ProtoMessage() {}
+
+func (x *StartProbeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_controlv1_control_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartProbeResponse.ProtoReflect.Descriptor instead.
+func (*StartProbeResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_controlv1_control_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *StartProbeResponse) GetProbe() *Probe {
+	if x != nil {
+		return x.Probe
+	}
+	return nil
+}
+
+type StopProbeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StopProbeRequest) Reset() {
+	*x = StopProbeRequest{}
+	mi := &file_pkg_api_controlv1_control_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopProbeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopProbeRequest) ProtoMessage() {}
+
+func (x *StopProbeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_controlv1_control_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopProbeRequest.ProtoReflect.Descriptor instead.
+func (*StopProbeRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_controlv1_control_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *StopProbeRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type StopProbeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StopProbeResponse) Reset() {
+	*x = StopProbeResponse{}
+	mi := &file_pkg_api_controlv1_control_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopProbeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopProbeResponse) ProtoMessage() {}
+
+func (x *StopProbeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_controlv1_control_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopProbeResponse.ProtoReflect.Descriptor instead.
+func (*StopProbeResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_controlv1_control_proto_rawDescGZIP(), []int{6}
+}
+
+type GetStatsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Probe ID; empty selects the primary probe.
+	Id            string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsRequest) Reset() {
+	*x = GetStatsRequest{}
+	mi := &file_pkg_api_controlv1_control_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsRequest) ProtoMessage() {}
+
+func (x *GetStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_controlv1_control_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetStatsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_controlv1_control_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetStatsRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetStatsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stats         *Stats                 `protobuf:"bytes,1,opt,name=stats,proto3" json:"stats,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsResponse) Reset() {
+	*x = GetStatsResponse{}
+	mi := &file_pkg_api_controlv1_control_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsResponse) ProtoMessage() {}
+
+func (x *GetStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_controlv1_control_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetStatsResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_api_controlv1_control_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetStatsResponse) GetStats() *Stats {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+// Stats mirrors the statistics shown in the TUI. Latencies are in milliseconds.
+type Stats struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	TotalSamples   int64                  `protobuf:"varint,1,opt,name=total_samples,json=totalSamples,proto3" json:"total_samples,omitempty"`
+	TotalSuccess   int64                  `protobuf:"varint,2,opt,name=total_success,json=totalSuccess,proto3" json:"total_success,omitempty"`
+	TotalTimeouts  int64                  `protobuf:"varint,3,opt,name=total_timeouts,json=totalTimeouts,proto3" json:"total_timeouts,omitempty"`
+	LossPercent    float64                `protobuf:"fixed64,4,opt,name=loss_percent,json=lossPercent,proto3" json:"loss_percent,omitempty"`
+	MinRttMs       float64                `protobuf:"fixed64,5,opt,name=min_rtt_ms,json=minRttMs,proto3" json:"min_rtt_ms,omitempty"`
+	AvgRttMs       float64                `protobuf:"fixed64,6,opt,name=avg_rtt_ms,json=avgRttMs,proto3" json:"avg_rtt_ms,omitempty"`
+	MaxRttMs       float64                `protobuf:"fixed64,7,opt,name=max_rtt_ms,json=maxRttMs,proto3" json:"max_rtt_ms,omitempty"`
+	StddevMs       float64                `protobuf:"fixed64,8,opt,name=stddev_ms,json=stddevMs,proto3" json:"stddev_ms,omitempty"`
+	JitterMs       float64                `protobuf:"fixed64,9,opt,name=jitter_ms,json=jitterMs,proto3" json:"jitter_ms,omitempty"`
+	LastRttMs      float64                `protobuf:"fixed64,10,opt,name=last_rtt_ms,json=lastRttMs,proto3" json:"last_rtt_ms,omitempty"`
+	P50Ms          float64                `protobuf:"fixed64,11,opt,name=p50_ms,json=p50Ms,proto3" json:"p50_ms,omitempty"`
+	P90Ms          float64                `protobuf:"fixed64,12,opt,name=p90_ms,json=p90Ms,proto3" json:"p90_ms,omitempty"`
+	P95Ms          float64                `protobuf:"fixed64,13,opt,name=p95_ms,json=p95Ms,proto3" json:"p95_ms,omitempty"`
+	P99Ms          float64                `protobuf:"fixed64,14,opt,name=p99_ms,json=p99Ms,proto3" json:"p99_ms,omitempty"`
+	CurrentStreak  int64                  `protobuf:"varint,15,opt,name=current_streak,json=currentStreak,proto3" json:"current_streak,omitempty"`
+	LossBursts     int64                  `protobuf:"varint,16,opt,name=loss_bursts,json=lossBursts,proto3" json:"loss_bursts,omitempty"`
+	BrownoutBursts int64                  `protobuf:"varint,17,opt,name=brownout_bursts,json=brownoutBursts,proto3" json:"brownout_bursts,omitempty"`
+	InBrownout     bool                   `protobuf:"varint,18,opt,name=in_brownout,json=inBrownout,proto3" json:"in_brownout,omitempty"`
+	UptimeSeconds  float64                `protobuf:"fixed64,19,opt,name=uptime_seconds,json=uptimeSeconds,proto3" json:"uptime_seconds,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Stats) Reset() {
+	*x = Stats{}
+	mi := &file_pkg_api_controlv1_control_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Stats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Stats) ProtoMessage() {}
+
+func (x *Stats) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_controlv1_control_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Stats.ProtoReflect.Descriptor instead.
+func (*Stats) Descriptor() ([]byte, []int) {
+	return file_pkg_api_controlv1_control_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Stats) GetTotalSamples() int64 {
+	if x != nil {
+		return x.TotalSamples
+	}
+	return 0
+}
+
+func (x *Stats) GetTotalSuccess() int64 {
+	if x != nil {
+		return x.TotalSuccess
+	}
+	return 0
+}
+
+func (x *Stats) GetTotalTimeouts() int64 {
+	if x != nil {
+		return x.TotalTimeouts
+	}
+	return 0
+}
+
+func (x *Stats) GetLossPercent() float64 {
+	if x != nil {
+		return x.LossPercent
+	}
+	return 0
+}
+
+func (x *Stats) GetMinRttMs() float64 {
+	if x != nil {
+		return x.MinRttMs
+	}
+	return 0
+}
+
+func (x *Stats) GetAvgRttMs() float64 {
+	if x != nil {
+		return x.AvgRttMs
+	}
+	return 0
+}
+
+func (x *Stats) GetMaxRttMs() float64 {
+	if x != nil {
+		return x.MaxRttMs
+	}
+	return 0
+}
+
+func (x *Stats) GetStddevMs() float64 {
+	if x != nil {
+		return x.StddevMs
+	}
+	return 0
+}
+
+func (x *Stats) GetJitterMs() float64 {
+	if x != nil {
+		return x.JitterMs
+	}
+	return 0
+}
+
+func (x *Stats) GetLastRttMs() float64 {
+	if x != nil {
+		return x.LastRttMs
+	}
+	return 0
+}
+
+func (x *Stats) GetP50Ms() float64 {
+	if x != nil {
+		return x.P50Ms
+	}
+	return 0
+}
+
+func (x *Stats) GetP90Ms() float64 {
+	if x != nil {
+		return x.P90Ms
+	}
+	return 0
+}
+
+func (x *Stats) GetP95Ms() float64 {
+	if x != nil {
+		return x.P95Ms
+	}
+	return 0
+}
+
+func (x *Stats) GetP99Ms() float64 {
+	if x != nil {
+		return x.P99Ms
+	}
+	return 0
+}
+
+func (x *Stats) GetCurrentStreak() int64 {
+	if x != nil {
+		return x.CurrentStreak
+	}
+	return 0
+}
+
+func (x *Stats) GetLossBursts() int64 {
+	if x != nil {
+		return x.LossBursts
+	}
+	return 0
+}
+
+func (x *Stats) GetBrownoutBursts() int64 {
+	if x != nil {
+		return x.BrownoutBursts
+	}
+	return 0
+}
+
+func (x *Stats) GetInBrownout() bool {
+	if x != nil {
+		return x.InBrownout
+	}
+	return false
+}
+
+func (x *Stats) GetUptimeSeconds() float64 {
+	if x != nil {
+		return x.UptimeSeconds
+	}
+	return 0
+}
+
+type StreamSamplesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Probe ID; empty selects the primary probe.
+	Id            string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamSamplesRequest) Reset() {
+	*x = StreamSamplesRequest{}
+	mi := &file_pkg_api_controlv1_control_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamSamplesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamSamplesRequest) ProtoMessage() {}
+
+func (x *StreamSamplesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_controlv1_control_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamSamplesRequest.ProtoReflect.Descriptor instead.
+func (*StreamSamplesRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_api_controlv1_control_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *StreamSamplesRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type Sample struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ProbeId   string                 `protobuf:"bytes,1,opt,name=probe_id,json=probeId,proto3" json:"probe_id,omitempty"`
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Seq       int64                  `protobuf:"varint,3,opt,name=seq,proto3" json:"seq,omitempty"`
+	// Round-trip time; unset for timeouts.
+	Rtt           *durationpb.Duration `protobuf:"bytes,4,opt,name=rtt,proto3" json:"rtt,omitempty"`
+	Timeout       bool                 `protobuf:"varint,5,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Sample) Reset() {
+	*x = Sample{}
+	mi := &file_pkg_api_controlv1_control_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Sample) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Sample) ProtoMessage() {}
+
+func (x *Sample) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_api_controlv1_control_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Sample.ProtoReflect.Descriptor instead.
+func (*Sample) Descriptor() ([]byte, []int) {
+	return file_pkg_api_controlv1_control_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Sample) GetProbeId() string {
+	if x != nil {
+		return x.ProbeId
+	}
+	return ""
+}
+
+func (x *Sample) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Sample) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Sample) GetRtt() *durationpb.Duration {
+	if x != nil {
+		return x.Rtt
+	}
+	return nil
+}
+
+func (x *Sample) GetTimeout() bool {
+	if x != nil {
+		return x.Timeout
+	}
+	return false
+}
+
+var File_pkg_api_controlv1_control_proto protoreflect.FileDescriptor
+
+const file_pkg_api_controlv1_control_proto_rawDesc = "" +
+	"\n" +
+	"\x1fpkg/api/controlv1/control.proto\x12\x13pingheat.control.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xbb\x01\n" +
+	"\x05Probe\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06target\x18\x02 \x01(\tR\x06target\x125\n" +
+	"\binterval\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\binterval\x12\x18\n" +
+	"\aprimary\x18\x04 \x01(\bR\aprimary\x129\n" +
+	"\n" +
+	"started_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\"\x13\n" +
+	"\x11ListProbesRequest\"H\n" +
+	"\x12ListProbesResponse\x122\n" +
+	"\x06probes\x18\x01 \x03(\v2\x1a.pingheat.control.v1.ProbeR\x06probes\"b\n" +
+	"\x11StartProbeRequest\x12\x16\n" +
+	"\x06target\x18\x01 \x01(\tR\x06target\x125\n" +
+	"\binterval\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\binterval\"F\n" +
+	"\x12StartProbeResponse\x120\n" +
+	"\x05probe\x18\x01 \x01(\v2\x1a.pingheat.control.v1.ProbeR\x05probe\"\"\n" +
+	"\x10StopProbeRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x13\n" +
+	"\x11StopProbeResponse\"!\n" +
+	"\x0fGetStatsRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"D\n" +
+	"\x10GetStatsResponse\x120\n" +
+	"\x05stats\x18\x01 \x01(\v2\x1a.pingheat.control.v1.StatsR\x05stats\"\xe4\x04\n" +
+	"\x05Stats\x12#\n" +
+	"\rtotal_samples\x18\x01 \x01(\x03R\ftotalSamples\x12#\n" +
+	"\rtotal_success\x18\x02 \x01(\x03R\ftotalSuccess\x12%\n" +
+	"\x0etotal_timeouts\x18\x03 \x01(\x03R\rtotalTimeouts\x12!\n" +
+	"\floss_percent\x18\x04 \x01(\x01R\vlossPercent\x12\x1c\n" +
+	"\n" +
+	"min_rtt_ms\x18\x05 \x01(\x01R\bminRttMs\x12\x1c\n" +
+	"\n" +
+	"avg_rtt_ms\x18\x06 \x01(\x01R\bavgRttMs\x12\x1c\n" +
+	"\n" +
+	"max_rtt_ms\x18\a \x01(\x01R\bmaxRttMs\x12\x1b\n" +
+	"\tstddev_ms\x18\b \x01(\x01R\bstddevMs\x12\x1b\n" +
+	"\tjitter_ms\x18\t \x01(\x01R\bjitterMs\x12\x1e\n" +
+	"\vlast_rtt_ms\x18\n" +
+	" \x01(\x01R\tlastRttMs\x12\x15\n" +
+	"\x06p50_ms\x18\v \x01(\x01R\x05p50Ms\x12\x15\n" +
+	"\x06p90_ms\x18\f \x01(\x01R\x05p90Ms\x12\x15\n" +
+	"\x06p95_ms\x18\r \x01(\x01R\x05p95Ms\x12\x15\n" +
+	"\x06p99_ms\x18\x0e \x01(\x01R\x05p99Ms\x12%\n" +
+	"\x0ecurrent_streak\x18\x0f \x01(\x03R\rcurrentStreak\x12\x1f\n" +
+	"\vloss_bursts\x18\x10 \x01(\x03R\n" +
+	"lossBursts\x12'\n" +
+	"\x0fbrownout_bursts\x18\x11 \x01(\x03R\x0ebrownoutBursts\x12\x1f\n" +
+	"\vin_brownout\x18\x12 \x01(\bR\n" +
+	"inBrownout\x12%\n" +
+	"\x0euptime_seconds\x18\x13 \x01(\x01R\ruptimeSeconds\"&\n" +
+	"\x14StreamSamplesRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xb6\x01\n" +
+	"\x06Sample\x12\x19\n" +
+	"\bprobe_id\x18\x01 \x01(\tR\aprobeId\x128\n" +
+	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x10\n" +
+	"\x03seq\x18\x03 \x01(\x03R\x03seq\x12+\n" +
+	"\x03rtt\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\x03rtt\x12\x18\n" +
+	"\atimeout\x18\x05 \x01(\bR\atimeout2\xde\x03\n" +
+	"\x0eControlService\x12]\n" +
+	"\n" +
+	"ListProbes\x12&.pingheat.control.v1.ListProbesRequest\x1a'.pingheat.control.v1.ListProbesResponse\x12]\n" +
+	"\n" +
+	"StartProbe\x12&.pingheat.control.v1.StartProbeRequest\x1a'.pingheat.control.v1.StartProbeResponse\x12Z\n" +
+	"\tStopProbe\x12%.pingheat.control.v1.StopProbeRequest\x1a&.pingheat.control.v1.StopProbeResponse\x12W\n" +
+	"\bGetStats\x12$.pingheat.control.v1.GetStatsRequest\x1a%.pingheat.control.v1.GetStatsResponse\x12Y\n" +
+	"\rStreamSamples\x12).pingheat.control.v1.StreamSamplesRequest\x1a\x1b.pingheat.control.v1.Sample0\x01B6Z4github.com/pbv7/pingheat/pkg/api/controlv1;controlv1b\x06proto3"
+
+var (
+	file_pkg_api_controlv1_control_proto_rawDescOnce sync.Once
+	file_pkg_api_controlv1_control_proto_rawDescData []byte
+)
+
+func file_pkg_api_controlv1_control_proto_rawDescGZIP() []byte {
+	file_pkg_api_controlv1_control_proto_rawDescOnce.Do(func() {
+		file_pkg_api_controlv1_control_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pkg_api_controlv1_control_proto_rawDesc), len(file_pkg_api_controlv1_control_proto_rawDesc)))
+	})
+	return file_pkg_api_controlv1_control_proto_rawDescData
+}
+
+var file_pkg_api_controlv1_control_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_pkg_api_controlv1_control_proto_goTypes = []any{
+	(*Probe)(nil),                 // 0: pingheat.control.v1.Probe
+	(*ListProbesRequest)(nil),     // 1: pingheat.control.v1.ListProbesRequest
+	(*ListProbesResponse)(nil),    // 2: pingheat.control.v1.ListProbesResponse
+	(*StartProbeRequest)(nil),     // 3: pingheat.control.v1.StartProbeRequest
+	(*StartProbeResponse)(nil),    // 4: pingheat.control.v1.StartProbeResponse
+	(*StopProbeRequest)(nil),      // 5: pingheat.control.v1.StopProbeRequest
+	(*StopProbeResponse)(nil),     // 6: pingheat.control.v1.StopProbeResponse
+	(*GetStatsRequest)(nil),       // 7: pingheat.control.v1.GetStatsRequest
+	(*GetStatsResponse)(nil),      // 8: pingheat.control.v1.GetStatsResponse
+	(*Stats)(nil),                 // 9: pingheat.control.v1.Stats
+	(*StreamSamplesRequest)(nil),  // 10: pingheat.control.v1.StreamSamplesRequest
+	(*Sample)(nil),                // 11: pingheat.control.v1.Sample
+	(*durationpb.Duration)(nil),   // 12: google.protobuf.Duration
+	(*timestamppb.Timestamp)(nil), // 13: google.protobuf.Timestamp
+}
+var file_pkg_api_controlv1_control_proto_depIdxs = []int32{
+	12, // 0: pingheat.control.v1.Probe.interval:type_name -> google.protobuf.Duration
+	13, // 1: pingheat.control.v1.Probe.started_at:type_name -> google.protobuf.Timestamp
+	0,  // 2: pingheat.control.v1.ListProbesResponse.probes:type_name -> pingheat.control.v1.Probe
+	12, // 3: pingheat.control.v1.StartProbeRequest.interval:type_name -> google.protobuf.Duration
+	0,  // 4: pingheat.control.v1.StartProbeResponse.probe:type_name -> pingheat.control.v1.Probe
+	9,  // 5: pingheat.control.v1.GetStatsResponse.stats:type_name -> pingheat.control.v1.Stats
+	13, // 6: pingheat.control.v1.Sample.timestamp:type_name -> google.protobuf.Timestamp
+	12, // 7: pingheat.control.v1.Sample.rtt:type_name -> google.protobuf.Duration
+	1,  // 8: pingheat.control.v1.ControlService.ListProbes:input_type -> pingheat.control.v1.ListProbesRequest
+	3,  // 9: pingheat.control.v1.ControlService.StartProbe:input_type -> pingheat.control.v1.StartProbeRequest
+	5,  // 10: pingheat.control.v1.ControlService.StopProbe:input_type -> pingheat.control.v1.StopProbeRequest
+	7,  // 11: pingheat.control.v1.ControlService.GetStats:input_type -> pingheat.control.v1.GetStatsRequest
+	10, // 12: pingheat.control.v1.ControlService.StreamSamples:input_type -> pingheat.control.v1.StreamSamplesRequest
+	2,  // 13: pingheat.control.v1.ControlService.ListProbes:output_type -> pingheat.control.v1.ListProbesResponse
+	4,  // 14: pingheat.control.v1.ControlService.StartProbe:output_type -> pingheat.control.v1.StartProbeResponse
+	6,  // 15: pingheat.control.v1.ControlService.StopProbe:output_type -> pingheat.control.v1.StopProbeResponse
+	8,  // 16: pingheat.control.v1.ControlService.GetStats:output_type -> pingheat.control.v1.GetStatsResponse
+	11, // 17: pingheat.control.v1.ControlService.StreamSamples:output_type -> pingheat.control.v1.Sample
+	13, // [13:18] is the sub-list for method output_type
+	8,  // [8:13] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_pkg_api_controlv1_control_proto_init() }
+func file_pkg_api_controlv1_control_proto_init() {
+	if File_pkg_api_controlv1_control_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_api_controlv1_control_proto_rawDesc), len(file_pkg_api_controlv1_control_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkg_api_controlv1_control_proto_goTypes,
+		DependencyIndexes: file_pkg_api_controlv1_control_proto_depIdxs,
+		MessageInfos:      file_pkg_api_controlv1_control_proto_msgTypes,
+	}.Build()
+	File_pkg_api_controlv1_control_proto = out.File
+	file_pkg_api_controlv1_control_proto_goTypes = nil
+	file_pkg_api_controlv1_control_proto_depIdxs = nil
+}
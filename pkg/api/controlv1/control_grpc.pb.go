@@ -0,0 +1,301 @@
+// Control API for a long-running pingheat instance, enabled with -grpc.
+//
+// Regenerate the Go code with `make proto`.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: pkg/api/controlv1/control.proto
+
+package controlv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ControlService_ListProbes_FullMethodName    = "/pingheat.control.v1.ControlService/ListProbes"
+	ControlService_StartProbe_FullMethodName    = "/pingheat.control.v1.ControlService/StartProbe"
+	ControlService_StopProbe_FullMethodName     = "/pingheat.control.v1.ControlService/StopProbe"
+	ControlService_GetStats_FullMethodName      = "/pingheat.control.v1.ControlService/GetStats"
+	ControlService_StreamSamples_FullMethodName = "/pingheat.control.v1.ControlService/StreamSamples"
+)
+
+// ControlServiceClient is the client API for ControlService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ControlService manages probes and exposes their statistics and samples.
+//
+// The probe started from the command line is the primary probe (id "main").
+// It can be queried and streamed like any other probe but not stopped.
+type ControlServiceClient interface {
+	// ListProbes returns every running probe.
+	ListProbes(ctx context.Context, in *ListProbesRequest, opts ...grpc.CallOption) (*ListProbesResponse, error)
+	// StartProbe starts pinging an additional target.
+	StartProbe(ctx context.Context, in *StartProbeRequest, opts ...grpc.CallOption) (*StartProbeResponse, error)
+	// StopProbe stops a probe started with StartProbe.
+	StopProbe(ctx context.Context, in *StopProbeRequest, opts ...grpc.CallOption) (*StopProbeResponse, error)
+	// GetStats returns the current statistics of a probe.
+	GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error)
+	// StreamSamples streams every new sample of a probe until cancelled.
+	StreamSamples(ctx context.Context, in *StreamSamplesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Sample], error)
+}
+
+type controlServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewControlServiceClient(cc grpc.ClientConnInterface) ControlServiceClient {
+	return &controlServiceClient{cc}
+}
+
+func (c *controlServiceClient) ListProbes(ctx context.Context, in *ListProbesRequest, opts ...grpc.CallOption) (*ListProbesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListProbesResponse)
+	err := c.cc.Invoke(ctx, ControlService_ListProbes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) StartProbe(ctx context.Context, in *StartProbeRequest, opts ...grpc.CallOption) (*StartProbeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StartProbeResponse)
+	err := c.cc.Invoke(ctx, ControlService_StartProbe_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) StopProbe(ctx context.Context, in *StopProbeRequest, opts ...grpc.CallOption) (*StopProbeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StopProbeResponse)
+	err := c.cc.Invoke(ctx, ControlService_StopProbe_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetStatsResponse)
+	err := c.cc.Invoke(ctx, ControlService_GetStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlServiceClient) StreamSamples(ctx context.Context, in *StreamSamplesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Sample], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ControlService_ServiceDesc.Streams[0], ControlService_StreamSamples_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamSamplesRequest, Sample]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ControlService_StreamSamplesClient = grpc.ServerStreamingClient[Sample]
+
+// ControlServiceServer is the server API for ControlService service.
+// All implementations must embed UnimplementedControlServiceServer
+// for forward compatibility.
+//
+// ControlService manages probes and exposes their statistics and samples.
+//
+// The probe started from the command line is the primary probe (id "main").
+// It can be queried and streamed like any other probe but not stopped.
+type ControlServiceServer interface {
+	// ListProbes returns every running probe.
+	ListProbes(context.Context, *ListProbesRequest) (*ListProbesResponse, error)
+	// StartProbe starts pinging an additional target.
+	StartProbe(context.Context, *StartProbeRequest) (*StartProbeResponse, error)
+	// StopProbe stops a probe started with StartProbe.
+	StopProbe(context.Context, *StopProbeRequest) (*StopProbeResponse, error)
+	// GetStats returns the current statistics of a probe.
+	GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error)
+	// StreamSamples streams every new sample of a probe until cancelled.
+	StreamSamples(*StreamSamplesRequest, grpc.ServerStreamingServer[Sample]) error
+	mustEmbedUnimplementedControlServiceServer()
+}
+
+// UnimplementedControlServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedControlServiceServer struct{}
+
+func (UnimplementedControlServiceServer) ListProbes(context.Context, *ListProbesRequest) (*ListProbesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListProbes not implemented")
+}
+func (UnimplementedControlServiceServer) StartProbe(context.Context, *StartProbeRequest) (*StartProbeResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method StartProbe not implemented")
+}
+func (UnimplementedControlServiceServer) StopProbe(context.Context, *StopProbeRequest) (*StopProbeResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method StopProbe not implemented")
+}
+func (UnimplementedControlServiceServer) GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetStats not implemented")
+}
+func (UnimplementedControlServiceServer) StreamSamples(*StreamSamplesRequest, grpc.ServerStreamingServer[Sample]) error {
+	return status.Error(codes.Unimplemented, "method StreamSamples not implemented")
+}
+func (UnimplementedControlServiceServer) mustEmbedUnimplementedControlServiceServer() {}
+func (UnimplementedControlServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeControlServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ControlServiceServer will
+// result in compilation errors.
+type UnsafeControlServiceServer interface {
+	mustEmbedUnimplementedControlServiceServer()
+}
+
+func RegisterControlServiceServer(s grpc.ServiceRegistrar, srv ControlServiceServer) {
+	// If the following call panics, it indicates UnimplementedControlServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ControlService_ServiceDesc, srv)
+}
+
+func _ControlService_ListProbes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListProbesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).ListProbes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_ListProbes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).ListProbes(ctx, req.(*ListProbesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_StartProbe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartProbeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).StartProbe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_StartProbe_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).StartProbe(ctx, req.(*StartProbeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_StopProbe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StopProbeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).StopProbe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_StopProbe_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).StopProbe(ctx, req.(*StopProbeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlService_GetStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).GetStats(ctx, req.(*GetStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlService_StreamSamples_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamSamplesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ControlServiceServer).StreamSamples(m, &grpc.GenericServerStream[StreamSamplesRequest, Sample]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ControlService_StreamSamplesServer = grpc.ServerStreamingServer[Sample]
+
+// ControlService_ServiceDesc is the grpc.ServiceDesc for ControlService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ControlService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pingheat.control.v1.ControlService",
+	HandlerType: (*ControlServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListProbes",
+			Handler:    _ControlService_ListProbes_Handler,
+		},
+		{
+			MethodName: "StartProbe",
+			Handler:    _ControlService_StartProbe_Handler,
+		},
+		{
+			MethodName: "StopProbe",
+			Handler:    _ControlService_StopProbe_Handler,
+		},
+		{
+			MethodName: "GetStats",
+			Handler:    _ControlService_GetStats_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamSamples",
+			Handler:       _ControlService_StreamSamples_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pkg/api/controlv1/control.proto",
+}
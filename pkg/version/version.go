@@ -1,11 +1,73 @@
 package version
 
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Version, Commit and BuildTime are set with -ldflags -X by release builds.
+// Otherwise they are derived from the module and VCS information embedded
+// by the Go toolchain (e.g. after go install), where available.
 var (
 	Version   = "dev"
 	Commit    = "unknown"
 	BuildTime = "unknown"
 )
 
+func init() {
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		applyBuildInfo(bi)
+	}
+}
+
+// applyBuildInfo fills in the fields not set by ldflags from embedded build
+// information.
+func applyBuildInfo(bi *debug.BuildInfo) {
+	if Version == "dev" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		Version = bi.Main.Version
+	}
+
+	var revision, modified string
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value
+		case "vcs.time":
+			if BuildTime == "unknown" {
+				BuildTime = s.Value
+			}
+		}
+	}
+	if Commit == "unknown" && revision != "" {
+		Commit = revision[:min(len(revision), 7)]
+		if modified == "true" {
+			Commit += "-dirty"
+		}
+	}
+}
+
 func Info() string {
 	return Version + " (" + Commit + ") built at " + BuildTime
 }
+
+// BuildInfo describes the running binary, as printed by -version=json.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+// Get returns the build information of the running binary.
+func Get() BuildInfo {
+	return BuildInfo{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+}
@@ -1,6 +1,9 @@
 package version
 
-import "testing"
+import (
+	"runtime/debug"
+	"testing"
+)
 
 func TestInfo(t *testing.T) {
 	Version = "v1.2.3"
@@ -13,3 +16,31 @@ func TestInfo(t *testing.T) {
 		t.Fatalf("Info() = %q, want %q", got, want)
 	}
 }
+
+func TestApplyBuildInfo(t *testing.T) {
+	settings := []debug.BuildSetting{
+		{Key: "vcs.revision", Value: "0123456789abcdef"},
+		{Key: "vcs.time", Value: "2024-05-01T12:00:00Z"},
+		{Key: "vcs.modified", Value: "true"},
+	}
+
+	tests := []struct {
+		name                              string
+		version, commit                   string
+		main                              string
+		wantVersion, wantCommit, wantTime string
+	}{
+		{"defaults", "dev", "unknown", "v1.4.0", "v1.4.0", "0123456-dirty", "2024-05-01T12:00:00Z"},
+		{"devel build", "dev", "unknown", "(devel)", "dev", "0123456-dirty", "2024-05-01T12:00:00Z"},
+		{"ldflags win", "v2.0.0", "feedbee", "v1.4.0", "v2.0.0", "feedbee", "2024-05-01T12:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Version, Commit, BuildTime = tt.version, tt.commit, "unknown"
+			applyBuildInfo(&debug.BuildInfo{Main: debug.Module{Version: tt.main}, Settings: settings})
+			if Version != tt.wantVersion || Commit != tt.wantCommit || BuildTime != tt.wantTime {
+				t.Fatalf("got %q %q %q, want %q %q %q", Version, Commit, BuildTime, tt.wantVersion, tt.wantCommit, tt.wantTime)
+			}
+		})
+	}
+}